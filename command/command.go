@@ -2,11 +2,17 @@ package command
 
 import (
 	"github.com/giantswarm/microerror"
+	microflag "github.com/giantswarm/microkit/flag"
 	"github.com/giantswarm/micrologger"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 
+	"github.com/giantswarm/route53-manager/command/export"
+	"github.com/giantswarm/route53-manager/command/gc"
 	"github.com/giantswarm/route53-manager/command/sync"
+	"github.com/giantswarm/route53-manager/command/verify"
 	"github.com/giantswarm/route53-manager/flag"
+	"github.com/giantswarm/route53-manager/pkg/logformat"
 )
 
 var (
@@ -16,6 +22,11 @@ var (
 // Config represents the configuration used to create a new root command.
 type Config struct {
 	Logger micrologger.Logger
+	// LogWriter is the writer of Logger. Its format is set from the flags
+	// and config files before any sub command runs. It is optional.
+	LogWriter *logformat.Writer
+
+	Viper *viper.Viper
 
 	Description string
 	GitCommit   string
@@ -27,21 +38,61 @@ type Config struct {
 func New(config Config) (*Command, error) {
 	var err error
 
+	if config.Viper == nil {
+		config.Viper = viper.New()
+	}
+
 	newCommand := &Command{
+		logWriter: config.LogWriter,
+
 		cobraCommand: nil,
+
+		viper: config.Viper,
 	}
 
 	newCommand.cobraCommand = &cobra.Command{
-		Use:   config.Name,
-		Short: config.Description,
-		Long:  config.Description,
-		Run:   newCommand.Execute,
+		Use:               config.Name,
+		Short:             config.Description,
+		Long:              config.Description,
+		PersistentPreRunE: newCommand.PersistentPreRunE,
+		Run:               newCommand.Execute,
+		Args:              validateArgs,
+		// Errors of the sub commands are logged and mapped to exit codes
+		// by main.
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	var exportCommand *export.Command
+	{
+		c := export.Config{
+			Logger: config.Logger,
+		}
+
+		exportCommand, err = export.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var gcCommand *gc.Command
+	{
+		c := gc.Config{
+			Logger: config.Logger,
+		}
+
+		gcCommand, err = gc.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
 	}
 
 	var syncCommand *sync.Command
 	{
 		c := sync.Config{
 			Logger: config.Logger,
+
+			GitCommit: config.GitCommit,
 		}
 
 		syncCommand, err = sync.New(c)
@@ -50,24 +101,84 @@ func New(config Config) (*Command, error) {
 		}
 	}
 
+	var verifyCommand *verify.Command
+	{
+		c := verify.Config{
+			Logger: config.Logger,
+		}
+
+		verifyCommand, err = verify.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	// Flag errors of the root and all sub commands are usage errors.
+	newCommand.cobraCommand.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return microerror.Maskf(usageError, "%s", err)
+	})
+
+	newCommand.CobraCommand().AddCommand(exportCommand.CobraCommand())
+	newCommand.CobraCommand().AddCommand(gcCommand.CobraCommand())
 	newCommand.CobraCommand().AddCommand(syncCommand.CobraCommand())
+	newCommand.CobraCommand().AddCommand(verifyCommand.CobraCommand())
 
 	// Add config dirs and files so flags can be parsed from a config map.
 	newCommand.cobraCommand.PersistentFlags().StringSlice(f.Config.Dirs, []string{"."}, "List of config file directories.")
 	newCommand.cobraCommand.PersistentFlags().StringSlice(f.Config.Files, []string{"config"}, "List of the config file names. All viper supported extensions can be used.")
 
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Log.Format, logformat.FormatJSON, "Format of the log lines, either json or text.")
+
 	return newCommand, nil
 }
 
 type Command struct {
+	// Dependencies.
+	logWriter *logformat.Writer
+
 	// Internals.
 	cobraCommand *cobra.Command
+
+	viper *viper.Viper
 }
 
 func (c *Command) CobraCommand() *cobra.Command {
 	return c.cobraCommand
 }
 
+// validateArgs rejects arguments of the root command, which are unknown sub
+// commands.
+func validateArgs(cmd *cobra.Command, args []string) error {
+	if len(args) > 0 {
+		return microerror.Maskf(usageError, "unknown command %#q for %#q", args[0], cmd.Name())
+	}
+
+	return nil
+}
+
+// PersistentPreRunE sets the format of the log lines from the flags and
+// config files before the command runs. Lines logged before, e.g. for flag
+// errors, are JSON.
+func (c *Command) PersistentPreRunE(cmd *cobra.Command, args []string) error {
+	if c.logWriter == nil {
+		return nil
+	}
+
+	microflag.Parse(c.viper, cmd.Flags())
+
+	err := microflag.Merge(c.viper, cmd.Flags(), c.viper.GetStringSlice(f.Config.Dirs), c.viper.GetStringSlice(f.Config.Files))
+	if err != nil {
+		return microerror.Maskf(usageError, "merging flags: %s", err)
+	}
+
+	err = c.logWriter.SetFormat(c.viper.GetString(f.Service.Log.Format))
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
 func (c *Command) Execute(cmd *cobra.Command, args []string) {
 	cmd.HelpFunc()(cmd, nil)
 }
@@ -0,0 +1,15 @@
+package command
+
+import (
+	"github.com/giantswarm/microerror"
+)
+
+var usageError = &microerror.Error{
+	Kind: "usageError",
+}
+
+// IsUsage asserts usageError, returned for unknown commands and for flags
+// which can not be parsed.
+func IsUsage(err error) bool {
+	return microerror.Cause(err) == usageError
+}
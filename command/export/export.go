@@ -0,0 +1,200 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	microflag "github.com/giantswarm/microkit/flag"
+	"github.com/giantswarm/micrologger"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/giantswarm/route53-manager/flag"
+	"github.com/giantswarm/route53-manager/pkg/client"
+	"github.com/giantswarm/route53-manager/pkg/recordset"
+	"github.com/giantswarm/route53-manager/pkg/recordset/plan"
+)
+
+const (
+	// FormatTerraformImport prints one `terraform import` command per target
+	// stack and record set.
+	FormatTerraformImport = "terraform-import"
+)
+
+var (
+	f = flag.New()
+)
+
+type Config struct {
+	Logger micrologger.Logger
+
+	Viper *viper.Viper
+}
+
+func New(config Config) (*Command, error) {
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+	if config.Viper == nil {
+		config.Viper = viper.New()
+	}
+
+	newCommand := &Command{
+		logger: config.Logger,
+
+		cobraCommand: nil,
+
+		viper: config.Viper,
+	}
+
+	newCommand.cobraCommand = &cobra.Command{
+		Use:   "export",
+		Short: "Export the target stacks and their recordsets.",
+		Long:  "Prints the target stacks and the recordsets of their templates in the given format, e.g. as terraform import commands to migrate them to Terraform. Nothing is changed.\n\nroute53-manager keeps updating and deleting the exported target stacks until it is stopped, so stop syncing the exported clusters before running the imports. Deleting a target stack deletes its recordsets, so set DeletionPolicy Retain on the recordsets before the stack is removed when Terraform takes over the recordsets only.",
+		RunE:  newCommand.Execute,
+	}
+
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Export.Format, FormatTerraformImport, "Output format. Only terraform-import is supported.")
+
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Installation.Name, "", "Installation name")
+
+	newCommand.cobraCommand.PersistentFlags().Float64(f.Service.Limits.CloudFormation, 0, "Maximum CloudFormation requests per second per account. Zero disables the limit.")
+
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Recordset.Cluster, "", "ID of the only cluster to export. All clusters are exported when empty.")
+
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Target.AccessKey, "", "Target account access key")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Target.CredentialsFile, "", "Target account shared credentials file, e.g. rendered by a secret manager. Takes precedence over the access keys when set.")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Target.CredentialsProfile, client.DefaultCredentialsProfile, "Profile read from the target account shared credentials file.")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Target.SecretAccessKey, "", "Target account secret access key")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Target.Region, "", "Target account region")
+
+	return newCommand, nil
+}
+
+type Command struct {
+	logger micrologger.Logger
+
+	cobraCommand *cobra.Command
+
+	viper *viper.Viper
+}
+
+func (c *Command) CobraCommand() *cobra.Command {
+	return c.cobraCommand
+}
+
+func (c *Command) Execute(cmd *cobra.Command, args []string) error {
+	microflag.Parse(c.viper, cmd.Flags())
+
+	err := microflag.Merge(c.viper, cmd.Flags(), c.viper.GetStringSlice(f.Config.Dirs), c.viper.GetStringSlice(f.Config.Files))
+	if err != nil {
+		return microerror.Maskf(invalidConfigError, "merging flags: %s", err)
+	}
+
+	err = c.execute(cmd.OutOrStdout())
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (c *Command) execute(w io.Writer) error {
+	format := c.viper.GetString(f.Service.Export.Format)
+	if format != FormatTerraformImport {
+		return microerror.Maskf(invalidConfigError, "format must be %#q, got %#q", FormatTerraformImport, format)
+	}
+
+	targetClientConfig := &client.Config{
+		AccessKeyID:     c.viper.GetString(f.Service.Target.AccessKey),
+		AccessKeySecret: c.viper.GetString(f.Service.Target.SecretAccessKey),
+		Region:          c.viper.GetString(f.Service.Target.Region),
+
+		CredentialsFile:    c.viper.GetString(f.Service.Target.CredentialsFile),
+		CredentialsProfile: c.viper.GetString(f.Service.Target.CredentialsProfile),
+
+		Limits: client.ServiceLimits{
+			CloudFormation: c.viper.GetFloat64(f.Service.Limits.CloudFormation),
+		},
+	}
+
+	targetClient, err := client.NewClients(targetClientConfig)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	cfg := recordset.VerifierConfig{
+		Logger:       c.logger,
+		Installation: c.viper.GetString(f.Service.Installation.Name),
+		TargetClient: targetClient,
+
+		Cluster: c.viper.GetString(f.Service.Recordset.Cluster),
+	}
+
+	v, err := recordset.NewVerifier(cfg)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	records, err := v.ManagedRecords()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = printTerraformImports(w, records)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// printTerraformImports writes a `terraform import` command for every target
+// stack followed by one for each of its record sets to w, e.g.
+//
+//	terraform import aws_cloudformation_stack.cluster_foo 'cluster-foo-guest-recordsets'
+//	terraform import aws_route53_record.cluster_foo_apiDNSRecord 'Z123_api.foo.example.com_CNAME'
+//	terraform import aws_route53_record.cluster_foo_apiDNSRecord 'Z123_api.foo.example.com_CNAME_gauss'
+//
+// The resources are named after the cluster ID and the CloudFormation
+// logical ID of the record set. Record sets with a routing policy, e.g.
+// weighted ones, have their set identifier appended to the import ID.
+func printTerraformImports(w io.Writer, records []recordset.ManagedRecord) error {
+	stackName := ""
+	for _, r := range records {
+		name := terraformName(r.StackName)
+		if r.StackName != stackName {
+			stackName = r.StackName
+
+			_, err := fmt.Fprintf(w, "terraform import aws_cloudformation_stack.%s '%s'\n", name, r.StackName)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+
+		id := fmt.Sprintf("%s_%s_%s", r.HostedZoneID, strings.TrimSuffix(r.Name, "."), r.Type)
+		if r.SetIdentifier != "" {
+			id += "_" + r.SetIdentifier
+		}
+		_, err := fmt.Fprintf(w, "terraform import aws_route53_record.%s_%s '%s'\n", name, r.ResourceName, id)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+// terraformName returns the Terraform resource name of the target stack,
+// e.g. `cluster_foo`. Cluster IDs may start with a digit, which Terraform
+// does not allow, so they are prefixed.
+func terraformName(stackName string) string {
+	clusterID, err := plan.ClusterID(stackName)
+	if err != nil {
+		return strings.Replace(stackName, "-", "_", -1)
+	}
+
+	return "cluster_" + clusterID
+}
@@ -0,0 +1,62 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/giantswarm/route53-manager/pkg/recordset"
+)
+
+func TestPrintTerraformImports(t *testing.T) {
+	records := []recordset.ManagedRecord{
+		{
+			StackName:    "cluster-foo-guest-recordsets",
+			ResourceName: "apiDNSRecord",
+			HostedZoneID: "Z123",
+			Name:         "api.foo.example.com",
+			Type:         "CNAME",
+			Values:       []string{"a.elb"},
+		},
+		{
+			StackName:    "cluster-foo-guest-recordsets",
+			ResourceName: "ingressWildcardDNSRecord",
+			HostedZoneID: "Z123",
+			Name:         "*.foo.example.com.",
+			Type:         "CNAME",
+			Values:       []string{"ingress.foo.example.com"},
+		},
+		{
+			StackName:     "cluster-foo-guest-recordsets",
+			ResourceName:  "apiWeightedDNSRecord",
+			HostedZoneID:  "Z123",
+			Name:          "api.foo.example.com",
+			Type:          "CNAME",
+			SetIdentifier: "gauss",
+			Values:        []string{"a.elb"},
+		},
+		{
+			StackName:    "cluster-1bar-guest-recordsets",
+			ResourceName: "EtcdEniDNSRecordSet1",
+			HostedZoneID: "Z456",
+			Name:         "etcd1.1bar.example.com",
+			Type:         "A",
+			Values:       []string{"10.0.0.1"},
+		},
+	}
+
+	var out bytes.Buffer
+	err := printTerraformImports(&out, records)
+	if err != nil {
+		t.Fatalf("printTerraformImports: %v", err)
+	}
+
+	expected := "terraform import aws_cloudformation_stack.cluster_foo 'cluster-foo-guest-recordsets'\n" +
+		"terraform import aws_route53_record.cluster_foo_apiDNSRecord 'Z123_api.foo.example.com_CNAME'\n" +
+		"terraform import aws_route53_record.cluster_foo_ingressWildcardDNSRecord 'Z123_*.foo.example.com_CNAME'\n" +
+		"terraform import aws_route53_record.cluster_foo_apiWeightedDNSRecord 'Z123_api.foo.example.com_CNAME_gauss'\n" +
+		"terraform import aws_cloudformation_stack.cluster_1bar 'cluster-1bar-guest-recordsets'\n" +
+		"terraform import aws_route53_record.cluster_1bar_EtcdEniDNSRecordSet1 'Z456_etcd1.1bar.example.com_A'\n"
+	if out.String() != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, out.String())
+	}
+}
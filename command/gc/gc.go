@@ -0,0 +1,178 @@
+package gc
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/giantswarm/microerror"
+	microflag "github.com/giantswarm/microkit/flag"
+	"github.com/giantswarm/micrologger"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/giantswarm/route53-manager/flag"
+	"github.com/giantswarm/route53-manager/pkg/client"
+	"github.com/giantswarm/route53-manager/pkg/recordset"
+)
+
+var (
+	f = flag.New()
+)
+
+type Config struct {
+	Logger micrologger.Logger
+
+	Viper *viper.Viper
+}
+
+func New(config Config) (*Command, error) {
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+	if config.Viper == nil {
+		config.Viper = viper.New()
+	}
+
+	newCommand := &Command{
+		logger: config.Logger,
+
+		cobraCommand: nil,
+
+		viper: config.Viper,
+	}
+
+	newCommand.cobraCommand = &cobra.Command{
+		Use:   "gc",
+		Short: "Delete target stacks whose creation failed.",
+		Long:  "Finds the target stacks in CREATE_FAILED or ROLLBACK_COMPLETE without any created resource, which can neither be updated nor created again, and deletes them so the next sync run creates them cleanly. Only prints the stacks by default.",
+		RunE:  newCommand.Execute,
+	}
+
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Installation.Name, "", "Installation name")
+
+	newCommand.cobraCommand.PersistentFlags().Float64(f.Service.Limits.CloudFormation, 0, "Maximum CloudFormation requests per second per account. Zero disables the limit.")
+	newCommand.cobraCommand.PersistentFlags().Float64(f.Service.Limits.Route53, 0, "Maximum Route53 requests per second per account. Zero disables the limit.")
+
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Recordset.Cluster, "", "ID of the only cluster whose target stack is checked. All target stacks are checked when empty.")
+	newCommand.cobraCommand.PersistentFlags().Bool(f.Service.Recordset.DryRun, true, "Only print the target stacks which would be deleted. Set to false to delete them.")
+	newCommand.cobraCommand.PersistentFlags().Bool(f.Service.Recordset.Lock, false, "Hold the same advisory lock record as sync in the target and etcd Hosted Zones while deleting, and back off when another instance holds it.")
+	newCommand.cobraCommand.PersistentFlags().Duration(f.Service.Recordset.LockLease, recordset.DefaultLockLease, "Duration the Hosted Zone lock is held for before other instances may take it over.")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Recordset.LockOwner, "", "Identity written to the Hosted Zone lock. Defaults to <installation>/<hostname>.")
+
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Target.AccessKey, "", "Target account access key")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Target.CredentialsFile, "", "Target account shared credentials file, e.g. rendered by a secret manager. Takes precedence over the access keys when set.")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Target.CredentialsProfile, client.DefaultCredentialsProfile, "Profile read from the target account shared credentials file.")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Target.SecretAccessKey, "", "Target account secret access key")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Target.Region, "", "Target account region")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Target.HostedZone.Name, "", "Target account Hosted Zone name. Required when locking.")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Target.HostedZone.ID, "", "Target account Hosted Zone ID. Resolved from the Hosted Zone name when empty.")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Target.EtcdHostedZone.Name, "", "Target account Hosted Zone name for etcd records. Defaults to the target Hosted Zone.")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Target.EtcdHostedZone.ID, "", "Target account Hosted Zone ID for etcd records. Defaults to the target Hosted Zone.")
+
+	return newCommand, nil
+}
+
+type Command struct {
+	logger micrologger.Logger
+
+	cobraCommand *cobra.Command
+
+	viper *viper.Viper
+}
+
+func (c *Command) CobraCommand() *cobra.Command {
+	return c.cobraCommand
+}
+
+func (c *Command) Execute(cmd *cobra.Command, args []string) error {
+	microflag.Parse(c.viper, cmd.Flags())
+
+	err := microflag.Merge(c.viper, cmd.Flags(), c.viper.GetStringSlice(f.Config.Dirs), c.viper.GetStringSlice(f.Config.Files))
+	if err != nil {
+		return microerror.Maskf(invalidConfigError, "merging flags: %s", err)
+	}
+
+	err = c.execute(cmd.OutOrStdout())
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (c *Command) execute(w io.Writer) error {
+	targetClientConfig := &client.Config{
+		AccessKeyID:     c.viper.GetString(f.Service.Target.AccessKey),
+		AccessKeySecret: c.viper.GetString(f.Service.Target.SecretAccessKey),
+		Region:          c.viper.GetString(f.Service.Target.Region),
+
+		CredentialsFile:    c.viper.GetString(f.Service.Target.CredentialsFile),
+		CredentialsProfile: c.viper.GetString(f.Service.Target.CredentialsProfile),
+
+		Limits: client.ServiceLimits{
+			CloudFormation: c.viper.GetFloat64(f.Service.Limits.CloudFormation),
+			Route53:        c.viper.GetFloat64(f.Service.Limits.Route53),
+		},
+	}
+
+	targetClient, err := client.NewClients(targetClientConfig)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	dryRun := c.viper.GetBool(f.Service.Recordset.DryRun)
+
+	cfg := recordset.GarbageCollectorConfig{
+		Logger:       c.logger,
+		Installation: c.viper.GetString(f.Service.Installation.Name),
+		TargetClient: targetClient,
+
+		Cluster: c.viper.GetString(f.Service.Recordset.Cluster),
+		DryRun:  dryRun,
+
+		TargetHostedZoneID:   c.viper.GetString(f.Service.Target.HostedZone.ID),
+		TargetHostedZoneName: c.viper.GetString(f.Service.Target.HostedZone.Name),
+		EtcdHostedZoneID:     c.viper.GetString(f.Service.Target.EtcdHostedZone.ID),
+		EtcdHostedZoneName:   c.viper.GetString(f.Service.Target.EtcdHostedZone.Name),
+
+		LockHostedZone: c.viper.GetBool(f.Service.Recordset.Lock),
+		LockOwner:      c.viper.GetString(f.Service.Recordset.LockOwner),
+		LockLease:      c.viper.GetDuration(f.Service.Recordset.LockLease),
+	}
+
+	g, err := recordset.NewGarbageCollector(cfg)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	stackNames, err := g.Collect()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = printStacks(w, stackNames, dryRun)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// printStacks writes one line per collected target stack to w, e.g.
+//
+//	cluster-foo-guest-recordsets deleted
+func printStacks(w io.Writer, stackNames []string, dryRun bool) error {
+	action := "deleted"
+	if dryRun {
+		action = "would be deleted"
+	}
+
+	for _, stackName := range stackNames {
+		_, err := fmt.Fprintf(w, "%s %s\n", stackName, action)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,38 @@
+package gc
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPrintStacks(t *testing.T) {
+	tcs := []struct {
+		name     string
+		dryRun   bool
+		expected string
+	}{
+		{
+			name:     "case 0: dry run",
+			dryRun:   true,
+			expected: "cluster-foo-guest-recordsets would be deleted\ncluster-bar-guest-recordsets would be deleted\n",
+		},
+		{
+			name:     "case 1: deleted",
+			expected: "cluster-foo-guest-recordsets deleted\ncluster-bar-guest-recordsets deleted\n",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := printStacks(&out, []string{"cluster-foo-guest-recordsets", "cluster-bar-guest-recordsets"}, tc.dryRun)
+			if err != nil {
+				t.Fatalf("printStacks: %v", err)
+			}
+
+			if out.String() != tc.expected {
+				t.Errorf("expected\n%s\ngot\n%s", tc.expected, out.String())
+			}
+		})
+	}
+}
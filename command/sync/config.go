@@ -0,0 +1,58 @@
+package sync
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	"github.com/spf13/viper"
+)
+
+const (
+	redactedValue = "[REDACTED]"
+)
+
+var (
+	// secretKeyParts is the list of substrings which mark a configuration key
+	// as secret. Keys are compared in lower case since viper normalizes them.
+	secretKeyParts = []string{
+		"accesskey",
+		"password",
+		"secret",
+		"token",
+	}
+)
+
+// printConfig writes the effective configuration resolved by viper to w, one
+// key per line in alphabetical order. Values of secret keys are redacted.
+func printConfig(w io.Writer, v *viper.Viper) error {
+	keys := v.AllKeys()
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		value := fmt.Sprintf("%v", v.Get(k))
+		if isSecretKey(k) && value != "" {
+			value = redactedValue
+		}
+
+		_, err := fmt.Fprintf(w, "%s: %s\n", k, value)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+func isSecretKey(k string) bool {
+	k = strings.ToLower(k)
+	for _, p := range secretKeyParts {
+		if strings.Contains(k, p) {
+			return true
+		}
+	}
+
+	return false
+}
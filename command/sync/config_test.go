@@ -0,0 +1,55 @@
+package sync
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestPrintConfig_Redacted(t *testing.T) {
+	v := viper.New()
+	v.Set(f.Service.Installation.Name, "gauss")
+	v.Set(f.Service.Source.AccessKey, "source-access-key")
+	v.Set(f.Service.Source.SecretAccessKey, "source-secret-access-key")
+	v.Set(f.Service.Source.Region, "eu-central-1")
+	v.Set(f.Service.Target.AccessKey, "target-access-key")
+	v.Set(f.Service.Target.SecretAccessKey, "target-secret-access-key")
+	v.Set(f.Service.Target.HostedZone.ID, "Z123")
+	v.Set("service.target.sessionToken", "target-session-token")
+
+	var out bytes.Buffer
+	err := printConfig(&out, v)
+	if err != nil {
+		t.Fatalf("printConfig: %v", err)
+	}
+
+	secrets := []string{
+		"source-access-key",
+		"source-secret-access-key",
+		"target-access-key",
+		"target-secret-access-key",
+		"target-session-token",
+	}
+	for _, s := range secrets {
+		if strings.Contains(out.String(), s) {
+			t.Errorf("expected secret %#q to be redacted, got\n%s", s, out.String())
+		}
+	}
+
+	visible := []string{
+		"gauss",
+		"eu-central-1",
+		"Z123",
+	}
+	for _, s := range visible {
+		if !strings.Contains(out.String(), s) {
+			t.Errorf("expected %#q to be printed, got\n%s", s, out.String())
+		}
+	}
+
+	if strings.Count(out.String(), redactedValue) != len(secrets) {
+		t.Errorf("expected %d redacted values, got\n%s", len(secrets), out.String())
+	}
+}
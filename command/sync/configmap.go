@@ -0,0 +1,139 @@
+package sync
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	"github.com/spf13/pflag"
+)
+
+const (
+	// serviceAccountDir is where Kubernetes mounts the service account
+	// credentials into the pod.
+	serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+)
+
+// configMapClient reads ConfigMaps from the Kubernetes API with plain REST
+// calls, so route53-manager does not depend on client-go.
+type configMapClient struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+// newInClusterConfigMapClient returns the configMapClient of the cluster
+// route53-manager runs in, authenticated with the mounted service account.
+func newInClusterConfigMapClient() (*configMapClient, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, microerror.Maskf(invalidConfigError, "not running in a Kubernetes cluster, KUBERNETES_SERVICE_HOST and KUBERNETES_SERVICE_PORT must be set")
+	}
+
+	token, err := ioutil.ReadFile(serviceAccountDir + "/token")
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	ca, err := ioutil.ReadFile(serviceAccountDir + "/ca.crt")
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, microerror.Maskf(invalidConfigError, "service account CA certificate must be PEM encoded")
+	}
+
+	c := &configMapClient{
+		baseURL: "https://" + net.JoinHostPort(host, port),
+		token:   strings.TrimSpace(string(token)),
+		httpClient: &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{RootCAs: pool},
+			},
+		},
+	}
+
+	return c, nil
+}
+
+// Get returns the data of the ConfigMap name in namespace.
+func (c *configMapClient) Get(ctx context.Context, namespace, name string) (map[string]string, error) {
+	u := fmt.Sprintf("%s/api/v1/namespaces/%s/configmaps/%s", c.baseURL, url.PathEscape(namespace), url.PathEscape(name))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, microerror.Maskf(invalidConfigError, "ConfigMap %s/%s could not be read: %s", namespace, name, res.Status)
+	}
+
+	var cm struct {
+		Data map[string]string `json:"data"`
+	}
+	err = json.NewDecoder(res.Body).Decode(&cm)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return cm.Data, nil
+}
+
+// loadConfigMap sets the flags named by the keys of the ConfigMap referenced
+// as <namespace>/<name>, e.g. the key `service.installation.name`. Flags
+// given on the command line take precedence, so their keys are ignored. As
+// the flags are set, the ConfigMap takes precedence over config files.
+func loadConfigMap(ctx context.Context, client *configMapClient, fs *pflag.FlagSet, ref string) error {
+	parts := strings.SplitN(ref, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return microerror.Maskf(invalidConfigError, "ConfigMap %#q must be in the form <namespace>/<name>", ref)
+	}
+
+	data, err := client.Get(ctx, parts[0], parts[1])
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	var keys []string
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		fl := fs.Lookup(k)
+		if fl == nil {
+			return microerror.Maskf(invalidConfigError, "ConfigMap %#q key %#q must be a flag name", ref, k)
+		}
+		if fl.Changed {
+			continue
+		}
+
+		err = fs.Set(k, data[k])
+		if err != nil {
+			return microerror.Maskf(invalidConfigError, "ConfigMap %#q key %#q: %s", ref, k, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func TestLoadConfigMap(t *testing.T) {
+	tcs := []struct {
+		name               string
+		ref                string
+		data               map[string]string
+		args               []string
+		expectedName       string
+		expectedComponents []string
+		expectedErr        bool
+	}{
+		{
+			name: "case 0: values from ConfigMap",
+			ref:  "giantswarm/route53-manager",
+			data: map[string]string{
+				f.Service.Installation.Name:    "gauss",
+				f.Service.Recordset.Components: "konnectivity:-konnectivity,foo:-foo",
+			},
+			expectedName:       "gauss",
+			expectedComponents: []string{"konnectivity:-konnectivity", "foo:-foo"},
+		},
+		{
+			name: "case 1: command line flags take precedence",
+			ref:  "giantswarm/route53-manager",
+			data: map[string]string{
+				f.Service.Installation.Name:    "gauss",
+				f.Service.Recordset.Components: "konnectivity:-konnectivity",
+			},
+			args:               []string{"--" + f.Service.Installation.Name, "ginger"},
+			expectedName:       "ginger",
+			expectedComponents: []string{"konnectivity:-konnectivity"},
+		},
+		{
+			name: "case 2: unknown key",
+			ref:  "giantswarm/route53-manager",
+			data: map[string]string{
+				"service.unknown": "foo",
+			},
+			expectedErr: true,
+		},
+		{
+			name:        "case 3: missing ConfigMap",
+			ref:         "giantswarm/other",
+			expectedErr: true,
+		},
+		{
+			name:        "case 4: invalid reference",
+			ref:         "route53-manager",
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/api/v1/namespaces/giantswarm/configmaps/route53-manager" {
+					http.NotFound(w, r)
+					return
+				}
+				if r.Header.Get("Authorization") != "Bearer token" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": tc.data})
+			}))
+			defer srv.Close()
+			client := &configMapClient{
+				baseURL:    srv.URL,
+				token:      "token",
+				httpClient: srv.Client(),
+			}
+
+			fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+			fs.String(f.Service.Installation.Name, "", "")
+			fs.StringSlice(f.Service.Recordset.Components, nil, "")
+			err := fs.Parse(tc.args)
+			if err != nil {
+				t.Fatalf("fs.Parse: %v", err)
+			}
+
+			err = loadConfigMap(context.Background(), client, fs, tc.ref)
+			if tc.expectedErr {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadConfigMap: %v", err)
+			}
+
+			name, err := fs.GetString(f.Service.Installation.Name)
+			if err != nil {
+				t.Fatalf("fs.GetString: %v", err)
+			}
+			if name != tc.expectedName {
+				t.Errorf("expected installation name %#q, got %#q", tc.expectedName, name)
+			}
+			components, err := fs.GetStringSlice(f.Service.Recordset.Components)
+			if err != nil {
+				t.Fatalf("fs.GetStringSlice: %v", err)
+			}
+			if !reflect.DeepEqual(components, tc.expectedComponents) {
+				t.Errorf("expected components %v, got %v", tc.expectedComponents, components)
+			}
+		})
+	}
+}
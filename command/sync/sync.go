@@ -1,9 +1,14 @@
 package sync
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"io"
+	"io/ioutil"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 
 	"github.com/giantswarm/microerror"
 	microflag "github.com/giantswarm/microkit/flag"
@@ -13,6 +18,8 @@ import (
 
 	"github.com/giantswarm/route53-manager/flag"
 	"github.com/giantswarm/route53-manager/pkg/client"
+	"github.com/giantswarm/route53-manager/pkg/events"
+	"github.com/giantswarm/route53-manager/pkg/metrics"
 	"github.com/giantswarm/route53-manager/pkg/recordset"
 )
 
@@ -23,7 +30,8 @@ var (
 type Config struct {
 	Logger micrologger.Logger
 
-	Viper *viper.Viper
+	GitCommit string
+	Viper     *viper.Viper
 }
 
 func New(config Config) (*Command, error) {
@@ -39,27 +47,144 @@ func New(config Config) (*Command, error) {
 
 		cobraCommand: nil,
 
-		viper: config.Viper,
+		gitCommit: config.GitCommit,
+		viper:     config.Viper,
 	}
 
 	newCommand.cobraCommand = &cobra.Command{
 		Use:   "sync",
 		Short: "Synchronize recordsets between AWS accounts.",
 		Long:  "Creates, deletes and updates recordsets on a target AWS account related to resources on a source AWS account.",
-		Run:   newCommand.Execute,
+		RunE:  newCommand.Execute,
 	}
 
+	newCommand.cobraCommand.PersistentFlags().String(f.Config.ConfigMap, "", "ConfigMap holding flag values by flag name, in the form <namespace>/<name>. It is read from the Kubernetes API with the mounted service account. Flags given on the command line take precedence.")
+	newCommand.cobraCommand.PersistentFlags().Bool(f.Config.Print, false, "Print the effective configuration with secrets redacted and exit.")
+
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Events.QueueURL, "", "URL of an SQS queue in the source account receiving CloudFormation stack events, e.g. from an EventBridge rule or the SNS topic of the source stacks. When set, the command syncs all clusters once and keeps running, syncing the cluster of every source stack completing a transition. It syncs all clusters once and exits when empty. Cannot be combined with --service.recordset.cluster or --service.source.stackNames.")
+
 	newCommand.cobraCommand.PersistentFlags().String(f.Service.Installation.Name, "", "Installation name")
 
+	newCommand.cobraCommand.PersistentFlags().Float64(f.Service.Limits.CloudFormation, 0, "Maximum CloudFormation requests per second per account. Zero disables the limit.")
+	newCommand.cobraCommand.PersistentFlags().Float64(f.Service.Limits.EC2, 0, "Maximum EC2 requests per second per account. Zero disables the limit.")
+	newCommand.cobraCommand.PersistentFlags().Float64(f.Service.Limits.ELB, 0, "Maximum ELB requests per second per account. Zero disables the limit.")
+	newCommand.cobraCommand.PersistentFlags().Float64(f.Service.Limits.Route53, 0, "Maximum Route53 requests per second per account. Zero disables the limit.")
+	newCommand.cobraCommand.PersistentFlags().Int(f.Service.Limits.Concurrency.CloudFormation, 0, "Maximum concurrent CloudFormation requests per account. Zero disables the limit.")
+	newCommand.cobraCommand.PersistentFlags().Int(f.Service.Limits.Concurrency.EC2, 0, "Maximum concurrent EC2 requests per account. Zero disables the limit.")
+	newCommand.cobraCommand.PersistentFlags().Int(f.Service.Limits.Concurrency.ELB, 0, "Maximum concurrent ELB requests per account. Zero disables the limit.")
+	newCommand.cobraCommand.PersistentFlags().Int(f.Service.Limits.Concurrency.Route53, 0, "Maximum concurrent Route53 requests per account. Zero disables the limit.")
+
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Log.AuditFile, "", "Path of a file a JSON line is appended to for every create, update and deletion of a target stack, separate from the regular log. Nothing is written when empty.")
+	newCommand.cobraCommand.PersistentFlags().Bool(f.Service.Log.BufferClusterLogs, false, "Write the log lines of each cluster as a contiguous block once it is processed.")
+	newCommand.cobraCommand.PersistentFlags().Bool(f.Service.Log.Quiet, false, "Suppress per stack debug messages about stacks which are left untouched.")
+
+	newCommand.cobraCommand.PersistentFlags().Bool(f.Service.Metrics.OperationLabel, false, "Label the route53_manager_cluster_reconcile_seconds histogram by operation, i.e. create, update or delete.")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Metrics.TextFile, "", "Path of a file the metrics are written to in the Prometheus text format after each run, e.g. for the node exporter textfile collector.")
+
+	newCommand.cobraCommand.PersistentFlags().Bool(f.Service.Recordset.AdoptExisting, false, "Add the managed-by tag to updated target stacks lacking it, e.g. stacks created out-of-band, to take them under management.")
+	newCommand.cobraCommand.PersistentFlags().Bool(f.Service.Recordset.AliasWildcard, false, "Create the wildcard record as an A alias record of the ingress ELB instead of a CNAME of the ingress record. The ingress ELB must exist for every cluster.")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Recordset.APIRecordType, "CNAME", "Type of the api record, either CNAME of the api ELB or A for an alias record of it. Clusters override it with the giantswarm.io/api-record-type tag of their source stack.")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Recordset.ApplyMode, recordset.ApplyModeCloudFormation, "How the records of a cluster are applied, either cloudformation through its target stack, route53-atomic through one Route53 change batch per hosted zone or route53-direct like route53-atomic without any target stack, e.g. for hosted zones not managed by CloudFormation. The clusters are tracked by their metadata records in route53-direct. The mode applies to all clusters of the target hosted zone, run one instance per mode to mix them.")
+	newCommand.cobraCommand.PersistentFlags().Bool(f.Service.Recordset.AutoExecute, false, "Execute the change sets created for target stack updates right away. They are left for manual execution otherwise.")
+	newCommand.cobraCommand.PersistentFlags().Bool(f.Service.Recordset.BareDomainRecord, false, "Create a record for the cluster domain <cluster>.<zone> pointing at ingress like the wildcard record. It is a CNAME of the ingress record unless the wildcard record is an alias, which is required together with a CAA record.")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Recordset.CAA, "", "Value of the CAA record created for every cluster domain, e.g. '0 issue \"letsencrypt.org\"'. No CAA record is created when empty.")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Recordset.Cluster, "", "ID of the only cluster to sync, e.g. for targeted remediation. Its stacks are looked up by name instead of listing all stacks. All clusters are synced when empty.")
+	newCommand.cobraCommand.PersistentFlags().StringSlice(f.Service.Recordset.Components, nil, "Additional control plane components to create CNAME records for, in the form <name>:<elb-suffix>, e.g. konnectivity:-konnectivity. The ELB must exist for every cluster.")
+	newCommand.cobraCommand.PersistentFlags().Bool(f.Service.Recordset.ConfirmOrphans, false, "Describe the source stacks of an orphan cluster again right before deleting its target stack, and keep the target stack when they reappeared.")
+	newCommand.cobraCommand.PersistentFlags().StringSlice(f.Service.Recordset.DeleteTriggerStatuses, []string{"DELETE_COMPLETE"}, "Source stack statuses treated like a missing source stack, deleting the target stack of the cluster, e.g. DELETE_IN_PROGRESS. Statuses other than DELETE_COMPLETE only count once the source stack has been in them for the minimum stack age.")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Recordset.DeletionOrder, recordset.DeletionOrderRecordsAfterStack, "Order orphan target stacks and their leftover record sets are deleted in, either records-after-stack or records-first.")
+	newCommand.cobraCommand.PersistentFlags().Bool(f.Service.Recordset.DeletionStopOnFailure, false, "Skip the second deletion step of an orphan cluster when the first one failed.")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Recordset.Discovery, recordset.DiscoveryStacks, "How the clusters of the source accounts are discovered, either stacks by their source stacks or tags by the cluster tag of their EC2 instances and load balancers, for installations without per cluster stacks.")
+	newCommand.cobraCommand.PersistentFlags().Duration(f.Service.Recordset.DiscoveryGracePeriod, recordset.DefaultDiscoveryGracePeriod, "Minimum time the cluster of a target stack must be missing from tags discovery before the target stack is deleted.")
+	newCommand.cobraCommand.PersistentFlags().Bool(f.Service.Recordset.DryRun, false, "Print the diff between the current and the rendered template of every target stack to be created or updated instead of applying the changes. Only supported with the cloudformation apply mode.")
+	newCommand.cobraCommand.PersistentFlags().Bool(f.Service.Recordset.EmitPTR, false, "Create a PTR record for the private IP of every etcd ENI in the target reverse Hosted Zone.")
+	newCommand.cobraCommand.PersistentFlags().Bool(f.Service.Recordset.DeleteOnlyOnCleanSync, false, "Keep the target stacks and record sets of clusters without source stack when creating or updating any target stack failed in the same sync run. They are only reported as skipped then.")
+	newCommand.cobraCommand.PersistentFlags().Bool(f.Service.Recordset.EnableOrphanDeletion, true, "Delete target stacks and record sets of clusters without source stack. When disabled they are only reported as skipped.")
+	newCommand.cobraCommand.PersistentFlags().Bool(f.Service.Recordset.ForceMassDelete, false, "Delete the orphan target stacks even when they exceed the maximum number or percentage of deletions per run.")
+	newCommand.cobraCommand.PersistentFlags().Duration(f.Service.Recordset.LeftoverChangeInterval, 0, "Minimum interval between the change batches deleting leftover record sets, to stay below the Route53 change limits in large hosted zones. Zero disables the limit.")
+	newCommand.cobraCommand.PersistentFlags().Bool(f.Service.Recordset.ExplainCleanup, false, "Log for every record set listed by the cleanup of a deleted cluster whether it is below the cluster domain, whether it is managed and whether it is kept or deleted.")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Recordset.LeftoverCheckpointFile, "", "Path of a file the progress of the leftover record set cleanups is persisted to, so an interrupted cleanup of a large hosted zone resumes where it left off. Nothing is persisted when empty.")
+	newCommand.cobraCommand.PersistentFlags().Bool(f.Service.Recordset.Lock, false, "Hold an advisory lock record in every Hosted Zone written to while computing and applying changes, and back off when another instance holds it.")
+	newCommand.cobraCommand.PersistentFlags().Duration(f.Service.Recordset.LockLease, recordset.DefaultLockLease, "Duration the Hosted Zone lock is held for before other instances may take it over.")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Recordset.LockOwner, "", "Identity written to the Hosted Zone lock. Defaults to <installation>/<hostname>.")
+	newCommand.cobraCommand.PersistentFlags().Int(f.Service.Recordset.MaxDeletePercentage, 0, "Maximum percentage of the target stacks deleted as orphans in one run. The deletions are aborted when exceeded, unless forced. Zero disables the limit.")
+	newCommand.cobraCommand.PersistentFlags().Int(f.Service.Recordset.MaxDeletes, 0, "Maximum number of target stacks deleted as orphans in one run. The deletions are aborted when exceeded, unless forced. Zero disables the limit.")
+	newCommand.cobraCommand.PersistentFlags().Int(f.Service.Recordset.MaxTemplateBodySize, recordset.DefaultMaxTemplateBodySize, "Maximum size in bytes of target stack templates passed inline to CloudFormation. Larger templates are uploaded to the target template bucket.")
+	newCommand.cobraCommand.PersistentFlags().Bool(f.Service.Recordset.MetadataRecord, false, "Create a _meta TXT record for every cluster domain holding the installation, the source stack creation time and the route53-manager version.")
+	newCommand.cobraCommand.PersistentFlags().Duration(f.Service.Recordset.MinStackAge, 0, "Minimum time a source stack must have been complete before its recordsets are created or updated, e.g. 5m. Zero disables the check.")
+	newCommand.cobraCommand.PersistentFlags().Bool(f.Service.Recordset.NonLegacyIngress, false, "Create the ingress record for non legacy clusters too. The ingress ELB must exist for every cluster.")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Recordset.PauseTag, recordset.DefaultPauseTag, "Stack tag pausing the sync of a cluster when set to true on its source or target stack.")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Recordset.PlanOutputFile, "", "Path of a file the creates, updates, deletes and skips planned by each run are written to as JSON before any change is applied, e.g. for review pipelines. Nothing is written when empty.")
+	newCommand.cobraCommand.PersistentFlags().Int(f.Service.Recordset.PriorRequestRetries, recordset.DefaultPriorRequestRetries, "Maximum number of retries of a record set change rejected by Route53 because a prior change of the hosted zone is not complete yet.")
+	newCommand.cobraCommand.PersistentFlags().Int(f.Service.Recordset.RecordLimitMargin, 0, "Number of record sets the target Hosted Zones must stay below their limit. No target stacks are created while a Hosted Zone is within the margin, updates and deletions are still applied. Zero disables the check.")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Recordset.RecreateCluster, "", "ID of a cluster whose target stack is deleted together with its leftover record sets and created again, e.g. after a bad manual edit. Only this cluster is synced then.")
+	newCommand.cobraCommand.PersistentFlags().Bool(f.Service.Recordset.SkipUnchangedUpdates, false, "Skip the update of target stacks whose etcd ENI IPs, other records, tags, notification ARNs and stack policy are unchanged. Costs one additional CloudFormation request per updated stack, two with a stack policy.")
+	newCommand.cobraCommand.PersistentFlags().StringSlice(f.Service.Recordset.StackOutputKeys, nil, "Source stack output keys holding the ELB DNS name of a component, in the form <name>:<output-key>, e.g. api:APIELBDNSName. Overrides the default output key of the component.")
+	newCommand.cobraCommand.PersistentFlags().Bool(f.Service.Recordset.IgnoreUnparseableStacks, false, "Do not log the stacks whose cluster ID cannot be extracted from their name. They are still counted in the route53_manager_skipped_total metric. Each of them is logged once per run otherwise.")
+	newCommand.cobraCommand.PersistentFlags().Bool(f.Service.Recordset.StrictClusterNames, false, "Fail the run before changing anything when the name of a source or target stack cannot be derived from the cluster ID extracted from it, e.g. for hyphenated cluster IDs. Such stacks are only logged otherwise.")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Recordset.SummaryHistoryFile, "", "Path of a file the clusters created, updated and deleted by a run are persisted in. Each run logs the clusters which newly appeared in or dropped out of these since the previous run. Nothing is persisted when empty.")
+	newCommand.cobraCommand.PersistentFlags().Int(f.Service.Recordset.SyncRetries, 0, "Maximum number of retries with backoff of a whole sync run failing with a throttling or server error, e.g. a throttled ListStacks call on startup. Changes applied by the failed attempt are not rolled back, and no retry is made past the sync timeout. Credential and configuration errors are not retried.")
+	newCommand.cobraCommand.PersistentFlags().Int(f.Service.Recordset.ConsistencyRetries, 0, "Maximum number of retries with backoff listing the target stacks after applying the changes, until created target stacks are listed and deleted ones are gone, so the next sync run does not create or delete them again. Disabled when 0.")
+	newCommand.cobraCommand.PersistentFlags().Duration(f.Service.Recordset.SyncTimeout, 0, "Maximum duration of a whole sync run, e.g. 10m. Once exceeded, AWS calls in flight are cancelled and the run stops. Zero disables the deadline.")
+	newCommand.cobraCommand.PersistentFlags().Bool(f.Service.Recordset.TagOnlyUpdates, false, "Update only the tags of target stacks whose template is unchanged, reusing the previous template. Costs one additional CloudFormation request per updated stack.")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Recordset.TemplateFormat, recordset.TemplateFormatYAML, "Format of the rendered CloudFormation templates, either yaml or json.")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Recordset.ChangeReason, "", "Reason of the sync run, e.g. the incident of a targeted single cluster sync, added as stack tag to the created and updated target stacks and to the comments of the record set changes.")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Recordset.TemplateVersion, "", "Version embedded into the rendered CloudFormation templates and added as stack tag. Bumping it updates every target stack. It does not pin the rendering of the templates.")
+	newCommand.cobraCommand.PersistentFlags().Bool(f.Service.Recordset.IgnoreVersionTag, false, "Ignore the route53-manager version tag when comparing target stacks with --service.recordset.skipUnchangedUpdates, so a new route53-manager version alone does not update target stacks whose templates are unchanged.")
+	newCommand.cobraCommand.PersistentFlags().Bool(f.Service.Recordset.UseChangeSets, false, "Update target stacks through CloudFormation change sets, so the changes can be reviewed. The stack policy is not applied through change sets.")
+	newCommand.cobraCommand.PersistentFlags().Bool(f.Service.Recordset.UseStackOutputs, false, "Read the ELB DNS names of the components from the source stack outputs, falling back to the ELB lookup by name when an output is absent.")
+	newCommand.cobraCommand.PersistentFlags().Bool(f.Service.Recordset.WaitForSync, false, "Wait for record set changes applied directly through Route53 to be in sync.")
+	newCommand.cobraCommand.PersistentFlags().Duration(f.Service.Recordset.WaitForSyncTimeout, recordset.DefaultWaitForSyncTimeout, "Maximum time to wait for a record set change to be in sync.")
+
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Parent.AccessKey, "", "Parent account access key")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Parent.CredentialsFile, "", "Parent account shared credentials file, e.g. rendered by a secret manager. Takes precedence over the access keys when set.")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Parent.CredentialsProfile, client.DefaultCredentialsProfile, "Profile read from the parent account shared credentials file.")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Parent.SecretAccessKey, "", "Parent account secret access key")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Parent.Region, "", "Parent account region")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Parent.HostedZone.Name, "", "Parent account Hosted Zone name. Delegation records of clusters outside of it are not created when set.")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Parent.HostedZone.ID, "", "Parent account Hosted Zone ID. The cluster NS delegation record is only ensured when set.")
+
 	newCommand.cobraCommand.PersistentFlags().String(f.Service.Source.AccessKey, "", "Source account access key")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Source.CredentialsFile, "", "Source account shared credentials file, e.g. rendered by a secret manager. Takes precedence over the access keys when set.")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Source.CredentialsProfile, client.DefaultCredentialsProfile, "Profile read from the source account shared credentials file.")
 	newCommand.cobraCommand.PersistentFlags().String(f.Service.Source.SecretAccessKey, "", "Source account secret access key")
 	newCommand.cobraCommand.PersistentFlags().String(f.Service.Source.Region, "", "Source account region")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Source.ENIClusterTag, recordset.DefaultENIClusterTag, "Tag key carrying the cluster ID the network interfaces of the etcd records are filtered by.")
+	newCommand.cobraCommand.PersistentFlags().Int(f.Service.Source.MinEtcdENIs, recordset.DefaultMinEtcdENIs, "Minimum number of etcd network interfaces of a non legacy cluster. Clusters with fewer network interfaces are skipped with reason `etcd_enis_out_of_bounds`, keeping their current records.")
+	newCommand.cobraCommand.PersistentFlags().Int(f.Service.Source.MaxEtcdENIs, recordset.DefaultMaxEtcdENIs, "Maximum number of etcd network interfaces of a non legacy cluster. Clusters with more network interfaces are skipped with reason `etcd_enis_out_of_bounds`, keeping their current records.")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Source.ENIOrderTag, recordset.DefaultENIOrderTag, "Tag key the network interfaces of the etcd records are ordered by, assigning etcd1, etcd2 and etcd3 in order, e.g. giantswarm.io/etcd-index. Integer values are ordered numerically.")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Source.ELBLookup, recordset.ELBLookupName, "How the ELBs of the components are looked up, either name, matching <cluster-id><elb-suffix>, or tags, matching the cluster tag and the role tag holding the component name, e.g. for ELBs with generated names.")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Source.ELBRoleTag, recordset.DefaultELBRoleTag, "Tag key carrying the component name, e.g. api, the ELBs are matched by when looked up by tags.")
+	newCommand.cobraCommand.PersistentFlags().Bool(f.Service.Source.RequireHealthyELB, false, "Leave out the record of a component while its ELB has no healthy instance or target, e.g. during cluster creation. The records of the other components are still applied. ELB DNS names read from stack outputs are not checked.")
+	newCommand.cobraCommand.PersistentFlags().StringSlice(f.Service.Source.AdditionalAccessKeys, nil, "Credentials of additional source accounts whose clusters are synced too, in the form <access-key>:<secret-access-key>[:<region>]. The region defaults to the source account region.")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Source.Organization.Role, "", "ARN of a role of the management account of an AWS organization allowed to list its accounts. When set, every active member account is synced as additional source account, assuming the member role with the source account credentials.")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Source.Organization.MemberRole, "", "Name of the role assumed in every member account of the organization, e.g. route53-manager-readonly. Required when the organization role is set.")
+	newCommand.cobraCommand.PersistentFlags().StringSlice(f.Service.Source.Organization.ExcludedAccounts, nil, "IDs of further member accounts of the organization which are not synced. The main source account and the management account are always excluded.")
+	newCommand.cobraCommand.PersistentFlags().StringSlice(f.Service.Source.StackNames, nil, "Names of source stacks whose clusters are the only ones to sync, e.g. cluster-foo-tccp. All source stacks and the target stacks of these clusters are looked up by name instead of listing all stacks.")
+	newCommand.cobraCommand.PersistentFlags().Bool(f.Service.Source.VerifyReadOnly, false, "Refuse to sync when the source credentials belong to the target account, e.g. because source and target credentials were swapped. The accounts are compared with sts:GetCallerIdentity, so source and target must be separate accounts.")
 
 	newCommand.cobraCommand.PersistentFlags().String(f.Service.Target.AccessKey, "", "Target account access key")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Target.CredentialsFile, "", "Target account shared credentials file, e.g. rendered by a secret manager. Takes precedence over the access keys when set.")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Target.CredentialsProfile, client.DefaultCredentialsProfile, "Profile read from the target account shared credentials file.")
 	newCommand.cobraCommand.PersistentFlags().String(f.Service.Target.SecretAccessKey, "", "Target account secret access key")
 	newCommand.cobraCommand.PersistentFlags().String(f.Service.Target.Region, "", "Target account region")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Target.Route53Endpoint, "", "Endpoint of the target account Route53 API, e.g. for custom deployments. Derived from the target account region for regions outside the standard partition, e.g. China or isolated regions, when empty.")
 	newCommand.cobraCommand.PersistentFlags().String(f.Service.Target.HostedZone.Name, "", "Target account Hosted Zone name")
-	newCommand.cobraCommand.PersistentFlags().String(f.Service.Target.HostedZone.ID, "", "Target account Hosted Zone ID")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Target.HostedZone.ID, "", "Target account Hosted Zone ID. Resolved from the Hosted Zone name when empty, which must then be the name of exactly one Hosted Zone.")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Target.HostedZone.Failover, "", "Failover role of the records in the target Hosted Zone, PRIMARY or SECONDARY. Failover routing is disabled when empty.")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Target.HostedZone.HealthCheckID, "", "Route53 health check ID of the failover records in the target Hosted Zone. Required for PRIMARY records.")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Target.HostedZone.SetIdentifierPrefix, "", "Prefix of the set identifiers of the failover records in the target Hosted Zone, e.g. to keep them apart from the records of other tools.")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Target.EtcdHostedZone.Name, "", "Target account Hosted Zone name for etcd records. Defaults to the target Hosted Zone.")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Target.EtcdHostedZone.ID, "", "Target account Hosted Zone ID for etcd records. Defaults to the target Hosted Zone.")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Target.ReverseHostedZone.Name, "", "Target account reverse Hosted Zone name for etcd PTR records, e.g. 10.in-addr.arpa. Required when PTR records are emitted.")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Target.ReverseHostedZone.ID, "", "Target account reverse Hosted Zone ID for etcd PTR records. Required when PTR records are emitted.")
+	newCommand.cobraCommand.PersistentFlags().StringSlice(f.Service.Target.RegionHostedZones, nil, "Hosted Zones the records of the clusters of a region are created in, in the form <region>:<hosted-zone-id>:<hosted-zone-name>. Clusters of other regions use the target Hosted Zone.")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Target.RegionTag, recordset.DefaultRegionTag, "Tag key of the source stacks carrying the region of the cluster the Hosted Zone is selected by.")
+	newCommand.cobraCommand.PersistentFlags().StringSlice(f.Service.Target.NotificationARNs, nil, "SNS topic ARNs CloudFormation publishes the target stack events to.")
+	newCommand.cobraCommand.PersistentFlags().StringSlice(f.Service.Target.PreserveTags, nil, "Keys of the target stack tags kept on update, e.g. cost center tags added out-of-band. Their current values take precedence over the source stack tags.")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Target.TemplateBucket, "", "S3 bucket in the target account target stack templates exceeding the inline size limit are uploaded to. Creating or updating such target stacks fails when empty.")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Target.TemplateBucketRegion, "", "Region of the S3 bucket given by --service.target.templateBucket. Defaults to --service.target.region.")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Target.StackPolicy, "", "CloudFormation stack policy attached to the target stacks, either inline JSON or the path of a JSON file. No policy is applied when empty.")
 
 	return newCommand, nil
 }
@@ -69,67 +194,535 @@ type Command struct {
 
 	cobraCommand *cobra.Command
 
-	viper *viper.Viper
+	gitCommit string
+	viper     *viper.Viper
 }
 
 func (c *Command) CobraCommand() *cobra.Command {
 	return c.cobraCommand
 }
 
-func (c *Command) Execute(cmd *cobra.Command, args []string) {
+func (c *Command) Execute(cmd *cobra.Command, args []string) error {
 	// We have to parse the flags given via command line first. Only that way we
 	// are able to use the flag configuration for the location of configuration
 	// directories and files in the next step below.
 	microflag.Parse(c.viper, cmd.Flags())
 
+	// Apply the flag values of the ConfigMap, if any, before merging, so they
+	// are treated like flags given via command line.
+	configMap := c.viper.GetString(f.Config.ConfigMap)
+	if configMap != "" {
+		client, err := newInClusterConfigMapClient()
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		err = loadConfigMap(context.Background(), client, cmd.Flags(), configMap)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
 	// Merge the given command line flags with the given environment variables and
 	// the given config files, if any. The merged flags will be applied to the
 	// given viper.
 	err := microflag.Merge(c.viper, cmd.Flags(), c.viper.GetStringSlice(f.Config.Dirs), c.viper.GetStringSlice(f.Config.Files))
 	if err != nil {
-		panic(err)
+		return microerror.Maskf(invalidConfigError, "merging flags: %s", err)
+	}
+
+	if c.viper.GetBool(f.Config.Print) {
+		err = printConfig(cmd.OutOrStdout(), c.viper)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		return nil
 	}
 
 	err = c.execute()
 	if err != nil {
-		c.logger.Log("level", "error", "message", fmt.Sprintf("command %#q failed", cmd.Name()), "stack", microerror.JSON(microerror.Mask(err)), "verbosity", 0)
-		os.Exit(1)
+		return microerror.Mask(err)
 	}
+
+	return nil
 }
 
 func (c *Command) execute() error {
+	// Event syncs are scoped to the cluster of each event, which neither
+	// honours a single cluster nor a list of source stacks.
+	if c.viper.GetString(f.Service.Events.QueueURL) != "" {
+		if c.viper.GetString(f.Service.Recordset.Cluster) != "" {
+			return microerror.Maskf(invalidConfigError, "--%s must not be combined with --%s", f.Service.Events.QueueURL, f.Service.Recordset.Cluster)
+		}
+		if len(c.viper.GetStringSlice(f.Service.Source.StackNames)) > 0 {
+			return microerror.Maskf(invalidConfigError, "--%s must not be combined with --%s", f.Service.Events.QueueURL, f.Service.Source.StackNames)
+		}
+	}
+
 	installationName := c.viper.GetString(f.Service.Installation.Name)
 
+	limits := client.ServiceLimits{
+		CloudFormation: c.viper.GetFloat64(f.Service.Limits.CloudFormation),
+		EC2:            c.viper.GetFloat64(f.Service.Limits.EC2),
+		ELB:            c.viper.GetFloat64(f.Service.Limits.ELB),
+		Route53:        c.viper.GetFloat64(f.Service.Limits.Route53),
+
+		Concurrency: client.ServiceConcurrency{
+			CloudFormation: c.viper.GetInt(f.Service.Limits.Concurrency.CloudFormation),
+			EC2:            c.viper.GetInt(f.Service.Limits.Concurrency.EC2),
+			ELB:            c.viper.GetInt(f.Service.Limits.Concurrency.ELB),
+			Route53:        c.viper.GetInt(f.Service.Limits.Concurrency.Route53),
+		},
+	}
+
+	templateBucketRegion := c.viper.GetString(f.Service.Target.TemplateBucketRegion)
+	if templateBucketRegion == "" {
+		templateBucketRegion = c.viper.GetString(f.Service.Target.Region)
+	}
+
 	targetClientConfig := &client.Config{
 		AccessKeyID:     c.viper.GetString(f.Service.Target.AccessKey),
 		AccessKeySecret: c.viper.GetString(f.Service.Target.SecretAccessKey),
 		Region:          c.viper.GetString(f.Service.Target.Region),
+		Limits:          limits,
+
+		CredentialsFile:    c.viper.GetString(f.Service.Target.CredentialsFile),
+		CredentialsProfile: c.viper.GetString(f.Service.Target.CredentialsProfile),
+
+		Route53Endpoint: c.viper.GetString(f.Service.Target.Route53Endpoint),
+		S3Region:        templateBucketRegion,
 	}
 	sourceClientConfig := &client.Config{
 		AccessKeyID:     c.viper.GetString(f.Service.Source.AccessKey),
 		AccessKeySecret: c.viper.GetString(f.Service.Source.SecretAccessKey),
 		Region:          c.viper.GetString(f.Service.Source.Region),
+		Limits:          limits,
+
+		CredentialsFile:    c.viper.GetString(f.Service.Source.CredentialsFile),
+		CredentialsProfile: c.viper.GetString(f.Service.Source.CredentialsProfile),
+	}
+
+	var parentClient client.ParentInterface
+	parentHostedZoneID := c.viper.GetString(f.Service.Parent.HostedZone.ID)
+	if parentHostedZoneID != "" {
+		parentClientConfig := &client.Config{
+			AccessKeyID:     c.viper.GetString(f.Service.Parent.AccessKey),
+			AccessKeySecret: c.viper.GetString(f.Service.Parent.SecretAccessKey),
+			Region:          c.viper.GetString(f.Service.Parent.Region),
+			Limits:          limits,
+
+			CredentialsFile:    c.viper.GetString(f.Service.Parent.CredentialsFile),
+			CredentialsProfile: c.viper.GetString(f.Service.Parent.CredentialsProfile),
+		}
+		pc, err := client.NewClients(parentClientConfig)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		parentClient = pc
+	}
+
+	sourceClient, err := client.NewClients(sourceClientConfig)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	targetClient, err := client.NewClients(targetClientConfig)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	additionalSourceClients, err := parseAdditionalSourceClients(c.viper.GetStringSlice(f.Service.Source.AdditionalAccessKeys), sourceClientConfig.Region, limits)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	organizationRole := c.viper.GetString(f.Service.Source.Organization.Role)
+	if organizationRole != "" {
+		partition, err := client.RolePartition(organizationRole)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		managementClientConfig := *sourceClientConfig
+		managementClientConfig.RoleARN = organizationRole
+		organizationsClient, err := client.NewOrganizationsClient(&managementClientConfig)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		sourceAccountID, err := sourceClient.AccountID()
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		organizationSourceClients, err := client.NewOrganizationSourceClients(client.OrganizationConfig{
+			Client:             organizationsClient,
+			Config:             *sourceClientConfig,
+			Partition:          partition,
+			MemberRole:         c.viper.GetString(f.Service.Source.Organization.MemberRole),
+			SourceAccountID:    sourceAccountID,
+			ExcludedAccountIDs: c.viper.GetStringSlice(f.Service.Source.Organization.ExcludedAccounts),
+		})
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		c.logger.Log("level", "debug", "message", fmt.Sprintf("discovered %d source accounts of the organization", len(organizationSourceClients)))
+
+		for _, cl := range organizationSourceClients {
+			additionalSourceClients = append(additionalSourceClients, cl)
+		}
+	}
+
+	if c.viper.GetBool(f.Service.Source.VerifyReadOnly) {
+		err = sourceClient.VerifySourceAccount(targetClient)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		for _, a := range additionalSourceClients {
+			if ac, ok := a.(*client.Clients); ok {
+				err = ac.VerifySourceAccount(targetClient)
+				if err != nil {
+					return microerror.Mask(err)
+				}
+			}
+		}
+	}
+
+	components, err := parseComponents(c.viper.GetStringSlice(f.Service.Recordset.Components))
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	outputKeys, err := parseStackOutputKeys(c.viper.GetStringSlice(f.Service.Recordset.StackOutputKeys))
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	regionHostedZones, err := parseRegionHostedZones(c.viper.GetStringSlice(f.Service.Target.RegionHostedZones))
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	stackPolicy, err := readStackPolicy(c.viper.GetString(f.Service.Target.StackPolicy))
+	if err != nil {
+		return microerror.Mask(err)
 	}
 
+	var auditLog io.Writer
+	auditFile := c.viper.GetString(f.Service.Log.AuditFile)
+	if auditFile != "" {
+		file, err := os.OpenFile(auditFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		defer file.Close()
+		auditLog = file
+	}
+
+	minEtcdENIs := c.viper.GetInt(f.Service.Source.MinEtcdENIs)
+
 	cfg := &recordset.Config{
 		Logger:       c.logger,
 		Installation: installationName,
-		SourceClient: client.NewClients(sourceClientConfig),
-		TargetClient: client.NewClients(targetClientConfig),
+		SourceClient: sourceClient,
+		TargetClient: targetClient,
+
+		AdditionalSourceClients: additionalSourceClients,
+
+		AliasWildcard:    c.viper.GetBool(f.Service.Recordset.AliasWildcard),
+		BareDomainRecord: c.viper.GetBool(f.Service.Recordset.BareDomainRecord),
+		APIRecordType:    c.viper.GetString(f.Service.Recordset.APIRecordType),
+		NonLegacyIngress: c.viper.GetBool(f.Service.Recordset.NonLegacyIngress),
+		DryRun:           c.viper.GetBool(f.Service.Recordset.DryRun),
+		ApplyMode:        c.viper.GetString(f.Service.Recordset.ApplyMode),
+		Discovery:        c.viper.GetString(f.Service.Recordset.Discovery),
+		CAAValue:         c.viper.GetString(f.Service.Recordset.CAA),
+		MetadataRecord:   c.viper.GetBool(f.Service.Recordset.MetadataRecord),
+		Cluster:          c.viper.GetString(f.Service.Recordset.Cluster),
+		RecreateCluster:  c.viper.GetString(f.Service.Recordset.RecreateCluster),
+		SourceStackNames: c.viper.GetStringSlice(f.Service.Source.StackNames),
+		Components:       components,
+		TemplateFormat:   c.viper.GetString(f.Service.Recordset.TemplateFormat),
+		TemplateVersion:  c.viper.GetString(f.Service.Recordset.TemplateVersion),
+		IgnoreVersionTag: c.viper.GetBool(f.Service.Recordset.IgnoreVersionTag),
+		Version:          c.gitCommit,
+		ChangeReason:     c.viper.GetString(f.Service.Recordset.ChangeReason),
+		Quiet:            c.viper.GetBool(f.Service.Log.Quiet),
+		PauseTag:         c.viper.GetString(f.Service.Recordset.PauseTag),
+		TagOnlyUpdates:   c.viper.GetBool(f.Service.Recordset.TagOnlyUpdates),
+		AdoptExisting:    c.viper.GetBool(f.Service.Recordset.AdoptExisting),
+		MinStackAge:      c.viper.GetDuration(f.Service.Recordset.MinStackAge),
+
+		SkipUnchangedUpdates: c.viper.GetBool(f.Service.Recordset.SkipUnchangedUpdates),
+
+		MaxTemplateBodySize:  c.viper.GetInt(f.Service.Recordset.MaxTemplateBodySize),
+		TemplateBucket:       c.viper.GetString(f.Service.Target.TemplateBucket),
+		TemplateBucketRegion: templateBucketRegion,
+
+		BufferClusterLogs: c.viper.GetBool(f.Service.Log.BufferClusterLogs),
+
+		ClusterMetricsOperationLabel: c.viper.GetBool(f.Service.Metrics.OperationLabel),
+
+		AuditLog: auditLog,
+
+		SummaryHistoryFile: c.viper.GetString(f.Service.Recordset.SummaryHistoryFile),
+		PlanOutputFile:     c.viper.GetString(f.Service.Recordset.PlanOutputFile),
+
+		LeftoverCheckpointFile: c.viper.GetString(f.Service.Recordset.LeftoverCheckpointFile),
+		LeftoverChangeInterval: c.viper.GetDuration(f.Service.Recordset.LeftoverChangeInterval),
+		ExplainCleanup:         c.viper.GetBool(f.Service.Recordset.ExplainCleanup),
+
+		StrictClusterNames:      c.viper.GetBool(f.Service.Recordset.StrictClusterNames),
+		IgnoreUnparseableStacks: c.viper.GetBool(f.Service.Recordset.IgnoreUnparseableStacks),
+
+		UseChangeSets:         c.viper.GetBool(f.Service.Recordset.UseChangeSets),
+		AutoExecuteChangeSets: c.viper.GetBool(f.Service.Recordset.AutoExecute),
+
+		DeletionOrder:         c.viper.GetString(f.Service.Recordset.DeletionOrder),
+		DeletionStopOnFailure: c.viper.GetBool(f.Service.Recordset.DeletionStopOnFailure),
+		DisableOrphanDeletion: !c.viper.GetBool(f.Service.Recordset.EnableOrphanDeletion),
+		DeleteOnlyOnCleanSync: c.viper.GetBool(f.Service.Recordset.DeleteOnlyOnCleanSync),
+		ConfirmOrphans:        c.viper.GetBool(f.Service.Recordset.ConfirmOrphans),
+		DeleteTriggerStatuses: c.viper.GetStringSlice(f.Service.Recordset.DeleteTriggerStatuses),
+		DiscoveryGracePeriod:  c.viper.GetDuration(f.Service.Recordset.DiscoveryGracePeriod),
+
+		MaxDeletes:          c.viper.GetInt(f.Service.Recordset.MaxDeletes),
+		MaxDeletePercentage: c.viper.GetInt(f.Service.Recordset.MaxDeletePercentage),
+		ForceMassDelete:     c.viper.GetBool(f.Service.Recordset.ForceMassDelete),
+
+		LockHostedZone: c.viper.GetBool(f.Service.Recordset.Lock),
+		LockOwner:      c.viper.GetString(f.Service.Recordset.LockOwner),
+		LockLease:      c.viper.GetDuration(f.Service.Recordset.LockLease),
+
+		WaitForSync:        c.viper.GetBool(f.Service.Recordset.WaitForSync),
+		WaitForSyncTimeout: c.viper.GetDuration(f.Service.Recordset.WaitForSyncTimeout),
+		SyncTimeout:        c.viper.GetDuration(f.Service.Recordset.SyncTimeout),
+
+		PriorRequestRetries: c.viper.GetInt(f.Service.Recordset.PriorRequestRetries),
+
+		SyncRetries:        c.viper.GetInt(f.Service.Recordset.SyncRetries),
+		ConsistencyRetries: c.viper.GetInt(f.Service.Recordset.ConsistencyRetries),
+
+		RecordLimitMargin: c.viper.GetInt(f.Service.Recordset.RecordLimitMargin),
+
+		ENIClusterTag: c.viper.GetString(f.Service.Source.ENIClusterTag),
+		ENIOrderTag:   c.viper.GetString(f.Service.Source.ENIOrderTag),
+		MinEtcdENIs:   &minEtcdENIs,
+		MaxEtcdENIs:   c.viper.GetInt(f.Service.Source.MaxEtcdENIs),
+
+		ELBLookup:  c.viper.GetString(f.Service.Source.ELBLookup),
+		ELBRoleTag: c.viper.GetString(f.Service.Source.ELBRoleTag),
+
+		ELBDNSFromOutputs: c.viper.GetBool(f.Service.Recordset.UseStackOutputs),
+		ELBDNSOutputKeys:  outputKeys,
+		RequireHealthyELB: c.viper.GetBool(f.Service.Source.RequireHealthyELB),
+
+		NotificationARNs: c.viper.GetStringSlice(f.Service.Target.NotificationARNs),
+		StackPolicy:      stackPolicy,
+
+		PreserveTargetTags: c.viper.GetStringSlice(f.Service.Target.PreserveTags),
+
+		ParentClient:         parentClient,
+		ParentHostedZoneID:   parentHostedZoneID,
+		ParentHostedZoneName: c.viper.GetString(f.Service.Parent.HostedZone.Name),
 
 		TargetHostedZoneID:   c.viper.GetString(f.Service.Target.HostedZone.ID),
 		TargetHostedZoneName: c.viper.GetString(f.Service.Target.HostedZone.Name),
+		EtcdHostedZoneID:     c.viper.GetString(f.Service.Target.EtcdHostedZone.ID),
+		EtcdHostedZoneName:   c.viper.GetString(f.Service.Target.EtcdHostedZone.Name),
+
+		RegionHostedZones: regionHostedZones,
+		RegionTag:         c.viper.GetString(f.Service.Target.RegionTag),
+
+		TargetHostedZoneFailover:      c.viper.GetString(f.Service.Target.HostedZone.Failover),
+		TargetHostedZoneHealthCheckID: c.viper.GetString(f.Service.Target.HostedZone.HealthCheckID),
+
+		SetIdentifierPrefix: c.viper.GetString(f.Service.Target.HostedZone.SetIdentifierPrefix),
+
+		EmitPTR:               c.viper.GetBool(f.Service.Recordset.EmitPTR),
+		ReverseHostedZoneID:   c.viper.GetString(f.Service.Target.ReverseHostedZone.ID),
+		ReverseHostedZoneName: c.viper.GetString(f.Service.Target.ReverseHostedZone.Name),
+	}
+
+	queueURL := c.viper.GetString(f.Service.Events.QueueURL)
+	if queueURL == "" {
+		err = c.sync(*cfg)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		return nil
+	}
+
+	// The initial sync catches up on the events missed while the command
+	// was not running. Its failure is not fatal, the clusters are synced
+	// again on their next stack event.
+	err = c.sync(*cfg)
+	if err != nil {
+		c.logger.Log("level", "error", "message", "failed to sync all clusters", "stack", microerror.JSON(err))
 	}
 
-	m, err := recordset.NewManager(cfg)
+	w, err := events.New(events.Config{
+		Logger:   c.logger,
+		Client:   sourceClient,
+		QueueURL: queueURL,
+		Sync: func(clusterID string) error {
+			clusterCfg := *cfg
+			clusterCfg.Cluster = clusterID
+			// The recreation is only done by the initial sync.
+			clusterCfg.RecreateCluster = ""
+			return c.sync(clusterCfg)
+		},
+	})
 	if err != nil {
-		log.Fatalf("could not create recordset manager %v", err)
+		return microerror.Mask(err)
 	}
 
-	err = m.Sync()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigs)
+	go func() {
+		select {
+		case <-sigs:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	err = w.Run(ctx)
 	if err != nil {
 		return microerror.Mask(err)
 	}
 
 	return nil
 }
+
+// sync runs one sync with the given config and writes the metrics text file
+// afterwards, also when the sync failed.
+func (c *Command) sync(cfg recordset.Config) error {
+	m, err := recordset.NewManager(&cfg)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	syncErr := m.Sync()
+
+	textFile := c.viper.GetString(f.Service.Metrics.TextFile)
+	if textFile != "" {
+		err = metrics.DefaultRegistry.WriteTextFile(textFile)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	if syncErr != nil {
+		return microerror.Mask(syncErr)
+	}
+
+	return nil
+}
+
+// parseAdditionalSourceClients returns the clients of the additional source
+// accounts given in the form <access-key>:<secret-access-key>[:<region>].
+func parseAdditionalSourceClients(accessKeys []string, defaultRegion string, limits client.ServiceLimits) ([]client.SourceInterface, error) {
+	var clients []client.SourceInterface
+	for i, a := range accessKeys {
+		parts := strings.SplitN(a, ":", 3)
+		if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+			// The value holds a secret, so only its position is reported.
+			return nil, microerror.Maskf(invalidConfigError, "additional source access key %d must be in the form <access-key>:<secret-access-key>[:<region>]", i)
+		}
+
+		config := &client.Config{
+			AccessKeyID:     parts[0],
+			AccessKeySecret: parts[1],
+			Region:          defaultRegion,
+			Limits:          limits,
+		}
+		if len(parts) == 3 && parts[2] != "" {
+			config.Region = parts[2]
+		}
+		cl, err := client.NewClients(config)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		clients = append(clients, cl)
+	}
+
+	return clients, nil
+}
+
+// parseComponents returns the default components extended by the additional
+// components given in the form <name>:<elb-suffix>.
+func parseComponents(extra []string) ([]recordset.Component, error) {
+	components := append([]recordset.Component{}, recordset.DefaultComponents...)
+	for _, e := range extra {
+		parts := strings.SplitN(e, ":", 2)
+		if len(parts) != 2 {
+			return nil, microerror.Maskf(invalidConfigError, "component %#q must be in the form <name>:<elb-suffix>", e)
+		}
+
+		c := recordset.Component{
+			Name:      parts[0],
+			ELBSuffix: parts[1],
+		}
+		components = append(components, c)
+	}
+
+	return components, nil
+}
+
+// parseStackOutputKeys returns the default ELB DNS output keys overridden by
+// the output keys given in the form <name>:<output-key>.
+func parseStackOutputKeys(overrides []string) (map[string]string, error) {
+	outputKeys := map[string]string{}
+	for name, outputKey := range recordset.DefaultELBDNSOutputKeys {
+		outputKeys[name] = outputKey
+	}
+	for _, o := range overrides {
+		parts := strings.SplitN(o, ":", 2)
+		if len(parts) != 2 {
+			return nil, microerror.Maskf(invalidConfigError, "stack output key %#q must be in the form <name>:<output-key>", o)
+		}
+
+		outputKeys[parts[0]] = parts[1]
+	}
+
+	return outputKeys, nil
+}
+
+// parseRegionHostedZones returns the hosted zones of the regions given in the
+// form <region>:<hosted-zone-id>:<hosted-zone-name>.
+func parseRegionHostedZones(zones []string) (map[string]recordset.HostedZone, error) {
+	regionHostedZones := map[string]recordset.HostedZone{}
+	for _, z := range zones {
+		parts := strings.SplitN(z, ":", 3)
+		if len(parts) != 3 {
+			return nil, microerror.Maskf(invalidConfigError, "region hosted zone %#q must be in the form <region>:<hosted-zone-id>:<hosted-zone-name>", z)
+		}
+
+		regionHostedZones[parts[0]] = recordset.HostedZone{
+			ID:   parts[1],
+			Name: parts[2],
+		}
+	}
+
+	return regionHostedZones, nil
+}
+
+// readStackPolicy returns the given stack policy when it is inline JSON and
+// the content of the given file otherwise.
+func readStackPolicy(policy string) (string, error) {
+	if policy == "" || strings.HasPrefix(strings.TrimSpace(policy), "{") {
+		return policy, nil
+	}
+
+	b, err := ioutil.ReadFile(policy)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return string(b), nil
+}
@@ -0,0 +1,52 @@
+package sync
+
+import (
+	"testing"
+
+	"github.com/giantswarm/micrologger"
+)
+
+func TestExecute_EventsScope(t *testing.T) {
+	tcs := []struct {
+		name string
+		args []string
+	}{
+		{
+			name: "case 0: events with a single cluster",
+			args: []string{
+				"--" + f.Service.Events.QueueURL, "https://sqs.eu-central-1.amazonaws.com/123456789012/events",
+				"--" + f.Service.Recordset.Cluster, "foo",
+			},
+		},
+		{
+			name: "case 1: events with source stack names",
+			args: []string{
+				"--" + f.Service.Events.QueueURL, "https://sqs.eu-central-1.amazonaws.com/123456789012/events",
+				"--" + f.Service.Source.StackNames, "cluster-foo-tccp",
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			logger, err := micrologger.New(micrologger.Config{})
+			if err != nil {
+				t.Fatalf("micrologger.New: %v", err)
+			}
+			c, err := New(Config{Logger: logger})
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+
+			cmd := c.CobraCommand()
+			cmd.SetArgs(tc.args)
+			cmd.SilenceErrors = true
+			cmd.SilenceUsage = true
+
+			err = cmd.Execute()
+			if !IsInvalidConfig(err) {
+				t.Fatalf("expected invalid config error, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package verify
+
+import (
+	"github.com/giantswarm/microerror"
+)
+
+var invalidConfigError = &microerror.Error{
+	Kind: "invalidConfigError",
+}
+
+// IsInvalidConfig asserts invalidConfigError.
+func IsInvalidConfig(err error) bool {
+	return microerror.Cause(err) == invalidConfigError
+}
+
+var mismatchError = &microerror.Error{
+	Kind: "mismatchError",
+}
+
+// IsMismatch asserts mismatchError.
+func IsMismatch(err error) bool {
+	return microerror.Cause(err) == mismatchError
+}
@@ -0,0 +1,166 @@
+package verify
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	microflag "github.com/giantswarm/microkit/flag"
+	"github.com/giantswarm/micrologger"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/giantswarm/route53-manager/flag"
+	"github.com/giantswarm/route53-manager/pkg/client"
+	"github.com/giantswarm/route53-manager/pkg/recordset"
+)
+
+var (
+	f = flag.New()
+)
+
+type Config struct {
+	Logger micrologger.Logger
+
+	Viper *viper.Viper
+}
+
+func New(config Config) (*Command, error) {
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+	if config.Viper == nil {
+		config.Viper = viper.New()
+	}
+
+	newCommand := &Command{
+		logger: config.Logger,
+
+		cobraCommand: nil,
+
+		viper: config.Viper,
+	}
+
+	newCommand.cobraCommand = &cobra.Command{
+		Use:   "verify",
+		Short: "Verify that the recordsets of the target stacks exist in Route53.",
+		Long:  "Compares the recordsets of the target stack templates with the live recordsets in Route53 and reports missing and mismatching ones. Nothing is changed.",
+		RunE:  newCommand.Execute,
+	}
+
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Installation.Name, "", "Installation name")
+
+	newCommand.cobraCommand.PersistentFlags().Float64(f.Service.Limits.CloudFormation, 0, "Maximum CloudFormation requests per second per account. Zero disables the limit.")
+	newCommand.cobraCommand.PersistentFlags().Float64(f.Service.Limits.Route53, 0, "Maximum Route53 requests per second per account. Zero disables the limit.")
+
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Recordset.Cluster, "", "ID of the only cluster to verify. All clusters are verified when empty.")
+
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Target.AccessKey, "", "Target account access key")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Target.CredentialsFile, "", "Target account shared credentials file, e.g. rendered by a secret manager. Takes precedence over the access keys when set.")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Target.CredentialsProfile, client.DefaultCredentialsProfile, "Profile read from the target account shared credentials file.")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Target.SecretAccessKey, "", "Target account secret access key")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Target.Region, "", "Target account region")
+	newCommand.cobraCommand.PersistentFlags().String(f.Service.Target.Route53Endpoint, "", "Endpoint of the target account Route53 API, e.g. for custom deployments. Derived from the target account region for regions outside the standard partition, e.g. China or isolated regions, when empty.")
+
+	return newCommand, nil
+}
+
+type Command struct {
+	logger micrologger.Logger
+
+	cobraCommand *cobra.Command
+
+	viper *viper.Viper
+}
+
+func (c *Command) CobraCommand() *cobra.Command {
+	return c.cobraCommand
+}
+
+func (c *Command) Execute(cmd *cobra.Command, args []string) error {
+	microflag.Parse(c.viper, cmd.Flags())
+
+	err := microflag.Merge(c.viper, cmd.Flags(), c.viper.GetStringSlice(f.Config.Dirs), c.viper.GetStringSlice(f.Config.Files))
+	if err != nil {
+		return microerror.Maskf(invalidConfigError, "merging flags: %s", err)
+	}
+
+	err = c.execute(cmd.OutOrStdout())
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (c *Command) execute(w io.Writer) error {
+	targetClientConfig := &client.Config{
+		AccessKeyID:     c.viper.GetString(f.Service.Target.AccessKey),
+		AccessKeySecret: c.viper.GetString(f.Service.Target.SecretAccessKey),
+		Region:          c.viper.GetString(f.Service.Target.Region),
+
+		CredentialsFile:    c.viper.GetString(f.Service.Target.CredentialsFile),
+		CredentialsProfile: c.viper.GetString(f.Service.Target.CredentialsProfile),
+
+		Limits: client.ServiceLimits{
+			CloudFormation: c.viper.GetFloat64(f.Service.Limits.CloudFormation),
+			Route53:        c.viper.GetFloat64(f.Service.Limits.Route53),
+		},
+
+		Route53Endpoint: c.viper.GetString(f.Service.Target.Route53Endpoint),
+	}
+
+	targetClient, err := client.NewClients(targetClientConfig)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	cfg := recordset.VerifierConfig{
+		Logger:       c.logger,
+		Installation: c.viper.GetString(f.Service.Installation.Name),
+		TargetClient: targetClient,
+
+		Cluster: c.viper.GetString(f.Service.Recordset.Cluster),
+	}
+
+	v, err := recordset.NewVerifier(cfg)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	mismatches, err := v.Verify()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = printMismatches(w, mismatches)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if len(mismatches) > 0 {
+		return microerror.Maskf(mismatchError, "%d recordsets do not match", len(mismatches))
+	}
+
+	return nil
+}
+
+// printMismatches writes one line per mismatch to w, e.g.
+//
+//	cluster-foo-guest-recordsets Z123 api.foo.example.com CNAME value: expected [a.elb] got [b.elb]
+func printMismatches(w io.Writer, mismatches []recordset.RecordMismatch) error {
+	for _, m := range mismatches {
+		line := fmt.Sprintf("%s %s %s %s %s: expected [%s]", m.StackName, m.HostedZoneID, m.Name, m.Type, m.Reason, strings.Join(m.Expected, " "))
+		if m.Reason == recordset.MismatchReasonValue {
+			line += fmt.Sprintf(" got [%s]", strings.Join(m.Actual, " "))
+		}
+
+		_, err := fmt.Fprintln(w, line)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package verify
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/giantswarm/route53-manager/pkg/recordset"
+)
+
+func TestPrintMismatches(t *testing.T) {
+	mismatches := []recordset.RecordMismatch{
+		{
+			StackName:    "cluster-foo-guest-recordsets",
+			HostedZoneID: "Z123",
+			Name:         "api.foo.example.com",
+			Type:         "CNAME",
+			Reason:       recordset.MismatchReasonValue,
+			Expected:     []string{"a.elb"},
+			Actual:       []string{"b.elb"},
+		},
+		{
+			StackName:    "cluster-foo-guest-recordsets",
+			HostedZoneID: "Z123",
+			Name:         "etcd1.foo.example.com",
+			Type:         "A",
+			Reason:       recordset.MismatchReasonMissing,
+			Expected:     []string{"10.0.0.1"},
+		},
+	}
+
+	var out bytes.Buffer
+	err := printMismatches(&out, mismatches)
+	if err != nil {
+		t.Fatalf("printMismatches: %v", err)
+	}
+
+	expected := "cluster-foo-guest-recordsets Z123 api.foo.example.com CNAME value: expected [a.elb] got [b.elb]\n" +
+		"cluster-foo-guest-recordsets Z123 etcd1.foo.example.com A missing: expected [10.0.0.1]\n"
+	if out.String() != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, out.String())
+	}
+}
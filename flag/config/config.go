@@ -1,6 +1,8 @@
 package config
 
 type Config struct {
-	Dirs  string
-	Files string
+	ConfigMap string
+	Dirs      string
+	Files     string
+	Print     string
 }
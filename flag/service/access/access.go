@@ -1,7 +1,9 @@
 package access
 
 type Config struct {
-	AccessKey       string
-	SecretAccessKey string
-	Region          string
+	AccessKey          string
+	CredentialsFile    string
+	CredentialsProfile string
+	SecretAccessKey    string
+	Region             string
 }
@@ -0,0 +1,5 @@
+package events
+
+type Events struct {
+	QueueURL string
+}
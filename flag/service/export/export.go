@@ -0,0 +1,5 @@
+package export
+
+type Export struct {
+	Format string
+}
@@ -0,0 +1,8 @@
+package concurrency
+
+type Config struct {
+	CloudFormation string
+	EC2            string
+	ELB            string
+	Route53        string
+}
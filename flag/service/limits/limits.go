@@ -0,0 +1,13 @@
+package limits
+
+import (
+	"github.com/giantswarm/route53-manager/flag/service/limits/concurrency"
+)
+
+type Limits struct {
+	CloudFormation string
+	Concurrency    concurrency.Config
+	EC2            string
+	ELB            string
+	Route53        string
+}
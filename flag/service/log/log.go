@@ -0,0 +1,8 @@
+package log
+
+type Log struct {
+	AuditFile         string
+	BufferClusterLogs string
+	Format            string
+	Quiet             string
+}
@@ -0,0 +1,6 @@
+package metrics
+
+type Metrics struct {
+	OperationLabel string
+	TextFile       string
+}
@@ -0,0 +1,11 @@
+package parent
+
+import (
+	"github.com/giantswarm/route53-manager/flag/service/access"
+	"github.com/giantswarm/route53-manager/flag/service/target/hostedzone"
+)
+
+type Parent struct {
+	access.Config
+	HostedZone hostedzone.Config
+}
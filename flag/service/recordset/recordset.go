@@ -0,0 +1,58 @@
+package recordset
+
+type Recordset struct {
+	AdoptExisting           string
+	AliasWildcard           string
+	APIRecordType           string
+	ApplyMode               string
+	AutoExecute             string
+	BareDomainRecord        string
+	CAA                     string
+	ChangeReason            string
+	Cluster                 string
+	Components              string
+	ConfirmOrphans          string
+	ConsistencyRetries      string
+	DeleteOnlyOnCleanSync   string
+	DeleteTriggerStatuses   string
+	DeletionOrder           string
+	DeletionStopOnFailure   string
+	Discovery               string
+	DiscoveryGracePeriod    string
+	DryRun                  string
+	EmitPTR                 string
+	EnableOrphanDeletion    string
+	ExplainCleanup          string
+	ForceMassDelete         string
+	IgnoreUnparseableStacks string
+	IgnoreVersionTag        string
+	LeftoverChangeInterval  string
+	LeftoverCheckpointFile  string
+	Lock                    string
+	LockLease               string
+	LockOwner               string
+	MaxDeletePercentage     string
+	MaxDeletes              string
+	MaxTemplateBodySize     string
+	MetadataRecord          string
+	MinStackAge             string
+	NonLegacyIngress        string
+	PauseTag                string
+	PlanOutputFile          string
+	PriorRequestRetries     string
+	RecordLimitMargin       string
+	RecreateCluster         string
+	SkipUnchangedUpdates    string
+	StackOutputKeys         string
+	StrictClusterNames      string
+	SummaryHistoryFile      string
+	SyncRetries             string
+	SyncTimeout             string
+	TagOnlyUpdates          string
+	TemplateFormat          string
+	TemplateVersion         string
+	UseChangeSets           string
+	UseStackOutputs         string
+	WaitForSync             string
+	WaitForSyncTimeout      string
+}
@@ -1,13 +1,27 @@
 package service
 
 import (
+	"github.com/giantswarm/route53-manager/flag/service/events"
+	"github.com/giantswarm/route53-manager/flag/service/export"
 	"github.com/giantswarm/route53-manager/flag/service/installation"
+	"github.com/giantswarm/route53-manager/flag/service/limits"
+	"github.com/giantswarm/route53-manager/flag/service/log"
+	"github.com/giantswarm/route53-manager/flag/service/metrics"
+	"github.com/giantswarm/route53-manager/flag/service/parent"
+	"github.com/giantswarm/route53-manager/flag/service/recordset"
 	"github.com/giantswarm/route53-manager/flag/service/source"
 	"github.com/giantswarm/route53-manager/flag/service/target"
 )
 
 type Service struct {
+	Events       events.Events
+	Export       export.Export
 	Installation installation.Installation
+	Limits       limits.Limits
+	Log          log.Log
+	Metrics      metrics.Metrics
+	Parent       parent.Parent
+	Recordset    recordset.Recordset
 	Source       source.Source
 	Target       target.Target
 }
@@ -0,0 +1,7 @@
+package organization
+
+type Config struct {
+	ExcludedAccounts string
+	MemberRole       string
+	Role             string
+}
@@ -1,7 +1,21 @@
 package source
 
-import "github.com/giantswarm/route53-manager/flag/service/access"
+import (
+	"github.com/giantswarm/route53-manager/flag/service/access"
+	"github.com/giantswarm/route53-manager/flag/service/source/organization"
+)
 
 type Source struct {
 	access.Config
+	AdditionalAccessKeys string
+	ELBLookup            string
+	ELBRoleTag           string
+	ENIClusterTag        string
+	ENIOrderTag          string
+	MaxEtcdENIs          string
+	MinEtcdENIs          string
+	Organization         organization.Config
+	RequireHealthyELB    string
+	StackNames           string
+	VerifyReadOnly       string
 }
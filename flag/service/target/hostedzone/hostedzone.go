@@ -1,6 +1,9 @@
 package hostedzone
 
 type Config struct {
-	Name string
-	ID   string
+	Failover            string
+	HealthCheckID       string
+	Name                string
+	ID                  string
+	SetIdentifierPrefix string
 }
@@ -7,5 +7,15 @@ import (
 
 type Target struct {
 	access.Config
-	HostedZone hostedzone.Config
+	HostedZone           hostedzone.Config
+	EtcdHostedZone       hostedzone.Config
+	ReverseHostedZone    hostedzone.Config
+	NotificationARNs     string
+	PreserveTags         string
+	RegionHostedZones    string
+	RegionTag            string
+	Route53Endpoint      string
+	StackPolicy          string
+	TemplateBucket       string
+	TemplateBucketRegion string
 }
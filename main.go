@@ -2,11 +2,28 @@ package main
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/micrologger"
 
 	"github.com/giantswarm/route53-manager/command"
+	"github.com/giantswarm/route53-manager/command/export"
+	"github.com/giantswarm/route53-manager/command/gc"
+	"github.com/giantswarm/route53-manager/command/sync"
+	"github.com/giantswarm/route53-manager/command/verify"
+	"github.com/giantswarm/route53-manager/pkg/client"
+	"github.com/giantswarm/route53-manager/pkg/events"
+	"github.com/giantswarm/route53-manager/pkg/logformat"
+	"github.com/giantswarm/route53-manager/pkg/recordset"
+)
+
+const (
+	// exitCodeError is used for operational errors, e.g. failing AWS
+	// requests, and for recordsets not matching in the verify command.
+	exitCodeError = 1
+	// exitCodeInvalidConfig is used for invalid flags and configuration.
+	exitCodeInvalidConfig = 2
 )
 
 var (
@@ -19,15 +36,24 @@ var (
 func main() {
 	err := mainWithError()
 	if err != nil {
-		panic(fmt.Sprintf("%#v\n", err))
+		os.Exit(exitCode(err))
 	}
 }
 
 func mainWithError() (err error) {
+	// The format of the log lines is set by the root command once the flags
+	// are parsed.
+	logWriter := logformat.New(os.Stdout)
+
 	var newLogger micrologger.Logger
 	{
-		newLogger, err = micrologger.New(micrologger.Config{})
+		c := micrologger.Config{
+			IOWriter: logWriter,
+		}
+
+		newLogger, err = micrologger.New(c)
 		if err != nil {
+			fmt.Fprintf(os.Stderr, "could not create logger: %#v\n", err)
 			return microerror.Mask(err)
 		}
 	}
@@ -35,7 +61,8 @@ func mainWithError() (err error) {
 	var newCommand *command.Command
 	{
 		c := command.Config{
-			Logger: newLogger,
+			Logger:    newLogger,
+			LogWriter: logWriter,
 
 			Description: description,
 			GitCommit:   gitCommit,
@@ -45,9 +72,41 @@ func mainWithError() (err error) {
 
 		newCommand, err = command.New(c)
 		if err != nil {
+			newLogger.Log("level", "error", "message", "could not create command", "stack", microerror.JSON(err), "verbosity", 0)
 			return microerror.Mask(err)
 		}
 	}
 
-	return newCommand.CobraCommand().Execute()
+	cmd, err := newCommand.CobraCommand().ExecuteC()
+	// Mismatches are already printed by the verify command.
+	if err != nil && !verify.IsMismatch(err) {
+		newLogger.Log("level", "error", "message", fmt.Sprintf("command %#q failed", cmd.Name()), "stack", microerror.JSON(microerror.Mask(err)), "verbosity", 0)
+	}
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// exitCode returns the exit code of the given error of mainWithError. Usage
+// errors of the command line and invalid config errors of all packages are
+// mapped to exitCodeInvalidConfig, all others to exitCodeError.
+func exitCode(err error) int {
+	switch {
+	case err == nil:
+		return 0
+	case command.IsUsage(err),
+		export.IsInvalidConfig(err),
+		gc.IsInvalidConfig(err),
+		sync.IsInvalidConfig(err),
+		verify.IsInvalidConfig(err),
+		client.IsInvalidConfig(err),
+		events.IsInvalidConfig(err),
+		logformat.IsInvalidConfig(err),
+		recordset.IsInvalidConfig(err):
+		return exitCodeInvalidConfig
+	default:
+		return exitCodeError
+	}
 }
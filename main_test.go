@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+
+	"github.com/giantswarm/route53-manager/command"
+	"github.com/giantswarm/route53-manager/command/sync"
+	"github.com/giantswarm/route53-manager/pkg/recordset"
+)
+
+func Test_exitCode(t *testing.T) {
+	_, invalidManagerConfigErr := recordset.NewManager(&recordset.Config{})
+	if !recordset.IsInvalidConfig(invalidManagerConfigErr) {
+		t.Fatalf("expected invalid config error, got %v", invalidManagerConfigErr)
+	}
+
+	_, invalidSyncConfigErr := sync.New(sync.Config{})
+	if !sync.IsInvalidConfig(invalidSyncConfigErr) {
+		t.Fatalf("expected invalid config error, got %v", invalidSyncConfigErr)
+	}
+
+	usageErr := func(args ...string) error {
+		logger, err := micrologger.New(micrologger.Config{IOWriter: ioutil.Discard})
+		if err != nil {
+			t.Fatalf("micrologger.New: %v", err)
+		}
+		newCommand, err := command.New(command.Config{Logger: logger, Name: name})
+		if err != nil {
+			t.Fatalf("command.New: %v", err)
+		}
+		newCommand.CobraCommand().SetArgs(args)
+
+		_, err = newCommand.CobraCommand().ExecuteC()
+		if !command.IsUsage(err) {
+			t.Fatalf("expected usage error, got %v", err)
+		}
+
+		return err
+	}
+	unknownFlagErr := usageErr("sync", "--service.unknown", "foo")
+	unknownCommandErr := usageErr("unknown")
+
+	tcs := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{
+			name:     "case 0: success",
+			err:      nil,
+			expected: 0,
+		},
+		{
+			name:     "case 1: invalid recordset manager config",
+			err:      microerror.Mask(invalidManagerConfigErr),
+			expected: 2,
+		},
+		{
+			name:     "case 2: invalid sync command config",
+			err:      microerror.Mask(invalidSyncConfigErr),
+			expected: 2,
+		},
+		{
+			name:     "case 3: AWS error",
+			err:      microerror.Mask(awserr.New("Throttling", "Rate exceeded", nil)),
+			expected: 1,
+		},
+		{
+			name:     "case 4: other error",
+			err:      fmt.Errorf("unknown"),
+			expected: 1,
+		},
+		{
+			name:     "case 5: unknown flag",
+			err:      microerror.Mask(unknownFlagErr),
+			expected: 2,
+		},
+		{
+			name:     "case 6: unknown command",
+			err:      microerror.Mask(unknownCommandErr),
+			expected: 2,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			code := exitCode(tc.err)
+			if code != tc.expected {
+				t.Fatalf("expected exit code %d, got %d", tc.expected, code)
+			}
+		})
+	}
+}
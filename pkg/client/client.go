@@ -2,14 +2,24 @@ package client
 
 import (
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/cloudformation"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
 	"github.com/aws/aws-sdk-go/service/elb"
 	"github.com/aws/aws-sdk-go/service/elb/elbiface"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/aws/aws-sdk-go/service/elbv2/elbv2iface"
 	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/aws/aws-sdk-go/service/sts/stsiface"
+	"github.com/giantswarm/microerror"
 )
 
 type Config struct {
@@ -17,27 +27,102 @@ type Config struct {
 	AccessKeySecret string
 	SessionToken    string
 	Region          string
+
+	// CredentialsFile is the path of a shared credentials file, e.g. rendered
+	// by a secret manager. The keys of CredentialsProfile, which defaults to
+	// DefaultCredentialsProfile, take precedence over the inline keys when
+	// set.
+	CredentialsFile    string
+	CredentialsProfile string
+
+	// RoleARN is the role assumed with the credentials, e.g. in a member
+	// account of an organization. The credentials are used as they are when
+	// empty.
+	RoleARN string
+
+	// Limits are the request rate and concurrency limits applied to the AWS
+	// service clients.
+	Limits ServiceLimits
+
+	// Route53Endpoint overrides the endpoint of the Route53 client, e.g. for
+	// custom deployments. Requests are signed for Region then. The endpoint
+	// is derived from Region for regions outside the standard partition like
+	// `cn-north-1` or `us-iso-east-1` when empty.
+	Route53Endpoint string
+
+	// S3Region overrides the region of the S3 client, e.g. for a template
+	// bucket in another region than Region.
+	S3Region string
 }
 
 type StackDescribeLister interface {
-	DescribeStacks(*cloudformation.DescribeStacksInput) (*cloudformation.DescribeStacksOutput, error)
-	ListStacks(*cloudformation.ListStacksInput) (*cloudformation.ListStacksOutput, error)
+	DescribeStacksWithContext(aws.Context, *cloudformation.DescribeStacksInput, ...request.Option) (*cloudformation.DescribeStacksOutput, error)
+	ListStacksWithContext(aws.Context, *cloudformation.ListStacksInput, ...request.Option) (*cloudformation.ListStacksOutput, error)
 }
 
 type SourceInterface interface {
 	StackDescribeLister
-	DescribeInstances(*ec2.DescribeInstancesInput) (*ec2.DescribeInstancesOutput, error)
-	DescribeLoadBalancers(*elb.DescribeLoadBalancersInput) (*elb.DescribeLoadBalancersOutput, error)
-	DescribeNetworkInterfaces(input *ec2.DescribeNetworkInterfacesInput) (*ec2.DescribeNetworkInterfacesOutput, error)
+	DescribeInstancesWithContext(aws.Context, *ec2.DescribeInstancesInput, ...request.Option) (*ec2.DescribeInstancesOutput, error)
+	// DescribeLoadBalancerTagsWithContext describes the tags of classic ELBs.
+	DescribeLoadBalancerTagsWithContext(aws.Context, *elb.DescribeTagsInput, ...request.Option) (*elb.DescribeTagsOutput, error)
+	// DescribeLoadBalancerTagsV2WithContext describes the tags of
+	// application and network load balancers.
+	DescribeLoadBalancerTagsV2WithContext(aws.Context, *elbv2.DescribeTagsInput, ...request.Option) (*elbv2.DescribeTagsOutput, error)
+	DescribeLoadBalancersWithContext(aws.Context, *elb.DescribeLoadBalancersInput, ...request.Option) (*elb.DescribeLoadBalancersOutput, error)
+	// DescribeLoadBalancersV2WithContext describes application and network
+	// load balancers.
+	DescribeLoadBalancersV2WithContext(aws.Context, *elbv2.DescribeLoadBalancersInput, ...request.Option) (*elbv2.DescribeLoadBalancersOutput, error)
+	DescribeNetworkInterfacesWithContext(aws.Context, *ec2.DescribeNetworkInterfacesInput, ...request.Option) (*ec2.DescribeNetworkInterfacesOutput, error)
+	// DescribeInstanceHealthWithContext describes the health of the
+	// instances of a classic ELB.
+	DescribeInstanceHealthWithContext(aws.Context, *elb.DescribeInstanceHealthInput, ...request.Option) (*elb.DescribeInstanceHealthOutput, error)
+	// DescribeTargetGroupsWithContext and DescribeTargetHealthWithContext
+	// describe the target groups of application and network load balancers
+	// and the health of their targets.
+	DescribeTargetGroupsWithContext(aws.Context, *elbv2.DescribeTargetGroupsInput, ...request.Option) (*elbv2.DescribeTargetGroupsOutput, error)
+	DescribeTargetHealthWithContext(aws.Context, *elbv2.DescribeTargetHealthInput, ...request.Option) (*elbv2.DescribeTargetHealthOutput, error)
 }
 
 type TargetInterface interface {
 	StackDescribeLister
-	CreateStack(*cloudformation.CreateStackInput) (*cloudformation.CreateStackOutput, error)
-	ChangeResourceRecordSets(*route53.ChangeResourceRecordSetsInput) (*route53.ChangeResourceRecordSetsOutput, error)
-	DeleteStack(*cloudformation.DeleteStackInput) (*cloudformation.DeleteStackOutput, error)
-	ListResourceRecordSets(*route53.ListResourceRecordSetsInput) (*route53.ListResourceRecordSetsOutput, error)
-	UpdateStack(*cloudformation.UpdateStackInput) (*cloudformation.UpdateStackOutput, error)
+	CreateChangeSetWithContext(aws.Context, *cloudformation.CreateChangeSetInput, ...request.Option) (*cloudformation.CreateChangeSetOutput, error)
+	CreateStackWithContext(aws.Context, *cloudformation.CreateStackInput, ...request.Option) (*cloudformation.CreateStackOutput, error)
+	ChangeResourceRecordSetsWithContext(aws.Context, *route53.ChangeResourceRecordSetsInput, ...request.Option) (*route53.ChangeResourceRecordSetsOutput, error)
+	DeleteChangeSetWithContext(aws.Context, *cloudformation.DeleteChangeSetInput, ...request.Option) (*cloudformation.DeleteChangeSetOutput, error)
+	DeleteStackWithContext(aws.Context, *cloudformation.DeleteStackInput, ...request.Option) (*cloudformation.DeleteStackOutput, error)
+	DescribeChangeSetWithContext(aws.Context, *cloudformation.DescribeChangeSetInput, ...request.Option) (*cloudformation.DescribeChangeSetOutput, error)
+	ExecuteChangeSetWithContext(aws.Context, *cloudformation.ExecuteChangeSetInput, ...request.Option) (*cloudformation.ExecuteChangeSetOutput, error)
+	GetChangeWithContext(aws.Context, *route53.GetChangeInput, ...request.Option) (*route53.GetChangeOutput, error)
+	GetHostedZoneWithContext(aws.Context, *route53.GetHostedZoneInput, ...request.Option) (*route53.GetHostedZoneOutput, error)
+	GetHostedZoneLimitWithContext(aws.Context, *route53.GetHostedZoneLimitInput, ...request.Option) (*route53.GetHostedZoneLimitOutput, error)
+	// GetStackPolicyWithContext returns the stack policy of target stacks,
+	// compared before skipping unchanged updates.
+	GetStackPolicyWithContext(aws.Context, *cloudformation.GetStackPolicyInput, ...request.Option) (*cloudformation.GetStackPolicyOutput, error)
+	GetTemplateWithContext(aws.Context, *cloudformation.GetTemplateInput, ...request.Option) (*cloudformation.GetTemplateOutput, error)
+	// ListHostedZonesByNameWithContext resolves the target hosted zone ID by
+	// name.
+	ListHostedZonesByNameWithContext(aws.Context, *route53.ListHostedZonesByNameInput, ...request.Option) (*route53.ListHostedZonesByNameOutput, error)
+	ListChangeSetsWithContext(aws.Context, *cloudformation.ListChangeSetsInput, ...request.Option) (*cloudformation.ListChangeSetsOutput, error)
+	ListResourceRecordSetsWithContext(aws.Context, *route53.ListResourceRecordSetsInput, ...request.Option) (*route53.ListResourceRecordSetsOutput, error)
+	ListStackResourcesWithContext(aws.Context, *cloudformation.ListStackResourcesInput, ...request.Option) (*cloudformation.ListStackResourcesOutput, error)
+	// PutObjectWithContext uploads target stack templates exceeding the
+	// inline template size limit of CloudFormation.
+	PutObjectWithContext(aws.Context, *s3.PutObjectInput, ...request.Option) (*s3.PutObjectOutput, error)
+	UpdateStackWithContext(aws.Context, *cloudformation.UpdateStackInput, ...request.Option) (*cloudformation.UpdateStackOutput, error)
+}
+
+// ParentInterface is the client of the account owning the parent hosted zone
+// the target hosted zone is delegated from.
+type ParentInterface interface {
+	ChangeResourceRecordSetsWithContext(aws.Context, *route53.ChangeResourceRecordSetsInput, ...request.Option) (*route53.ChangeResourceRecordSetsOutput, error)
+	ListResourceRecordSetsWithContext(aws.Context, *route53.ListResourceRecordSetsInput, ...request.Option) (*route53.ListResourceRecordSetsOutput, error)
+}
+
+// EventQueueInterface is the client of the SQS queue CloudFormation stack
+// events are received from.
+type EventQueueInterface interface {
+	DeleteMessageWithContext(aws.Context, *sqs.DeleteMessageInput, ...request.Option) (*sqs.DeleteMessageOutput, error)
+	ReceiveMessageWithContext(aws.Context, *sqs.ReceiveMessageInput, ...request.Option) (*sqs.ReceiveMessageOutput, error)
 }
 
 type Clients struct {
@@ -45,27 +130,124 @@ type Clients struct {
 	ec2iface.EC2API
 	elbiface.ELBAPI
 	*route53.Route53
+
+	// ELBV2 is not embedded since its methods collide with the ones of
+	// elbiface.ELBAPI.
+	ELBV2 elbv2iface.ELBV2API
+	S3    s3iface.S3API
+	SQS   sqsiface.SQSAPI
+	STS   stsiface.STSAPI
 }
 
-func NewClients(config *Config) *Clients {
-	s := newSession(config)
+func NewClients(config *Config) (*Clients, error) {
+	s, err := newSession(config)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
 
-	return &Clients{
-		cloudformation.New(s),
-		ec2.New(s),
-		elb.New(s),
-		route53.New(s),
+	cloudFormationClient := cloudformation.New(s)
+	limit(&cloudFormationClient.Handlers, config.Limits.CloudFormation, config.Limits.Concurrency.CloudFormation)
+	ec2Client := ec2.New(s)
+	limit(&ec2Client.Handlers, config.Limits.EC2, config.Limits.Concurrency.EC2)
+	elbClient := elb.New(s)
+	limit(&elbClient.Handlers, config.Limits.ELB, config.Limits.Concurrency.ELB)
+	elbv2Client := elbv2.New(s)
+	limit(&elbv2Client.Handlers, config.Limits.ELB, config.Limits.Concurrency.ELB)
+	var route53Cfgs []*aws.Config
+	if cfg := route53Config(config); cfg != nil {
+		route53Cfgs = append(route53Cfgs, cfg)
 	}
+	route53Client := route53.New(s, route53Cfgs...)
+	limit(&route53Client.Handlers, config.Limits.Route53, config.Limits.Concurrency.Route53)
+	var s3Cfgs []*aws.Config
+	if config.S3Region != "" {
+		s3Cfgs = append(s3Cfgs, aws.NewConfig().WithRegion(config.S3Region))
+	}
+	s3Client := s3.New(s, s3Cfgs...)
+	sqsClient := sqs.New(s)
+	stsClient := sts.New(s)
+
+	return &Clients{
+		CloudFormation: cloudFormationClient,
+		EC2API:         ec2Client,
+		ELBAPI:         elbClient,
+		Route53:        route53Client,
+
+		ELBV2: elbv2Client,
+		S3:    s3Client,
+		SQS:   sqsClient,
+		STS:   stsClient,
+	}, nil
+}
+
+// DescribeLoadBalancersV2WithContext calls DescribeLoadBalancers of the elbv2
+// API.
+func (c *Clients) DescribeLoadBalancersV2WithContext(ctx aws.Context, input *elbv2.DescribeLoadBalancersInput, opts ...request.Option) (*elbv2.DescribeLoadBalancersOutput, error) {
+	return c.ELBV2.DescribeLoadBalancersWithContext(ctx, input, opts...)
 }
 
-func newSession(config *Config) *session.Session {
+// DescribeLoadBalancerTagsWithContext calls DescribeTags of the classic ELB
+// API, which collides with DescribeTags of the EC2 API.
+func (c *Clients) DescribeLoadBalancerTagsWithContext(ctx aws.Context, input *elb.DescribeTagsInput, opts ...request.Option) (*elb.DescribeTagsOutput, error) {
+	return c.ELBAPI.DescribeTagsWithContext(ctx, input, opts...)
+}
+
+// DescribeLoadBalancerTagsV2WithContext calls DescribeTags of the elbv2 API.
+func (c *Clients) DescribeLoadBalancerTagsV2WithContext(ctx aws.Context, input *elbv2.DescribeTagsInput, opts ...request.Option) (*elbv2.DescribeTagsOutput, error) {
+	return c.ELBV2.DescribeTagsWithContext(ctx, input, opts...)
+}
+
+// DescribeTargetGroupsWithContext calls DescribeTargetGroups of the elbv2
+// API.
+func (c *Clients) DescribeTargetGroupsWithContext(ctx aws.Context, input *elbv2.DescribeTargetGroupsInput, opts ...request.Option) (*elbv2.DescribeTargetGroupsOutput, error) {
+	return c.ELBV2.DescribeTargetGroupsWithContext(ctx, input, opts...)
+}
+
+// DescribeTargetHealthWithContext calls DescribeTargetHealth of the elbv2
+// API.
+func (c *Clients) DescribeTargetHealthWithContext(ctx aws.Context, input *elbv2.DescribeTargetHealthInput, opts ...request.Option) (*elbv2.DescribeTargetHealthOutput, error) {
+	return c.ELBV2.DescribeTargetHealthWithContext(ctx, input, opts...)
+}
+
+// PutObjectWithContext calls PutObject of the S3 API.
+func (c *Clients) PutObjectWithContext(ctx aws.Context, input *s3.PutObjectInput, opts ...request.Option) (*s3.PutObjectOutput, error) {
+	return c.S3.PutObjectWithContext(ctx, input, opts...)
+}
+
+// DeleteMessageWithContext calls DeleteMessage of the SQS API.
+func (c *Clients) DeleteMessageWithContext(ctx aws.Context, input *sqs.DeleteMessageInput, opts ...request.Option) (*sqs.DeleteMessageOutput, error) {
+	return c.SQS.DeleteMessageWithContext(ctx, input, opts...)
+}
+
+// ReceiveMessageWithContext calls ReceiveMessage of the SQS API.
+func (c *Clients) ReceiveMessageWithContext(ctx aws.Context, input *sqs.ReceiveMessageInput, opts ...request.Option) (*sqs.ReceiveMessageOutput, error) {
+	return c.SQS.ReceiveMessageWithContext(ctx, input, opts...)
+}
+
+func newSession(config *Config) (*session.Session, error) {
+	c, err := newCredentials(config)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
 	awsCfg := &aws.Config{
-		Credentials: credentials.NewStaticCredentials(config.AccessKeyID, config.AccessKeySecret, config.SessionToken),
+		Credentials: c,
 		Region:      aws.String(config.Region),
 	}
 	s, err := session.NewSession(awsCfg)
 	if err != nil {
-		panic(err)
+		return nil, microerror.Mask(err)
 	}
-	return s
+	if config.RoleARN != "" {
+		awsCfg.Credentials = stscreds.NewCredentials(s, config.RoleARN)
+		s, err = session.NewSession(awsCfg)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+	// The handlers of the session are copied to every client created from
+	// it.
+	instrument(&s.Handlers)
+
+	return s, nil
 }
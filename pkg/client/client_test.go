@@ -0,0 +1,43 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+func TestNewClients_S3Region(t *testing.T) {
+	tcs := []struct {
+		name           string
+		s3Region       string
+		expectedRegion string
+	}{
+		{
+			name:           "case 0: S3 client in the client region",
+			expectedRegion: "eu-central-1",
+		},
+		{
+			name:           "case 1: S3 client in another region",
+			s3Region:       "us-east-1",
+			expectedRegion: "us-east-1",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			cl, err := NewClients(&Config{
+				Region:   "eu-central-1",
+				S3Region: tc.s3Region,
+			})
+			if err != nil {
+				t.Fatalf("NewClients: %v", err)
+			}
+
+			region := aws.StringValue(cl.S3.(*s3.S3).Config.Region)
+			if region != tc.expectedRegion {
+				t.Errorf("expected S3 region %#q, got %#q", tc.expectedRegion, region)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package client
+
+import (
+	"os"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	// DefaultCredentialsProfile is the profile read from the credentials
+	// file when none is given.
+	DefaultCredentialsProfile = "default"
+)
+
+// newCredentials returns the credentials of the account. The profile of the
+// credentials file, e.g. rendered by a secret manager, takes precedence over
+// the inline keys when set. The file must exist and hold the profile.
+func newCredentials(config *Config) (*credentials.Credentials, error) {
+	if config.CredentialsFile == "" {
+		return credentials.NewStaticCredentials(config.AccessKeyID, config.AccessKeySecret, config.SessionToken), nil
+	}
+
+	profile := config.CredentialsProfile
+	if profile == "" {
+		profile = DefaultCredentialsProfile
+	}
+
+	_, err := os.Stat(config.CredentialsFile)
+	if os.IsNotExist(err) {
+		return nil, microerror.Maskf(invalidConfigError, "credentials file %#q does not exist", config.CredentialsFile)
+	} else if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	c := credentials.NewSharedCredentials(config.CredentialsFile, profile)
+	_, err = c.Get()
+	if err != nil {
+		return nil, microerror.Maskf(invalidConfigError, "credentials file %#q does not hold valid credentials for profile %#q: %s", config.CredentialsFile, profile, err.Error())
+	}
+
+	return c, nil
+}
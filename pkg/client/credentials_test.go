@@ -0,0 +1,94 @@
+package client
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewCredentials(t *testing.T) {
+	dir, err := ioutil.TempDir("", "credentials")
+	if err != nil {
+		t.Fatalf("ioutil.TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "credentials")
+	content := "[default]\naws_access_key_id = defaultKey\naws_secret_access_key = defaultSecret\n\n[target]\naws_access_key_id = targetKey\naws_secret_access_key = targetSecret\n"
+	err = ioutil.WriteFile(path, []byte(content), 0600)
+	if err != nil {
+		t.Fatalf("ioutil.WriteFile: %v", err)
+	}
+
+	tcs := []struct {
+		name          string
+		config        Config
+		expectedKeyID string
+		errorMatcher  func(error) bool
+	}{
+		{
+			name: "case 0: inline keys",
+			config: Config{
+				AccessKeyID:     "inlineKey",
+				AccessKeySecret: "inlineSecret",
+			},
+			expectedKeyID: "inlineKey",
+		},
+		{
+			name: "case 1: default profile of the credentials file",
+			config: Config{
+				CredentialsFile: path,
+			},
+			expectedKeyID: "defaultKey",
+		},
+		{
+			name: "case 2: credentials file takes precedence over inline keys",
+			config: Config{
+				AccessKeyID:        "inlineKey",
+				AccessKeySecret:    "inlineSecret",
+				CredentialsFile:    path,
+				CredentialsProfile: "target",
+			},
+			expectedKeyID: "targetKey",
+		},
+		{
+			name: "case 3: missing credentials file",
+			config: Config{
+				CredentialsFile: filepath.Join(dir, "missing"),
+			},
+			errorMatcher: IsInvalidConfig,
+		},
+		{
+			name: "case 4: missing profile",
+			config: Config{
+				CredentialsFile:    path,
+				CredentialsProfile: "missing",
+			},
+			errorMatcher: IsInvalidConfig,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := newCredentials(&tc.config)
+			if tc.errorMatcher != nil {
+				if !tc.errorMatcher(err) {
+					t.Fatalf("expected matching error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("newCredentials: %v", err)
+			}
+
+			v, err := c.Get()
+			if err != nil {
+				t.Fatalf("c.Get: %v", err)
+			}
+			if v.AccessKeyID != tc.expectedKeyID {
+				t.Errorf("expected access key ID %#q, got %#q", tc.expectedKeyID, v.AccessKeyID)
+			}
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package client
+
+import "github.com/giantswarm/microerror"
+
+var invalidConfigError = &microerror.Error{
+	Kind: "invalidConfigError",
+}
+
+// IsInvalidConfig asserts invalidConfigError.
+func IsInvalidConfig(err error) bool {
+	return microerror.Cause(err) == invalidConfigError
+}
+
+var writableCredentialsError = &microerror.Error{
+	Kind: "writableCredentialsError",
+}
+
+// IsWritableCredentials asserts writableCredentialsError.
+func IsWritableCredentials(err error) bool {
+	return microerror.Cause(err) == writableCredentialsError
+}
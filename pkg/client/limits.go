@@ -0,0 +1,143 @@
+package client
+
+import (
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+// ServiceLimits are the maximum request rates per AWS service in requests
+// per second, e.g. to keep CloudFormation calls below its lower API limits
+// than EC2 or ELB, and the maximum number of concurrent requests per
+// service. Zero disables the limit of a service. Each client enforces its own
+// limits.
+type ServiceLimits struct {
+	CloudFormation float64
+	EC2            float64
+	// ELB limits the classic and the elbv2 API independently.
+	ELB     float64
+	Route53 float64
+
+	Concurrency ServiceConcurrency
+}
+
+// ServiceConcurrency are the maximum numbers of requests per AWS service in
+// flight at the same time. A request holds its slot from its first send
+// until it completes, including its retries.
+type ServiceConcurrency struct {
+	CloudFormation int
+	EC2            int
+	// ELB limits the classic and the elbv2 API independently.
+	ELB     int
+	Route53 int
+}
+
+// limiter paces calls so they are at least interval apart.
+type limiter struct {
+	interval time.Duration
+
+	mutex sync.Mutex
+	next  time.Time
+
+	// now and sleep are replaced in tests.
+	now   func() time.Time
+	sleep func(time.Duration)
+}
+
+func newLimiter(requestsPerSecond float64) *limiter {
+	l := &limiter{
+		interval: time.Duration(float64(time.Second) / requestsPerSecond),
+
+		now:   time.Now,
+		sleep: time.Sleep,
+	}
+
+	return l
+}
+
+// Wait blocks until the next call is allowed.
+func (l *limiter) Wait() {
+	l.mutex.Lock()
+	now := l.now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	wait := l.next.Sub(now)
+	l.next = l.next.Add(l.interval)
+	l.mutex.Unlock()
+
+	if wait > 0 {
+		l.sleep(wait)
+	}
+}
+
+// inFlightLimiter caps the number of requests in flight.
+type inFlightLimiter struct {
+	slots chan struct{}
+
+	mutex sync.Mutex
+	held  map[*request.Request]bool
+}
+
+func newInFlightLimiter(maxInFlight int) *inFlightLimiter {
+	l := &inFlightLimiter{
+		slots: make(chan struct{}, maxInFlight),
+		held:  map[*request.Request]bool{},
+	}
+
+	return l
+}
+
+// Acquire blocks until the request holds a slot. Retries of a request
+// holding a slot do not block. When the context of the request is done
+// first, it returns without a slot and the request fails when it is sent.
+func (l *inFlightLimiter) Acquire(r *request.Request) {
+	l.mutex.Lock()
+	held := l.held[r]
+	l.mutex.Unlock()
+	if held {
+		return
+	}
+
+	select {
+	case l.slots <- struct{}{}:
+	case <-r.Context().Done():
+		return
+	}
+
+	l.mutex.Lock()
+	l.held[r] = true
+	l.mutex.Unlock()
+}
+
+// Release frees the slot of the request, if it holds one.
+func (l *inFlightLimiter) Release(r *request.Request) {
+	l.mutex.Lock()
+	held := l.held[r]
+	delete(l.held, r)
+	l.mutex.Unlock()
+
+	if held {
+		<-l.slots
+	}
+}
+
+// limit makes every request sent with the given handlers, including
+// retries, wait for the limiter of the service, and caps the requests of the
+// service in flight. A request waits for its slot before it is paced. Each
+// limit is a no-op when disabled.
+func limit(handlers *request.Handlers, requestsPerSecond float64, maxInFlight int) {
+	if requestsPerSecond > 0 {
+		l := newLimiter(requestsPerSecond)
+		handlers.Send.PushFront(func(*request.Request) {
+			l.Wait()
+		})
+	}
+
+	if maxInFlight > 0 {
+		l := newInFlightLimiter(maxInFlight)
+		handlers.Send.PushFront(l.Acquire)
+		handlers.Complete.PushBack(l.Release)
+	}
+}
@@ -0,0 +1,143 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+func TestLimiter_Wait(t *testing.T) {
+	tcs := []struct {
+		name              string
+		requestsPerSecond float64
+		expectedSleeps    []time.Duration
+	}{
+		{
+			name:              "case 0: CloudFormation at 2 requests per second",
+			requestsPerSecond: 2,
+			expectedSleeps:    []time.Duration{500 * time.Millisecond, time.Second},
+		},
+		{
+			name:              "case 1: EC2 at 20 requests per second",
+			requestsPerSecond: 20,
+			expectedSleeps:    []time.Duration{50 * time.Millisecond, 100 * time.Millisecond},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+			var sleeps []time.Duration
+
+			l := newLimiter(tc.requestsPerSecond)
+			l.now = func() time.Time { return now }
+			l.sleep = func(d time.Duration) { sleeps = append(sleeps, d) }
+
+			for i := 0; i < 3; i++ {
+				l.Wait()
+			}
+
+			if len(sleeps) != len(tc.expectedSleeps) {
+				t.Fatalf("expected sleeps %v, got %v", tc.expectedSleeps, sleeps)
+			}
+			for i := range sleeps {
+				if sleeps[i] != tc.expectedSleeps[i] {
+					t.Errorf("expected sleeps %v, got %v", tc.expectedSleeps, sleeps)
+				}
+			}
+		})
+	}
+}
+
+func TestLimiter_WaitAfterIdle(t *testing.T) {
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	var sleeps []time.Duration
+
+	l := newLimiter(1)
+	l.now = func() time.Time { return now }
+	l.sleep = func(d time.Duration) { sleeps = append(sleeps, d) }
+
+	l.Wait()
+	now = now.Add(5 * time.Second)
+	l.Wait()
+
+	if len(sleeps) != 0 {
+		t.Errorf("expected no sleep after being idle, got %v", sleeps)
+	}
+}
+
+func TestNewClients_Limits(t *testing.T) {
+	unlimited, err := NewClients(&Config{
+		Region: "eu-central-1",
+	})
+	if err != nil {
+		t.Fatalf("NewClients: %v", err)
+	}
+	limited, err := NewClients(&Config{
+		Region: "eu-central-1",
+		Limits: ServiceLimits{
+			CloudFormation: 2,
+			Route53:        5,
+
+			Concurrency: ServiceConcurrency{
+				Route53: 4,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewClients: %v", err)
+	}
+
+	if limited.CloudFormation.Handlers.Send.Len() != unlimited.CloudFormation.Handlers.Send.Len()+1 {
+		t.Errorf("expected CloudFormation requests to be limited")
+	}
+	// The Route53 requests are paced and their requests in flight capped.
+	if limited.Route53.Handlers.Send.Len() != unlimited.Route53.Handlers.Send.Len()+2 {
+		t.Errorf("expected Route53 requests to be limited")
+	}
+	if limited.Route53.Handlers.Complete.Len() != unlimited.Route53.Handlers.Complete.Len()+1 {
+		t.Errorf("expected Route53 requests in flight to be limited")
+	}
+}
+
+func TestInFlightLimiter(t *testing.T) {
+	l := newInFlightLimiter(1)
+	first, second := &request.Request{}, &request.Request{}
+
+	l.Acquire(first)
+	// A retry of the request holding the slot must not block.
+	l.Acquire(first)
+
+	acquired := make(chan struct{})
+	go func() {
+		l.Acquire(second)
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatalf("expected second request to wait for a slot")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	l.Release(first)
+
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatalf("expected second request to get the released slot")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	canceled := &request.Request{}
+	canceled.SetContext(ctx)
+	l.Acquire(canceled)
+	l.Release(canceled)
+
+	if len(l.slots) != 1 {
+		t.Errorf("expected the slot of the second request to be held, got %d slots in use", len(l.slots))
+	}
+}
@@ -0,0 +1,57 @@
+package client
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+
+	"github.com/giantswarm/route53-manager/pkg/metrics"
+)
+
+const (
+	// statusSuccess is the status label of AWS requests without error.
+	// Failed requests are labelled with their AWS error code, e.g.
+	// `Throttling`, or `error` for errors without code.
+	statusSuccess = "success"
+	statusError   = "error"
+)
+
+var (
+	awsRequestsTotal   = metrics.NewCounterVec("aws_requests_total", "Number of AWS API request attempts, by service, operation and status.", "service", "operation", "status")
+	awsRequestDuration = metrics.NewHistogramVec("aws_request_duration_seconds", "Latency of AWS API request attempts in seconds, by service and operation.", metrics.DefBuckets, "service", "operation")
+)
+
+func init() {
+	metrics.DefaultRegistry.MustRegister(awsRequestsTotal, awsRequestDuration)
+}
+
+// instrument makes every request attempt sent with the given handlers,
+// including retries, count towards the AWS request metrics. Counting attempts
+// instead of requests makes throttled attempts visible.
+func instrument(handlers *request.Handlers) {
+	handlers.CompleteAttempt.PushBack(func(r *request.Request) {
+		operation := ""
+		if r.Operation != nil {
+			operation = r.Operation.Name
+		}
+
+		awsRequestsTotal.Inc(r.ClientInfo.ServiceName, operation, requestStatus(r.Error))
+		if !r.AttemptTime.IsZero() {
+			awsRequestDuration.Observe(time.Since(r.AttemptTime).Seconds(), r.ClientInfo.ServiceName, operation)
+		}
+	})
+}
+
+// requestStatus returns the status label of a request attempt with the given
+// error.
+func requestStatus(err error) string {
+	if err == nil {
+		return statusSuccess
+	}
+	if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() != "" {
+		return awsErr.Code()
+	}
+
+	return statusError
+}
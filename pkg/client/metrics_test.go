@@ -0,0 +1,67 @@
+package client
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+func TestInstrument(t *testing.T) {
+	awsRequestsTotal.Reset()
+	awsRequestDuration.Reset()
+
+	var handlers request.Handlers
+	instrument(&handlers)
+
+	newRequest := func(operation string, err error) *request.Request {
+		return &request.Request{
+			ClientInfo:  request.ClientInfo{ServiceName: "cloudformation"},
+			Operation:   &request.Operation{Name: operation},
+			Error:       err,
+			AttemptTime: time.Now().Add(-time.Second),
+		}
+	}
+
+	handlers.CompleteAttempt.Run(newRequest("DescribeStacks", nil))
+	handlers.CompleteAttempt.Run(newRequest("DescribeStacks", awserr.New("Throttling", "Rate exceeded", nil)))
+	handlers.CompleteAttempt.Run(newRequest("DescribeStacks", nil))
+	handlers.CompleteAttempt.Run(newRequest("CreateStack", errors.New("connection reset")))
+
+	tcs := []struct {
+		name          string
+		labelValues   []string
+		expectedValue float64
+	}{
+		{
+			name:          "case 0: successful attempts",
+			labelValues:   []string{"cloudformation", "DescribeStacks", "success"},
+			expectedValue: 2,
+		},
+		{
+			name:          "case 1: throttled attempt",
+			labelValues:   []string{"cloudformation", "DescribeStacks", "Throttling"},
+			expectedValue: 1,
+		},
+		{
+			name:          "case 2: attempt failed without error code",
+			labelValues:   []string{"cloudformation", "CreateStack", "error"},
+			expectedValue: 1,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			v := awsRequestsTotal.Value(tc.labelValues...)
+			if v != tc.expectedValue {
+				t.Errorf("expected %v requests %v, got %v", tc.labelValues, tc.expectedValue, v)
+			}
+		})
+	}
+
+	if c := awsRequestDuration.Count("cloudformation", "DescribeStacks"); c != 3 {
+		t.Errorf("expected 3 DescribeStacks latency observations, got %d", c)
+	}
+}
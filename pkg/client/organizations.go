@@ -0,0 +1,166 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/service/organizations"
+	"github.com/giantswarm/microerror"
+)
+
+// OrganizationsInterface is the client of the management account of an AWS
+// organization the source accounts are discovered with.
+type OrganizationsInterface interface {
+	DescribeOrganization(*organizations.DescribeOrganizationInput) (*organizations.DescribeOrganizationOutput, error)
+	ListAccounts(*organizations.ListAccountsInput) (*organizations.ListAccountsOutput, error)
+}
+
+// OrganizationConfig is the configuration of the discovery of the source
+// accounts among the member accounts of an AWS organization.
+type OrganizationConfig struct {
+	// Client lists the member accounts, e.g. returned by
+	// NewOrganizationsClient.
+	Client OrganizationsInterface
+	// Config is the configuration the clients of the member accounts are
+	// created with. Its credentials must be allowed to assume MemberRole in
+	// every member account, its RoleARN is overridden.
+	Config Config
+	// Partition is the AWS partition of the organization, e.g. `aws`.
+	Partition string
+	// MemberRole is the name of the role assumed in every member account.
+	MemberRole string
+	// SourceAccountID is the ID of the account of the main source client,
+	// e.g. returned by Clients.AccountID. It is excluded, since its clusters
+	// are synced by the main source client already.
+	SourceAccountID string
+	// ExcludedAccountIDs are the IDs of further member accounts which are no
+	// source accounts. The management account of the organization is always
+	// excluded.
+	ExcludedAccountIDs []string
+}
+
+// NewOrganizationsClient returns the Organizations client of the management
+// account whose role is given by config.RoleARN.
+func NewOrganizationsClient(config *Config) (*organizations.Organizations, error) {
+	s, err := newSession(config)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return organizations.New(s), nil
+}
+
+// RolePartition returns the partition of the given role ARN, e.g. `aws` for
+// `arn:aws:iam::123456789012:role/route53-manager`.
+func RolePartition(roleARN string) (string, error) {
+	a, err := arn.Parse(roleARN)
+	if err != nil || a.Service != "iam" {
+		return "", microerror.Maskf(invalidConfigError, "role %#q must be an IAM role ARN", roleARN)
+	}
+
+	return a.Partition, nil
+}
+
+// NewOrganizationSourceClients returns the clients of the active member
+// accounts of the organization, assuming the member role in each of them.
+// The role is assumed right away, so a member account without the role fails
+// the creation of the clients instead of the first sync.
+func NewOrganizationSourceClients(c OrganizationConfig) ([]*Clients, error) {
+	configs, err := memberConfigs(c)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var clients []*Clients
+	for i := range configs {
+		cl, err := NewClients(&configs[i])
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		_, err = cl.AccountID()
+		if err != nil {
+			return nil, microerror.Maskf(invalidConfigError, "role %#q must be assumable: %s", configs[i].RoleARN, err)
+		}
+		clients = append(clients, cl)
+	}
+
+	return clients, nil
+}
+
+// memberConfigs returns the client configurations of the active member
+// accounts of the organization which are neither excluded, the management
+// account nor the account of the main source client.
+func memberConfigs(c OrganizationConfig) ([]Config, error) {
+	if c.Client == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Client must not be empty", c)
+	}
+	if c.Partition == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Partition must not be empty", c)
+	}
+	if c.MemberRole == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.MemberRole must not be empty", c)
+	}
+	if c.SourceAccountID == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.SourceAccountID must not be empty", c)
+	}
+
+	o, err := c.Client.DescribeOrganization(&organizations.DescribeOrganizationInput{})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	accountIDs, err := ListActiveAccountIDs(c.Client)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	excluded := map[string]bool{
+		c.SourceAccountID: true,
+	}
+	if o.Organization != nil {
+		excluded[aws.StringValue(o.Organization.MasterAccountId)] = true
+	}
+	for _, id := range c.ExcludedAccountIDs {
+		excluded[id] = true
+	}
+
+	var configs []Config
+	for _, id := range accountIDs {
+		if excluded[id] {
+			continue
+		}
+
+		config := c.Config
+		config.RoleARN = fmt.Sprintf("arn:%s:iam::%s:role/%s", c.Partition, id, c.MemberRole)
+		configs = append(configs, config)
+	}
+
+	return configs, nil
+}
+
+// ListActiveAccountIDs returns the IDs of the active member accounts of the
+// organization. Suspended accounts are omitted.
+func ListActiveAccountIDs(cl OrganizationsInterface) ([]string, error) {
+	var ids []string
+	input := &organizations.ListAccountsInput{}
+	for {
+		output, err := cl.ListAccounts(input)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, a := range output.Accounts {
+			if aws.StringValue(a.Status) != organizations.AccountStatusActive {
+				continue
+			}
+			ids = append(ids, aws.StringValue(a.Id))
+		}
+
+		if output.NextToken == nil || *output.NextToken == "" {
+			break
+		}
+		input.NextToken = output.NextToken
+	}
+
+	return ids, nil
+}
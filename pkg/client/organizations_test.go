@@ -0,0 +1,235 @@
+package client
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/organizations"
+)
+
+type listAccountsMock struct {
+	// managementAccountID is returned by DescribeOrganization.
+	managementAccountID string
+	// pages are returned page by page by ListAccounts.
+	pages [][]*organizations.Account
+}
+
+func (m *listAccountsMock) DescribeOrganization(input *organizations.DescribeOrganizationInput) (*organizations.DescribeOrganizationOutput, error) {
+	output := &organizations.DescribeOrganizationOutput{
+		Organization: &organizations.Organization{
+			MasterAccountId: aws.String(m.managementAccountID),
+		},
+	}
+
+	return output, nil
+}
+
+// ListAccounts uses the index of the next page as token.
+func (m *listAccountsMock) ListAccounts(input *organizations.ListAccountsInput) (*organizations.ListAccountsOutput, error) {
+	var i int
+	if input.NextToken != nil {
+		var err error
+		i, err = strconv.Atoi(*input.NextToken)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	output := &organizations.ListAccountsOutput{
+		Accounts: m.pages[i],
+	}
+	if i+1 < len(m.pages) {
+		output.NextToken = aws.String(strconv.Itoa(i + 1))
+	}
+
+	return output, nil
+}
+
+func newAccount(id, status string) *organizations.Account {
+	return &organizations.Account{
+		Id:     aws.String(id),
+		Status: aws.String(status),
+	}
+}
+
+func TestMemberConfigs(t *testing.T) {
+	tcs := []struct {
+		name             string
+		pages            [][]*organizations.Account
+		sourceAccountID  string
+		excluded         []string
+		memberRole       string
+		expectedRoleARNs []string
+		errorMatcher     func(error) bool
+	}{
+		{
+			name: "case 0: active accounts of all pages",
+			pages: [][]*organizations.Account{
+				{
+					newAccount("111111111111", organizations.AccountStatusActive),
+					newAccount("222222222222", organizations.AccountStatusSuspended),
+				},
+				{
+					newAccount("333333333333", organizations.AccountStatusActive),
+				},
+			},
+			sourceAccountID: "999999999999",
+			memberRole:      "route53-manager",
+			expectedRoleARNs: []string{
+				"arn:aws:iam::111111111111:role/route53-manager",
+				"arn:aws:iam::333333333333:role/route53-manager",
+			},
+		},
+		{
+			name: "case 1: excluded accounts",
+			pages: [][]*organizations.Account{
+				{
+					newAccount("111111111111", organizations.AccountStatusActive),
+					newAccount("333333333333", organizations.AccountStatusActive),
+				},
+			},
+			excluded:        []string{"111111111111"},
+			sourceAccountID: "999999999999",
+			memberRole:      "route53-manager",
+			expectedRoleARNs: []string{
+				"arn:aws:iam::333333333333:role/route53-manager",
+			},
+		},
+		{
+			name: "case 2: organization without other accounts",
+			pages: [][]*organizations.Account{
+				{
+					newAccount("111111111111", organizations.AccountStatusActive),
+				},
+			},
+			excluded:        []string{"111111111111"},
+			sourceAccountID: "999999999999",
+			memberRole:      "route53-manager",
+		},
+		{
+			name: "case 3: missing member role",
+			pages: [][]*organizations.Account{
+				{
+					newAccount("111111111111", organizations.AccountStatusActive),
+				},
+			},
+			sourceAccountID: "999999999999",
+			errorMatcher:    IsInvalidConfig,
+		},
+		{
+			name: "case 4: source and management accounts",
+			pages: [][]*organizations.Account{
+				{
+					newAccount("000000000000", organizations.AccountStatusActive),
+					newAccount("111111111111", organizations.AccountStatusActive),
+					newAccount("333333333333", organizations.AccountStatusActive),
+				},
+			},
+			sourceAccountID: "111111111111",
+			memberRole:      "route53-manager",
+			expectedRoleARNs: []string{
+				"arn:aws:iam::333333333333:role/route53-manager",
+			},
+		},
+		{
+			name: "case 5: missing source account",
+			pages: [][]*organizations.Account{
+				{
+					newAccount("333333333333", organizations.AccountStatusActive),
+				},
+			},
+			memberRole:   "route53-manager",
+			errorMatcher: IsInvalidConfig,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			c := OrganizationConfig{
+				Client: &listAccountsMock{managementAccountID: "000000000000", pages: tc.pages},
+				Config: Config{
+					AccessKeyID:     "accessKeyID",
+					AccessKeySecret: "accessKeySecret",
+					Region:          "eu-west-1",
+				},
+				Partition:          "aws",
+				MemberRole:         tc.memberRole,
+				SourceAccountID:    tc.sourceAccountID,
+				ExcludedAccountIDs: tc.excluded,
+			}
+
+			configs, err := memberConfigs(c)
+			if tc.errorMatcher != nil {
+				if !tc.errorMatcher(err) {
+					t.Fatalf("expected matching error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("memberConfigs: %v", err)
+			}
+
+			var roleARNs []string
+			for _, config := range configs {
+				roleARNs = append(roleARNs, config.RoleARN)
+				if config.Region != c.Config.Region {
+					t.Errorf("expected region %#q, got %#q", c.Config.Region, config.Region)
+				}
+			}
+			if !reflect.DeepEqual(tc.expectedRoleARNs, roleARNs) {
+				t.Errorf("expected role ARNs %v, got %v", tc.expectedRoleARNs, roleARNs)
+			}
+		})
+	}
+}
+
+func TestRolePartition(t *testing.T) {
+	tcs := []struct {
+		name         string
+		roleARN      string
+		expected     string
+		errorMatcher func(error) bool
+	}{
+		{
+			name:     "case 0: commercial partition",
+			roleARN:  "arn:aws:iam::123456789012:role/organization-reader",
+			expected: "aws",
+		},
+		{
+			name:     "case 1: China partition",
+			roleARN:  "arn:aws-cn:iam::123456789012:role/organization-reader",
+			expected: "aws-cn",
+		},
+		{
+			name:         "case 2: no IAM ARN",
+			roleARN:      "arn:aws:s3:::bucket",
+			errorMatcher: IsInvalidConfig,
+		},
+		{
+			name:         "case 3: no ARN",
+			roleARN:      "organization-reader",
+			errorMatcher: IsInvalidConfig,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			partition, err := RolePartition(tc.roleARN)
+			if tc.errorMatcher != nil {
+				if !tc.errorMatcher(err) {
+					t.Fatalf("expected matching error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RolePartition: %v", err)
+			}
+
+			if partition != tc.expected {
+				t.Errorf("expected partition %#q, got %#q", tc.expected, partition)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package client
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/giantswarm/microerror"
+)
+
+// AccountID returns the ID of the AWS account the credentials of the clients
+// belong to.
+func (c *Clients) AccountID() (string, error) {
+	output, err := c.STS.GetCallerIdentity(&sts.GetCallerIdentityInput{})
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return aws.StringValue(output.Account), nil
+}
+
+// VerifySourceAccount returns a writableCredentialsError when the credentials
+// of the clients belong to the account of the target clients, e.g. because
+// the source and target credentials were swapped. Source and target must be
+// separate accounts then, as the target credentials are allowed to write.
+func (c *Clients) VerifySourceAccount(target *Clients) error {
+	sourceAccountID, err := c.AccountID()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	targetAccountID, err := target.AccountID()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if sourceAccountID == targetAccountID {
+		return microerror.Maskf(writableCredentialsError, "source credentials belong to the target account %#q", targetAccountID)
+	}
+
+	return nil
+}
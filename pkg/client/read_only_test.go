@@ -0,0 +1,91 @@
+package client
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/aws/aws-sdk-go/service/sts/stsiface"
+)
+
+type callerIdentityMock struct {
+	stsiface.STSAPI
+
+	accountID string
+}
+
+func (m *callerIdentityMock) GetCallerIdentity(input *sts.GetCallerIdentityInput) (*sts.GetCallerIdentityOutput, error) {
+	if m.accountID == "" {
+		return nil, awserr.New("ExpiredToken", "The security token included in the request is expired", nil)
+	}
+
+	return &sts.GetCallerIdentityOutput{Account: aws.String(m.accountID)}, nil
+}
+
+func TestClients_VerifySourceAccount(t *testing.T) {
+	tcs := []struct {
+		name            string
+		sourceAccountID string
+		targetAccountID string
+		errorMatcher    func(error) bool
+	}{
+		{
+			name:            "case 0: separate accounts",
+			sourceAccountID: "111111111111",
+			targetAccountID: "222222222222",
+		},
+		{
+			name:            "case 1: source credentials of the target account",
+			sourceAccountID: "222222222222",
+			targetAccountID: "222222222222",
+			errorMatcher:    IsWritableCredentials,
+		},
+		{
+			name:            "case 2: caller identity failure",
+			targetAccountID: "222222222222",
+			errorMatcher:    func(err error) bool { return err != nil && !IsWritableCredentials(err) },
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			source := &Clients{STS: &callerIdentityMock{accountID: tc.sourceAccountID}}
+			target := &Clients{STS: &callerIdentityMock{accountID: tc.targetAccountID}}
+
+			err := source.VerifySourceAccount(target)
+			if tc.errorMatcher != nil {
+				if !tc.errorMatcher(err) {
+					t.Fatalf("expected matching error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("VerifySourceAccount: %v", err)
+			}
+		})
+	}
+}
+
+// TestSourceInterface_ReadOnly guards against write calls being added to the
+// source client, which must only ever read from the source account.
+func TestSourceInterface_ReadOnly(t *testing.T) {
+	readPrefixes := []string{"Describe", "Get", "List"}
+
+	ti := reflect.TypeOf((*SourceInterface)(nil)).Elem()
+	for i := 0; i < ti.NumMethod(); i++ {
+		name := ti.Method(i).Name
+
+		var ok bool
+		for _, p := range readPrefixes {
+			if strings.HasPrefix(name, p) {
+				ok = true
+			}
+		}
+		if !ok {
+			t.Errorf("expected only read methods in SourceInterface, got %#q", name)
+		}
+	}
+}
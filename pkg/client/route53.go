@@ -0,0 +1,82 @@
+package client
+
+import (
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+const (
+	// defaultDNSSuffix is the DNS suffix of the endpoints of the standard
+	// AWS partition.
+	defaultDNSSuffix = "amazonaws.com"
+)
+
+// route53Partition is the Route53 deployment of an AWS partition other than
+// the standard one. Route53 has no global endpoint there, so requests are sent
+// to the partition endpoint and signed for its home region. Every client is
+// resolved by its own region, so the target Route53 client stays in its
+// partition when the source clients are in another one, e.g. for clusters in
+// `cn-north-1` whose records are managed in the standard partition.
+type route53Partition struct {
+	regionPrefix string
+	endpoint     string
+	region       string
+	dnsSuffix    string
+}
+
+var route53Partitions = []route53Partition{
+	{
+		regionPrefix: "cn-",
+		endpoint:     "https://route53.amazonaws.com.cn",
+		region:       "cn-northwest-1",
+		dnsSuffix:    "amazonaws.com.cn",
+	},
+	{
+		regionPrefix: "us-isob-",
+		endpoint:     "https://route53.sc2s.sgov.gov",
+		region:       "us-isob-east-1",
+		dnsSuffix:    "sc2s.sgov.gov",
+	},
+	{
+		regionPrefix: "us-iso-",
+		endpoint:     "https://route53.c2s.ic.gov",
+		region:       "us-iso-east-1",
+		dnsSuffix:    "c2s.ic.gov",
+	},
+}
+
+// DNSSuffix returns the DNS suffix of the endpoints of the partition of the
+// given region, e.g. `amazonaws.com.cn` for `cn-north-1`.
+func DNSSuffix(region string) string {
+	for _, p := range route53Partitions {
+		if strings.HasPrefix(region, p.regionPrefix) {
+			return p.dnsSuffix
+		}
+	}
+
+	return defaultDNSSuffix
+}
+
+// route53Config returns the configuration of the Route53 client on top of the
+// session configuration. Config.Route53Endpoint takes precedence over the
+// endpoint of the partition of Config.Region. Nil is returned for regions of
+// the standard partition, whose endpoints are resolved by the SDK.
+func route53Config(config *Config) *aws.Config {
+	if config.Route53Endpoint != "" {
+		return &aws.Config{
+			Endpoint: aws.String(config.Route53Endpoint),
+		}
+	}
+
+	for _, p := range route53Partitions {
+		if strings.HasPrefix(config.Region, p.regionPrefix) {
+			return &aws.Config{
+				Endpoint: aws.String(p.endpoint),
+				Region:   aws.String(p.region),
+			}
+		}
+	}
+
+	return nil
+}
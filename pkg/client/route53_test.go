@@ -0,0 +1,140 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func TestRoute53Config(t *testing.T) {
+	tcs := []struct {
+		name             string
+		config           Config
+		expectedEndpoint string
+		expectedRegion   string
+	}{
+		{
+			name: "case 0: standard region is resolved by the SDK",
+			config: Config{
+				Region: "eu-central-1",
+			},
+		},
+		{
+			name: "case 1: isolated region",
+			config: Config{
+				Region: "us-iso-west-1",
+			},
+			expectedEndpoint: "https://route53.c2s.ic.gov",
+			expectedRegion:   "us-iso-east-1",
+		},
+		{
+			name: "case 2: isolated region b",
+			config: Config{
+				Region: "us-isob-east-1",
+			},
+			expectedEndpoint: "https://route53.sc2s.sgov.gov",
+			expectedRegion:   "us-isob-east-1",
+		},
+		{
+			name: "case 3: China region",
+			config: Config{
+				Region: "cn-north-1",
+			},
+			expectedEndpoint: "https://route53.amazonaws.com.cn",
+			expectedRegion:   "cn-northwest-1",
+		},
+		{
+			name: "case 4: endpoint override",
+			config: Config{
+				Region:          "us-iso-east-1",
+				Route53Endpoint: "https://route53.example.com",
+			},
+			expectedEndpoint: "https://route53.example.com",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := route53Config(&tc.config)
+			if tc.expectedEndpoint == "" {
+				if cfg != nil {
+					t.Fatalf("expected no Route53 config, got endpoint %#q", aws.StringValue(cfg.Endpoint))
+				}
+				return
+			}
+
+			if cfg == nil {
+				t.Fatalf("expected Route53 config, got none")
+			}
+			if aws.StringValue(cfg.Endpoint) != tc.expectedEndpoint {
+				t.Errorf("expected endpoint %#q, got %#q", tc.expectedEndpoint, aws.StringValue(cfg.Endpoint))
+			}
+			if aws.StringValue(cfg.Region) != tc.expectedRegion {
+				t.Errorf("expected region %#q, got %#q", tc.expectedRegion, aws.StringValue(cfg.Region))
+			}
+		})
+	}
+}
+
+func TestNewClients_Route53Endpoint(t *testing.T) {
+	c, err := NewClients(&Config{
+		Region:          "eu-central-1",
+		Route53Endpoint: "https://route53.example.com",
+	})
+	if err != nil {
+		t.Fatalf("NewClients: %v", err)
+	}
+
+	if c.Route53.Endpoint != "https://route53.example.com" {
+		t.Errorf("expected Route53 endpoint %#q, got %#q", "https://route53.example.com", c.Route53.Endpoint)
+	}
+	if c.Route53.SigningRegion != "eu-central-1" {
+		t.Errorf("expected Route53 signing region %#q, got %#q", "eu-central-1", c.Route53.SigningRegion)
+	}
+}
+
+func TestNewClients_CrossPartition(t *testing.T) {
+	source, err := NewClients(&Config{
+		Region: "cn-north-1",
+	})
+	if err != nil {
+		t.Fatalf("NewClients: %v", err)
+	}
+	target, err := NewClients(&Config{
+		Region: "us-iso-east-1",
+	})
+	if err != nil {
+		t.Fatalf("NewClients: %v", err)
+	}
+
+	if source.Route53.Endpoint != "https://route53.amazonaws.com.cn" {
+		t.Errorf("expected source Route53 endpoint %#q, got %#q", "https://route53.amazonaws.com.cn", source.Route53.Endpoint)
+	}
+	if target.Route53.Endpoint != "https://route53.c2s.ic.gov" {
+		t.Errorf("expected target Route53 endpoint %#q, got %#q", "https://route53.c2s.ic.gov", target.Route53.Endpoint)
+	}
+	if source.Route53.SigningRegion == target.Route53.SigningRegion {
+		t.Errorf("expected Route53 clients signed for different regions, got %#q", source.Route53.SigningRegion)
+	}
+}
+
+func TestDNSSuffix(t *testing.T) {
+	tcs := []struct {
+		region   string
+		expected string
+	}{
+		{region: "eu-central-1", expected: "amazonaws.com"},
+		{region: "cn-north-1", expected: "amazonaws.com.cn"},
+		{region: "us-iso-east-1", expected: "c2s.ic.gov"},
+		{region: "us-isob-east-1", expected: "sc2s.sgov.gov"},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.region, func(t *testing.T) {
+			suffix := DNSSuffix(tc.region)
+			if suffix != tc.expected {
+				t.Errorf("expected DNS suffix %#q, got %#q", tc.expected, suffix)
+			}
+		})
+	}
+}
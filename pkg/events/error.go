@@ -0,0 +1,23 @@
+package events
+
+import (
+	"github.com/giantswarm/microerror"
+)
+
+var invalidConfigError = &microerror.Error{
+	Kind: "invalidConfigError",
+}
+
+// IsInvalidConfig asserts invalidConfigError.
+func IsInvalidConfig(err error) bool {
+	return microerror.Cause(err) == invalidConfigError
+}
+
+var invalidEventError = &microerror.Error{
+	Kind: "invalidEventError",
+}
+
+// IsInvalidEvent asserts invalidEventError.
+func IsInvalidEvent(err error) bool {
+	return microerror.Cause(err) == invalidEventError
+}
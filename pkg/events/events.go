@@ -0,0 +1,315 @@
+// Package events triggers the sync of single clusters on the CloudFormation
+// stack events received from an SQS queue, e.g. fed by an EventBridge rule or
+// the SNS topic the source stacks notify, instead of syncing all clusters on
+// an interval.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+
+	"github.com/giantswarm/route53-manager/pkg/client"
+	"github.com/giantswarm/route53-manager/pkg/recordset/plan"
+)
+
+const (
+	// waitTimeSeconds is the long polling duration of ReceiveMessage, the
+	// maximum supported by SQS.
+	waitTimeSeconds = 20
+	// maxMessages is the maximum number of messages received at once
+	// supported by SQS.
+	maxMessages = 10
+
+	// errorInterval is the duration the Watcher waits for after failing to
+	// receive messages.
+	errorInterval = 10 * time.Second
+
+	eventBridgeSource     = "aws.cloudformation"
+	eventBridgeDetailType = "CloudFormation Stack Status Change"
+	snsNotificationType   = "Notification"
+	stackResourceType     = "AWS::CloudFormation::Stack"
+)
+
+var (
+	// sourceStackNameREs match the names of the source stacks whose events
+	// trigger a sync. The events of the target stacks are ignored, they are
+	// caused by the syncs themselves.
+	sourceStackNameREs = []*regexp.Regexp{
+		regexp.MustCompile(plan.LegacySourceStackNamePattern),
+		regexp.MustCompile(plan.SourceStackNamePattern),
+	}
+)
+
+type Config struct {
+	Logger   micrologger.Logger
+	Client   client.EventQueueInterface
+	QueueURL string
+
+	// Sync syncs the cluster with the given ID, e.g. `foo`.
+	Sync func(clusterID string) error
+}
+
+// Watcher receives CloudFormation stack events from an SQS queue and syncs
+// the clusters whose source stacks completed a transition. Messages are
+// deleted once their cluster is synced, so failed syncs are retried when
+// the messages become visible again.
+type Watcher struct {
+	logger   micrologger.Logger
+	client   client.EventQueueInterface
+	queueURL string
+	sync     func(clusterID string) error
+
+	// sleep is sleepContext, replaceable in tests.
+	sleep func(context.Context, time.Duration)
+}
+
+func New(c Config) (*Watcher, error) {
+	if c.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", c)
+	}
+	if c.Client == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Client must not be empty", c)
+	}
+	if c.QueueURL == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.QueueURL must not be empty", c)
+	}
+	if c.Sync == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Sync must not be empty", c)
+	}
+
+	w := &Watcher{
+		logger:   c.Logger,
+		client:   c.Client,
+		queueURL: c.QueueURL,
+		sync:     c.Sync,
+
+		sleep: sleepContext,
+	}
+
+	return w, nil
+}
+
+// Run polls the queue until the context is done. Failures to receive
+// messages are logged and retried. Both the long poll and the wait after a
+// failure end as soon as the context is done.
+func (w *Watcher) Run(ctx context.Context) error {
+	w.logger.Log("level", "info", "message", fmt.Sprintf("watching stack events of queue %#q", w.queueURL))
+
+	for ctx.Err() == nil {
+		err := w.Poll(ctx)
+		if ctx.Err() != nil {
+			break
+		}
+		if err != nil {
+			w.logger.Log("level", "error", "message", fmt.Sprintf("failed to receive stack events of queue %#q", w.queueURL), "stack", microerror.JSON(err))
+			w.sleep(ctx, errorInterval)
+		}
+	}
+
+	return nil
+}
+
+// Poll receives one batch of messages and syncs every cluster with a
+// relevant stack event once. Messages which are no relevant stack event are
+// deleted right away. No further cluster is synced once the context is done,
+// their messages are handled again once they become visible.
+func (w *Watcher) Poll(ctx context.Context) error {
+	input := &sqs.ReceiveMessageInput{
+		MaxNumberOfMessages: aws.Int64(maxMessages),
+		QueueUrl:            aws.String(w.queueURL),
+		WaitTimeSeconds:     aws.Int64(waitTimeSeconds),
+	}
+	output, err := w.client.ReceiveMessageWithContext(ctx, input)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	var clusterIDs []string
+	receiptHandles := map[string][]*string{}
+	for _, msg := range output.Messages {
+		e, err := parseMessage(aws.StringValue(msg.Body))
+		if err != nil {
+			w.logger.Log("level", "warning", "message", fmt.Sprintf("dropped invalid message %#q", aws.StringValue(msg.MessageId)), "stack", microerror.JSON(err))
+			w.deleteMessage(ctx, msg.ReceiptHandle)
+			continue
+		}
+		if !e.relevant() {
+			w.logger.Log("level", "debug", "message", fmt.Sprintf("ignored event of stack %#q with status %#q", e.StackName, e.Status))
+			w.deleteMessage(ctx, msg.ReceiptHandle)
+			continue
+		}
+
+		clusterID, err := plan.ClusterID(e.StackName)
+		if err != nil {
+			w.logger.Log("level", "warning", "message", fmt.Sprintf("dropped event of stack %#q", e.StackName), "stack", microerror.JSON(err))
+			w.deleteMessage(ctx, msg.ReceiptHandle)
+			continue
+		}
+
+		w.logger.Log("level", "debug", "message", fmt.Sprintf("received event of stack %#q with status %#q", e.StackName, e.Status))
+		if _, ok := receiptHandles[clusterID]; !ok {
+			clusterIDs = append(clusterIDs, clusterID)
+		}
+		receiptHandles[clusterID] = append(receiptHandles[clusterID], msg.ReceiptHandle)
+	}
+
+	for _, clusterID := range clusterIDs {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		err := w.sync(clusterID)
+		if err != nil {
+			w.logger.Log("level", "error", "message", fmt.Sprintf("failed to sync cluster %#q", clusterID), "stack", microerror.JSON(err))
+			continue
+		}
+
+		for _, h := range receiptHandles[clusterID] {
+			w.deleteMessage(ctx, h)
+		}
+	}
+
+	return nil
+}
+
+// deleteMessage deletes the message with the given receipt handle. Failures
+// are only logged, the message is handled again once it becomes visible.
+func (w *Watcher) deleteMessage(ctx context.Context, receiptHandle *string) {
+	input := &sqs.DeleteMessageInput{
+		QueueUrl:      aws.String(w.queueURL),
+		ReceiptHandle: receiptHandle,
+	}
+	_, err := w.client.DeleteMessageWithContext(ctx, input)
+	if err != nil {
+		w.logger.Log("level", "warning", "message", fmt.Sprintf("failed to delete message of queue %#q", w.queueURL), "stack", microerror.JSON(err))
+	}
+}
+
+// sleepContext waits for the given duration or until the context is done.
+func sleepContext(ctx context.Context, d time.Duration) {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+	case <-t.C:
+	}
+}
+
+// stackEvent is the status transition of a stack.
+type stackEvent struct {
+	StackName string
+	Status    string
+}
+
+// relevant returns true for source stacks which completed a transition, e.g.
+// CREATE_COMPLETE or DELETE_COMPLETE.
+func (e stackEvent) relevant() bool {
+	if !strings.HasSuffix(e.Status, "_COMPLETE") {
+		return false
+	}
+	for _, re := range sourceStackNameREs {
+		if re.MatchString(e.StackName) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// message holds the fields of both the EventBridge events and the SNS
+// notifications wrapping them or the CloudFormation notifications.
+type message struct {
+	// Type and Message are set for SNS notifications.
+	Type    string `json:"Type"`
+	Message string `json:"Message"`
+
+	// Source, DetailType and Detail are set for EventBridge events.
+	Source     string `json:"source"`
+	DetailType string `json:"detail-type"`
+	Detail     struct {
+		StackID       string `json:"stack-id"`
+		StatusDetails struct {
+			Status string `json:"status"`
+		} `json:"status-details"`
+	} `json:"detail"`
+}
+
+// parseMessage returns the stack event of the body of an SQS message, either
+// an EventBridge event, an SNS notification wrapping one or a CloudFormation
+// SNS notification. Events which are no stack status change are returned
+// empty.
+func parseMessage(body string) (stackEvent, error) {
+	var m message
+	err := json.Unmarshal([]byte(body), &m)
+	if err != nil {
+		return stackEvent{}, microerror.Maskf(invalidEventError, "message body is no JSON: %s", err)
+	}
+
+	switch {
+	case m.Type == snsNotificationType && strings.HasPrefix(strings.TrimSpace(m.Message), "{"):
+		return parseMessage(m.Message)
+	case m.Type == snsNotificationType:
+		return parseCloudFormationNotification(m.Message)
+	case m.Source == eventBridgeSource:
+		if m.DetailType != eventBridgeDetailType {
+			return stackEvent{}, nil
+		}
+		e := stackEvent{
+			StackName: stackNameFromID(m.Detail.StackID),
+			Status:    m.Detail.StatusDetails.Status,
+		}
+		return e, nil
+	}
+
+	return stackEvent{}, microerror.Maskf(invalidEventError, "message is neither an EventBridge event of %#q nor an SNS notification", eventBridgeSource)
+}
+
+// parseCloudFormationNotification returns the stack event of the
+// `Key='value'` lines CloudFormation publishes to SNS for every resource
+// event. Events of resources other than the stack itself are returned empty.
+func parseCloudFormationNotification(notification string) (stackEvent, error) {
+	fields := map[string]string{}
+	for _, line := range strings.Split(notification, "\n") {
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		fields[parts[0]] = strings.Trim(parts[1], "'")
+	}
+
+	if fields["StackName"] == "" {
+		return stackEvent{}, microerror.Maskf(invalidEventError, "CloudFormation notification without stack name")
+	}
+	if fields["ResourceType"] != stackResourceType || fields["LogicalResourceId"] != fields["StackName"] {
+		return stackEvent{}, nil
+	}
+
+	e := stackEvent{
+		StackName: fields["StackName"],
+		Status:    fields["ResourceStatus"],
+	}
+
+	return e, nil
+}
+
+// stackNameFromID returns the name of the stack with the given ID, e.g.
+// `cluster-foo-tccp` for
+// `arn:aws:cloudformation:eu-west-1:123456789012:stack/cluster-foo-tccp/...`.
+func stackNameFromID(stackID string) string {
+	parts := strings.Split(stackID, "/")
+	if len(parts) < 2 {
+		return stackID
+	}
+
+	return parts[1]
+}
@@ -0,0 +1,237 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+)
+
+const (
+	eventBridgeBody = `{
+  "source": "aws.cloudformation",
+  "detail-type": "CloudFormation Stack Status Change",
+  "detail": {
+    "stack-id": "arn:aws:cloudformation:eu-west-1:123456789012:stack/%s/0d6e3a50-0000-11ef-0000-0a1b2c3d4e5f",
+    "status-details": {
+      "status": "%s"
+    }
+  }
+}`
+	snsBody = `{
+  "Type": "Notification",
+  "Message": "StackId='arn:aws:cloudformation:eu-west-1:123456789012:stack/%[1]s/0d6e3a50'\nStackName='%[1]s'\nLogicalResourceId='%[2]s'\nResourceStatus='%[3]s'\nResourceType='%[4]s'\n"
+}`
+)
+
+type queueMock struct {
+	messages []*sqs.Message
+	// receiveErr is returned by ReceiveMessage when set.
+	receiveErr error
+	// longPoll makes ReceiveMessage block until the context is done.
+	longPoll bool
+
+	deleted []string
+}
+
+func (q *queueMock) DeleteMessageWithContext(ctx aws.Context, input *sqs.DeleteMessageInput, opts ...request.Option) (*sqs.DeleteMessageOutput, error) {
+	q.deleted = append(q.deleted, aws.StringValue(input.ReceiptHandle))
+	return &sqs.DeleteMessageOutput{}, nil
+}
+
+func (q *queueMock) ReceiveMessageWithContext(ctx aws.Context, input *sqs.ReceiveMessageInput, opts ...request.Option) (*sqs.ReceiveMessageOutput, error) {
+	if q.longPoll {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	if q.receiveErr != nil {
+		return nil, q.receiveErr
+	}
+	return &sqs.ReceiveMessageOutput{Messages: q.messages}, nil
+}
+
+func newMessage(receiptHandle, body string) *sqs.Message {
+	return &sqs.Message{
+		Body:          aws.String(body),
+		MessageId:     aws.String(receiptHandle),
+		ReceiptHandle: aws.String(receiptHandle),
+	}
+}
+
+func TestPoll(t *testing.T) {
+	tcs := []struct {
+		name            string
+		messages        []*sqs.Message
+		failingClusters []string
+		expectedSynced  []string
+		expectedDeleted []string
+	}{
+		{
+			name: "case 0: EventBridge event of a source stack",
+			messages: []*sqs.Message{
+				newMessage("a", fmt.Sprintf(eventBridgeBody, "cluster-foo-tccp", "CREATE_COMPLETE")),
+			},
+			expectedSynced:  []string{"foo"},
+			expectedDeleted: []string{"a"},
+		},
+		{
+			name: "case 1: SNS notification of a legacy source stack",
+			messages: []*sqs.Message{
+				newMessage("a", fmt.Sprintf(snsBody, "cluster-foo-guest-main", "cluster-foo-guest-main", "DELETE_COMPLETE", "AWS::CloudFormation::Stack")),
+			},
+			expectedSynced:  []string{"foo"},
+			expectedDeleted: []string{"a"},
+		},
+		{
+			name: "case 2: SNS notification wrapping an EventBridge event",
+			messages: []*sqs.Message{
+				newMessage("a", fmt.Sprintf(`{"Type": "Notification", "Message": %q}`, fmt.Sprintf(eventBridgeBody, "cluster-foo-tccp", "UPDATE_COMPLETE"))),
+			},
+			expectedSynced:  []string{"foo"},
+			expectedDeleted: []string{"a"},
+		},
+		{
+			name: "case 3: events of one cluster sync it once",
+			messages: []*sqs.Message{
+				newMessage("a", fmt.Sprintf(eventBridgeBody, "cluster-foo-tccp", "UPDATE_COMPLETE")),
+				newMessage("b", fmt.Sprintf(eventBridgeBody, "cluster-bar-tccp", "CREATE_COMPLETE")),
+				newMessage("c", fmt.Sprintf(eventBridgeBody, "cluster-foo-tccp", "UPDATE_COMPLETE")),
+			},
+			expectedSynced:  []string{"foo", "bar"},
+			expectedDeleted: []string{"a", "c", "b"},
+		},
+		{
+			name: "case 4: irrelevant events are deleted without sync",
+			messages: []*sqs.Message{
+				newMessage("a", fmt.Sprintf(eventBridgeBody, "cluster-foo-guest-recordsets", "CREATE_COMPLETE")),
+				newMessage("b", fmt.Sprintf(eventBridgeBody, "cluster-foo-tccp", "UPDATE_IN_PROGRESS")),
+				newMessage("c", fmt.Sprintf(snsBody, "cluster-foo-tccp", "ApiLoadBalancer", "CREATE_COMPLETE", "AWS::ElasticLoadBalancing::LoadBalancer")),
+				newMessage("d", `{"source": "aws.cloudformation", "detail-type": "CloudFormation Resource Status Change"}`),
+			},
+			expectedDeleted: []string{"a", "b", "c", "d"},
+		},
+		{
+			name: "case 5: invalid messages are deleted without sync",
+			messages: []*sqs.Message{
+				newMessage("a", "not json"),
+				newMessage("b", `{"source": "aws.ec2"}`),
+			},
+			expectedDeleted: []string{"a", "b"},
+		},
+		{
+			name: "case 6: messages of failed syncs are kept",
+			messages: []*sqs.Message{
+				newMessage("a", fmt.Sprintf(eventBridgeBody, "cluster-foo-tccp", "CREATE_COMPLETE")),
+				newMessage("b", fmt.Sprintf(eventBridgeBody, "cluster-bar-tccp", "CREATE_COMPLETE")),
+			},
+			failingClusters: []string{"foo"},
+			expectedSynced:  []string{"foo", "bar"},
+			expectedDeleted: []string{"b"},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			logger, err := micrologger.New(micrologger.Config{IOWriter: ioutil.Discard})
+			if err != nil {
+				t.Fatalf("micrologger.New: %v", err)
+			}
+
+			q := &queueMock{messages: tc.messages}
+
+			var synced []string
+			w, err := New(Config{
+				Logger:   logger,
+				Client:   q,
+				QueueURL: "queueURL",
+				Sync: func(clusterID string) error {
+					synced = append(synced, clusterID)
+					for _, id := range tc.failingClusters {
+						if id == clusterID {
+							return microerror.Mask(invalidConfigError)
+						}
+					}
+					return nil
+				},
+			})
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+
+			err = w.Poll(context.Background())
+			if err != nil {
+				t.Fatalf("Poll: %v", err)
+			}
+
+			if !reflect.DeepEqual(tc.expectedSynced, synced) {
+				t.Errorf("expected synced clusters %v, got %v", tc.expectedSynced, synced)
+			}
+			if !reflect.DeepEqual(tc.expectedDeleted, q.deleted) {
+				t.Errorf("expected deleted messages %v, got %v", tc.expectedDeleted, q.deleted)
+			}
+		})
+	}
+}
+
+func TestRun_ContextDone(t *testing.T) {
+	tcs := []struct {
+		name  string
+		queue *queueMock
+	}{
+		{
+			name:  "case 0: context done during the long poll",
+			queue: &queueMock{longPoll: true},
+		},
+		{
+			name:  "case 1: context done while waiting after a failed poll",
+			queue: &queueMock{receiveErr: microerror.Mask(invalidEventError)},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			logger, err := micrologger.New(micrologger.Config{IOWriter: ioutil.Discard})
+			if err != nil {
+				t.Fatalf("micrologger.New: %v", err)
+			}
+
+			w, err := New(Config{
+				Logger:   logger,
+				Client:   tc.queue,
+				QueueURL: "queueURL",
+				Sync: func(clusterID string) error {
+					return nil
+				},
+			})
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan error, 1)
+			go func() {
+				done <- w.Run(ctx)
+			}()
+
+			time.Sleep(10 * time.Millisecond)
+			cancel()
+
+			select {
+			case err := <-done:
+				if err != nil {
+					t.Fatalf("Run: %v", err)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("expected Run to return once the context is done")
+			}
+		})
+	}
+}
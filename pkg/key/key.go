@@ -17,3 +17,11 @@ func EtcdENIDNSName(baseDomain string, index int) string {
 func EtcdEniResourceName(index int) string {
 	return fmt.Sprintf("EtcdEniDNSRecordSet%d", index+1)
 }
+
+func ComponentResourceName(name string) string {
+	return fmt.Sprintf("%sDNSRecord", name)
+}
+
+func ComponentAliasResourceName(name string) string {
+	return fmt.Sprintf("%sAliasDNSRecord", name)
+}
@@ -0,0 +1,105 @@
+// Package logbuffer provides a logger which holds back its log lines until
+// they are flushed, so the lines of e.g. one cluster are written as a
+// contiguous block even when several clusters are processed concurrently.
+package logbuffer
+
+import (
+	"context"
+	"sync"
+
+	"github.com/giantswarm/micrologger"
+)
+
+var (
+	// flushMutex serializes the flushes of all buffers, so the blocks of
+	// different buffers never interleave.
+	flushMutex sync.Mutex
+)
+
+// buffer holds the log lines of a Logger and the loggers derived from it
+// with With, in order.
+type buffer struct {
+	mutex sync.Mutex
+	lines []func()
+}
+
+// Logger is a micrologger.Logger which buffers all log lines until Flush is
+// called. The caller of the buffered lines is the one of the flush.
+type Logger struct {
+	logger micrologger.Logger
+	buffer *buffer
+}
+
+// New creates a Logger writing the buffered lines to logger when flushed.
+func New(logger micrologger.Logger) *Logger {
+	l := &Logger{
+		logger: logger,
+		buffer: &buffer{},
+	}
+
+	return l
+}
+
+// Flush writes all buffered log lines to the underlying logger as one block
+// and empties the buffer.
+func (l *Logger) Flush() {
+	l.buffer.mutex.Lock()
+	lines := l.buffer.lines
+	l.buffer.lines = nil
+	l.buffer.mutex.Unlock()
+
+	flushMutex.Lock()
+	defer flushMutex.Unlock()
+
+	for _, line := range lines {
+		line()
+	}
+}
+
+func (l *Logger) Debug(ctx context.Context, message string) {
+	l.add(func() { l.logger.Debug(ctx, message) })
+}
+
+func (l *Logger) Debugf(ctx context.Context, format string, params ...interface{}) {
+	l.add(func() { l.logger.Debugf(ctx, format, params...) })
+}
+
+func (l *Logger) Error(ctx context.Context, err error, message string) {
+	l.add(func() { l.logger.Error(ctx, err, message) })
+}
+
+func (l *Logger) Errorf(ctx context.Context, err error, format string, params ...interface{}) {
+	l.add(func() { l.logger.Errorf(ctx, err, format, params...) })
+}
+
+func (l *Logger) Log(keyVals ...interface{}) {
+	l.add(func() { l.logger.Log(keyVals...) })
+}
+
+func (l *Logger) LogCtx(ctx context.Context, keyVals ...interface{}) {
+	l.add(func() { l.logger.LogCtx(ctx, keyVals...) })
+}
+
+// With returns a logger with keyVals appended to those of the underlying
+// logger. It shares the buffer of l.
+func (l *Logger) With(keyVals ...interface{}) micrologger.Logger {
+	return &Logger{
+		logger: l.logger.With(keyVals...),
+		buffer: l.buffer,
+	}
+}
+
+// WithIncreasedCallerDepth returns a logger sharing the buffer of l.
+func (l *Logger) WithIncreasedCallerDepth() micrologger.Logger {
+	return &Logger{
+		logger: l.logger.WithIncreasedCallerDepth(),
+		buffer: l.buffer,
+	}
+}
+
+func (l *Logger) add(line func()) {
+	l.buffer.mutex.Lock()
+	defer l.buffer.mutex.Unlock()
+
+	l.buffer.lines = append(l.buffer.lines, line)
+}
@@ -0,0 +1,104 @@
+package logbuffer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/giantswarm/micrologger"
+)
+
+func TestLogger_ConcurrentClusters(t *testing.T) {
+	var out bytes.Buffer
+	logger, err := micrologger.New(micrologger.Config{IOWriter: &out})
+	if err != nil {
+		t.Fatalf("micrologger.New: %v", err)
+	}
+
+	clusters := []string{"foo", "bar"}
+	linesPerCluster := 50
+
+	// started makes both clusters log concurrently before either of them
+	// flushes.
+	var started sync.WaitGroup
+	started.Add(len(clusters))
+
+	var wg sync.WaitGroup
+	for _, cluster := range clusters {
+		wg.Add(1)
+		go func(cluster string) {
+			defer wg.Done()
+
+			l := New(logger)
+			started.Done()
+			started.Wait()
+
+			for i := 0; i < linesPerCluster; i++ {
+				l.With("cluster", cluster).Log("level", "debug", "message", fmt.Sprintf("line %d", i))
+			}
+			l.Flush()
+		}(cluster)
+	}
+	wg.Wait()
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != len(clusters)*linesPerCluster {
+		t.Fatalf("expected %d lines, got %d", len(clusters)*linesPerCluster, len(lines))
+	}
+
+	// The lines of a cluster must form one contiguous block in order.
+	blocks := map[string]bool{}
+	previous := ""
+	next := 0
+	for _, line := range lines {
+		var entry map[string]interface{}
+		err := json.Unmarshal([]byte(line), &entry)
+		if err != nil {
+			t.Fatalf("json.Unmarshal: %v", err)
+		}
+
+		cluster := fmt.Sprint(entry["cluster"])
+		if cluster != previous {
+			if blocks[cluster] {
+				t.Fatalf("expected contiguous lines of cluster %#q, got\n%s", cluster, out.String())
+			}
+			blocks[cluster] = true
+			previous = cluster
+			next = 0
+		}
+
+		expected := fmt.Sprintf("line %d", next)
+		if entry["message"] != expected {
+			t.Fatalf("expected message %#q of cluster %#q, got %#q", expected, cluster, entry["message"])
+		}
+		next++
+	}
+}
+
+func TestLogger_Flush(t *testing.T) {
+	var out bytes.Buffer
+	logger, err := micrologger.New(micrologger.Config{IOWriter: &out})
+	if err != nil {
+		t.Fatalf("micrologger.New: %v", err)
+	}
+
+	l := New(logger)
+	l.Log("level", "debug", "message", "buffered")
+	if out.Len() != 0 {
+		t.Fatalf("expected no output before flush, got %s", out.String())
+	}
+
+	l.Flush()
+	if !strings.Contains(out.String(), "buffered") {
+		t.Fatalf("expected buffered line after flush, got %s", out.String())
+	}
+
+	out.Reset()
+	l.Flush()
+	if out.Len() != 0 {
+		t.Fatalf("expected empty buffer after flush, got %s", out.String())
+	}
+}
@@ -0,0 +1,186 @@
+// Package logformat selects the format of the log lines written by
+// micrologger, which always writes JSON lines. The text format rewrites them
+// into logfmt lines, e.g. for local runs.
+package logformat
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	// FormatJSON writes the JSON lines of micrologger as they are, e.g. for
+	// structured log backends.
+	FormatJSON = "json"
+	// FormatText writes logfmt lines starting with the time, level and
+	// message.
+	FormatText = "text"
+)
+
+var (
+	// leadingKeys are written first and in this order by the text format.
+	leadingKeys = []string{"time", "level", "message"}
+)
+
+// Writer is the writer micrologger writes its lines to. The logger is
+// created before the command line and config files are parsed, so the
+// format is set afterwards by SetFormat. Lines written before are JSON.
+type Writer struct {
+	out io.Writer
+
+	mutex sync.Mutex
+	w     io.Writer
+}
+
+// New returns a Writer writing JSON lines to out until SetFormat is called.
+func New(out io.Writer) *Writer {
+	w := &Writer{
+		out: out,
+		w:   out,
+	}
+
+	return w
+}
+
+// SetFormat makes the Writer write all following lines in the given format,
+// either FormatJSON or FormatText.
+func (w *Writer) SetFormat(format string) error {
+	nw, err := NewWriter(w.out, format)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.w = nw
+
+	return nil
+}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	return w.w.Write(p)
+}
+
+// NewWriter returns the writer micrologger writes its lines to in the given
+// format.
+func NewWriter(w io.Writer, format string) (io.Writer, error) {
+	switch format {
+	case FormatJSON:
+		return w, nil
+	case FormatText:
+		return &textWriter{w: w}, nil
+	default:
+		return nil, microerror.Maskf(invalidConfigError, "format must be %#q or %#q, got %#q", FormatJSON, FormatText, format)
+	}
+}
+
+// textWriter rewrites every JSON line written to it into a logfmt line.
+// Lines which are no JSON objects are written as they are.
+type textWriter struct {
+	w io.Writer
+}
+
+func (t *textWriter) Write(p []byte) (int, error) {
+	var out bytes.Buffer
+	for _, line := range bytes.SplitAfter(p, []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+
+		text, ok := logfmtLine(bytes.TrimSuffix(line, []byte("\n")))
+		if !ok {
+			out.Write(line)
+			continue
+		}
+		out.WriteString(text)
+		out.WriteString("\n")
+	}
+
+	_, err := t.w.Write(out.Bytes())
+	if err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// logfmtLine returns the JSON object as logfmt line with the leading keys
+// first and the other keys in alphabetical order. It returns false when the
+// line is no JSON object.
+func logfmtLine(line []byte) (string, bool) {
+	var fields map[string]interface{}
+	d := json.NewDecoder(bytes.NewReader(line))
+	d.UseNumber()
+	err := d.Decode(&fields)
+	if err != nil || fields == nil {
+		return "", false
+	}
+
+	var keys []string
+	for _, k := range leadingKeys {
+		if _, ok := fields[k]; ok {
+			keys = append(keys, k)
+		}
+	}
+	var otherKeys []string
+	for k := range fields {
+		if !isLeadingKey(k) {
+			otherKeys = append(otherKeys, k)
+		}
+	}
+	sort.Strings(otherKeys)
+	keys = append(keys, otherKeys...)
+
+	var pairs []string
+	for _, k := range keys {
+		pairs = append(pairs, k+"="+logfmtValue(fields[k]))
+	}
+
+	return strings.Join(pairs, " "), true
+}
+
+func isLeadingKey(key string) bool {
+	for _, k := range leadingKeys {
+		if k == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+// logfmtValue returns the value quoted when it is empty or holds spaces,
+// quotes or equal signs. Values other than strings are written as JSON.
+func logfmtValue(v interface{}) string {
+	var s string
+	switch v := v.(type) {
+	case string:
+		s = v
+	case json.Number:
+		s = v.String()
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			s = fmt.Sprint(v)
+		} else {
+			s = string(b)
+		}
+	}
+
+	if s == "" || strings.ContainsAny(s, " =\"\t\n") {
+		return strconv.Quote(s)
+	}
+
+	return s
+}
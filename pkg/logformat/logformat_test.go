@@ -0,0 +1,97 @@
+package logformat
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/giantswarm/micrologger"
+)
+
+func TestWriter_SetFormat(t *testing.T) {
+	var out bytes.Buffer
+	w := New(&out)
+	logger, err := micrologger.New(micrologger.Config{IOWriter: w})
+	if err != nil {
+		t.Fatalf("micrologger.New: %v", err)
+	}
+
+	logger.Log("level", "debug", "message", "before")
+
+	err = w.SetFormat("logfmt")
+	if !IsInvalidConfig(err) {
+		t.Fatalf("expected invalid config error, got %v", err)
+	}
+	err = w.SetFormat(FormatText)
+	if err != nil {
+		t.Fatalf("SetFormat: %v", err)
+	}
+
+	logger.Log("level", "debug", "message", "after")
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d in %s", len(lines), out.String())
+	}
+	if !json.Valid([]byte(lines[0])) {
+		t.Errorf("expected JSON line before SetFormat, got %s", lines[0])
+	}
+	if json.Valid([]byte(lines[1])) || !strings.Contains(lines[1], " message=after") {
+		t.Errorf("expected logfmt line after SetFormat, got %s", lines[1])
+	}
+}
+
+func TestNewWriter(t *testing.T) {
+	tcs := []struct {
+		name         string
+		format       string
+		expectedJSON bool
+	}{
+		{
+			name:         "case 0: JSON",
+			format:       FormatJSON,
+			expectedJSON: true,
+		},
+		{
+			name:         "case 1: text",
+			format:       FormatText,
+			expectedJSON: false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			w, err := NewWriter(&out, tc.format)
+			if err != nil {
+				t.Fatalf("NewWriter: %v", err)
+			}
+			logger, err := micrologger.New(micrologger.Config{IOWriter: w})
+			if err != nil {
+				t.Fatalf("micrologger.New: %v", err)
+			}
+
+			logger.Log("level", "debug", "message", "created target stack", "cluster", "foo")
+			logger.Log("level", "info", "message", "done")
+
+			lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+			if len(lines) != 2 {
+				t.Fatalf("expected 2 lines, got %d in %s", len(lines), out.String())
+			}
+			for _, line := range lines {
+				if json.Valid([]byte(line)) != tc.expectedJSON {
+					t.Errorf("expected JSON %t, got %s", tc.expectedJSON, line)
+				}
+			}
+			if !tc.expectedJSON {
+				if !strings.Contains(lines[0], ` level=debug message="created target stack" `) || !strings.Contains(lines[0], " cluster=foo") {
+					t.Errorf("expected logfmt line, got %s", lines[0])
+				}
+				if !strings.HasPrefix(lines[1], "time=") {
+					t.Errorf("expected line to start with the time, got %s", lines[1])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,369 @@
+// Package metrics provides counters rendered in the Prometheus text
+// exposition format, e.g. for the node exporter textfile collector, since the
+// route53-manager runs as a short lived job without a metrics endpoint. The
+// format is written following the exposition format specification, including
+// its escaping rules, so no Prometheus client library is required.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	namespace = "route53_manager"
+)
+
+var (
+	// helpReplacer escapes HELP texts as the text exposition format
+	// requires.
+	helpReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	// labelValueReplacer escapes label values as the text exposition format
+	// requires. Other characters, including non ASCII ones, are written as is.
+	labelValueReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+// Collector is a metric which can be rendered in the Prometheus text
+// exposition format.
+type Collector interface {
+	Name() string
+	Write(w io.Writer) error
+}
+
+// CounterVec is a counter partitioned by label values.
+type CounterVec struct {
+	name   string
+	help   string
+	labels []string
+
+	mutex  sync.Mutex
+	values map[string]float64
+}
+
+// NewCounterVec creates a counter named `route53_manager_<name>`.
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{
+		name:   namespace + "_" + name,
+		help:   help,
+		labels: labels,
+
+		values: map[string]float64{},
+	}
+
+	return c
+}
+
+// Name returns the full name of the counter.
+func (c *CounterVec) Name() string {
+	return c.name
+}
+
+// Inc increments the counter of the given label values by one. Label values
+// must be given in the order of the labels the counter was created with.
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds v to the counter of the given label values.
+func (c *CounterVec) Add(v float64, labelValues ...string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.values[c.key(labelValues)] += v
+}
+
+// Value returns the counter of the given label values.
+func (c *CounterVec) Value(labelValues ...string) float64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.values[c.key(labelValues)]
+}
+
+// Reset removes all counters.
+func (c *CounterVec) Reset() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.values = map[string]float64{}
+}
+
+func (c *CounterVec) Write(w io.Writer) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", c.name, helpReplacer.Replace(c.help), c.name)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	var keys []string
+	for k := range c.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		_, err := fmt.Fprintf(w, "%s%s %v\n", c.name, formatLabels(c.labels, k), c.values[k])
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+func (c *CounterVec) key(labelValues []string) string {
+	if len(labelValues) != len(c.labels) {
+		panic(fmt.Sprintf("metric %#q expects %d label values, got %d", c.name, len(c.labels), len(labelValues)))
+	}
+
+	return strings.Join(labelValues, "\xff")
+}
+
+// formatLabels returns the label pairs of the given key in the text
+// exposition format, e.g. `{reason="a"}`, with the values escaped by
+// labelValueReplacer.
+func formatLabels(labels []string, key string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	var pairs []string
+	for i, v := range strings.Split(key, "\xff") {
+		pairs = append(pairs, formatLabel(labels[i], v))
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// DefBuckets are the default upper bounds of histogram buckets in seconds,
+// suited for the latencies of network calls.
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// HistogramVec is a histogram partitioned by label values.
+type HistogramVec struct {
+	name    string
+	help    string
+	labels  []string
+	buckets []float64
+
+	mutex  sync.Mutex
+	values map[string]*histogram
+}
+
+// histogram holds the observations of one set of label values. counts holds
+// the number of observations per bucket, not cumulated.
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// NewHistogramVec creates a histogram named `route53_manager_<name>` with the
+// given sorted bucket upper bounds. The +Inf bucket is added implicitly.
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	h := &HistogramVec{
+		name:    namespace + "_" + name,
+		help:    help,
+		labels:  labels,
+		buckets: buckets,
+
+		values: map[string]*histogram{},
+	}
+
+	return h
+}
+
+// Name returns the full name of the histogram.
+func (h *HistogramVec) Name() string {
+	return h.name
+}
+
+// Observe adds v to the histogram of the given label values. Label values
+// must be given in the order of the labels the histogram was created with.
+func (h *HistogramVec) Observe(v float64, labelValues ...string) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	k := h.key(labelValues)
+	o, ok := h.values[k]
+	if !ok {
+		o = &histogram{counts: make([]uint64, len(h.buckets))}
+		h.values[k] = o
+	}
+
+	for i, b := range h.buckets {
+		if v <= b {
+			o.counts[i]++
+			break
+		}
+	}
+	o.count++
+	o.sum += v
+}
+
+// Count returns the number of observations of the given label values.
+func (h *HistogramVec) Count(labelValues ...string) uint64 {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	o, ok := h.values[h.key(labelValues)]
+	if !ok {
+		return 0
+	}
+
+	return o.count
+}
+
+// Reset removes all observations.
+func (h *HistogramVec) Reset() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.values = map[string]*histogram{}
+}
+
+func (h *HistogramVec) Write(w io.Writer) error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", h.name, helpReplacer.Replace(h.help), h.name)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	var keys []string
+	for k := range h.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		o := h.values[k]
+		labels := formatLabels(h.labels, k)
+
+		var cumulative uint64
+		for i, b := range h.buckets {
+			cumulative += o.counts[i]
+			_, err := fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, withLabel(labels, "le", fmt.Sprint(b)), cumulative)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+		_, err := fmt.Fprintf(w, "%s_bucket%s %d\n%s_sum%s %v\n%s_count%s %d\n", h.name, withLabel(labels, "le", "+Inf"), o.count, h.name, labels, o.sum, h.name, labels, o.count)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+func (h *HistogramVec) key(labelValues []string) string {
+	if len(labelValues) != len(h.labels) {
+		panic(fmt.Sprintf("metric %#q expects %d label values, got %d", h.name, len(h.labels), len(labelValues)))
+	}
+
+	return strings.Join(labelValues, "\xff")
+}
+
+// formatLabel returns the label pair in the text exposition format.
+func formatLabel(name, value string) string {
+	return name + `="` + labelValueReplacer.Replace(value) + `"`
+}
+
+// withLabel adds the given label to labels formatted by formatLabels.
+func withLabel(labels, name, value string) string {
+	pair := formatLabel(name, value)
+	if labels == "" {
+		return "{" + pair + "}"
+	}
+
+	return strings.TrimSuffix(labels, "}") + "," + pair + "}"
+}
+
+// Registry holds the collectors to render.
+type Registry struct {
+	mutex      sync.Mutex
+	collectors []Collector
+}
+
+// DefaultRegistry is the registry the route53-manager metrics are registered
+// in.
+var DefaultRegistry = &Registry{}
+
+// MustRegister adds the collectors to the registry. It panics when a
+// collector with the same name is already registered, as the rendered
+// metrics would be rejected by the text format parser.
+func (r *Registry) MustRegister(collectors ...Collector) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, c := range collectors {
+		for _, registered := range r.collectors {
+			if registered.Name() == c.Name() {
+				panic(fmt.Sprintf("metric %#q is already registered", c.Name()))
+			}
+		}
+		r.collectors = append(r.collectors, c)
+	}
+}
+
+// WriteText renders all collectors in the Prometheus text exposition format.
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, c := range r.collectors {
+		err := c.Write(w)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+// WriteTextFile renders all collectors to the given file. The file is
+// replaced atomically so collectors never read partial files. It is world
+// readable, as e.g. the node exporter usually runs as another user.
+func (r *Registry) WriteTextFile(path string) error {
+	f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	defer os.Remove(f.Name())
+
+	err = r.WriteText(f)
+	if err != nil {
+		f.Close()
+		return microerror.Mask(err)
+	}
+
+	err = f.Chmod(0644)
+	if err != nil {
+		f.Close()
+		return microerror.Mask(err)
+	}
+
+	err = f.Close()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = os.Rename(f.Name(), path)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,135 @@
+package metrics
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRegistry_WriteText(t *testing.T) {
+	c := NewCounterVec("test_total", "Test counter.", "reason")
+	c.Inc("b")
+	c.Inc("a")
+	c.Add(2, "b")
+
+	r := &Registry{}
+	r.MustRegister(c)
+
+	var out bytes.Buffer
+	err := r.WriteText(&out)
+	if err != nil {
+		t.Fatalf("WriteText: %v", err)
+	}
+
+	expected := `# HELP route53_manager_test_total Test counter.
+# TYPE route53_manager_test_total counter
+route53_manager_test_total{reason="a"} 1
+route53_manager_test_total{reason="b"} 3
+`
+	if out.String() != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, out.String())
+	}
+}
+
+func TestRegistry_WriteTextFile(t *testing.T) {
+	c := NewCounterVec("test_total", "Test counter.")
+	c.Inc()
+
+	r := &Registry{}
+	r.MustRegister(c)
+
+	dir, err := ioutil.TempDir("", "metrics")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "route53-manager.prom")
+	err = r.WriteTextFile(path)
+	if err != nil {
+		t.Fatalf("WriteTextFile: %v", err)
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if !bytes.Contains(b, []byte("route53_manager_test_total 1\n")) {
+		t.Errorf("expected counter in file, got\n%s", b)
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if fi.Mode().Perm() != 0644 {
+		t.Errorf("expected file mode %v, got %v", os.FileMode(0644), fi.Mode().Perm())
+	}
+}
+
+func TestHistogramVec_Write(t *testing.T) {
+	h := NewHistogramVec("test_seconds", "Test histogram.", []float64{0.1, 1}, "operation")
+	h.Observe(0.05, "a")
+	h.Observe(0.5, "a")
+	h.Observe(2, "a")
+	h.Observe(1, "b")
+
+	r := &Registry{}
+	r.MustRegister(h)
+
+	var out bytes.Buffer
+	err := r.WriteText(&out)
+	if err != nil {
+		t.Fatalf("WriteText: %v", err)
+	}
+
+	expected := `# HELP route53_manager_test_seconds Test histogram.
+# TYPE route53_manager_test_seconds histogram
+route53_manager_test_seconds_bucket{operation="a",le="0.1"} 1
+route53_manager_test_seconds_bucket{operation="a",le="1"} 2
+route53_manager_test_seconds_bucket{operation="a",le="+Inf"} 3
+route53_manager_test_seconds_sum{operation="a"} 2.55
+route53_manager_test_seconds_count{operation="a"} 3
+route53_manager_test_seconds_bucket{operation="b",le="0.1"} 0
+route53_manager_test_seconds_bucket{operation="b",le="1"} 1
+route53_manager_test_seconds_bucket{operation="b",le="+Inf"} 1
+route53_manager_test_seconds_sum{operation="b"} 1
+route53_manager_test_seconds_count{operation="b"} 1
+`
+	if out.String() != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, out.String())
+	}
+}
+
+func TestCounterVec_Write_Escaping(t *testing.T) {
+	c := NewCounterVec("test_total", "Test counter\\with\nescapes.", "reason")
+	c.Inc("a\\b\"c\nd é")
+
+	var out bytes.Buffer
+	err := c.Write(&out)
+	if err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	expected := `# HELP route53_manager_test_total Test counter\\with\nescapes.
+# TYPE route53_manager_test_total counter
+route53_manager_test_total{reason="a\\b\"c\nd é"} 1
+`
+	if out.String() != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, out.String())
+	}
+}
+
+func TestRegistry_MustRegister_Duplicate(t *testing.T) {
+	r := &Registry{}
+	r.MustRegister(NewCounterVec("test_total", "Test counter."))
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected panic for duplicate metric name")
+		}
+	}()
+	r.MustRegister(NewHistogramVec("test_total", "Test histogram.", DefBuckets))
+}
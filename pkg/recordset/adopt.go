@@ -0,0 +1,67 @@
+package recordset
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+)
+
+const (
+	// managedByTag marks the target stacks owned by route53-manager. It is
+	// added to every created target stack and to adopted ones.
+	managedByTag   = "giantswarm.io/managed-by"
+	managedByValue = "route53-manager"
+)
+
+// applyOwnership adds the managed-by tag to the update of a target stack
+// which is already managed by route53-manager. Target stacks without the tag,
+// e.g. created out-of-band or by an older route53-manager version, are
+// adopted when m.adoptExisting is set. Otherwise they are updated without
+// taking ownership and the missing tag is reported.
+func (m *Manager) applyOwnership(input *cloudformation.UpdateStackInput, targetStack cloudformation.Stack) {
+	if isManaged(targetStack) {
+		input.Tags = withManagedByTag(input.Tags)
+		return
+	}
+
+	if !m.adoptExisting {
+		m.logSkipped(fmt.Sprintf("target stack %#q has no managed-by tag, not adopting it", *input.StackName))
+		return
+	}
+
+	input.Tags = withManagedByTag(input.Tags)
+	m.logger.Log("level", "info", "message", fmt.Sprintf("adopting target stack %#q", *input.StackName))
+}
+
+// isManaged returns true when the stack carries the managed-by tag of
+// route53-manager.
+func isManaged(stack cloudformation.Stack) bool {
+	for _, t := range stack.Tags {
+		if aws.StringValue(t.Key) == managedByTag && aws.StringValue(t.Value) == managedByValue {
+			return true
+		}
+	}
+
+	return false
+}
+
+// withManagedByTag returns the tags extended by the managed-by tag of
+// route53-manager. Any other managed-by tag is replaced.
+func withManagedByTag(tags []*cloudformation.Tag) []*cloudformation.Tag {
+	var result []*cloudformation.Tag
+	for _, t := range tags {
+		if aws.StringValue(t.Key) == managedByTag {
+			continue
+		}
+		result = append(result, t)
+	}
+
+	t := &cloudformation.Tag{
+		Key:   aws.String(managedByTag),
+		Value: aws.String(managedByValue),
+	}
+	result = append(result, t)
+
+	return result
+}
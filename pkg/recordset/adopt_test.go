@@ -0,0 +1,114 @@
+package recordset
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+)
+
+func TestSync_AdoptExisting(t *testing.T) {
+	tags := []*cloudformation.Tag{
+		&cloudformation.Tag{
+			Key:   aws.String(installationTag),
+			Value: aws.String("installation"),
+		},
+	}
+	managedTags := withManagedByTag(tags)
+
+	tcs := []struct {
+		name             string
+		adoptExisting    bool
+		targetStacks     []cloudformation.Stack
+		expectedManaged  bool
+		expectedCreation bool
+	}{
+		{
+			name:          "case 0: target stack without managed-by tag is not adopted",
+			adoptExisting: false,
+			targetStacks: []cloudformation.Stack{
+				{
+					StackName:   aws.String("cluster-foo-guest-recordsets"),
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+					Tags:        tags,
+				},
+			},
+			expectedManaged: false,
+		},
+		{
+			name:          "case 1: target stack without managed-by tag is adopted",
+			adoptExisting: true,
+			targetStacks: []cloudformation.Stack{
+				{
+					StackName:   aws.String("cluster-foo-guest-recordsets"),
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+					Tags:        tags,
+				},
+			},
+			expectedManaged: true,
+		},
+		{
+			name:          "case 2: managed target stack keeps managed-by tag",
+			adoptExisting: false,
+			targetStacks: []cloudformation.Stack{
+				{
+					StackName:   aws.String("cluster-foo-guest-recordsets"),
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+					Tags:        managedTags,
+				},
+			},
+			expectedManaged: true,
+		},
+		{
+			name:             "case 3: created target stack gets managed-by tag",
+			adoptExisting:    false,
+			expectedManaged:  true,
+			expectedCreation: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			sourceClient := newSourceWithStacks([]cloudformation.Stack{
+				{
+					StackName:   aws.String("cluster-foo-tccp"),
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+					Tags:        tags,
+				},
+			})
+			targetClient := newTargetWithStacks(tc.targetStacks)
+
+			c := newTestConfig(t)
+			c.SourceClient = sourceClient
+			c.TargetClient = targetClient
+			c.AdoptExisting = tc.adoptExisting
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			err = m.Sync()
+			if err != nil {
+				t.Fatalf("m.Sync: %v", err)
+			}
+
+			var stackTags []*cloudformation.Tag
+			if tc.expectedCreation {
+				if len(targetClient.createStackInputs) != 1 {
+					t.Fatalf("expected 1 creation, got %d", len(targetClient.createStackInputs))
+				}
+				stackTags = targetClient.createStackInputs[0].Tags
+			} else {
+				if len(targetClient.updateStackInputs) != 1 {
+					t.Fatalf("expected 1 update, got %d", len(targetClient.updateStackInputs))
+				}
+				stackTags = targetClient.updateStackInputs[0].Tags
+			}
+
+			managed := isManaged(cloudformation.Stack{Tags: stackTags})
+			if managed != tc.expectedManaged {
+				t.Errorf("expected managed-by tag %t, got tags %v", tc.expectedManaged, stackTags)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package recordset
+
+import (
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/route53-manager/pkg/client"
+)
+
+// flattenApexCNAMEs turns CNAME records at the apex of their hosted zone,
+// which Route53 rejects, into A alias records of the ELB they point at. It
+// applies to the records of every RecordSource. The canonical hosted zone ID
+// of an ELB which was not looked up during the sync, e.g. when its DNS name
+// was read from the source stack outputs or computed by a custom
+// RecordSource, is looked up among the load balancers of the source account
+// of the cluster.
+func (m *Manager) flattenApexCNAMEs(cluster Cluster, records []DesiredRecord) ([]DesiredRecord, error) {
+	hostedZoneNames := map[string]string{
+		m.targetHostedZoneID: m.targetHostedZoneName,
+		m.etcdHostedZoneID:   m.etcdHostedZoneName,
+	}
+	for _, z := range m.regionHostedZones {
+		hostedZoneNames[z.ID] = z.Name
+	}
+	if m.emitPTR {
+		hostedZoneNames[m.reverseHostedZoneID] = m.reverseHostedZoneName
+	}
+
+	var result []DesiredRecord
+	for _, r := range records {
+		hostedZoneID := m.targetHostedZoneID
+		if r.HostedZoneID != "" {
+			hostedZoneID = r.HostedZoneID
+		}
+		hostedZoneName, ok := hostedZoneNames[hostedZoneID]
+		if r.Type != route53.RRTypeCname || !ok || !equalDNSNames(r.Name, hostedZoneName) {
+			result = append(result, r)
+			continue
+		}
+
+		elbHostedZoneID, err := m.elbHostedZoneID(m.sourceClientOf(cluster), r.Values[0])
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		if elbHostedZoneID == "" {
+			return nil, microerror.Maskf(apexAliasUnavailableError, "record %#q at the apex of hosted zone %#q must be an alias record, but the canonical hosted zone ID of %#q is unknown", r.ResourceName, hostedZoneName, r.Values[0])
+		}
+
+		r.Type = route53.RRTypeA
+		r.AliasTarget = &AliasTarget{
+			HostedZoneID: elbHostedZoneID,
+			DNSName:      r.Values[0],
+		}
+		r.Values = nil
+		result = append(result, r)
+	}
+
+	return result, nil
+}
+
+// elbHostedZoneID returns the canonical hosted zone ID of the load balancer
+// with the given DNS name, or an empty string when the source account has no
+// such load balancer. Load balancers which were not looked up during the sync
+// are found in the listing of the source account, which is done once per
+// sync run.
+func (m *Manager) elbHostedZoneID(cl client.SourceInterface, dnsName string) (string, error) {
+	id, ok := m.elbHostedZoneIDs[dnsName]
+	if ok {
+		return id, nil
+	}
+
+	lbs, err := m.cachedTaggedELBs(cl)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	for _, lb := range lbs {
+		if equalDNSNames(lb.DNSName, dnsName) {
+			m.elbHostedZoneIDs[dnsName] = lb.CanonicalHostedZoneID
+			return lb.CanonicalHostedZoneID, nil
+		}
+	}
+
+	return "", nil
+}
+
+// equalDNSNames compares the DNS names regardless of their case and trailing
+// dot.
+func equalDNSNames(a, b string) bool {
+	return normalizeRecordName(a) == normalizeRecordName(b)
+}
@@ -0,0 +1,145 @@
+package recordset
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+func TestGetRecords_ApexCNAME(t *testing.T) {
+	tcs := []struct {
+		name           string
+		record         DesiredRecord
+		lookupELB      bool
+		expectedRecord DesiredRecord
+		errorMatcher   func(error) bool
+	}{
+		{
+			name: "case 0: CNAME below the apex is kept",
+			record: DesiredRecord{
+				ResourceName: "apiDNSRecord",
+				Name:         "api.foo.zoneName",
+				Type:         route53.RRTypeCname,
+				Values:       []string{"elb.dns.test"},
+			},
+			lookupELB: true,
+			expectedRecord: DesiredRecord{
+				ResourceName: "apiDNSRecord",
+				Name:         "api.foo.zoneName",
+				Type:         route53.RRTypeCname,
+				Values:       []string{"elb.dns.test"},
+			},
+		},
+		{
+			name: "case 1: CNAME at the apex becomes an alias record",
+			record: DesiredRecord{
+				ResourceName: "apiDNSRecord",
+				Name:         "zoneName.",
+				Type:         route53.RRTypeCname,
+				Values:       []string{"elb.dns.test"},
+			},
+			lookupELB: true,
+			expectedRecord: DesiredRecord{
+				ResourceName: "apiDNSRecord",
+				Name:         "zoneName.",
+				Type:         route53.RRTypeA,
+				AliasTarget: &AliasTarget{
+					HostedZoneID: "elbZoneID",
+					DNSName:      "elb.dns.test",
+				},
+			},
+		},
+		{
+			name: "case 2: CNAME at the apex of another hosted zone",
+			record: DesiredRecord{
+				ResourceName: "apiDNSRecord",
+				Name:         "etcdZoneName",
+				Type:         route53.RRTypeCname,
+				Values:       []string{"elb.dns.test"},
+				HostedZoneID: "etcdZoneID",
+			},
+			lookupELB: true,
+			expectedRecord: DesiredRecord{
+				ResourceName: "apiDNSRecord",
+				Name:         "etcdZoneName",
+				Type:         route53.RRTypeA,
+				AliasTarget: &AliasTarget{
+					HostedZoneID: "elbZoneID",
+					DNSName:      "elb.dns.test",
+				},
+				HostedZoneID: "etcdZoneID",
+			},
+		},
+		{
+			name: "case 3: CNAME at the apex of an ELB not looked up during the sync",
+			record: DesiredRecord{
+				ResourceName: "apiDNSRecord",
+				Name:         "zoneName",
+				Type:         route53.RRTypeCname,
+				Values:       []string{"elb.dns.test."},
+			},
+			lookupELB: false,
+			expectedRecord: DesiredRecord{
+				ResourceName: "apiDNSRecord",
+				Name:         "zoneName",
+				Type:         route53.RRTypeA,
+				AliasTarget: &AliasTarget{
+					HostedZoneID: "elbZoneID",
+					DNSName:      "elb.dns.test",
+				},
+			},
+		},
+		{
+			name: "case 4: CNAME at the apex without ELB",
+			record: DesiredRecord{
+				ResourceName: "apiDNSRecord",
+				Name:         "zoneName",
+				Type:         route53.RRTypeCname,
+				Values:       []string{"other.dns.test"},
+			},
+			lookupELB:    false,
+			errorMatcher: IsApexAliasUnavailable,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newTestConfig(t)
+			c.EtcdHostedZoneID = "etcdZoneID"
+			c.EtcdHostedZoneName = "etcdZoneName"
+			c.RecordSource = &recordSourceMock{
+				records: map[string][]DesiredRecord{
+					"foo": {tc.record},
+				},
+			}
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			if tc.lookupELB {
+				_, err = m.getELB(c.SourceClient, "foo-api")
+				if err != nil {
+					t.Fatalf("getELB: %v", err)
+				}
+			}
+
+			records, err := m.getRecords(Cluster{ID: "foo"})
+			if tc.errorMatcher != nil {
+				if !tc.errorMatcher(err) {
+					t.Fatalf("expected matching error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("getRecords: %v", err)
+			}
+
+			expected := []DesiredRecord{tc.expectedRecord}
+			if !reflect.DeepEqual(expected, records) {
+				t.Errorf("expected records %+v, got %+v", expected, records)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package recordset
+
+import (
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	// APIRecordTypeTag is the source stack tag overriding the type of the api
+	// record of a cluster, either `CNAME` or `A`.
+	APIRecordTypeTag = "giantswarm.io/api-record-type"
+)
+
+// validAPIRecordType returns true for the supported api record types. A
+// records are alias records of the api ELB.
+func validAPIRecordType(recordType string) bool {
+	return recordType == route53.RRTypeCname || recordType == route53.RRTypeA
+}
+
+// clusterAPIRecordType returns the type of the api record of the cluster, either
+// from the APIRecordTypeTag of its source stack or m.apiRecordType.
+func (m *Manager) clusterAPIRecordType(cluster Cluster) (string, error) {
+	recordType, ok := m.clusterTags(cluster)[APIRecordTypeTag]
+	if !ok {
+		return m.apiRecordType, nil
+	}
+	if !validAPIRecordType(recordType) {
+		return "", microerror.Maskf(invalidConfigError, "tag %#q of cluster %#q must be %#q or %#q, got %#q", APIRecordTypeTag, cluster.ID, route53.RRTypeCname, route53.RRTypeA, recordType)
+	}
+
+	return recordType, nil
+}
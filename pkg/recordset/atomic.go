@@ -0,0 +1,262 @@
+package recordset
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/route53-manager/pkg/recordset/plan"
+)
+
+const (
+	// ApplyModeCloudFormation applies the records of a cluster through its
+	// target stack.
+	ApplyModeCloudFormation = "cloudformation"
+	// ApplyModeRoute53Atomic applies the records of a cluster directly through
+	// one Route53 change batch per hosted zone, so they are updated
+	// atomically. The managed clusters are discovered through their target
+	// stacks, if any, and their metadata records.
+	ApplyModeRoute53Atomic = "route53-atomic"
+	// ApplyModeRoute53Direct applies the records like ApplyModeRoute53Atomic
+	// without any target stack, e.g. for hosted zones not managed by
+	// CloudFormation. The managed clusters are discovered through their
+	// metadata records instead.
+	ApplyModeRoute53Direct = "route53-direct"
+)
+
+const (
+	recordSetTTLSeconds = 30
+)
+
+// applyRecordsAtomically replaces the create and update phases in
+// ApplyModeRoute53Atomic and ApplyModeRoute53Direct. The desired record sets
+// of each planned cluster which differ from the current ones are upserted in
+// one change batch per hosted zone, together with the deletion of managed
+// record sets which are no longer desired. Clusters whose target stack is
+// busy or being deleted are deferred like in the other mode.
+func (m *Manager) applyRecordsAtomically(refs []plan.ClusterRef) error {
+	m.logger.Log("level", "debug", "message", "apply records atomically")
+	for _, ref := range refs {
+		m.startClusterLogs()
+		m.startClusterReconcile(auditOperationUpdate)
+
+		err := m.checkDeadline()
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		err = m.renewLock()
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		source := *ref.SourceStack
+
+		records, err := m.getRecords(m.newCluster(ref))
+		if err != nil {
+			m.skipRecordsFailed(*source.StackName, ref.ID, err)
+			continue
+		}
+
+		changed, err := m.applyClusterRecords(ref.ID, records)
+		m.audit(ref.ID, auditOperationUpdate, "", auditOutcome(err), err)
+		if err != nil {
+			m.logger.Log("level", "error", "message", fmt.Sprintf("failed to apply records of cluster %#q", ref.ID), "stack", microerror.JSON(err))
+			m.summary.failed++
+			continue
+		}
+		if !changed {
+			m.logSkipped(fmt.Sprintf("records of cluster %#q are up to date", ref.ID))
+			m.summary.unchanged++
+			continue
+		}
+
+		m.logger.Log("level", "debug", "message", fmt.Sprintf("applied records of cluster %#q", ref.ID))
+		m.summary.addUpdated(ref.ID)
+	}
+	m.flushClusterLogs()
+	m.observeClusterReconcile()
+
+	return nil
+}
+
+// applyClusterRecords submits one change batch per hosted zone the records of
+// the cluster live in, unless the batch would be empty. It returns true when
+// any batch was submitted.
+func (m *Manager) applyClusterRecords(clusterName string, records []DesiredRecord) (bool, error) {
+	recordsByZone := map[string][]DesiredRecord{}
+	for _, r := range records {
+		hostedZoneID := m.targetHostedZoneID
+		if r.HostedZoneID != "" {
+			hostedZoneID = r.HostedZoneID
+		}
+		recordsByZone[hostedZoneID] = append(recordsByZone[hostedZoneID], r)
+	}
+
+	var hostedZoneIDs []string
+	for hostedZoneID := range recordsByZone {
+		hostedZoneIDs = append(hostedZoneIDs, hostedZoneID)
+	}
+	sort.Strings(hostedZoneIDs)
+
+	var changed bool
+	for _, hostedZoneID := range hostedZoneIDs {
+		managedRecordSets := m.getManagedHostedZoneRecordSets(hostedZoneID, clusterName)
+
+		changes, err := m.getAtomicChanges(hostedZoneID, recordsByZone[hostedZoneID], managedRecordSets)
+		if err != nil {
+			return false, microerror.Mask(err)
+		}
+		if len(changes) == 0 {
+			continue
+		}
+
+		input := &route53.ChangeResourceRecordSetsInput{
+			ChangeBatch: &route53.ChangeBatch{
+				Changes: changes,
+				Comment: m.changeComment(fmt.Sprintf("route53-manager records of cluster %s", clusterName)),
+			},
+			HostedZoneId: aws.String(hostedZoneID),
+		}
+
+		output, err := m.changeResourceRecordSets(m.targetClient, input)
+		if err != nil {
+			return false, microerror.Mask(err)
+		}
+		changed = true
+
+		err = m.waitForChange(output.ChangeInfo)
+		if err != nil {
+			return false, microerror.Mask(err)
+		}
+	}
+
+	return changed, nil
+}
+
+// getManagedHostedZoneRecordSets returns the names of the record sets of the
+// cluster managed in the given hosted zone.
+func (m *Manager) getManagedHostedZoneRecordSets(hostedZoneID, clusterName string) []string {
+	hostedZoneName, isMain := m.mainHostedZoneName(hostedZoneID)
+
+	switch {
+	case m.etcdHostedZoneID == m.targetHostedZoneID && isMain:
+		return getManagedRecordSets(clusterName, hostedZoneName, m.components, m.caaValue != "", m.bareDomainRecord, m.metadataRecord)
+	case isMain:
+		return getManagedMainRecordSets(clusterName, hostedZoneName, m.components, m.caaValue != "", m.bareDomainRecord, m.metadataRecord)
+	case hostedZoneID == m.etcdHostedZoneID:
+		return getManagedEtcdRecordSets(clusterName, m.etcdHostedZoneName, m.components)
+	}
+
+	return nil
+}
+
+// getAtomicChanges returns an UPSERT change for every desired record which
+// differs from the current record set and a DELETE change for every managed
+// record set in the hosted zone which is no longer desired.
+func (m *Manager) getAtomicChanges(hostedZoneID string, records []DesiredRecord, managedRecordSets []string) ([]*route53.Change, error) {
+	recordSets, err := listRecordSets(m.ctx, m.targetClient, hostedZoneID)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	current := map[string]*route53.ResourceRecordSet{}
+	for _, rr := range recordSets {
+		current[recordSetKey(*rr.Name, *rr.Type, aws.StringValue(rr.SetIdentifier))] = rr
+	}
+
+	var upserts []*route53.Change
+	desired := map[string]bool{}
+	for _, r := range records {
+		recordSet := &route53.ResourceRecordSet{
+			Name: aws.String(route53RecordName(r.Name)),
+			Type: aws.String(r.Type),
+		}
+		if r.AliasTarget != nil {
+			recordSet.AliasTarget = &route53.AliasTarget{
+				DNSName:              aws.String(r.AliasTarget.DNSName),
+				EvaluateTargetHealth: aws.Bool(false),
+				HostedZoneId:         aws.String(r.AliasTarget.HostedZoneID),
+			}
+		} else {
+			for _, v := range r.Values {
+				recordSet.ResourceRecords = append(recordSet.ResourceRecords, &route53.ResourceRecord{
+					Value: aws.String(v),
+				})
+			}
+			recordSet.TTL = aws.Int64(recordSetTTLSeconds)
+			if r.TTL > 0 {
+				recordSet.TTL = aws.Int64(r.TTL)
+			}
+		}
+		if r.Weight != nil {
+			recordSet.Weight = aws.Int64(*r.Weight)
+			recordSet.SetIdentifier = aws.String(r.SetIdentifier)
+		}
+
+		if hostedZoneID == m.targetHostedZoneID {
+			applyFailoverRecordSet(recordSet, m.failover)
+		}
+
+		k := recordSetKey(*recordSet.Name, *recordSet.Type, aws.StringValue(recordSet.SetIdentifier))
+		desired[k] = true
+		if rr, ok := current[k]; ok && equalRecordSets(rr, recordSet) {
+			continue
+		}
+		upserts = append(upserts, &route53.Change{
+			Action:            aws.String(route53.ChangeActionUpsert),
+			ResourceRecordSet: recordSet,
+		})
+	}
+
+	// Deletions come first, so e.g. a CNAME record can be replaced by an
+	// alias record of the same name within the batch, or a simple record by
+	// a weighted one.
+	var changes []*route53.Change
+	for _, rr := range recordSets {
+		name := normalizeRecordName(*rr.Name)
+		if !stringInSlice(name, managedRecordSets) || desired[recordSetKey(name, *rr.Type, aws.StringValue(rr.SetIdentifier))] {
+			continue
+		}
+		// The cluster domain is managed for its CAA record, but it may also
+		// hold e.g. the NS records delegating it, which must be kept.
+		if *rr.Type == route53.RRTypeNs || *rr.Type == route53.RRTypeSoa {
+			continue
+		}
+		// With failover or weighted routing the record sets of the other
+		// installations share the names of ours and must be kept.
+		if hostedZoneID == m.targetHostedZoneID && m.failover.enabled() && aws.StringValue(rr.SetIdentifier) != m.failover.SetIdentifier {
+			continue
+		}
+		if rr.Weight != nil && aws.StringValue(rr.SetIdentifier) != m.setIdentifier {
+			continue
+		}
+
+		changes = append(changes, &route53.Change{
+			Action:            aws.String(route53.ChangeActionDelete),
+			ResourceRecordSet: rr,
+		})
+	}
+
+	return append(changes, upserts...), nil
+}
+
+// equalRecordSets compares the current record set with the desired one
+// regardless of the order, case and trailing dots of their values.
+func equalRecordSets(current, desired *route53.ResourceRecordSet) bool {
+	if (current.AliasTarget == nil) != (desired.AliasTarget == nil) {
+		return false
+	}
+	if current.AliasTarget != nil && aws.StringValue(current.AliasTarget.HostedZoneId) != aws.StringValue(desired.AliasTarget.HostedZoneId) {
+		return false
+	}
+
+	return aws.Int64Value(current.TTL) == aws.Int64Value(desired.TTL) &&
+		aws.Int64Value(current.Weight) == aws.Int64Value(desired.Weight) &&
+		(current.Weight == nil) == (desired.Weight == nil) &&
+		aws.StringValue(current.Failover) == aws.StringValue(desired.Failover) &&
+		aws.StringValue(current.HealthCheckId) == aws.StringValue(desired.HealthCheckId) &&
+		equalRecordValues(recordSetValues(current), recordSetValues(desired))
+}
@@ -0,0 +1,295 @@
+package recordset
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+func TestSync_ApplyModeRoute53Atomic(t *testing.T) {
+	tcs := []struct {
+		name               string
+		etcdHostedZoneID   string
+		etcdHostedZoneName string
+		caaValue           string
+		expectedChanges    map[string][]string
+	}{
+		{
+			name: "case 0: one batch with all records",
+			expectedChanges: map[string][]string{
+				"zoneID": {
+					"DELETE ingress.foo.zoneName.",
+					"UPSERT \\052.foo.zoneName.",
+					"UPSERT _meta.foo.zoneName.",
+					"UPSERT api.foo.zoneName.",
+					"UPSERT etcd.foo.zoneName.",
+					"UPSERT etcd0.foo.zoneName.",
+					"UPSERT etcd1.foo.zoneName.",
+				},
+			},
+		},
+		{
+			name:               "case 1: one batch per hosted zone",
+			etcdHostedZoneID:   "etcdZoneID",
+			etcdHostedZoneName: "etcdZoneName",
+			expectedChanges: map[string][]string{
+				"zoneID": {
+					"DELETE ingress.foo.zoneName.",
+					"UPSERT \\052.foo.zoneName.",
+					"UPSERT _meta.foo.zoneName.",
+					"UPSERT api.foo.zoneName.",
+				},
+				"etcdZoneID": {
+					"UPSERT etcd.foo.etcdZoneName.",
+					"UPSERT etcd0.foo.etcdZoneName.",
+					"UPSERT etcd1.foo.etcdZoneName.",
+				},
+			},
+		},
+		{
+			name:     "case 2: CAA record of the cluster domain",
+			caaValue: `0 issue "letsencrypt.org"`,
+			expectedChanges: map[string][]string{
+				"zoneID": {
+					"DELETE ingress.foo.zoneName.",
+					"UPSERT \\052.foo.zoneName.",
+					"UPSERT _meta.foo.zoneName.",
+					"UPSERT api.foo.zoneName.",
+					"UPSERT etcd.foo.zoneName.",
+					"UPSERT etcd0.foo.zoneName.",
+					"UPSERT etcd1.foo.zoneName.",
+					"UPSERT foo.zoneName.",
+				},
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			sourceStacks := []cloudformation.Stack{
+				cloudformation.Stack{
+					StackName:   aws.String("cluster-foo-tccp"),
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+					Tags: []*cloudformation.Tag{
+						&cloudformation.Tag{
+							Key:   aws.String(installationTag),
+							Value: aws.String("installation"),
+						},
+					},
+				},
+			}
+			targetClient := newTargetWithStacks(nil)
+			targetClient.recordSets = map[string][]*route53.ResourceRecordSet{
+				"zoneID": {
+					{Name: aws.String("\\052.foo.zoneName."), Type: aws.String(route53.RRTypeCname)},
+					{Name: aws.String("ingress.foo.zoneName."), Type: aws.String(route53.RRTypeCname)},
+					{Name: aws.String("vault.foo.zoneName."), Type: aws.String(route53.RRTypeCname)},
+					{Name: aws.String("foo.zoneName."), Type: aws.String(route53.RRTypeNs)},
+				},
+			}
+
+			c := newTestConfig(t)
+			c.SourceClient = newSourceWithStacks(sourceStacks)
+			c.TargetClient = targetClient
+			c.ApplyMode = ApplyModeRoute53Atomic
+			c.EtcdHostedZoneID = tc.etcdHostedZoneID
+			c.EtcdHostedZoneName = tc.etcdHostedZoneName
+			c.CAAValue = tc.caaValue
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			err = m.Sync()
+			if err != nil {
+				t.Fatalf("m.Sync: %v", err)
+			}
+
+			if len(targetClient.createdStacks) != 0 || len(targetClient.updatedStacks) != 0 {
+				t.Errorf("expected no target stack changes, got created %v and updated %v", targetClient.createdStacks, targetClient.updatedStacks)
+			}
+			for hostedZoneID := range tc.expectedChanges {
+				if targetClient.changeBatches[hostedZoneID] != 1 {
+					t.Errorf("expected 1 change batch in hosted zone %#q, got %d", hostedZoneID, targetClient.changeBatches[hostedZoneID])
+				}
+			}
+
+			changes := map[string][]string{}
+			for hostedZoneID, cs := range targetClient.changes {
+				for _, c := range cs {
+					changes[hostedZoneID] = append(changes[hostedZoneID], *c.Action+" "+*c.ResourceRecordSet.Name)
+				}
+				sort.Strings(changes[hostedZoneID])
+			}
+			if !reflect.DeepEqual(changes, tc.expectedChanges) {
+				t.Errorf("expected changes %v, got %v", tc.expectedChanges, changes)
+			}
+		})
+	}
+}
+
+func TestSync_ApplyModeRoute53AtomicUnchanged(t *testing.T) {
+	sourceStacks := []cloudformation.Stack{
+		cloudformation.Stack{
+			StackName:   aws.String("cluster-foo-tccp"),
+			StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+			Tags: []*cloudformation.Tag{
+				&cloudformation.Tag{
+					Key:   aws.String(installationTag),
+					Value: aws.String("installation"),
+				},
+			},
+		},
+	}
+	targetClient := newTargetWithStacks(nil)
+
+	c := newTestConfig(t)
+	c.SourceClient = newSourceWithStacks(sourceStacks)
+	c.TargetClient = targetClient
+	c.ApplyMode = ApplyModeRoute53Atomic
+	m, err := NewManager(c)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	err = m.Sync()
+	if err != nil {
+		t.Fatalf("m.Sync: %v", err)
+	}
+	if m.summary.updated != 1 {
+		t.Fatalf("expected 1 updated cluster, got %s", m.summary)
+	}
+
+	// The second run finds the record sets applied by the first one.
+	targetClient.recordSets = map[string][]*route53.ResourceRecordSet{}
+	for _, c := range targetClient.changes["zoneID"] {
+		targetClient.recordSets["zoneID"] = append(targetClient.recordSets["zoneID"], c.ResourceRecordSet)
+	}
+	targetClient.changes = nil
+	targetClient.changeBatches = nil
+
+	err = m.Sync()
+	if err != nil {
+		t.Fatalf("m.Sync: %v", err)
+	}
+	if len(targetClient.changeBatches) != 0 {
+		t.Errorf("expected no change batches, got %v", targetClient.changeBatches)
+	}
+	if m.summary.updated != 0 || m.summary.unchanged != 1 {
+		t.Errorf("expected 0 updated and 1 unchanged clusters, got %s", m.summary)
+	}
+}
+
+func TestSync_ApplyModeRoute53AtomicOrphans(t *testing.T) {
+	metadataRecordSet := func(clusterID, installation string) *route53.ResourceRecordSet {
+		return &route53.ResourceRecordSet{
+			Name: aws.String("_meta." + clusterID + ".zoneName."),
+			Type: aws.String(route53.RRTypeTxt),
+			ResourceRecords: []*route53.ResourceRecord{
+				{Value: aws.String(`"installation=` + installation + ` created=2020-01-01T12:00:00Z"`)},
+			},
+		}
+	}
+
+	tcs := []struct {
+		name                  string
+		targetStacks          []cloudformation.Stack
+		recordSets            []*route53.ResourceRecordSet
+		expectedChanges       []string
+		expectedDeletedStacks []string
+		expectedDeleted       int
+	}{
+		{
+			name: "case 0: delete records of an orphan cluster without target stack",
+			recordSets: []*route53.ResourceRecordSet{
+				metadataRecordSet("foo", "installation"),
+				{Name: aws.String("api.foo.zoneName."), Type: aws.String(route53.RRTypeCname)},
+			},
+			expectedChanges: []string{
+				"DELETE _meta.foo.zoneName.",
+				"DELETE api.foo.zoneName.",
+			},
+			expectedDeleted: 1,
+		},
+		{
+			name: "case 1: delete target stack of an orphan cluster",
+			targetStacks: []cloudformation.Stack{
+				{
+					StackName:   aws.String("cluster-foo-guest-recordsets"),
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+					Tags: []*cloudformation.Tag{
+						{
+							Key:   aws.String(installationTag),
+							Value: aws.String("installation"),
+						},
+					},
+				},
+			},
+			recordSets: []*route53.ResourceRecordSet{
+				metadataRecordSet("foo", "installation"),
+			},
+			expectedDeletedStacks: []string{"cluster-foo-guest-recordsets"},
+			expectedDeleted:       1,
+		},
+		{
+			name: "case 2: keep records of a cluster of another installation",
+			recordSets: []*route53.ResourceRecordSet{
+				metadataRecordSet("bar", "other"),
+				{Name: aws.String("api.bar.zoneName."), Type: aws.String(route53.RRTypeCname)},
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			targetClient := newTargetWithStacks(tc.targetStacks)
+			targetClient.stackNotFoundErrors = true
+			targetClient.recordSets = map[string][]*route53.ResourceRecordSet{
+				"zoneID": tc.recordSets,
+			}
+
+			c := newTestConfig(t)
+			c.SourceClient = newSourceWithStacks(nil)
+			c.TargetClient = targetClient
+			c.ApplyMode = ApplyModeRoute53Atomic
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			err = m.Sync()
+			if err != nil {
+				t.Fatalf("m.Sync: %v", err)
+			}
+
+			if !reflect.DeepEqual(targetClient.deletedStacks, tc.expectedDeletedStacks) {
+				t.Errorf("expected deleted stacks %v, got %v", tc.expectedDeletedStacks, targetClient.deletedStacks)
+			}
+			var changes []string
+			for _, c := range targetClient.changes["zoneID"] {
+				changes = append(changes, *c.Action+" "+*c.ResourceRecordSet.Name)
+			}
+			sort.Strings(changes)
+			if !reflect.DeepEqual(changes, tc.expectedChanges) {
+				t.Errorf("expected changes %v, got %v", tc.expectedChanges, changes)
+			}
+			if m.summary.deleted != tc.expectedDeleted {
+				t.Errorf("expected %d deleted, got %s", tc.expectedDeleted, m.summary)
+			}
+		})
+	}
+}
+
+func TestNewManager_InvalidApplyMode(t *testing.T) {
+	c := newTestConfig(t)
+	c.ApplyMode = "terraform"
+
+	_, err := NewManager(c)
+	if !IsInvalidConfig(err) {
+		t.Errorf("expected invalid config error, got %v", err)
+	}
+}
@@ -0,0 +1,98 @@
+package recordset
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	auditOperationCreate = "create"
+	auditOperationUpdate = "update"
+	auditOperationDelete = "delete"
+
+	auditOutcomeSuccess = "success"
+	auditOutcomeFailure = "failure"
+	// auditOutcomePending is used for updates left in a change set for manual
+	// execution.
+	auditOutcomePending = "pending"
+)
+
+// auditRecord is a line of the audit log, describing one mutation of the
+// records of a cluster.
+type auditRecord struct {
+	Time         time.Time `json:"time"`
+	Installation string    `json:"installation"`
+	ClusterID    string    `json:"cluster_id"`
+	Operation    string    `json:"operation"`
+	// StackName is the target stack mutated. It is empty for records applied
+	// in ApplyModeRoute53Atomic and ApplyModeRoute53Direct.
+	StackName string `json:"stack_name,omitempty"`
+	Outcome   string `json:"outcome"`
+	Error     string `json:"error,omitempty"`
+	Version   string `json:"version,omitempty"`
+}
+
+// syncer is implemented by audit logs which can be flushed to stable storage,
+// e.g. *os.File.
+type syncer interface {
+	Sync() error
+}
+
+// audit appends a record of the mutation to m.auditLog, if any, and flushes
+// it. Failing to write the record is logged, but does not fail the mutation.
+func (m *Manager) audit(clusterID, operation, stackName, outcome string, err error) {
+	if m.auditLog == nil {
+		return
+	}
+
+	r := auditRecord{
+		Time:         m.now().UTC(),
+		Installation: m.installation,
+		ClusterID:    clusterID,
+		Operation:    operation,
+		StackName:    stackName,
+		Outcome:      outcome,
+		Version:      m.version,
+	}
+	if err != nil {
+		r.Error = err.Error()
+	}
+
+	err = m.writeAuditRecord(r)
+	if err != nil {
+		m.logger.Log("level", "error", "message", fmt.Sprintf("failed to write audit record of %s of cluster %#q", operation, clusterID), "stack", microerror.JSON(err))
+	}
+}
+
+func (m *Manager) writeAuditRecord(r auditRecord) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	_, err = m.auditLog.Write(append(b, '\n'))
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if s, ok := m.auditLog.(syncer); ok {
+		err = s.Sync()
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+// auditOutcome returns the audit outcome of a mutation failing with err.
+func auditOutcome(err error) string {
+	if err != nil {
+		return auditOutcomeFailure
+	}
+
+	return auditOutcomeSuccess
+}
@@ -0,0 +1,118 @@
+package recordset
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+)
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestSync_AuditLog(t *testing.T) {
+	tags := []*cloudformation.Tag{
+		&cloudformation.Tag{
+			Key:   aws.String(installationTag),
+			Value: aws.String("installation"),
+		},
+	}
+	sourceStacks := []cloudformation.Stack{
+		cloudformation.Stack{
+			StackName:   aws.String("cluster-foo-guest-main"),
+			StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+			Tags:        tags,
+		},
+		cloudformation.Stack{
+			StackName:   aws.String("cluster-bar-guest-main"),
+			StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+			Tags:        tags,
+		},
+	}
+	targetStacks := []cloudformation.Stack{
+		cloudformation.Stack{
+			StackName:   aws.String("cluster-bar-guest-recordsets"),
+			StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+			Tags:        tags,
+		},
+		cloudformation.Stack{
+			StackName:   aws.String("cluster-baz-guest-recordsets"),
+			StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+			Tags:        tags,
+		},
+	}
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("case 0: one audit record per mutation", func(t *testing.T) {
+		targetClient := newTargetWithStacks(targetStacks)
+		targetClient.deleteStackError = errors.New("access denied")
+
+		var auditLog bytes.Buffer
+		c := newTestConfig(t)
+		c.SourceClient = newSourceWithStacks(sourceStacks)
+		c.TargetClient = targetClient
+		c.Version = "1a2b"
+		c.AuditLog = &auditLog
+		m, err := NewManager(c)
+		if err != nil {
+			t.Fatalf("NewManager: %v", err)
+		}
+		m.now = func() time.Time { return now }
+
+		err = m.Sync()
+		if err != nil {
+			t.Fatalf("m.Sync: %v", err)
+		}
+
+		var records []auditRecord
+		s := bufio.NewScanner(&auditLog)
+		for s.Scan() {
+			var r auditRecord
+			err = json.Unmarshal(s.Bytes(), &r)
+			if err != nil {
+				t.Fatalf("json.Unmarshal: %v", err)
+			}
+			records = append(records, r)
+		}
+
+		expected := []auditRecord{
+			{Time: now, Installation: "installation", ClusterID: "foo", Operation: auditOperationCreate, StackName: "cluster-foo-guest-recordsets", Outcome: auditOutcomeSuccess, Version: "1a2b"},
+			{Time: now, Installation: "installation", ClusterID: "bar", Operation: auditOperationUpdate, StackName: "cluster-bar-guest-recordsets", Outcome: auditOutcomeSuccess, Version: "1a2b"},
+			{Time: now, Installation: "installation", ClusterID: "baz", Operation: auditOperationDelete, StackName: "cluster-baz-guest-recordsets", Outcome: auditOutcomeFailure, Error: "access denied", Version: "1a2b"},
+		}
+		if !reflect.DeepEqual(records, expected) {
+			t.Errorf("expected audit records %+v, got %+v", expected, records)
+		}
+	})
+
+	t.Run("case 1: failing audit log does not fail the mutation", func(t *testing.T) {
+		targetClient := newTargetWithStacks(targetStacks)
+
+		c := newTestConfig(t)
+		c.SourceClient = newSourceWithStacks(sourceStacks)
+		c.TargetClient = targetClient
+		c.AuditLog = failingWriter{}
+		m, err := NewManager(c)
+		if err != nil {
+			t.Fatalf("NewManager: %v", err)
+		}
+
+		err = m.Sync()
+		if err != nil {
+			t.Fatalf("m.Sync: %v", err)
+		}
+
+		if len(targetClient.createdStacks) != 1 || m.summary.failed != 0 {
+			t.Errorf("expected target stack to be created, got created stacks %v and %d failures", targetClient.createdStacks, m.summary.failed)
+		}
+	})
+}
@@ -0,0 +1,57 @@
+package recordset
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	// DefaultPriorRequestRetries is the number of times a record set change
+	// is retried while a prior change of the hosted zone is in flight, when
+	// no limit is configured.
+	DefaultPriorRequestRetries = 5
+
+	priorRequestInitialInterval = 1 * time.Second
+	priorRequestMaxInterval     = 16 * time.Second
+)
+
+// recordSetsChanger is implemented by the target and parent clients.
+type recordSetsChanger interface {
+	ChangeResourceRecordSetsWithContext(aws.Context, *route53.ChangeResourceRecordSetsInput, ...request.Option) (*route53.ChangeResourceRecordSetsOutput, error)
+}
+
+// changeResourceRecordSets submits the change batch and retries it up to
+// m.priorRequestRetries times while Route53 rejects it because a prior change
+// of the hosted zone is not complete yet. The interval between attempts
+// doubles up to priorRequestMaxInterval.
+func (m *Manager) changeResourceRecordSets(cl recordSetsChanger, input *route53.ChangeResourceRecordSetsInput) (*route53.ChangeResourceRecordSetsOutput, error) {
+	interval := priorRequestInitialInterval
+
+	for attempt := 0; ; attempt++ {
+		output, err := cl.ChangeResourceRecordSetsWithContext(m.ctx, input)
+		if IsPriorRequestNotComplete(err) && attempt < m.priorRequestRetries {
+			err = m.checkDeadline()
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+
+			m.logger.Log("level", "debug", "message", fmt.Sprintf("prior change of hosted zone %#q not complete, retrying in %s", aws.StringValue(input.HostedZoneId), interval))
+			m.sleep(interval)
+
+			interval *= 2
+			if interval > priorRequestMaxInterval {
+				interval = priorRequestMaxInterval
+			}
+			continue
+		} else if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		return output, nil
+	}
+}
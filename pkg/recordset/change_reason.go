@@ -0,0 +1,59 @@
+package recordset
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+)
+
+const (
+	// changeReasonTag holds the reason of the sync run which last created or
+	// updated a target stack, see Config.ChangeReason.
+	changeReasonTag = "giantswarm.io/route53-manager-change-reason"
+
+	// maxChangeReasonLength keeps the change batch comments including the
+	// change reason below the 256 characters accepted by Route53.
+	maxChangeReasonLength = 128
+)
+
+// changeComment returns the given Route53 change batch comment extended by
+// the change reason of the sync run, if any.
+func (m *Manager) changeComment(comment string) *string {
+	if m.changeReason == "" {
+		return aws.String(comment)
+	}
+
+	return aws.String(fmt.Sprintf("%s (reason: %s)", comment, m.changeReason))
+}
+
+// changeReasonStackTag returns the stack tag holding the change reason of the
+// sync run. It returns nil when no change reason is set.
+func (m *Manager) changeReasonStackTag() *cloudformation.Tag {
+	if m.changeReason == "" {
+		return nil
+	}
+
+	t := &cloudformation.Tag{
+		Key:   aws.String(changeReasonTag),
+		Value: aws.String(m.changeReason),
+	}
+
+	return t
+}
+
+// applyCurrentChangeReason keeps the change reason tag of the target stack
+// when the sync run has no change reason, so the reason of the last change
+// is not dropped by an update without one.
+func (m *Manager) applyCurrentChangeReason(input *cloudformation.UpdateStackInput, targetStack cloudformation.Stack) {
+	if m.changeReason != "" {
+		return
+	}
+
+	for _, t := range targetStack.Tags {
+		if aws.StringValue(t.Key) == changeReasonTag {
+			input.Tags = append(input.Tags, t)
+			return
+		}
+	}
+}
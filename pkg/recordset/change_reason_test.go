@@ -0,0 +1,195 @@
+package recordset
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+)
+
+func TestSync_ChangeReason(t *testing.T) {
+	tcs := []struct {
+		name             string
+		changeReason     string
+		applyMode        string
+		expectedTag      string
+		expectedComments []string
+	}{
+		{
+			name:         "case 0: change reason tag of created target stack",
+			changeReason: "INC-123 bypass",
+			applyMode:    ApplyModeCloudFormation,
+			expectedTag:  "INC-123 bypass",
+		},
+		{
+			name:         "case 1: change reason comment of atomic changes",
+			changeReason: "INC-123 bypass",
+			applyMode:    ApplyModeRoute53Atomic,
+			expectedComments: []string{
+				"route53-manager records of cluster foo (reason: INC-123 bypass)",
+			},
+		},
+		{
+			name:      "case 2: no change reason",
+			applyMode: ApplyModeRoute53Atomic,
+			expectedComments: []string{
+				"route53-manager records of cluster foo",
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			sourceClient := newSourceWithStacks([]cloudformation.Stack{
+				{
+					StackName:   aws.String("cluster-foo-tccp"),
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+					Tags: []*cloudformation.Tag{
+						{
+							Key:   aws.String(installationTag),
+							Value: aws.String("installation"),
+						},
+						{
+							Key:   aws.String(changeReasonTag),
+							Value: aws.String("copied from source stack"),
+						},
+					},
+				},
+			})
+			targetClient := newTargetWithStacks(nil)
+
+			c := newTestConfig(t)
+			c.SourceClient = sourceClient
+			c.TargetClient = targetClient
+			c.ApplyMode = tc.applyMode
+			c.ChangeReason = tc.changeReason
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			err = m.Sync()
+			if err != nil {
+				t.Fatalf("Sync: %v", err)
+			}
+
+			if tc.applyMode == ApplyModeCloudFormation {
+				if len(targetClient.createStackInputs) != 1 {
+					t.Fatalf("expected 1 created target stack, got %d", len(targetClient.createStackInputs))
+				}
+				var values []string
+				for _, tag := range targetClient.createStackInputs[0].Tags {
+					if aws.StringValue(tag.Key) == changeReasonTag {
+						values = append(values, aws.StringValue(tag.Value))
+					}
+				}
+				expectedValues := []string{tc.expectedTag}
+				if !reflect.DeepEqual(expectedValues, values) {
+					t.Errorf("expected change reason tags %v, got %v", expectedValues, values)
+				}
+			}
+			if !reflect.DeepEqual(tc.expectedComments, targetClient.changeComments) {
+				t.Errorf("expected change comments %v, got %v", tc.expectedComments, targetClient.changeComments)
+			}
+		})
+	}
+}
+
+func TestNewManager_ChangeReason(t *testing.T) {
+	c := newTestConfig(t)
+	c.ChangeReason = string(make([]byte, maxChangeReasonLength+1))
+	_, err := NewManager(c)
+	if !IsInvalidConfig(err) {
+		t.Fatalf("expected invalid config error, got %v", err)
+	}
+}
+
+func TestManager_ApplyCurrentChangeReason(t *testing.T) {
+	tcs := []struct {
+		name         string
+		changeReason string
+		currentTags  []*cloudformation.Tag
+		expectedTags []*cloudformation.Tag
+	}{
+		{
+			name: "case 0: current change reason kept without change reason",
+			currentTags: []*cloudformation.Tag{
+				{Key: aws.String(changeReasonTag), Value: aws.String("INC-123 bypass")},
+			},
+			expectedTags: []*cloudformation.Tag{
+				{Key: aws.String(installationTag), Value: aws.String("installation")},
+				{Key: aws.String(changeReasonTag), Value: aws.String("INC-123 bypass")},
+			},
+		},
+		{
+			name:         "case 1: current change reason replaced by change reason",
+			changeReason: "INC-456 rollback",
+			currentTags: []*cloudformation.Tag{
+				{Key: aws.String(changeReasonTag), Value: aws.String("INC-123 bypass")},
+			},
+			expectedTags: []*cloudformation.Tag{
+				{Key: aws.String(installationTag), Value: aws.String("installation")},
+			},
+		},
+		{
+			name: "case 2: no current change reason",
+			expectedTags: []*cloudformation.Tag{
+				{Key: aws.String(installationTag), Value: aws.String("installation")},
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newTestConfig(t)
+			c.ChangeReason = tc.changeReason
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			input := &cloudformation.UpdateStackInput{
+				Tags: []*cloudformation.Tag{
+					{Key: aws.String(installationTag), Value: aws.String("installation")},
+				},
+			}
+			m.applyCurrentChangeReason(input, cloudformation.Stack{Tags: tc.currentTags})
+
+			if !reflect.DeepEqual(tc.expectedTags, input.Tags) {
+				t.Errorf("expected tags %v, got %v", tc.expectedTags, input.Tags)
+			}
+		})
+	}
+}
+
+func TestManager_IsTagOnlyUpdate_ChangeReason(t *testing.T) {
+	c := newTestConfig(t)
+	m, err := NewManager(c)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	input := &cloudformation.UpdateStackInput{
+		StackName:    aws.String("cluster-foo-tccp-target"),
+		TemplateBody: aws.String("{}"),
+		Tags: []*cloudformation.Tag{
+			{Key: aws.String(installationTag), Value: aws.String("installation")},
+			{Key: aws.String(changeReasonTag), Value: aws.String("INC-456 rollback")},
+		},
+	}
+	targetStack := cloudformation.Stack{
+		Tags: []*cloudformation.Tag{
+			{Key: aws.String(installationTag), Value: aws.String("installation")},
+			{Key: aws.String(changeReasonTag), Value: aws.String("INC-123 bypass")},
+		},
+	}
+
+	tagOnly, err := m.isTagOnlyUpdate(input, targetStack)
+	if err != nil {
+		t.Fatalf("isTagOnlyUpdate: %v", err)
+	}
+	if tagOnly {
+		t.Errorf("expected a change reason alone not to be a tag only update")
+	}
+}
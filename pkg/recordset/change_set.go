@@ -0,0 +1,242 @@
+package recordset
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	// SkipReasonChangeSetPending is used for target stacks whose update is
+	// left as change set for manual execution.
+	SkipReasonChangeSetPending SkipReason = "change_set_pending"
+)
+
+const (
+	changeSetNamePrefix = "route53-manager-"
+
+	changeSetTimeout         = 2 * time.Minute
+	changeSetInitialInterval = 1 * time.Second
+	changeSetMaxInterval     = 10 * time.Second
+)
+
+// updateTargetStackWithChangeSet applies the update through a change set
+// instead of UpdateStack. The change set is executed when
+// m.autoExecuteChangeSets is set, otherwise it is left for manual review and
+// pending is true. A change set still pending from a previous run is reused
+// when it applies the same update and replaced otherwise, so at most one
+// change set per target stack waits for review. The stack policy of the
+// input is not applied, as change sets do not support it. Change sets
+// without changes result in noUpdateNeededError.
+func (m *Manager) updateTargetStackWithChangeSet(input *cloudformation.UpdateStackInput) (bool, error) {
+	if !m.autoExecuteChangeSets {
+		pendingName, err := m.replacePendingChangeSets(input)
+		if err != nil {
+			return false, microerror.Mask(err)
+		}
+		if pendingName != "" {
+			m.logger.Log("level", "info", "message", fmt.Sprintf("change set %#q of target stack %#q is still pending for manual execution", pendingName, *input.StackName))
+			return true, nil
+		}
+	}
+
+	err := m.locateUpdateTemplate(input)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	changeSetName := changeSetNamePrefix + m.now().UTC().Format("20060102150405")
+
+	createInput := &cloudformation.CreateChangeSetInput{
+		ChangeSetName:       aws.String(changeSetName),
+		ChangeSetType:       aws.String(cloudformation.ChangeSetTypeUpdate),
+		NotificationARNs:    input.NotificationARNs,
+		StackName:           input.StackName,
+		Tags:                input.Tags,
+		TemplateBody:        input.TemplateBody,
+		TemplateURL:         input.TemplateURL,
+		UsePreviousTemplate: input.UsePreviousTemplate,
+	}
+	_, err = m.targetClient.CreateChangeSetWithContext(m.ctx, createInput)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	err = m.waitForChangeSet(*input.StackName, changeSetName)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	if !m.autoExecuteChangeSets {
+		m.logger.Log("level", "info", "message", fmt.Sprintf("created change set %#q of target stack %#q for manual execution", changeSetName, *input.StackName))
+		return true, nil
+	}
+
+	executeInput := &cloudformation.ExecuteChangeSetInput{
+		ChangeSetName: aws.String(changeSetName),
+		StackName:     input.StackName,
+	}
+	_, err = m.targetClient.ExecuteChangeSetWithContext(m.ctx, executeInput)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	m.logger.Log("level", "debug", "message", fmt.Sprintf("executed change set %#q of target stack %#q", changeSetName, *input.StackName))
+
+	return false, nil
+}
+
+// replacePendingChangeSets returns the name of the change set created by
+// route53-manager which is pending for manual execution of the target stack
+// and applies the same template and tags as the input, if any. All other
+// pending or failed change sets of route53-manager are deleted, as they are
+// outdated.
+func (m *Manager) replacePendingChangeSets(input *cloudformation.UpdateStackInput) (string, error) {
+	var summaries []*cloudformation.ChangeSetSummary
+	listInput := &cloudformation.ListChangeSetsInput{
+		StackName: input.StackName,
+	}
+	for {
+		output, err := m.targetClient.ListChangeSetsWithContext(m.ctx, listInput)
+		if err != nil {
+			return "", microerror.Mask(err)
+		}
+		summaries = append(summaries, output.Summaries...)
+
+		if output.NextToken == nil {
+			break
+		}
+		listInput.NextToken = output.NextToken
+	}
+
+	var pendingName string
+	for _, s := range summaries {
+		name := aws.StringValue(s.ChangeSetName)
+		if !strings.HasPrefix(name, changeSetNamePrefix) {
+			continue
+		}
+
+		available := aws.StringValue(s.Status) == cloudformation.ChangeSetStatusCreateComplete &&
+			aws.StringValue(s.ExecutionStatus) == cloudformation.ExecutionStatusAvailable
+		failed := aws.StringValue(s.Status) == cloudformation.ChangeSetStatusFailed
+		if !available && !failed {
+			continue
+		}
+
+		if available && pendingName == "" {
+			equal, err := m.equalChangeSet(input, name)
+			if err != nil {
+				return "", microerror.Mask(err)
+			}
+			if equal {
+				pendingName = name
+				continue
+			}
+		}
+
+		deleteInput := &cloudformation.DeleteChangeSetInput{
+			ChangeSetName: aws.String(name),
+			StackName:     input.StackName,
+		}
+		_, err := m.targetClient.DeleteChangeSetWithContext(m.ctx, deleteInput)
+		if err != nil {
+			return "", microerror.Mask(err)
+		}
+
+		m.logger.Log("level", "debug", "message", fmt.Sprintf("deleted outdated change set %#q of target stack %#q", name, *input.StackName))
+	}
+
+	return pendingName, nil
+}
+
+// equalChangeSet returns true when the change set applies the template body
+// and tags of the input. Inputs reusing the previous template are never
+// equal.
+func (m *Manager) equalChangeSet(input *cloudformation.UpdateStackInput, changeSetName string) (bool, error) {
+	if input.TemplateBody == nil {
+		return false, nil
+	}
+
+	templateInput := &cloudformation.GetTemplateInput{
+		ChangeSetName: aws.String(changeSetName),
+		StackName:     input.StackName,
+		TemplateStage: aws.String(cloudformation.TemplateStageOriginal),
+	}
+	templateOutput, err := m.targetClient.GetTemplateWithContext(m.ctx, templateInput)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+	if aws.StringValue(templateOutput.TemplateBody) != aws.StringValue(input.TemplateBody) {
+		return false, nil
+	}
+
+	describeInput := &cloudformation.DescribeChangeSetInput{
+		ChangeSetName: aws.String(changeSetName),
+		StackName:     input.StackName,
+	}
+	describeOutput, err := m.targetClient.DescribeChangeSetWithContext(m.ctx, describeInput)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	return equalStackTags(describeOutput.Tags, input.Tags), nil
+}
+
+// waitForChangeSet polls the change set until it is created, for at most
+// changeSetTimeout. The interval between polls doubles up to
+// changeSetMaxInterval.
+func (m *Manager) waitForChangeSet(stackName, changeSetName string) error {
+	deadline := m.now().Add(changeSetTimeout)
+	interval := changeSetInitialInterval
+
+	for {
+		input := &cloudformation.DescribeChangeSetInput{
+			ChangeSetName: aws.String(changeSetName),
+			StackName:     aws.String(stackName),
+		}
+		output, err := m.targetClient.DescribeChangeSetWithContext(m.ctx, input)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		switch aws.StringValue(output.Status) {
+		case cloudformation.ChangeSetStatusCreateComplete:
+			return nil
+		case cloudformation.ChangeSetStatusFailed:
+			if isEmptyChangeSet(aws.StringValue(output.StatusReason)) {
+				return microerror.Maskf(noUpdateNeededError, "change set %#q of target stack %#q contains no changes", changeSetName, stackName)
+			}
+			return microerror.Maskf(changeSetFailedError, "change set %#q of target stack %#q: %s", changeSetName, stackName, aws.StringValue(output.StatusReason))
+		}
+
+		err = m.checkDeadline()
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		remaining := deadline.Sub(m.now())
+		if remaining <= 0 {
+			return microerror.Maskf(waitTimeoutError, "change set %#q of target stack %#q not created after %s", changeSetName, stackName, changeSetTimeout)
+		}
+		if interval > remaining {
+			interval = remaining
+		}
+		m.sleep(interval)
+
+		interval *= 2
+		if interval > changeSetMaxInterval {
+			interval = changeSetMaxInterval
+		}
+	}
+}
+
+// isEmptyChangeSet returns true for the status reason of change sets which
+// failed because the template and tags are unchanged.
+func isEmptyChangeSet(statusReason string) bool {
+	return strings.Contains(statusReason, "didn't contain changes") ||
+		strings.Contains(statusReason, "No updates are to be performed")
+}
@@ -0,0 +1,265 @@
+package recordset
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+)
+
+func TestSync_UseChangeSets(t *testing.T) {
+	tags := []*cloudformation.Tag{
+		&cloudformation.Tag{
+			Key:   aws.String(installationTag),
+			Value: aws.String("installation"),
+		},
+	}
+
+	tcs := []struct {
+		name                  string
+		autoExecute           bool
+		changeSetStatuses     []string
+		changeSetStatusReason string
+		expectedExecuted      []string
+		expectedSleeps        []time.Duration
+		expectedSummary       syncSummary
+		expectedPending       int
+	}{
+		{
+			name:             "case 0: execute change set",
+			autoExecute:      true,
+			expectedExecuted: []string{"route53-manager-20200101120000"},
+			expectedSummary:  syncSummary{updated: 1, updatedClusters: []string{"foo"}},
+		},
+		{
+			name:            "case 1: leave change set for manual execution",
+			autoExecute:     false,
+			expectedSummary: syncSummary{},
+			expectedPending: 1,
+		},
+		{
+			name:                  "case 2: change set without changes",
+			autoExecute:           true,
+			changeSetStatuses:     []string{cloudformation.ChangeSetStatusFailed},
+			changeSetStatusReason: "The submitted information didn't contain changes. Submit different information to create a change set.",
+			expectedSummary:       syncSummary{unchanged: 1},
+		},
+		{
+			name:              "case 3: wait for change set creation",
+			autoExecute:       true,
+			changeSetStatuses: []string{cloudformation.ChangeSetStatusCreatePending, cloudformation.ChangeSetStatusCreateInProgress},
+			expectedExecuted:  []string{"route53-manager-20200101120000"},
+			expectedSleeps:    []time.Duration{1 * time.Second, 2 * time.Second},
+			expectedSummary:   syncSummary{updated: 1, updatedClusters: []string{"foo"}},
+		},
+		{
+			name:                  "case 4: failed change set",
+			autoExecute:           true,
+			changeSetStatuses:     []string{cloudformation.ChangeSetStatusFailed},
+			changeSetStatusReason: "Internal failure",
+			expectedSummary:       syncSummary{failed: 1},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			sourceClient := newSourceWithStacks([]cloudformation.Stack{
+				{
+					StackName:   aws.String("cluster-foo-tccp"),
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+					Tags:        tags,
+				},
+			})
+			targetClient := newTargetWithStacks([]cloudformation.Stack{
+				{
+					StackName:   aws.String("cluster-foo-guest-recordsets"),
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+					Tags:        tags,
+				},
+			})
+			targetClient.changeSetStatuses = tc.changeSetStatuses
+			targetClient.changeSetStatusReason = tc.changeSetStatusReason
+
+			c := newTestConfig(t)
+			c.SourceClient = sourceClient
+			c.TargetClient = targetClient
+			c.UseChangeSets = true
+			c.AutoExecuteChangeSets = tc.autoExecute
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+			var sleeps []time.Duration
+			m.now = func() time.Time { return now }
+			m.sleep = func(d time.Duration) {
+				sleeps = append(sleeps, d)
+			}
+
+			err = m.Sync()
+			if err != nil {
+				t.Fatalf("m.Sync: %v", err)
+			}
+
+			if len(targetClient.updatedStacks) != 0 {
+				t.Errorf("expected no UpdateStack calls, got %v", targetClient.updatedStacks)
+			}
+			if len(targetClient.createChangeSetInputs) != 1 {
+				t.Fatalf("expected 1 change set, got %d", len(targetClient.createChangeSetInputs))
+			}
+			input := targetClient.createChangeSetInputs[0]
+			if aws.StringValue(input.ChangeSetType) != cloudformation.ChangeSetTypeUpdate {
+				t.Errorf("expected change set type %#q, got %#q", cloudformation.ChangeSetTypeUpdate, aws.StringValue(input.ChangeSetType))
+			}
+			if aws.StringValue(input.StackName) != "cluster-foo-guest-recordsets" {
+				t.Errorf("expected change set of %#q, got %#q", "cluster-foo-guest-recordsets", aws.StringValue(input.StackName))
+			}
+			if input.TemplateBody == nil {
+				t.Errorf("expected change set template body")
+			}
+
+			if !reflect.DeepEqual(tc.expectedExecuted, targetClient.executedChangeSets) {
+				t.Errorf("expected executed change sets %v, got %v", tc.expectedExecuted, targetClient.executedChangeSets)
+			}
+			if !reflect.DeepEqual(tc.expectedSleeps, sleeps) {
+				t.Errorf("expected sleeps %v, got %v", tc.expectedSleeps, sleeps)
+			}
+
+			summary := m.summary
+			summary.skipped = nil
+			if !reflect.DeepEqual(summary, tc.expectedSummary) {
+				t.Errorf("expected summary %+v, got %+v", tc.expectedSummary, summary)
+			}
+			if m.summary.skippedCount(SkipReasonChangeSetPending) != tc.expectedPending {
+				t.Errorf("expected %d pending change sets, got %d", tc.expectedPending, m.summary.skippedCount(SkipReasonChangeSetPending))
+			}
+		})
+	}
+}
+
+func TestSync_PendingChangeSets(t *testing.T) {
+	tags := []*cloudformation.Tag{
+		&cloudformation.Tag{
+			Key:   aws.String(installationTag),
+			Value: aws.String("installation"),
+		},
+	}
+	newClients := func() (*sourceClientMock, *targetClientMock) {
+		sourceClient := newSourceWithStacks([]cloudformation.Stack{
+			{
+				StackName:   aws.String("cluster-foo-tccp"),
+				StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+				Tags:        tags,
+			},
+		})
+		targetClient := newTargetWithStacks([]cloudformation.Stack{
+			{
+				StackName:   aws.String("cluster-foo-guest-recordsets"),
+				StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+				Tags:        tags,
+			},
+		})
+
+		return sourceClient, targetClient
+	}
+	newManager := func(t *testing.T, sourceClient *sourceClientMock, targetClient *targetClientMock) *Manager {
+		c := newTestConfig(t)
+		c.SourceClient = sourceClient
+		c.TargetClient = targetClient
+		c.UseChangeSets = true
+		m, err := NewManager(c)
+		if err != nil {
+			t.Fatalf("NewManager: %v", err)
+		}
+		m.now = func() time.Time { return time.Date(2020, 1, 2, 12, 0, 0, 0, time.UTC) }
+		m.sleep = func(time.Duration) {}
+
+		return m
+	}
+
+	// The first run leaves the change set the later runs find pending.
+	sourceClient, targetClient := newClients()
+	err := newManager(t, sourceClient, targetClient).Sync()
+	if err != nil {
+		t.Fatalf("m.Sync: %v", err)
+	}
+	pending := targetClient.createChangeSetInputs[0]
+
+	tcs := []struct {
+		name            string
+		changeSet       string
+		status          string
+		template        string
+		expectedCreated int
+		expectedDeleted []string
+	}{
+		{
+			name:            "case 0: reuse pending change set applying the same update",
+			changeSet:       "route53-manager-20200101120000",
+			status:          cloudformation.ChangeSetStatusCreateComplete,
+			template:        aws.StringValue(pending.TemplateBody),
+			expectedCreated: 0,
+		},
+		{
+			name:            "case 1: replace outdated pending change set",
+			changeSet:       "route53-manager-20200101120000",
+			status:          cloudformation.ChangeSetStatusCreateComplete,
+			template:        "outdated",
+			expectedCreated: 1,
+			expectedDeleted: []string{"route53-manager-20200101120000"},
+		},
+		{
+			name:            "case 2: delete failed change set",
+			changeSet:       "route53-manager-20200101120000",
+			status:          cloudformation.ChangeSetStatusFailed,
+			template:        aws.StringValue(pending.TemplateBody),
+			expectedCreated: 1,
+			expectedDeleted: []string{"route53-manager-20200101120000"},
+		},
+		{
+			name:            "case 3: keep change sets of others",
+			changeSet:       "manual",
+			status:          cloudformation.ChangeSetStatusCreateComplete,
+			template:        aws.StringValue(pending.TemplateBody),
+			expectedCreated: 1,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			sourceClient, targetClient := newClients()
+			targetClient.changeSets = []*cloudformation.ChangeSetSummary{
+				{
+					ChangeSetName:   aws.String(tc.changeSet),
+					Status:          aws.String(tc.status),
+					ExecutionStatus: aws.String(cloudformation.ExecutionStatusAvailable),
+				},
+			}
+			targetClient.changeSetTemplates = map[string]string{
+				tc.changeSet: tc.template,
+			}
+			targetClient.changeSetTags = map[string][]*cloudformation.Tag{
+				tc.changeSet: pending.Tags,
+			}
+			m := newManager(t, sourceClient, targetClient)
+
+			err := m.Sync()
+			if err != nil {
+				t.Fatalf("m.Sync: %v", err)
+			}
+
+			if len(targetClient.createChangeSetInputs) != tc.expectedCreated {
+				t.Errorf("expected %d created change sets, got %d", tc.expectedCreated, len(targetClient.createChangeSetInputs))
+			}
+			if !reflect.DeepEqual(tc.expectedDeleted, targetClient.deletedChangeSets) {
+				t.Errorf("expected deleted change sets %v, got %v", tc.expectedDeleted, targetClient.deletedChangeSets)
+			}
+			if m.summary.skippedCount(SkipReasonChangeSetPending) != 1 {
+				t.Errorf("expected 1 pending change set, got %d", m.summary.skippedCount(SkipReasonChangeSetPending))
+			}
+		})
+	}
+}
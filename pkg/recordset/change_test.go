@@ -0,0 +1,105 @@
+package recordset
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+func TestDeleteTargetLeftovers_PriorRequestNotComplete(t *testing.T) {
+	priorRequestNotComplete := awserr.New(route53.ErrCodePriorRequestNotComplete, "The request was rejected because Route 53 was still processing a prior request.", nil)
+
+	tcs := []struct {
+		name                string
+		priorRequestRetries int
+		changeErrors        []error
+		expectedCalls       int
+		expectedSleeps      []time.Duration
+		expectedChanges     int
+		expectedError       bool
+	}{
+		{
+			name:            "case 0: change applied at once",
+			expectedCalls:   1,
+			expectedChanges: 1,
+		},
+		{
+			name:            "case 1: change retried once",
+			changeErrors:    []error{priorRequestNotComplete},
+			expectedCalls:   2,
+			expectedSleeps:  []time.Duration{1 * time.Second},
+			expectedChanges: 1,
+		},
+		{
+			name:                "case 2: retries exhausted",
+			priorRequestRetries: 2,
+			changeErrors:        []error{priorRequestNotComplete, priorRequestNotComplete, priorRequestNotComplete},
+			expectedCalls:       3,
+			expectedSleeps:      []time.Duration{1 * time.Second, 2 * time.Second},
+			expectedError:       true,
+		},
+		{
+			name:          "case 3: other errors are not retried",
+			changeErrors:  []error{awserr.New(route53.ErrCodeInvalidChangeBatch, "Invalid change batch", nil)},
+			expectedCalls: 1,
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			targetClient := newTargetWithStacks(nil)
+			targetClient.changeErrors = tc.changeErrors
+			targetClient.recordSets = map[string][]*route53.ResourceRecordSet{
+				"zoneID": {
+					&route53.ResourceRecordSet{
+						Name: aws.String("vault.foo.zoneName."),
+						Type: aws.String(route53.RRTypeCname),
+					},
+				},
+			}
+
+			c := newTestConfig(t)
+			c.TargetClient = targetClient
+			c.PriorRequestRetries = tc.priorRequestRetries
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			var sleeps []time.Duration
+			m.sleep = func(d time.Duration) {
+				sleeps = append(sleeps, d)
+			}
+
+			err = m.deleteTargetLeftovers("foo", nil)
+			if tc.expectedError != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tc.expectedError, err)
+			}
+
+			if targetClient.changeRecordCalls != tc.expectedCalls {
+				t.Errorf("expected %d ChangeResourceRecordSets calls, got %d", tc.expectedCalls, targetClient.changeRecordCalls)
+			}
+			if !reflect.DeepEqual(tc.expectedSleeps, sleeps) {
+				t.Errorf("expected sleeps %v, got %v", tc.expectedSleeps, sleeps)
+			}
+			if len(targetClient.changes["zoneID"]) != tc.expectedChanges {
+				t.Errorf("expected %d changes, got %d", tc.expectedChanges, len(targetClient.changes["zoneID"]))
+			}
+		})
+	}
+}
+
+func TestNewManager_InvalidPriorRequestRetries(t *testing.T) {
+	c := newTestConfig(t)
+	c.PriorRequestRetries = -1
+
+	_, err := NewManager(c)
+	if !IsInvalidConfig(err) {
+		t.Fatalf("expected invalid config error, got %v", err)
+	}
+}
@@ -0,0 +1,129 @@
+package recordset
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/route53-manager/pkg/client"
+	"github.com/giantswarm/route53-manager/pkg/recordset/plan"
+)
+
+var (
+	// clusterIDRE matches the cluster IDs which can be extracted from stack
+	// names again, see plan.ClusterID.
+	clusterIDRE = regexp.MustCompile("^[a-z0-9]+$")
+)
+
+// clusterStackName returns the name of the stack of the given cluster matching
+// the given stack name pattern, e.g. `cluster-foo-tccp` for
+// sourceStackNamePattern.
+func clusterStackName(pattern, clusterID string) string {
+	return strings.TrimSuffix(strings.Replace(pattern, ".*", clusterID, 1), "$")
+}
+
+// scoped returns true when the sync run is restricted to a known set of
+// stacks, either the stacks of m.cluster or m.sourceStackNames and their
+// target stacks. The stacks are described by name then.
+func (m *Manager) scoped() bool {
+	return m.cluster != "" || len(m.sourceStackNames) > 0
+}
+
+// scopedClusterIDs returns the IDs of the clusters of a scoped sync run,
+// either m.cluster or the clusters of m.sourceStackNames.
+func (m *Manager) scopedClusterIDs() []string {
+	if m.cluster != "" {
+		return []string{m.cluster}
+	}
+
+	var result []string
+	seen := map[string]bool{}
+	for _, sourceStackName := range m.sourceStackNames {
+		// The source stack names are validated in NewManager.
+		clusterID, _ := plan.ClusterID(sourceStackName)
+		if seen[clusterID] {
+			continue
+		}
+		seen[clusterID] = true
+
+		result = append(result, clusterID)
+	}
+
+	return result
+}
+
+// scopedSourceStackNames returns the names of all source stacks of the
+// clusters of a scoped sync run, so a cluster is not taken for gone while
+// any of its source stacks exists.
+func (m *Manager) scopedSourceStackNames() []string {
+	var result []string
+	for _, clusterID := range m.scopedClusterIDs() {
+		result = append(result,
+			clusterStackName(legacySourceStackNamePattern, clusterID),
+			clusterStackName(sourceStackNamePattern, clusterID),
+		)
+	}
+
+	return result
+}
+
+// scopedTargetStackNames returns the names of the target stacks of the
+// clusters of a scoped sync run. Target stacks of other clusters are never
+// listed, so they are not deleted as orphans either.
+func (m *Manager) scopedTargetStackNames() []string {
+	var result []string
+	for _, clusterID := range m.scopedClusterIDs() {
+		result = append(result, plan.TargetStackName(clusterID))
+	}
+
+	return result
+}
+
+// getStacksByName describes the stacks with the given names instead of
+// listing all stacks of the account. Stacks which do not exist are ignored.
+func (m *Manager) getStacksByName(cl client.StackDescribeLister, stackNames []string) ([]cloudformation.Stack, error) {
+	validStatuses := aws.StringValueSlice(plan.StackStatusValid)
+
+	var result []cloudformation.Stack
+	for _, stackName := range stackNames {
+		stacks, err := describeStacks(m.ctx, cl, stackName)
+		if IsStackNotFound(err) {
+			continue
+		} else if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		key := validStackInstallationTag(stacks, m.installation)
+		if key == -1 {
+			m.skip(stackName, SkipReasonMissingInstallationTag, fmt.Sprintf("skipped stack %#q without installation tag %#q", stackName, m.installation), nil)
+			continue
+		}
+
+		if !plan.HasStatus(*stacks.Stacks[key], validStatuses) {
+			continue
+		}
+
+		result = append(result, *stacks.Stacks[key])
+	}
+
+	return result, nil
+}
+
+// validateSourceStackNames checks that every given stack name matches one of
+// the source stack name patterns.
+func validateSourceStackNames(stackNames []string) error {
+	for _, stackName := range stackNames {
+		summary := cloudformation.StackSummary{
+			StackName: aws.String(stackName),
+		}
+		if !validStackName(summary, sourceStackNameREs) {
+			return microerror.Maskf(invalidConfigError, "source stack name %#q must match %#q or %#q", stackName, legacySourceStackNamePattern, sourceStackNamePattern)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,32 @@
+package recordset
+
+import (
+	"github.com/giantswarm/route53-manager/pkg/logbuffer"
+)
+
+// startClusterLogs flushes the log lines of the previous cluster and, when
+// m.bufferClusterLogs is set, buffers the ones of the next cluster until it is
+// processed. The lines of a cluster are written as a contiguous block then.
+func (m *Manager) startClusterLogs() {
+	m.flushClusterLogs()
+
+	if !m.bufferClusterLogs {
+		return
+	}
+
+	m.unbufferedLogger = m.logger
+	m.clusterLogs = logbuffer.New(m.logger)
+	m.logger = m.clusterLogs
+}
+
+// flushClusterLogs writes the buffered log lines of the current cluster, if
+// any, and stops buffering.
+func (m *Manager) flushClusterLogs() {
+	if m.clusterLogs == nil {
+		return
+	}
+
+	m.clusterLogs.Flush()
+	m.logger = m.unbufferedLogger
+	m.clusterLogs = nil
+}
@@ -0,0 +1,60 @@
+package recordset
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/giantswarm/micrologger"
+)
+
+func TestSync_BufferClusterLogs(t *testing.T) {
+	tags := []*cloudformation.Tag{
+		&cloudformation.Tag{
+			Key:   aws.String(installationTag),
+			Value: aws.String("installation"),
+		},
+	}
+
+	var out bytes.Buffer
+	logger, err := micrologger.New(micrologger.Config{IOWriter: &out})
+	if err != nil {
+		t.Fatalf("micrologger.New: %v", err)
+	}
+
+	c := newTestConfig(t)
+	c.Logger = logger
+	c.SourceClient = newSourceWithStacks([]cloudformation.Stack{
+		{
+			StackName:   aws.String("cluster-foo-tccp"),
+			StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+			Tags:        tags,
+		},
+		{
+			StackName:   aws.String("cluster-bar-tccp"),
+			StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+			Tags:        tags,
+		},
+	})
+	c.BufferClusterLogs = true
+	m, err := NewManager(c)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	err = m.Sync()
+	if err != nil {
+		t.Fatalf("m.Sync: %v", err)
+	}
+
+	for _, stackName := range []string{"cluster-foo-guest-recordsets", "cluster-bar-guest-recordsets"} {
+		if !strings.Contains(out.String(), "created target stack `"+stackName+"`") {
+			t.Errorf("expected flushed log line of target stack %#q, got\n%s", stackName, out.String())
+		}
+	}
+	if m.clusterLogs != nil || m.logger != logger {
+		t.Errorf("expected unbuffered logger after sync")
+	}
+}
@@ -0,0 +1,54 @@
+package recordset
+
+import (
+	"time"
+
+	"github.com/giantswarm/route53-manager/pkg/metrics"
+)
+
+var (
+	// clusterReconcileBuckets are the upper bounds of the cluster reconcile
+	// duration buckets in seconds. Reconciling a cluster takes several AWS
+	// calls and may wait for Route53 changes to be in sync.
+	clusterReconcileBuckets = []float64{.1, .25, .5, 1, 2.5, 5, 10, 30, 60, 120, 300}
+
+	clusterReconcileDuration = metrics.NewHistogramVec("cluster_reconcile_seconds", "Duration of the reconciliation of single clusters in seconds, by operation when enabled.", clusterReconcileBuckets, "operation")
+)
+
+func init() {
+	metrics.DefaultRegistry.MustRegister(clusterReconcileDuration)
+}
+
+// clusterReconcile is the reconciliation of a cluster being measured.
+type clusterReconcile struct {
+	operation string
+	start     time.Time
+}
+
+// startClusterReconcile observes the duration of the reconciliation of the
+// previous cluster, if any, and starts measuring the one of the next cluster
+// with the given operation, e.g. auditOperationCreate. The operation label is
+// left empty unless m.clusterMetricsOperationLabel is set, which Prometheus
+// treats like a missing label.
+func (m *Manager) startClusterReconcile(operation string) {
+	m.observeClusterReconcile()
+
+	if !m.clusterMetricsOperationLabel {
+		operation = ""
+	}
+	m.clusterReconcile = &clusterReconcile{
+		operation: operation,
+		start:     m.now(),
+	}
+}
+
+// observeClusterReconcile observes the duration of the reconciliation of the
+// current cluster, if any, and stops measuring.
+func (m *Manager) observeClusterReconcile() {
+	if m.clusterReconcile == nil {
+		return
+	}
+
+	clusterReconcileDuration.Observe(m.now().Sub(m.clusterReconcile.start).Seconds(), m.clusterReconcile.operation)
+	m.clusterReconcile = nil
+}
@@ -0,0 +1,109 @@
+package recordset
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+)
+
+func TestSync_ClusterReconcileDuration(t *testing.T) {
+	tags := []*cloudformation.Tag{
+		{
+			Key:   aws.String(installationTag),
+			Value: aws.String("installation"),
+		},
+	}
+
+	tcs := []struct {
+		name           string
+		operationLabel bool
+		applyMode      string
+		expectedCounts map[string]uint64
+	}{
+		{
+			name: "case 0: without operation label",
+			expectedCounts: map[string]uint64{
+				"": 3,
+			},
+		},
+		{
+			name:           "case 1: with operation label",
+			operationLabel: true,
+			expectedCounts: map[string]uint64{
+				"create": 1,
+				"update": 1,
+				"delete": 1,
+			},
+		},
+		{
+			name:           "case 2: atomic apply mode",
+			operationLabel: true,
+			applyMode:      ApplyModeRoute53Atomic,
+			expectedCounts: map[string]uint64{
+				"create": 0,
+				"update": 2,
+				"delete": 1,
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			clusterReconcileDuration.Reset()
+
+			sourceClient := newSourceWithStacks([]cloudformation.Stack{
+				{
+					StackName:   aws.String("cluster-foo-tccp"),
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+					Tags:        tags,
+				},
+				{
+					StackName:   aws.String("cluster-bar-tccp"),
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+					Tags:        tags,
+				},
+			})
+			targetClient := newTargetWithStacks([]cloudformation.Stack{
+				{
+					StackName:   aws.String("cluster-bar-guest-recordsets"),
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+					Tags:        tags,
+				},
+				{
+					StackName:   aws.String("cluster-baz-guest-recordsets"),
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+					Tags:        tags,
+				},
+			})
+
+			c := newTestConfig(t)
+			c.SourceClient = sourceClient
+			c.TargetClient = targetClient
+			c.ApplyMode = tc.applyMode
+			c.ClusterMetricsOperationLabel = tc.operationLabel
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+			now := time.Now()
+			m.now = func() time.Time {
+				now = now.Add(time.Second)
+				return now
+			}
+
+			err = m.Sync()
+			if err != nil {
+				t.Fatalf("Sync: %v", err)
+			}
+
+			for operation, expected := range tc.expectedCounts {
+				count := clusterReconcileDuration.Count(operation)
+				if count != expected {
+					t.Errorf("expected %d observations of operation %#q, got %d", expected, operation, count)
+				}
+			}
+		})
+	}
+}
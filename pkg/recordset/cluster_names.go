@@ -0,0 +1,58 @@
+package recordset
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/route53-manager/pkg/recordset/plan"
+)
+
+var (
+	// stackKindPatterns are the stack name patterns the names of the stacks
+	// of each kind are derived from.
+	stackKindPatterns = map[StackKind]string{
+		StackKindLegacy: legacySourceStackNamePattern,
+		StackKindTCCP:   sourceStackNamePattern,
+		StackKindTarget: targetStackNamePattern,
+	}
+)
+
+// checkClusterNames logs every source and target stack whose name cannot be
+// derived from the cluster ID extracted from it, e.g. stacks of clusters with
+// hyphenated IDs. The source and target stacks of such clusters are never
+// matched, so target stacks are created twice or deleted as orphans. With
+// m.strictClusterNames the sync run fails before changing anything.
+func (m *Manager) checkClusterNames(sourceStacks, targetStacks []cloudformation.Stack) error {
+	var mismatches int
+	for _, stacks := range [][]cloudformation.Stack{sourceStacks, targetStacks} {
+		for _, stack := range stacks {
+			stackName := *stack.StackName
+
+			pattern, ok := stackKindPatterns[getStackKind(stackName)]
+			if !ok {
+				continue
+			}
+			clusterID, err := plan.ClusterID(stackName)
+			if err != nil {
+				// The plan computation skips stacks with invalid names.
+				continue
+			}
+
+			expected := clusterStackName(pattern, clusterID)
+			if expected == stackName {
+				continue
+			}
+
+			m.logger.Log("level", "error", "message", fmt.Sprintf("stack %#q does not match its cluster %#q, whose stack would be named %#q, the source and target stacks of the cluster cannot be matched", stackName, clusterID, expected))
+			mismatches++
+		}
+	}
+
+	if mismatches > 0 && m.strictClusterNames {
+		return microerror.Maskf(clusterNameMismatchError, "%d stack names do not match their cluster", mismatches)
+	}
+
+	return nil
+}
@@ -0,0 +1,146 @@
+package recordset
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/giantswarm/micrologger"
+)
+
+func TestSync_ClusterNameMismatch(t *testing.T) {
+	tags := []*cloudformation.Tag{
+		&cloudformation.Tag{
+			Key:   aws.String(installationTag),
+			Value: aws.String("installation"),
+		},
+	}
+
+	tcs := []struct {
+		name               string
+		sourceStacks       []cloudformation.Stack
+		targetStacks       []cloudformation.Stack
+		strictClusterNames bool
+		expectedMismatches []string
+		expectedError      bool
+	}{
+		{
+			name: "case 0: matching names",
+			sourceStacks: []cloudformation.Stack{
+				cloudformation.Stack{
+					StackName:   aws.String("cluster-foo-tccp"),
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+					Tags:        tags,
+				},
+				cloudformation.Stack{
+					StackName:   aws.String("cluster-bar-guest-main"),
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+					Tags:        tags,
+				},
+			},
+			targetStacks: []cloudformation.Stack{
+				cloudformation.Stack{
+					StackName:   aws.String("cluster-foo-guest-recordsets"),
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+					Tags:        tags,
+				},
+			},
+			strictClusterNames: true,
+		},
+		{
+			name: "case 1: hyphenated cluster ID",
+			sourceStacks: []cloudformation.Stack{
+				cloudformation.Stack{
+					StackName:   aws.String("cluster-my-foo-tccp"),
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+					Tags:        tags,
+				},
+			},
+			targetStacks: []cloudformation.Stack{
+				cloudformation.Stack{
+					StackName:   aws.String("cluster-my-foo-guest-recordsets"),
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+					Tags:        tags,
+				},
+			},
+			expectedMismatches: []string{
+				"cluster-my-foo-tccp",
+				"cluster-my-foo-guest-recordsets",
+			},
+		},
+		{
+			name: "case 2: hyphenated cluster ID with strict cluster names",
+			sourceStacks: []cloudformation.Stack{
+				cloudformation.Stack{
+					StackName:   aws.String("cluster-my-foo-tccp"),
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+					Tags:        tags,
+				},
+			},
+			targetStacks: []cloudformation.Stack{
+				cloudformation.Stack{
+					StackName:   aws.String("cluster-my-foo-guest-recordsets"),
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+					Tags:        tags,
+				},
+			},
+			strictClusterNames: true,
+			expectedMismatches: []string{
+				"cluster-my-foo-tccp",
+				"cluster-my-foo-guest-recordsets",
+			},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			logger, err := micrologger.New(micrologger.Config{IOWriter: &out})
+			if err != nil {
+				t.Fatalf("micrologger.New: %v", err)
+			}
+
+			targetClient := newTargetWithStacks(tc.targetStacks)
+
+			c := newTestConfig(t)
+			c.Logger = logger
+			c.SourceClient = newSourceWithStacks(tc.sourceStacks)
+			c.TargetClient = targetClient
+			c.StrictClusterNames = tc.strictClusterNames
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			err = m.Sync()
+			if tc.expectedError {
+				if !IsClusterNameMismatch(err) {
+					t.Fatalf("expected cluster name mismatch error, got %v", err)
+				}
+				if len(targetClient.createdStacks)+len(targetClient.updatedStacks)+len(targetClient.deletedStacks) != 0 {
+					t.Errorf("expected no changes, got created %v, updated %v, deleted %v", targetClient.createdStacks, targetClient.updatedStacks, targetClient.deletedStacks)
+				}
+			} else if err != nil {
+				t.Fatalf("m.Sync: %v", err)
+			}
+
+			var mismatches int
+			for _, line := range strings.Split(out.String(), "\n") {
+				if strings.Contains(line, "does not match its cluster") {
+					mismatches++
+				}
+			}
+			if mismatches != len(tc.expectedMismatches) {
+				t.Errorf("expected %d mismatches to be logged, got %d in %s", len(tc.expectedMismatches), mismatches, out.String())
+			}
+			for _, stackName := range tc.expectedMismatches {
+				if !strings.Contains(out.String(), stackName) {
+					t.Errorf("expected mismatch of %#q to be logged", stackName)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+package recordset
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+)
+
+func TestSync_Cluster(t *testing.T) {
+	tags := []*cloudformation.Tag{
+		&cloudformation.Tag{
+			Key:   aws.String(installationTag),
+			Value: aws.String("installation"),
+		},
+	}
+	newStack := func(name string) cloudformation.Stack {
+		return cloudformation.Stack{
+			StackName:   aws.String(name),
+			StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+			Tags:        tags,
+		}
+	}
+
+	tcs := []struct {
+		name            string
+		cluster         string
+		expectedCreated []string
+		expectedUpdated []string
+		expectedDeleted []string
+	}{
+		{
+			name:            "case 0: create target stack of cluster without one",
+			cluster:         "foo",
+			expectedCreated: []string{"cluster-foo-guest-recordsets"},
+		},
+		{
+			name:            "case 1: update target stack of legacy cluster",
+			cluster:         "bar",
+			expectedUpdated: []string{"cluster-bar-guest-recordsets"},
+		},
+		{
+			name:            "case 2: delete orphan target stack of cluster",
+			cluster:         "baz",
+			expectedDeleted: []string{"cluster-baz-guest-recordsets"},
+		},
+		{
+			name:    "case 3: unknown cluster",
+			cluster: "qux",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			sourceClient := newSourceWithStacks([]cloudformation.Stack{
+				newStack("cluster-foo-tccp"),
+				newStack("cluster-bar-guest-main"),
+			})
+			sourceClient.stackNotFoundErrors = true
+			targetClient := newTargetWithStacks([]cloudformation.Stack{
+				newStack("cluster-bar-guest-recordsets"),
+				newStack("cluster-baz-guest-recordsets"),
+			})
+			targetClient.stackNotFoundErrors = true
+
+			c := newTestConfig(t)
+			c.Cluster = tc.cluster
+			c.SourceClient = sourceClient
+			c.TargetClient = targetClient
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			err = m.Sync()
+			if err != nil {
+				t.Fatalf("m.Sync: %v", err)
+			}
+
+			if !reflect.DeepEqual(tc.expectedCreated, targetClient.createdStacks) {
+				t.Errorf("expected created stacks %v, got %v", tc.expectedCreated, targetClient.createdStacks)
+			}
+			if !reflect.DeepEqual(tc.expectedUpdated, targetClient.updatedStacks) {
+				t.Errorf("expected updated stacks %v, got %v", tc.expectedUpdated, targetClient.updatedStacks)
+			}
+			if !reflect.DeepEqual(tc.expectedDeleted, targetClient.deletedStacks) {
+				t.Errorf("expected deleted stacks %v, got %v", tc.expectedDeleted, targetClient.deletedStacks)
+			}
+			if sourceClient.listStacksCalls != 0 || targetClient.listStacksCalls != 0 {
+				t.Errorf("expected stacks to be described by name, got %d source and %d target ListStacks calls", sourceClient.listStacksCalls, targetClient.listStacksCalls)
+			}
+		})
+	}
+}
+
+func TestNewManager_InvalidCluster(t *testing.T) {
+	c := newTestConfig(t)
+	c.Cluster = "cluster-foo"
+
+	_, err := NewManager(c)
+	if !IsInvalidConfig(err) {
+		t.Errorf("expected invalid config error, got %v", err)
+	}
+}
+
+func TestSync_SourceStackNames(t *testing.T) {
+	tags := []*cloudformation.Tag{
+		&cloudformation.Tag{
+			Key:   aws.String(installationTag),
+			Value: aws.String("installation"),
+		},
+	}
+	newStack := func(name, status string, tags []*cloudformation.Tag) cloudformation.Stack {
+		return cloudformation.Stack{
+			StackName:   aws.String(name),
+			StackStatus: aws.String(status),
+			Tags:        tags,
+		}
+	}
+
+	tcs := []struct {
+		name             string
+		sourceStackNames []string
+		expectedCreated  []string
+		expectedUpdated  []string
+		expectedDeleted  []string
+		expectedSkipped  map[SkipReason]map[string]bool
+	}{
+		{
+			name:             "case 0: create target stack of named source stack",
+			sourceStackNames: []string{"cluster-foo-tccp"},
+			expectedCreated:  []string{"cluster-foo-guest-recordsets"},
+		},
+		{
+			name:             "case 1: create and update target stacks of named source stacks",
+			sourceStackNames: []string{"cluster-foo-tccp", "cluster-bar-guest-main"},
+			expectedCreated:  []string{"cluster-foo-guest-recordsets"},
+			expectedUpdated:  []string{"cluster-bar-guest-recordsets"},
+		},
+		{
+			name:             "case 2: delete target stack of missing named source stack",
+			sourceStackNames: []string{"cluster-baz-tccp"},
+			expectedDeleted:  []string{"cluster-baz-guest-recordsets"},
+		},
+		{
+			name:             "case 3: skip named source stack without installation tag",
+			sourceStackNames: []string{"cluster-qux-tccp"},
+			expectedSkipped: map[SkipReason]map[string]bool{
+				SkipReasonMissingInstallationTag: {"cluster-qux-tccp": true},
+			},
+		},
+		{
+			name:             "case 4: skip named source stack with invalid status",
+			sourceStackNames: []string{"cluster-quux-tccp"},
+			expectedSkipped: map[SkipReason]map[string]bool{
+				SkipReasonSourceStatus: {"cluster-quux-tccp": true},
+			},
+		},
+		{
+			name:             "case 5: update target stack of cluster with other source stack",
+			sourceStackNames: []string{"cluster-bar-tccp"},
+			expectedUpdated:  []string{"cluster-bar-guest-recordsets"},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			sourceClient := newSourceWithStacks([]cloudformation.Stack{
+				newStack("cluster-foo-tccp", cloudformation.StackStatusCreateComplete, tags),
+				newStack("cluster-bar-guest-main", cloudformation.StackStatusUpdateComplete, tags),
+				newStack("cluster-qux-tccp", cloudformation.StackStatusCreateComplete, nil),
+				newStack("cluster-quux-tccp", cloudformation.StackStatusUpdateInProgress, tags),
+			})
+			sourceClient.stackNotFoundErrors = true
+			targetClient := newTargetWithStacks([]cloudformation.Stack{
+				newStack("cluster-bar-guest-recordsets", cloudformation.StackStatusCreateComplete, tags),
+				newStack("cluster-baz-guest-recordsets", cloudformation.StackStatusCreateComplete, tags),
+				newStack("cluster-other-guest-recordsets", cloudformation.StackStatusCreateComplete, tags),
+			})
+			targetClient.stackNotFoundErrors = true
+
+			c := newTestConfig(t)
+			c.SourceStackNames = tc.sourceStackNames
+			c.SourceClient = sourceClient
+			c.TargetClient = targetClient
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			err = m.Sync()
+			if err != nil {
+				t.Fatalf("m.Sync: %v", err)
+			}
+
+			if !reflect.DeepEqual(tc.expectedCreated, targetClient.createdStacks) {
+				t.Errorf("expected created stacks %v, got %v", tc.expectedCreated, targetClient.createdStacks)
+			}
+			if !reflect.DeepEqual(tc.expectedUpdated, targetClient.updatedStacks) {
+				t.Errorf("expected updated stacks %v, got %v", tc.expectedUpdated, targetClient.updatedStacks)
+			}
+			if !reflect.DeepEqual(tc.expectedDeleted, targetClient.deletedStacks) {
+				t.Errorf("expected deleted stacks %v, got %v", tc.expectedDeleted, targetClient.deletedStacks)
+			}
+			if tc.expectedSkipped != nil && !reflect.DeepEqual(tc.expectedSkipped, m.summary.skipped) {
+				t.Errorf("expected skipped stacks %v, got %v", tc.expectedSkipped, m.summary.skipped)
+			}
+			if sourceClient.listStacksCalls != 0 || targetClient.listStacksCalls != 0 {
+				t.Errorf("expected stacks to be described by name, got %d source and %d target ListStacks calls", sourceClient.listStacksCalls, targetClient.listStacksCalls)
+			}
+		})
+	}
+}
+
+func TestNewManager_InvalidSourceStackNames(t *testing.T) {
+	tcs := []struct {
+		name             string
+		cluster          string
+		sourceStackNames []string
+	}{
+		{
+			name:             "case 0: target stack name",
+			sourceStackNames: []string{"cluster-foo-guest-recordsets"},
+		},
+		{
+			name:             "case 1: combined with cluster",
+			cluster:          "foo",
+			sourceStackNames: []string{"cluster-foo-tccp"},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newTestConfig(t)
+			c.Cluster = tc.cluster
+			c.SourceStackNames = tc.sourceStackNames
+
+			_, err := NewManager(c)
+			if !IsInvalidConfig(err) {
+				t.Errorf("expected invalid config error, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package recordset
+
+import (
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+// CNAME values are rendered without trailing dot, the way ELBs report their
+// DNS names. Route53 accepts both forms and may return them fully qualified
+// or in another case, e.g. for records created by hand or by other tools, so
+// values are compared in the form returned by normalizeCNAMEValue.
+
+// normalizeCNAMEValue returns the CNAME value in lower case without trailing
+// dot. It is the only form CNAME values are compared in.
+func normalizeCNAMEValue(value string) string {
+	return strings.ToLower(strings.TrimSuffix(value, "."))
+}
+
+// normalizeCNAMERecords returns the records with the trailing dots of the
+// values of their CNAME records removed. The case is kept, so rendered
+// templates do not change.
+func normalizeCNAMERecords(records []DesiredRecord) []DesiredRecord {
+	var result []DesiredRecord
+	for _, r := range records {
+		if r.Type == route53.RRTypeCname {
+			var values []string
+			for _, v := range r.Values {
+				values = append(values, strings.TrimSuffix(v, "."))
+			}
+			r.Values = values
+		}
+		result = append(result, r)
+	}
+
+	return result
+}
+
+// normalizeTemplateCNAMEs normalizes the values of the CNAME record sets of
+// the template with normalizeCNAMEValue before templates are compared.
+func normalizeTemplateCNAMEs(t stackTemplate) {
+	for name, r := range t.Resources {
+		if r.Properties.Type != route53.RRTypeCname {
+			continue
+		}
+
+		var values []string
+		for _, v := range r.Properties.ResourceRecords {
+			values = append(values, normalizeCNAMEValue(v))
+		}
+		r.Properties.ResourceRecords = values
+		t.Resources[name] = r
+	}
+}
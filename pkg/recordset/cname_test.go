@@ -0,0 +1,136 @@
+package recordset
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+)
+
+func TestGetStackTemplateBody_CNAMETrailingDot(t *testing.T) {
+	c := newTestConfig(t)
+	c.RecordSource = &recordSourceMock{
+		records: map[string][]DesiredRecord{
+			"foo": {
+				{
+					ResourceName: "vaultDNSRecord",
+					Name:         "vault.foo.zoneName",
+					Type:         "CNAME",
+					Values:       []string{"vault.internal."},
+				},
+				{
+					ResourceName: "gatewayDNSRecord",
+					Name:         "gateway.foo.zoneName",
+					Type:         "CNAME",
+					Values:       []string{"gateway.internal"},
+				},
+			},
+		},
+	}
+	m, err := NewManager(c)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	records, err := m.getRecords(Cluster{ID: "foo"})
+	if err != nil {
+		t.Fatalf("getRecords: %v", err)
+	}
+	template := m.newTargetStackTemplate(records)
+
+	expected := map[string][]string{
+		"vaultDNSRecord":   {"vault.internal"},
+		"gatewayDNSRecord": {"gateway.internal"},
+	}
+	for name, values := range expected {
+		got := template.Resources[name].Properties.ResourceRecords
+		if !reflect.DeepEqual(values, got) {
+			t.Errorf("expected %s values %v, got %v", name, values, got)
+		}
+	}
+}
+
+func TestSync_CNAMETrailingDot(t *testing.T) {
+	tags := []*cloudformation.Tag{
+		&cloudformation.Tag{
+			Key:   aws.String(installationTag),
+			Value: aws.String("installation"),
+		},
+	}
+
+	tcs := []struct {
+		name            string
+		currentTemplate func(body string) string
+		expectedUpdate  bool
+	}{
+		{
+			name: "case 0: current CNAME values with trailing dot",
+			currentTemplate: func(body string) string {
+				return strings.Replace(body, "elb.dns.test", "elb.dns.test.", -1)
+			},
+			expectedUpdate: false,
+		},
+		{
+			name: "case 1: current CNAME values differ",
+			currentTemplate: func(body string) string {
+				return strings.Replace(body, "elb.dns.test", "old.elb.dns.test.", -1)
+			},
+			expectedUpdate: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			sourceClient := newSourceWithStacks([]cloudformation.Stack{
+				{
+					StackName:   aws.String("cluster-foo-tccp"),
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+					Tags:        tags,
+				},
+			})
+			targetClient := newTargetWithStacks([]cloudformation.Stack{
+				{
+					StackName:   aws.String("cluster-foo-guest-recordsets"),
+					StackStatus: aws.String(cloudformation.StackStatusUpdateComplete),
+					Tags:        tags,
+				},
+			})
+
+			c := newTestConfig(t)
+			c.SourceClient = sourceClient
+			c.TargetClient = targetClient
+			c.SkipUnchangedUpdates = true
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			records, err := m.getRecords(Cluster{ID: "foo"})
+			if err != nil {
+				t.Fatalf("getRecords: %v", err)
+			}
+			body, err := m.getStackTemplateBody(records)
+			if err != nil {
+				t.Fatalf("getStackTemplateBody: %v", err)
+			}
+			if strings.Contains(body, "elb.dns.test.") {
+				t.Fatalf("expected CNAME values without trailing dot, got %s", body)
+			}
+			targetClient.templates = map[string]string{
+				"cluster-foo-guest-recordsets": tc.currentTemplate(body),
+			}
+
+			err = m.Sync()
+			if err != nil {
+				t.Fatalf("m.Sync: %v", err)
+			}
+
+			updated := len(targetClient.updateStackInputs) > 0
+			if updated != tc.expectedUpdate {
+				t.Errorf("expected update %t, got %d updates", tc.expectedUpdate, len(targetClient.updateStackInputs))
+			}
+		})
+	}
+}
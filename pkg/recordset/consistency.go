@@ -0,0 +1,133 @@
+package recordset
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/route53-manager/pkg/recordset/plan"
+)
+
+// verifyApplied lists the target stacks again after the plan was applied
+// until the target stacks created by the current sync run are listed and
+// the deleted ones are gone or being deleted, for at most
+// m.consistencyRetries retries. CloudFormation lists stacks eventually
+// consistent, so the next sync run could otherwise plan to create or delete
+// the same target stacks again. The interval between retries doubles up to
+// waitForSyncMaxInterval. Stacks still not observable afterwards are only
+// logged.
+func (m *Manager) verifyApplied() error {
+	if m.consistencyRetries == 0 || m.applyMode == ApplyModeRoute53Direct {
+		return nil
+	}
+
+	var created, deleted []string
+	for _, id := range m.summary.createdClusters {
+		created = append(created, plan.TargetStackName(id))
+	}
+	for _, id := range m.summary.deletedClusters {
+		deleted = append(deleted, plan.TargetStackName(id))
+	}
+	if len(created) == 0 && len(deleted) == 0 {
+		return nil
+	}
+
+	interval := waitForSyncInitialInterval
+
+	for attempt := 0; ; attempt++ {
+		statuses, err := m.targetStackStatuses(append(created, deleted...))
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		created = pendingCreatedStacks(created, statuses)
+		deleted = pendingDeletedStacks(deleted, statuses)
+		if len(created) == 0 && len(deleted) == 0 {
+			return nil
+		}
+
+		if attempt >= m.consistencyRetries {
+			m.logger.Log("level", "warning", "message", fmt.Sprintf("created target stacks %v and deleted target stacks %v not observable after %d retries", created, deleted, m.consistencyRetries))
+			return nil
+		}
+
+		err = m.checkDeadline()
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		m.logger.Log("level", "debug", "message", fmt.Sprintf("waiting %s for created target stacks %v and deleted target stacks %v to be observable", interval, created, deleted))
+		m.sleep(interval)
+
+		interval *= 2
+		if interval > waitForSyncMaxInterval {
+			interval = waitForSyncMaxInterval
+		}
+	}
+}
+
+// targetStackStatuses returns the statuses of the target stacks the next
+// sync run would find by name, listed the same way as by targetStacks.
+// Target stacks of a scoped sync run are described by name, the given names
+// are only used then. Missing stacks have no status.
+func (m *Manager) targetStackStatuses(stackNames []string) (map[string]string, error) {
+	statuses := map[string]string{}
+
+	if m.scoped() {
+		for _, stackName := range stackNames {
+			stacks, err := describeStacks(m.ctx, m.targetClient, stackName)
+			if IsStackNotFound(err) {
+				continue
+			} else if err != nil {
+				return nil, microerror.Mask(err)
+			}
+			for _, stack := range stacks.Stacks {
+				statuses[aws.StringValue(stack.StackName)] = aws.StringValue(stack.StackStatus)
+			}
+		}
+
+		return statuses, nil
+	}
+
+	input := &cloudformation.ListStacksInput{
+		StackStatusFilter: plan.StackStatusValid,
+	}
+	output, err := m.targetClient.ListStacksWithContext(m.ctx, input)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	for _, item := range output.StackSummaries {
+		statuses[aws.StringValue(item.StackName)] = aws.StringValue(item.StackStatus)
+	}
+
+	return statuses, nil
+}
+
+// pendingCreatedStacks returns the given created stacks which are not listed
+// yet.
+func pendingCreatedStacks(stackNames []string, statuses map[string]string) []string {
+	var pending []string
+	for _, stackName := range stackNames {
+		if _, ok := statuses[stackName]; !ok {
+			pending = append(pending, stackName)
+		}
+	}
+
+	return pending
+}
+
+// pendingDeletedStacks returns the given deleted stacks which are still
+// listed with a status other than a deleting one.
+func pendingDeletedStacks(stackNames []string, statuses map[string]string) []string {
+	var pending []string
+	for _, stackName := range stackNames {
+		status, ok := statuses[stackName]
+		if ok && !stringInSlice(status, plan.StackStatusDeleting) {
+			pending = append(pending, stackName)
+		}
+	}
+
+	return pending
+}
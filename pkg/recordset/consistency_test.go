@@ -0,0 +1,137 @@
+package recordset
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+)
+
+func TestSync_ConsistencyRetries(t *testing.T) {
+	tags := []*cloudformation.Tag{
+		{
+			Key:   aws.String(installationTag),
+			Value: aws.String("installation"),
+		},
+	}
+
+	tcs := []struct {
+		name                        string
+		cluster                     string
+		consistencyRetries          int
+		createdStacksHiddenListings int
+		targetStacks                []cloudformation.Stack
+		deletionPolls               map[string]int
+		expectedIntervals           []time.Duration
+		expectedListStacksCalls     int
+	}{
+		{
+			name:                        "case 0: created stack listed after a retry",
+			consistencyRetries:          3,
+			createdStacksHiddenListings: 1,
+			expectedIntervals: []time.Duration{
+				1 * time.Second,
+			},
+			expectedListStacksCalls: 3,
+		},
+		{
+			name:               "case 1: updated stacks are not verified",
+			consistencyRetries: 3,
+			targetStacks: []cloudformation.Stack{
+				{
+					StackName:   aws.String("cluster-foo-guest-recordsets"),
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+					Tags:        tags,
+				},
+			},
+			expectedListStacksCalls: 1,
+		},
+		{
+			name:                        "case 2: retries exhausted",
+			consistencyRetries:          2,
+			createdStacksHiddenListings: 10,
+			expectedIntervals: []time.Duration{
+				1 * time.Second,
+				2 * time.Second,
+			},
+			expectedListStacksCalls: 4,
+		},
+		{
+			name:                        "case 3: disabled",
+			createdStacksHiddenListings: 1,
+			expectedListStacksCalls:     1,
+		},
+		{
+			name:               "case 4: deleted stack still listed",
+			consistencyRetries: 1,
+			targetStacks: []cloudformation.Stack{
+				{
+					StackName:   aws.String("cluster-bar-guest-recordsets"),
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+					Tags:        tags,
+				},
+			},
+			expectedIntervals: []time.Duration{
+				1 * time.Second,
+			},
+			expectedListStacksCalls: 3,
+		},
+		{
+			name:               "case 5: deleted stack of scoped run being deleted",
+			cluster:            "bar",
+			consistencyRetries: 3,
+			targetStacks: []cloudformation.Stack{
+				{
+					StackName:   aws.String("cluster-bar-guest-recordsets"),
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+					Tags:        tags,
+				},
+			},
+			deletionPolls: map[string]int{
+				"cluster-bar-guest-recordsets": 1,
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			sourceClient := newSourceWithStacks([]cloudformation.Stack{
+				{
+					StackName:   aws.String("cluster-foo-tccp"),
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+					Tags:        tags,
+				},
+			})
+			sourceClient.stackNotFoundErrors = true
+			targetClient := newTargetWithStacks(tc.targetStacks)
+			targetClient.createdStacksHiddenListings = tc.createdStacksHiddenListings
+			targetClient.deletionPolls = tc.deletionPolls
+
+			c := newTestConfig(t)
+			c.SourceClient = sourceClient
+			c.TargetClient = targetClient
+			c.Cluster = tc.cluster
+			c.ConsistencyRetries = tc.consistencyRetries
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+			var intervals []time.Duration
+			m.sleep = func(d time.Duration) { intervals = append(intervals, d) }
+
+			err = m.Sync()
+			if err != nil {
+				t.Fatalf("Sync: %v", err)
+			}
+
+			if !reflect.DeepEqual(tc.expectedIntervals, intervals) {
+				t.Errorf("expected intervals %v, got %v", tc.expectedIntervals, intervals)
+			}
+			if targetClient.listStacksCalls != tc.expectedListStacksCalls {
+				t.Errorf("expected %d target ListStacks calls, got %d", tc.expectedListStacksCalls, targetClient.listStacksCalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package recordset
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/route53-manager/pkg/key"
+	"github.com/giantswarm/route53-manager/pkg/recordset/plan"
+)
+
+const (
+	delegationRecordTTL = 300
+)
+
+// ensureDelegation ensures the `<cluster>.<zone>` NS record in the parent
+// hosted zone points at the name servers of the hosted zone of the cluster
+// with the given stack tags. The record is only changed when it is missing or
+// points at other name servers. It is a no-op when no parent hosted zone is
+// configured.
+func (m *Manager) ensureDelegation(clusterName string, tags map[string]string) error {
+	if m.parentClient == nil {
+		return nil
+	}
+
+	zone, _ := m.clusterHostedZones(tags)
+	name := key.BaseDomain(clusterName, zone.Name)
+	if m.parentHostedZoneName != "" && !isSubdomain(name, m.parentHostedZoneName) {
+		return microerror.Maskf(invalidConfigError, "delegation record %#q is not below parent hosted zone %#q", name, m.parentHostedZoneName)
+	}
+
+	nameServers, err := m.getTargetNameServers(zone.ID)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	current, err := m.getParentNameServers(name)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	if equalNameServers(current, nameServers) {
+		m.logSkipped(fmt.Sprintf("delegation record %#q in parent hosted zone %#q is up to date", name, m.parentHostedZoneID))
+		return nil
+	}
+
+	var records []*route53.ResourceRecord
+	for _, ns := range nameServers {
+		records = append(records, &route53.ResourceRecord{
+			Value: aws.String(ns),
+		})
+	}
+
+	input := &route53.ChangeResourceRecordSetsInput{
+		ChangeBatch: &route53.ChangeBatch{
+			Changes: []*route53.Change{
+				{
+					Action: aws.String(route53.ChangeActionUpsert),
+					ResourceRecordSet: &route53.ResourceRecordSet{
+						Name:            aws.String(name),
+						ResourceRecords: records,
+						TTL:             aws.Int64(delegationRecordTTL),
+						Type:            aws.String(route53.RRTypeNs),
+					},
+				},
+			},
+		},
+		HostedZoneId: aws.String(m.parentHostedZoneID),
+	}
+
+	_, err = m.changeResourceRecordSets(m.parentClient, input)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	m.logger.Log("level", "debug", "message", fmt.Sprintf("ensured delegation record %#q in parent hosted zone %#q", name, m.parentHostedZoneID))
+
+	return nil
+}
+
+// ensureClusterDelegation ensures the delegation of the cluster of the given
+// target stack. Failures are logged and counted, the target stack itself was
+// applied already.
+func (m *Manager) ensureClusterDelegation(ref plan.ClusterRef) {
+	err := m.ensureDelegation(ref.ID, refTags(ref))
+	if err != nil {
+		m.logger.Log("level", "error", "message", fmt.Sprintf("failed to ensure delegation of cluster %#q", ref.ID), "stack", microerror.JSON(err))
+		m.summary.failed++
+	}
+}
+
+// getParentNameServers returns the values of the NS record with the given name
+// in the parent hosted zone. Nil is returned when the record does not exist.
+func (m *Manager) getParentNameServers(name string) ([]string, error) {
+	input := &route53.ListResourceRecordSetsInput{
+		HostedZoneId:    aws.String(m.parentHostedZoneID),
+		MaxItems:        aws.String("1"),
+		StartRecordName: aws.String(name),
+		StartRecordType: aws.String(route53.RRTypeNs),
+	}
+	output, err := m.parentClient.ListResourceRecordSetsWithContext(m.ctx, input)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	for _, rr := range output.ResourceRecordSets {
+		if normalizeRecordName(aws.StringValue(rr.Name)) != normalizeRecordName(name) || aws.StringValue(rr.Type) != route53.RRTypeNs {
+			continue
+		}
+
+		var nameServers []string
+		for _, r := range rr.ResourceRecords {
+			nameServers = append(nameServers, aws.StringValue(r.Value))
+		}
+		return nameServers, nil
+	}
+
+	return nil, nil
+}
+
+// equalNameServers returns true when both lists hold the same name servers,
+// regardless of order, case and trailing dots.
+func equalNameServers(a, b []string) bool {
+	normalize := func(nameServers []string) []string {
+		var result []string
+		for _, ns := range nameServers {
+			result = append(result, normalizeRecordName(ns))
+		}
+		sort.Strings(result)
+		return result
+	}
+
+	return len(a) == len(b) && reflect.DeepEqual(normalize(a), normalize(b))
+}
+
+// isSubdomain returns true when name is zone itself or below it.
+func isSubdomain(name, zone string) bool {
+	name = normalizeRecordName(name)
+	zone = normalizeRecordName(zone)
+
+	return name == zone || strings.HasSuffix(name, "."+zone)
+}
+
+// getTargetNameServers returns the name servers of the delegation set of the
+// given hosted zone of the target account.
+func (m *Manager) getTargetNameServers(hostedZoneID string) ([]string, error) {
+	input := &route53.GetHostedZoneInput{
+		Id: aws.String(hostedZoneID),
+	}
+	output, err := m.targetClient.GetHostedZoneWithContext(m.ctx, input)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var nameServers []string
+	if output.DelegationSet != nil {
+		for _, ns := range output.DelegationSet.NameServers {
+			if ns != nil {
+				nameServers = append(nameServers, *ns)
+			}
+		}
+	}
+	if len(nameServers) == 0 {
+		return nil, microerror.Maskf(tooFewResultsError, "hosted zone %#q has no name servers", hostedZoneID)
+	}
+
+	return nameServers, nil
+}
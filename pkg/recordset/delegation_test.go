@@ -0,0 +1,174 @@
+package recordset
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+func TestSync_Delegation(t *testing.T) {
+	tcs := []struct {
+		name               string
+		targetStacks       []cloudformation.Stack
+		nameServers        []string
+		parentRecordSets   []*route53.ResourceRecordSet
+		parentZoneName     string
+		expectedDelegation bool
+	}{
+		{
+			name:               "case 0: ensure delegation on cluster create",
+			targetStacks:       nil,
+			nameServers:        []string{"ns-1.awsdns.com", "ns-2.awsdns.net"},
+			expectedDelegation: true,
+		},
+		{
+			name: "case 1: ensure delegation for existing cluster",
+			targetStacks: []cloudformation.Stack{
+				cloudformation.Stack{
+					StackName:   aws.String("cluster-foo-guest-recordsets"),
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+					Tags: []*cloudformation.Tag{
+						&cloudformation.Tag{
+							Key:   aws.String(installationTag),
+							Value: aws.String("installation"),
+						},
+					},
+				},
+			},
+			nameServers:        []string{"ns-1.awsdns.com", "ns-2.awsdns.net"},
+			expectedDelegation: true,
+		},
+		{
+			name:               "case 2: do not ensure delegation without name servers",
+			targetStacks:       nil,
+			nameServers:        nil,
+			expectedDelegation: false,
+		},
+		{
+			name:        "case 3: do not change up to date delegation",
+			nameServers: []string{"ns-1.awsdns.com", "ns-2.awsdns.net"},
+			parentRecordSets: []*route53.ResourceRecordSet{
+				{
+					Name: aws.String("foo.zonename."),
+					Type: aws.String(route53.RRTypeNs),
+					ResourceRecords: []*route53.ResourceRecord{
+						{Value: aws.String("ns-2.awsdns.net.")},
+						{Value: aws.String("ns-1.awsdns.com.")},
+					},
+				},
+			},
+			expectedDelegation: false,
+		},
+		{
+			name:        "case 4: change delegation to other name servers",
+			nameServers: []string{"ns-1.awsdns.com", "ns-2.awsdns.net"},
+			parentRecordSets: []*route53.ResourceRecordSet{
+				{
+					Name: aws.String("foo.zonename."),
+					Type: aws.String(route53.RRTypeNs),
+					ResourceRecords: []*route53.ResourceRecord{
+						{Value: aws.String("ns-3.awsdns.org.")},
+					},
+				},
+			},
+			expectedDelegation: true,
+		},
+		{
+			name:               "case 5: do not ensure delegation outside of parent hosted zone",
+			nameServers:        []string{"ns-1.awsdns.com", "ns-2.awsdns.net"},
+			parentZoneName:     "other.",
+			expectedDelegation: false,
+		},
+		{
+			name:               "case 6: ensure delegation below parent hosted zone",
+			nameServers:        []string{"ns-1.awsdns.com", "ns-2.awsdns.net"},
+			parentZoneName:     "ZoneName.",
+			expectedDelegation: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			sourceStacks := []cloudformation.Stack{
+				cloudformation.Stack{
+					StackName:   aws.String("cluster-foo-tccp"),
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+					Tags: []*cloudformation.Tag{
+						&cloudformation.Tag{
+							Key:   aws.String(installationTag),
+							Value: aws.String("installation"),
+						},
+					},
+				},
+			}
+			targetClient := newTargetWithStacks(tc.targetStacks)
+			targetClient.nameServers = tc.nameServers
+			parentClient := &parentClientMock{recordSets: tc.parentRecordSets}
+
+			c := newTestConfig(t)
+			c.SourceClient = newSourceWithStacks(sourceStacks)
+			c.TargetClient = targetClient
+			c.ParentClient = parentClient
+			c.ParentHostedZoneID = "parentZoneID"
+			c.ParentHostedZoneName = tc.parentZoneName
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			err = m.Sync()
+			if err != nil {
+				t.Fatalf("m.Sync: %v", err)
+			}
+
+			if !tc.expectedDelegation {
+				if len(parentClient.changes) > 0 {
+					t.Errorf("expected no delegation, got %v", parentClient.changes)
+				}
+				return
+			}
+
+			if len(parentClient.changes) != 1 {
+				t.Fatalf("expected 1 change, got %d", len(parentClient.changes))
+			}
+			change := parentClient.changes[0]
+			if *change.Action != route53.ChangeActionUpsert {
+				t.Errorf("expected action %#q, got %#q", route53.ChangeActionUpsert, *change.Action)
+			}
+			if *change.ResourceRecordSet.Name != "foo.zoneName" {
+				t.Errorf("expected record name %#q, got %#q", "foo.zoneName", *change.ResourceRecordSet.Name)
+			}
+			if *change.ResourceRecordSet.Type != route53.RRTypeNs {
+				t.Errorf("expected record type %#q, got %#q", route53.RRTypeNs, *change.ResourceRecordSet.Type)
+			}
+			var values []string
+			for _, r := range change.ResourceRecordSet.ResourceRecords {
+				values = append(values, *r.Value)
+			}
+			if !reflect.DeepEqual(values, tc.nameServers) {
+				t.Errorf("expected record values %v, got %v", tc.nameServers, values)
+			}
+		})
+	}
+}
+
+func TestNewManager_InvalidParent(t *testing.T) {
+	c := newTestConfig(t)
+	c.ParentClient = &parentClientMock{}
+
+	_, err := NewManager(c)
+	if !IsInvalidConfig(err) {
+		t.Errorf("expected invalid config error, got %v", err)
+	}
+
+	c = newTestConfig(t)
+	c.ParentHostedZoneID = "parentZoneID"
+
+	_, err = NewManager(c)
+	if !IsInvalidConfig(err) {
+		t.Errorf("expected invalid config error, got %v", err)
+	}
+}
@@ -0,0 +1,160 @@
+package recordset
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// diffContext is the number of unchanged lines shown around the changed
+	// lines of a unified diff.
+	diffContext = 3
+)
+
+// diffLine is a line of an edit script, prefixed with ' ' when unchanged,
+// '-' when removed and '+' when added.
+type diffLine struct {
+	op   byte
+	text string
+}
+
+// unifiedDiff returns the unified diff of the texts a and b, labeled with
+// fromName and toName. It is empty when both texts are equal.
+func unifiedDiff(fromName, toName, a, b string) string {
+	if a == b {
+		return ""
+	}
+
+	lines := diffLines(splitLines(a), splitLines(b))
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n", fromName)
+	fmt.Fprintf(&sb, "+++ %s\n", toName)
+
+	// aLines and bLines count the lines of a and b before each line of the
+	// edit script, to compute the ranges of the hunk headers.
+	aLines := make([]int, len(lines)+1)
+	bLines := make([]int, len(lines)+1)
+	for i, l := range lines {
+		aLines[i+1] = aLines[i]
+		bLines[i+1] = bLines[i]
+		if l.op != '+' {
+			aLines[i+1]++
+		}
+		if l.op != '-' {
+			bLines[i+1]++
+		}
+	}
+
+	i := 0
+	for i < len(lines) {
+		if lines[i].op == ' ' {
+			i++
+			continue
+		}
+
+		start := i - diffContext
+		if start < 0 {
+			start = 0
+		}
+		end := hunkEnd(lines, i)
+
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(aLines[start], aLines[end]), hunkRange(bLines[start], bLines[end]))
+		for _, l := range lines[start:end] {
+			fmt.Fprintf(&sb, "%c%s\n", l.op, l.text)
+		}
+
+		i = end
+	}
+
+	return sb.String()
+}
+
+// hunkEnd returns the end of the hunk holding the changed line i. Changes
+// separated by at most twice diffContext unchanged lines share a hunk.
+func hunkEnd(lines []diffLine, i int) int {
+	for {
+		changesEnd := i
+		for changesEnd < len(lines) && lines[changesEnd].op != ' ' {
+			changesEnd++
+		}
+		next := changesEnd
+		for next < len(lines) && lines[next].op == ' ' {
+			next++
+		}
+		if next < len(lines) && next-changesEnd <= 2*diffContext {
+			i = next
+			continue
+		}
+
+		end := changesEnd + diffContext
+		if end > len(lines) {
+			end = len(lines)
+		}
+		return end
+	}
+}
+
+// hunkRange returns the range of a hunk header for the lines from start to
+// end, e.g. `4,7`. Empty ranges start at the line before.
+func hunkRange(start, end int) string {
+	if end == start {
+		return fmt.Sprintf("%d,0", start)
+	}
+
+	return fmt.Sprintf("%d,%d", start+1, end-start)
+}
+
+// diffLines returns the edit script turning a into b, based on their longest
+// common subsequence. Templates are small, so the quadratic table is fine.
+func diffLines(a, b []string) []diffLine {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var lines []diffLine
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			lines = append(lines, diffLine{op: ' ', text: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, diffLine{op: '-', text: a[i]})
+			i++
+		default:
+			lines = append(lines, diffLine{op: '+', text: b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		lines = append(lines, diffLine{op: '-', text: a[i]})
+	}
+	for ; j < len(b); j++ {
+		lines = append(lines, diffLine{op: '+', text: b[j]})
+	}
+
+	return lines
+}
+
+// splitLines returns the lines of s without their line breaks.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
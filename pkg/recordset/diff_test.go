@@ -0,0 +1,71 @@
+package recordset
+
+import (
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	tcs := []struct {
+		name     string
+		a        string
+		b        string
+		expected string
+	}{
+		{
+			name:     "case 0: identical texts",
+			a:        "a\nb\nc\n",
+			b:        "a\nb\nc\n",
+			expected: "",
+		},
+		{
+			name: "case 1: changed line",
+			a:    "a\nb\nc\n",
+			b:    "a\nx\nc\n",
+			expected: "--- from\n" +
+				"+++ to\n" +
+				"@@ -1,3 +1,3 @@\n" +
+				" a\n" +
+				"-b\n" +
+				"+x\n" +
+				" c\n",
+		},
+		{
+			name: "case 2: added text",
+			a:    "",
+			b:    "a\nb\n",
+			expected: "--- from\n" +
+				"+++ to\n" +
+				"@@ -0,0 +1,2 @@\n" +
+				"+a\n" +
+				"+b\n",
+		},
+		{
+			name: "case 3: distant changes in separate hunks",
+			a:    "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			b:    "x\n2\n3\n4\n5\n6\n7\n8\n9\ny\n",
+			expected: "--- from\n" +
+				"+++ to\n" +
+				"@@ -1,4 +1,4 @@\n" +
+				"-1\n" +
+				"+x\n" +
+				" 2\n" +
+				" 3\n" +
+				" 4\n" +
+				"@@ -7,4 +7,4 @@\n" +
+				" 7\n" +
+				" 8\n" +
+				" 9\n" +
+				"-10\n" +
+				"+y\n",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			diff := unifiedDiff("from", "to", tc.a, tc.b)
+			if diff != tc.expected {
+				t.Fatalf("expected diff\n%s\ngot\n%s", tc.expected, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package recordset
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/route53-manager/pkg/recordset/plan"
+)
+
+// listRecordClusterIDs returns the IDs of the clusters with a metadata record of
+// the installation in the target hosted zone. In ApplyModeRoute53Direct they
+// are the clusters with records, as there are no target stacks, so the plan
+// matches the clusters and finds the orphan ones without target stacks.
+func (m *Manager) listRecordClusterIDs() ([]string, error) {
+	recordSets, err := listRecordSets(m.ctx, m.targetClient, m.targetHostedZoneID)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var result []string
+	for _, rr := range recordSets {
+		if aws.StringValue(rr.Type) != route53.RRTypeTxt {
+			continue
+		}
+		clusterID, ok := m.metadataRecordClusterID(normalizeRecordName(aws.StringValue(rr.Name)))
+		if !ok || !m.ownsMetadataRecord(rr) {
+			continue
+		}
+		if m.scoped() && !stringInSlice(plan.TargetStackName(clusterID), m.scopedTargetStackNames()) {
+			continue
+		}
+
+		result = append(result, clusterID)
+	}
+
+	return result, nil
+}
+
+// findRecordClusters sets m.recordClusterIDs to the clusters found by
+// listRecordClusterIDs which have none of the given target stacks. In
+// ApplyModeRoute53Atomic the target stack only exists for clusters which were
+// synced in ApplyModeCloudFormation before.
+func (m *Manager) findRecordClusters(targetStacks []cloudformation.Stack) error {
+	m.recordClusterIDs = nil
+
+	clusterIDs, err := m.listRecordClusterIDs()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	names := map[string]bool{}
+	for _, s := range targetStacks {
+		names[*s.StackName] = true
+	}
+
+	for _, id := range clusterIDs {
+		if names[plan.TargetStackName(id)] {
+			continue
+		}
+		m.recordClusterIDs = append(m.recordClusterIDs, id)
+	}
+
+	return nil
+}
+
+// metadataRecordClusterID returns the ID of the cluster whose metadata record
+// has the given name, e.g. `foo` for `_meta.foo.zonename.`.
+func (m *Manager) metadataRecordClusterID(name string) (string, bool) {
+	prefix := metadataRecordPrefix
+	suffix := "." + normalizeRecordName(m.targetHostedZoneName)
+	if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, suffix) {
+		return "", false
+	}
+
+	clusterID := strings.TrimSuffix(strings.TrimPrefix(name, prefix), suffix)
+	if !clusterIDRE.MatchString(clusterID) {
+		return "", false
+	}
+
+	return clusterID, true
+}
+
+// ownsMetadataRecord returns true when the metadata record was written by the
+// installation, so the clusters of other installations sharing the hosted
+// zone are left alone.
+func (m *Manager) ownsMetadataRecord(rr *route53.ResourceRecordSet) bool {
+	for _, r := range rr.ResourceRecords {
+		for _, field := range strings.Fields(strings.Trim(aws.StringValue(r.Value), `"`)) {
+			if field == "installation="+m.installation {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// deleteDirectClusterRecords deletes the managed record sets of the orphan
+// cluster in ApplyModeRoute53Direct, followed by its leftover record sets.
+func (m *Manager) deleteDirectClusterRecords(clusterName string) {
+	err := m.deleteClusterRecords(clusterName)
+	m.audit(clusterName, auditOperationDelete, "", auditOutcome(err), err)
+	if err != nil {
+		m.logger.Log("level", "error", "message", fmt.Sprintf("failed to delete records of cluster %#q", clusterName), "stack", microerror.JSON(err))
+		m.summary.failed++
+		return
+	}
+
+	m.logger.Log("level", "debug", "message", fmt.Sprintf("deleted records of cluster %#q", clusterName))
+	m.summary.addDeleted(clusterName)
+
+	err = m.deleteTargetLeftovers(clusterName, nil)
+	if err != nil {
+		m.logger.Log("level", "error", "message", "failed to delete target record sets leftovers", "stack", microerror.JSON(err))
+	}
+}
+
+// deleteClusterRecords submits one change batch per hosted zone deleting the
+// managed record sets of the cluster. The main hosted zone holding the
+// metadata record comes last, so the cluster is still found on the next run
+// when deleting the etcd records fails.
+func (m *Manager) deleteClusterRecords(clusterName string) error {
+	main, etcd := m.clusterHostedZones(nil)
+
+	hostedZoneIDs := []string{main.ID}
+	if etcd.ID != main.ID {
+		hostedZoneIDs = []string{etcd.ID, main.ID}
+	}
+
+	for _, hostedZoneID := range hostedZoneIDs {
+		managedRecordSets := m.getManagedHostedZoneRecordSets(hostedZoneID, clusterName)
+
+		changes, err := m.getAtomicChanges(hostedZoneID, nil, managedRecordSets)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		if len(changes) == 0 {
+			continue
+		}
+
+		input := &route53.ChangeResourceRecordSetsInput{
+			ChangeBatch: &route53.ChangeBatch{
+				Changes: changes,
+				Comment: m.changeComment(fmt.Sprintf("route53-manager deletion of cluster %s", clusterName)),
+			},
+			HostedZoneId: aws.String(hostedZoneID),
+		}
+
+		output, err := m.changeResourceRecordSets(m.targetClient, input)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		err = m.waitForChange(output.ChangeInfo)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
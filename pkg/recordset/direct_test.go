@@ -0,0 +1,163 @@
+package recordset
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+func TestSync_ApplyModeRoute53Direct(t *testing.T) {
+	fooStack := cloudformation.Stack{
+		StackName:   aws.String("cluster-foo-tccp"),
+		StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+		Tags: []*cloudformation.Tag{
+			{
+				Key:   aws.String(installationTag),
+				Value: aws.String("installation"),
+			},
+		},
+	}
+	metadataRecordSet := func(clusterID, installation string) *route53.ResourceRecordSet {
+		return &route53.ResourceRecordSet{
+			Name: aws.String("_meta." + clusterID + ".zoneName."),
+			Type: aws.String(route53.RRTypeTxt),
+			ResourceRecords: []*route53.ResourceRecord{
+				{Value: aws.String(`"installation=` + installation + ` created=2020-01-01T12:00:00Z"`)},
+			},
+		}
+	}
+
+	tcs := []struct {
+		name            string
+		sourceStacks    []cloudformation.Stack
+		recordSets      []*route53.ResourceRecordSet
+		expectedChanges []string
+		// expectedUpdated counts the clusters whose records changed.
+		expectedUpdated int
+		expectedDeleted int
+	}{
+		{
+			name:         "case 0: records of a new cluster",
+			sourceStacks: []cloudformation.Stack{fooStack},
+			expectedChanges: []string{
+				"UPSERT \\052.foo.zoneName.",
+				"UPSERT _meta.foo.zoneName.",
+				"UPSERT api.foo.zoneName.",
+				"UPSERT etcd.foo.zoneName.",
+				"UPSERT etcd0.foo.zoneName.",
+				"UPSERT etcd1.foo.zoneName.",
+			},
+			expectedUpdated: 1,
+		},
+		{
+			name:         "case 1: records of a cluster with metadata record",
+			sourceStacks: []cloudformation.Stack{fooStack},
+			recordSets: []*route53.ResourceRecordSet{
+				metadataRecordSet("foo", "installation"),
+				{Name: aws.String("ingress.foo.zoneName."), Type: aws.String(route53.RRTypeCname)},
+			},
+			expectedChanges: []string{
+				"DELETE ingress.foo.zoneName.",
+				"UPSERT \\052.foo.zoneName.",
+				"UPSERT _meta.foo.zoneName.",
+				"UPSERT api.foo.zoneName.",
+				"UPSERT etcd.foo.zoneName.",
+				"UPSERT etcd0.foo.zoneName.",
+				"UPSERT etcd1.foo.zoneName.",
+			},
+			expectedUpdated: 1,
+		},
+		{
+			name: "case 2: records of an orphan cluster",
+			recordSets: []*route53.ResourceRecordSet{
+				metadataRecordSet("foo", "installation"),
+				{Name: aws.String("api.foo.zoneName."), Type: aws.String(route53.RRTypeCname)},
+				{Name: aws.String("foo.zoneName."), Type: aws.String(route53.RRTypeNs)},
+			},
+			expectedChanges: []string{
+				"DELETE _meta.foo.zoneName.",
+				"DELETE api.foo.zoneName.",
+			},
+			expectedDeleted: 1,
+		},
+		{
+			name: "case 3: records of a cluster of another installation",
+			recordSets: []*route53.ResourceRecordSet{
+				metadataRecordSet("bar", "other"),
+				{Name: aws.String("api.bar.zoneName."), Type: aws.String(route53.RRTypeCname)},
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			targetClient := newTargetWithStacks(nil)
+			targetClient.recordSets = map[string][]*route53.ResourceRecordSet{
+				"zoneID": tc.recordSets,
+			}
+
+			c := newTestConfig(t)
+			c.SourceClient = newSourceWithStacks(tc.sourceStacks)
+			c.TargetClient = targetClient
+			c.ApplyMode = ApplyModeRoute53Direct
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			err = m.Sync()
+			if err != nil {
+				t.Fatalf("m.Sync: %v", err)
+			}
+
+			if targetClient.listStacksCalls != 0 {
+				t.Errorf("expected no target stacks listed, got %d ListStacks calls", targetClient.listStacksCalls)
+			}
+			if len(targetClient.createdStacks) != 0 || len(targetClient.updatedStacks) != 0 || len(targetClient.deletedStacks) != 0 {
+				t.Errorf("expected no target stack changes, got created %v, updated %v and deleted %v", targetClient.createdStacks, targetClient.updatedStacks, targetClient.deletedStacks)
+			}
+
+			var changes []string
+			for _, c := range targetClient.changes["zoneID"] {
+				changes = append(changes, *c.Action+" "+*c.ResourceRecordSet.Name)
+			}
+			sort.Strings(changes)
+			if !reflect.DeepEqual(changes, tc.expectedChanges) {
+				t.Errorf("expected changes %v, got %v", tc.expectedChanges, changes)
+			}
+
+			s := m.summary
+			if s.updated != tc.expectedUpdated || s.deleted != tc.expectedDeleted {
+				t.Errorf("expected %d updated and %d deleted, got %s", tc.expectedUpdated, tc.expectedDeleted, s)
+			}
+		})
+	}
+}
+
+func TestNewManager_ApplyModeRoute53Direct(t *testing.T) {
+	c := newTestConfig(t)
+	c.ApplyMode = ApplyModeRoute53Direct
+
+	m, err := NewManager(c)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	if !m.metadataRecord {
+		t.Errorf("expected metadata record enabled")
+	}
+
+	c = newTestConfig(t)
+	c.ApplyMode = ApplyModeRoute53Direct
+	c.RegionHostedZones = map[string]HostedZone{
+		"eu-west-1": {ID: "euZoneID", Name: "eu.zoneName"},
+	}
+
+	_, err = NewManager(c)
+	if !IsInvalidConfig(err) {
+		t.Errorf("expected invalid config error, got %v", err)
+	}
+}
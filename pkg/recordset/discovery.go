@@ -0,0 +1,307 @@
+package recordset
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/route53-manager/pkg/client"
+	"github.com/giantswarm/route53-manager/pkg/recordset/plan"
+)
+
+const (
+	// DefaultDiscoveryGracePeriod is the default time the cluster of a
+	// target stack must be missing from tag based discovery before the
+	// target stack is deleted.
+	DefaultDiscoveryGracePeriod = time.Hour
+
+	// discoveredTag marks the source stacks of clusters discovered without
+	// source stack, see discoveredSourceStack.
+	discoveredTag = "giantswarm.io/route53-manager-discovered-by"
+	// missingSinceTag is the target stack tag holding the time the cluster
+	// of the target stack was first missing from tag based discovery.
+	missingSinceTag = "giantswarm.io/route53-manager-missing-since"
+)
+
+const (
+	// SkipReasonDiscoveryGracePeriod is used for target stacks of clusters
+	// missing from tag based discovery for less than the discovery grace
+	// period.
+	SkipReasonDiscoveryGracePeriod SkipReason = "discovery_grace_period"
+)
+
+const (
+	// DiscoveryStacks discovers the clusters of a source account by their
+	// source stacks.
+	DiscoveryStacks = "stacks"
+	// DiscoveryTags discovers the clusters of a source account by the cluster
+	// tag of their EC2 instances and load balancers, for installations
+	// without per cluster CloudFormation stacks.
+	DiscoveryTags = "tags"
+)
+
+var (
+	// discoveredInstanceStates are the states of the EC2 instances clusters
+	// are discovered by. Terminated instances may linger for a while after
+	// their cluster is gone.
+	discoveredInstanceStates = []string{
+		ec2.InstanceStateNamePending,
+		ec2.InstanceStateNameRunning,
+		ec2.InstanceStateNameStopping,
+		ec2.InstanceStateNameStopped,
+	}
+)
+
+// DiscoveredCluster is a cluster of a source account found by a
+// ClusterDiscoverer.
+type DiscoveredCluster struct {
+	// ID is the cluster ID, e.g. `foo`.
+	ID string
+	// SourceStack is the source stack of the cluster. It is nil for clusters
+	// discovered without source stack.
+	SourceStack *cloudformation.Stack
+	// FirstSeen is the creation time of the oldest resource the cluster was
+	// discovered by, for clusters discovered without source stack.
+	FirstSeen time.Time
+}
+
+// ClusterDiscoverer discovers the clusters of a source account. Source
+// stacks must carry the installation tag and be named after the cluster,
+// e.g. `cluster-foo-tccp`.
+type ClusterDiscoverer interface {
+	Clusters(ctx context.Context, cl client.SourceInterface) ([]DiscoveredCluster, error)
+}
+
+// stackClusterDiscoverer is the default ClusterDiscoverer. It lists the
+// source stacks of the source account, or describes them by name in scoped
+// sync runs.
+type stackClusterDiscoverer struct {
+	manager *Manager
+}
+
+func (d *stackClusterDiscoverer) Clusters(ctx context.Context, cl client.SourceInterface) ([]DiscoveredCluster, error) {
+	m := d.manager
+
+	var stacks []cloudformation.Stack
+	var err error
+	if m.scoped() {
+		stacks, err = m.getStacksByName(cl, m.scopedSourceStackNames())
+	} else {
+		stacks, err = m.getStacks(cl, sourceStackNameREs)
+	}
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var result []DiscoveredCluster
+	for i := range stacks {
+		// Stacks with invalid names are kept without ID, so the plan skips
+		// them.
+		clusterID, _ := plan.ClusterID(*stacks[i].StackName)
+		result = append(result, DiscoveredCluster{ID: clusterID, SourceStack: &stacks[i]})
+	}
+
+	return result, nil
+}
+
+// tagClusterDiscoverer discovers clusters by the EC2 instances and load
+// balancers of the installation carrying the cluster tag. The clusters have
+// no source stacks, see discoveredSourceStack.
+type tagClusterDiscoverer struct {
+	manager *Manager
+}
+
+func (d *tagClusterDiscoverer) Clusters(ctx context.Context, cl client.SourceInterface) ([]DiscoveredCluster, error) {
+	m := d.manager
+
+	firstSeen := map[string]time.Time{}
+	see := func(clusterID string, t time.Time) {
+		if clusterID == "" {
+			return
+		}
+		current, ok := firstSeen[clusterID]
+		if !ok || (!t.IsZero() && (current.IsZero() || t.Before(current))) {
+			firstSeen[clusterID] = t
+		}
+	}
+
+	input := &ec2.DescribeInstancesInput{
+		Filters: []*ec2.Filter{
+			{
+				Name:   aws.String(fmt.Sprintf("tag:%s", installationTag)),
+				Values: aws.StringSlice([]string{m.installation}),
+			},
+			{
+				Name:   aws.String("tag-key"),
+				Values: aws.StringSlice([]string{m.eniClusterTag}),
+			},
+			{
+				Name:   aws.String("instance-state-name"),
+				Values: aws.StringSlice(discoveredInstanceStates),
+			},
+		},
+	}
+	for {
+		output, err := cl.DescribeInstancesWithContext(ctx, input)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, r := range output.Reservations {
+			for _, i := range r.Instances {
+				see(ec2TagValue(i.Tags, m.eniClusterTag), aws.TimeValue(i.LaunchTime))
+			}
+		}
+
+		if output.NextToken == nil || *output.NextToken == "" {
+			break
+		}
+		input.NextToken = output.NextToken
+	}
+
+	// Clusters whose instances are not tagged, e.g. with managed node
+	// groups, are still found by their load balancers.
+	lbs, err := m.cachedTaggedELBs(cl)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	for _, lb := range lbs {
+		if lb.tags[installationTag] != m.installation {
+			continue
+		}
+		see(lb.tags[m.eniClusterTag], lb.createdTime)
+	}
+
+	var scopedNames map[string]bool
+	if m.scoped() {
+		scopedNames = map[string]bool{}
+		for _, name := range m.scopedSourceStackNames() {
+			scopedNames[name] = true
+		}
+	}
+
+	var result []DiscoveredCluster
+	for clusterID, t := range firstSeen {
+		if scopedNames != nil && !scopedNames[clusterStackName(sourceStackNamePattern, clusterID)] {
+			continue
+		}
+
+		result = append(result, DiscoveredCluster{ID: clusterID, FirstSeen: t})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+
+	return result, nil
+}
+
+// discoveredSourceStack returns the source stack the plan is computed from
+// for a cluster discovered without source stack. It is named after the
+// cluster, e.g. `cluster-foo-tccp`, complete since the cluster was first
+// seen and marked with discoveredTag, so it is never described and the etcd
+// ENIs of the cluster are looked up by the cluster tag only.
+func (m *Manager) discoveredSourceStack(c DiscoveredCluster) cloudformation.Stack {
+	stack := cloudformation.Stack{
+		StackName:   aws.String(clusterStackName(sourceStackNamePattern, c.ID)),
+		StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+		Tags: []*cloudformation.Tag{
+			{
+				Key:   aws.String(installationTag),
+				Value: aws.String(m.installation),
+			},
+			{
+				Key:   aws.String(discoveredTag),
+				Value: aws.String(DiscoveryTags),
+			},
+		},
+	}
+	if !c.FirstSeen.IsZero() {
+		stack.CreationTime = aws.Time(c.FirstSeen)
+	}
+
+	return stack
+}
+
+// isDiscoveredStack returns true for the source stacks returned by
+// discoveredSourceStack.
+func isDiscoveredStack(stack cloudformation.Stack) bool {
+	return stackTags(stack)[discoveredTag] != ""
+}
+
+// confirmGoneClusters returns the planned deletes whose clusters are
+// confirmed gone. Tag based discovery cannot tell a cluster that is gone from
+// one whose resources are missing for a moment, e.g. while its instances are
+// replaced, so the target stack of a missing cluster is tagged with
+// missingSinceTag first and only deleted once the cluster was missing for
+// m.discoveryGracePeriod. The tag is dropped by the next update when the
+// cluster is discovered again, as target stack tags are taken from the source
+// stack.
+func (m *Manager) confirmGoneClusters(deletes []plan.ClusterRef) []plan.ClusterRef {
+	if m.discoveryGracePeriod == 0 {
+		return deletes
+	}
+
+	var result []plan.ClusterRef
+	for _, ref := range deletes {
+		if ref.TargetStack == nil {
+			result = append(result, ref)
+			continue
+		}
+
+		missingSince, err := time.Parse(time.RFC3339, stackTags(*ref.TargetStack)[missingSinceTag])
+		if err == nil && m.now().Sub(missingSince) >= m.discoveryGracePeriod {
+			result = append(result, ref)
+			continue
+		}
+
+		message := fmt.Sprintf("deferred deletion of target stack %#q of cluster %#q missing from discovery for less than %s", ref.TargetStackName, ref.ID, m.discoveryGracePeriod)
+		if err != nil && !m.dryRun {
+			err = m.tagMissingSince(*ref.TargetStack)
+			if err != nil {
+				m.logger.Log("level", "error", "message", fmt.Sprintf("failed to tag target stack %#q as missing", ref.TargetStackName), "stack", microerror.JSON(err))
+				m.summary.failed++
+				continue
+			}
+		}
+		m.skip(ref.TargetStackName, SkipReasonDiscoveryGracePeriod, message, nil)
+	}
+
+	return result
+}
+
+// tagMissingSince tags the target stack with missingSinceTag set to now. The
+// template of the target stack is kept.
+func (m *Manager) tagMissingSince(targetStack cloudformation.Stack) error {
+	tags := append(withoutStackTag(targetStack.Tags, missingSinceTag), &cloudformation.Tag{
+		Key:   aws.String(missingSinceTag),
+		Value: aws.String(m.now().UTC().Format(time.RFC3339)),
+	})
+	_, err := m.targetClient.UpdateStackWithContext(m.ctx, &cloudformation.UpdateStackInput{
+		StackName:           targetStack.StackName,
+		Tags:                tags,
+		UsePreviousTemplate: aws.Bool(true),
+	})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// ec2TagValue returns the value of the tag with the given key, or an empty
+// string when the tag is missing.
+func ec2TagValue(tags []*ec2.Tag, key string) string {
+	for _, t := range tags {
+		if aws.StringValue(t.Key) == key {
+			return aws.StringValue(t.Value)
+		}
+	}
+
+	return ""
+}
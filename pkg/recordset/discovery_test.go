@@ -0,0 +1,346 @@
+package recordset
+
+import (
+	"io/ioutil"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/elb"
+)
+
+func TestTagClusterDiscoverer(t *testing.T) {
+	older := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	newer := older.Add(time.Hour)
+
+	instance := func(clusterID string, launchTime time.Time) *ec2.Instance {
+		i := &ec2.Instance{
+			LaunchTime: aws.Time(launchTime),
+		}
+		if clusterID != "" {
+			i.Tags = []*ec2.Tag{
+				{
+					Key:   aws.String("giantswarm.io/cluster"),
+					Value: aws.String(clusterID),
+				},
+			}
+		}
+		return i
+	}
+
+	loadBalancer := func(name string, createdTime time.Time) *elb.LoadBalancerDescription {
+		return &elb.LoadBalancerDescription{
+			LoadBalancerName: aws.String(name),
+			CreatedTime:      aws.Time(createdTime),
+		}
+	}
+	loadBalancerTags := func(installation, clusterID string) []*elb.Tag {
+		return []*elb.Tag{
+			{
+				Key:   aws.String(installationTag),
+				Value: aws.String(installation),
+			},
+			{
+				Key:   aws.String("giantswarm.io/cluster"),
+				Value: aws.String(clusterID),
+			},
+		}
+	}
+
+	tcs := []struct {
+		name              string
+		instancePages     [][]*ec2.Instance
+		loadBalancers     []*elb.LoadBalancerDescription
+		loadBalancerTags  map[string][]*elb.Tag
+		cluster           string
+		expectedStacks    []string
+		expectedCreations map[string]time.Time
+	}{
+		{
+			name: "case 0: no instances",
+			instancePages: [][]*ec2.Instance{
+				{},
+			},
+		},
+		{
+			name: "case 1: one cluster per tag value",
+			instancePages: [][]*ec2.Instance{
+				{
+					instance("foo", newer),
+					instance("bar", newer),
+					instance("", older),
+				},
+				{
+					instance("foo", older),
+				},
+			},
+			expectedStacks: []string{
+				"cluster-bar-tccp",
+				"cluster-foo-tccp",
+			},
+			expectedCreations: map[string]time.Time{
+				"cluster-bar-tccp": newer,
+				"cluster-foo-tccp": older,
+			},
+		},
+		{
+			name: "case 2: scoped to cluster",
+			instancePages: [][]*ec2.Instance{
+				{
+					instance("foo", newer),
+					instance("bar", newer),
+				},
+			},
+			cluster: "foo",
+			expectedStacks: []string{
+				"cluster-foo-tccp",
+			},
+			expectedCreations: map[string]time.Time{
+				"cluster-foo-tccp": newer,
+			},
+		},
+		{
+			name: "case 3: clusters of load balancers of the installation",
+			instancePages: [][]*ec2.Instance{
+				{
+					instance("foo", newer),
+				},
+			},
+			loadBalancers: []*elb.LoadBalancerDescription{
+				loadBalancer("foo-api", older),
+				loadBalancer("bar-api", newer),
+				loadBalancer("baz-api", older),
+			},
+			loadBalancerTags: map[string][]*elb.Tag{
+				"foo-api": loadBalancerTags("installation", "foo"),
+				"bar-api": loadBalancerTags("installation", "bar"),
+				"baz-api": loadBalancerTags("other", "baz"),
+			},
+			expectedStacks: []string{
+				"cluster-bar-tccp",
+				"cluster-foo-tccp",
+			},
+			expectedCreations: map[string]time.Time{
+				"cluster-bar-tccp": newer,
+				"cluster-foo-tccp": older,
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			sourceClient := newSourceWithStacks(nil)
+			sourceClient.instancePages = tc.instancePages
+			sourceClient.loadBalancers = tc.loadBalancers
+			sourceClient.loadBalancerTags = tc.loadBalancerTags
+
+			c := newTestConfig(t)
+			c.SourceClient = sourceClient
+			c.Discovery = DiscoveryTags
+			c.Cluster = tc.cluster
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			stacks, err := m.accountSourceStacks(sourceClient)
+			if err != nil {
+				t.Fatalf("accountSourceStacks: %v", err)
+			}
+
+			var names []string
+			for _, s := range stacks {
+				names = append(names, *s.StackName)
+
+				if aws.StringValue(s.StackStatus) != cloudformation.StackStatusCreateComplete {
+					t.Errorf("expected stack %#q with status %#q, got %#q", *s.StackName, cloudformation.StackStatusCreateComplete, aws.StringValue(s.StackStatus))
+				}
+				if validStackInstallationTag(&cloudformation.DescribeStacksOutput{Stacks: []*cloudformation.Stack{&s}}, "installation") != 0 {
+					t.Errorf("expected stack %#q with installation tag", *s.StackName)
+				}
+				if !isDiscoveredStack(s) {
+					t.Errorf("expected stack %#q marked as discovered", *s.StackName)
+				}
+				if !aws.TimeValue(s.CreationTime).Equal(tc.expectedCreations[*s.StackName]) {
+					t.Errorf("expected stack %#q created at %v, got %v", *s.StackName, tc.expectedCreations[*s.StackName], aws.TimeValue(s.CreationTime))
+				}
+			}
+			if !reflect.DeepEqual(tc.expectedStacks, names) {
+				t.Errorf("expected stacks %v, got %v", tc.expectedStacks, names)
+			}
+
+			filters := map[string][]string{}
+			for _, f := range sourceClient.describeInstancesInput.Filters {
+				filters[*f.Name] = aws.StringValueSlice(f.Values)
+			}
+			if !reflect.DeepEqual(filters["tag:giantswarm.io/installation"], []string{"installation"}) {
+				t.Errorf("expected instances filtered by installation, got %v", filters)
+			}
+			if !reflect.DeepEqual(filters["tag-key"], []string{"giantswarm.io/cluster"}) {
+				t.Errorf("expected instances filtered by cluster tag, got %v", filters)
+			}
+		})
+	}
+}
+
+func TestSync_DiscoveryTags(t *testing.T) {
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tcs := []struct {
+		name            string
+		missingSince    string
+		dryRun          bool
+		expectedDeleted []string
+		expectedTagged  bool
+	}{
+		{
+			name:           "case 0: missing cluster is tagged instead of deleted",
+			expectedTagged: true,
+		},
+		{
+			name:         "case 1: cluster missing within grace period is kept",
+			missingSince: now.Add(-time.Minute).Format(time.RFC3339),
+		},
+		{
+			name:            "case 2: cluster missing beyond grace period is deleted",
+			missingSince:    now.Add(-2 * time.Hour).Format(time.RFC3339),
+			expectedDeleted: []string{"cluster-bar-guest-recordsets"},
+		},
+		{
+			name:   "case 3: missing cluster is not tagged in dry run",
+			dryRun: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			sourceClient := newSourceWithStacks(nil)
+			sourceClient.instancePages = [][]*ec2.Instance{
+				{
+					&ec2.Instance{
+						Tags: []*ec2.Tag{
+							{
+								Key:   aws.String("giantswarm.io/cluster"),
+								Value: aws.String("foo"),
+							},
+						},
+					},
+				},
+			}
+			tags := []*cloudformation.Tag{
+				{
+					Key:   aws.String(installationTag),
+					Value: aws.String("installation"),
+				},
+			}
+			if tc.missingSince != "" {
+				tags = append(tags, &cloudformation.Tag{
+					Key:   aws.String(missingSinceTag),
+					Value: aws.String(tc.missingSince),
+				})
+			}
+			targetClient := newTargetWithStacks([]cloudformation.Stack{
+				cloudformation.Stack{
+					StackName:   aws.String("cluster-bar-guest-recordsets"),
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+					Tags:        tags,
+				},
+			})
+
+			c := newTestConfig(t)
+			c.SourceClient = sourceClient
+			c.TargetClient = targetClient
+			c.Discovery = DiscoveryTags
+			c.DryRun = tc.dryRun
+			c.DryRunOutput = ioutil.Discard
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+			m.now = func() time.Time { return now }
+
+			err = m.Sync()
+			if err != nil {
+				t.Fatalf("m.Sync: %v", err)
+			}
+
+			if !tc.dryRun {
+				expectedCreated := []string{"cluster-foo-guest-recordsets"}
+				if !reflect.DeepEqual(expectedCreated, targetClient.createdStacks) {
+					t.Errorf("expected created stacks %v, got %v", expectedCreated, targetClient.createdStacks)
+				}
+			}
+			if !reflect.DeepEqual(tc.expectedDeleted, targetClient.deletedStacks) {
+				t.Errorf("expected deleted stacks %v, got %v", tc.expectedDeleted, targetClient.deletedStacks)
+			}
+
+			var tagged bool
+			for _, input := range targetClient.updateStackInputs {
+				if *input.StackName != "cluster-bar-guest-recordsets" {
+					continue
+				}
+				if !aws.BoolValue(input.UsePreviousTemplate) {
+					t.Errorf("expected target stack %#q tagged with previous template", *input.StackName)
+				}
+				tags := stackTags(cloudformation.Stack{Tags: input.Tags})
+				if tags[missingSinceTag] != now.Format(time.RFC3339) || tags[installationTag] != "installation" {
+					t.Errorf("expected target stack %#q tagged missing since %s, got %v", *input.StackName, now.Format(time.RFC3339), tags)
+				}
+				tagged = true
+			}
+			if tagged != tc.expectedTagged {
+				t.Errorf("expected tagged %t, got %t", tc.expectedTagged, tagged)
+			}
+		})
+	}
+}
+
+func TestNewManager_InvalidDiscovery(t *testing.T) {
+	tcs := []struct {
+		name   string
+		config func(c *Config)
+	}{
+		{
+			name: "case 0: unknown discovery",
+			config: func(c *Config) {
+				c.Discovery = "instances"
+			},
+		},
+		{
+			name: "case 1: tags discovery with ELB DNS from outputs",
+			config: func(c *Config) {
+				c.Discovery = DiscoveryTags
+				c.ELBDNSFromOutputs = true
+			},
+		},
+		{
+			name: "case 2: tags discovery without CloudFormation",
+			config: func(c *Config) {
+				c.Discovery = DiscoveryTags
+				c.ApplyMode = ApplyModeRoute53Atomic
+			},
+		},
+		{
+			name: "case 3: negative grace period",
+			config: func(c *Config) {
+				c.Discovery = DiscoveryTags
+				c.DiscoveryGracePeriod = -time.Minute
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newTestConfig(t)
+			tc.config(c)
+
+			_, err := NewManager(c)
+			if !IsInvalidConfig(err) {
+				t.Errorf("expected invalid config error, got %v", err)
+			}
+		})
+	}
+}
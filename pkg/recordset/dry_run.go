@@ -0,0 +1,87 @@
+package recordset
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/route53-manager/pkg/recordset/plan"
+)
+
+// reportPlan reports the planned changes instead of applying them. For every
+// target stack to be created or updated the unified diff between its current
+// and the rendered template is written to m.dryRunOutput. Target stacks
+// already up to date get no diff.
+func (m *Manager) reportPlan(p plan.Plan) error {
+	for _, ref := range p.Creates {
+		err := m.printTemplateDiff(ref, "")
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	for _, ref := range p.Updates {
+		o, err := m.targetClient.GetTemplateWithContext(m.ctx, &cloudformation.GetTemplateInput{
+			StackName: aws.String(ref.TargetStackName),
+		})
+		if err != nil {
+			m.logger.Log("level", "error", "message", fmt.Sprintf("failed to get template of target stack %#q", ref.TargetStackName), "stack", microerror.JSON(err))
+			m.summary.failed++
+			continue
+		}
+
+		err = m.printTemplateDiff(ref, aws.StringValue(o.TemplateBody))
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	for _, ref := range p.Deletes {
+		m.logger.Log("level", "info", "message", fmt.Sprintf("would delete orphan target stack %#q", ref.TargetStackName))
+	}
+
+	return nil
+}
+
+// printTemplateDiff writes the diff between the current template of the
+// target stack and its rendered template to m.dryRunOutput. The current
+// template is empty for target stacks to be created.
+func (m *Manager) printTemplateDiff(ref plan.ClusterRef, current string) error {
+	err := m.checkDeadline()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	records, err := m.getRecords(m.newCluster(ref))
+	if err != nil {
+		m.skipRecordsFailed(*ref.SourceStack.StackName, ref.ID, err)
+		return nil
+	}
+
+	rendered, err := m.getStackTemplateBody(records)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	diff := unifiedDiff(ref.TargetStackName+" (current)", ref.TargetStackName+" (rendered)", current, rendered)
+	if diff == "" {
+		m.logSkipped(fmt.Sprintf("would skip target stack %#q (already up to date)", ref.TargetStackName))
+		m.summary.unchanged++
+		return nil
+	}
+
+	_, err = fmt.Fprint(m.dryRunOutput, diff)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if current == "" {
+		m.summary.addCreated(ref.ID)
+	} else {
+		m.summary.addUpdated(ref.ID)
+	}
+
+	return nil
+}
@@ -0,0 +1,121 @@
+package recordset
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+)
+
+func TestSync_DryRun(t *testing.T) {
+	tags := []*cloudformation.Tag{
+		&cloudformation.Tag{
+			Key:   aws.String(installationTag),
+			Value: aws.String("installation"),
+		},
+	}
+
+	tcs := []struct {
+		name            string
+		currentTemplate func(rendered string) string
+		expectedDiff    bool
+	}{
+		{
+			name: "case 0: no diff for identical templates",
+			currentTemplate: func(rendered string) string {
+				return rendered
+			},
+			expectedDiff: false,
+		},
+		{
+			name: "case 1: diff for changed templates",
+			currentTemplate: func(rendered string) string {
+				return strings.Replace(rendered, "elb.dns.test", "old.elb.dns.test", -1)
+			},
+			expectedDiff: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			sourceClient := newSourceWithStacks([]cloudformation.Stack{
+				{
+					StackName:   aws.String("cluster-foo-tccp"),
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+					Tags:        tags,
+				},
+			})
+			targetClient := newTargetWithStacks([]cloudformation.Stack{
+				{
+					StackName:   aws.String("cluster-foo-guest-recordsets"),
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+					Tags:        tags,
+				},
+			})
+
+			var out bytes.Buffer
+			c := newTestConfig(t)
+			c.SourceClient = sourceClient
+			c.TargetClient = targetClient
+			c.DryRun = true
+			c.DryRunOutput = &out
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			records, err := m.getRecords(Cluster{ID: "foo"})
+			if err != nil {
+				t.Fatalf("getRecords: %v", err)
+			}
+			rendered, err := m.getStackTemplateBody(records)
+			if err != nil {
+				t.Fatalf("getStackTemplateBody: %v", err)
+			}
+			targetClient.templates = map[string]string{
+				"cluster-foo-guest-recordsets": tc.currentTemplate(rendered),
+			}
+
+			err = m.Sync()
+			if err != nil {
+				t.Fatalf("m.Sync: %v", err)
+			}
+
+			if len(targetClient.updatedStacks) != 0 || len(targetClient.createdStacks) != 0 {
+				t.Fatalf("expected no changes in dry run, got updates %v and creations %v", targetClient.updatedStacks, targetClient.createdStacks)
+			}
+
+			diff := out.String()
+			if !tc.expectedDiff {
+				if diff != "" {
+					t.Fatalf("expected no diff, got\n%s", diff)
+				}
+				return
+			}
+			if !strings.HasPrefix(diff, "--- cluster-foo-guest-recordsets (current)\n+++ cluster-foo-guest-recordsets (rendered)\n") {
+				t.Errorf("expected diff of target stack %#q, got\n%s", "cluster-foo-guest-recordsets", diff)
+			}
+			var removed, added bool
+			for _, l := range strings.Split(diff, "\n") {
+				removed = removed || strings.HasPrefix(l, "-") && strings.Contains(l, `"old.elb.dns.test"`)
+				added = added || strings.HasPrefix(l, "+") && strings.Contains(l, `"elb.dns.test"`)
+			}
+			if !removed || !added {
+				t.Errorf("expected changed ELB DNS name in diff, got\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNewManager_InvalidDryRun(t *testing.T) {
+	c := newTestConfig(t)
+	c.DryRun = true
+	c.ApplyMode = ApplyModeRoute53Atomic
+
+	_, err := NewManager(c)
+	if !IsInvalidConfig(err) {
+		t.Errorf("expected invalid config error, got %v", err)
+	}
+}
@@ -0,0 +1,84 @@
+package recordset
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/route53-manager/pkg/client"
+)
+
+const (
+	// instanceStateInService is the state of healthy instances of classic
+	// ELBs.
+	instanceStateInService = "InService"
+)
+
+// checkELBHealth returns unhealthyELBError unless the load balancer has at
+// least one healthy instance, for classic ELBs, or at least one healthy
+// target in any of its target groups, for application and network load
+// balancers.
+func (m *Manager) checkELBHealth(cl client.SourceInterface, lb *loadBalancer) error {
+	var healthy bool
+	var err error
+	if lb.ARN != "" {
+		healthy, err = m.hasHealthyTargets(cl, lb.ARN)
+	} else {
+		healthy, err = m.hasHealthyInstances(cl, lb.Name)
+	}
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if !healthy {
+		return microerror.Maskf(unhealthyELBError, "load balancer %#q has no healthy backend", lb.DNSName)
+	}
+
+	return nil
+}
+
+func (m *Manager) hasHealthyInstances(cl client.SourceInterface, elbName string) (bool, error) {
+	input := &elb.DescribeInstanceHealthInput{
+		LoadBalancerName: aws.String(elbName),
+	}
+	output, err := cl.DescribeInstanceHealthWithContext(m.ctx, input)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	for _, s := range output.InstanceStates {
+		if aws.StringValue(s.State) == instanceStateInService {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (m *Manager) hasHealthyTargets(cl client.SourceInterface, elbARN string) (bool, error) {
+	input := &elbv2.DescribeTargetGroupsInput{
+		LoadBalancerArn: aws.String(elbARN),
+	}
+	output, err := cl.DescribeTargetGroupsWithContext(m.ctx, input)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	for _, g := range output.TargetGroups {
+		healthOutput, err := cl.DescribeTargetHealthWithContext(m.ctx, &elbv2.DescribeTargetHealthInput{
+			TargetGroupArn: g.TargetGroupArn,
+		})
+		if err != nil {
+			return false, microerror.Mask(err)
+		}
+
+		for _, d := range healthOutput.TargetHealthDescriptions {
+			if d.TargetHealth != nil && aws.StringValue(d.TargetHealth.State) == elbv2.TargetHealthStateEnumHealthy {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
@@ -0,0 +1,179 @@
+package recordset
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+)
+
+func TestGetComponentELB_Health(t *testing.T) {
+	tcs := []struct {
+		name               string
+		requireHealthyELB  bool
+		loadBalancersV2    []*elbv2.LoadBalancer
+		instanceStates     []string
+		targetHealthStates []string
+		expectedUnhealthy  bool
+	}{
+		{
+			name:           "case 0: unhealthy classic ELB without health requirement",
+			instanceStates: []string{"OutOfService"},
+		},
+		{
+			name:              "case 1: healthy classic ELB",
+			requireHealthyELB: true,
+			instanceStates:    []string{"OutOfService", "InService"},
+		},
+		{
+			name:              "case 2: classic ELB without healthy instances",
+			requireHealthyELB: true,
+			instanceStates:    []string{"OutOfService", "Unknown"},
+			expectedUnhealthy: true,
+		},
+		{
+			name:              "case 3: classic ELB without instances",
+			requireHealthyELB: true,
+			instanceStates:    []string{},
+			expectedUnhealthy: true,
+		},
+		{
+			name:               "case 4: healthy network load balancer",
+			requireHealthyELB:  true,
+			loadBalancersV2:    []*elbv2.LoadBalancer{newTestLoadBalancerV2()},
+			targetHealthStates: []string{elbv2.TargetHealthStateEnumInitial, elbv2.TargetHealthStateEnumHealthy},
+		},
+		{
+			name:               "case 5: network load balancer without healthy targets",
+			requireHealthyELB:  true,
+			loadBalancersV2:    []*elbv2.LoadBalancer{newTestLoadBalancerV2()},
+			targetHealthStates: []string{elbv2.TargetHealthStateEnumInitial, elbv2.TargetHealthStateEnumUnhealthy},
+			expectedUnhealthy:  true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			sourceClient := newSourceWithStacks(nil)
+			sourceClient.noLoadBalancers = len(tc.loadBalancersV2) > 0
+			sourceClient.loadBalancersV2 = tc.loadBalancersV2
+			sourceClient.instanceStates = tc.instanceStates
+			sourceClient.targetHealthStates = tc.targetHealthStates
+
+			c := newTestConfig(t)
+			c.SourceClient = sourceClient
+			c.RequireHealthyELB = tc.requireHealthyELB
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			_, err = m.getComponentELB(sourceClient, "foo", Component{Name: "api", ELBSuffix: "-api"})
+			if tc.expectedUnhealthy != IsUnhealthyELB(err) {
+				t.Fatalf("expected unhealthy %v, got %v", tc.expectedUnhealthy, err)
+			}
+			if !tc.expectedUnhealthy && err != nil {
+				t.Fatalf("getComponentELB: %v", err)
+			}
+
+			if len(tc.loadBalancersV2) > 0 {
+				if len(sourceClient.targetGroupsInputs) != 1 {
+					t.Fatalf("expected 1 DescribeTargetGroups call, got %d", len(sourceClient.targetGroupsInputs))
+				}
+				arn := aws.StringValue(sourceClient.targetGroupsInputs[0].LoadBalancerArn)
+				if arn != "nlbARN" {
+					t.Errorf("expected target groups of load balancer %#q, got %#q", "nlbARN", arn)
+				}
+			}
+		})
+	}
+}
+
+func TestSync_UnhealthyELB(t *testing.T) {
+	tcs := []struct {
+		name              string
+		isLegacy          bool
+		unhealthyELBs     []string
+		expectedRecords   []string
+		unexpectedRecords []string
+	}{
+		{
+			name:              "case 0: unhealthy api ELB",
+			unhealthyELBs:     []string{"foo-api"},
+			expectedRecords:   []string{"etcd.foo.zoneName", "*.foo.zoneName"},
+			unexpectedRecords: []string{"api.foo.zoneName"},
+		},
+		{
+			name:              "case 1: unhealthy ingress ELB of a legacy cluster",
+			isLegacy:          true,
+			unhealthyELBs:     []string{"foo-ingress"},
+			expectedRecords:   []string{"api.foo.zoneName", "etcd.foo.zoneName"},
+			unexpectedRecords: []string{"ingress.foo.zoneName", "*.foo.zoneName"},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			stackName := "cluster-foo-tccp"
+			if tc.isLegacy {
+				stackName = "cluster-foo-guest-main"
+			}
+			sourceClient := newSourceWithStacks([]cloudformation.Stack{
+				{
+					StackName:   aws.String(stackName),
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+					Tags: []*cloudformation.Tag{
+						{
+							Key:   aws.String(installationTag),
+							Value: aws.String("installation"),
+						},
+					},
+				},
+			})
+			sourceClient.unhealthyELBs = tc.unhealthyELBs
+			targetClient := newTargetWithStacks(nil)
+
+			c := newTestConfig(t)
+			c.SourceClient = sourceClient
+			c.TargetClient = targetClient
+			c.RequireHealthyELB = true
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			err = m.Sync()
+			if err != nil {
+				t.Fatalf("Sync: %v", err)
+			}
+
+			if len(targetClient.createStackInputs) != 1 {
+				t.Fatalf("expected 1 created stack, got %v", targetClient.createdStacks)
+			}
+			body := aws.StringValue(targetClient.createStackInputs[0].TemplateBody)
+			for _, name := range tc.expectedRecords {
+				if !strings.Contains(body, `"`+name+`"`) {
+					t.Errorf("expected record %#q, got %s", name, body)
+				}
+			}
+			for _, name := range tc.unexpectedRecords {
+				if strings.Contains(body, `"`+name+`"`) {
+					t.Errorf("expected no record %#q, got %s", name, body)
+				}
+			}
+		})
+	}
+}
+
+func newTestLoadBalancerV2() *elbv2.LoadBalancer {
+	lb := &elbv2.LoadBalancer{
+		CanonicalHostedZoneId: aws.String("nlbZoneID"),
+		DNSName:               aws.String("nlb.dns.test"),
+		LoadBalancerArn:       aws.String("nlbARN"),
+		Type:                  aws.String(elbv2.LoadBalancerTypeEnumNetwork),
+	}
+
+	return lb
+}
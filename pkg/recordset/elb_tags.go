@@ -0,0 +1,247 @@
+package recordset
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/route53-manager/pkg/client"
+)
+
+const (
+	// ELBLookupName looks the ELB of a component up by its name, the cluster
+	// ID followed by the ELB suffix of the component, e.g. `foo-api`.
+	ELBLookupName = "name"
+	// ELBLookupTags looks the ELB of a component up by its tags, the cluster
+	// tag holding the cluster ID and the role tag holding the component
+	// name, e.g. for ELBs with generated names.
+	ELBLookupTags = "tags"
+)
+
+const (
+	// DefaultELBRoleTag is the tag key carrying the name of the component an
+	// ELB belongs to, e.g. `api`.
+	DefaultELBRoleTag = "giantswarm.io/elb-role"
+
+	// describeTagsLimit is the maximum number of load balancers the tags of
+	// which DescribeTags returns per call, in both ELB APIs.
+	describeTagsLimit = 20
+)
+
+// taggedLoadBalancer is a classic, application or network load balancer
+// with its tags and creation time.
+type taggedLoadBalancer struct {
+	loadBalancer
+	tags        map[string]string
+	createdTime time.Time
+}
+
+// getComponentELB looks the ELB of the component of the cluster up, either by
+// name or by tags.
+func (m *Manager) getComponentELB(cl client.SourceInterface, clusterID string, c Component) (*loadBalancer, error) {
+	var lb *loadBalancer
+	var err error
+	if m.elbLookup == ELBLookupTags {
+		lb, err = m.getTaggedELB(cl, clusterID, c.Name)
+	} else {
+		lb, err = m.getELB(cl, clusterID+c.ELBSuffix)
+	}
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	if m.requireHealthyELB {
+		err = m.checkELBHealth(cl, lb)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	return lb, nil
+}
+
+// getTaggedELB returns the load balancer carrying the cluster tag with the
+// cluster ID and the role tag with the given role. The load balancers of the
+// source account are listed once per sync run.
+func (m *Manager) getTaggedELB(cl client.SourceInterface, clusterID, role string) (*loadBalancer, error) {
+	lbs, err := m.cachedTaggedELBs(cl)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	for _, lb := range lbs {
+		if lb.tags[m.eniClusterTag] != clusterID || lb.tags[m.elbRoleTag] != role {
+			continue
+		}
+
+		m.elbHostedZoneIDs[lb.DNSName] = lb.CanonicalHostedZoneID
+		result := lb.loadBalancer
+		return &result, nil
+	}
+
+	return nil, microerror.Maskf(elbNotFoundError, "load balancer tagged %s=%s and %s=%s", m.eniClusterTag, clusterID, m.elbRoleTag, role)
+}
+
+// cachedTaggedELBs returns the load balancers of the source account with
+// their tags, listing them once per sync run.
+func (m *Manager) cachedTaggedELBs(cl client.SourceInterface) ([]taggedLoadBalancer, error) {
+	if m.taggedELBs == nil {
+		m.taggedELBs = map[client.SourceInterface][]taggedLoadBalancer{}
+	}
+
+	lbs, ok := m.taggedELBs[cl]
+	if !ok {
+		var err error
+		lbs, err = m.listTaggedELBs(cl)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		m.taggedELBs[cl] = lbs
+	}
+
+	return lbs, nil
+}
+
+// listTaggedELBs returns all classic, application and network load balancers
+// of the source account with their tags.
+func (m *Manager) listTaggedELBs(cl client.SourceInterface) ([]taggedLoadBalancer, error) {
+	classic, err := m.listTaggedClassicELBs(cl)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	v2, err := m.listTaggedELBV2s(cl)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return append(classic, v2...), nil
+}
+
+func (m *Manager) listTaggedClassicELBs(cl client.SourceInterface) ([]taggedLoadBalancer, error) {
+	var descriptions []*elb.LoadBalancerDescription
+	input := &elb.DescribeLoadBalancersInput{}
+	for {
+		output, err := cl.DescribeLoadBalancersWithContext(m.ctx, input)
+		if isAWSErrorCode(err, elb.ErrCodeAccessPointNotFoundException) {
+			break
+		} else if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		descriptions = append(descriptions, output.LoadBalancerDescriptions...)
+
+		if output.NextMarker == nil || *output.NextMarker == "" {
+			break
+		}
+		input.Marker = output.NextMarker
+	}
+
+	tags := map[string]map[string]string{}
+	for i := 0; i < len(descriptions); i += describeTagsLimit {
+		end := i + describeTagsLimit
+		if end > len(descriptions) {
+			end = len(descriptions)
+		}
+
+		var names []*string
+		for _, d := range descriptions[i:end] {
+			names = append(names, d.LoadBalancerName)
+		}
+		output, err := cl.DescribeLoadBalancerTagsWithContext(m.ctx, &elb.DescribeTagsInput{
+			LoadBalancerNames: names,
+		})
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, d := range output.TagDescriptions {
+			t := map[string]string{}
+			for _, tag := range d.Tags {
+				t[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+			}
+			tags[aws.StringValue(d.LoadBalancerName)] = t
+		}
+	}
+
+	var result []taggedLoadBalancer
+	for _, d := range descriptions {
+		lb := taggedLoadBalancer{
+			loadBalancer: loadBalancer{
+				CanonicalHostedZoneID: aws.StringValue(d.CanonicalHostedZoneNameID),
+				DNSName:               aws.StringValue(d.DNSName),
+				Name:                  aws.StringValue(d.LoadBalancerName),
+			},
+			tags:        tags[aws.StringValue(d.LoadBalancerName)],
+			createdTime: aws.TimeValue(d.CreatedTime),
+		}
+		result = append(result, lb)
+	}
+
+	return result, nil
+}
+
+func (m *Manager) listTaggedELBV2s(cl client.SourceInterface) ([]taggedLoadBalancer, error) {
+	var lbs []*elbv2.LoadBalancer
+	input := &elbv2.DescribeLoadBalancersInput{}
+	for {
+		output, err := cl.DescribeLoadBalancersV2WithContext(m.ctx, input)
+		if isAWSErrorCode(err, elbv2.ErrCodeLoadBalancerNotFoundException) {
+			break
+		} else if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		lbs = append(lbs, output.LoadBalancers...)
+
+		if output.NextMarker == nil || *output.NextMarker == "" {
+			break
+		}
+		input.Marker = output.NextMarker
+	}
+
+	tags := map[string]map[string]string{}
+	for i := 0; i < len(lbs); i += describeTagsLimit {
+		end := i + describeTagsLimit
+		if end > len(lbs) {
+			end = len(lbs)
+		}
+
+		var arns []*string
+		for _, lb := range lbs[i:end] {
+			arns = append(arns, lb.LoadBalancerArn)
+		}
+		output, err := cl.DescribeLoadBalancerTagsV2WithContext(m.ctx, &elbv2.DescribeTagsInput{
+			ResourceArns: arns,
+		})
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, d := range output.TagDescriptions {
+			t := map[string]string{}
+			for _, tag := range d.Tags {
+				t[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+			}
+			tags[aws.StringValue(d.ResourceArn)] = t
+		}
+	}
+
+	var result []taggedLoadBalancer
+	for _, lb := range lbs {
+		r := taggedLoadBalancer{
+			loadBalancer: loadBalancer{
+				CanonicalHostedZoneID: aws.StringValue(lb.CanonicalHostedZoneId),
+				DNSName:               aws.StringValue(lb.DNSName),
+				ARN:                   aws.StringValue(lb.LoadBalancerArn),
+			},
+			tags:        tags[aws.StringValue(lb.LoadBalancerArn)],
+			createdTime: aws.TimeValue(lb.CreatedTime),
+		}
+		result = append(result, r)
+	}
+
+	return result, nil
+}
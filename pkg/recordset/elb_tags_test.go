@@ -0,0 +1,136 @@
+package recordset
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+)
+
+func TestGetComponentELB_Tags(t *testing.T) {
+	classicTags := func(clusterID, role string) []*elb.Tag {
+		return []*elb.Tag{
+			{
+				Key:   aws.String("giantswarm.io/cluster"),
+				Value: aws.String(clusterID),
+			},
+			{
+				Key:   aws.String(DefaultELBRoleTag),
+				Value: aws.String(role),
+			},
+		}
+	}
+
+	tcs := []struct {
+		name             string
+		clusterID        string
+		component        Component
+		expected         *loadBalancer
+		expectedNotFound bool
+	}{
+		{
+			name:      "case 0: classic ELB matched by tags",
+			clusterID: "foo",
+			component: Component{Name: "api", ELBSuffix: "-api"},
+			expected: &loadBalancer{
+				CanonicalHostedZoneID: "elbZoneID",
+				DNSName:               "foo-api-1a2b.elb.dns.test",
+				Name:                  "foo-api-1a2b",
+			},
+		},
+		{
+			name:      "case 1: elbv2 load balancer matched by tags",
+			clusterID: "foo",
+			component: Component{Name: "ingress", ELBSuffix: "-ingress"},
+			expected: &loadBalancer{
+				CanonicalHostedZoneID: "nlbZoneID",
+				DNSName:               "foo-ingress-3c4d.nlb.dns.test",
+				ARN:                   "arn:foo-ingress",
+			},
+		},
+		{
+			name:             "case 2: role tag of another cluster",
+			clusterID:        "foo",
+			component:        Component{Name: "etcd", ELBSuffix: "-etcd"},
+			expectedNotFound: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			sourceClient := newSourceWithStacks(nil)
+			sourceClient.loadBalancers = []*elb.LoadBalancerDescription{
+				{
+					LoadBalancerName:          aws.String("foo-api-1a2b"),
+					CanonicalHostedZoneNameID: aws.String("elbZoneID"),
+					DNSName:                   aws.String("foo-api-1a2b.elb.dns.test"),
+				},
+				{
+					LoadBalancerName:          aws.String("bar-etcd-5e6f"),
+					CanonicalHostedZoneNameID: aws.String("elbZoneID"),
+					DNSName:                   aws.String("bar-etcd-5e6f.elb.dns.test"),
+				},
+			}
+			sourceClient.loadBalancerTags = map[string][]*elb.Tag{
+				"foo-api-1a2b":  classicTags("foo", "api"),
+				"bar-etcd-5e6f": classicTags("bar", "etcd"),
+			}
+			sourceClient.loadBalancersV2 = []*elbv2.LoadBalancer{
+				{
+					LoadBalancerArn:       aws.String("arn:foo-ingress"),
+					CanonicalHostedZoneId: aws.String("nlbZoneID"),
+					DNSName:               aws.String("foo-ingress-3c4d.nlb.dns.test"),
+				},
+			}
+			sourceClient.loadBalancerTagsV2 = map[string][]*elbv2.Tag{
+				"arn:foo-ingress": {
+					{
+						Key:   aws.String("giantswarm.io/cluster"),
+						Value: aws.String("foo"),
+					},
+					{
+						Key:   aws.String(DefaultELBRoleTag),
+						Value: aws.String("ingress"),
+					},
+				},
+			}
+
+			c := newTestConfig(t)
+			c.SourceClient = sourceClient
+			c.ELBLookup = ELBLookupTags
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			lb, err := m.getComponentELB(sourceClient, tc.clusterID, tc.component)
+			if tc.expectedNotFound {
+				if !IsELBNotFound(err) {
+					t.Fatalf("expected not found error, got %v", err)
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("getComponentELB: %v", err)
+			}
+
+			if !reflect.DeepEqual(tc.expected, lb) {
+				t.Errorf("expected %#v, got %#v", tc.expected, lb)
+			}
+			if m.elbHostedZoneIDs[lb.DNSName] != lb.CanonicalHostedZoneID {
+				t.Errorf("expected hosted zone ID of %#q to be cached, got %v", lb.DNSName, m.elbHostedZoneIDs)
+			}
+		})
+	}
+}
+
+func TestNewManager_InvalidELBLookup(t *testing.T) {
+	c := newTestConfig(t)
+	c.ELBLookup = "arn"
+
+	_, err := NewManager(c)
+	if !IsInvalidConfig(err) {
+		t.Errorf("expected invalid config error, got %v", err)
+	}
+}
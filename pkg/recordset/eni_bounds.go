@@ -0,0 +1,30 @@
+package recordset
+
+import (
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	// DefaultMinEtcdENIs and DefaultMaxEtcdENIs are the bounds of the number
+	// of etcd ENIs of a cluster when no bounds are configured.
+	DefaultMinEtcdENIs = 1
+	DefaultMaxEtcdENIs = 9
+)
+
+// checkEtcdENIBounds returns etcdENIsOutOfBoundsError when the number of etcd
+// ENIs of the cluster is not within m.minEtcdENIs and m.maxEtcdENIs, e.g. for
+// a misconfigured ENI filter matching no or unrelated network interfaces. The
+// `etcd0` record added for the first ENI is not counted. The cluster is then
+// skipped, so its current etcd records are kept instead of being removed.
+func (m *Manager) checkEtcdENIBounds(clusterID string, eniList []EtcdEni) error {
+	count := len(eniList)
+	if count > 0 {
+		count--
+	}
+
+	if count < m.minEtcdENIs || count > m.maxEtcdENIs {
+		return microerror.Maskf(etcdENIsOutOfBoundsError, "found %d etcd ENIs of cluster %#q, expected between %d and %d", count, clusterID, m.minEtcdENIs, m.maxEtcdENIs)
+	}
+
+	return nil
+}
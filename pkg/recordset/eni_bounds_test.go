@@ -0,0 +1,154 @@
+package recordset
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func TestGetSourceStackData_EtcdENIBounds(t *testing.T) {
+	newNetworkInterfaces := func(n int) []*ec2.NetworkInterface {
+		var result []*ec2.NetworkInterface
+		for i := 0; i < n; i++ {
+			result = append(result, &ec2.NetworkInterface{
+				PrivateIpAddress: aws.String(fmt.Sprintf("10.1.0.%d", i+1)),
+			})
+		}
+		return result
+	}
+
+	tcs := []struct {
+		name                string
+		isLegacyCluster     bool
+		noNetworkInterfaces bool
+		networkInterfaces   []*ec2.NetworkInterface
+		minEtcdENIs         *int
+		maxEtcdENIs         int
+		expectedENIs        int
+		expectedOutOfBounds bool
+	}{
+		{
+			name:              "case 0: three ENIs",
+			networkInterfaces: newNetworkInterfaces(3),
+			// etcd1 to etcd3 and etcd0.
+			expectedENIs: 4,
+		},
+		{
+			name:                "case 1: zero ENIs",
+			noNetworkInterfaces: true,
+			expectedOutOfBounds: true,
+		},
+		{
+			name:                "case 2: over-limit count",
+			networkInterfaces:   newNetworkInterfaces(10),
+			expectedOutOfBounds: true,
+		},
+		{
+			name:              "case 3: configured maximum",
+			networkInterfaces: newNetworkInterfaces(10),
+			maxEtcdENIs:       10,
+			expectedENIs:      11,
+		},
+		{
+			name:                "case 4: zero ENIs of legacy cluster",
+			isLegacyCluster:     true,
+			noNetworkInterfaces: true,
+			expectedENIs:        0,
+		},
+		{
+			name:                "case 5: zero ENIs with configured minimum of 0",
+			noNetworkInterfaces: true,
+			minEtcdENIs:         aws.Int(0),
+			expectedENIs:        0,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			sourceClient := newSourceWithStacks(nil)
+			sourceClient.noNetworkInterfaces = tc.noNetworkInterfaces
+			sourceClient.networkInterfaces = tc.networkInterfaces
+
+			c := newTestConfig(t)
+			c.SourceClient = sourceClient
+			c.MinEtcdENIs = tc.minEtcdENIs
+			c.MaxEtcdENIs = tc.maxEtcdENIs
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			data, err := m.getSourceStackData(Cluster{ID: "foo", IsLegacy: tc.isLegacyCluster})
+			if tc.expectedOutOfBounds {
+				if !IsEtcdENIsOutOfBounds(err) {
+					t.Fatalf("expected etcd ENIs out of bounds error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("getSourceStackData: %v", err)
+			}
+
+			if len(data.EtcdEniList) != tc.expectedENIs {
+				t.Errorf("expected %d etcd ENI records, got %d", tc.expectedENIs, len(data.EtcdEniList))
+			}
+		})
+	}
+}
+
+func TestNewManager_EtcdENIBounds(t *testing.T) {
+	c := newTestConfig(t)
+	c.MinEtcdENIs = aws.Int(5)
+	c.MaxEtcdENIs = 3
+	_, err := NewManager(c)
+	if !IsInvalidConfig(err) {
+		t.Fatalf("expected invalid config error, got %v", err)
+	}
+}
+
+func TestSync_EtcdENIsOutOfBounds(t *testing.T) {
+	tags := []*cloudformation.Tag{
+		{
+			Key:   aws.String(installationTag),
+			Value: aws.String("installation"),
+		},
+	}
+	sourceClient := newSourceWithStacks([]cloudformation.Stack{
+		{
+			StackName:   aws.String("cluster-foo-tccp"),
+			StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+			Tags:        tags,
+		},
+	})
+	sourceClient.noNetworkInterfaces = true
+	targetClient := newTargetWithStacks([]cloudformation.Stack{
+		{
+			StackName:   aws.String("cluster-foo-guest-recordsets"),
+			StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+			Tags:        tags,
+		},
+	})
+
+	c := newTestConfig(t)
+	c.SourceClient = sourceClient
+	c.TargetClient = targetClient
+	m, err := NewManager(c)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	err = m.Sync()
+	if err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+
+	if len(targetClient.updateStackInputs) != 0 {
+		t.Errorf("expected no update removing the etcd records, got %d", len(targetClient.updateStackInputs))
+	}
+	if m.summary.skippedCount(SkipReasonEtcdENIsOutOfBounds) != 1 {
+		t.Errorf("expected 1 stack skipped with reason %#q, got %d", SkipReasonEtcdENIsOutOfBounds, m.summary.skippedCount(SkipReasonEtcdENIsOutOfBounds))
+	}
+}
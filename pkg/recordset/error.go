@@ -1,8 +1,13 @@
 package recordset
 
 import (
+	"strings"
+
 	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/route53"
 	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/route53-manager/pkg/recordset/plan"
 )
 
 var invalidConfigError = &microerror.Error{
@@ -14,13 +19,49 @@ func IsInvalidConfig(err error) bool {
 	return microerror.Cause(err) == invalidConfigError
 }
 
-var invalidClusterNameError = &microerror.Error{
-	Kind: "invalidClusterNameError",
+// IsInvalidClusterNameError asserts the invalidClusterNameError of
+// plan.ClusterID.
+func IsInvalidClusterNameError(err error) bool {
+	return plan.IsInvalidClusterName(err)
+}
+
+var elbNotFoundError = &microerror.Error{
+	Kind: "elbNotFoundError",
 }
 
-// IsInvalidClusterNameError asserts invalidClusterNameError.
-func IsInvalidClusterNameError(err error) bool {
-	return microerror.Cause(err) == invalidClusterNameError
+// IsELBNotFound asserts elbNotFoundError, returned when neither the classic
+// nor the elbv2 API knows a load balancer.
+func IsELBNotFound(err error) bool {
+	return microerror.Cause(err) == elbNotFoundError
+}
+
+var unhealthyELBError = &microerror.Error{
+	Kind: "unhealthyELBError",
+}
+
+// IsUnhealthyELB asserts unhealthyELBError, returned when a load balancer has
+// no healthy instance or target.
+func IsUnhealthyELB(err error) bool {
+	return microerror.Cause(err) == unhealthyELBError
+}
+
+var etcdENIsOutOfBoundsError = &microerror.Error{
+	Kind: "etcdENIsOutOfBoundsError",
+}
+
+// IsEtcdENIsOutOfBounds asserts etcdENIsOutOfBoundsError, returned when the
+// number of etcd ENIs of a cluster is not within the configured bounds.
+func IsEtcdENIsOutOfBounds(err error) bool {
+	return microerror.Cause(err) == etcdENIsOutOfBoundsError
+}
+
+var invalidRecordError = &microerror.Error{
+	Kind: "invalidRecordError",
+}
+
+// IsInvalidRecord asserts invalidRecordError.
+func IsInvalidRecord(err error) bool {
+	return microerror.Cause(err) == invalidRecordError
 }
 
 var noUpdateNeededError = &microerror.Error{
@@ -47,3 +88,167 @@ var tooFewResultsError = &microerror.Error{
 func IsTooFewResults(err error) bool {
 	return microerror.Cause(err) == tooFewResultsError
 }
+
+var stackNotFoundError = &microerror.Error{
+	Kind: "stackNotFoundError",
+}
+
+// IsStackNotFound asserts stackNotFoundError and the AWS validation error
+// returned for stacks which do not exist.
+func IsStackNotFound(err error) bool {
+	if microerror.Cause(err) == stackNotFoundError {
+		return true
+	}
+
+	awsErr, ok := microerror.Cause(err).(awserr.Error)
+	return ok &&
+		awsErr.Code() == "ValidationError" &&
+		strings.Contains(awsErr.Message(), "does not exist")
+}
+
+var syncTimeoutError = &microerror.Error{
+	Kind: "syncTimeoutError",
+}
+
+// IsSyncTimeout asserts syncTimeoutError, returned when a sync run exceeds
+// its deadline.
+func IsSyncTimeout(err error) bool {
+	return microerror.Cause(err) == syncTimeoutError
+}
+
+var waitTimeoutError = &microerror.Error{
+	Kind: "waitTimeoutError",
+}
+
+// IsWaitTimeout asserts waitTimeoutError.
+func IsWaitTimeout(err error) bool {
+	return microerror.Cause(err) == waitTimeoutError
+}
+
+var changeSetFailedError = &microerror.Error{
+	Kind: "changeSetFailedError",
+}
+
+// IsChangeSetFailed asserts changeSetFailedError.
+func IsChangeSetFailed(err error) bool {
+	return microerror.Cause(err) == changeSetFailedError
+}
+
+var stackDeletionFailedError = &microerror.Error{
+	Kind: "stackDeletionFailedError",
+}
+
+// IsStackDeletionFailed asserts stackDeletionFailedError.
+func IsStackDeletionFailed(err error) bool {
+	return microerror.Cause(err) == stackDeletionFailedError
+}
+
+var apexAliasUnavailableError = &microerror.Error{
+	Kind: "apexAliasUnavailableError",
+}
+
+// IsApexAliasUnavailable asserts apexAliasUnavailableError.
+func IsApexAliasUnavailable(err error) bool {
+	return microerror.Cause(err) == apexAliasUnavailableError
+}
+
+var hostedZoneNotFoundError = &microerror.Error{
+	Kind: "hostedZoneNotFoundError",
+}
+
+// IsHostedZoneNotFound asserts hostedZoneNotFoundError, returned when no
+// hosted zone has the name the target hosted zone ID is resolved by.
+func IsHostedZoneNotFound(err error) bool {
+	return microerror.Cause(err) == hostedZoneNotFoundError
+}
+
+var ambiguousHostedZoneError = &microerror.Error{
+	Kind: "ambiguousHostedZoneError",
+}
+
+// IsAmbiguousHostedZone asserts ambiguousHostedZoneError, returned when
+// several hosted zones have the name the target hosted zone ID is resolved
+// by.
+func IsAmbiguousHostedZone(err error) bool {
+	return microerror.Cause(err) == ambiguousHostedZoneError
+}
+
+var templateTooLargeError = &microerror.Error{
+	Kind: "templateTooLargeError",
+}
+
+// IsTemplateTooLarge asserts templateTooLargeError, returned when the
+// rendered template of a target stack cannot be passed to CloudFormation due
+// to its size.
+func IsTemplateTooLarge(err error) bool {
+	return microerror.Cause(err) == templateTooLargeError
+}
+
+var lockHeldError = &microerror.Error{
+	Kind: "lockHeldError",
+}
+
+// IsLockHeld asserts lockHeldError, returned when the hosted zone lock is
+// held by another route53-manager instance.
+func IsLockHeld(err error) bool {
+	return microerror.Cause(err) == lockHeldError
+}
+
+// IsPriorRequestNotComplete asserts the Route53 error returned for changes of
+// a hosted zone while a prior change of it is still in flight.
+func IsPriorRequestNotComplete(err error) bool {
+	return isAWSErrorCode(err, route53.ErrCodePriorRequestNotComplete)
+}
+
+// IsRetryable asserts errors of AWS requests which may succeed when retried,
+// i.e. throttling and server errors. Errors of invalid credentials, missing
+// permissions or invalid configuration are not retryable.
+func IsRetryable(err error) bool {
+	awsErr, ok := microerror.Cause(err).(awserr.Error)
+	if !ok {
+		return false
+	}
+
+	if stringInSlice(awsErr.Code(), retryableErrorCodes) {
+		return true
+	}
+	reqErr, ok := awsErr.(awserr.RequestFailure)
+	if ok && reqErr.StatusCode() >= 500 {
+		return true
+	}
+
+	return false
+}
+
+// isAWSErrorCode checks if err is an AWS error with the given code.
+func isAWSErrorCode(err error, code string) bool {
+	awsErr, ok := microerror.Cause(err).(awserr.Error)
+	return ok && awsErr.Code() == code
+}
+
+var massDeletionError = &microerror.Error{
+	Kind: "massDeletionError",
+}
+
+// IsMassDeletion asserts massDeletionError.
+func IsMassDeletion(err error) bool {
+	return microerror.Cause(err) == massDeletionError
+}
+
+var clusterNameMismatchError = &microerror.Error{
+	Kind: "clusterNameMismatchError",
+}
+
+// IsClusterNameMismatch asserts clusterNameMismatchError.
+func IsClusterNameMismatch(err error) bool {
+	return microerror.Cause(err) == clusterNameMismatchError
+}
+
+var weightToggledError = &microerror.Error{
+	Kind: "weightToggledError",
+}
+
+// IsWeightToggled asserts weightToggledError.
+func IsWeightToggled(err error) bool {
+	return microerror.Cause(err) == weightToggledError
+}
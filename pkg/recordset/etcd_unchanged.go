@@ -0,0 +1,279 @@
+package recordset
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/giantswarm/microerror"
+	"gopkg.in/yaml.v3"
+
+	"github.com/giantswarm/route53-manager/pkg/client"
+	"github.com/giantswarm/route53-manager/pkg/recordset/plan"
+)
+
+// isUnchangedUpdate returns true when the update of the target stack would
+// not change it, so UpdateStack can be skipped. The etcd A records of the
+// current template are compared with the IPs of the etcd ENIs first, as they
+// are what changes between runs most of the time. The remaining resources,
+// with CNAME values compared by normalizeCNAMEValue, the template
+// version and the tags must be unchanged too. With m.ignoreVersionTag the
+// version tag is ignored, so a new route53-manager version alone does not
+// update the target stack. The change reason tag is always ignored, so a run
+// with another reason alone does not update it either. The update also changes
+// the target stack when it has notification ARNs or a stack policy other
+// than the configured ones, or when its template has sections not rendered
+// by route53-manager, e.g. Outputs, which the update would remove.
+func (m *Manager) isUnchangedUpdate(input *cloudformation.UpdateStackInput, targetStack cloudformation.Stack, records []DesiredRecord) (bool, error) {
+	desiredTags, currentTags := withoutStackTag(input.Tags, changeReasonTag), withoutStackTag(targetStack.Tags, changeReasonTag)
+	if m.ignoreVersionTag {
+		desiredTags, currentTags = withoutStackTag(desiredTags, versionTag), withoutStackTag(currentTags, versionTag)
+	}
+	if !equalStackTags(desiredTags, currentTags) {
+		return false, nil
+	}
+	if len(input.NotificationARNs) > 0 && !equalStringSets(aws.StringValueSlice(input.NotificationARNs), aws.StringValueSlice(targetStack.NotificationARNs)) {
+		m.logger.Log("level", "debug", "message", fmt.Sprintf("notification ARNs of target stack %#q changed", *input.StackName))
+		return false, nil
+	}
+
+	o, err := m.targetClient.GetTemplateWithContext(m.ctx, &cloudformation.GetTemplateInput{
+		StackName: input.StackName,
+	})
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	current, err := parseStackTemplate(aws.StringValue(o.TemplateBody))
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+	desired := m.newTargetStackTemplate(records)
+	normalizeTemplateCNAMEs(current)
+	normalizeTemplateCNAMEs(desired)
+
+	if !reflect.DeepEqual(m.etcdIPs(current), m.etcdIPs(desired)) {
+		m.logger.Log("level", "debug", "message", fmt.Sprintf("etcd ENI IPs of target stack %#q changed", *input.StackName))
+		return false, nil
+	}
+
+	if !reflect.DeepEqual(current.Metadata, desired.Metadata) {
+		m.logger.Log("level", "debug", "message", fmt.Sprintf("template version of target stack %#q changed", *input.StackName))
+		return false, nil
+	}
+
+	if !reflect.DeepEqual(current, desired) {
+		return false, nil
+	}
+
+	sections, err := templateSections(aws.StringValue(o.TemplateBody))
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+	for _, s := range sections {
+		if !renderedTemplateSections[s] {
+			m.logger.Log("level", "debug", "message", fmt.Sprintf("template of target stack %#q has section %#q", *input.StackName, s))
+			return false, nil
+		}
+	}
+
+	if input.StackPolicyBody != nil {
+		p, err := m.targetClient.GetStackPolicyWithContext(m.ctx, &cloudformation.GetStackPolicyInput{
+			StackName: input.StackName,
+		})
+		if err != nil {
+			return false, microerror.Mask(err)
+		}
+		if !equalJSON(aws.StringValue(p.StackPolicyBody), *input.StackPolicyBody) {
+			m.logger.Log("level", "debug", "message", fmt.Sprintf("stack policy of target stack %#q changed", *input.StackName))
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// renderedTemplateSections are the top level sections of the templates
+// rendered for target stacks.
+var renderedTemplateSections = map[string]bool{
+	"AWSTemplateFormatVersion": true,
+	"Description":              true,
+	"Metadata":                 true,
+	"Resources":                true,
+}
+
+// templateSections returns the top level sections of the JSON or YAML
+// template body.
+func templateSections(body string) ([]string, error) {
+	var t map[string]interface{}
+	var err error
+	if strings.HasPrefix(strings.TrimSpace(body), "{") {
+		err = json.Unmarshal([]byte(body), &t)
+	} else {
+		err = yaml.Unmarshal([]byte(body), &t)
+	}
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var sections []string
+	for s := range t {
+		sections = append(sections, s)
+	}
+	sort.Strings(sections)
+
+	return sections, nil
+}
+
+// equalJSON returns true when both documents are valid JSON with the same
+// content, regardless of formatting.
+func equalJSON(a, b string) bool {
+	var va, vb interface{}
+	if json.Unmarshal([]byte(a), &va) != nil || json.Unmarshal([]byte(b), &vb) != nil {
+		return false
+	}
+
+	return reflect.DeepEqual(va, vb)
+}
+
+// equalStringSets returns true when both slices have the same elements,
+// regardless of their order.
+func equalStringSets(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	count := map[string]int{}
+	for _, s := range a {
+		count[s]++
+	}
+	for _, s := range b {
+		count[s]--
+		if count[s] < 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// withoutStackTag returns the tags without the tag with the given key.
+func withoutStackTag(tags []*cloudformation.Tag, key string) []*cloudformation.Tag {
+	var result []*cloudformation.Tag
+	for _, t := range tags {
+		if aws.StringValue(t.Key) == key {
+			continue
+		}
+		result = append(result, t)
+	}
+
+	return result
+}
+
+// etcdIPs returns the IPs of the etcd A records of the template by resource
+// name.
+func (m *Manager) etcdIPs(t stackTemplate) map[string][]string {
+	ips := map[string][]string{}
+	for name, r := range t.Resources {
+		if r.Properties.HostedZoneID != m.etcdHostedZoneID || r.Properties.Type != route53.RRTypeA || r.Properties.AliasTarget != nil {
+			continue
+		}
+		ips[name] = r.Properties.ResourceRecords
+	}
+
+	return ips
+}
+
+// maxFilterValues is the maximum number of values of an EC2 filter.
+const maxFilterValues = 200
+
+// prefetchEtcdENIs describes the etcd ENIs of the clusters of the updates
+// with one DescribeNetworkInterfaces call per source account and up to
+// maxFilterValues clusters, instead of one call per cluster. With
+// SkipUnchangedUpdates most updates are skipped after comparing the etcd ENI
+// IPs, so describing them is most of the EC2 load of a sync run. Clusters
+// whose ENIs could not be prefetched are described by getEniList.
+func (m *Manager) prefetchEtcdENIs(updates []plan.ClusterRef) {
+	m.etcdENIs = map[string][]*ec2.NetworkInterface{}
+
+	var clients []client.SourceInterface
+	clusterIDs := map[client.SourceInterface][]string{}
+	for _, ref := range updates {
+		// The etcd ENIs of discovered clusters are filtered differently, see
+		// getEniList.
+		if ref.SourceStack != nil && isDiscoveredStack(*ref.SourceStack) {
+			continue
+		}
+		cl := m.sourceClientOf(Cluster{SourceAccount: m.sourceAccounts[ref.ID]})
+		if _, ok := clusterIDs[cl]; !ok {
+			clients = append(clients, cl)
+		}
+		clusterIDs[cl] = append(clusterIDs[cl], ref.ID)
+	}
+
+	for _, cl := range clients {
+		ids := clusterIDs[cl]
+		for len(ids) > 0 {
+			n := len(ids)
+			if n > maxFilterValues {
+				n = maxFilterValues
+			}
+			err := m.describeEtcdENIs(cl, ids[:n])
+			if err != nil {
+				m.logger.Log("level", "warning", "message", "failed to prefetch etcd ENIs", "stack", microerror.JSON(err))
+			}
+			ids = ids[n:]
+		}
+	}
+}
+
+// describeEtcdENIs adds the etcd ENIs of the clusters to m.etcdENIs.
+func (m *Manager) describeEtcdENIs(cl client.SourceInterface, clusterIDs []string) error {
+	var stackNames []string
+	for _, id := range clusterIDs {
+		stackNames = append(stackNames, fmt.Sprintf("cluster-%s-tccpn", id))
+	}
+
+	eniList := map[string][]*ec2.NetworkInterface{}
+	input := &ec2.DescribeNetworkInterfacesInput{
+		Filters: []*ec2.Filter{
+			{
+				Name:   aws.String(fmt.Sprintf("tag:%s", m.eniClusterTag)),
+				Values: aws.StringSlice(clusterIDs),
+			},
+			{
+				Name:   aws.String("tag:aws:cloudformation:stack-name"),
+				Values: aws.StringSlice(stackNames),
+			},
+		},
+	}
+	for {
+		output, err := cl.DescribeNetworkInterfacesWithContext(m.ctx, input)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		for _, nic := range output.NetworkInterfaces {
+			id := ec2TagValue(nic.TagSet, m.eniClusterTag)
+			if ec2TagValue(nic.TagSet, "aws:cloudformation:stack-name") != fmt.Sprintf("cluster-%s-tccpn", id) {
+				continue
+			}
+			eniList[id] = append(eniList[id], nic)
+		}
+		if output.NextToken == nil {
+			break
+		}
+		input.NextToken = output.NextToken
+	}
+
+	for _, id := range clusterIDs {
+		m.etcdENIs[id] = eniList[id]
+	}
+
+	return nil
+}
@@ -0,0 +1,365 @@
+package recordset
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+)
+
+func TestSync_SkipUnchangedUpdates(t *testing.T) {
+	tags := []*cloudformation.Tag{
+		&cloudformation.Tag{
+			Key:   aws.String(installationTag),
+			Value: aws.String("installation"),
+		},
+	}
+
+	tcs := []struct {
+		name                 string
+		skipUnchangedUpdates bool
+		currentTemplate      func(body string, t stackTemplate) string
+		notificationARNs     []string
+		currentStackPolicy   string
+		expectedUpdate       bool
+	}{
+		{
+			name:                 "case 0: etcd ENI IPs match",
+			skipUnchangedUpdates: true,
+			currentTemplate: func(body string, t stackTemplate) string {
+				return body
+			},
+			expectedUpdate: false,
+		},
+		{
+			name:                 "case 1: etcd ENI IPs match in a JSON template",
+			skipUnchangedUpdates: true,
+			currentTemplate: func(body string, t stackTemplate) string {
+				b, _ := json.MarshalIndent(t, "", "  ")
+				return string(b)
+			},
+			expectedUpdate: false,
+		},
+		{
+			name:                 "case 2: etcd ENI IPs differ",
+			skipUnchangedUpdates: true,
+			currentTemplate: func(body string, t stackTemplate) string {
+				return strings.Replace(body, "10.1.0.1", "10.1.0.2", -1)
+			},
+			expectedUpdate: true,
+		},
+		{
+			name:                 "case 3: other records differ",
+			skipUnchangedUpdates: true,
+			currentTemplate: func(body string, t stackTemplate) string {
+				return strings.Replace(body, "elb.dns.test", "old.elb.dns.test", -1)
+			},
+			expectedUpdate: true,
+		},
+		{
+			name:                 "case 4: disabled",
+			skipUnchangedUpdates: false,
+			currentTemplate: func(body string, t stackTemplate) string {
+				return body
+			},
+			expectedUpdate: true,
+		},
+		{
+			name:                 "case 5: current template has outputs",
+			skipUnchangedUpdates: true,
+			currentTemplate: func(body string, t stackTemplate) string {
+				return body + "Outputs:\n    Foo:\n        Value: bar\n"
+			},
+			expectedUpdate: true,
+		},
+		{
+			name:                 "case 6: notification ARNs differ",
+			skipUnchangedUpdates: true,
+			currentTemplate: func(body string, t stackTemplate) string {
+				return body
+			},
+			notificationARNs: []string{"arn:aws:sns:eu-central-1:123456789012:new"},
+			expectedUpdate:   true,
+		},
+		{
+			name:                 "case 7: notification ARNs match",
+			skipUnchangedUpdates: true,
+			currentTemplate: func(body string, t stackTemplate) string {
+				return body
+			},
+			notificationARNs: []string{"arn:aws:sns:eu-central-1:123456789012:current"},
+			expectedUpdate:   false,
+		},
+		{
+			name:                 "case 8: stack policy differs",
+			skipUnchangedUpdates: true,
+			currentTemplate: func(body string, t stackTemplate) string {
+				return body
+			},
+			currentStackPolicy: `{"Statement":[{"Effect":"Allow","Action":"Update:*","Principal":"*","Resource":"*"}]}`,
+			expectedUpdate:     true,
+		},
+		{
+			name:                 "case 9: stack policy matches",
+			skipUnchangedUpdates: true,
+			currentTemplate: func(body string, t stackTemplate) string {
+				return body
+			},
+			currentStackPolicy: `{ "Statement": [{"Effect": "Deny", "Action": "Update:Delete", "Principal": "*", "Resource": "*"}] }`,
+			expectedUpdate:     false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			sourceClient := newSourceWithStacks([]cloudformation.Stack{
+				{
+					StackName:   aws.String("cluster-foo-tccp"),
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+					Tags:        tags,
+				},
+			})
+			targetClient := newTargetWithStacks([]cloudformation.Stack{
+				{
+					StackName:        aws.String("cluster-foo-guest-recordsets"),
+					StackStatus:      aws.String(cloudformation.StackStatusUpdateComplete),
+					Tags:             tags,
+					NotificationARNs: aws.StringSlice([]string{"arn:aws:sns:eu-central-1:123456789012:current"}),
+				},
+			})
+			if tc.currentStackPolicy != "" {
+				targetClient.stackPolicies = map[string]string{
+					"cluster-foo-guest-recordsets": tc.currentStackPolicy,
+				}
+			}
+
+			c := newTestConfig(t)
+			c.SourceClient = sourceClient
+			c.TargetClient = targetClient
+			c.SkipUnchangedUpdates = tc.skipUnchangedUpdates
+			c.NotificationARNs = tc.notificationARNs
+			if tc.currentStackPolicy != "" {
+				c.StackPolicy = `{"Statement":[{"Effect":"Deny","Action":"Update:Delete","Principal":"*","Resource":"*"}]}`
+			}
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			records, err := m.getRecords(Cluster{ID: "foo"})
+			if err != nil {
+				t.Fatalf("getRecords: %v", err)
+			}
+			body, err := m.getStackTemplateBody(records)
+			if err != nil {
+				t.Fatalf("getStackTemplateBody: %v", err)
+			}
+			if !strings.Contains(body, "10.1.0.1") || !strings.Contains(body, "elb.dns.test") {
+				t.Fatalf("expected template with etcd ENI IP and ELB DNS name, got %s", body)
+			}
+			targetClient.templates = map[string]string{
+				"cluster-foo-guest-recordsets": tc.currentTemplate(body, m.newTargetStackTemplate(records)),
+			}
+
+			err = m.Sync()
+			if err != nil {
+				t.Fatalf("m.Sync: %v", err)
+			}
+
+			updated := len(targetClient.updateStackInputs) > 0
+			if updated != tc.expectedUpdate {
+				t.Errorf("expected update %t, got %d updates", tc.expectedUpdate, len(targetClient.updateStackInputs))
+			}
+			if !tc.expectedUpdate && m.summary.unchanged != 1 {
+				t.Errorf("expected 1 unchanged target stack, got %s", m.summary.String())
+			}
+		})
+	}
+}
+
+func TestSync_TemplateVersion(t *testing.T) {
+	tcs := []struct {
+		name             string
+		templateVersion  string
+		ignoreVersionTag bool
+		expectedUpdate   bool
+	}{
+		{
+			name:             "case 0: same template version ignoring version tag",
+			templateVersion:  "1",
+			ignoreVersionTag: true,
+			expectedUpdate:   false,
+		},
+		{
+			name:             "case 1: bumped template version ignoring version tag",
+			templateVersion:  "2",
+			ignoreVersionTag: true,
+			expectedUpdate:   true,
+		},
+		{
+			name:             "case 2: no template version ignoring version tag",
+			templateVersion:  "",
+			ignoreVersionTag: true,
+			expectedUpdate:   true,
+		},
+		{
+			name:            "case 3: same template version with new version tag",
+			templateVersion: "1",
+			expectedUpdate:  true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			tags := []*cloudformation.Tag{
+				&cloudformation.Tag{
+					Key:   aws.String(installationTag),
+					Value: aws.String("installation"),
+				},
+			}
+			sourceClient := newSourceWithStacks([]cloudformation.Stack{
+				{
+					StackName:   aws.String("cluster-foo-tccp"),
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+					Tags:        tags,
+				},
+			})
+			// The target stack was last updated by another route53-manager
+			// version with template version 1.
+			targetClient := newTargetWithStacks([]cloudformation.Stack{
+				{
+					StackName:   aws.String("cluster-foo-guest-recordsets"),
+					StackStatus: aws.String(cloudformation.StackStatusUpdateComplete),
+					Tags: append([]*cloudformation.Tag{
+						{
+							Key:   aws.String(versionTag),
+							Value: aws.String("old"),
+						},
+						{
+							Key:   aws.String(templateVersionTag),
+							Value: aws.String("1"),
+						},
+					}, tags...),
+				},
+			})
+
+			previous := newTestConfig(t)
+			previous.TemplateVersion = "1"
+			pm, err := NewManager(previous)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+			records, err := pm.getRecords(Cluster{ID: "foo"})
+			if err != nil {
+				t.Fatalf("getRecords: %v", err)
+			}
+			body, err := pm.getStackTemplateBody(records)
+			if err != nil {
+				t.Fatalf("getStackTemplateBody: %v", err)
+			}
+			targetClient.templates = map[string]string{
+				"cluster-foo-guest-recordsets": body,
+			}
+
+			c := newTestConfig(t)
+			c.SourceClient = sourceClient
+			c.TargetClient = targetClient
+			c.SkipUnchangedUpdates = true
+			c.TemplateVersion = tc.templateVersion
+			c.IgnoreVersionTag = tc.ignoreVersionTag
+			c.Version = "new"
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			err = m.Sync()
+			if err != nil {
+				t.Fatalf("m.Sync: %v", err)
+			}
+
+			updated := len(targetClient.updateStackInputs) > 0
+			if updated != tc.expectedUpdate {
+				t.Errorf("expected update %t, got %d updates", tc.expectedUpdate, len(targetClient.updateStackInputs))
+			}
+		})
+	}
+}
+
+func TestSync_SkipUnchangedUpdates_PrefetchENIs(t *testing.T) {
+	tags := []*cloudformation.Tag{
+		&cloudformation.Tag{
+			Key:   aws.String(installationTag),
+			Value: aws.String("installation"),
+		},
+	}
+
+	sourceClient := newSourceWithStacks([]cloudformation.Stack{
+		{
+			StackName:   aws.String("cluster-foo-tccp"),
+			StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+			Tags:        tags,
+		},
+		{
+			StackName:   aws.String("cluster-bar-tccp"),
+			StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+			Tags:        tags,
+		},
+	})
+	targetClient := newTargetWithStacks([]cloudformation.Stack{
+		{
+			StackName:   aws.String("cluster-foo-guest-recordsets"),
+			StackStatus: aws.String(cloudformation.StackStatusUpdateComplete),
+			Tags:        tags,
+		},
+		{
+			StackName:   aws.String("cluster-bar-guest-recordsets"),
+			StackStatus: aws.String(cloudformation.StackStatusUpdateComplete),
+			Tags:        tags,
+		},
+	})
+
+	c := newTestConfig(t)
+	c.SourceClient = sourceClient
+	c.TargetClient = targetClient
+	c.SkipUnchangedUpdates = true
+	m, err := NewManager(c)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	targetClient.templates = map[string]string{}
+	for _, id := range []string{"foo", "bar"} {
+		records, err := m.getRecords(Cluster{ID: id})
+		if err != nil {
+			t.Fatalf("getRecords: %v", err)
+		}
+		body, err := m.getStackTemplateBody(records)
+		if err != nil {
+			t.Fatalf("getStackTemplateBody: %v", err)
+		}
+		targetClient.templates["cluster-"+id+"-guest-recordsets"] = body
+	}
+	sourceClient.networkInterfacesInputs = nil
+
+	err = m.Sync()
+	if err != nil {
+		t.Fatalf("m.Sync: %v", err)
+	}
+
+	if len(targetClient.updateStackInputs) != 0 {
+		t.Errorf("expected no updates, got %d", len(targetClient.updateStackInputs))
+	}
+	if m.summary.unchanged != 2 {
+		t.Errorf("expected 2 unchanged target stacks, got %s", m.summary.String())
+	}
+	if len(sourceClient.networkInterfacesInputs) != 1 {
+		t.Fatalf("expected 1 DescribeNetworkInterfaces call, got %d", len(sourceClient.networkInterfacesInputs))
+	}
+	values := aws.StringValueSlice(sourceClient.networkInterfacesInputs[0].Filters[0].Values)
+	if len(values) != 2 {
+		t.Errorf("expected ENIs of 2 clusters described, got %v", values)
+	}
+}
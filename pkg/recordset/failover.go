@@ -0,0 +1,72 @@
+package recordset
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+const (
+	// FailoverPrimary marks the target hosted zone records of this
+	// installation as primary failover records.
+	FailoverPrimary = "PRIMARY"
+	// FailoverSecondary marks the target hosted zone records of this
+	// installation as secondary failover records, answered while the health
+	// check of the primary records fails.
+	FailoverSecondary = "SECONDARY"
+
+	// maxSetIdentifierLength is the maximum length of set identifiers
+	// accepted by Route53.
+	maxSetIdentifierLength = 128
+)
+
+// failoverRouting is the failover routing policy of the records in the target
+// hosted zone. The zero value disables failover routing.
+type failoverRouting struct {
+	// Role is FailoverPrimary or FailoverSecondary.
+	Role string
+	// SetIdentifier tells the record sets of the installations apart which
+	// share the same name and type. It is the installation name prefixed
+	// with Config.SetIdentifierPrefix.
+	SetIdentifier string
+	// HealthCheckID is the Route53 health check deciding whether the record
+	// sets are answered. It is optional for secondary records.
+	HealthCheckID string
+}
+
+func (f failoverRouting) enabled() bool {
+	return f.Role != ""
+}
+
+// applyFailover sets the failover routing policy on every record set of the
+// template in the given hosted zone. Records in other hosted zones, e.g. the
+// etcd hosted zone, and weighted records are left as they are.
+func applyFailover(t stackTemplate, hostedZoneID string, f failoverRouting) {
+	if !f.enabled() {
+		return
+	}
+
+	for name, r := range t.Resources {
+		if r.Properties.HostedZoneID != hostedZoneID || r.Properties.Weight != nil {
+			continue
+		}
+
+		r.Properties.Failover = f.Role
+		r.Properties.SetIdentifier = f.SetIdentifier
+		r.Properties.HealthCheckID = f.HealthCheckID
+		t.Resources[name] = r
+	}
+}
+
+// applyFailoverRecordSet sets the failover routing policy on a record set
+// changed directly through Route53, unless it is weighted.
+func applyFailoverRecordSet(recordSet *route53.ResourceRecordSet, f failoverRouting) {
+	if !f.enabled() || recordSet.Weight != nil {
+		return
+	}
+
+	recordSet.Failover = aws.String(f.Role)
+	recordSet.SetIdentifier = aws.String(f.SetIdentifier)
+	if f.HealthCheckID != "" {
+		recordSet.HealthCheckId = aws.String(f.HealthCheckID)
+	}
+}
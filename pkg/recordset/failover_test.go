@@ -0,0 +1,199 @@
+package recordset
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func TestGetStackTemplateBody_Failover(t *testing.T) {
+	tcs := []struct {
+		name                  string
+		failover              string
+		healthCheckID         string
+		setIdentifierPrefix   string
+		expectedFailover      string
+		expectedSetIdentifier string
+		expectedHealthCheckID string
+	}{
+		{
+			name: "case 0: no failover routing by default",
+		},
+		{
+			name:                  "case 1: primary records",
+			failover:              FailoverPrimary,
+			healthCheckID:         "healthCheckID",
+			expectedFailover:      "PRIMARY",
+			expectedSetIdentifier: "installation",
+			expectedHealthCheckID: "healthCheckID",
+		},
+		{
+			name:                  "case 2: secondary records without health check",
+			failover:              FailoverSecondary,
+			expectedFailover:      "SECONDARY",
+			expectedSetIdentifier: "installation",
+		},
+		{
+			name:                  "case 3: set identifier prefix",
+			failover:              FailoverSecondary,
+			setIdentifierPrefix:   "r53mgr-",
+			expectedFailover:      "SECONDARY",
+			expectedSetIdentifier: "r53mgr-installation",
+		},
+		{
+			name:                "case 4: set identifier prefix without failover routing",
+			setIdentifierPrefix: "r53mgr-",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newTestConfig(t)
+			c.TemplateFormat = TemplateFormatJSON
+			c.EtcdHostedZoneID = "etcdZoneID"
+			c.EtcdHostedZoneName = "etcdZoneName"
+			c.TargetHostedZoneFailover = tc.failover
+			c.TargetHostedZoneHealthCheckID = tc.healthCheckID
+			c.SetIdentifierPrefix = tc.setIdentifierPrefix
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			records, err := m.getRecords(Cluster{ID: "foo"})
+			if err != nil {
+				t.Fatalf("getRecords: %v", err)
+			}
+
+			body, err := m.getStackTemplateBody(records)
+			if err != nil {
+				t.Fatalf("getStackTemplateBody: %v", err)
+			}
+
+			var template stackTemplate
+			err = json.Unmarshal([]byte(body), &template)
+			if err != nil {
+				t.Fatalf("json.Unmarshal: %v", err)
+			}
+
+			for name, r := range template.Resources {
+				p := r.Properties
+				if p.HostedZoneID != "zoneID" {
+					if p.Failover != "" || p.SetIdentifier != "" || p.HealthCheckID != "" {
+						t.Errorf("expected resource %#q in hosted zone %#q without failover routing, got %#v", name, p.HostedZoneID, p)
+					}
+					continue
+				}
+				if p.Failover != tc.expectedFailover {
+					t.Errorf("expected resource %#q failover %#q, got %#q", name, tc.expectedFailover, p.Failover)
+				}
+				if p.SetIdentifier != tc.expectedSetIdentifier {
+					t.Errorf("expected resource %#q set identifier %#q, got %#q", name, tc.expectedSetIdentifier, p.SetIdentifier)
+				}
+				if p.HealthCheckID != tc.expectedHealthCheckID {
+					t.Errorf("expected resource %#q health check %#q, got %#q", name, tc.expectedHealthCheckID, p.HealthCheckID)
+				}
+			}
+
+			if tc.failover == "" && strings.Contains(body, "Failover") {
+				t.Errorf("expected template without failover attributes, got %s", body)
+			}
+		})
+	}
+}
+
+func TestGetAtomicChanges_Failover(t *testing.T) {
+	c := newTestConfig(t)
+	c.TargetHostedZoneFailover = FailoverPrimary
+	c.TargetHostedZoneHealthCheckID = "healthCheckID"
+	m, err := NewManager(c)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	records, err := m.getRecords(Cluster{ID: "foo"})
+	if err != nil {
+		t.Fatalf("getRecords: %v", err)
+	}
+
+	changes, err := m.getAtomicChanges(m.targetHostedZoneID, records, nil)
+	if err != nil {
+		t.Fatalf("getAtomicChanges: %v", err)
+	}
+	if len(changes) == 0 {
+		t.Fatalf("expected changes, got none")
+	}
+
+	for _, ch := range changes {
+		rr := ch.ResourceRecordSet
+		if aws.StringValue(rr.Failover) != FailoverPrimary {
+			t.Errorf("expected record set %#q failover %#q, got %#q", *rr.Name, FailoverPrimary, aws.StringValue(rr.Failover))
+		}
+		if aws.StringValue(rr.SetIdentifier) != "installation" {
+			t.Errorf("expected record set %#q set identifier %#q, got %#q", *rr.Name, "installation", aws.StringValue(rr.SetIdentifier))
+		}
+		if aws.StringValue(rr.HealthCheckId) != "healthCheckID" {
+			t.Errorf("expected record set %#q health check %#q, got %#q", *rr.Name, "healthCheckID", aws.StringValue(rr.HealthCheckId))
+		}
+	}
+}
+
+func TestNewManager_Failover(t *testing.T) {
+	tcs := []struct {
+		name                string
+		failover            string
+		healthCheckID       string
+		setIdentifierPrefix string
+		errorMatcher        func(error) bool
+	}{
+		{
+			name:          "case 0: primary with health check",
+			failover:      FailoverPrimary,
+			healthCheckID: "healthCheckID",
+		},
+		{
+			name:         "case 1: primary without health check",
+			failover:     FailoverPrimary,
+			errorMatcher: IsInvalidConfig,
+		},
+		{
+			name:          "case 2: unknown failover role",
+			failover:      "TERTIARY",
+			healthCheckID: "healthCheckID",
+			errorMatcher:  IsInvalidConfig,
+		},
+		{
+			name:          "case 3: health check without failover",
+			healthCheckID: "healthCheckID",
+			errorMatcher:  IsInvalidConfig,
+		},
+		{
+			name:                "case 4: too long set identifier",
+			failover:            FailoverSecondary,
+			setIdentifierPrefix: strings.Repeat("a", 128),
+			errorMatcher:        IsInvalidConfig,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newTestConfig(t)
+			c.TargetHostedZoneFailover = tc.failover
+			c.TargetHostedZoneHealthCheckID = tc.healthCheckID
+			c.SetIdentifierPrefix = tc.setIdentifierPrefix
+
+			_, err := NewManager(c)
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("expected no error, got %v", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("expected error, got none")
+			case !tc.errorMatcher(err):
+				t.Fatalf("unexpected error %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package recordset
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+
+	"github.com/giantswarm/route53-manager/pkg/client"
+	"github.com/giantswarm/route53-manager/pkg/recordset/plan"
+)
+
+var (
+	// stackStatusFailedCreate are the statuses of target stacks whose
+	// creation failed. They can neither be updated nor created again until
+	// they are deleted.
+	stackStatusFailedCreate = []string{
+		cloudformation.StackStatusCreateFailed,
+		cloudformation.StackStatusRollbackComplete,
+	}
+	// resourceStatusGone are the statuses of stack resources which do not
+	// exist, either because their creation failed or because they were
+	// deleted during the rollback.
+	resourceStatusGone = []string{
+		cloudformation.ResourceStatusCreateFailed,
+		cloudformation.ResourceStatusDeleteComplete,
+	}
+)
+
+type GarbageCollectorConfig struct {
+	Logger       micrologger.Logger
+	Installation string
+	TargetClient client.TargetInterface
+
+	// Cluster restricts the garbage collection to the target stack of the
+	// cluster with the given ID, e.g. `foo`. All target stacks are checked
+	// when empty.
+	Cluster string
+	// DryRun only returns the target stacks which would be deleted.
+	DryRun bool
+
+	// TargetHostedZoneID, TargetHostedZoneName, EtcdHostedZoneID and
+	// EtcdHostedZoneName are the hosted zones locked while target stacks are
+	// deleted, see Config. They are only required with LockHostedZone.
+	TargetHostedZoneID   string
+	TargetHostedZoneName string
+	EtcdHostedZoneID     string
+	EtcdHostedZoneName   string
+	// LockHostedZone, LockOwner and LockLease hold the same advisory lock of
+	// the hosted zones as Sync while target stacks are deleted, so garbage
+	// collection does not race sync runs. See Config.
+	LockHostedZone bool
+	LockOwner      string
+	LockLease      time.Duration
+}
+
+// GarbageCollector deletes target stacks whose creation failed without
+// leaving any resource behind, so the next sync run can create them again.
+type GarbageCollector struct {
+	logger       micrologger.Logger
+	targetClient client.TargetInterface
+	dryRun       bool
+
+	// manager lists, locks and deletes the target stacks the same way Sync
+	// does.
+	manager *Manager
+}
+
+func NewGarbageCollector(c GarbageCollectorConfig) (*GarbageCollector, error) {
+	if c.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", c)
+	}
+	if c.Installation == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Installation must not be empty", c)
+	}
+	if c.TargetClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.TargetClient must not be empty", c)
+	}
+	if c.LockHostedZone && c.TargetHostedZoneName == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.TargetHostedZoneName must not be empty when %T.LockHostedZone is set", c, c)
+	}
+
+	manager, err := NewManager(&Config{
+		Logger:       c.Logger,
+		Installation: c.Installation,
+		TargetClient: c.TargetClient,
+		TargetOnly:   true,
+
+		Cluster: c.Cluster,
+		Quiet:   true,
+
+		TargetHostedZoneID:   c.TargetHostedZoneID,
+		TargetHostedZoneName: c.TargetHostedZoneName,
+		EtcdHostedZoneID:     c.EtcdHostedZoneID,
+		EtcdHostedZoneName:   c.EtcdHostedZoneName,
+
+		LockHostedZone: c.LockHostedZone,
+		LockOwner:      c.LockOwner,
+		LockLease:      c.LockLease,
+	})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	g := &GarbageCollector{
+		logger:       c.Logger,
+		targetClient: c.TargetClient,
+		dryRun:       c.DryRun,
+
+		manager: manager,
+	}
+
+	return g, nil
+}
+
+// Collect returns the names of the target stacks in CREATE_FAILED or
+// ROLLBACK_COMPLETE without any created resource and deletes them, unless
+// in dry run mode. Target stacks whose deletion fails are not returned. With
+// LockHostedZone nothing is collected while another instance holds the lock.
+func (g *GarbageCollector) Collect() ([]string, error) {
+	if !g.dryRun {
+		locked, err := g.manager.lockOrBackOff()
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		if !locked {
+			return nil, nil
+		}
+		defer g.manager.releaseLock()
+	}
+
+	targetStacks, err := g.manager.targetStacks()
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var collected []string
+	for _, stack := range targetStacks {
+		if !plan.HasStatus(stack, stackStatusFailedCreate) {
+			continue
+		}
+
+		hasResources, err := g.hasResources(*stack.StackName)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		if hasResources {
+			g.logger.Log("level", "debug", "message", fmt.Sprintf("kept target stack %#q with status %#q, it has created resources", *stack.StackName, aws.StringValue(stack.StackStatus)))
+			continue
+		}
+
+		if g.dryRun {
+			g.logger.Log("level", "debug", "message", fmt.Sprintf("would delete target stack %#q with status %#q", *stack.StackName, aws.StringValue(stack.StackStatus)))
+			collected = append(collected, *stack.StackName)
+			continue
+		}
+
+		err = g.manager.renewLock()
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		err = g.manager.deleteTargetStack(*stack.StackName)
+		if err != nil {
+			g.logger.Log("level", "error", "message", fmt.Sprintf("failed to delete target stack %#q", *stack.StackName), "stack", microerror.JSON(err))
+			continue
+		}
+
+		g.logger.Log("level", "debug", "message", fmt.Sprintf("deleted target stack %#q with status %#q", *stack.StackName, aws.StringValue(stack.StackStatus)))
+		collected = append(collected, *stack.StackName)
+	}
+
+	return collected, nil
+}
+
+// hasResources returns true when any resource of the stack exists.
+func (g *GarbageCollector) hasResources(stackName string) (bool, error) {
+	input := &cloudformation.ListStackResourcesInput{
+		StackName: aws.String(stackName),
+	}
+	for {
+		output, err := g.targetClient.ListStackResourcesWithContext(g.manager.ctx, input)
+		if err != nil {
+			return false, microerror.Mask(err)
+		}
+
+		for _, r := range output.StackResourceSummaries {
+			if !stringInSlice(aws.StringValue(r.ResourceStatus), resourceStatusGone) {
+				return true, nil
+			}
+		}
+
+		if output.NextToken == nil || *output.NextToken == "" {
+			break
+		}
+		input.NextToken = output.NextToken
+	}
+
+	return false, nil
+}
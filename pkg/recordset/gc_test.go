@@ -0,0 +1,246 @@
+package recordset
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+func TestGarbageCollector_Collect(t *testing.T) {
+	tags := []*cloudformation.Tag{
+		&cloudformation.Tag{
+			Key:   aws.String(installationTag),
+			Value: aws.String("installation"),
+		},
+	}
+	targetStacks := []cloudformation.Stack{
+		cloudformation.Stack{
+			StackName:   aws.String("cluster-foo-guest-recordsets"),
+			StackStatus: aws.String(cloudformation.StackStatusRollbackComplete),
+			Tags:        tags,
+		},
+		cloudformation.Stack{
+			StackName:   aws.String("cluster-bar-guest-recordsets"),
+			StackStatus: aws.String(cloudformation.StackStatusCreateFailed),
+			Tags:        tags,
+		},
+		cloudformation.Stack{
+			StackName:   aws.String("cluster-baz-guest-recordsets"),
+			StackStatus: aws.String(cloudformation.StackStatusRollbackComplete),
+			Tags:        tags,
+		},
+		cloudformation.Stack{
+			StackName:   aws.String("cluster-qux-guest-recordsets"),
+			StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+			Tags:        tags,
+		},
+	}
+	stackResources := map[string][]*cloudformation.StackResourceSummary{
+		"cluster-foo-guest-recordsets": {
+			{
+				LogicalResourceId: aws.String("apiDNSRecord"),
+				ResourceStatus:    aws.String(cloudformation.ResourceStatusDeleteComplete),
+			},
+			{
+				LogicalResourceId: aws.String("etcdDNSRecord"),
+				ResourceStatus:    aws.String(cloudformation.ResourceStatusCreateFailed),
+			},
+		},
+		"cluster-baz-guest-recordsets": {
+			{
+				LogicalResourceId: aws.String("apiDNSRecord"),
+				ResourceStatus:    aws.String(cloudformation.ResourceStatusDeleteFailed),
+			},
+		},
+		"cluster-qux-guest-recordsets": {
+			{
+				LogicalResourceId: aws.String("apiDNSRecord"),
+				ResourceStatus:    aws.String(cloudformation.ResourceStatusCreateComplete),
+			},
+		},
+	}
+
+	tcs := []struct {
+		name              string
+		dryRun            bool
+		cluster           string
+		expectedCollected []string
+		expectedDeleted   []string
+	}{
+		{
+			name:   "case 0: dry run",
+			dryRun: true,
+			expectedCollected: []string{
+				"cluster-foo-guest-recordsets",
+				"cluster-bar-guest-recordsets",
+			},
+		},
+		{
+			name: "case 1: delete",
+			expectedCollected: []string{
+				"cluster-foo-guest-recordsets",
+				"cluster-bar-guest-recordsets",
+			},
+			expectedDeleted: []string{
+				"cluster-foo-guest-recordsets",
+				"cluster-bar-guest-recordsets",
+			},
+		},
+		{
+			name:    "case 2: delete scoped to cluster",
+			cluster: "bar",
+			expectedCollected: []string{
+				"cluster-bar-guest-recordsets",
+			},
+			expectedDeleted: []string{
+				"cluster-bar-guest-recordsets",
+			},
+		},
+		{
+			name:    "case 3: delete scoped to healthy cluster",
+			cluster: "qux",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			targetClient := newTargetWithStacks(targetStacks)
+			targetClient.stackResources = stackResources
+
+			c := newTestConfig(t)
+			g, err := NewGarbageCollector(GarbageCollectorConfig{
+				Logger:       c.Logger,
+				Installation: c.Installation,
+				TargetClient: targetClient,
+
+				Cluster: tc.cluster,
+				DryRun:  tc.dryRun,
+			})
+			if err != nil {
+				t.Fatalf("NewGarbageCollector: %v", err)
+			}
+
+			collected, err := g.Collect()
+			if err != nil {
+				t.Fatalf("Collect: %v", err)
+			}
+
+			if !reflect.DeepEqual(tc.expectedCollected, collected) {
+				t.Errorf("expected collected stacks %v, got %v", tc.expectedCollected, collected)
+			}
+			if !reflect.DeepEqual(tc.expectedDeleted, targetClient.deletedStacks) {
+				t.Errorf("expected deleted stacks %v, got %v", tc.expectedDeleted, targetClient.deletedStacks)
+			}
+		})
+	}
+}
+
+func TestGarbageCollector_Collect_LockHostedZone(t *testing.T) {
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tcs := []struct {
+		name            string
+		recordSets      []*route53.ResourceRecordSet
+		expectedDeleted []string
+		expectedChanges []string
+	}{
+		{
+			name: "case 0: missing lock is acquired and released",
+			expectedDeleted: []string{
+				"cluster-foo-guest-recordsets",
+			},
+			expectedChanges: []string{
+				`CREATE "owner=installation/me expires=2020-01-01T12:15:00Z"`,
+				`DELETE "owner=installation/me expires=2020-01-01T12:15:00Z"`,
+			},
+		},
+		{
+			name: "case 1: lock held by another owner backs off",
+			recordSets: []*route53.ResourceRecordSet{
+				{
+					Name: aws.String("_r53mgr-lock.zoneName."),
+					Type: aws.String(route53.RRTypeTxt),
+					TTL:  aws.Int64(60),
+					ResourceRecords: []*route53.ResourceRecord{
+						{
+							Value: aws.String(`"owner=installation/other expires=2020-01-01T12:05:00Z"`),
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			targetClient := newTargetWithStacks([]cloudformation.Stack{
+				{
+					StackName:   aws.String("cluster-foo-guest-recordsets"),
+					StackStatus: aws.String(cloudformation.StackStatusRollbackComplete),
+					Tags: []*cloudformation.Tag{
+						{
+							Key:   aws.String(installationTag),
+							Value: aws.String("installation"),
+						},
+					},
+				},
+			})
+			targetClient.recordSets = map[string][]*route53.ResourceRecordSet{
+				"zoneID": tc.recordSets,
+			}
+
+			c := newTestConfig(t)
+			g, err := NewGarbageCollector(GarbageCollectorConfig{
+				Logger:       c.Logger,
+				Installation: c.Installation,
+				TargetClient: targetClient,
+
+				TargetHostedZoneID:   c.TargetHostedZoneID,
+				TargetHostedZoneName: c.TargetHostedZoneName,
+
+				LockHostedZone: true,
+				LockOwner:      "installation/me",
+			})
+			if err != nil {
+				t.Fatalf("NewGarbageCollector: %v", err)
+			}
+			g.manager.now = func() time.Time { return now }
+
+			_, err = g.Collect()
+			if err != nil {
+				t.Fatalf("Collect: %v", err)
+			}
+
+			if !reflect.DeepEqual(tc.expectedDeleted, targetClient.deletedStacks) {
+				t.Errorf("expected deleted stacks %v, got %v", tc.expectedDeleted, targetClient.deletedStacks)
+			}
+
+			var changes []string
+			for _, change := range targetClient.changes["zoneID"] {
+				rr := change.ResourceRecordSet
+				changes = append(changes, aws.StringValue(change.Action)+" "+aws.StringValue(rr.ResourceRecords[0].Value))
+			}
+			if !reflect.DeepEqual(tc.expectedChanges, changes) {
+				t.Errorf("expected lock changes %v, got %v", tc.expectedChanges, changes)
+			}
+		})
+	}
+}
+
+func TestNewGarbageCollector_LockWithoutHostedZone(t *testing.T) {
+	c := newTestConfig(t)
+	_, err := NewGarbageCollector(GarbageCollectorConfig{
+		Logger:       c.Logger,
+		Installation: c.Installation,
+		TargetClient: c.TargetClient,
+
+		LockHostedZone: true,
+	})
+	if !IsInvalidConfig(err) {
+		t.Errorf("expected invalid config error, got %v", err)
+	}
+}
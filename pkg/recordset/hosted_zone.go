@@ -0,0 +1,63 @@
+package recordset
+
+import (
+	"context"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/route53-manager/pkg/client"
+)
+
+// hostedZoneIDPrefix is the prefix of the hosted zone IDs returned by the
+// Route53 API, e.g. `/hostedzone/Z123`.
+const hostedZoneIDPrefix = "/hostedzone/"
+
+// resolveHostedZoneID returns the ID of the only hosted zone with the given
+// name, e.g. `Z123` for `example.com`, looked up with ListHostedZonesByName.
+// It fails with hostedZoneNotFoundError when no hosted zone has the name and
+// with ambiguousHostedZoneError when several do, e.g. a public and a private
+// one.
+func resolveHostedZoneID(ctx context.Context, cl client.TargetInterface, name string) (string, error) {
+	fqdn := strings.TrimSuffix(name, ".") + "."
+
+	input := &route53.ListHostedZonesByNameInput{
+		DNSName: aws.String(fqdn),
+	}
+
+	var ids []string
+	for {
+		output, err := cl.ListHostedZonesByNameWithContext(ctx, input)
+		if err != nil {
+			return "", microerror.Mask(err)
+		}
+
+		// The hosted zones are sorted by name starting at DNSName, so the
+		// matching ones come first.
+		done := false
+		for _, z := range output.HostedZones {
+			if !equalDNSNames(aws.StringValue(z.Name), fqdn) {
+				done = true
+				break
+			}
+			ids = append(ids, strings.TrimPrefix(aws.StringValue(z.Id), hostedZoneIDPrefix))
+		}
+
+		if done || !aws.BoolValue(output.IsTruncated) {
+			break
+		}
+		input.DNSName = output.NextDNSName
+		input.HostedZoneId = output.NextHostedZoneId
+	}
+
+	if len(ids) == 0 {
+		return "", microerror.Maskf(hostedZoneNotFoundError, "no hosted zone named %#q", name)
+	}
+	if len(ids) > 1 {
+		return "", microerror.Maskf(ambiguousHostedZoneError, "hosted zones %v are named %#q", ids, name)
+	}
+
+	return ids[0], nil
+}
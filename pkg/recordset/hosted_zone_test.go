@@ -0,0 +1,110 @@
+package recordset
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+func TestNewManager_ResolveTargetHostedZoneID(t *testing.T) {
+	tcs := []struct {
+		name                 string
+		hostedZones          []*route53.HostedZone
+		hostedZonesPageSize  int
+		targetHostedZoneName string
+		expectedID           string
+		errorMatcher         func(error) bool
+	}{
+		{
+			name: "case 0: single match",
+			hostedZones: []*route53.HostedZone{
+				{Id: aws.String("/hostedzone/Z1"), Name: aws.String("example.com.")},
+				{Id: aws.String("/hostedzone/Z2"), Name: aws.String("other.com.")},
+			},
+			targetHostedZoneName: "example.com",
+			expectedID:           "Z1",
+		},
+		{
+			name: "case 1: single match with trailing dot",
+			hostedZones: []*route53.HostedZone{
+				{Id: aws.String("/hostedzone/Z1"), Name: aws.String("example.com.")},
+			},
+			targetHostedZoneName: "example.com.",
+			expectedID:           "Z1",
+		},
+		{
+			name: "case 2: no match",
+			hostedZones: []*route53.HostedZone{
+				{Id: aws.String("/hostedzone/Z2"), Name: aws.String("other.com.")},
+				{Id: aws.String("/hostedzone/Z3"), Name: aws.String("sub.example.com.")},
+			},
+			targetHostedZoneName: "example.com",
+			errorMatcher:         IsHostedZoneNotFound,
+		},
+		{
+			name: "case 3: ambiguous name",
+			hostedZones: []*route53.HostedZone{
+				{Id: aws.String("/hostedzone/Z1"), Name: aws.String("example.com.")},
+				{Id: aws.String("/hostedzone/Z4"), Name: aws.String("example.com.")},
+			},
+			targetHostedZoneName: "example.com",
+			errorMatcher:         IsAmbiguousHostedZone,
+		},
+		{
+			name: "case 4: ambiguous name across pages",
+			hostedZones: []*route53.HostedZone{
+				{Id: aws.String("/hostedzone/Z1"), Name: aws.String("example.com.")},
+				{Id: aws.String("/hostedzone/Z4"), Name: aws.String("example.com.")},
+			},
+			hostedZonesPageSize:  1,
+			targetHostedZoneName: "example.com",
+			errorMatcher:         IsAmbiguousHostedZone,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			targetClient := newTargetWithStacks(nil)
+			targetClient.hostedZones = tc.hostedZones
+			targetClient.hostedZonesPageSize = tc.hostedZonesPageSize
+
+			c := newTestConfig(t)
+			c.TargetClient = targetClient
+			c.TargetHostedZoneID = ""
+			c.TargetHostedZoneName = tc.targetHostedZoneName
+			m, err := NewManager(c)
+			if tc.errorMatcher != nil {
+				if !tc.errorMatcher(err) {
+					t.Fatalf("expected matching error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			if m.targetHostedZoneID != tc.expectedID {
+				t.Errorf("expected target hosted zone ID %#q, got %#q", tc.expectedID, m.targetHostedZoneID)
+			}
+			if m.etcdHostedZoneID != tc.expectedID {
+				t.Errorf("expected etcd hosted zone ID %#q, got %#q", tc.expectedID, m.etcdHostedZoneID)
+			}
+		})
+	}
+}
+
+func TestNewManager_TargetHostedZoneIDNotResolved(t *testing.T) {
+	targetClient := newTargetWithStacks(nil)
+
+	c := newTestConfig(t)
+	c.TargetClient = targetClient
+	_, err := NewManager(c)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	if len(targetClient.calls) != 0 {
+		t.Errorf("expected no calls with given hosted zone ID, got %v", targetClient.calls)
+	}
+}
@@ -0,0 +1,142 @@
+package recordset
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/giantswarm/microerror"
+)
+
+// leftoverCheckpoint is the progress of the leftover cleanup of a cluster in
+// a hosted zone, persisted in the leftover checkpoint file after every page
+// of record sets, so an interrupted cleanup resumes at the next page instead
+// of listing the hosted zone from the start.
+type leftoverCheckpoint struct {
+	HostedZoneID          string `json:"hosted_zone_id"`
+	Cluster               string `json:"cluster"`
+	StartRecordName       string `json:"start_record_name"`
+	StartRecordType       string `json:"start_record_type"`
+	StartRecordIdentifier string `json:"start_record_identifier,omitempty"`
+}
+
+// leftoverCheckpointKey identifies the cleanup of a cluster in a hosted zone
+// within the leftover checkpoint file.
+func leftoverCheckpointKey(hostedZoneID, clusterID string) string {
+	return hostedZoneID + "/" + clusterID
+}
+
+// readLeftoverCheckpoints returns the checkpoints of all interrupted leftover
+// cleanups by leftoverCheckpointKey. An unreadable checkpoint file is logged
+// and treated as empty, so the cleanups start from scratch.
+func (m *Manager) readLeftoverCheckpoints() (map[string]leftoverCheckpoint, error) {
+	checkpoints := map[string]leftoverCheckpoint{}
+
+	b, err := ioutil.ReadFile(m.leftoverCheckpointFile)
+	if os.IsNotExist(err) {
+		return checkpoints, nil
+	} else if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	err = json.Unmarshal(b, &checkpoints)
+	if err != nil {
+		m.logger.Log("level", "error", "message", fmt.Sprintf("failed to parse leftover checkpoint file %#q", m.leftoverCheckpointFile), "stack", microerror.JSON(err))
+		return map[string]leftoverCheckpoint{}, nil
+	}
+
+	return checkpoints, nil
+}
+
+// writeLeftoverCheckpoints replaces the leftover checkpoint file with the
+// given checkpoints. The file is removed when there are none.
+func (m *Manager) writeLeftoverCheckpoints(checkpoints map[string]leftoverCheckpoint) error {
+	if len(checkpoints) == 0 {
+		err := os.Remove(m.leftoverCheckpointFile)
+		if err != nil && !os.IsNotExist(err) {
+			return microerror.Mask(err)
+		}
+
+		return nil
+	}
+
+	b, err := json.Marshal(checkpoints)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = writeFileAtomic(m.leftoverCheckpointFile, b)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// readLeftoverCheckpoint returns the checkpoint of the leftover cleanup of
+// the cluster in the hosted zone, or nil when there is none.
+func (m *Manager) readLeftoverCheckpoint(hostedZoneID, clusterID string) (*leftoverCheckpoint, error) {
+	if m.leftoverCheckpointFile == "" {
+		return nil, nil
+	}
+
+	checkpoints, err := m.readLeftoverCheckpoints()
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	c, ok := checkpoints[leftoverCheckpointKey(hostedZoneID, clusterID)]
+	if !ok || c.StartRecordName == "" {
+		return nil, nil
+	}
+
+	return &c, nil
+}
+
+// writeLeftoverCheckpoint records the given checkpoint in the leftover
+// checkpoint file.
+func (m *Manager) writeLeftoverCheckpoint(c leftoverCheckpoint) error {
+	if m.leftoverCheckpointFile == "" {
+		return nil
+	}
+
+	checkpoints, err := m.readLeftoverCheckpoints()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	checkpoints[leftoverCheckpointKey(c.HostedZoneID, c.Cluster)] = c
+
+	err = m.writeLeftoverCheckpoints(checkpoints)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// removeLeftoverCheckpoint removes the checkpoint of the leftover cleanup of
+// the cluster in the hosted zone from the leftover checkpoint file once the
+// cleanup completed.
+func (m *Manager) removeLeftoverCheckpoint(hostedZoneID, clusterID string) error {
+	if m.leftoverCheckpointFile == "" {
+		return nil
+	}
+
+	checkpoints, err := m.readLeftoverCheckpoints()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	k := leftoverCheckpointKey(hostedZoneID, clusterID)
+	if _, ok := checkpoints[k]; !ok {
+		return nil
+	}
+	delete(checkpoints, k)
+
+	err = m.writeLeftoverCheckpoints(checkpoints)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
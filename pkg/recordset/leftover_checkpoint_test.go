@@ -0,0 +1,174 @@
+package recordset
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+func TestDeleteTargetLeftovers_ResumeFromCheckpoint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "route53-manager")
+	if err != nil {
+		t.Fatalf("ioutil.TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	checkpointFile := filepath.Join(dir, "checkpoint.json")
+
+	targetClient := newTargetWithStacks(nil)
+	targetClient.recordSets = map[string][]*route53.ResourceRecordSet{
+		"zoneID": {
+			{Name: aws.String("api.foo.zonename."), Type: aws.String(route53.RRTypeCname)},
+			{Name: aws.String("old1.foo.zonename."), Type: aws.String(route53.RRTypeCname)},
+			{Name: aws.String("old2.foo.zonename."), Type: aws.String(route53.RRTypeCname)},
+			{Name: aws.String("etcd1.foo.zonename."), Type: aws.String(route53.RRTypeA)},
+			{Name: aws.String("old3.foo.zonename."), Type: aws.String(route53.RRTypeCname)},
+			{Name: aws.String("api.bar.zonename."), Type: aws.String(route53.RRTypeCname)},
+		},
+	}
+	targetClient.recordSetsPageSize = 2
+	// The first page is deleted, the deletion of the second page is
+	// interrupted.
+	targetClient.changeCallErrors = map[int]error{
+		2: awserr.New("Throttling", "Rate exceeded", nil),
+	}
+
+	newManager := func() (*Manager, *[]time.Duration) {
+		c := newTestConfig(t)
+		c.TargetClient = targetClient
+		c.LeftoverCheckpointFile = checkpointFile
+		c.LeftoverChangeInterval = time.Second
+		m, err := NewManager(c)
+		if err != nil {
+			t.Fatalf("NewManager: %v", err)
+		}
+
+		var sleeps []time.Duration
+		m.sleep = func(d time.Duration) { sleeps = append(sleeps, d) }
+
+		return m, &sleeps
+	}
+
+	deletedNames := func() []string {
+		var names []string
+		for _, change := range targetClient.changes["zoneID"] {
+			names = append(names, *change.ResourceRecordSet.Name)
+		}
+		return names
+	}
+
+	{
+		m, sleeps := newManager()
+
+		err = m.deleteTargetLeftovers("foo", nil)
+		if err == nil {
+			t.Fatalf("expected interrupted cleanup to fail")
+		}
+
+		expected := []string{"old1.foo.zonename."}
+		if !reflect.DeepEqual(deletedNames(), expected) {
+			t.Errorf("expected deleted record sets %v, got %v", expected, deletedNames())
+		}
+		// The change batch of the second page waits for the interval.
+		if !reflect.DeepEqual(*sleeps, []time.Duration{time.Second}) {
+			t.Errorf("expected to wait once between change batches, got %v", *sleeps)
+		}
+		if _, err := os.Stat(checkpointFile); err != nil {
+			t.Fatalf("expected checkpoint file, got %v", err)
+		}
+	}
+
+	{
+		m, sleeps := newManager()
+
+		err = m.deleteTargetLeftovers("foo", nil)
+		if err != nil {
+			t.Fatalf("deleteTargetLeftovers: %v", err)
+		}
+
+		// The first page is not listed again, so old1 is deleted only once.
+		expected := []string{"old1.foo.zonename.", "old2.foo.zonename.", "old3.foo.zonename."}
+		if !reflect.DeepEqual(deletedNames(), expected) {
+			t.Errorf("expected deleted record sets %v, got %v", expected, deletedNames())
+		}
+		if !reflect.DeepEqual(*sleeps, []time.Duration{time.Second}) {
+			t.Errorf("expected to wait once between change batches, got %v", *sleeps)
+		}
+		if _, err := os.Stat(checkpointFile); !os.IsNotExist(err) {
+			t.Errorf("expected checkpoint file to be removed, got %v", err)
+		}
+	}
+}
+
+func TestReadLeftoverCheckpoint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "route53-manager")
+	if err != nil {
+		t.Fatalf("ioutil.TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	tcs := []struct {
+		name         string
+		content      string
+		hostedZoneID string
+		cluster      string
+		expected     *leftoverCheckpoint
+	}{
+		{
+			name:         "case 0: matching checkpoint",
+			content:      `{"zoneID/foo":{"hosted_zone_id":"zoneID","cluster":"foo","start_record_name":"old.foo.zonename.","start_record_type":"CNAME"}}`,
+			hostedZoneID: "zoneID",
+			cluster:      "foo",
+			expected: &leftoverCheckpoint{
+				HostedZoneID:    "zoneID",
+				Cluster:         "foo",
+				StartRecordName: "old.foo.zonename.",
+				StartRecordType: "CNAME",
+			},
+		},
+		{
+			name:         "case 1: checkpoint of another cluster",
+			content:      `{"zoneID/bar":{"hosted_zone_id":"zoneID","cluster":"bar","start_record_name":"old.bar.zonename.","start_record_type":"CNAME"}}`,
+			hostedZoneID: "zoneID",
+			cluster:      "foo",
+		},
+		{
+			name:         "case 2: unreadable checkpoint file",
+			content:      `{`,
+			hostedZoneID: "zoneID",
+			cluster:      "foo",
+		},
+	}
+
+	for i, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			checkpointFile := filepath.Join(dir, fmt.Sprintf("checkpoint-%d.json", i))
+			err := ioutil.WriteFile(checkpointFile, []byte(tc.content), 0600)
+			if err != nil {
+				t.Fatalf("ioutil.WriteFile: %v", err)
+			}
+
+			c := newTestConfig(t)
+			c.LeftoverCheckpointFile = checkpointFile
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			checkpoint, err := m.readLeftoverCheckpoint(tc.hostedZoneID, tc.cluster)
+			if err != nil {
+				t.Fatalf("readLeftoverCheckpoint: %v", err)
+			}
+			if !reflect.DeepEqual(checkpoint, tc.expected) {
+				t.Errorf("expected checkpoint %#v, got %#v", tc.expected, checkpoint)
+			}
+		})
+	}
+}
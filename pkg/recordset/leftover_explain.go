@@ -0,0 +1,32 @@
+package recordset
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+const (
+	leftoverDecisionDelete = "delete"
+	leftoverDecisionKeep   = "keep"
+)
+
+// explainLeftover logs why the record set listed by the leftover cleanup of
+// the cluster is kept or deleted. Only record sets below the cluster domain
+// which are not managed are deleted.
+func (m *Manager) explainLeftover(hostedZoneID, clusterName string, rr *route53.ResourceRecordSet, matched, managed bool) {
+	decision := leftoverDecisionKeep
+	if matched && !managed {
+		decision = leftoverDecisionDelete
+	}
+
+	m.logger.Log(
+		"level", "debug",
+		"message", fmt.Sprintf("%s record set %#q of type %#q in hosted zone %#q during cleanup of cluster %#q", decision, aws.StringValue(rr.Name), aws.StringValue(rr.Type), hostedZoneID, clusterName),
+		"recordSet", aws.StringValue(rr.Name),
+		"matched", fmt.Sprintf("%t", matched),
+		"managed", fmt.Sprintf("%t", managed),
+		"decision", decision,
+	)
+}
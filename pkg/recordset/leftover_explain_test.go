@@ -0,0 +1,87 @@
+package recordset
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/giantswarm/micrologger"
+)
+
+func TestDeleteTargetLeftovers_ExplainCleanup(t *testing.T) {
+	tcs := []struct {
+		name                 string
+		explainCleanup       bool
+		expectedExplanations map[string]string
+	}{
+		{
+			name:           "case 0: explanations of all listed record sets",
+			explainCleanup: true,
+			expectedExplanations: map[string]string{
+				"api.foo.zoneName.":   "matched=true managed=true decision=keep",
+				"vault.foo.zoneName.": "matched=true managed=false decision=delete",
+				"api.bar.zoneName.":   "matched=false managed=false decision=keep",
+				"foo.zoneName.":       "matched=false managed=false decision=keep",
+			},
+		},
+		{
+			name:                 "case 1: no explanations by default",
+			expectedExplanations: map[string]string{},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			logger, err := micrologger.New(micrologger.Config{IOWriter: &out})
+			if err != nil {
+				t.Fatalf("micrologger.New: %v", err)
+			}
+
+			targetClient := newTargetWithStacks(nil)
+			targetClient.recordSets = map[string][]*route53.ResourceRecordSet{
+				"zoneID": {
+					{Name: aws.String("api.foo.zoneName."), Type: aws.String(route53.RRTypeCname)},
+					{Name: aws.String("vault.foo.zoneName."), Type: aws.String(route53.RRTypeCname)},
+					{Name: aws.String("api.bar.zoneName."), Type: aws.String(route53.RRTypeCname)},
+					{Name: aws.String("foo.zoneName."), Type: aws.String(route53.RRTypeNs)},
+				},
+			}
+
+			c := newTestConfig(t)
+			c.Logger = logger
+			c.TargetClient = targetClient
+			c.ExplainCleanup = tc.explainCleanup
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			err = m.deleteTargetLeftovers("foo", nil)
+			if err != nil {
+				t.Fatalf("deleteTargetLeftovers: %v", err)
+			}
+
+			explanations := map[string]string{}
+			s := bufio.NewScanner(&out)
+			for s.Scan() {
+				var line map[string]string
+				err := json.Unmarshal(s.Bytes(), &line)
+				if err != nil {
+					t.Fatalf("json.Unmarshal: %v", err)
+				}
+				if line["decision"] == "" {
+					continue
+				}
+				explanations[line["recordSet"]] = "matched=" + line["matched"] + " managed=" + line["managed"] + " decision=" + line["decision"]
+			}
+			if !reflect.DeepEqual(tc.expectedExplanations, explanations) {
+				t.Errorf("expected explanations %v, got %v", tc.expectedExplanations, explanations)
+			}
+		})
+	}
+}
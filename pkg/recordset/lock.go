@@ -0,0 +1,260 @@
+package recordset
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	// DefaultLockLease is the duration the hosted zone lock is held for
+	// when no lease is configured.
+	DefaultLockLease = 15 * time.Minute
+
+	lockRecordPrefix = "_r53mgr-lock."
+	lockRecordTTL    = 60
+)
+
+// hostedZoneLock is the content of the lock record of a hosted zone.
+type hostedZoneLock struct {
+	Owner   string
+	Expires time.Time
+}
+
+// value returns the TXT record value of the lock, e.g.
+// `"owner=installation/pod expires=2020-01-01T12:00:00Z"`.
+func (l hostedZoneLock) value() string {
+	return fmt.Sprintf("%q", fmt.Sprintf("owner=%s expires=%s", l.Owner, l.Expires.UTC().Format(time.RFC3339)))
+}
+
+// parseHostedZoneLock parses the TXT record value of a lock. Values which
+// cannot be parsed result in a lock which is expired already.
+func parseHostedZoneLock(value string) hostedZoneLock {
+	var l hostedZoneLock
+	for _, field := range strings.Fields(strings.Trim(value, `"`)) {
+		switch {
+		case strings.HasPrefix(field, "owner="):
+			l.Owner = strings.TrimPrefix(field, "owner=")
+		case strings.HasPrefix(field, "expires="):
+			l.Expires, _ = time.Parse(time.RFC3339, strings.TrimPrefix(field, "expires="))
+		}
+	}
+
+	return l
+}
+
+// lockRecordName returns the name of the lock record of the hosted zone,
+// e.g. `_r53mgr-lock.example.com.`.
+func lockRecordName(zone HostedZone) string {
+	return route53RecordName(lockRecordPrefix + zone.Name)
+}
+
+// lockZones returns the hosted zones a sync run writes to and therefore
+// locks, the target hosted zone followed by the etcd, region and reverse
+// hosted zones. Hosted zones are only returned once.
+func (m *Manager) lockZones() []HostedZone {
+	zones := []HostedZone{
+		{ID: m.targetHostedZoneID, Name: m.targetHostedZoneName},
+		{ID: m.etcdHostedZoneID, Name: m.etcdHostedZoneName},
+	}
+
+	var regions []string
+	for region := range m.regionHostedZones {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+	for _, region := range regions {
+		zones = append(zones, m.regionHostedZones[region])
+	}
+
+	if m.emitPTR {
+		zones = append(zones, HostedZone{ID: m.reverseHostedZoneID, Name: m.reverseHostedZoneName})
+	}
+
+	var result []HostedZone
+	seen := map[string]bool{}
+	for _, z := range zones {
+		if z.ID == "" || seen[z.ID] {
+			continue
+		}
+		seen[z.ID] = true
+		result = append(result, z)
+	}
+
+	return result
+}
+
+// acquireLock acquires the advisory lock of the hosted zone for m.lockLease.
+// Locks held by another owner are respected until they expire, in which case
+// lockHeldError is returned. The lock record is replaced in one change batch
+// deleting the exact record read before, so of two instances racing for the
+// lock only one succeeds.
+func (m *Manager) acquireLock(zone HostedZone) error {
+	current, err := getRecordSet(m.ctx, m.targetClient, zone.ID, lockRecordName(zone), route53.RRTypeTxt)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	var changes []*route53.Change
+	if current != nil {
+		var l hostedZoneLock
+		if len(current.ResourceRecords) > 0 {
+			l = parseHostedZoneLock(aws.StringValue(current.ResourceRecords[0].Value))
+		}
+		if l.Owner != m.lockOwner && m.now().Before(l.Expires) {
+			return microerror.Maskf(lockHeldError, "hosted zone %#q is locked by %#q until %s", zone.ID, l.Owner, l.Expires.UTC().Format(time.RFC3339))
+		}
+		if l.Owner != m.lockOwner {
+			m.logger.Log("level", "info", "message", fmt.Sprintf("taking over expired lock of hosted zone %#q from %#q", zone.ID, l.Owner))
+		}
+
+		changes = append(changes, &route53.Change{
+			Action:            aws.String(route53.ChangeActionDelete),
+			ResourceRecordSet: current,
+		})
+	}
+
+	err = m.replaceLock(zone, changes)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	m.logger.Log("level", "debug", "message", fmt.Sprintf("acquired lock of hosted zone %#q until %s", zone.ID, m.locks[zone.ID].Expires.UTC().Format(time.RFC3339)))
+
+	return nil
+}
+
+// replaceLock applies the given changes deleting the current lock record of
+// the hosted zone, if any, together with the creation of a new lock record
+// expiring m.lockLease from now. lockHeldError is returned when the current
+// lock record was changed concurrently.
+func (m *Manager) replaceLock(zone HostedZone, changes []*route53.Change) error {
+	l := hostedZoneLock{
+		Owner:   m.lockOwner,
+		Expires: m.now().Add(m.lockLease),
+	}
+	changes = append(changes, &route53.Change{
+		Action:            aws.String(route53.ChangeActionCreate),
+		ResourceRecordSet: lockRecordSet(zone, l),
+	})
+
+	input := &route53.ChangeResourceRecordSetsInput{
+		ChangeBatch: &route53.ChangeBatch{
+			Changes: changes,
+		},
+		HostedZoneId: aws.String(zone.ID),
+	}
+	_, err := m.changeResourceRecordSets(m.targetClient, input)
+	if isAWSErrorCode(err, route53.ErrCodeInvalidChangeBatch) {
+		return microerror.Maskf(lockHeldError, "lock of hosted zone %#q was changed concurrently", zone.ID)
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	m.locks[zone.ID] = l
+
+	return nil
+}
+
+// lockOrBackOff acquires the locks of all hosted zones the sync run writes
+// to when m.lockHostedZone is set. It returns false when any lock is held by
+// another owner, so the sync run backs off without applying changes. Locks
+// acquired before are released then.
+func (m *Manager) lockOrBackOff() (bool, error) {
+	if !m.lockHostedZone {
+		return true, nil
+	}
+
+	for _, zone := range m.lockZones() {
+		err := m.acquireLock(zone)
+		if IsLockHeld(err) {
+			m.logger.Log("level", "info", "message", fmt.Sprintf("backing off: %s", err.Error()))
+			m.releaseLock()
+			return false, nil
+		} else if err != nil {
+			m.releaseLock()
+			return false, microerror.Mask(err)
+		}
+	}
+
+	return true, nil
+}
+
+// renewLock extends the locks held by the sync run by m.lockLease once half
+// of the lease has passed, so long runs do not lose their locks. It is called
+// between clusters. lockHeldError is returned when a lock was taken over in
+// the meantime, which aborts the run.
+func (m *Manager) renewLock() error {
+	for _, zone := range m.lockZones() {
+		l, ok := m.locks[zone.ID]
+		if !ok || m.now().Before(l.Expires.Add(-m.lockLease/2)) {
+			continue
+		}
+
+		changes := []*route53.Change{
+			{
+				Action:            aws.String(route53.ChangeActionDelete),
+				ResourceRecordSet: lockRecordSet(zone, l),
+			},
+		}
+		err := m.replaceLock(zone, changes)
+		if err != nil {
+			delete(m.locks, zone.ID)
+			return microerror.Mask(err)
+		}
+
+		m.logger.Log("level", "debug", "message", fmt.Sprintf("renewed lock of hosted zone %#q until %s", zone.ID, m.locks[zone.ID].Expires.UTC().Format(time.RFC3339)))
+	}
+
+	return nil
+}
+
+// releaseLock deletes the lock records written by acquireLock, if any. The
+// deletion fails when a lock was taken over in the meantime, which is
+// logged.
+func (m *Manager) releaseLock() {
+	for _, zone := range m.lockZones() {
+		l, ok := m.locks[zone.ID]
+		if !ok {
+			continue
+		}
+
+		input := &route53.ChangeResourceRecordSetsInput{
+			ChangeBatch: &route53.ChangeBatch{
+				Changes: []*route53.Change{
+					{
+						Action:            aws.String(route53.ChangeActionDelete),
+						ResourceRecordSet: lockRecordSet(zone, l),
+					},
+				},
+			},
+			HostedZoneId: aws.String(zone.ID),
+		}
+		_, err := m.changeResourceRecordSets(m.targetClient, input)
+		if err != nil {
+			m.logger.Log("level", "error", "message", fmt.Sprintf("failed to release lock of hosted zone %#q", zone.ID), "stack", microerror.JSON(err))
+		} else {
+			m.logger.Log("level", "debug", "message", fmt.Sprintf("released lock of hosted zone %#q", zone.ID))
+		}
+
+		delete(m.locks, zone.ID)
+	}
+}
+
+func lockRecordSet(zone HostedZone, l hostedZoneLock) *route53.ResourceRecordSet {
+	return &route53.ResourceRecordSet{
+		Name: aws.String(lockRecordName(zone)),
+		Type: aws.String(route53.RRTypeTxt),
+		TTL:  aws.Int64(lockRecordTTL),
+		ResourceRecords: []*route53.ResourceRecord{
+			{
+				Value: aws.String(l.value()),
+			},
+		},
+	}
+}
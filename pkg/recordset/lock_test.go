@@ -0,0 +1,378 @@
+package recordset
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+func TestSync_LockHostedZone(t *testing.T) {
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	tags := []*cloudformation.Tag{
+		&cloudformation.Tag{
+			Key:   aws.String(installationTag),
+			Value: aws.String("installation"),
+		},
+	}
+	lockRecordSet := func(value string) *route53.ResourceRecordSet {
+		return &route53.ResourceRecordSet{
+			Name: aws.String("_r53mgr-lock.zoneName."),
+			Type: aws.String(route53.RRTypeTxt),
+			TTL:  aws.Int64(60),
+			ResourceRecords: []*route53.ResourceRecord{
+				{
+					Value: aws.String(value),
+				},
+			},
+		}
+	}
+
+	tcs := []struct {
+		name             string
+		recordSets       []*route53.ResourceRecordSet
+		changeErrors     []error
+		expectedDeletion bool
+		expectedChanges  []string
+	}{
+		{
+			name:             "case 0: missing lock is acquired and released",
+			expectedDeletion: true,
+			expectedChanges: []string{
+				`CREATE "owner=installation/me expires=2020-01-01T12:15:00Z"`,
+				`DELETE "owner=installation/me expires=2020-01-01T12:15:00Z"`,
+			},
+		},
+		{
+			name: "case 1: lock held by another owner backs off",
+			recordSets: []*route53.ResourceRecordSet{
+				lockRecordSet(`"owner=installation/other expires=2020-01-01T12:05:00Z"`),
+			},
+			expectedDeletion: false,
+		},
+		{
+			name: "case 2: expired lock of another owner is taken over",
+			recordSets: []*route53.ResourceRecordSet{
+				lockRecordSet(`"owner=installation/other expires=2020-01-01T11:55:00Z"`),
+			},
+			expectedDeletion: true,
+			expectedChanges: []string{
+				`DELETE "owner=installation/other expires=2020-01-01T11:55:00Z"`,
+				`CREATE "owner=installation/me expires=2020-01-01T12:15:00Z"`,
+				`DELETE "owner=installation/me expires=2020-01-01T12:15:00Z"`,
+			},
+		},
+		{
+			name: "case 3: own lock is renewed",
+			recordSets: []*route53.ResourceRecordSet{
+				lockRecordSet(`"owner=installation/me expires=2020-01-01T12:05:00Z"`),
+			},
+			expectedDeletion: true,
+			expectedChanges: []string{
+				`DELETE "owner=installation/me expires=2020-01-01T12:05:00Z"`,
+				`CREATE "owner=installation/me expires=2020-01-01T12:15:00Z"`,
+				`DELETE "owner=installation/me expires=2020-01-01T12:15:00Z"`,
+			},
+		},
+		{
+			name: "case 4: lock acquired concurrently by another owner backs off",
+			changeErrors: []error{
+				awserr.New(route53.ErrCodeInvalidChangeBatch, "Tried to create resource record set but it already exists", nil),
+			},
+			expectedDeletion: false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			targetClient := newTargetWithStacks([]cloudformation.Stack{
+				{
+					StackName:   aws.String("cluster-foo-guest-recordsets"),
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+					Tags:        tags,
+				},
+			})
+			targetClient.recordSets = map[string][]*route53.ResourceRecordSet{
+				"zoneID": tc.recordSets,
+			}
+			targetClient.changeErrors = tc.changeErrors
+
+			c := newTestConfig(t)
+			c.TargetClient = targetClient
+			c.LockHostedZone = true
+			c.LockOwner = "installation/me"
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+			m.now = func() time.Time { return now }
+
+			err = m.Sync()
+			if err != nil {
+				t.Fatalf("m.Sync: %v", err)
+			}
+
+			deleted := stringInSlice("cluster-foo-guest-recordsets", targetClient.deletedStacks)
+			if deleted != tc.expectedDeletion {
+				t.Fatalf("expected deletion %t, got deleted stacks %v", tc.expectedDeletion, targetClient.deletedStacks)
+			}
+
+			var changes []string
+			for _, change := range targetClient.changes["zoneID"] {
+				rr := change.ResourceRecordSet
+				if aws.StringValue(rr.Name) != "_r53mgr-lock.zoneName." || aws.StringValue(rr.Type) != route53.RRTypeTxt {
+					continue
+				}
+				changes = append(changes, aws.StringValue(change.Action)+" "+aws.StringValue(rr.ResourceRecords[0].Value))
+			}
+			if len(changes) != len(tc.expectedChanges) {
+				t.Fatalf("expected lock changes %v, got %v", tc.expectedChanges, changes)
+			}
+			for i := range changes {
+				if changes[i] != tc.expectedChanges[i] {
+					t.Errorf("expected lock changes %v, got %v", tc.expectedChanges, changes)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestSync_LockHostedZones(t *testing.T) {
+	tags := []*cloudformation.Tag{
+		&cloudformation.Tag{
+			Key:   aws.String(installationTag),
+			Value: aws.String("installation"),
+		},
+	}
+
+	tcs := []struct {
+		name       string
+		recordSets map[string][]*route53.ResourceRecordSet
+		// advance is the duration the clock advances during each
+		// CreateStack.
+		advance         time.Duration
+		dryRun          bool
+		expectedCreated []string
+		expectedChanges map[string][]string
+		expectedListed  bool
+	}{
+		{
+			name:            "case 0: etcd and reverse hosted zones are locked",
+			expectedCreated: []string{"cluster-foo-guest-recordsets", "cluster-bar-guest-recordsets"},
+			expectedChanges: map[string][]string{
+				"zoneID": {
+					`CREATE "owner=installation/me expires=2020-01-01T12:15:00Z"`,
+					`DELETE "owner=installation/me expires=2020-01-01T12:15:00Z"`,
+				},
+				"etcdZoneID": {
+					`CREATE "owner=installation/me expires=2020-01-01T12:15:00Z"`,
+					`DELETE "owner=installation/me expires=2020-01-01T12:15:00Z"`,
+				},
+				"reverseZoneID": {
+					`CREATE "owner=installation/me expires=2020-01-01T12:15:00Z"`,
+					`DELETE "owner=installation/me expires=2020-01-01T12:15:00Z"`,
+				},
+			},
+			expectedListed: true,
+		},
+		{
+			name: "case 1: lock of the etcd hosted zone held by another owner backs off before listing",
+			recordSets: map[string][]*route53.ResourceRecordSet{
+				"etcdZoneID": {
+					{
+						Name: aws.String("_r53mgr-lock.etcdZoneName."),
+						Type: aws.String(route53.RRTypeTxt),
+						ResourceRecords: []*route53.ResourceRecord{
+							{
+								Value: aws.String(`"owner=installation/other expires=2020-01-01T12:05:00Z"`),
+							},
+						},
+					},
+				},
+			},
+			expectedChanges: map[string][]string{
+				"zoneID": {
+					`CREATE "owner=installation/me expires=2020-01-01T12:15:00Z"`,
+					`DELETE "owner=installation/me expires=2020-01-01T12:15:00Z"`,
+				},
+			},
+			expectedListed: false,
+		},
+		{
+			name:            "case 2: locks are renewed once half of the lease passed",
+			advance:         10 * time.Minute,
+			expectedCreated: []string{"cluster-foo-guest-recordsets", "cluster-bar-guest-recordsets"},
+			expectedChanges: map[string][]string{
+				"zoneID": {
+					`CREATE "owner=installation/me expires=2020-01-01T12:15:00Z"`,
+					`DELETE "owner=installation/me expires=2020-01-01T12:15:00Z"`,
+					`CREATE "owner=installation/me expires=2020-01-01T12:25:00Z"`,
+					`DELETE "owner=installation/me expires=2020-01-01T12:25:00Z"`,
+				},
+				"etcdZoneID": {
+					`CREATE "owner=installation/me expires=2020-01-01T12:15:00Z"`,
+					`DELETE "owner=installation/me expires=2020-01-01T12:15:00Z"`,
+					`CREATE "owner=installation/me expires=2020-01-01T12:25:00Z"`,
+					`DELETE "owner=installation/me expires=2020-01-01T12:25:00Z"`,
+				},
+				"reverseZoneID": {
+					`CREATE "owner=installation/me expires=2020-01-01T12:15:00Z"`,
+					`DELETE "owner=installation/me expires=2020-01-01T12:15:00Z"`,
+					`CREATE "owner=installation/me expires=2020-01-01T12:25:00Z"`,
+					`DELETE "owner=installation/me expires=2020-01-01T12:25:00Z"`,
+				},
+			},
+			expectedListed: true,
+		},
+		{
+			name:            "case 3: dry run is not locked",
+			dryRun:          true,
+			expectedChanges: map[string][]string{},
+			expectedListed:  true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+
+			sourceClient := newSourceWithStacks([]cloudformation.Stack{
+				{
+					StackName:   aws.String("cluster-foo-tccp"),
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+					Tags:        tags,
+				},
+				{
+					StackName:   aws.String("cluster-bar-tccp"),
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+					Tags:        tags,
+				},
+			})
+			targetClient := newTargetWithStacks(nil)
+			targetClient.recordSets = tc.recordSets
+			targetClient.onCreateStack = func() {
+				now = now.Add(tc.advance)
+			}
+
+			c := newTestConfig(t)
+			c.SourceClient = sourceClient
+			c.TargetClient = targetClient
+			c.EtcdHostedZoneID = "etcdZoneID"
+			c.EtcdHostedZoneName = "etcdZoneName"
+			c.EmitPTR = true
+			c.ReverseHostedZoneID = "reverseZoneID"
+			c.ReverseHostedZoneName = "10.in-addr.arpa"
+			c.LockHostedZone = true
+			c.LockOwner = "installation/me"
+			c.DryRun = tc.dryRun
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+			m.now = func() time.Time { return now }
+
+			err = m.Sync()
+			if err != nil {
+				t.Fatalf("m.Sync: %v", err)
+			}
+
+			if !reflect.DeepEqual(tc.expectedCreated, targetClient.createdStacks) {
+				t.Errorf("expected created stacks %v, got %v", tc.expectedCreated, targetClient.createdStacks)
+			}
+
+			changes := map[string][]string{}
+			for zoneID, zoneChanges := range targetClient.changes {
+				for _, change := range zoneChanges {
+					rr := change.ResourceRecordSet
+					if !strings.HasPrefix(aws.StringValue(rr.Name), lockRecordPrefix) {
+						continue
+					}
+					changes[zoneID] = append(changes[zoneID], aws.StringValue(change.Action)+" "+aws.StringValue(rr.ResourceRecords[0].Value))
+				}
+			}
+			if !reflect.DeepEqual(tc.expectedChanges, changes) {
+				t.Errorf("expected lock changes %v, got %v", tc.expectedChanges, changes)
+			}
+
+			listed := sourceClient.listStacksCalls > 0
+			if listed != tc.expectedListed {
+				t.Errorf("expected source stacks listed %t, got %t", tc.expectedListed, listed)
+			}
+		})
+	}
+}
+
+func TestParseHostedZoneLock(t *testing.T) {
+	tcs := []struct {
+		name         string
+		value        string
+		expectedLock hostedZoneLock
+	}{
+		{
+			name:  "case 0: lock is parsed",
+			value: `"owner=installation/me expires=2020-01-01T12:00:00Z"`,
+			expectedLock: hostedZoneLock{
+				Owner:   "installation/me",
+				Expires: time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:  "case 1: invalid expiry is expired",
+			value: `"owner=installation/me expires=soon"`,
+			expectedLock: hostedZoneLock{
+				Owner: "installation/me",
+			},
+		},
+		{
+			name:         "case 2: invalid value is expired",
+			value:        `"foo"`,
+			expectedLock: hostedZoneLock{},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			l := parseHostedZoneLock(tc.value)
+			if l.Owner != tc.expectedLock.Owner || !l.Expires.Equal(tc.expectedLock.Expires) {
+				t.Errorf("expected %#v, got %#v", tc.expectedLock, l)
+			}
+		})
+	}
+}
+
+func TestNewManager_LockOwner(t *testing.T) {
+	c := newTestConfig(t)
+	m, err := NewManager(c)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	if m.lockOwner != "" {
+		t.Errorf("expected no lock owner without locking, got %#q", m.lockOwner)
+	}
+
+	c = newTestConfig(t)
+	c.LockHostedZone = true
+	m, err = NewManager(c)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	if !strings.HasPrefix(m.lockOwner, "installation/") {
+		t.Errorf("expected lock owner of the installation, got %#q", m.lockOwner)
+	}
+}
+
+func TestNewManager_InvalidLockOwner(t *testing.T) {
+	c := newTestConfig(t)
+	c.LockOwner = "installation me"
+
+	_, err := NewManager(c)
+	if !IsInvalidConfig(err) {
+		t.Errorf("expected invalid config error, got %v", err)
+	}
+}
@@ -0,0 +1,43 @@
+package recordset
+
+import (
+	"fmt"
+
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/route53-manager/pkg/recordset/plan"
+)
+
+// checkMassDeletion returns massDeletionError when the planned deletions
+// exceed m.maxDeletes or m.maxDeletePercentage of the given number of target
+// stacks, unless m.forceMassDelete is set. Runs which do not delete orphan
+// target stacks and scoped runs, touching a single cluster, are not checked.
+func (m *Manager) checkMassDeletion(deletes []plan.ClusterRef, targetStacks int) error {
+	if m.disableOrphanDeletion || m.scoped() || len(deletes) == 0 {
+		return nil
+	}
+
+	var reason string
+	if m.maxDeletes > 0 && len(deletes) > m.maxDeletes {
+		reason = fmt.Sprintf("more than %d", m.maxDeletes)
+	}
+	if m.maxDeletePercentage > 0 && len(deletes)*100 > m.maxDeletePercentage*targetStacks {
+		reason = fmt.Sprintf("more than %d%% of %d", m.maxDeletePercentage, targetStacks)
+	}
+	if reason == "" {
+		return nil
+	}
+
+	if m.forceMassDelete {
+		m.logger.Log("level", "warning", "message", fmt.Sprintf("deleting %d orphan target stacks, %s target stacks, mass deletion is forced", len(deletes), reason))
+		return nil
+	}
+
+	var names []string
+	for _, ref := range deletes {
+		names = append(names, ref.TargetStackName)
+	}
+	m.logger.Log("level", "error", "message", fmt.Sprintf("refused to delete %d orphan target stacks, %s target stacks, force mass deletion to proceed", len(deletes), reason), "stacks", fmt.Sprintf("%v", names))
+
+	return microerror.Maskf(massDeletionError, "%d orphan target stacks planned for deletion, %s target stacks", len(deletes), reason)
+}
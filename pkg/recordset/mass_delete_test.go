@@ -0,0 +1,140 @@
+package recordset
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+)
+
+func TestSync_MassDeletion(t *testing.T) {
+	tags := []*cloudformation.Tag{
+		{
+			Key:   aws.String(installationTag),
+			Value: aws.String("installation"),
+		},
+	}
+	stack := func(name string) cloudformation.Stack {
+		return cloudformation.Stack{
+			StackName:   aws.String(name),
+			StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+			Tags:        tags,
+		}
+	}
+
+	tcs := []struct {
+		name                string
+		maxDeletes          int
+		maxDeletePercentage int
+		forceMassDelete     bool
+		expectedDeleted     []string
+		errorMatcher        func(error) bool
+	}{
+		{
+			name: "case 0: no limits",
+			expectedDeleted: []string{
+				"cluster-bar-guest-recordsets",
+				"cluster-baz-guest-recordsets",
+			},
+		},
+		{
+			name:       "case 1: deletions within the maximum number",
+			maxDeletes: 2,
+			expectedDeleted: []string{
+				"cluster-bar-guest-recordsets",
+				"cluster-baz-guest-recordsets",
+			},
+		},
+		{
+			name:         "case 2: deletions exceed the maximum number",
+			maxDeletes:   1,
+			errorMatcher: IsMassDeletion,
+		},
+		{
+			name:                "case 3: deletions exceed the maximum percentage",
+			maxDeletePercentage: 50,
+			errorMatcher:        IsMassDeletion,
+		},
+		{
+			name:                "case 4: forced mass deletion",
+			maxDeletes:          1,
+			maxDeletePercentage: 50,
+			forceMassDelete:     true,
+			expectedDeleted: []string{
+				"cluster-bar-guest-recordsets",
+				"cluster-baz-guest-recordsets",
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			targetClient := newTargetWithStacks([]cloudformation.Stack{
+				stack("cluster-foo-guest-recordsets"),
+				stack("cluster-bar-guest-recordsets"),
+				stack("cluster-baz-guest-recordsets"),
+			})
+
+			c := newTestConfig(t)
+			c.SourceClient = newSourceWithStacks([]cloudformation.Stack{
+				stack("cluster-foo-tccp"),
+			})
+			c.TargetClient = targetClient
+			c.MaxDeletes = tc.maxDeletes
+			c.MaxDeletePercentage = tc.maxDeletePercentage
+			c.ForceMassDelete = tc.forceMassDelete
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			err = m.Sync()
+			if tc.errorMatcher != nil {
+				if !tc.errorMatcher(err) {
+					t.Fatalf("expected matching error, got %v", err)
+				}
+			} else if err != nil {
+				t.Fatalf("m.Sync: %v", err)
+			}
+
+			if !reflect.DeepEqual(tc.expectedDeleted, targetClient.deletedStacks) {
+				t.Errorf("expected deleted stacks %v, got %v", tc.expectedDeleted, targetClient.deletedStacks)
+			}
+		})
+	}
+}
+
+func TestNewManager_InvalidMaxDeletes(t *testing.T) {
+	tcs := []struct {
+		name                string
+		maxDeletes          int
+		maxDeletePercentage int
+	}{
+		{
+			name:       "case 0: negative maximum number",
+			maxDeletes: -1,
+		},
+		{
+			name:                "case 1: negative maximum percentage",
+			maxDeletePercentage: -1,
+		},
+		{
+			name:                "case 2: maximum percentage above 100",
+			maxDeletePercentage: 101,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newTestConfig(t)
+			c.MaxDeletes = tc.maxDeletes
+			c.MaxDeletePercentage = tc.maxDeletePercentage
+
+			_, err := NewManager(c)
+			if !IsInvalidConfig(err) {
+				t.Errorf("expected invalid config error, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package recordset
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// metadataRecordPrefix is prepended to the cluster domain to get the name of
+// the metadata record, e.g. `_meta.foo.example.com`.
+const metadataRecordPrefix = "_meta."
+
+// clusterMetadata returns the quoted value of the metadata record of the
+// cluster, e.g. `"installation=foo created=2020-01-01T12:00:00Z version=1a2b"`.
+// The creation time and version are left out when unknown.
+func (m *Manager) clusterMetadata(cluster Cluster) string {
+	fields := []string{
+		"installation=" + m.installation,
+	}
+	if !cluster.CreationTime.IsZero() {
+		fields = append(fields, "created="+cluster.CreationTime.UTC().Format(time.RFC3339))
+	}
+	if m.version != "" {
+		fields = append(fields, "version="+m.version)
+	}
+
+	return fmt.Sprintf("%q", strings.Join(fields, " "))
+}
@@ -0,0 +1,122 @@
+package recordset
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+func TestGetRecords_MetadataRecord(t *testing.T) {
+	tcs := []struct {
+		name            string
+		metadataRecord  bool
+		version         string
+		creationTime    time.Time
+		expectedRecords []string
+	}{
+		{
+			name: "case 0: no metadata record by default",
+		},
+		{
+			name:           "case 1: metadata record",
+			metadataRecord: true,
+			version:        "1a2b",
+			creationTime:   time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC),
+			expectedRecords: []string{
+				`"installation=installation created=2020-01-01T12:00:00Z version=1a2b"`,
+			},
+		},
+		{
+			name:           "case 2: metadata record without version and creation time",
+			metadataRecord: true,
+			expectedRecords: []string{
+				`"installation=installation"`,
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newTestConfig(t)
+			c.MetadataRecord = tc.metadataRecord
+			c.Version = tc.version
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			records, err := m.getRecords(Cluster{ID: "foo", CreationTime: tc.creationTime})
+			if err != nil {
+				t.Fatalf("getRecords: %v", err)
+			}
+
+			var values []string
+			for _, r := range m.newTargetStackTemplate(records).Resources {
+				if r.Properties.Type != route53.RRTypeTxt {
+					continue
+				}
+				if r.Properties.Name != "_meta.foo.zoneName" || r.Properties.HostedZoneID != "zoneID" {
+					t.Errorf("expected TXT record `_meta.foo.zoneName` in hosted zone `zoneID`, got %#v", r.Properties)
+				}
+				values = append(values, r.Properties.ResourceRecords...)
+			}
+			if !reflect.DeepEqual(values, tc.expectedRecords) {
+				t.Errorf("expected TXT record values %v, got %v", tc.expectedRecords, values)
+			}
+		})
+	}
+}
+
+func TestDeleteTargetLeftovers_MetadataRecord(t *testing.T) {
+	tcs := []struct {
+		name            string
+		metadataRecord  bool
+		expectedDeleted []string
+	}{
+		{
+			name:           "case 0: metadata record is managed",
+			metadataRecord: true,
+		},
+		{
+			name:            "case 1: metadata record is a leftover when disabled",
+			metadataRecord:  false,
+			expectedDeleted: []string{"_meta.foo.zoneName."},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			targetClient := newTargetWithStacks(nil)
+			targetClient.recordSets = map[string][]*route53.ResourceRecordSet{
+				"zoneID": {
+					{Name: aws.String("_meta.foo.zoneName."), Type: aws.String(route53.RRTypeTxt)},
+					{Name: aws.String("api.foo.zoneName."), Type: aws.String(route53.RRTypeCname)},
+				},
+			}
+
+			c := newTestConfig(t)
+			c.TargetClient = targetClient
+			c.MetadataRecord = tc.metadataRecord
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			err = m.deleteTargetLeftovers("foo", nil)
+			if err != nil {
+				t.Fatalf("deleteTargetLeftovers: %v", err)
+			}
+
+			var deleted []string
+			for _, change := range targetClient.changes["zoneID"] {
+				deleted = append(deleted, *change.ResourceRecordSet.Name)
+			}
+			if !reflect.DeepEqual(deleted, tc.expectedDeleted) {
+				t.Errorf("expected deleted record sets %v, got %v", tc.expectedDeleted, deleted)
+			}
+		})
+	}
+}
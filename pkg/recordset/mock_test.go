@@ -1,15 +1,84 @@
 package recordset
 
 import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/cloudformation"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/aws/aws-sdk-go/service/elbv2"
 	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/aws/aws-sdk-go/service/s3"
 )
 
 type sourceClientMock struct {
 	sourceStacks []cloudformation.Stack
+	// describeStacksPages, when set, is returned page by page by
+	// DescribeStacks regardless of the requested stack name.
+	describeStacksPages [][]*cloudformation.Stack
+	// noLoadBalancers makes DescribeLoadBalancers return the classic ELB not
+	// found error.
+	noLoadBalancers bool
+	// loadBalancersV2 are returned by DescribeLoadBalancersV2. The elbv2 not
+	// found error is returned when empty.
+	loadBalancersV2 []*elbv2.LoadBalancer
+	// loadBalancers, when set, are returned by DescribeLoadBalancers
+	// regardless of the requested names.
+	loadBalancers []*elb.LoadBalancerDescription
+	// loadBalancerTags and loadBalancerTagsV2 are returned by
+	// DescribeLoadBalancerTags and DescribeLoadBalancerTagsV2 by load
+	// balancer name and ARN.
+	loadBalancerTags   map[string][]*elb.Tag
+	loadBalancerTagsV2 map[string][]*elbv2.Tag
+	// emptyLoadBalancers and emptyLoadBalancersV2 make DescribeLoadBalancers
+	// and DescribeLoadBalancersV2 return empty results instead of load
+	// balancers or not found errors.
+	emptyLoadBalancers   bool
+	emptyLoadBalancersV2 bool
+	// loadBalancersError is returned by DescribeLoadBalancers when set.
+	loadBalancersError error
+	listStacksCalls    int
+	// listStacksErrors are returned by the first ListStacks calls, one per
+	// call. A nil entry lists the stacks.
+	listStacksErrors []error
+	// unlistedStacks are the names of source stacks missing in the
+	// ListStacks output, e.g. stacks created after the initial listing,
+	// which are still returned by DescribeStacks.
+	unlistedStacks map[string]bool
+	// networkInterfacesInputs are the inputs DescribeNetworkInterfaces was
+	// called with.
+	networkInterfacesInputs []*ec2.DescribeNetworkInterfacesInput
+	// networkInterfaces, when set, are returned by
+	// DescribeNetworkInterfaces instead of a single untagged one.
+	networkInterfaces []*ec2.NetworkInterface
+	// noNetworkInterfaces makes DescribeNetworkInterfaces return no network
+	// interfaces.
+	noNetworkInterfaces bool
+	// instancePages, when set, are returned page by page by
+	// DescribeInstances.
+	instancePages          [][]*ec2.Instance
+	describeInstancesInput *ec2.DescribeInstancesInput
+	// instanceStates and targetHealthStates are the states returned by
+	// DescribeInstanceHealth and by DescribeTargetHealth for every target
+	// group. A single healthy instance or target is returned when nil.
+	instanceStates     []string
+	targetHealthStates []string
+	// unhealthyELBs are the names of the classic ELBs DescribeInstanceHealth
+	// returns a single out of service instance for.
+	unhealthyELBs []string
+	// targetGroupsInputs are the inputs DescribeTargetGroups was called
+	// with.
+	targetGroupsInputs []*elbv2.DescribeTargetGroupsInput
+	// stackNotFoundErrors makes DescribeStacks return the CloudFormation
+	// error of missing stacks instead of mockClientError for unknown stacks.
+	stackNotFoundErrors bool
 }
 
 func newSourceWithStacks(stacks []cloudformation.Stack) *sourceClientMock {
@@ -18,11 +87,31 @@ func newSourceWithStacks(stacks []cloudformation.Stack) *sourceClientMock {
 	}
 }
 
-func (s *sourceClientMock) DescribeStacks(input *cloudformation.DescribeStacksInput) (*cloudformation.DescribeStacksOutput, error) {
+func (s *sourceClientMock) DescribeStacksWithContext(ctx aws.Context, input *cloudformation.DescribeStacksInput, opts ...request.Option) (*cloudformation.DescribeStacksOutput, error) {
 	if s == nil || input == nil || input.StackName == nil {
 		return nil, mockClientError
 	}
 
+	if len(s.describeStacksPages) > 0 {
+		page := 0
+		if input.NextToken != nil {
+			var err error
+			page, err = strconv.Atoi(*input.NextToken)
+			if err != nil || page >= len(s.describeStacksPages) {
+				return nil, mockClientError
+			}
+		}
+
+		output := &cloudformation.DescribeStacksOutput{
+			Stacks: s.describeStacksPages[page],
+		}
+		if page+1 < len(s.describeStacksPages) {
+			output.NextToken = aws.String(strconv.Itoa(page + 1))
+		}
+
+		return output, nil
+	}
+
 	for i, stack := range s.sourceStacks {
 		if stack.StackName != nil && *stack.StackName == *input.StackName {
 			output := &cloudformation.DescribeStacksOutput{
@@ -35,14 +124,23 @@ func (s *sourceClientMock) DescribeStacks(input *cloudformation.DescribeStacksIn
 		}
 	}
 
+	if s.stackNotFoundErrors {
+		return nil, newStackNotFoundError(*input.StackName)
+	}
+
 	return nil, mockClientError
 }
 
-func (s *sourceClientMock) ListStacks(input *cloudformation.ListStacksInput) (*cloudformation.ListStacksOutput, error) {
+func (s *sourceClientMock) ListStacksWithContext(ctx aws.Context, input *cloudformation.ListStacksInput, opts ...request.Option) (*cloudformation.ListStacksOutput, error) {
 	if s == nil {
 		return nil, mockClientError
 	}
 
+	s.listStacksCalls++
+	if len(s.listStacksErrors) >= s.listStacksCalls && s.listStacksErrors[s.listStacksCalls-1] != nil {
+		return nil, s.listStacksErrors[s.listStacksCalls-1]
+	}
+
 	filters := []string{}
 	if input != nil {
 		for _, f := range input.StackStatusFilter {
@@ -54,6 +152,10 @@ func (s *sourceClientMock) ListStacks(input *cloudformation.ListStacksInput) (*c
 
 	output := &cloudformation.ListStacksOutput{}
 	for _, stack := range s.sourceStacks {
+		if s.unlistedStacks[aws.StringValue(stack.StackName)] {
+			continue
+		}
+
 		add := false
 		if len(filters) > 0 && stack.StackStatus != nil {
 			for _, f := range filters {
@@ -78,7 +180,29 @@ func (s *sourceClientMock) ListStacks(input *cloudformation.ListStacksInput) (*c
 	return output, nil
 }
 
-func (s *sourceClientMock) DescribeInstances(*ec2.DescribeInstancesInput) (*ec2.DescribeInstancesOutput, error) {
+func (s *sourceClientMock) DescribeInstancesWithContext(ctx aws.Context, input *ec2.DescribeInstancesInput, opts ...request.Option) (*ec2.DescribeInstancesOutput, error) {
+	s.describeInstancesInput = input
+
+	if s.instancePages != nil {
+		page := 0
+		if input.NextToken != nil {
+			page, _ = strconv.Atoi(*input.NextToken)
+		}
+
+		output := &ec2.DescribeInstancesOutput{
+			Reservations: []*ec2.Reservation{
+				&ec2.Reservation{
+					Instances: s.instancePages[page],
+				},
+			},
+		}
+		if page+1 < len(s.instancePages) {
+			output.NextToken = aws.String(strconv.Itoa(page + 1))
+		}
+
+		return output, nil
+	}
+
 	output := &ec2.DescribeInstancesOutput{
 		Reservations: []*ec2.Reservation{
 			&ec2.Reservation{
@@ -92,34 +216,263 @@ func (s *sourceClientMock) DescribeInstances(*ec2.DescribeInstancesInput) (*ec2.
 	}
 	return output, nil
 }
-func (s *sourceClientMock) DescribeLoadBalancers(*elb.DescribeLoadBalancersInput) (*elb.DescribeLoadBalancersOutput, error) {
+func (s *sourceClientMock) DescribeLoadBalancersWithContext(aws.Context, *elb.DescribeLoadBalancersInput, ...request.Option) (*elb.DescribeLoadBalancersOutput, error) {
+	if s.loadBalancersError != nil {
+		return nil, s.loadBalancersError
+	}
+	if s.emptyLoadBalancers {
+		return &elb.DescribeLoadBalancersOutput{}, nil
+	}
+	if s.noLoadBalancers {
+		return nil, awserr.New(elb.ErrCodeAccessPointNotFoundException, "There is no ACTIVE Load Balancer", nil)
+	}
+	if len(s.loadBalancers) > 0 {
+		return &elb.DescribeLoadBalancersOutput{LoadBalancerDescriptions: s.loadBalancers}, nil
+	}
+
 	output := &elb.DescribeLoadBalancersOutput{
 		LoadBalancerDescriptions: []*elb.LoadBalancerDescription{
 			&elb.LoadBalancerDescription{
-				DNSName: aws.String("elb.dns.test"),
+				CanonicalHostedZoneNameID: aws.String("elbZoneID"),
+				DNSName:                   aws.String("elb.dns.test"),
 			},
 		},
 	}
 
 	return output, nil
 }
-func (s *sourceClientMock) DescribeNetworkInterfaces(input *ec2.DescribeNetworkInterfacesInput) (*ec2.DescribeNetworkInterfacesOutput, error) {
-	output := &ec2.DescribeNetworkInterfacesOutput{
-		NetworkInterfaces: []*ec2.NetworkInterface{
-			&ec2.NetworkInterface{
-				PrivateIpAddress: aws.String("10.1.0.1"),
+func (s *sourceClientMock) DescribeLoadBalancerTagsWithContext(ctx aws.Context, input *elb.DescribeTagsInput, opts ...request.Option) (*elb.DescribeTagsOutput, error) {
+	output := &elb.DescribeTagsOutput{}
+	for _, name := range input.LoadBalancerNames {
+		d := &elb.TagDescription{
+			LoadBalancerName: name,
+			Tags:             s.loadBalancerTags[aws.StringValue(name)],
+		}
+		output.TagDescriptions = append(output.TagDescriptions, d)
+	}
+
+	return output, nil
+}
+func (s *sourceClientMock) DescribeLoadBalancerTagsV2WithContext(ctx aws.Context, input *elbv2.DescribeTagsInput, opts ...request.Option) (*elbv2.DescribeTagsOutput, error) {
+	output := &elbv2.DescribeTagsOutput{}
+	for _, arn := range input.ResourceArns {
+		d := &elbv2.TagDescription{
+			ResourceArn: arn,
+			Tags:        s.loadBalancerTagsV2[aws.StringValue(arn)],
+		}
+		output.TagDescriptions = append(output.TagDescriptions, d)
+	}
+
+	return output, nil
+}
+func (s *sourceClientMock) DescribeLoadBalancersV2WithContext(aws.Context, *elbv2.DescribeLoadBalancersInput, ...request.Option) (*elbv2.DescribeLoadBalancersOutput, error) {
+	if s.emptyLoadBalancersV2 {
+		return &elbv2.DescribeLoadBalancersOutput{}, nil
+	}
+	if len(s.loadBalancersV2) == 0 {
+		return nil, awserr.New(elbv2.ErrCodeLoadBalancerNotFoundException, "One or more load balancers not found", nil)
+	}
+
+	output := &elbv2.DescribeLoadBalancersOutput{
+		LoadBalancers: s.loadBalancersV2,
+	}
+
+	return output, nil
+}
+func (s *sourceClientMock) DescribeInstanceHealthWithContext(ctx aws.Context, input *elb.DescribeInstanceHealthInput, opts ...request.Option) (*elb.DescribeInstanceHealthOutput, error) {
+	states := s.instanceStates
+	if states == nil {
+		states = []string{"InService"}
+	}
+	if stringInSlice(aws.StringValue(input.LoadBalancerName), s.unhealthyELBs) {
+		states = []string{"OutOfService"}
+	}
+
+	output := &elb.DescribeInstanceHealthOutput{}
+	for _, state := range states {
+		output.InstanceStates = append(output.InstanceStates, &elb.InstanceState{State: aws.String(state)})
+	}
+
+	return output, nil
+}
+func (s *sourceClientMock) DescribeTargetGroupsWithContext(ctx aws.Context, input *elbv2.DescribeTargetGroupsInput, opts ...request.Option) (*elbv2.DescribeTargetGroupsOutput, error) {
+	s.targetGroupsInputs = append(s.targetGroupsInputs, input)
+
+	output := &elbv2.DescribeTargetGroupsOutput{
+		TargetGroups: []*elbv2.TargetGroup{
+			{
+				TargetGroupArn: aws.String("targetGroupARN"),
 			},
 		},
 	}
 
 	return output, nil
 }
+func (s *sourceClientMock) DescribeTargetHealthWithContext(aws.Context, *elbv2.DescribeTargetHealthInput, ...request.Option) (*elbv2.DescribeTargetHealthOutput, error) {
+	states := s.targetHealthStates
+	if states == nil {
+		states = []string{elbv2.TargetHealthStateEnumHealthy}
+	}
+
+	output := &elbv2.DescribeTargetHealthOutput{}
+	for _, state := range states {
+		d := &elbv2.TargetHealthDescription{
+			TargetHealth: &elbv2.TargetHealth{State: aws.String(state)},
+		}
+		output.TargetHealthDescriptions = append(output.TargetHealthDescriptions, d)
+	}
+
+	return output, nil
+}
+func (s *sourceClientMock) DescribeNetworkInterfacesWithContext(ctx aws.Context, input *ec2.DescribeNetworkInterfacesInput, opts ...request.Option) (*ec2.DescribeNetworkInterfacesOutput, error) {
+	s.networkInterfacesInputs = append(s.networkInterfacesInputs, input)
+
+	if s.noNetworkInterfaces {
+		return &ec2.DescribeNetworkInterfacesOutput{}, nil
+	}
+
+	if len(s.networkInterfaces) > 0 {
+		return &ec2.DescribeNetworkInterfacesOutput{NetworkInterfaces: s.networkInterfaces}, nil
+	}
+
+	// By default a single network interface is returned per cluster of the
+	// filters, tagged with the filtered tags of the cluster.
+	n := 1
+	for _, f := range input.Filters {
+		if len(f.Values) > n {
+			n = len(f.Values)
+		}
+	}
+	output := &ec2.DescribeNetworkInterfacesOutput{}
+	for i := 0; i < n; i++ {
+		nic := &ec2.NetworkInterface{
+			PrivateIpAddress: aws.String("10.1.0.1"),
+		}
+		for _, f := range input.Filters {
+			name := aws.StringValue(f.Name)
+			if strings.HasPrefix(name, "tag:") && i < len(f.Values) {
+				nic.TagSet = append(nic.TagSet, &ec2.Tag{Key: aws.String(strings.TrimPrefix(name, "tag:")), Value: f.Values[i]})
+			}
+		}
+		output.NetworkInterfaces = append(output.NetworkInterfaces, nic)
+	}
+
+	return output, nil
+}
 
 type targetClientMock struct {
 	createdStacks []string
 	deletedStacks []string
 	updatedStacks []string
 	targetStacks  []cloudformation.Stack
+
+	createStackInputs []*cloudformation.CreateStackInput
+	updateStackInputs []*cloudformation.UpdateStackInput
+	// templates are the template bodies per stack name returned by
+	// GetTemplate.
+	templates map[string]string
+	// stackPolicies are the stack policies per stack name returned by
+	// GetStackPolicy.
+	stackPolicies map[string]string
+	nameServers   []string
+	// recordSets are the record sets per hosted zone ID returned by
+	// ListResourceRecordSets.
+	recordSets map[string][]*route53.ResourceRecordSet
+	// recordSetsPageSize makes ListResourceRecordSets return pages of the
+	// given size when set.
+	recordSetsPageSize int
+	// hostedZones are the hosted zones returned by ListHostedZonesByName.
+	// hostedZonesPageSize makes it return pages of the given size when set.
+	hostedZones         []*route53.HostedZone
+	hostedZonesPageSize int
+	// recordSetLimits are the record set limits per hosted zone ID returned
+	// by GetHostedZoneLimit. The limit defaults to 10000.
+	recordSetLimits map[string]int64
+	// deletionPolls simulates the deletion of the target stacks it holds by
+	// name. Once deleted, DescribeStacks reports them as DELETE_IN_PROGRESS
+	// for the given number of calls and as missing afterwards.
+	deletionPolls map[string]int
+	// stackResources are the resources per stack name returned by
+	// ListStackResources.
+	stackResources map[string][]*cloudformation.StackResourceSummary
+	// changes are the record set changes per hosted zone ID applied by
+	// ChangeResourceRecordSets.
+	changes map[string][]*route53.Change
+	// changeBatches counts the ChangeResourceRecordSets calls per hosted zone
+	// ID.
+	changeBatches map[string]int
+	// changeComments are the comments of the change batches applied by
+	// ChangeResourceRecordSets.
+	changeComments []string
+	// changeErrors are returned by consecutive ChangeResourceRecordSets
+	// calls. The changes are applied once they are used up.
+	changeErrors []error
+	// changeCallErrors are returned by the ChangeResourceRecordSets calls
+	// with the given number, starting at 1. The changes of all other calls
+	// are applied.
+	changeCallErrors  map[int]error
+	changeRecordCalls int
+	// changeStatuses are the statuses returned by consecutive GetChange
+	// calls. INSYNC is returned once they are used up.
+	changeStatuses  []string
+	getChangeCalls  int
+	listStacksCalls int
+	// createdStacksHiddenListings is the number of ListStacks calls after
+	// CreateStack which do not list the created stacks yet. Later calls list
+	// them as CREATE_IN_PROGRESS. Created stacks are never listed when zero.
+	createdStacksHiddenListings int
+	createdStacksListings       map[string]int
+	// onCreateStack is called by CreateStack before it creates the stack.
+	onCreateStack func()
+	// blockCreateStack makes CreateStack block until its context is done.
+	blockCreateStack bool
+
+	createChangeSetInputs []*cloudformation.CreateChangeSetInput
+	executedChangeSets    []string
+	// changeSetStatuses are the statuses returned by consecutive
+	// DescribeChangeSet calls. CREATE_COMPLETE is returned once they are used
+	// up. changeSetStatusReason is returned along with them.
+	changeSetStatuses      []string
+	changeSetStatusReason  string
+	describeChangeSetCalls int
+	// changeSets are listed by ListChangeSets. changeSetTemplates and
+	// changeSetTags are the templates and tags of the listed change sets by
+	// name.
+	changeSets         []*cloudformation.ChangeSetSummary
+	changeSetTemplates map[string]string
+	changeSetTags      map[string][]*cloudformation.Tag
+	deletedChangeSets  []string
+
+	// objects are the objects uploaded by PutObject, by bucket and key.
+	objects map[string]string
+
+	// stackNotFoundErrors makes DescribeStacks return the CloudFormation
+	// error of missing stacks instead of mockClientError for unknown stacks.
+	stackNotFoundErrors bool
+
+	createStackError            error
+	deleteStackError            error
+	listResourceRecordSetsError error
+
+	// calls records the names of the stack and record set operations in the
+	// order they were called.
+	calls []string
+}
+
+// newStackNotFoundError returns the error CloudFormation returns when
+// describing the missing stack with the given name.
+func newStackNotFoundError(stackName string) error {
+	return awserr.New("ValidationError", fmt.Sprintf("Stack with id %s does not exist", stackName), nil)
+}
+
+// newStackTags returns the stack tags of the given tags by key.
+func newStackTags(tags map[string]string) []*cloudformation.Tag {
+	var result []*cloudformation.Tag
+	for k, v := range tags {
+		result = append(result, &cloudformation.Tag{Key: aws.String(k), Value: aws.String(v)})
+	}
+
+	return result
 }
 
 func newTargetWithStacks(stacks []cloudformation.Stack) *targetClientMock {
@@ -127,11 +480,31 @@ func newTargetWithStacks(stacks []cloudformation.Stack) *targetClientMock {
 		targetStacks: stacks,
 	}
 }
-func (t *targetClientMock) DescribeStacks(input *cloudformation.DescribeStacksInput) (*cloudformation.DescribeStacksOutput, error) {
+func (t *targetClientMock) DescribeStacksWithContext(ctx aws.Context, input *cloudformation.DescribeStacksInput, opts ...request.Option) (*cloudformation.DescribeStacksOutput, error) {
 	if t == nil || input == nil || input.StackName == nil {
 		return nil, mockClientError
 	}
 
+	t.calls = append(t.calls, "DescribeStacks")
+
+	if polls, ok := t.deletionPolls[*input.StackName]; ok && stringInSlice(*input.StackName, t.deletedStacks) {
+		if polls == 0 {
+			return nil, newStackNotFoundError(*input.StackName)
+		}
+		t.deletionPolls[*input.StackName] = polls - 1
+
+		output := &cloudformation.DescribeStacksOutput{
+			Stacks: []*cloudformation.Stack{
+				{
+					StackName:   input.StackName,
+					StackStatus: aws.String(cloudformation.StackStatusDeleteInProgress),
+				},
+			},
+		}
+
+		return output, nil
+	}
+
 	for i, stack := range t.targetStacks {
 		if stack.StackName != nil && *stack.StackName == *input.StackName {
 			output := &cloudformation.DescribeStacksOutput{
@@ -144,24 +517,152 @@ func (t *targetClientMock) DescribeStacks(input *cloudformation.DescribeStacksIn
 		}
 	}
 
+	if t.stackNotFoundErrors {
+		return nil, newStackNotFoundError(*input.StackName)
+	}
+
 	return nil, mockClientError
 }
 
-func (t *targetClientMock) ListResourceRecordSets(input *route53.ListResourceRecordSetsInput) (*route53.ListResourceRecordSetsOutput, error) {
+func (t *targetClientMock) GetChangeWithContext(ctx aws.Context, input *route53.GetChangeInput, opts ...request.Option) (*route53.GetChangeOutput, error) {
+	if t == nil || input == nil || input.Id == nil {
+		return nil, mockClientError
+	}
+
+	status := route53.ChangeStatusInsync
+	if t.getChangeCalls < len(t.changeStatuses) {
+		status = t.changeStatuses[t.getChangeCalls]
+	}
+	t.getChangeCalls++
+
+	output := &route53.GetChangeOutput{
+		ChangeInfo: &route53.ChangeInfo{
+			Id:     input.Id,
+			Status: aws.String(status),
+		},
+	}
+
+	return output, nil
+}
+
+func (t *targetClientMock) GetHostedZoneWithContext(ctx aws.Context, input *route53.GetHostedZoneInput, opts ...request.Option) (*route53.GetHostedZoneOutput, error) {
+	if t == nil || input == nil || input.Id == nil {
+		return nil, mockClientError
+	}
+
+	output := &route53.GetHostedZoneOutput{
+		DelegationSet: &route53.DelegationSet{
+			NameServers: aws.StringSlice(t.nameServers),
+		},
+		HostedZone: &route53.HostedZone{
+			Id: input.Id,
+		},
+	}
+
+	return output, nil
+}
+
+func (t *targetClientMock) GetHostedZoneLimitWithContext(ctx aws.Context, input *route53.GetHostedZoneLimitInput, opts ...request.Option) (*route53.GetHostedZoneLimitOutput, error) {
+	if t == nil || input == nil || input.HostedZoneId == nil {
+		return nil, mockClientError
+	}
+
+	limit, ok := t.recordSetLimits[*input.HostedZoneId]
+	if !ok {
+		limit = 10000
+	}
+	output := &route53.GetHostedZoneLimitOutput{
+		Count: aws.Int64(int64(len(t.recordSets[*input.HostedZoneId]))),
+		Limit: &route53.HostedZoneLimit{
+			Type:  input.Type,
+			Value: aws.Int64(limit),
+		},
+	}
+
+	return output, nil
+}
+
+func (t *targetClientMock) ListHostedZonesByNameWithContext(ctx aws.Context, input *route53.ListHostedZonesByNameInput, opts ...request.Option) (*route53.ListHostedZonesByNameOutput, error) {
+	if t == nil || input == nil || input.DNSName == nil {
+		return nil, mockClientError
+	}
+
+	t.calls = append(t.calls, "ListHostedZonesByName")
+
+	// The hosted zones are sorted by name, as returned by Route53.
+	zones := append([]*route53.HostedZone{}, t.hostedZones...)
+	sort.SliceStable(zones, func(i, j int) bool {
+		return aws.StringValue(zones[i].Name) < aws.StringValue(zones[j].Name)
+	})
+
+	start := len(zones)
+	for i, z := range zones {
+		if aws.StringValue(z.Name) < *input.DNSName {
+			continue
+		}
+		if input.HostedZoneId != nil && aws.StringValue(z.Id) != *input.HostedZoneId {
+			continue
+		}
+		start = i
+		break
+	}
+
+	output := &route53.ListHostedZonesByNameOutput{
+		HostedZones: zones[start:],
+	}
+	if t.hostedZonesPageSize > 0 && len(output.HostedZones) > t.hostedZonesPageSize {
+		next := output.HostedZones[t.hostedZonesPageSize]
+		output.IsTruncated = aws.Bool(true)
+		output.NextDNSName = next.Name
+		output.NextHostedZoneId = next.Id
+		output.HostedZones = output.HostedZones[:t.hostedZonesPageSize]
+	}
+
+	return output, nil
+}
+
+func (t *targetClientMock) ListResourceRecordSetsWithContext(ctx aws.Context, input *route53.ListResourceRecordSetsInput, opts ...request.Option) (*route53.ListResourceRecordSetsOutput, error) {
 	if t == nil {
 		return nil, mockClientError
 	}
 
+	t.calls = append(t.calls, "ListResourceRecordSets")
+
+	if t.listResourceRecordSetsError != nil {
+		return nil, t.listResourceRecordSetsError
+	}
+
 	output := &route53.ListResourceRecordSetsOutput{}
+	if input != nil && input.HostedZoneId != nil {
+		output.ResourceRecordSets = t.recordSets[*input.HostedZoneId]
+	}
+	if t.recordSetsPageSize > 0 {
+		start := 0
+		for i, rr := range output.ResourceRecordSets {
+			if input.StartRecordName != nil && strings.EqualFold(*rr.Name, *input.StartRecordName) && *rr.Type == aws.StringValue(input.StartRecordType) {
+				start = i
+			}
+		}
+		output.ResourceRecordSets = output.ResourceRecordSets[start:]
+		if len(output.ResourceRecordSets) > t.recordSetsPageSize {
+			next := output.ResourceRecordSets[t.recordSetsPageSize]
+			output.IsTruncated = aws.Bool(true)
+			output.NextRecordName = next.Name
+			output.NextRecordType = next.Type
+			output.ResourceRecordSets = output.ResourceRecordSets[:t.recordSetsPageSize]
+		}
+	}
 
 	return output, nil
 }
 
-func (t *targetClientMock) ListStacks(input *cloudformation.ListStacksInput) (*cloudformation.ListStacksOutput, error) {
+func (t *targetClientMock) ListStacksWithContext(ctx aws.Context, input *cloudformation.ListStacksInput, opts ...request.Option) (*cloudformation.ListStacksOutput, error) {
 	if t == nil {
 		return nil, mockClientError
 	}
 
+	t.listStacksCalls++
+
 	filters := []string{}
 	if input != nil {
 		for _, f := range input.StackStatusFilter {
@@ -193,46 +694,277 @@ func (t *targetClientMock) ListStacks(input *cloudformation.ListStacksInput) (*c
 			output.StackSummaries = append(output.StackSummaries, s)
 		}
 	}
+	for _, name := range t.createdStacks {
+		if t.createdStacksHiddenListings == 0 {
+			break
+		}
+		if t.createdStacksListings == nil {
+			t.createdStacksListings = map[string]int{}
+		}
+		t.createdStacksListings[name]++
+		if t.createdStacksListings[name] <= t.createdStacksHiddenListings {
+			continue
+		}
+
+		s := &cloudformation.StackSummary{
+			StackId:     aws.String(name),
+			StackName:   aws.String(name),
+			StackStatus: aws.String(cloudformation.StackStatusCreateInProgress),
+		}
+		output.StackSummaries = append(output.StackSummaries, s)
+	}
 
 	return output, nil
 }
 
-func (t *targetClientMock) ChangeResourceRecordSets(input *route53.ChangeResourceRecordSetsInput) (*route53.ChangeResourceRecordSetsOutput, error) {
+func (t *targetClientMock) ChangeResourceRecordSetsWithContext(ctx aws.Context, input *route53.ChangeResourceRecordSetsInput, opts ...request.Option) (*route53.ChangeResourceRecordSetsOutput, error) {
 	if t == nil {
 		return nil, mockClientError
 	}
 
-	output := &route53.ChangeResourceRecordSetsOutput{}
+	t.changeRecordCalls++
+	if t.changeRecordCalls <= len(t.changeErrors) {
+		return nil, t.changeErrors[t.changeRecordCalls-1]
+	}
+	if err, ok := t.changeCallErrors[t.changeRecordCalls]; ok {
+		return nil, err
+	}
+
+	if input != nil && input.HostedZoneId != nil && input.ChangeBatch != nil {
+		if t.changes == nil {
+			t.changes = map[string][]*route53.Change{}
+		}
+		t.changes[*input.HostedZoneId] = append(t.changes[*input.HostedZoneId], input.ChangeBatch.Changes...)
+		if t.changeBatches == nil {
+			t.changeBatches = map[string]int{}
+		}
+		t.changeBatches[*input.HostedZoneId]++
+		t.changeComments = append(t.changeComments, aws.StringValue(input.ChangeBatch.Comment))
+	}
+
+	output := &route53.ChangeResourceRecordSetsOutput{
+		ChangeInfo: &route53.ChangeInfo{
+			Id:     aws.String("change"),
+			Status: aws.String(route53.ChangeStatusPending),
+		},
+	}
 
 	return output, nil
 }
 
-func (t *targetClientMock) CreateStack(input *cloudformation.CreateStackInput) (*cloudformation.CreateStackOutput, error) {
+func (t *targetClientMock) CreateStackWithContext(ctx aws.Context, input *cloudformation.CreateStackInput, opts ...request.Option) (*cloudformation.CreateStackOutput, error) {
 	if input == nil || input.StackName == nil {
 		return nil, mockClientError
 	}
 
+	if t.onCreateStack != nil {
+		t.onCreateStack()
+	}
+	if t.blockCreateStack {
+		<-ctx.Done()
+		return nil, awserr.New(request.CanceledErrorCode, "request context canceled", ctx.Err())
+	}
+
+	if t.createStackError != nil {
+		return nil, t.createStackError
+	}
+
+	t.calls = append(t.calls, "CreateStack")
 	t.createdStacks = append(t.createdStacks, *input.StackName)
+	t.createStackInputs = append(t.createStackInputs, input)
 
 	return nil, nil
 }
 
-func (t *targetClientMock) DeleteStack(input *cloudformation.DeleteStackInput) (*cloudformation.DeleteStackOutput, error) {
+func (t *targetClientMock) CreateChangeSetWithContext(ctx aws.Context, input *cloudformation.CreateChangeSetInput, opts ...request.Option) (*cloudformation.CreateChangeSetOutput, error) {
+	if input == nil || input.StackName == nil || input.ChangeSetName == nil {
+		return nil, mockClientError
+	}
+
+	t.calls = append(t.calls, "CreateChangeSet")
+	t.createChangeSetInputs = append(t.createChangeSetInputs, input)
+
+	output := &cloudformation.CreateChangeSetOutput{
+		Id:      input.ChangeSetName,
+		StackId: input.StackName,
+	}
+
+	return output, nil
+}
+
+func (t *targetClientMock) DescribeChangeSetWithContext(ctx aws.Context, input *cloudformation.DescribeChangeSetInput, opts ...request.Option) (*cloudformation.DescribeChangeSetOutput, error) {
+	if input == nil || input.StackName == nil || input.ChangeSetName == nil {
+		return nil, mockClientError
+	}
+
+	status := cloudformation.ChangeSetStatusCreateComplete
+	if t.describeChangeSetCalls < len(t.changeSetStatuses) {
+		status = t.changeSetStatuses[t.describeChangeSetCalls]
+	}
+	t.describeChangeSetCalls++
+
+	output := &cloudformation.DescribeChangeSetOutput{
+		ChangeSetName: input.ChangeSetName,
+		StackName:     input.StackName,
+		Status:        aws.String(status),
+		StatusReason:  aws.String(t.changeSetStatusReason),
+		Tags:          t.changeSetTags[*input.ChangeSetName],
+	}
+
+	return output, nil
+}
+
+func (t *targetClientMock) ListChangeSetsWithContext(ctx aws.Context, input *cloudformation.ListChangeSetsInput, opts ...request.Option) (*cloudformation.ListChangeSetsOutput, error) {
 	if input == nil || input.StackName == nil {
 		return nil, mockClientError
 	}
 
+	output := &cloudformation.ListChangeSetsOutput{
+		Summaries: t.changeSets,
+	}
+
+	return output, nil
+}
+
+func (t *targetClientMock) DeleteChangeSetWithContext(ctx aws.Context, input *cloudformation.DeleteChangeSetInput, opts ...request.Option) (*cloudformation.DeleteChangeSetOutput, error) {
+	if input == nil || input.StackName == nil || input.ChangeSetName == nil {
+		return nil, mockClientError
+	}
+
+	t.calls = append(t.calls, "DeleteChangeSet")
+	t.deletedChangeSets = append(t.deletedChangeSets, *input.ChangeSetName)
+
+	return nil, nil
+}
+
+func (t *targetClientMock) ExecuteChangeSetWithContext(ctx aws.Context, input *cloudformation.ExecuteChangeSetInput, opts ...request.Option) (*cloudformation.ExecuteChangeSetOutput, error) {
+	if input == nil || input.StackName == nil || input.ChangeSetName == nil {
+		return nil, mockClientError
+	}
+
+	t.calls = append(t.calls, "ExecuteChangeSet")
+	t.executedChangeSets = append(t.executedChangeSets, *input.ChangeSetName)
+
+	return nil, nil
+}
+
+func (t *targetClientMock) ListStackResourcesWithContext(ctx aws.Context, input *cloudformation.ListStackResourcesInput, opts ...request.Option) (*cloudformation.ListStackResourcesOutput, error) {
+	output := &cloudformation.ListStackResourcesOutput{
+		StackResourceSummaries: t.stackResources[*input.StackName],
+	}
+
+	return output, nil
+}
+
+func (t *targetClientMock) DeleteStackWithContext(ctx aws.Context, input *cloudformation.DeleteStackInput, opts ...request.Option) (*cloudformation.DeleteStackOutput, error) {
+	if input == nil || input.StackName == nil {
+		return nil, mockClientError
+	}
+
+	t.calls = append(t.calls, "DeleteStack")
+
+	if t.deleteStackError != nil {
+		return nil, t.deleteStackError
+	}
+
 	t.deletedStacks = append(t.deletedStacks, *input.StackName)
 
 	return nil, nil
 }
 
-func (t *targetClientMock) UpdateStack(input *cloudformation.UpdateStackInput) (*cloudformation.UpdateStackOutput, error) {
+func (t *targetClientMock) GetTemplateWithContext(ctx aws.Context, input *cloudformation.GetTemplateInput, opts ...request.Option) (*cloudformation.GetTemplateOutput, error) {
+	if input == nil || input.StackName == nil {
+		return nil, mockClientError
+	}
+
+	t.calls = append(t.calls, "GetTemplate")
+
+	if input.ChangeSetName != nil {
+		output := &cloudformation.GetTemplateOutput{
+			TemplateBody: aws.String(t.changeSetTemplates[*input.ChangeSetName]),
+		}
+		return output, nil
+	}
+
+	output := &cloudformation.GetTemplateOutput{
+		TemplateBody: aws.String(t.templates[*input.StackName]),
+	}
+
+	return output, nil
+}
+
+func (t *targetClientMock) GetStackPolicyWithContext(ctx aws.Context, input *cloudformation.GetStackPolicyInput, opts ...request.Option) (*cloudformation.GetStackPolicyOutput, error) {
 	if input == nil || input.StackName == nil {
 		return nil, mockClientError
 	}
 
+	t.calls = append(t.calls, "GetStackPolicy")
+
+	output := &cloudformation.GetStackPolicyOutput{}
+	if p, ok := t.stackPolicies[*input.StackName]; ok {
+		output.StackPolicyBody = aws.String(p)
+	}
+
+	return output, nil
+}
+
+func (t *targetClientMock) PutObjectWithContext(ctx aws.Context, input *s3.PutObjectInput, opts ...request.Option) (*s3.PutObjectOutput, error) {
+	if input == nil || input.Bucket == nil || input.Key == nil || input.Body == nil {
+		return nil, mockClientError
+	}
+
+	t.calls = append(t.calls, "PutObject")
+
+	b, err := ioutil.ReadAll(input.Body)
+	if err != nil {
+		return nil, err
+	}
+	if t.objects == nil {
+		t.objects = map[string]string{}
+	}
+	t.objects[*input.Bucket+"/"+*input.Key] = string(b)
+
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (t *targetClientMock) UpdateStackWithContext(ctx aws.Context, input *cloudformation.UpdateStackInput, opts ...request.Option) (*cloudformation.UpdateStackOutput, error) {
+	if input == nil || input.StackName == nil {
+		return nil, mockClientError
+	}
+
+	t.calls = append(t.calls, "UpdateStack")
 	t.updatedStacks = append(t.updatedStacks, *input.StackName)
+	t.updateStackInputs = append(t.updateStackInputs, input)
 
 	return nil, nil
 }
+
+type parentClientMock struct {
+	changes []*route53.Change
+	// recordSets are returned by ListResourceRecordSets.
+	recordSets []*route53.ResourceRecordSet
+}
+
+func (p *parentClientMock) ListResourceRecordSetsWithContext(ctx aws.Context, input *route53.ListResourceRecordSetsInput, opts ...request.Option) (*route53.ListResourceRecordSetsOutput, error) {
+	if input == nil || input.HostedZoneId == nil {
+		return nil, mockClientError
+	}
+
+	output := &route53.ListResourceRecordSetsOutput{
+		ResourceRecordSets: p.recordSets,
+	}
+
+	return output, nil
+}
+
+func (p *parentClientMock) ChangeResourceRecordSetsWithContext(ctx aws.Context, input *route53.ChangeResourceRecordSetsInput, opts ...request.Option) (*route53.ChangeResourceRecordSetsOutput, error) {
+	if input == nil || input.ChangeBatch == nil || input.HostedZoneId == nil {
+		return nil, mockClientError
+	}
+
+	p.changes = append(p.changes, input.ChangeBatch.Changes...)
+
+	output := &route53.ChangeResourceRecordSetsOutput{}
+
+	return output, nil
+}
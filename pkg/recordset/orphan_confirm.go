@@ -0,0 +1,39 @@
+package recordset
+
+import (
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/route53-manager/pkg/recordset/plan"
+)
+
+const (
+	// SkipReasonSourceReappeared is used for orphan target stacks whose
+	// source stack was found again right before their deletion.
+	SkipReasonSourceReappeared SkipReason = "source_reappeared"
+)
+
+// orphanSourceExists describes the source stacks of the cluster by name in
+// all source accounts, right before its orphan target stack is deleted. This
+// way clusters recreated during a long sync run keep their DNS records,
+// despite missing in the source stacks listed at its start.
+func (m *Manager) orphanSourceExists(clusterID string) (bool, error) {
+	stackNames := []string{
+		clusterStackName(legacySourceStackNamePattern, clusterID),
+		clusterStackName(sourceStackNamePattern, clusterID),
+	}
+
+	for _, cl := range m.sourceClients {
+		stacks, err := m.getStacksByName(cl, stackNames)
+		if err != nil {
+			return false, microerror.Mask(err)
+		}
+		for _, s := range stacks {
+			// Source stacks in a delete trigger status are gone already.
+			if !plan.HasStatus(s, m.deleteTriggerStatuses) {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
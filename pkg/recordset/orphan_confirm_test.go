@@ -0,0 +1,189 @@
+package recordset
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+)
+
+func TestSync_ConfirmOrphans(t *testing.T) {
+	tags := []*cloudformation.Tag{
+		&cloudformation.Tag{
+			Key:   aws.String(installationTag),
+			Value: aws.String("installation"),
+		},
+	}
+
+	tcs := []struct {
+		name             string
+		confirmOrphans   bool
+		sourceStacks     []cloudformation.Stack
+		expectedDeletion bool
+	}{
+		{
+			name:           "case 0: reappeared source stack keeps target stack",
+			confirmOrphans: true,
+			sourceStacks: []cloudformation.Stack{
+				{
+					StackName:   aws.String("cluster-foo-tccp"),
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+					Tags:        tags,
+				},
+			},
+			expectedDeletion: false,
+		},
+		{
+			name:           "case 1: reappeared source stack is ignored without confirmation",
+			confirmOrphans: false,
+			sourceStacks: []cloudformation.Stack{
+				{
+					StackName:   aws.String("cluster-foo-tccp"),
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+					Tags:        tags,
+				},
+			},
+			expectedDeletion: true,
+		},
+		{
+			name:             "case 2: missing source stack is confirmed",
+			confirmOrphans:   true,
+			expectedDeletion: true,
+		},
+		{
+			name:           "case 3: deleted source stack is confirmed",
+			confirmOrphans: true,
+			sourceStacks: []cloudformation.Stack{
+				{
+					StackName:   aws.String("cluster-foo-tccp"),
+					StackStatus: aws.String(cloudformation.StackStatusDeleteComplete),
+					Tags:        tags,
+				},
+			},
+			expectedDeletion: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			sourceClient := newSourceWithStacks(tc.sourceStacks)
+			sourceClient.stackNotFoundErrors = true
+			// The source stack is created after the initial listing of the
+			// source stacks.
+			sourceClient.unlistedStacks = map[string]bool{
+				"cluster-foo-tccp": true,
+			}
+			targetClient := newTargetWithStacks([]cloudformation.Stack{
+				{
+					StackName:   aws.String("cluster-foo-guest-recordsets"),
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+					Tags:        tags,
+				},
+			})
+
+			c := newTestConfig(t)
+			c.SourceClient = sourceClient
+			c.TargetClient = targetClient
+			c.ConfirmOrphans = tc.confirmOrphans
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			err = m.Sync()
+			if err != nil {
+				t.Fatalf("m.Sync: %v", err)
+			}
+
+			deleted := stringInSlice("cluster-foo-guest-recordsets", targetClient.deletedStacks)
+			if deleted != tc.expectedDeletion {
+				t.Fatalf("expected deletion %t, got deleted stacks %v", tc.expectedDeletion, targetClient.deletedStacks)
+			}
+			if !tc.expectedDeletion && !m.summary.skipped[SkipReasonSourceReappeared]["cluster-foo-guest-recordsets"] {
+				t.Errorf("expected target stack skipped with reason %#q, got %v", SkipReasonSourceReappeared, m.summary.skipped)
+			}
+		})
+	}
+}
+
+func TestSync_DeleteTriggerStatuses(t *testing.T) {
+	tags := []*cloudformation.Tag{
+		&cloudformation.Tag{
+			Key:   aws.String(installationTag),
+			Value: aws.String("installation"),
+		},
+	}
+
+	tcs := []struct {
+		name                  string
+		deleteTriggerStatuses []string
+		confirmOrphans        bool
+		expectedDeletion      bool
+	}{
+		{
+			name:             "case 0: source stack being deleted keeps target stack by default",
+			expectedDeletion: false,
+		},
+		{
+			name:                  "case 1: source stack being deleted triggers deletion",
+			deleteTriggerStatuses: []string{cloudformation.StackStatusDeleteInProgress},
+			expectedDeletion:      true,
+		},
+		{
+			name:                  "case 2: source stack being deleted is confirmed gone",
+			deleteTriggerStatuses: []string{cloudformation.StackStatusDeleteInProgress},
+			confirmOrphans:        true,
+			expectedDeletion:      true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			sourceClient := newSourceWithStacks([]cloudformation.Stack{
+				{
+					StackName:   aws.String("cluster-foo-tccp"),
+					StackStatus: aws.String(cloudformation.StackStatusDeleteInProgress),
+					Tags:        tags,
+				},
+			})
+			sourceClient.stackNotFoundErrors = true
+			targetClient := newTargetWithStacks([]cloudformation.Stack{
+				{
+					StackName:   aws.String("cluster-foo-guest-recordsets"),
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+					Tags:        tags,
+				},
+			})
+
+			c := newTestConfig(t)
+			c.SourceClient = sourceClient
+			c.TargetClient = targetClient
+			c.DeleteTriggerStatuses = tc.deleteTriggerStatuses
+			c.ConfirmOrphans = tc.confirmOrphans
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			err = m.Sync()
+			if err != nil {
+				t.Fatalf("m.Sync: %v", err)
+			}
+
+			deleted := stringInSlice("cluster-foo-guest-recordsets", targetClient.deletedStacks)
+			if deleted != tc.expectedDeletion {
+				t.Fatalf("expected deletion %t, got deleted stacks %v", tc.expectedDeletion, targetClient.deletedStacks)
+			}
+		})
+	}
+}
+
+func TestNewManager_InvalidDeleteTriggerStatuses(t *testing.T) {
+	c := newTestConfig(t)
+	c.DeleteTriggerStatuses = []string{cloudformation.StackStatusUpdateInProgress}
+
+	_, err := NewManager(c)
+	if !IsInvalidConfig(err) {
+		t.Errorf("expected invalid config error, got %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package recordset
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+
+	"github.com/giantswarm/route53-manager/pkg/recordset/plan"
+)
+
+const (
+	// DefaultPauseTag is the stack tag pausing the sync of a cluster when set
+	// to `true` on its source or target stack.
+	DefaultPauseTag = "giantswarm.io/route53-manager-paused"
+)
+
+const (
+	// SkipReasonPaused is used for the stacks of clusters whose source or
+	// target stack carries the pause tag.
+	SkipReasonPaused SkipReason = "paused"
+)
+
+// filterPausedClusters drops the source and target stacks of clusters with a
+// paused source or target stack, so their target stacks are neither created,
+// updated nor deleted.
+func (m *Manager) filterPausedClusters(sourceStacks, targetStacks []cloudformation.Stack) ([]cloudformation.Stack, []cloudformation.Stack) {
+	pausedStackNames := map[string]string{}
+	for _, stacks := range [][]cloudformation.Stack{sourceStacks, targetStacks} {
+		for _, stack := range stacks {
+			if !m.isPaused(stack) {
+				continue
+			}
+			clusterName, err := plan.ClusterID(*stack.StackName)
+			if err != nil {
+				continue
+			}
+			pausedStackNames[clusterName] = *stack.StackName
+		}
+	}
+
+	if len(pausedStackNames) == 0 {
+		return sourceStacks, targetStacks
+	}
+
+	filter := func(stacks []cloudformation.Stack) []cloudformation.Stack {
+		var result []cloudformation.Stack
+		for _, stack := range stacks {
+			clusterName, err := plan.ClusterID(*stack.StackName)
+			if err == nil && pausedStackNames[clusterName] != "" {
+				m.skip(*stack.StackName, SkipReasonPaused, fmt.Sprintf("ignored stack %#q of cluster %#q paused by tag %#q of stack %#q", *stack.StackName, clusterName, m.pauseTag, pausedStackNames[clusterName]), nil)
+				continue
+			}
+
+			result = append(result, stack)
+		}
+
+		return result
+	}
+
+	return filter(sourceStacks), filter(targetStacks)
+}
+
+// isPaused returns true when the stack carries the pause tag set to `true`.
+func (m *Manager) isPaused(stack cloudformation.Stack) bool {
+	for _, t := range stack.Tags {
+		if t.Key != nil && *t.Key == m.pauseTag && t.Value != nil && strings.EqualFold(*t.Value, "true") {
+			return true
+		}
+	}
+
+	return false
+}
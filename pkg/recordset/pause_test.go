@@ -0,0 +1,127 @@
+package recordset
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+)
+
+func TestSync_Paused(t *testing.T) {
+	newStack := func(name string, paused bool) cloudformation.Stack {
+		tags := []*cloudformation.Tag{
+			&cloudformation.Tag{
+				Key:   aws.String(installationTag),
+				Value: aws.String("installation"),
+			},
+		}
+		if paused {
+			tags = append(tags, &cloudformation.Tag{
+				Key:   aws.String("example.com/paused"),
+				Value: aws.String("true"),
+			})
+		}
+
+		return cloudformation.Stack{
+			StackName:   aws.String(name),
+			StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+			Tags:        tags,
+		}
+	}
+
+	tcs := []struct {
+		name            string
+		sourceStacks    []cloudformation.Stack
+		targetStacks    []cloudformation.Stack
+		expectedCreated []string
+		expectedUpdated []string
+		expectedDeleted []string
+		expectedPaused  map[string]bool
+	}{
+		{
+			name: "case 0: paused source stack without target stack",
+			sourceStacks: []cloudformation.Stack{
+				newStack("cluster-foo-tccp", true),
+				newStack("cluster-bar-tccp", false),
+			},
+			expectedCreated: []string{"cluster-bar-guest-recordsets"},
+			expectedPaused: map[string]bool{
+				"cluster-foo-tccp": true,
+			},
+		},
+		{
+			name: "case 1: paused source stack with target stack",
+			sourceStacks: []cloudformation.Stack{
+				newStack("cluster-foo-tccp", true),
+			},
+			targetStacks: []cloudformation.Stack{
+				newStack("cluster-foo-guest-recordsets", false),
+			},
+			expectedPaused: map[string]bool{
+				"cluster-foo-tccp":             true,
+				"cluster-foo-guest-recordsets": true,
+			},
+		},
+		{
+			name: "case 2: paused target stack",
+			sourceStacks: []cloudformation.Stack{
+				newStack("cluster-foo-tccp", false),
+				newStack("cluster-bar-tccp", false),
+			},
+			targetStacks: []cloudformation.Stack{
+				newStack("cluster-foo-guest-recordsets", true),
+				newStack("cluster-bar-guest-recordsets", false),
+			},
+			expectedUpdated: []string{"cluster-bar-guest-recordsets"},
+			expectedPaused: map[string]bool{
+				"cluster-foo-tccp":             true,
+				"cluster-foo-guest-recordsets": true,
+			},
+		},
+		{
+			name: "case 3: paused orphan target stack",
+			targetStacks: []cloudformation.Stack{
+				newStack("cluster-foo-guest-recordsets", true),
+				newStack("cluster-bar-guest-recordsets", false),
+			},
+			expectedDeleted: []string{"cluster-bar-guest-recordsets"},
+			expectedPaused: map[string]bool{
+				"cluster-foo-guest-recordsets": true,
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			targetClient := newTargetWithStacks(tc.targetStacks)
+
+			c := newTestConfig(t)
+			c.PauseTag = "example.com/paused"
+			c.SourceClient = newSourceWithStacks(tc.sourceStacks)
+			c.TargetClient = targetClient
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			err = m.Sync()
+			if err != nil {
+				t.Fatalf("m.Sync: %v", err)
+			}
+
+			if !reflect.DeepEqual(tc.expectedCreated, targetClient.createdStacks) {
+				t.Errorf("expected created stacks %v, got %v", tc.expectedCreated, targetClient.createdStacks)
+			}
+			if !reflect.DeepEqual(tc.expectedUpdated, targetClient.updatedStacks) {
+				t.Errorf("expected updated stacks %v, got %v", tc.expectedUpdated, targetClient.updatedStacks)
+			}
+			if !reflect.DeepEqual(tc.expectedDeleted, targetClient.deletedStacks) {
+				t.Errorf("expected deleted stacks %v, got %v", tc.expectedDeleted, targetClient.deletedStacks)
+			}
+			if !reflect.DeepEqual(tc.expectedPaused, m.summary.skipped[SkipReasonPaused]) {
+				t.Errorf("expected paused stacks %v, got %v", tc.expectedPaused, m.summary.skipped[SkipReasonPaused])
+			}
+		})
+	}
+}
@@ -0,0 +1,12 @@
+package plan
+
+import "github.com/giantswarm/microerror"
+
+var invalidClusterNameError = &microerror.Error{
+	Kind: "invalidClusterNameError",
+}
+
+// IsInvalidClusterName asserts invalidClusterNameError.
+func IsInvalidClusterName(err error) bool {
+	return microerror.Cause(err) == invalidClusterNameError
+}
@@ -0,0 +1,342 @@
+// Package plan decides which target stacks to create, update and delete,
+// based on the source and target stacks listed in a sync run. It has no side
+// effects, the recordset Manager executes the computed Plan.
+package plan
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+)
+
+// SkipReason is the reason a stack is not processed.
+type SkipReason string
+
+const (
+	// SkipReasonSourceStatus is used for source stacks whose status does not
+	// allow reading valid data, e.g. UPDATE_IN_PROGRESS.
+	SkipReasonSourceStatus SkipReason = "source_status"
+	// SkipReasonTargetStatus is used for target stacks whose status does not
+	// allow the operation, e.g. DELETE_COMPLETE.
+	SkipReasonTargetStatus SkipReason = "target_status"
+	// SkipReasonSourceTooYoung is used for source stacks younger than the
+	// configured minimum stack age.
+	SkipReasonSourceTooYoung SkipReason = "source_too_young"
+	// SkipReasonTargetDeleting is used for target stacks which are still
+	// being deleted.
+	SkipReasonTargetDeleting SkipReason = "target_deleting"
+	// SkipReasonInvalidStackName is used for stacks whose cluster name cannot
+	// be extracted.
+	SkipReasonInvalidStackName SkipReason = "invalid_stack_name"
+	// SkipReasonMissingStatus is used for stacks without status, which
+	// usually means a malformed API response rather than a legitimate skip.
+	SkipReasonMissingStatus SkipReason = "missing_status"
+)
+
+// Config configures the eligibility checks of Compute.
+type Config struct {
+	// Now is the time source stack ages are computed against.
+	Now time.Time
+	// MinStackAge is the duration a source stack must have been in its
+	// current status before its target stack is created or updated. Zero
+	// disables the check.
+	MinStackAge time.Duration
+	// DeleteTriggerStatuses are the source stack statuses which count as the
+	// source stack being gone, so the target stack of the cluster is deleted.
+	// Source stacks in a status other than DELETE_COMPLETE only count once
+	// they have been in it for MinStackAge, so a transient status does not
+	// delete DNS records. Defaults to DELETE_COMPLETE.
+	DeleteTriggerStatuses []string
+	// RecordClusterIDs are the IDs of the clusters whose records exist in the
+	// target hosted zone without a target stack, e.g. found by their metadata
+	// records when the records are written without CloudFormation. They are
+	// planned like clusters with a target stack, but their refs have no
+	// TargetStack.
+	RecordClusterIDs []string
+}
+
+// ClusterRef references the cluster a planned operation acts on.
+type ClusterRef struct {
+	// ID is the cluster ID, e.g. `foo`.
+	ID string
+	// IsLegacy is true for clusters below Giant Swarm Release version 10.0.0.
+	IsLegacy bool
+	// SourceStack is the source stack of the cluster. It is nil for deletes.
+	SourceStack *cloudformation.Stack
+	// TargetStack is the current target stack of the cluster. It is only set
+	// for updates and deletes of clusters with a target stack, see
+	// Config.RecordClusterIDs.
+	TargetStack *cloudformation.Stack
+	// TargetStackName is the name of the target stack of the cluster.
+	TargetStackName string
+}
+
+// Skip is a stack left untouched by the plan.
+type Skip struct {
+	StackName string
+	Reason    SkipReason
+	Message   string
+	// Err is set when the stack is skipped because of an error.
+	Err error
+}
+
+// Plan are the operations of a sync run, in the order of the given stacks.
+type Plan struct {
+	Creates []ClusterRef
+	Updates []ClusterRef
+	Deletes []ClusterRef
+	// Skips holds every skipped stack once per reason.
+	Skips []Skip
+}
+
+type planner struct {
+	config  Config
+	plan    Plan
+	skipped map[string]bool
+}
+
+// Compute returns the plan for the given source and target stacks.
+//
+// A target stack is created for every eligible source stack without one, and
+// updated for every eligible source stack with one in a writable status.
+// Target stacks without a source stack are deleted.
+func Compute(sourceStacks, targetStacks []cloudformation.Stack, config Config) Plan {
+	p := &planner{
+		config:  config,
+		skipped: map[string]bool{},
+	}
+
+	p.computeCreates(sourceStacks, targetStacks)
+	p.computeUpdates(sourceStacks, targetStacks)
+	p.computeDeletes(sourceStacks, targetStacks)
+
+	return p.plan
+}
+
+// computeCreates plans a target stack for each source stack without one.
+// only source stack with StackStatus matching stackStatusValidSource are processed.
+// only target stack with StackStatus not matching stackStatusValidDelete are processed.
+func (p *planner) computeCreates(sourceStacks, targetStacks []cloudformation.Stack) {
+	for i, source := range sourceStacks {
+		sourceClusterID, ok := p.eligibleSource(source)
+		if !ok {
+			continue
+		}
+
+		found := false
+		deleting := false
+		for _, target := range targetStacks {
+			if HasStatus(target, stackStatusValidDelete) {
+				p.skip(*target.StackName, SkipReasonTargetStatus, fmt.Sprintf("skipped target stack %#q with status %#q", *target.StackName, aws.StringValue(target.StackStatus)), nil)
+				continue
+			}
+
+			targetClusterID, err := ClusterID(*target.StackName)
+			if err != nil {
+				p.skip(*target.StackName, SkipReasonInvalidStackName, fmt.Sprintf("failed to get target stack name %#q", *target.StackName), err)
+				continue
+			}
+
+			if sourceClusterID == targetClusterID {
+				found = true
+				deleting = HasStatus(target, stackStatusDeleteInProgress)
+				break
+			}
+		}
+		// A target stack being deleted, e.g. by hand, still holds its name.
+		// Creating it now would fail, so wait for the deletion to complete and
+		// recreate it on a later run.
+		if deleting {
+			p.skip(TargetStackName(sourceClusterID), SkipReasonTargetDeleting, fmt.Sprintf("deferred creation of target stack %#q until its deletion completes", TargetStackName(sourceClusterID)), nil)
+			continue
+		}
+		if !found && !p.isRecordCluster(sourceClusterID) {
+			p.plan.Creates = append(p.plan.Creates, newClusterRef(sourceClusterID, &sourceStacks[i]))
+		}
+	}
+}
+
+// computeUpdates plans the update of the target stack of each source stack.
+// only source stack with StackStatus matching stackStatusValidSource are processed.
+// only target stack with StackStatus matching stackStatusValidTarget are processed.
+func (p *planner) computeUpdates(sourceStacks, targetStacks []cloudformation.Stack) {
+	for i, source := range sourceStacks {
+		sourceClusterID, ok := p.eligibleSource(source)
+		if !ok {
+			continue
+		}
+
+		var found *cloudformation.Stack
+		for j, target := range targetStacks {
+			if !HasStatus(target, stackStatusValidTarget) {
+				p.skip(*target.StackName, statusSkipReason(target, SkipReasonTargetStatus), fmt.Sprintf("skipped target stack %#q with status %#q", *target.StackName, aws.StringValue(target.StackStatus)), nil)
+				continue
+			}
+
+			targetClusterID, err := ClusterID(*target.StackName)
+			if err != nil {
+				p.skip(*target.StackName, SkipReasonInvalidStackName, fmt.Sprintf("failed to get target stack name %#q", *target.StackName), err)
+				continue
+			}
+
+			if sourceClusterID == targetClusterID {
+				found = &targetStacks[j]
+				break
+			}
+		}
+		if found != nil || p.isRecordCluster(sourceClusterID) {
+			ref := newClusterRef(sourceClusterID, &sourceStacks[i])
+			ref.TargetStack = found
+			p.plan.Updates = append(p.plan.Updates, ref)
+		}
+	}
+}
+
+// computeDeletes plans the deletion of each target stack with no
+// corresponding source stack.
+// only source stack with StackStatus not matching the delete trigger statuses are processed.
+// only target stack with StackStatus not matching stackStatusValidDelete are processed.
+func (p *planner) computeDeletes(sourceStacks, targetStacks []cloudformation.Stack) {
+	for i, target := range targetStacks {
+		if HasStatus(target, stackStatusValidDelete) {
+			p.skip(*target.StackName, SkipReasonTargetStatus, fmt.Sprintf("skipped target stack %#q with status %#q", *target.StackName, aws.StringValue(target.StackStatus)), nil)
+			continue
+		}
+
+		targetClusterID, err := ClusterID(*target.StackName)
+		if err != nil {
+			p.skip(*target.StackName, SkipReasonInvalidStackName, fmt.Sprintf("failed to get target stack name %#q", *target.StackName), err)
+			continue
+		}
+
+		if !p.hasSource(sourceStacks, targetClusterID) {
+			ref := ClusterRef{
+				ID:              targetClusterID,
+				TargetStack:     &targetStacks[i],
+				TargetStackName: *target.StackName,
+			}
+			p.plan.Deletes = append(p.plan.Deletes, ref)
+		}
+	}
+
+	for _, clusterID := range p.config.RecordClusterIDs {
+		if !p.hasSource(sourceStacks, clusterID) {
+			ref := ClusterRef{
+				ID:              clusterID,
+				TargetStackName: TargetStackName(clusterID),
+			}
+			p.plan.Deletes = append(p.plan.Deletes, ref)
+		}
+	}
+}
+
+// hasSource returns true when a source stack of the cluster exists which does
+// not count as gone.
+func (p *planner) hasSource(sourceStacks []cloudformation.Stack, clusterID string) bool {
+	for _, source := range sourceStacks {
+		if HasStatus(source, p.deleteTriggerStatuses()) {
+			if !deletionTriggerTooYoung(source, p.config.Now, p.config.MinStackAge) {
+				p.skip(*source.StackName, SkipReasonSourceStatus, fmt.Sprintf("skipped source stack %#q with status %#q", *source.StackName, aws.StringValue(source.StackStatus)), nil)
+				continue
+			}
+			p.skip(*source.StackName, SkipReasonSourceTooYoung, fmt.Sprintf("deferred deletion for source stack %#q with status %#q younger than %s", *source.StackName, aws.StringValue(source.StackStatus), p.config.MinStackAge), nil)
+		}
+
+		sourceClusterID, err := ClusterID(*source.StackName)
+		if err != nil {
+			p.skip(*source.StackName, SkipReasonInvalidStackName, fmt.Sprintf("failed to get source stack name %#q", *source.StackName), err)
+			continue
+		}
+
+		if sourceClusterID == clusterID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isRecordCluster returns true when the cluster is one of
+// Config.RecordClusterIDs.
+func (p *planner) isRecordCluster(clusterID string) bool {
+	for _, id := range p.config.RecordClusterIDs {
+		if id == clusterID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// deleteTriggerStatuses returns the configured delete trigger statuses or
+// the default ones.
+func (p *planner) deleteTriggerStatuses() []string {
+	if len(p.config.DeleteTriggerStatuses) == 0 {
+		return stackStatusValidDelete
+	}
+
+	return p.config.DeleteTriggerStatuses
+}
+
+// eligibleSource returns the cluster ID of the source stack when its records
+// can be computed.
+func (p *planner) eligibleSource(source cloudformation.Stack) (string, bool) {
+	if !HasStatus(source, stackStatusValidSource) {
+		p.skip(*source.StackName, statusSkipReason(source, SkipReasonSourceStatus), fmt.Sprintf("skipped source stack %#q with status %#q", *source.StackName, aws.StringValue(source.StackStatus)), nil)
+		return "", false
+	}
+
+	if tooYoung(source, p.config.Now, p.config.MinStackAge) {
+		p.skip(*source.StackName, SkipReasonSourceTooYoung, fmt.Sprintf("deferred source stack %#q younger than %s", *source.StackName, p.config.MinStackAge), nil)
+		return "", false
+	}
+
+	sourceClusterID, err := ClusterID(*source.StackName)
+	if err != nil {
+		p.skip(*source.StackName, SkipReasonInvalidStackName, fmt.Sprintf("failed to get source stack name %#q", *source.StackName), err)
+		return "", false
+	}
+
+	return sourceClusterID, true
+}
+
+// statusSkipReason returns the reason for skipping the stack because of its
+// status. Stacks without status are skipped with SkipReasonMissingStatus, so
+// they are not mistaken for stacks in a legitimately skipped status.
+func statusSkipReason(stack cloudformation.Stack, reason SkipReason) SkipReason {
+	if aws.StringValue(stack.StackStatus) == "" {
+		return SkipReasonMissingStatus
+	}
+
+	return reason
+}
+
+// skip records the stack as skipped, once per reason.
+func (p *planner) skip(stackName string, reason SkipReason, message string, err error) {
+	k := stackName + "/" + string(reason)
+	if p.skipped[k] {
+		return
+	}
+	p.skipped[k] = true
+
+	s := Skip{
+		StackName: stackName,
+		Reason:    reason,
+		Message:   message,
+		Err:       err,
+	}
+	p.plan.Skips = append(p.plan.Skips, s)
+}
+
+func newClusterRef(clusterID string, source *cloudformation.Stack) ClusterRef {
+	ref := ClusterRef{
+		ID:              clusterID,
+		IsLegacy:        isLegacy(*source),
+		SourceStack:     source,
+		TargetStackName: TargetStackName(clusterID),
+	}
+
+	return ref
+}
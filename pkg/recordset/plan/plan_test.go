@@ -0,0 +1,348 @@
+package plan
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+)
+
+func TestCompute(t *testing.T) {
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tcs := []struct {
+		name            string
+		sourceStacks    []cloudformation.Stack
+		targetStacks    []cloudformation.Stack
+		config          Config
+		expectedCreates []string
+		expectedUpdates []string
+		expectedDeletes []string
+		expectedSkips   []string
+	}{
+		{
+			name:         "case 0: no stacks",
+			sourceStacks: nil,
+			targetStacks: nil,
+		},
+		{
+			name: "case 1: create 1 target stack",
+			sourceStacks: []cloudformation.Stack{
+				newStack("cluster-foo-tccp", cloudformation.StackStatusCreateComplete),
+			},
+			expectedCreates: []string{"cluster-foo-guest-recordsets"},
+		},
+		{
+			name: "case 2: create 2 of 3 target stacks",
+			sourceStacks: []cloudformation.Stack{
+				newStack("cluster-foo-tccp", cloudformation.StackStatusCreateComplete),
+				newStack("cluster-bar-guest-main", cloudformation.StackStatusUpdateComplete),
+				newStack("cluster-baz-tccp", cloudformation.StackStatusCreateComplete),
+			},
+			targetStacks: []cloudformation.Stack{
+				newStack("cluster-baz-guest-recordsets", cloudformation.StackStatusCreateComplete),
+			},
+			expectedCreates: []string{"cluster-foo-guest-recordsets", "cluster-bar-guest-recordsets"},
+			expectedUpdates: []string{"cluster-baz-guest-recordsets"},
+		},
+		{
+			name: "case 3: skip source stacks with invalid status",
+			sourceStacks: []cloudformation.Stack{
+				newStack("cluster-foo-tccp", cloudformation.StackStatusUpdateInProgress),
+				newStack("cluster-bar-tccp", cloudformation.StackStatusRollbackComplete),
+			},
+			targetStacks: []cloudformation.Stack{
+				newStack("cluster-bar-guest-recordsets", cloudformation.StackStatusCreateComplete),
+			},
+			expectedSkips: []string{
+				"cluster-foo-tccp/" + string(SkipReasonSourceStatus),
+				"cluster-bar-tccp/" + string(SkipReasonSourceStatus),
+			},
+		},
+		{
+			name: "case 4: skip target stacks with invalid status for updates",
+			sourceStacks: []cloudformation.Stack{
+				newStack("cluster-foo-tccp", cloudformation.StackStatusCreateComplete),
+			},
+			targetStacks: []cloudformation.Stack{
+				newStack("cluster-foo-guest-recordsets", cloudformation.StackStatusUpdateInProgress),
+			},
+			expectedSkips: []string{
+				"cluster-foo-guest-recordsets/" + string(SkipReasonTargetStatus),
+			},
+		},
+		{
+			name: "case 5: recreate deleted target stack",
+			sourceStacks: []cloudformation.Stack{
+				newStack("cluster-foo-tccp", cloudformation.StackStatusCreateComplete),
+			},
+			targetStacks: []cloudformation.Stack{
+				newStack("cluster-foo-guest-recordsets", cloudformation.StackStatusDeleteComplete),
+			},
+			expectedCreates: []string{"cluster-foo-guest-recordsets"},
+			expectedSkips: []string{
+				"cluster-foo-guest-recordsets/" + string(SkipReasonTargetStatus),
+			},
+		},
+		{
+			name: "case 6: defer creation of target stack being deleted",
+			sourceStacks: []cloudformation.Stack{
+				newStack("cluster-foo-tccp", cloudformation.StackStatusCreateComplete),
+			},
+			targetStacks: []cloudformation.Stack{
+				newStack("cluster-foo-guest-recordsets", cloudformation.StackStatusDeleteInProgress),
+			},
+			expectedSkips: []string{
+				"cluster-foo-guest-recordsets/" + string(SkipReasonTargetDeleting),
+				"cluster-foo-guest-recordsets/" + string(SkipReasonTargetStatus),
+			},
+		},
+		{
+			name: "case 7: delete orphan target stacks",
+			sourceStacks: []cloudformation.Stack{
+				newStack("cluster-foo-tccp", cloudformation.StackStatusCreateComplete),
+				newStack("cluster-bar-tccp", cloudformation.StackStatusDeleteComplete),
+			},
+			targetStacks: []cloudformation.Stack{
+				newStack("cluster-foo-guest-recordsets", cloudformation.StackStatusCreateComplete),
+				newStack("cluster-bar-guest-recordsets", cloudformation.StackStatusCreateComplete),
+				newStack("cluster-baz-guest-recordsets", cloudformation.StackStatusUpdateComplete),
+			},
+			expectedUpdates: []string{"cluster-foo-guest-recordsets"},
+			expectedDeletes: []string{"cluster-bar-guest-recordsets", "cluster-baz-guest-recordsets"},
+			expectedSkips: []string{
+				"cluster-bar-tccp/" + string(SkipReasonSourceStatus),
+			},
+		},
+		{
+			name: "case 8: defer source stacks younger than the minimum stack age",
+			sourceStacks: []cloudformation.Stack{
+				newStackCreatedAt("cluster-foo-tccp", cloudformation.StackStatusCreateComplete, now.Add(-time.Minute)),
+				newStackCreatedAt("cluster-bar-tccp", cloudformation.StackStatusCreateComplete, now.Add(-time.Hour)),
+			},
+			config: Config{
+				Now:         now,
+				MinStackAge: 10 * time.Minute,
+			},
+			expectedCreates: []string{"cluster-bar-guest-recordsets"},
+			expectedSkips: []string{
+				"cluster-foo-tccp/" + string(SkipReasonSourceTooYoung),
+			},
+		},
+		{
+			name: "case 9: skip stacks with invalid names",
+			sourceStacks: []cloudformation.Stack{
+				newStack("invalid", cloudformation.StackStatusCreateComplete),
+			},
+			targetStacks: []cloudformation.Stack{
+				newStack("broken", cloudformation.StackStatusCreateComplete),
+			},
+			expectedSkips: []string{
+				"invalid/" + string(SkipReasonInvalidStackName),
+				"broken/" + string(SkipReasonInvalidStackName),
+			},
+		},
+		{
+			name: "case 10: keep target stack of source stack being deleted by default",
+			sourceStacks: []cloudformation.Stack{
+				newStack("cluster-foo-tccp", cloudformation.StackStatusDeleteInProgress),
+			},
+			targetStacks: []cloudformation.Stack{
+				newStack("cluster-foo-guest-recordsets", cloudformation.StackStatusCreateComplete),
+			},
+			expectedSkips: []string{
+				"cluster-foo-tccp/" + string(SkipReasonSourceStatus),
+			},
+		},
+		{
+			name: "case 11: delete target stack of source stack being deleted",
+			sourceStacks: []cloudformation.Stack{
+				newStackDeletedAt("cluster-foo-tccp", cloudformation.StackStatusDeleteInProgress, now.Add(-time.Hour)),
+			},
+			targetStacks: []cloudformation.Stack{
+				newStack("cluster-foo-guest-recordsets", cloudformation.StackStatusCreateComplete),
+			},
+			config: Config{
+				Now:                   now,
+				MinStackAge:           10 * time.Minute,
+				DeleteTriggerStatuses: []string{cloudformation.StackStatusDeleteComplete, cloudformation.StackStatusDeleteInProgress},
+			},
+			expectedDeletes: []string{"cluster-foo-guest-recordsets"},
+			expectedSkips: []string{
+				"cluster-foo-tccp/" + string(SkipReasonSourceStatus),
+			},
+		},
+		{
+			name: "case 12: defer deletion for source stack being deleted shorter than the minimum stack age",
+			sourceStacks: []cloudformation.Stack{
+				newStackDeletedAt("cluster-foo-tccp", cloudformation.StackStatusDeleteInProgress, now.Add(-time.Minute)),
+			},
+			targetStacks: []cloudformation.Stack{
+				newStack("cluster-foo-guest-recordsets", cloudformation.StackStatusCreateComplete),
+			},
+			config: Config{
+				Now:                   now,
+				MinStackAge:           10 * time.Minute,
+				DeleteTriggerStatuses: []string{cloudformation.StackStatusDeleteInProgress},
+			},
+			expectedSkips: []string{
+				"cluster-foo-tccp/" + string(SkipReasonSourceStatus),
+				"cluster-foo-tccp/" + string(SkipReasonSourceTooYoung),
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			p := Compute(tc.sourceStacks, tc.targetStacks, tc.config)
+
+			creates := targetStackNames(p.Creates)
+			if !reflect.DeepEqual(tc.expectedCreates, creates) {
+				t.Errorf("expected creates %v, got %v", tc.expectedCreates, creates)
+			}
+			updates := targetStackNames(p.Updates)
+			if !reflect.DeepEqual(tc.expectedUpdates, updates) {
+				t.Errorf("expected updates %v, got %v", tc.expectedUpdates, updates)
+			}
+			deletes := targetStackNames(p.Deletes)
+			if !reflect.DeepEqual(tc.expectedDeletes, deletes) {
+				t.Errorf("expected deletes %v, got %v", tc.expectedDeletes, deletes)
+			}
+
+			var skips []string
+			for _, s := range p.Skips {
+				skips = append(skips, s.StackName+"/"+string(s.Reason))
+			}
+			if !reflect.DeepEqual(tc.expectedSkips, skips) {
+				t.Errorf("expected skips %v, got %v", tc.expectedSkips, skips)
+			}
+		})
+	}
+}
+
+func TestCompute_ClusterRef(t *testing.T) {
+	sourceStacks := []cloudformation.Stack{
+		newStack("cluster-foo-guest-main", cloudformation.StackStatusCreateComplete),
+		newStack("cluster-bar-tccp", cloudformation.StackStatusCreateComplete),
+		newStack("cluster-baz-tccp", cloudformation.StackStatusCreateComplete),
+	}
+	targetStacks := []cloudformation.Stack{
+		newStack("cluster-baz-guest-recordsets", cloudformation.StackStatusUpdateComplete),
+	}
+
+	p := Compute(sourceStacks, targetStacks, Config{})
+
+	expected := []ClusterRef{
+		{
+			ID:              "foo",
+			IsLegacy:        true,
+			SourceStack:     &sourceStacks[0],
+			TargetStackName: "cluster-foo-guest-recordsets",
+		},
+		{
+			ID:              "bar",
+			IsLegacy:        false,
+			SourceStack:     &sourceStacks[1],
+			TargetStackName: "cluster-bar-guest-recordsets",
+		},
+	}
+	if !reflect.DeepEqual(expected, p.Creates) {
+		t.Errorf("expected creates %v, got %v", expected, p.Creates)
+	}
+
+	expected = []ClusterRef{
+		{
+			ID:              "baz",
+			IsLegacy:        false,
+			SourceStack:     &sourceStacks[2],
+			TargetStack:     &targetStacks[0],
+			TargetStackName: "cluster-baz-guest-recordsets",
+		},
+	}
+	if !reflect.DeepEqual(expected, p.Updates) {
+		t.Errorf("expected updates %v, got %v", expected, p.Updates)
+	}
+}
+
+func TestCompute_RecordClusters(t *testing.T) {
+	sourceStacks := []cloudformation.Stack{
+		newStack("cluster-foo-tccp", cloudformation.StackStatusCreateComplete),
+		newStack("cluster-bar-tccp", cloudformation.StackStatusCreateComplete),
+		newStack("cluster-baz-tccp", cloudformation.StackStatusCreateComplete),
+	}
+	targetStacks := []cloudformation.Stack{
+		newStack("cluster-baz-guest-recordsets", cloudformation.StackStatusUpdateComplete),
+	}
+
+	p := Compute(sourceStacks, targetStacks, Config{RecordClusterIDs: []string{"bar", "qux"}})
+
+	expected := []ClusterRef{
+		{
+			ID:              "foo",
+			SourceStack:     &sourceStacks[0],
+			TargetStackName: "cluster-foo-guest-recordsets",
+		},
+	}
+	if !reflect.DeepEqual(expected, p.Creates) {
+		t.Errorf("expected creates %v, got %v", expected, p.Creates)
+	}
+
+	expected = []ClusterRef{
+		{
+			ID:              "bar",
+			SourceStack:     &sourceStacks[1],
+			TargetStackName: "cluster-bar-guest-recordsets",
+		},
+		{
+			ID:              "baz",
+			SourceStack:     &sourceStacks[2],
+			TargetStack:     &targetStacks[0],
+			TargetStackName: "cluster-baz-guest-recordsets",
+		},
+	}
+	if !reflect.DeepEqual(expected, p.Updates) {
+		t.Errorf("expected updates %v, got %v", expected, p.Updates)
+	}
+
+	expected = []ClusterRef{
+		{
+			ID:              "qux",
+			TargetStackName: "cluster-qux-guest-recordsets",
+		},
+	}
+	if !reflect.DeepEqual(expected, p.Deletes) {
+		t.Errorf("expected deletes %v, got %v", expected, p.Deletes)
+	}
+}
+
+func newStack(name, status string) cloudformation.Stack {
+	return cloudformation.Stack{
+		StackName:   aws.String(name),
+		StackStatus: aws.String(status),
+	}
+}
+
+func newStackCreatedAt(name, status string, creationTime time.Time) cloudformation.Stack {
+	s := newStack(name, status)
+	s.CreationTime = aws.Time(creationTime)
+
+	return s
+}
+
+func newStackDeletedAt(name, status string, deletionTime time.Time) cloudformation.Stack {
+	s := newStack(name, status)
+	s.DeletionTime = aws.Time(deletionTime)
+
+	return s
+}
+
+func targetStackNames(refs []ClusterRef) []string {
+	var names []string
+	for _, ref := range refs {
+		names = append(names, ref.TargetStackName)
+	}
+
+	return names
+}
@@ -0,0 +1,100 @@
+package plan
+
+import (
+	"encoding/json"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/giantswarm/microerror"
+)
+
+// Report is the serializable form of a Plan, e.g. written to a file for
+// review before the plan is applied.
+type Report struct {
+	Creates []ReportEntry `json:"creates"`
+	Updates []ReportEntry `json:"updates"`
+	Deletes []ReportEntry `json:"deletes"`
+	Skips   []ReportSkip  `json:"skips"`
+}
+
+// ReportEntry is a planned operation on the target stack of a cluster.
+type ReportEntry struct {
+	ClusterID         string `json:"clusterID"`
+	IsLegacy          bool   `json:"isLegacy,omitempty"`
+	SourceStackName   string `json:"sourceStackName,omitempty"`
+	SourceStackStatus string `json:"sourceStackStatus,omitempty"`
+	TargetStackName   string `json:"targetStackName"`
+	TargetStackStatus string `json:"targetStackStatus,omitempty"`
+}
+
+// ReportSkip is a stack left untouched by the plan.
+type ReportSkip struct {
+	StackName string     `json:"stackName"`
+	Reason    SkipReason `json:"reason"`
+	Message   string     `json:"message"`
+	Error     string     `json:"error,omitempty"`
+}
+
+// NewReport returns the report of the given plan.
+func NewReport(p Plan) Report {
+	r := Report{
+		Creates: newReportEntries(p.Creates),
+		Updates: newReportEntries(p.Updates),
+		Deletes: newReportEntries(p.Deletes),
+		Skips:   []ReportSkip{},
+	}
+	for _, s := range p.Skips {
+		skip := ReportSkip{
+			StackName: s.StackName,
+			Reason:    s.Reason,
+			Message:   s.Message,
+		}
+		if s.Err != nil {
+			skip.Error = s.Err.Error()
+		}
+		r.Skips = append(r.Skips, skip)
+	}
+
+	return r
+}
+
+// MarshalReport returns the indented JSON of the given report.
+func MarshalReport(r Report) ([]byte, error) {
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return b, nil
+}
+
+// UnmarshalReport parses a report returned by MarshalReport.
+func UnmarshalReport(b []byte) (Report, error) {
+	var r Report
+	err := json.Unmarshal(b, &r)
+	if err != nil {
+		return Report{}, microerror.Mask(err)
+	}
+
+	return r, nil
+}
+
+func newReportEntries(refs []ClusterRef) []ReportEntry {
+	entries := []ReportEntry{}
+	for _, ref := range refs {
+		e := ReportEntry{
+			ClusterID:       ref.ID,
+			IsLegacy:        ref.IsLegacy,
+			TargetStackName: ref.TargetStackName,
+		}
+		if ref.SourceStack != nil {
+			e.SourceStackName = aws.StringValue(ref.SourceStack.StackName)
+			e.SourceStackStatus = aws.StringValue(ref.SourceStack.StackStatus)
+		}
+		if ref.TargetStack != nil {
+			e.TargetStackStatus = aws.StringValue(ref.TargetStack.StackStatus)
+		}
+		entries = append(entries, e)
+	}
+
+	return entries
+}
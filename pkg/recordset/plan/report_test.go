@@ -0,0 +1,91 @@
+package plan
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+)
+
+func TestReport_RoundTrip(t *testing.T) {
+	sourceStacks := []cloudformation.Stack{
+		newStack("cluster-foo-guest-main", cloudformation.StackStatusCreateComplete),
+		newStack("cluster-bar-tccp", cloudformation.StackStatusUpdateComplete),
+		newStack("cluster-qux-tccp", cloudformation.StackStatusUpdateInProgress),
+	}
+	targetStacks := []cloudformation.Stack{
+		newStack("cluster-bar-guest-recordsets", cloudformation.StackStatusCreateComplete),
+		newStack("cluster-baz-guest-recordsets", cloudformation.StackStatusUpdateComplete),
+	}
+
+	p := Compute(sourceStacks, targetStacks, Config{})
+
+	expected := Report{
+		Creates: []ReportEntry{
+			{
+				ClusterID:         "foo",
+				IsLegacy:          true,
+				SourceStackName:   "cluster-foo-guest-main",
+				SourceStackStatus: cloudformation.StackStatusCreateComplete,
+				TargetStackName:   "cluster-foo-guest-recordsets",
+			},
+		},
+		Updates: []ReportEntry{
+			{
+				ClusterID:         "bar",
+				SourceStackName:   "cluster-bar-tccp",
+				SourceStackStatus: cloudformation.StackStatusUpdateComplete,
+				TargetStackName:   "cluster-bar-guest-recordsets",
+				TargetStackStatus: cloudformation.StackStatusCreateComplete,
+			},
+		},
+		Deletes: []ReportEntry{
+			{
+				ClusterID:         "baz",
+				TargetStackName:   "cluster-baz-guest-recordsets",
+				TargetStackStatus: cloudformation.StackStatusUpdateComplete,
+			},
+		},
+		Skips: []ReportSkip{
+			{
+				StackName: "cluster-qux-tccp",
+				Reason:    SkipReasonSourceStatus,
+				Message:   "skipped source stack `cluster-qux-tccp` with status `UPDATE_IN_PROGRESS`",
+			},
+		},
+	}
+
+	r := NewReport(p)
+	if !reflect.DeepEqual(expected, r) {
+		t.Fatalf("expected report %+v, got %+v", expected, r)
+	}
+
+	b, err := MarshalReport(r)
+	if err != nil {
+		t.Fatalf("MarshalReport: %v", err)
+	}
+	parsed, err := UnmarshalReport(b)
+	if err != nil {
+		t.Fatalf("UnmarshalReport: %v", err)
+	}
+	if !reflect.DeepEqual(r, parsed) {
+		t.Errorf("expected parsed report %+v, got %+v", r, parsed)
+	}
+}
+
+func TestReport_Empty(t *testing.T) {
+	b, err := MarshalReport(NewReport(Plan{}))
+	if err != nil {
+		t.Fatalf("MarshalReport: %v", err)
+	}
+
+	expected := `{
+  "creates": [],
+  "updates": [],
+  "deletes": [],
+  "skips": []
+}`
+	if string(b) != expected {
+		t.Errorf("expected report %s, got %s", expected, b)
+	}
+}
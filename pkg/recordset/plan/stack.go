@@ -0,0 +1,163 @@
+package plan
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	// LegacySourceStackNamePattern is the pattern for Cloud Formation Stack
+	// names of Tenant Clusters below Giant Swarm Release version 10.0.0, aka
+	// legacy clusters, aka non Node Pool clusters.
+	LegacySourceStackNamePattern = "cluster-.*-guest-main"
+	SourceStackNamePattern       = "cluster-.*-tccp$"
+	TargetStackNamePattern       = "cluster-.*-guest-recordsets"
+)
+
+var (
+	// Predefined set of cloudformation stack statuses
+	// which allow for valid data to be retrieved from the stack.
+	stackStatusValidSource = []string{
+		cloudformation.StackStatusCreateComplete,
+		cloudformation.StackStatusUpdateComplete,
+	}
+	// Predefined set of cloudformation stack statuses
+	// which allow for write operations to the stack.
+	stackStatusValidTarget = []string{
+		cloudformation.StackStatusCreateComplete,
+		cloudformation.StackStatusCreateFailed,
+		cloudformation.StackStatusDeleteFailed,
+		cloudformation.StackStatusRollbackComplete,
+		cloudformation.StackStatusRollbackFailed,
+		cloudformation.StackStatusUpdateComplete,
+		cloudformation.StackStatusUpdateRollbackComplete,
+		cloudformation.StackStatusUpdateRollbackFailed,
+	}
+	// Predefined set of cloudformation stack statuses
+	// which indicates a stack has been deleted.
+	stackStatusValidDelete = []string{
+		cloudformation.StackStatusDeleteComplete,
+	}
+	// Predefined set of cloudformation stack statuses
+	// which indicates a stack is being deleted.
+	stackStatusDeleteInProgress = []string{
+		cloudformation.StackStatusDeleteInProgress,
+	}
+	// StackStatusDeleting is the set of cloudformation stack statuses which
+	// indicates a stack is being or has been deleted.
+	StackStatusDeleting = append(append([]string{}, stackStatusDeleteInProgress...), stackStatusValidDelete...)
+	// StackStatusValid is the set of cloudformation stack statuses used to
+	// read from the AWS API. It includes all statuses except
+	// cloudformation.StackStatusDeleteComplete.
+	StackStatusValid = []*string{
+		aws.String(cloudformation.StackStatusCreateComplete),
+		aws.String(cloudformation.StackStatusCreateInProgress),
+		aws.String(cloudformation.StackStatusCreateFailed),
+		aws.String(cloudformation.StackStatusRollbackInProgress),
+		aws.String(cloudformation.StackStatusRollbackFailed),
+		aws.String(cloudformation.StackStatusRollbackComplete),
+		aws.String(cloudformation.StackStatusDeleteInProgress),
+		aws.String(cloudformation.StackStatusDeleteFailed),
+		aws.String(cloudformation.StackStatusUpdateInProgress),
+		aws.String(cloudformation.StackStatusUpdateCompleteCleanupInProgress),
+		aws.String(cloudformation.StackStatusUpdateComplete),
+		aws.String(cloudformation.StackStatusUpdateRollbackInProgress),
+		aws.String(cloudformation.StackStatusUpdateRollbackFailed),
+		aws.String(cloudformation.StackStatusUpdateRollbackCompleteCleanupInProgress),
+		aws.String(cloudformation.StackStatusUpdateRollbackComplete),
+		aws.String(cloudformation.StackStatusReviewInProgress),
+	}
+)
+
+var (
+	legacySourceStackNameRE = regexp.MustCompile(LegacySourceStackNamePattern)
+)
+
+// HasStatus checks if stack.StackStatus matches any of statuses.
+func HasStatus(stack cloudformation.Stack, statuses []string) bool {
+	if stack.StackStatus != nil {
+		for _, status := range statuses {
+			if *stack.StackStatus == status {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// SourceStatusRank ranks the status of a source stack, so one of multiple
+// source stacks of the same cluster can be picked. Source stacks in a status
+// records are read in rank highest, deleted or deleting source stacks rank
+// lowest.
+func SourceStatusRank(stack cloudformation.Stack) int {
+	switch {
+	case HasStatus(stack, stackStatusValidSource):
+		return 2
+	case HasStatus(stack, stackStatusValidDelete), HasStatus(stack, stackStatusDeleteInProgress):
+		return 0
+	default:
+		return 1
+	}
+}
+
+// ClusterID returns the cluster ID of a source or target stack, e.g. `foo`
+// for `cluster-foo-tccp`.
+func ClusterID(stackName string) (string, error) {
+	parts := strings.Split(stackName, "-")
+	if len(parts) >= 2 {
+		return parts[1], nil
+	}
+
+	return "", microerror.Maskf(invalidClusterNameError, "cluster name %#q", stackName)
+}
+
+// TargetStackName returns the name of the target stack of the cluster.
+func TargetStackName(clusterID string) string {
+	targetStackNameFmt := strings.Replace(TargetStackNamePattern, ".*", "%s", 1)
+
+	return fmt.Sprintf(targetStackNameFmt, clusterID)
+}
+
+// isLegacy checks if the source stack belongs to a legacy cluster.
+func isLegacy(stack cloudformation.Stack) bool {
+	return legacySourceStackNameRE.MatchString(*stack.StackName)
+}
+
+// tooYoung checks if the source stack reached its current status less than
+// minStackAge before now. Stacks without any timestamp are never too young.
+func tooYoung(stack cloudformation.Stack, now time.Time, minStackAge time.Duration) bool {
+	if minStackAge == 0 {
+		return false
+	}
+
+	t := stack.LastUpdatedTime
+	if t == nil {
+		t = stack.CreationTime
+	}
+	if t == nil {
+		return false
+	}
+
+	return now.Sub(*t) < minStackAge
+}
+
+// deletionTriggerTooYoung checks if the source stack reached its delete
+// trigger status less than minStackAge before now. The deletion time is used
+// when set. DELETE_COMPLETE is final, so it is never too young.
+func deletionTriggerTooYoung(stack cloudformation.Stack, now time.Time, minStackAge time.Duration) bool {
+	if HasStatus(stack, stackStatusValidDelete) {
+		return false
+	}
+	if stack.DeletionTime != nil {
+		return minStackAge > 0 && now.Sub(*stack.DeletionTime) < minStackAge
+	}
+
+	return tooYoung(stack, now, minStackAge)
+}
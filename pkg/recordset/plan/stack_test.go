@@ -0,0 +1,61 @@
+package plan
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+)
+
+func TestHasStatus(t *testing.T) {
+	tcs := []struct {
+		name     string
+		input    cloudformation.Stack
+		statuses []string
+		expected bool
+	}{
+		{
+			name:     "case 0: zero value inputs",
+			input:    cloudformation.Stack{},
+			statuses: nil,
+			expected: false,
+		},
+		{
+			name: "case 1: empty statuses",
+			input: cloudformation.Stack{
+				StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+			},
+			statuses: []string{},
+			expected: false,
+		},
+		{
+			name: "case 2: non matching statuses",
+			input: cloudformation.Stack{
+				StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+			},
+			statuses: []string{
+				cloudformation.StackStatusDeleteComplete,
+			},
+			expected: false,
+		},
+		{
+			name: "case 3: one matching status",
+			input: cloudformation.Stack{
+				StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+			},
+			statuses: []string{
+				cloudformation.StackStatusCreateComplete,
+			},
+			expected: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			output := HasStatus(tc.input, tc.statuses)
+			if tc.expected != output {
+				t.Errorf("expected %v, got %v", tc.expected, output)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package recordset
+
+import (
+	"fmt"
+
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/route53-manager/pkg/recordset/plan"
+)
+
+// writePlanOutput writes the report of the given plan to m.planOutputFile,
+// if any.
+func (m *Manager) writePlanOutput(p plan.Plan) error {
+	if m.planOutputFile == "" {
+		return nil
+	}
+
+	b, err := plan.MarshalReport(plan.NewReport(p))
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = writeFileAtomic(m.planOutputFile, b)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	m.logger.Log("level", "debug", "message", fmt.Sprintf("wrote plan to %#q", m.planOutputFile))
+
+	return nil
+}
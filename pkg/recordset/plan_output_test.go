@@ -0,0 +1,132 @@
+package recordset
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+
+	"github.com/giantswarm/route53-manager/pkg/recordset/plan"
+)
+
+func TestSync_PlanOutputFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "route53-manager")
+	if err != nil {
+		t.Fatalf("ioutil.TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	planOutputFile := filepath.Join(dir, "plan.json")
+
+	tags := []*cloudformation.Tag{
+		{
+			Key:   aws.String(installationTag),
+			Value: aws.String("installation"),
+		},
+	}
+	targetClient := newTargetWithStacks([]cloudformation.Stack{
+		{
+			StackName:   aws.String("cluster-bar-guest-recordsets"),
+			StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+			Tags:        tags,
+		},
+	})
+
+	c := newTestConfig(t)
+	c.SourceClient = newSourceWithStacks([]cloudformation.Stack{
+		{
+			StackName:   aws.String("cluster-foo-tccp"),
+			StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+			Tags:        tags,
+		},
+	})
+	c.TargetClient = targetClient
+	c.PlanOutputFile = planOutputFile
+	m, err := NewManager(c)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	err = m.Sync()
+	if err != nil {
+		t.Fatalf("m.Sync: %v", err)
+	}
+
+	b, err := ioutil.ReadFile(planOutputFile)
+	if err != nil {
+		t.Fatalf("ioutil.ReadFile: %v", err)
+	}
+	r, err := plan.UnmarshalReport(b)
+	if err != nil {
+		t.Fatalf("plan.UnmarshalReport: %v", err)
+	}
+
+	if len(r.Creates) != 1 || r.Creates[0].TargetStackName != "cluster-foo-guest-recordsets" || r.Creates[0].SourceStackName != "cluster-foo-tccp" {
+		t.Errorf("expected create of `cluster-foo-guest-recordsets`, got %+v", r.Creates)
+	}
+	if len(r.Updates) != 0 {
+		t.Errorf("expected no updates, got %+v", r.Updates)
+	}
+	if len(r.Deletes) != 1 || r.Deletes[0].TargetStackName != "cluster-bar-guest-recordsets" || r.Deletes[0].ClusterID != "bar" {
+		t.Errorf("expected delete of `cluster-bar-guest-recordsets`, got %+v", r.Deletes)
+	}
+}
+
+func TestSync_PlanOutputFile_RecordLimit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "route53-manager")
+	if err != nil {
+		t.Fatalf("ioutil.TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	planOutputFile := filepath.Join(dir, "plan.json")
+
+	targetClient := newTargetWithStacks(nil)
+	targetClient.recordSetLimits = map[string]int64{"zoneID": 10}
+
+	c := newTestConfig(t)
+	c.SourceClient = newSourceWithStacks([]cloudformation.Stack{
+		{
+			StackName:   aws.String("cluster-foo-tccp"),
+			StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+			Tags: []*cloudformation.Tag{
+				{
+					Key:   aws.String(installationTag),
+					Value: aws.String("installation"),
+				},
+			},
+		},
+	})
+	c.TargetClient = targetClient
+	c.PlanOutputFile = planOutputFile
+	c.RecordLimitMargin = 100
+	m, err := NewManager(c)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	err = m.Sync()
+	if err != nil {
+		t.Fatalf("m.Sync: %v", err)
+	}
+
+	b, err := ioutil.ReadFile(planOutputFile)
+	if err != nil {
+		t.Fatalf("ioutil.ReadFile: %v", err)
+	}
+	r, err := plan.UnmarshalReport(b)
+	if err != nil {
+		t.Fatalf("plan.UnmarshalReport: %v", err)
+	}
+
+	if len(r.Creates) != 0 {
+		t.Errorf("expected no creates, got %+v", r.Creates)
+	}
+	if len(r.Skips) != 1 || r.Skips[0].StackName != "cluster-foo-guest-recordsets" || r.Skips[0].Reason != SkipReasonRecordLimit {
+		t.Errorf("expected record limit skip of `cluster-foo-guest-recordsets`, got %+v", r.Skips)
+	}
+	if len(targetClient.createdStacks) != 0 {
+		t.Errorf("expected no created stacks, got %d", len(targetClient.createdStacks))
+	}
+}
@@ -0,0 +1,42 @@
+package recordset
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+)
+
+// applyPreservedTags keeps the preserved tags of the target stack in its
+// update, replacing source stack tags of the same key, so tags added to the
+// target stack out-of-band are not removed by the update. Preserved tags the
+// target stack does not carry are left to the source stack.
+func (m *Manager) applyPreservedTags(input *cloudformation.UpdateStackInput, targetStack cloudformation.Stack) {
+	if len(m.preserveTargetTags) == 0 {
+		return
+	}
+
+	preserved := map[string]*cloudformation.Tag{}
+	for _, t := range targetStack.Tags {
+		if stringInSlice(aws.StringValue(t.Key), m.preserveTargetTags) {
+			preserved[aws.StringValue(t.Key)] = t
+		}
+	}
+	if len(preserved) == 0 {
+		return
+	}
+
+	var tags []*cloudformation.Tag
+	for _, t := range input.Tags {
+		if _, ok := preserved[aws.StringValue(t.Key)]; ok {
+			continue
+		}
+		tags = append(tags, t)
+	}
+	for _, k := range m.preserveTargetTags {
+		if t, ok := preserved[k]; ok {
+			tags = append(tags, t)
+			delete(preserved, k)
+		}
+	}
+
+	input.Tags = tags
+}
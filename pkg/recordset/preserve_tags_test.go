@@ -0,0 +1,145 @@
+package recordset
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+)
+
+func TestSync_PreserveTargetTags(t *testing.T) {
+	tag := func(key, value string) *cloudformation.Tag {
+		return &cloudformation.Tag{
+			Key:   aws.String(key),
+			Value: aws.String(value),
+		}
+	}
+
+	tcs := []struct {
+		name               string
+		preserveTargetTags []string
+		sourceTags         []*cloudformation.Tag
+		expectedTags       map[string]string
+	}{
+		{
+			name: "case 0: target stack tags are replaced by default",
+			sourceTags: []*cloudformation.Tag{
+				tag(installationTag, "installation"),
+			},
+			expectedTags: map[string]string{
+				installationTag: "installation",
+				managedByTag:    managedByValue,
+			},
+		},
+		{
+			name:               "case 1: preserved tag survives the update",
+			preserveTargetTags: []string{"cost-center"},
+			sourceTags: []*cloudformation.Tag{
+				tag(installationTag, "installation"),
+			},
+			expectedTags: map[string]string{
+				installationTag: "installation",
+				managedByTag:    managedByValue,
+				"cost-center":   "ops",
+			},
+		},
+		{
+			name:               "case 2: preserved tag takes precedence over the source stack tag",
+			preserveTargetTags: []string{"cost-center", "team"},
+			sourceTags: []*cloudformation.Tag{
+				tag(installationTag, "installation"),
+				tag("cost-center", "dev"),
+				tag("team", "rocket"),
+			},
+			expectedTags: map[string]string{
+				installationTag: "installation",
+				managedByTag:    managedByValue,
+				"cost-center":   "ops",
+				"team":          "rocket",
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			sourceClient := newSourceWithStacks([]cloudformation.Stack{
+				{
+					StackName:   aws.String("cluster-foo-tccp"),
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+					Tags:        tc.sourceTags,
+				},
+			})
+			targetClient := newTargetWithStacks([]cloudformation.Stack{
+				{
+					StackName:   aws.String("cluster-foo-guest-recordsets"),
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+					Tags: []*cloudformation.Tag{
+						tag(installationTag, "installation"),
+						tag(managedByTag, managedByValue),
+						tag("cost-center", "ops"),
+						tag("owner", "someone"),
+					},
+				},
+			})
+
+			c := newTestConfig(t)
+			c.SourceClient = sourceClient
+			c.TargetClient = targetClient
+			c.PreserveTargetTags = tc.preserveTargetTags
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			err = m.Sync()
+			if err != nil {
+				t.Fatalf("m.Sync: %v", err)
+			}
+
+			if len(targetClient.updateStackInputs) != 1 {
+				t.Fatalf("expected 1 update, got %d", len(targetClient.updateStackInputs))
+			}
+			tags := stackTags(cloudformation.Stack{Tags: targetClient.updateStackInputs[0].Tags})
+			if len(tags) != len(tc.expectedTags) {
+				t.Fatalf("expected tags %v, got %v", tc.expectedTags, tags)
+			}
+			for k, v := range tc.expectedTags {
+				if tags[k] != v {
+					t.Errorf("expected tags %v, got %v", tc.expectedTags, tags)
+				}
+			}
+		})
+	}
+}
+
+func TestNewManager_InvalidPreserveTargetTags(t *testing.T) {
+	tcs := []struct {
+		name string
+		key  string
+	}{
+		{
+			name: "case 0: empty key",
+			key:  "",
+		},
+		{
+			name: "case 1: managed-by tag",
+			key:  managedByTag,
+		},
+		{
+			name: "case 2: version tag",
+			key:  versionTag,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newTestConfig(t)
+			c.PreserveTargetTags = []string{tc.key}
+
+			_, err := NewManager(c)
+			if !IsInvalidConfig(err) {
+				t.Errorf("expected invalid config error, got %v", err)
+			}
+		})
+	}
+}
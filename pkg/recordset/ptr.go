@@ -0,0 +1,57 @@
+package recordset
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+const (
+	reverseZoneSuffix = "in-addr.arpa"
+)
+
+// reverseDNSName returns the PTR record name of the given IPv4 address, e.g.
+// `4.3.2.10.in-addr.arpa` for `10.2.3.4`. It returns false for anything else.
+func reverseDNSName(ip string) (string, bool) {
+	v4 := net.ParseIP(ip).To4()
+	if v4 == nil {
+		return "", false
+	}
+
+	name := fmt.Sprintf("%d.%d.%d.%d.%s", v4[3], v4[2], v4[1], v4[0], reverseZoneSuffix)
+
+	return name, true
+}
+
+// isReverseZoneName returns true for IPv4 reverse zone names with up to three
+// octets, e.g. `10.in-addr.arpa` or `0.10.in-addr.arpa.`.
+func isReverseZoneName(name string) bool {
+	name = strings.TrimSuffix(name, ".")
+	if name == reverseZoneSuffix {
+		return true
+	}
+	if !strings.HasSuffix(name, "."+reverseZoneSuffix) {
+		return false
+	}
+
+	octets := strings.Split(strings.TrimSuffix(name, "."+reverseZoneSuffix), ".")
+	if len(octets) > 3 {
+		return false
+	}
+	for _, o := range octets {
+		if net.ParseIP("0.0.0."+o) == nil || (len(o) > 1 && o[0] == '0') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// inZone returns true when the record name is within the given zone. Both may
+// be fully qualified.
+func inZone(name, zone string) bool {
+	name = strings.TrimSuffix(name, ".")
+	zone = strings.TrimSuffix(zone, ".")
+
+	return name == zone || strings.HasSuffix(name, "."+zone)
+}
@@ -0,0 +1,96 @@
+package recordset
+
+import (
+	"testing"
+)
+
+func TestReverseDNSName(t *testing.T) {
+	tcs := []struct {
+		name         string
+		ip           string
+		expectedName string
+		expectedOK   bool
+	}{
+		{
+			name:         "case 0: IPv4 address",
+			ip:           "10.2.3.4",
+			expectedName: "4.3.2.10.in-addr.arpa",
+			expectedOK:   true,
+		},
+		{
+			name:       "case 1: IPv6 address",
+			ip:         "fd00::1",
+			expectedOK: false,
+		},
+		{
+			name:       "case 2: invalid address",
+			ip:         "foo",
+			expectedOK: false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			name, ok := reverseDNSName(tc.ip)
+			if ok != tc.expectedOK {
+				t.Fatalf("expected ok %t, got %t", tc.expectedOK, ok)
+			}
+			if name != tc.expectedName {
+				t.Errorf("expected name %#q, got %#q", tc.expectedName, name)
+			}
+		})
+	}
+}
+
+func TestIsReverseZoneName(t *testing.T) {
+	tcs := []struct {
+		name     string
+		zoneName string
+		expected bool
+	}{
+		{
+			name:     "case 0: one octet",
+			zoneName: "10.in-addr.arpa",
+			expected: true,
+		},
+		{
+			name:     "case 1: three octets, fully qualified",
+			zoneName: "3.2.10.in-addr.arpa.",
+			expected: true,
+		},
+		{
+			name:     "case 2: four octets",
+			zoneName: "4.3.2.10.in-addr.arpa",
+			expected: false,
+		},
+		{
+			name:     "case 3: octet out of range",
+			zoneName: "300.in-addr.arpa",
+			expected: false,
+		},
+		{
+			name:     "case 4: octet with leading zero",
+			zoneName: "010.in-addr.arpa",
+			expected: false,
+		},
+		{
+			name:     "case 5: forward zone",
+			zoneName: "example.com",
+			expected: false,
+		},
+		{
+			name:     "case 6: empty",
+			zoneName: "",
+			expected: false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			result := isReverseZoneName(tc.zoneName)
+			if result != tc.expected {
+				t.Errorf("expected %t, got %t", tc.expected, result)
+			}
+		})
+	}
+}
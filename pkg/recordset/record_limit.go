@@ -0,0 +1,91 @@
+package recordset
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/route53-manager/pkg/recordset/plan"
+)
+
+const (
+	// SkipReasonRecordLimit is used for target stacks which are not created
+	// because a hosted zone is within recordLimitMargin of its record set
+	// limit.
+	SkipReasonRecordLimit SkipReason = "record_limit"
+)
+
+// filterCreatesNearRecordLimit moves all planned creates to the skips of the
+// plan when a hosted zone records are created in holds fewer than
+// recordLimitMargin record sets below its limit. Updates and deletions are not
+// affected.
+func (m *Manager) filterCreatesNearRecordLimit(p plan.Plan) (plan.Plan, error) {
+	if m.recordLimitMargin == 0 || len(p.Creates) == 0 {
+		return p, nil
+	}
+
+	for _, hostedZoneID := range m.recordHostedZoneIDs() {
+		count, limit, err := m.getRecordSetLimit(hostedZoneID)
+		if err != nil {
+			return plan.Plan{}, microerror.Mask(err)
+		}
+		if limit-count >= int64(m.recordLimitMargin) {
+			continue
+		}
+
+		m.logger.Log("level", "error", "message", fmt.Sprintf("not creating target stacks, hosted zone %#q holds %d record sets, within %d of its limit of %d", hostedZoneID, count, m.recordLimitMargin, limit))
+		for _, ref := range p.Creates {
+			s := plan.Skip{
+				StackName: ref.TargetStackName,
+				Reason:    SkipReasonRecordLimit,
+				Message:   fmt.Sprintf("deferred creation of target stack %#q until hosted zone %#q is below its record set limit", ref.TargetStackName, hostedZoneID),
+			}
+			m.skip(s.StackName, s.Reason, s.Message, nil)
+			p.Skips = append(p.Skips, s)
+		}
+		p.Creates = nil
+
+		return p, nil
+	}
+
+	return p, nil
+}
+
+// recordHostedZoneIDs returns the IDs of the hosted zones records are created
+// in.
+func (m *Manager) recordHostedZoneIDs() []string {
+	hostedZoneIDs := []string{m.targetHostedZoneID}
+	if m.etcdHostedZoneID != m.targetHostedZoneID {
+		hostedZoneIDs = append(hostedZoneIDs, m.etcdHostedZoneID)
+	}
+	for _, id := range m.regionHostedZoneIDs() {
+		if !stringInSlice(id, hostedZoneIDs) {
+			hostedZoneIDs = append(hostedZoneIDs, id)
+		}
+	}
+	if m.reverseHostedZoneID != "" {
+		hostedZoneIDs = append(hostedZoneIDs, m.reverseHostedZoneID)
+	}
+
+	return hostedZoneIDs
+}
+
+// getRecordSetLimit returns the number of record sets in the hosted zone and
+// the maximum number of record sets it may hold.
+func (m *Manager) getRecordSetLimit(hostedZoneID string) (count, limit int64, err error) {
+	input := &route53.GetHostedZoneLimitInput{
+		HostedZoneId: aws.String(hostedZoneID),
+		Type:         aws.String(route53.HostedZoneLimitTypeMaxRrsetsByZone),
+	}
+	output, err := m.targetClient.GetHostedZoneLimitWithContext(m.ctx, input)
+	if err != nil {
+		return 0, 0, microerror.Mask(err)
+	}
+	if output.Limit == nil {
+		return 0, 0, microerror.Maskf(tooFewResultsError, "hosted zone %#q has no record set limit", hostedZoneID)
+	}
+
+	return aws.Int64Value(output.Count), aws.Int64Value(output.Limit.Value), nil
+}
@@ -0,0 +1,114 @@
+package recordset
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+func TestSync_RecordLimitMargin(t *testing.T) {
+	tags := []*cloudformation.Tag{
+		&cloudformation.Tag{
+			Key:   aws.String(installationTag),
+			Value: aws.String("installation"),
+		},
+	}
+	sourceStacks := []cloudformation.Stack{
+		cloudformation.Stack{
+			StackName:   aws.String("cluster-foo-guest-main"),
+			StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+			Tags:        tags,
+		},
+		cloudformation.Stack{
+			StackName:   aws.String("cluster-bar-guest-main"),
+			StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+			Tags:        tags,
+		},
+	}
+	targetStacks := []cloudformation.Stack{
+		cloudformation.Stack{
+			StackName:   aws.String("cluster-bar-guest-recordsets"),
+			StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+			Tags:        tags,
+		},
+		cloudformation.Stack{
+			StackName:   aws.String("cluster-baz-guest-recordsets"),
+			StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+			Tags:        tags,
+		},
+	}
+
+	var recordSets []*route53.ResourceRecordSet
+	for i := 0; i < 95; i++ {
+		recordSets = append(recordSets, &route53.ResourceRecordSet{
+			Name: aws.String("r" + strconv.Itoa(i) + ".zonename."),
+			Type: aws.String(route53.RRTypeA),
+		})
+	}
+
+	tcs := []struct {
+		name              string
+		recordLimitMargin int
+		expectedCreated   []string
+	}{
+		{
+			name:              "case 0: check disabled",
+			recordLimitMargin: 0,
+			expectedCreated:   []string{"cluster-foo-guest-recordsets"},
+		},
+		{
+			name:              "case 1: hosted zone below the margin",
+			recordLimitMargin: 5,
+			expectedCreated:   []string{"cluster-foo-guest-recordsets"},
+		},
+		{
+			name:              "case 2: hosted zone within the margin blocks creates",
+			recordLimitMargin: 10,
+			expectedCreated:   nil,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			targetClient := newTargetWithStacks(targetStacks)
+			targetClient.recordSets = map[string][]*route53.ResourceRecordSet{
+				"zoneID": recordSets,
+			}
+			targetClient.recordSetLimits = map[string]int64{
+				"zoneID": 100,
+			}
+
+			c := newTestConfig(t)
+			c.SourceClient = newSourceWithStacks(sourceStacks)
+			c.TargetClient = targetClient
+			c.RecordLimitMargin = tc.recordLimitMargin
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			err = m.Sync()
+			if err != nil {
+				t.Fatalf("m.Sync: %v", err)
+			}
+
+			if !reflect.DeepEqual(targetClient.createdStacks, tc.expectedCreated) {
+				t.Errorf("expected created stacks %v, got %v", tc.expectedCreated, targetClient.createdStacks)
+			}
+			if !reflect.DeepEqual(targetClient.updatedStacks, []string{"cluster-bar-guest-recordsets"}) {
+				t.Errorf("expected updated stacks [cluster-bar-guest-recordsets], got %v", targetClient.updatedStacks)
+			}
+			if !reflect.DeepEqual(targetClient.deletedStacks, []string{"cluster-baz-guest-recordsets"}) {
+				t.Errorf("expected deleted stacks [cluster-baz-guest-recordsets], got %v", targetClient.deletedStacks)
+			}
+			skipped := m.summary.skipped[SkipReasonRecordLimit]["cluster-foo-guest-recordsets"]
+			if skipped != (tc.expectedCreated == nil) {
+				t.Errorf("expected record limit skip %t, got %v", tc.expectedCreated == nil, m.summary.skipped)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package recordset
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/route53-manager/pkg/client"
+	"github.com/giantswarm/route53-manager/pkg/key"
+)
+
+// listRecordSets returns the record sets of the hosted zone across all pages
+// of the ListResourceRecordSets output.
+func listRecordSets(ctx context.Context, cl client.TargetInterface, hostedZoneID string) ([]*route53.ResourceRecordSet, error) {
+	var result []*route53.ResourceRecordSet
+
+	input := &route53.ListResourceRecordSetsInput{
+		HostedZoneId: aws.String(hostedZoneID),
+	}
+	for {
+		output, err := cl.ListResourceRecordSetsWithContext(ctx, input)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		result = append(result, output.ResourceRecordSets...)
+
+		if !aws.BoolValue(output.IsTruncated) {
+			break
+		}
+		input.StartRecordName = output.NextRecordName
+		input.StartRecordType = output.NextRecordType
+		input.StartRecordIdentifier = output.NextRecordIdentifier
+	}
+
+	return result, nil
+}
+
+// getRecordSet returns the record set of the hosted zone with the given name
+// and type, or nil when there is none. Only the first page of record sets
+// starting at the name is read.
+func getRecordSet(ctx context.Context, cl client.TargetInterface, hostedZoneID, name, recordType string) (*route53.ResourceRecordSet, error) {
+	input := &route53.ListResourceRecordSetsInput{
+		HostedZoneId:    aws.String(hostedZoneID),
+		StartRecordName: aws.String(name),
+		StartRecordType: aws.String(recordType),
+	}
+	output, err := cl.ListResourceRecordSetsWithContext(ctx, input)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	for _, rr := range output.ResourceRecordSets {
+		if normalizeRecordName(aws.StringValue(rr.Name)) == normalizeRecordName(name) && aws.StringValue(rr.Type) == recordType {
+			return rr, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// route53RecordName returns the record name the way it is sent to Route53,
+// i.e. fully qualified with the `*` wildcard escaped as `\052`.
+func route53RecordName(name string) string {
+	if strings.HasPrefix(name, "*.") {
+		name = "\\052" + strings.TrimPrefix(name, "*")
+	}
+	if !strings.HasSuffix(name, ".") {
+		name += "."
+	}
+
+	return name
+}
+
+// normalizeRecordName returns the record name in the form record names are
+// compared in, i.e. the way Route53 lists it, lowercased, fully qualified and
+// with the `*` wildcard escaped as `\052`. Generated names and names of listed
+// record sets both go through it before being compared, so mixed-case
+// cluster IDs or domains still match.
+func normalizeRecordName(name string) string {
+	return strings.ToLower(route53RecordName(name))
+}
+
+// leftoverRecordSetRE returns the regular expression matching the names of
+// the record sets below the domain of the cluster in the hosted zone, as
+// returned by normalizeRecordName, e.g. `x.foo.zonename.` and
+// `\052.foo.zonename.` for the cluster `foo`. The domain is matched
+// literally, so neither the cluster domain itself nor the domains of other
+// clusters sharing its suffix, e.g. `foo.zonenamebaz.`, match.
+func leftoverRecordSetRE(clusterName, hostedZoneName string) (*regexp.Regexp, error) {
+	pattern := fmt.Sprintf(`^.+\.%s$`, regexp.QuoteMeta(normalizeRecordName(key.BaseDomain(clusterName, hostedZoneName))))
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return re, nil
+}
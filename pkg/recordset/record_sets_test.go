@@ -0,0 +1,176 @@
+package recordset
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+func TestListRecordSets(t *testing.T) {
+	var recordSets []*route53.ResourceRecordSet
+	for _, name := range []string{"a.zoneName.", "b.zoneName.", "c.zoneName.", "d.zoneName.", "e.zoneName."} {
+		recordSets = append(recordSets, &route53.ResourceRecordSet{
+			Name: aws.String(name),
+			Type: aws.String(route53.RRTypeA),
+		})
+	}
+
+	targetClient := newTargetWithStacks(nil)
+	targetClient.recordSets = map[string][]*route53.ResourceRecordSet{
+		"zoneID": recordSets,
+	}
+	targetClient.recordSetsPageSize = 2
+
+	result, err := listRecordSets(context.Background(), targetClient, "zoneID")
+	if err != nil {
+		t.Fatalf("listRecordSets: %v", err)
+	}
+
+	if !reflect.DeepEqual(recordSets, result) {
+		t.Errorf("expected record sets %v, got %v", recordSets, result)
+	}
+	if len(targetClient.calls) != 3 {
+		t.Errorf("expected 3 ListResourceRecordSets calls, got %v", targetClient.calls)
+	}
+}
+
+func TestGetRecordSet(t *testing.T) {
+	var recordSets []*route53.ResourceRecordSet
+	for _, name := range []string{"a.zoneName.", "b.zoneName.", "c.zoneName."} {
+		recordSets = append(recordSets, &route53.ResourceRecordSet{
+			Name: aws.String(name),
+			Type: aws.String(route53.RRTypeA),
+		})
+	}
+
+	targetClient := newTargetWithStacks(nil)
+	targetClient.recordSets = map[string][]*route53.ResourceRecordSet{
+		"zoneID": recordSets,
+	}
+	targetClient.recordSetsPageSize = 2
+
+	result, err := getRecordSet(context.Background(), targetClient, "zoneID", "b.zoneName.", route53.RRTypeA)
+	if err != nil {
+		t.Fatalf("getRecordSet: %v", err)
+	}
+	if result != recordSets[1] {
+		t.Errorf("expected record set %v, got %v", recordSets[1], result)
+	}
+
+	result, err = getRecordSet(context.Background(), targetClient, "zoneID", "b.zoneName.", route53.RRTypeTxt)
+	if err != nil {
+		t.Fatalf("getRecordSet: %v", err)
+	}
+	if result != nil {
+		t.Errorf("expected no record set, got %v", result)
+	}
+}
+
+func TestNormalizeRecordName(t *testing.T) {
+	tcs := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "case 0: lowercase name gets a trailing dot",
+			input:    "api.foo.zonename",
+			expected: "api.foo.zonename.",
+		},
+		{
+			name:     "case 1: mixed-case cluster ID and domain are lowercased",
+			input:    "api.Foo.ZoneName.",
+			expected: "api.foo.zonename.",
+		},
+		{
+			name:     "case 2: wildcard is escaped",
+			input:    "*.Foo.ZoneName",
+			expected: "\\052.foo.zonename.",
+		},
+		{
+			name:     "case 3: escaped wildcard is kept",
+			input:    "\\052.foo.zonename.",
+			expected: "\\052.foo.zonename.",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			result := normalizeRecordName(tc.input)
+			if result != tc.expected {
+				t.Errorf("expected %#q, got %#q", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestLeftoverRecordSetRE(t *testing.T) {
+	tcs := []struct {
+		name     string
+		input    string
+		expected bool
+	}{
+		{
+			name:     "case 0: record below the cluster domain",
+			input:    "x.foo.bar.",
+			expected: true,
+		},
+		{
+			name:     "case 1: wildcard record",
+			input:    "*.foo.bar.",
+			expected: true,
+		},
+		{
+			name:     "case 2: nested record below the cluster domain",
+			input:    "x.y.foo.bar.",
+			expected: true,
+		},
+		{
+			name:     "case 3: mixed-case record",
+			input:    "X.Foo.Bar",
+			expected: true,
+		},
+		{
+			name:     "case 4: cluster domain",
+			input:    "foo.bar.",
+			expected: false,
+		},
+		{
+			name:     "case 5: zone sharing the suffix",
+			input:    "foo.barbaz.",
+			expected: false,
+		},
+		{
+			name:     "case 6: cluster sharing the suffix",
+			input:    "x.afoo.bar.",
+			expected: false,
+		},
+		{
+			name:     "case 7: dots are no wildcards",
+			input:    "x.fooxbar.",
+			expected: false,
+		},
+		{
+			name:     "case 8: other cluster",
+			input:    "x.baz.bar.",
+			expected: false,
+		},
+	}
+
+	re, err := leftoverRecordSetRE("foo", "bar")
+	if err != nil {
+		t.Fatalf("leftoverRecordSetRE: %v", err)
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			result := re.MatchString(normalizeRecordName(tc.input))
+			if result != tc.expected {
+				t.Errorf("expected %#q to match %t, got %t", tc.input, tc.expected, result)
+			}
+		})
+	}
+}
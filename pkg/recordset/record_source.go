@@ -0,0 +1,307 @@
+package recordset
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/route53-manager/pkg/key"
+	"github.com/giantswarm/route53-manager/pkg/recordset/plan"
+)
+
+var (
+	// resourceNameRE matches valid CloudFormation logical IDs.
+	resourceNameRE = regexp.MustCompile("^[A-Za-z0-9]+$")
+)
+
+// Cluster identifies the tenant cluster records are computed for.
+type Cluster struct {
+	// ID is the cluster ID, e.g. `foo` for the source stack `cluster-foo-tccp`.
+	ID string
+	// IsLegacy is true for clusters below Giant Swarm Release version 10.0.0,
+	// aka non Node Pool clusters.
+	IsLegacy bool
+	// SourceAccount is the index of the source account the source stack was
+	// found in, 0 for Config.SourceClient and i+1 for
+	// Config.AdditionalSourceClients[i].
+	SourceAccount int
+	// CreationTime is the creation time of the source stack.
+	CreationTime time.Time
+}
+
+// DesiredRecord is a record set the target stack of a cluster must contain.
+type DesiredRecord struct {
+	// ResourceName is the CloudFormation logical ID of the record set. It must
+	// be alphanumeric and unique within the cluster.
+	ResourceName string
+	// Name is the fully qualified record name, e.g. `api.foo.example.com`.
+	Name string
+	// Type is the record type, e.g. route53.RRTypeCname.
+	Type string
+	// Values are the resource records, e.g. the DNS name a CNAME points to.
+	// They must be empty for alias records.
+	Values []string
+	// AliasTarget, when set, makes the record an alias record pointing at the
+	// given AWS resource, e.g. an ELB.
+	AliasTarget *AliasTarget
+	// HostedZoneID is the hosted zone the record set is created in. Defaults
+	// to the target hosted zone.
+	HostedZoneID string
+	// Weight, when set, makes the record a weighted record set with the
+	// given SetIdentifier, e.g. to shift traffic between installations
+	// sharing the record name. Weighted records are not subject to failover
+	// routing.
+	Weight        *int64
+	SetIdentifier string
+	// TTL is the TTL in seconds of a non-alias record. Defaults to 30
+	// seconds when zero.
+	TTL int64
+}
+
+// AliasTarget is the AWS resource an alias record points at.
+type AliasTarget struct {
+	// HostedZoneID is the canonical hosted zone ID of the resource, e.g. the
+	// CanonicalHostedZoneNameID of an ELB.
+	HostedZoneID string
+	// DNSName is the DNS name of the resource.
+	DNSName string
+}
+
+// RecordSource computes the records the target stack of a cluster must
+// contain. It decouples record discovery from the sync engine, so records can
+// be computed from e.g. service discovery or configuration instead of the
+// source account resources.
+type RecordSource interface {
+	Records(ctx context.Context, cluster Cluster) ([]DesiredRecord, error)
+}
+
+// stackRecordSource is the default RecordSource. It computes the records
+// from the component ELBs and etcd ENIs of a cluster in the source account.
+type stackRecordSource struct {
+	manager *Manager
+}
+
+func (s *stackRecordSource) Records(ctx context.Context, cluster Cluster) ([]DesiredRecord, error) {
+	data, err := s.manager.getSourceStackData(cluster)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return data.desiredRecords(), nil
+}
+
+// newCluster returns the cluster the planned operation acts on. The source
+// stack is remembered for the tag and output lookups of the cluster.
+func (m *Manager) newCluster(ref plan.ClusterRef) Cluster {
+	c := Cluster{
+		ID:            ref.ID,
+		IsLegacy:      ref.IsLegacy,
+		SourceAccount: m.sourceAccounts[ref.ID],
+		CreationTime:  aws.TimeValue(ref.SourceStack.CreationTime),
+	}
+	m.clusterSourceStacks[ref.ID] = *ref.SourceStack
+
+	return c
+}
+
+// clusterOutputs returns the outputs of the source stack of the cluster by
+// output key.
+func (m *Manager) clusterOutputs(cluster Cluster) map[string]string {
+	return stackOutputs(m.clusterSourceStacks[cluster.ID])
+}
+
+// clusterTags returns the tags of the source stack of the cluster by key.
+func (m *Manager) clusterTags(cluster Cluster) map[string]string {
+	return stackTags(m.clusterSourceStacks[cluster.ID])
+}
+
+// stackOutputs returns the outputs of the stack by output key.
+func stackOutputs(stack cloudformation.Stack) map[string]string {
+	outputs := map[string]string{}
+	for _, o := range stack.Outputs {
+		if o.OutputKey == nil || o.OutputValue == nil {
+			continue
+		}
+		outputs[*o.OutputKey] = *o.OutputValue
+	}
+
+	return outputs
+}
+
+// stackTags returns the tags of the stack by key.
+func stackTags(stack cloudformation.Stack) map[string]string {
+	tags := map[string]string{}
+	for _, t := range stack.Tags {
+		if t.Key == nil || t.Value == nil {
+			continue
+		}
+		tags[*t.Key] = *t.Value
+	}
+
+	return tags
+}
+
+// desiredRecords returns the records described by the source stack data.
+// The etcd records are created in the etcd hosted zone.
+func (d *sourceStackData) desiredRecords() []DesiredRecord {
+	baseDomain := key.BaseDomain(d.ClusterName, d.HostedZoneName)
+	etcdBaseDomain := key.BaseDomain(d.ClusterName, d.EtcdHostedZoneName)
+
+	wildcard := DesiredRecord{
+		ResourceName: "ingressWildcardDNSRecord",
+		Name:         "*." + baseDomain,
+		Type:         route53.RRTypeCname,
+		Values:       []string{"ingress." + baseDomain},
+		HostedZoneID: d.HostedZoneID,
+	}
+	if d.IngressAliasTarget != nil {
+		wildcard.Type = route53.RRTypeA
+		wildcard.Values = nil
+		wildcard.AliasTarget = d.IngressAliasTarget
+	}
+
+	var records []DesiredRecord
+	if !d.OmitIngressRecords {
+		records = append(records, wildcard)
+	}
+	if d.BareDomainRecord && !d.OmitIngressRecords {
+		bare := wildcard
+		bare.ResourceName = "ingressBareDNSRecord"
+		bare.Name = baseDomain
+		records = append(records, bare)
+	}
+	for _, r := range d.ComponentRecords {
+		record := DesiredRecord{
+			ResourceName: r.ResourceName,
+			Name:         r.Name + "." + baseDomain,
+			Type:         route53.RRTypeCname,
+			Values:       []string{r.ELBDNS},
+			HostedZoneID: d.HostedZoneID,
+		}
+		if r.Name == etcdComponentName {
+			record.Name = r.Name + "." + etcdBaseDomain
+			record.HostedZoneID = d.EtcdHostedZoneID
+		}
+		if r.Name == apiComponentName && d.APIAliasTarget != nil {
+			record.Type = route53.RRTypeA
+			record.Values = nil
+			record.AliasTarget = d.APIAliasTarget
+		}
+		if w, ok := d.Weights[r.Name]; ok {
+			record.Weight = aws.Int64(w)
+			record.SetIdentifier = d.SetIdentifier
+		}
+		records = append(records, record)
+	}
+	if d.CAAValue != "" {
+		records = append(records, DesiredRecord{
+			ResourceName: "caaDNSRecord",
+			Name:         baseDomain,
+			Type:         route53.RRTypeCaa,
+			Values:       []string{d.CAAValue},
+			HostedZoneID: d.HostedZoneID,
+		})
+	}
+	if d.MetadataValue != "" {
+		records = append(records, DesiredRecord{
+			ResourceName: "metadataDNSRecord",
+			Name:         metadataRecordPrefix + baseDomain,
+			Type:         route53.RRTypeTxt,
+			Values:       []string{d.MetadataValue},
+			HostedZoneID: d.HostedZoneID,
+		})
+	}
+	for _, e := range d.EtcdEniList {
+		records = append(records, DesiredRecord{
+			ResourceName: e.Name,
+			Name:         e.DNSName,
+			Type:         route53.RRTypeA,
+			Values:       []string{e.IPAddress},
+			HostedZoneID: d.EtcdHostedZoneID,
+		})
+	}
+	if d.ReverseHostedZoneID != "" {
+		records = append(records, d.ptrRecords()...)
+	}
+	if d.TTL > 0 {
+		for i := range records {
+			if records[i].AliasTarget == nil {
+				records[i].TTL = d.TTL
+			}
+		}
+	}
+
+	return records
+}
+
+// ptrRecords returns a PTR record for the private IP of every etcd ENI in the
+// reverse hosted zone. The `etcd0` record shares its IP with `etcd1`, so only
+// the first etcd DNS name of each IP gets a PTR record.
+func (d *sourceStackData) ptrRecords() []DesiredRecord {
+	var records []DesiredRecord
+	seen := map[string]bool{}
+	for _, e := range d.EtcdEniList {
+		name, ok := reverseDNSName(e.IPAddress)
+		if !ok || !inZone(name, d.ReverseHostedZoneName) || seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		records = append(records, DesiredRecord{
+			ResourceName: e.Name + "PTR",
+			Name:         name,
+			Type:         route53.RRTypePtr,
+			Values:       []string{e.DNSName},
+			HostedZoneID: d.ReverseHostedZoneID,
+		})
+	}
+
+	return records
+}
+
+// validateRecords ensures records returned by a RecordSource can be rendered
+// into a target stack template.
+func validateRecords(records []DesiredRecord) error {
+	if len(records) == 0 {
+		return microerror.Maskf(invalidRecordError, "records must not be empty")
+	}
+
+	names := map[string]bool{}
+	for _, r := range records {
+		if !resourceNameRE.MatchString(r.ResourceName) {
+			return microerror.Maskf(invalidRecordError, "resource name %#q must match %#q", r.ResourceName, resourceNameRE.String())
+		}
+		if names[r.ResourceName] {
+			return microerror.Maskf(invalidRecordError, "resource name %#q must not be used twice", r.ResourceName)
+		}
+		if r.Name == "" {
+			return microerror.Maskf(invalidRecordError, "record %#q name must not be empty", r.ResourceName)
+		}
+		if r.Type == "" {
+			return microerror.Maskf(invalidRecordError, "record %#q type must not be empty", r.ResourceName)
+		}
+		if r.AliasTarget == nil && len(r.Values) == 0 {
+			return microerror.Maskf(invalidRecordError, "record %#q values must not be empty", r.ResourceName)
+		}
+		if r.AliasTarget != nil && len(r.Values) != 0 {
+			return microerror.Maskf(invalidRecordError, "record %#q values must be empty for alias records", r.ResourceName)
+		}
+		if r.AliasTarget != nil && (r.AliasTarget.HostedZoneID == "" || r.AliasTarget.DNSName == "") {
+			return microerror.Maskf(invalidRecordError, "record %#q alias target must not be empty", r.ResourceName)
+		}
+		if r.TTL < 0 || r.TTL > maxRecordTTL || (r.AliasTarget != nil && r.TTL != 0) {
+			return microerror.Maskf(invalidRecordError, "record %#q TTL must be between 0 and %d and 0 for alias records, got %d", r.ResourceName, maxRecordTTL, r.TTL)
+		}
+		if (r.Weight != nil) != (r.SetIdentifier != "") {
+			return microerror.Maskf(invalidRecordError, "record %#q weight and set identifier must be set together", r.ResourceName)
+		}
+		names[r.ResourceName] = true
+	}
+
+	return nil
+}
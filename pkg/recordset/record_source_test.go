@@ -0,0 +1,269 @@
+package recordset
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+)
+
+type recordSourceMock struct {
+	records map[string][]DesiredRecord
+	err     error
+
+	clusters []Cluster
+}
+
+func (r *recordSourceMock) Records(ctx context.Context, cluster Cluster) ([]DesiredRecord, error) {
+	r.clusters = append(r.clusters, cluster)
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	return r.records[cluster.ID], nil
+}
+
+func TestSync_CustomRecordSource(t *testing.T) {
+	tcs := []struct {
+		name                  string
+		recordSource          *recordSourceMock
+		expectedCreatedStacks []string
+		expectedResources     map[string]string
+	}{
+		{
+			name: "case 0: create stack with custom records",
+			recordSource: &recordSourceMock{
+				records: map[string][]DesiredRecord{
+					"foo": {
+						{
+							ResourceName: "vaultDNSRecord",
+							Name:         "vault.foo.zoneName",
+							Type:         "CNAME",
+							Values:       []string{"vault.internal"},
+						},
+						{
+							ResourceName: "gatewayDNSRecord",
+							Name:         "gateway.foo.zoneName",
+							Type:         "A",
+							Values:       []string{"10.0.0.1", "10.0.0.2"},
+						},
+					},
+				},
+			},
+			expectedCreatedStacks: []string{"cluster-foo-guest-recordsets"},
+			expectedResources: map[string]string{
+				"vaultDNSRecord":   "vault.foo.zoneName",
+				"gatewayDNSRecord": "gateway.foo.zoneName",
+			},
+		},
+		{
+			name: "case 1: skip cluster when record source fails",
+			recordSource: &recordSourceMock{
+				err: mockClientError,
+			},
+			expectedCreatedStacks: nil,
+		},
+		{
+			name: "case 2: skip cluster without records",
+			recordSource: &recordSourceMock{
+				records: map[string][]DesiredRecord{},
+			},
+			expectedCreatedStacks: nil,
+		},
+		{
+			name: "case 3: skip cluster with invalid resource name",
+			recordSource: &recordSourceMock{
+				records: map[string][]DesiredRecord{
+					"foo": {
+						{
+							ResourceName: "vault-record",
+							Name:         "vault.foo.zoneName",
+							Type:         "CNAME",
+							Values:       []string{"vault.internal"},
+						},
+					},
+				},
+			},
+			expectedCreatedStacks: nil,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			sourceStacks := []cloudformation.Stack{
+				cloudformation.Stack{
+					StackName:   aws.String("cluster-foo-tccp"),
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+					Tags: []*cloudformation.Tag{
+						&cloudformation.Tag{
+							Key:   aws.String(installationTag),
+							Value: aws.String("installation"),
+						},
+					},
+				},
+			}
+			targetClient := newTargetWithStacks(nil)
+
+			c := newTestConfig(t)
+			c.SourceClient = newSourceWithStacks(sourceStacks)
+			c.TargetClient = targetClient
+			c.TemplateFormat = TemplateFormatJSON
+			c.RecordSource = tc.recordSource
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			err = m.Sync()
+			if err != nil {
+				t.Fatalf("m.Sync: %v", err)
+			}
+
+			if len(tc.recordSource.clusters) != 1 || tc.recordSource.clusters[0] != (Cluster{ID: "foo"}) {
+				t.Errorf("expected records of cluster %v to be requested, got %v", Cluster{ID: "foo"}, tc.recordSource.clusters)
+			}
+			if len(targetClient.createdStacks) != len(tc.expectedCreatedStacks) {
+				t.Fatalf("expected created stacks %v, got %v", tc.expectedCreatedStacks, targetClient.createdStacks)
+			}
+			if len(tc.expectedCreatedStacks) == 0 {
+				return
+			}
+
+			var template stackTemplate
+			err = json.Unmarshal([]byte(*targetClient.createStackInputs[0].TemplateBody), &template)
+			if err != nil {
+				t.Fatalf("json.Unmarshal: %v", err)
+			}
+			if len(template.Resources) != len(tc.expectedResources) {
+				t.Errorf("expected resources %v, got %v", tc.expectedResources, template.Resources)
+			}
+			for resourceName, recordName := range tc.expectedResources {
+				r, ok := template.Resources[resourceName]
+				if !ok {
+					t.Errorf("expected resource %#q, got %v", resourceName, template.Resources)
+					continue
+				}
+				if r.Properties.Name != recordName {
+					t.Errorf("expected resource %#q record name %#q, got %#q", resourceName, recordName, r.Properties.Name)
+				}
+				if r.Properties.HostedZoneID != "zoneID" {
+					t.Errorf("expected resource %#q hosted zone %#q, got %#q", resourceName, "zoneID", r.Properties.HostedZoneID)
+				}
+			}
+		})
+	}
+}
+
+func TestSync_ELBDNSFromOutputs(t *testing.T) {
+	tcs := []struct {
+		name              string
+		elbDNSFromOutputs bool
+		outputKeys        map[string]string
+		expectedValues    map[string]string
+	}{
+		{
+			name:              "case 0: ignore outputs by default",
+			elbDNSFromOutputs: false,
+			expectedValues: map[string]string{
+				"apiDNSRecord":  "elb.dns.test",
+				"etcdDNSRecord": "elb.dns.test",
+			},
+		},
+		{
+			name:              "case 1: read outputs and fall back to ELB lookup",
+			elbDNSFromOutputs: true,
+			expectedValues: map[string]string{
+				"apiDNSRecord":  "api.output.test",
+				"etcdDNSRecord": "elb.dns.test",
+			},
+		},
+		{
+			name:              "case 2: read custom output keys",
+			elbDNSFromOutputs: true,
+			outputKeys: map[string]string{
+				"etcd": "CustomEtcdDNSName",
+			},
+			expectedValues: map[string]string{
+				"apiDNSRecord":  "elb.dns.test",
+				"etcdDNSRecord": "etcd.output.test",
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			sourceStacks := []cloudformation.Stack{
+				cloudformation.Stack{
+					StackName:   aws.String("cluster-foo-tccp"),
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+					Tags: []*cloudformation.Tag{
+						&cloudformation.Tag{
+							Key:   aws.String(installationTag),
+							Value: aws.String("installation"),
+						},
+					},
+					Outputs: []*cloudformation.Output{
+						&cloudformation.Output{
+							OutputKey:   aws.String("APIELBDNSName"),
+							OutputValue: aws.String("api.output.test"),
+						},
+						&cloudformation.Output{
+							OutputKey:   aws.String("CustomEtcdDNSName"),
+							OutputValue: aws.String("etcd.output.test"),
+						},
+					},
+				},
+			}
+			targetClient := newTargetWithStacks(nil)
+
+			c := newTestConfig(t)
+			c.SourceClient = newSourceWithStacks(sourceStacks)
+			c.TargetClient = targetClient
+			c.TemplateFormat = TemplateFormatJSON
+			c.ELBDNSFromOutputs = tc.elbDNSFromOutputs
+			c.ELBDNSOutputKeys = tc.outputKeys
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			err = m.Sync()
+			if err != nil {
+				t.Fatalf("m.Sync: %v", err)
+			}
+
+			if len(targetClient.createStackInputs) != 1 {
+				t.Fatalf("expected 1 created stack, got %v", targetClient.createdStacks)
+			}
+			var template stackTemplate
+			err = json.Unmarshal([]byte(*targetClient.createStackInputs[0].TemplateBody), &template)
+			if err != nil {
+				t.Fatalf("json.Unmarshal: %v", err)
+			}
+			for resourceName, value := range tc.expectedValues {
+				r, ok := template.Resources[resourceName]
+				if !ok {
+					t.Errorf("expected resource %#q, got %v", resourceName, template.Resources)
+					continue
+				}
+				if len(r.Properties.ResourceRecords) != 1 || r.Properties.ResourceRecords[0] != value {
+					t.Errorf("expected resource %#q to point at %#q, got %v", resourceName, value, r.Properties.ResourceRecords)
+				}
+			}
+		})
+	}
+}
+
+func TestNewManager_InvalidELBDNSOutputKeys(t *testing.T) {
+	c := newTestConfig(t)
+	c.ELBDNSOutputKeys = map[string]string{
+		"vault": "VaultELBDNSName",
+	}
+
+	_, err := NewManager(c)
+	if !IsInvalidConfig(err) {
+		t.Errorf("expected invalid config error, got %v", err)
+	}
+}
@@ -1,107 +1,752 @@
 package recordset
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"os"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
 	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/aws/aws-sdk-go/service/route53"
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/micrologger"
 
 	"github.com/giantswarm/route53-manager/pkg/client"
+	"github.com/giantswarm/route53-manager/pkg/logbuffer"
+	"github.com/giantswarm/route53-manager/pkg/recordset/plan"
 )
 
 const (
-	// legacySourceStackNamePattern is the pattern for Cloud Formation Stack names
-	// of Tenant Clusters below Giant Swarm Release version 10.0.0, aka legacy
-	// clusters, aka non Node Pool clusters.
-	legacySourceStackNamePattern = "cluster-.*-guest-main"
-	sourceStackNamePattern       = "cluster-.*-tccp$"
-	targetStackNamePattern       = "cluster-.*-guest-recordsets"
+	legacySourceStackNamePattern = plan.LegacySourceStackNamePattern
+	sourceStackNamePattern       = plan.SourceStackNamePattern
+	targetStackNamePattern       = plan.TargetStackNamePattern
 )
 
 const (
-	installationTag = "giantswarm.io/installation"
+	// DeletionOrderRecordsAfterStack deletes the target stack of an orphan
+	// cluster before its leftover record sets.
+	DeletionOrderRecordsAfterStack = "records-after-stack"
+	// DeletionOrderRecordsFirst deletes the leftover record sets of an orphan
+	// cluster before its target stack.
+	DeletionOrderRecordsFirst = "records-first"
+)
+
+const (
+	installationTag    = "giantswarm.io/installation"
+	templateVersionTag = "giantswarm.io/route53-manager-template-version"
+	versionTag         = "giantswarm.io/route53-manager-version"
 )
 
 var (
-	// Predefined set of cloudformation stack statuses
-	// which allow for valid data to be retrieved from the stack.
-	stackStatusValidSource = []string{
-		cloudformation.StackStatusCreateComplete,
-		cloudformation.StackStatusUpdateComplete,
-	}
-	// Predefined set of cloudformation stack statuses
-	// which allow for write operations to the stack.
-	stackStatusValidTarget = []string{
-		cloudformation.StackStatusCreateComplete,
-		cloudformation.StackStatusCreateFailed,
-		cloudformation.StackStatusDeleteFailed,
-		cloudformation.StackStatusRollbackComplete,
-		cloudformation.StackStatusRollbackFailed,
-		cloudformation.StackStatusUpdateComplete,
-		cloudformation.StackStatusUpdateRollbackComplete,
-		cloudformation.StackStatusUpdateRollbackFailed,
-	}
-	// Predefined set of cloudformation stack statuses
-	// which indicates a stack has been deleted.
-	stackStatusValidDelete = []string{
-		cloudformation.StackStatusDeleteComplete,
-	}
-	// Predefined set of cloudformation stack statuses used to read from AWS API.
-	// Note: this includes all statuses except cloudformation.StackStatusDeleteComplete.
-	stackStatusValid = []*string{
-		aws.String(cloudformation.StackStatusCreateComplete),
-		aws.String(cloudformation.StackStatusCreateInProgress),
-		aws.String(cloudformation.StackStatusCreateFailed),
-		aws.String(cloudformation.StackStatusRollbackInProgress),
-		aws.String(cloudformation.StackStatusRollbackFailed),
-		aws.String(cloudformation.StackStatusRollbackComplete),
-		aws.String(cloudformation.StackStatusDeleteInProgress),
-		aws.String(cloudformation.StackStatusDeleteFailed),
-		aws.String(cloudformation.StackStatusUpdateInProgress),
-		aws.String(cloudformation.StackStatusUpdateCompleteCleanupInProgress),
-		aws.String(cloudformation.StackStatusUpdateComplete),
-		aws.String(cloudformation.StackStatusUpdateRollbackInProgress),
-		aws.String(cloudformation.StackStatusUpdateRollbackFailed),
-		aws.String(cloudformation.StackStatusUpdateRollbackCompleteCleanupInProgress),
-		aws.String(cloudformation.StackStatusUpdateRollbackComplete),
-		aws.String(cloudformation.StackStatusReviewInProgress),
+	// apiComponentName is the name of the component whose record type can be
+	// overridden per cluster by the APIRecordTypeTag.
+	apiComponentName = "api"
+	// ingressComponentName is the name of the component whose ELB the
+	// wildcard record is an alias of.
+	ingressComponentName = "ingress"
+	// etcdComponentName is the name of the component whose record is created
+	// in the etcd hosted zone together with the etcd ENI records.
+	etcdComponentName = "etcd"
+	// DefaultComponents is the set of control plane components every cluster
+	// gets a CNAME record for.
+	DefaultComponents = []Component{
+		{
+			Name:      apiComponentName,
+			ELBSuffix: "-api",
+		},
+		{
+			Name:      etcdComponentName,
+			ELBSuffix: "-etcd",
+		},
+		{
+			Name:       ingressComponentName,
+			ELBSuffix:  "-ingress",
+			LegacyOnly: true,
+		},
+	}
+	// DefaultELBDNSOutputKeys maps the default components to the source stack
+	// outputs publishing the DNS name of their ELB.
+	DefaultELBDNSOutputKeys = map[string]string{
+		apiComponentName:     "APIELBDNSName",
+		etcdComponentName:    "EtcdELBDNSName",
+		ingressComponentName: "IngressELBDNSName",
 	}
 )
 
+var (
+	componentNameRE = regexp.MustCompile("^[a-z][a-z0-9]*$")
+	// caaValueRE matches CAA record values, e.g. `0 issue "letsencrypt.org"`.
+	caaValueRE = regexp.MustCompile(`^[0-9]+ (issue|issuewild|iodef) "[^"]*"$`)
+)
+
 type Config struct {
 	Logger       micrologger.Logger
 	Installation string
 	SourceClient client.SourceInterface
 	TargetClient client.TargetInterface
 
+	// AdditionalSourceClients are the clients of further source accounts
+	// whose clusters are synced into the same target hosted zones. Each
+	// cluster is tagged with the source account its source stack was found
+	// in, see Cluster.SourceAccount. Orphan target stacks are only deleted
+	// when their cluster is absent from all source accounts.
+	AdditionalSourceClients []client.SourceInterface
+	// TargetOnly creates a Manager which only reads the target stacks, as
+	// used by the Verifier. SourceClient and TargetHostedZoneName may be
+	// empty then and Sync fails with invalidConfigError.
+	TargetOnly bool
+
+	// Cluster restricts the sync run to the stacks of the cluster with the
+	// given ID, e.g. `foo`. The stacks are described by name instead of
+	// listing all stacks of the accounts. All clusters are synced when empty.
+	Cluster string
+	// RecreateCluster is the ID of a cluster whose target stack is deleted
+	// together with its leftover record sets and created again from its
+	// source stack, e.g. after a bad manual edit. The run is restricted to
+	// the cluster as with Cluster, which must be empty or equal. The plan is
+	// bypassed, so the target stack is recreated whatever its status, and
+	// creating it waits for the deletion to complete. The records are
+	// rendered before the target stack is deleted, so nothing is deleted
+	// when they cannot be computed. Paused clusters are skipped, and the run
+	// fails when the target stack cannot be created again. Only supported in
+	// ApplyModeCloudFormation.
+	RecreateCluster string
+	// SourceStackNames restricts the sync run to the clusters of the given
+	// source stacks, e.g. `cluster-foo-tccp`. All source stacks and the target
+	// stack of these clusters are described by name instead of listing all
+	// stacks of the accounts. It must not be combined with Cluster.
+	SourceStackNames []string
+	// Components is the list of control plane components to create CNAME
+	// records for. Defaults to DefaultComponents.
+	Components []Component
+	// AliasWildcard renders the wildcard record as an A alias record of the
+	// ingress ELB instead of a CNAME of the ingress record. The ingress ELB
+	// is resolved for legacy and non legacy clusters then. Target stacks
+	// created with the CNAME may have to be recreated when switching.
+	AliasWildcard bool
+	// BareDomainRecord adds a record for the cluster domain `<cluster>.<zone>`
+	// pointing at ingress like the wildcard record, e.g. so
+	// `https://<cluster>.<zone>` resolves. It is a CNAME of the ingress
+	// record unless AliasWildcard is set. A CNAME excludes other records of
+	// its name, so it requires AliasWildcard when combined with CAAValue.
+	BareDomainRecord bool
+	// APIRecordType is the type of the api record, either `CNAME` of the api
+	// ELB or `A` for an alias record of it. Clusters override it with the
+	// APIRecordTypeTag of their source stack. Defaults to `CNAME`. The alias
+	// record has the logical ID `apiAliasDNSRecord` instead of
+	// `apiDNSRecord`. With ELBDNSFromOutputs its DNS name is read from the
+	// source stack outputs too.
+	APIRecordType string
+	// NonLegacyIngress creates the ingress record for non legacy clusters
+	// too, pointing at the ingress ELB resolved by name or from the source
+	// stack outputs like for legacy clusters. The ingress ELB must exist for
+	// every cluster then.
+	NonLegacyIngress bool
+	// DryRun computes the plan without applying it. The unified diff between
+	// the current and the rendered template of every target stack to be
+	// created or updated is written to DryRunOutput, which defaults to
+	// stdout. It is only supported in ApplyModeCloudFormation.
+	DryRun       bool
+	DryRunOutput io.Writer
+	// ApplyMode is how the records of a cluster are applied, either
+	// ApplyModeCloudFormation, ApplyModeRoute53Atomic or
+	// ApplyModeRoute53Direct. Defaults to ApplyModeCloudFormation. Orphan
+	// target stacks are deleted in the first two modes. The last two enable
+	// MetadataRecord and delete the records of orphan clusters without
+	// target stack. RegionHostedZones are not supported in
+	// ApplyModeRoute53Direct. The mode applies to all clusters of the
+	// Manager, target hosted zones applied in different modes need one
+	// Manager each.
+	ApplyMode string
+	// CAAValue, when set, adds a CAA record with the given value, e.g.
+	// `0 issue "letsencrypt.org"`, for the cluster domain
+	// `<cluster>.<zone>` to every target stack. No CAA record is created
+	// when empty.
+	CAAValue string
+	// MetadataRecord adds a TXT record `_meta.<cluster>.<zone>` with the
+	// installation, the creation time of the source stack and Version of
+	// every cluster to its target stack, e.g. for discovery tooling.
+	MetadataRecord bool
+	// TemplateFormat is the format target stack templates are rendered in,
+	// either TemplateFormatYAML or TemplateFormatJSON. Defaults to
+	// TemplateFormatYAML.
+	TemplateFormat string
+	// TemplateVersion is the version of the target stack templates, e.g.
+	// `2`. It is embedded into the Metadata section of rendered templates
+	// and added as templateVersionTag to every created and updated target
+	// stack, so bumping it updates every target stack. It does not pin the
+	// rendering: templates rendered differently by a new route53-manager
+	// version still update the target stacks. Nothing is embedded when
+	// empty.
+	TemplateVersion string
+	// IgnoreVersionTag makes SkipUnchangedUpdates ignore the versionTag of
+	// target stacks, so a new Version alone does not update target stacks
+	// whose templates and other tags are unchanged.
+	IgnoreVersionTag bool
+	// MaxTemplateBodySize is the maximum size in bytes of target stack
+	// templates passed inline to CloudFormation. Larger templates are
+	// uploaded to TemplateBucket in TemplateBucketRegion and passed by URL.
+	// They are only uploaded when the target stack is created or updated,
+	// under the key `<Installation>/<target stack name>.<TemplateFormat>`, so
+	// every upload replaces the previous template of the stack. Without
+	// TemplateBucket creating or updating such target stacks fails
+	// with an error matched by IsTemplateTooLarge. Defaults to
+	// DefaultMaxTemplateBodySize, the limit of CloudFormation.
+	MaxTemplateBodySize  int
+	TemplateBucket       string
+	TemplateBucketRegion string
+	// Version is the route53-manager version, e.g. the git commit, added as
+	// versionTag to every created and updated target stack. No tag is added
+	// when empty.
+	Version string
+	// ChangeReason is the operational context of the sync run, e.g. an
+	// incident ticket of a targeted single cluster sync. It is added as
+	// changeReasonTag to every created and updated target stack and appended
+	// to the comment of every record set change batch of a cluster. Nothing
+	// is added when empty.
+	ChangeReason string
+	// AuditLog, when set, gets a JSON line appended for every create, update
+	// and deletion of the target stack or records of a cluster, holding the
+	// time, installation, cluster ID, operation, target stack name, outcome
+	// and Version, e.g. for a compliance audit trail separate from Logger.
+	// It is flushed after every line when it implements Sync, like *os.File.
+	AuditLog io.Writer
+	// Quiet suppresses the per stack debug messages about stacks which are
+	// left untouched. Phase messages and the run summary are still logged.
+	Quiet bool
+	// SummaryHistoryFile is the path of a file the clusters created, updated
+	// and deleted by a sync run are written to. Each run logs the clusters
+	// which newly appeared in or dropped out of these buckets compared to the
+	// previous run, to surface drift and flapping clusters. Nothing is
+	// persisted when empty.
+	SummaryHistoryFile string
+	// PlanOutputFile is the path of a file the plan of each sync run is
+	// written to as JSON, before any target stack or record set is changed,
+	// e.g. for review pipelines. Nothing is written when empty.
+	PlanOutputFile string
+	// LeftoverCheckpointFile is the path of a file the progress of the
+	// leftover cleanups is persisted to after every page of record sets, so
+	// an interrupted cleanup of a large hosted zone resumes where it left
+	// off. Cleanups always start from scratch when empty.
+	LeftoverCheckpointFile string
+	// LeftoverChangeInterval is the minimum interval between the change
+	// batches of a leftover cleanup, to stay below the Route53 change limits.
+	// Zero disables the limit.
+	LeftoverChangeInterval time.Duration
+	// ClusterMetricsOperationLabel labels the cluster reconcile duration
+	// histogram by operation, i.e. create, update or delete. The label is
+	// left empty otherwise.
+	ClusterMetricsOperationLabel bool
+	// ExplainCleanup logs for every record set listed by a leftover cleanup
+	// whether it is below the cluster domain, whether it is managed and
+	// whether it is kept or deleted, e.g. to debug accidental deletions.
+	ExplainCleanup bool
+	// IgnoreUnparseableStacks silences the errors logged for stacks whose
+	// cluster ID cannot be extracted from their name. They are still counted
+	// as skipped with SkipReasonInvalidStackName. Otherwise each of them is
+	// logged once per sync run.
+	IgnoreUnparseableStacks bool
+	// StrictClusterNames fails the sync run before changing anything when
+	// the name of a source or target stack cannot be derived from the cluster
+	// ID extracted from it, e.g. for hyphenated cluster IDs. Such stacks are
+	// only logged as errors otherwise.
+	StrictClusterNames bool
+	// BufferClusterLogs holds back the log lines of each cluster until it
+	// is processed and writes them as a contiguous block, so they stay
+	// readable when clusters are processed concurrently.
+	BufferClusterLogs bool
+	// DeletionOrder is the order the target stack and the leftover record
+	// sets of an orphan cluster are deleted in, either
+	// DeletionOrderRecordsAfterStack or DeletionOrderRecordsFirst. Defaults to
+	// DeletionOrderRecordsAfterStack.
+	DeletionOrder string
+	// DeletionStopOnFailure skips the second deletion step when the first one
+	// failed, e.g. leftover record sets are kept when the stack deletion
+	// failed.
+	DeletionStopOnFailure bool
+	// DisableOrphanDeletion keeps orphan target stacks and their leftover
+	// record sets. They are only reported as skipped with
+	// SkipReasonOrphanDeletionDisabled.
+	DisableOrphanDeletion bool
+	// DeleteOnlyOnCleanSync keeps orphan target stacks and their
+	// leftover record sets when computing the records of any cluster or
+	// creating or updating any target stack failed in the same sync run,
+	// e.g. due to throttling, so nothing is deleted based on a sync run which
+	// was not healthy. They are only reported as skipped with
+	// SkipReasonCreateUpdateFailed.
+	DeleteOnlyOnCleanSync bool
+	// ConfirmOrphans describes the source stacks of every orphan cluster
+	// again right before its target stack is deleted. The target stack is
+	// kept when a source stack was created in the meantime.
+	ConfirmOrphans bool
+	// MaxDeletes and MaxDeletePercentage abort the deletion of orphan target
+	// stacks with massDeletionError when more target stacks are planned to
+	// be deleted in one sync run than the given number or percentage of all
+	// target stacks, e.g. because of a source account outage making every
+	// cluster look orphaned. Zero disables the respective limit.
+	// ForceMassDelete deletes the orphan target stacks regardless.
+	MaxDeletes          int
+	MaxDeletePercentage int
+	ForceMassDelete     bool
+	// DeleteTriggerStatuses are the source stack statuses treated like a
+	// missing source stack, so the target stack of the cluster is deleted,
+	// e.g. DELETE_IN_PROGRESS to clean up before the source stack deletion
+	// completes. Source stacks only count once they have been in the status
+	// for MinStackAge. Defaults to DELETE_COMPLETE.
+	DeleteTriggerStatuses []string
+	// LockHostedZone guards each sync run with an advisory TXT record
+	// `_r53mgr-lock.<zone>` in every hosted zone it writes to, i.e. the
+	// target, etcd, region and reverse hosted zones, so concurrent
+	// route53-manager instances do not compute and apply changes at the same
+	// time. The locks are acquired before the stacks are listed. The record
+	// holds LockOwner and the expiry of the lease, LockLease after the lock
+	// was acquired, and is renewed between clusters once half of the lease
+	// has passed. Runs finding a lock held by another owner back off without
+	// applying changes. LockOwner defaults to `<installation>/<hostname>` and
+	// LockLease to DefaultLockLease.
+	LockHostedZone bool
+	LockOwner      string
+	LockLease      time.Duration
+	// WaitForSync makes record set changes applied directly through Route53
+	// wait until the change is INSYNC, for at most WaitForSyncTimeout.
+	// WaitForSyncTimeout defaults to DefaultWaitForSyncTimeout.
+	WaitForSync        bool
+	WaitForSyncTimeout time.Duration
+	// SyncTimeout is the deadline of a whole sync run. Once exceeded, Sync
+	// stops before the next operation and returns an error matched by
+	// IsSyncTimeout. The deadline is also set on the context passed to AWS
+	// calls and the RecordSource, so calls in flight are cancelled. Zero
+	// disables the deadline.
+	SyncTimeout time.Duration
+	// PriorRequestRetries is the number of times a record set change is
+	// retried with backoff while Route53 rejects it with
+	// PriorRequestNotComplete, as a prior change of the same hosted zone is
+	// still in flight. Defaults to DefaultPriorRequestRetries.
+	PriorRequestRetries int
+	// SyncRetries is the number of times a whole sync run is retried with
+	// backoff when it fails with an error matched by IsRetryable, e.g. a
+	// throttled ListStacks call on startup. A sync run is not idempotent:
+	// the changes applied before the failure are neither rolled back nor
+	// repeated, the retry computes a new plan from the state they left
+	// behind, and audit log entries and events of the failed attempt are
+	// kept. Retries share the deadline of SyncTimeout and are not attempted
+	// when the backoff would exceed it. Errors of invalid credentials or
+	// configuration are never retried. Zero disables the retries.
+	SyncRetries int
+	// ConsistencyRetries is the number of times the target stacks are listed
+	// again with backoff after the plan was applied, until the target stacks
+	// created by the sync run are listed and the deleted ones are gone. This
+	// keeps the next sync run from creating or deleting them again because
+	// of the eventual consistency of CloudFormation. Zero disables the
+	// verification.
+	ConsistencyRetries int
+	// RecordLimitMargin is the number of record sets the hosted zones records
+	// are created in must stay below their limit, as returned by
+	// GetHostedZoneLimit. Target stacks are not created while a hosted zone
+	// is within the margin, so onboarding does not hit the limit halfway.
+	// Updates and deletions are still applied and may free space. Zero
+	// disables the check.
+	RecordLimitMargin int
+	// ENIClusterTag is the tag key carrying the cluster ID the network
+	// interfaces of the etcd records are filtered by. With DiscoveryTags
+	// clusters are discovered by the same tag of their EC2 instances.
+	// Defaults to DefaultENIClusterTag.
+	ENIClusterTag string
+	// ENIOrderTag is the tag key the network interfaces of the etcd records
+	// are ordered by, so etcd1, etcd2 and etcd3 point at the intended etcd
+	// members, e.g. `giantswarm.io/etcd-index`. Defaults to
+	// DefaultENIOrderTag.
+	ENIOrderTag string
+	// MinEtcdENIs and MaxEtcdENIs bound the number of etcd ENIs of non
+	// legacy clusters. Clusters with fewer or more ENIs are skipped with
+	// SkipReasonEtcdENIsOutOfBounds and keep their current records, e.g. when
+	// a misconfigured ENI filter matches no or unrelated network interfaces.
+	// MinEtcdENIs defaults to DefaultMinEtcdENIs when nil, so a minimum of 0
+	// can be configured. MaxEtcdENIs defaults to DefaultMaxEtcdENIs.
+	MinEtcdENIs *int
+	MaxEtcdENIs int
+	// ELBLookup is how the ELBs of the components are looked up, either
+	// ELBLookupName or ELBLookupTags. With ELBLookupTags the load balancers
+	// of the source accounts are listed once per sync run and matched by
+	// the ENIClusterTag holding the cluster ID and the ELBRoleTag holding
+	// the component name. Defaults to ELBLookupName.
+	ELBLookup string
+	// ELBRoleTag is the tag key carrying the component name the ELBs are
+	// matched by with ELBLookupTags. Defaults to DefaultELBRoleTag.
+	ELBRoleTag string
+	// TagOnlyUpdates compares the rendered template of every updated target
+	// stack with its current template. When only the stack tags differ, the
+	// stack is updated with the previous template, so only the tags change.
+	TagOnlyUpdates bool
+	// SkipUnchangedUpdates compares the etcd ENI IPs and the other records of
+	// every updated target stack with its current template and skips the
+	// update when neither they, the stack tags, the notification ARNs nor the
+	// stack policy changed, and the current template has no sections like
+	// Outputs the update would remove. This saves the UpdateStack calls
+	// CloudFormation would reject as no-ops, at the cost of one GetTemplate
+	// call per updated stack, and one GetStackPolicy call with StackPolicy.
+	// The etcd ENIs of all updated clusters are described with one call per
+	// source account instead of one per cluster.
+	SkipUnchangedUpdates bool
+	// AdoptExisting adds the managed-by tag to target stacks lacking it when
+	// they are updated, e.g. target stacks created out-of-band. Such stacks
+	// are updated without taking ownership otherwise. Created target stacks
+	// always get the managed-by tag.
+	AdoptExisting bool
+	// UseChangeSets applies target stack updates through change sets, so they
+	// can be reviewed before execution. The change sets are executed right
+	// away with AutoExecuteChangeSets and left for manual execution
+	// otherwise. A pending change set is reused by later runs while it
+	// applies the same update and replaced once outdated. The stack policy is
+	// not applied through change sets.
+	UseChangeSets         bool
+	AutoExecuteChangeSets bool
+	// PauseTag is the stack tag pausing the sync of a cluster. The target
+	// stack of a cluster is neither created, updated nor deleted while its
+	// source or target stack carries the tag with the value `true`. Defaults
+	// to DefaultPauseTag.
+	PauseTag string
+	// MinStackAge is the duration a source stack must have been in its current
+	// status before its target stack is created or updated. Younger source
+	// stacks are deferred to a later run. Zero disables the check.
+	MinStackAge time.Duration
+	// ELBDNSFromOutputs makes the default RecordSource read the ELB DNS name
+	// of each component from the source stack output given by
+	// ELBDNSOutputKeys. Components without such an output fall back to the
+	// ELB lookup by name. ELBDNSOutputKeys defaults to
+	// DefaultELBDNSOutputKeys.
+	ELBDNSFromOutputs bool
+	ELBDNSOutputKeys  map[string]string
+	// RequireHealthyELB leaves out the record of every component whose ELB
+	// has no healthy instance or target, so records never point at load
+	// balancers which cannot serve traffic. The records of the other
+	// components are still applied. With an unhealthy ingress ELB, the
+	// wildcard and bare domain records are left out too. ELB DNS names read
+	// from the source stack outputs are not checked.
+	RequireHealthyELB bool
+	// StackPolicy is the JSON stack policy attached to every created and
+	// updated target stack, e.g. to deny the replacement of record sets. No
+	// policy is applied when empty.
+	StackPolicy string
+	// NotificationARNs are the SNS topic ARNs CloudFormation publishes the
+	// events of every created and updated target stack to. No notifications
+	// are published when empty.
+	NotificationARNs []string
+	// PreserveTargetTags are the keys of the tags of the target stacks kept
+	// on update, e.g. cost center tags added by operators out-of-band. Their
+	// current values take precedence over the source stack tags. Tags
+	// maintained by route53-manager itself must not be preserved.
+	PreserveTargetTags []string
+	// RecordSource computes the records of each target stack. Defaults to
+	// the records of the component ELBs and etcd ENIs in the source account.
+	RecordSource RecordSource
+	// Discovery is how the clusters of each source account are discovered,
+	// either DiscoveryStacks or DiscoveryTags. Defaults to DiscoveryStacks.
+	// It is ignored when ClusterDiscoverer is set.
+	Discovery string
+	// ClusterDiscoverer discovers the clusters of each source account.
+	// Defaults to the ClusterDiscoverer selected by Discovery.
+	ClusterDiscoverer ClusterDiscoverer
+	// DiscoveryGracePeriod is how long the cluster of a target stack must be
+	// missing from DiscoveryTags discovery before the target stack is
+	// deleted. Defaults to DefaultDiscoveryGracePeriod. It is ignored with
+	// DiscoveryStacks.
+	DiscoveryGracePeriod time.Duration
+
+	// ParentClient and ParentHostedZoneID are optional. When set, the NS
+	// record delegating `<cluster>.<zone>` to the target hosted zone is
+	// ensured in the parent hosted zone whenever a target stack is created
+	// or updated. ParentHostedZoneName is optional too. When set, clusters
+	// whose delegation record is not below it fail instead of changing the
+	// parent hosted zone.
+	ParentClient         client.ParentInterface
+	ParentHostedZoneID   string
+	ParentHostedZoneName string
+
+	// TargetHostedZoneName and EtcdHostedZoneName may be given with or
+	// without trailing dot. The trailing dot is stripped in NewManager and
+	// added where fully qualified names are compared. When
+	// TargetHostedZoneID is empty, it is resolved once in NewManager from
+	// the only hosted zone named TargetHostedZoneName.
 	TargetHostedZoneID   string
 	TargetHostedZoneName string
+	// TargetHostedZoneFailover is FailoverPrimary or FailoverSecondary to
+	// create the records in the target hosted zone with failover routing,
+	// e.g. for the same names served by two installations in different
+	// regions. The installation name is used as set identifier. Empty
+	// disables failover routing. TargetHostedZoneHealthCheckID is the Route53
+	// health check of the records, required for primary records.
+	TargetHostedZoneFailover      string
+	TargetHostedZoneHealthCheckID string
+	// SetIdentifierPrefix is prepended to the set identifier of every record
+	// set with a routing policy, e.g. `r53mgr-`, so they do not collide with
+	// the record sets of other tools managing the same hosted zone. The set
+	// identifier is the installation name when empty.
+	SetIdentifierPrefix string
+	// EtcdHostedZoneID and EtcdHostedZoneName are the hosted zone the etcd
+	// records are created in, e.g. a private zone. Both default to the target
+	// hosted zone.
+	EtcdHostedZoneID   string
+	EtcdHostedZoneName string
+	// RegionHostedZones are the hosted zones the records of the clusters of
+	// a region are created in instead of the target hosted zone, by region,
+	// e.g. in target accounts serving several regions. The region of a
+	// cluster is read from the RegionTag of its source stack, which is also
+	// added to its target stack, so orphan clusters are cleaned up in the
+	// same hosted zone. Clusters without a mapped region use the target
+	// hosted zone. The etcd records follow the hosted zone of the region
+	// unless EtcdHostedZoneID is set. Failover routing only applies to the
+	// target hosted zone. RegionTag defaults to DefaultRegionTag.
+	RegionHostedZones map[string]HostedZone
+	RegionTag         string
+	// EmitPTR adds a PTR record for the private IP of every etcd ENI,
+	// pointing at the etcd DNS name, to the reverse hosted zone given by
+	// ReverseHostedZoneID and ReverseHostedZoneName, e.g. `10.in-addr.arpa`.
+	// IPs outside of the reverse hosted zone get no PTR record. In
+	// ApplyModeRoute53Atomic stale PTR records are not deleted, as their
+	// names do not contain the cluster ID.
+	EmitPTR               bool
+	ReverseHostedZoneID   string
+	ReverseHostedZoneName string
 }
 
 type Manager struct {
 	logger       micrologger.Logger
 	installation string
-	sourceClient client.SourceInterface
 	targetClient client.TargetInterface
 
+	targetOnly bool
+
+	// sourceClients are the clients of all source accounts, starting with
+	// Config.SourceClient. sourceAccounts maps the clusters of the current
+	// sync run to the index of their source account, clusterSourceStacks to
+	// the source stack their tags and outputs are read from.
+	sourceClients       []client.SourceInterface
+	sourceAccounts      map[string]int
+	clusterSourceStacks map[string]cloudformation.Stack
+	// recordClusterIDs are the clusters of the current sync run found
+	// through their metadata record only, all clusters in
+	// ApplyModeRoute53Direct and the ones without target stack in
+	// ApplyModeRoute53Atomic.
+	recordClusterIDs []string
+
+	cluster          string
+	recreateCluster  string
+	sourceStackNames []string
+	aliasWildcard    bool
+	bareDomainRecord bool
+	apiRecordType    string
+	nonLegacyIngress bool
+	dryRun           bool
+	dryRunOutput     io.Writer
+	applyMode        string
+	caaValue         string
+	metadataRecord   bool
+	components       []Component
+	templateFormat   string
+	templateVersion  string
+	ignoreVersionTag bool
+	version          string
+	changeReason     string
+	quiet            bool
+	pauseTag         string
+	tagOnlyUpdates   bool
+	adoptExisting    bool
+	minStackAge      time.Duration
+
+	skipUnchangedUpdates bool
+
+	maxTemplateBodySize  int
+	templateBucket       string
+	templateBucketRegion string
+
+	useChangeSets         bool
+	autoExecuteChangeSets bool
+
+	deletionOrder         string
+	deletionStopOnFailure bool
+	disableOrphanDeletion bool
+	deleteOnlyOnCleanSync bool
+	confirmOrphans        bool
+	deleteTriggerStatuses []string
+
+	maxDeletes          int
+	maxDeletePercentage int
+	forceMassDelete     bool
+
+	// locks are the hosted zone locks acquired by the current sync run by
+	// hosted zone ID, when lockHostedZone is set.
+	lockHostedZone bool
+	lockOwner      string
+	lockLease      time.Duration
+	locks          map[string]hostedZoneLock
+
+	waitForSync        bool
+	waitForSyncTimeout time.Duration
+	syncTimeout        time.Duration
+
+	priorRequestRetries int
+
+	syncRetries        int
+	consistencyRetries int
+
+	recordLimitMargin int
+
+	eniClusterTag string
+	eniOrderTag   string
+	minEtcdENIs   int
+	maxEtcdENIs   int
+	// etcdENIs are the etcd ENIs by cluster ID described by prefetchEtcdENIs
+	// during the current sync run.
+	etcdENIs map[string][]*ec2.NetworkInterface
+
+	elbLookup  string
+	elbRoleTag string
+	// taggedELBs are the load balancers of the source accounts listed for
+	// ELBLookupTags during the current sync run.
+	taggedELBs map[client.SourceInterface][]taggedLoadBalancer
+
+	elbDNSFromOutputs bool
+	elbDNSOutputKeys  map[string]string
+	requireHealthyELB bool
+
+	stackPolicy      string
+	notificationARNs []string
+
+	preserveTargetTags []string
+
+	recordSource RecordSource
+	summary      syncSummary
+
+	clusterDiscoverer    ClusterDiscoverer
+	discoveryGracePeriod time.Duration
+
+	summaryHistoryFile string
+	planOutputFile     string
+
+	leftoverCheckpointFile string
+	leftoverChangeInterval time.Duration
+	explainCleanup         bool
+
+	strictClusterNames      bool
+	ignoreUnparseableStacks bool
+
+	auditLog io.Writer
+
+	// elbHostedZoneIDs are the canonical hosted zone IDs of the ELBs looked
+	// up by name, by DNS name.
+	elbHostedZoneIDs map[string]string
+
+	// clusterLogs buffers the log lines of the cluster being processed when
+	// bufferClusterLogs is set. m.logger is replaced by it meanwhile and
+	// restored from unbufferedLogger once flushed.
+	bufferClusterLogs bool
+	clusterLogs       *logbuffer.Logger
+	unbufferedLogger  micrologger.Logger
+
+	// clusterReconcile is the reconciliation of the cluster being processed
+	// whose duration is observed once the next cluster is started.
+	clusterMetricsOperationLabel bool
+	clusterReconcile             *clusterReconcile
+
+	// ctx is the context of the current sync run and is passed to every AWS
+	// call. It is done once syncTimeout is exceeded. deadline is the same
+	// deadline measured by now, zero when syncTimeout is disabled.
+	ctx      context.Context
+	deadline time.Time
+
+	// now and sleep are replaced in tests.
+	now   func() time.Time
+	sleep func(time.Duration)
+
+	parentClient         client.ParentInterface
+	parentHostedZoneID   string
+	parentHostedZoneName string
+
 	targetHostedZoneID   string
 	targetHostedZoneName string
+	etcdHostedZoneID     string
+	etcdHostedZoneName   string
+	failover             failoverRouting
+	// setIdentifier is the set identifier of the record sets with a routing
+	// policy.
+	setIdentifier string
+
+	regionHostedZones map[string]HostedZone
+	regionTag         string
+
+	emitPTR               bool
+	reverseHostedZoneID   string
+	reverseHostedZoneName string
+}
+
+// Component is a control plane endpoint exposed behind its own ELB, e.g.
+// `api`. Each component gets a `<name>.<cluster>.<zone>` CNAME record
+// pointing at the DNS name of the ELB named `<cluster><ELBSuffix>`.
+type Component struct {
+	// Name is the DNS label of the record. It is also used to build the
+	// CloudFormation resource name, so it must be lower case alphanumeric.
+	Name string
+	// ELBSuffix is appended to the cluster ID to get the ELB name.
+	ELBSuffix string
+	// LegacyOnly restricts the record to legacy clusters.
+	LegacyOnly bool
 }
 
 type sourceStackData struct {
-	HostedZoneID    string
-	HostedZoneName  string
-	ClusterName     string
-	IngressELBDNS   string
-	IsLegacyCluster bool
-	APIELBDNS       string
-	EtcdELBDNS      string
-	EtcdEniList     []EtcdEni
+	HostedZoneID       string
+	HostedZoneName     string
+	EtcdHostedZoneID   string
+	EtcdHostedZoneName string
+	ClusterName        string
+	IsLegacyCluster    bool
+	ComponentRecords   []ComponentRecord
+	EtcdEniList        []EtcdEni
+	// IngressAliasTarget is the ingress ELB the wildcard record is an alias
+	// of. The wildcard record is a CNAME when nil.
+	IngressAliasTarget *AliasTarget
+	// APIAliasTarget is the api ELB the api record is an alias of. The api
+	// record is a CNAME when nil.
+	APIAliasTarget *AliasTarget
+	// OmitIngressRecords leaves out the wildcard and bare domain records,
+	// e.g. when the ingress ELB has no healthy backend.
+	OmitIngressRecords bool
+	// BareDomainRecord adds a record for the cluster domain pointing at
+	// ingress like the wildcard record.
+	BareDomainRecord bool
+	// CAAValue is the value of the CAA record of the cluster domain. No CAA
+	// record is created when empty.
+	CAAValue string
+	// Weights are the weights of the component records by component name.
+	// Component records with a weight are created with weighted routing and
+	// SetIdentifier.
+	Weights       map[string]int64
+	SetIdentifier string
+	// MetadataValue is the value of the TXT record `_meta.<cluster>.<zone>`,
+	// e.g. `"installation=foo created=2020-01-01T12:00:00Z"`. No metadata
+	// record is created when empty.
+	MetadataValue string
+	// ReverseHostedZoneID and ReverseHostedZoneName are the reverse hosted
+	// zone the PTR records of the etcd ENIs are created in. No PTR records
+	// are created when empty.
+	ReverseHostedZoneID   string
+	ReverseHostedZoneName string
+	// TTL is the TTL in seconds of the non-alias records of the cluster, read
+	// from its RecordTTLTag. The default TTL applies when zero.
+	TTL int64
+}
+
+type ComponentRecord struct {
+	ResourceName string
+	Name         string
+	ELBDNS       string
 }
 
 type EtcdEni struct {
@@ -132,33 +777,495 @@ func NewManager(c *Config) (*Manager, error) {
 	if c.Installation == "" {
 		return nil, microerror.Maskf(invalidConfigError, "%T.Installation must not be empty", c)
 	}
-	if c.SourceClient == nil {
+	if c.SourceClient == nil && !c.TargetOnly {
 		return nil, microerror.Maskf(invalidConfigError, "%T.SourceClient must not be empty", c)
 	}
+	for _, sourceClient := range c.AdditionalSourceClients {
+		if sourceClient == nil {
+			return nil, microerror.Maskf(invalidConfigError, "%T.AdditionalSourceClients must not contain empty clients", c)
+		}
+	}
 	if c.TargetClient == nil {
 		return nil, microerror.Maskf(invalidConfigError, "%T.TargetClient must not be empty", c)
 	}
-	if c.TargetHostedZoneID == "" {
-		return nil, microerror.Maskf(invalidConfigError, "%T.TargetHostedZoneID must not be empty", c)
+	if c.RecreateCluster != "" {
+		if c.Cluster != "" && c.Cluster != c.RecreateCluster {
+			return nil, microerror.Maskf(invalidConfigError, "%T.Cluster must be empty or equal %T.RecreateCluster", c, c)
+		}
+		c.Cluster = c.RecreateCluster
+	}
+	if c.Cluster != "" && !clusterIDRE.MatchString(c.Cluster) {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Cluster must match %#q, got %#q", c, clusterIDRE.String(), c.Cluster)
+	}
+	err := validateSourceStackNames(c.SourceStackNames)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	if c.Cluster != "" && len(c.SourceStackNames) > 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Cluster and %T.SourceStackNames must not both be set", c, c)
+	}
+	if c.Components == nil {
+		c.Components = DefaultComponents
+	}
+	err = validateComponents(c.Components)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	err = validateELBDNSOutputKeys(c.ELBDNSOutputKeys, c.Components)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	if c.ELBDNSOutputKeys == nil {
+		c.ELBDNSOutputKeys = DefaultELBDNSOutputKeys
+	}
+	if c.ApplyMode == "" {
+		c.ApplyMode = ApplyModeCloudFormation
+	}
+	if c.ApplyMode != ApplyModeCloudFormation && c.ApplyMode != ApplyModeRoute53Atomic && c.ApplyMode != ApplyModeRoute53Direct {
+		return nil, microerror.Maskf(invalidConfigError, "%T.ApplyMode must be %#q, %#q or %#q", c, ApplyModeCloudFormation, ApplyModeRoute53Atomic, ApplyModeRoute53Direct)
+	}
+	if c.ApplyMode == ApplyModeRoute53Direct && len(c.RegionHostedZones) > 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.RegionHostedZones is not supported in %T.ApplyMode %#q", c, c, ApplyModeRoute53Direct)
+	}
+	if c.ApplyMode == ApplyModeRoute53Atomic || c.ApplyMode == ApplyModeRoute53Direct {
+		// The metadata records mark the clusters whose records are managed.
+		c.MetadataRecord = true
+	}
+	if c.APIRecordType == "" {
+		c.APIRecordType = route53.RRTypeCname
+	}
+	if !validAPIRecordType(c.APIRecordType) {
+		return nil, microerror.Maskf(invalidConfigError, "%T.APIRecordType must be %#q or %#q, got %#q", c, route53.RRTypeCname, route53.RRTypeA, c.APIRecordType)
+	}
+	if c.Discovery == "" {
+		c.Discovery = DiscoveryStacks
+	}
+	if c.Discovery != DiscoveryStacks && c.Discovery != DiscoveryTags {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Discovery must be %#q or %#q", c, DiscoveryStacks, DiscoveryTags)
+	}
+	if c.Discovery == DiscoveryTags && c.ApplyMode != ApplyModeCloudFormation {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Discovery %#q is only supported in %T.ApplyMode %#q", c, DiscoveryTags, c, ApplyModeCloudFormation)
+	}
+	if c.Discovery == DiscoveryTags && c.ELBDNSFromOutputs {
+		return nil, microerror.Maskf(invalidConfigError, "%T.ELBDNSFromOutputs is not supported with %T.Discovery %#q, discovered clusters have no source stack outputs", c, c, DiscoveryTags)
+	}
+	if c.DiscoveryGracePeriod < 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.DiscoveryGracePeriod must not be negative", c)
 	}
-	if c.TargetHostedZoneName == "" {
+	if c.DiscoveryGracePeriod == 0 {
+		c.DiscoveryGracePeriod = DefaultDiscoveryGracePeriod
+	}
+	if c.DryRun && c.ApplyMode != ApplyModeCloudFormation {
+		return nil, microerror.Maskf(invalidConfigError, "%T.DryRun is only supported in %T.ApplyMode %#q", c, c, ApplyModeCloudFormation)
+	}
+	if c.RecreateCluster != "" && (c.DryRun || c.ApplyMode != ApplyModeCloudFormation) {
+		return nil, microerror.Maskf(invalidConfigError, "%T.RecreateCluster is only supported in %T.ApplyMode %#q without %T.DryRun", c, c, ApplyModeCloudFormation, c)
+	}
+	if c.DryRunOutput == nil {
+		c.DryRunOutput = os.Stdout
+	}
+	if c.CAAValue != "" && !caaValueRE.MatchString(c.CAAValue) {
+		return nil, microerror.Maskf(invalidConfigError, "%T.CAAValue must match %#q, got %#q", c, caaValueRE.String(), c.CAAValue)
+	}
+	if c.BareDomainRecord && c.CAAValue != "" && !c.AliasWildcard {
+		return nil, microerror.Maskf(invalidConfigError, "%T.BareDomainRecord requires %T.AliasWildcard when %T.CAAValue is set", c, c, c)
+	}
+	if c.TemplateFormat == "" {
+		c.TemplateFormat = TemplateFormatYAML
+	}
+	if c.TemplateFormat != TemplateFormatYAML && c.TemplateFormat != TemplateFormatJSON {
+		return nil, microerror.Maskf(invalidConfigError, "%T.TemplateFormat must be %#q or %#q", c, TemplateFormatYAML, TemplateFormatJSON)
+	}
+	if c.MaxTemplateBodySize == 0 {
+		c.MaxTemplateBodySize = DefaultMaxTemplateBodySize
+	}
+	if c.MaxTemplateBodySize < 0 || c.MaxTemplateBodySize > DefaultMaxTemplateBodySize {
+		return nil, microerror.Maskf(invalidConfigError, "%T.MaxTemplateBodySize must be between 1 and %d, got %d", c, DefaultMaxTemplateBodySize, c.MaxTemplateBodySize)
+	}
+	if c.TemplateBucket != "" && c.TemplateBucketRegion == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.TemplateBucketRegion must not be empty when %T.TemplateBucket is set", c, c)
+	}
+	if c.DeletionOrder == "" {
+		c.DeletionOrder = DeletionOrderRecordsAfterStack
+	}
+	if c.DeletionOrder != DeletionOrderRecordsAfterStack && c.DeletionOrder != DeletionOrderRecordsFirst {
+		return nil, microerror.Maskf(invalidConfigError, "%T.DeletionOrder must be %#q or %#q", c, DeletionOrderRecordsAfterStack, DeletionOrderRecordsFirst)
+	}
+	if c.LockHostedZone && c.LockOwner == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		c.LockOwner = c.Installation + "/" + hostname
+	}
+	if strings.ContainsAny(c.LockOwner, " \"") {
+		return nil, microerror.Maskf(invalidConfigError, "%T.LockOwner must not contain spaces or quotes, got %#q", c, c.LockOwner)
+	}
+	if c.LockLease == 0 {
+		c.LockLease = DefaultLockLease
+	}
+	if c.LockLease < 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.LockLease must not be negative", c)
+	}
+	if c.RecordLimitMargin < 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.RecordLimitMargin must not be negative", c)
+	}
+	if c.WaitForSyncTimeout == 0 {
+		c.WaitForSyncTimeout = DefaultWaitForSyncTimeout
+	}
+	if c.WaitForSyncTimeout < 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.WaitForSyncTimeout must not be negative", c)
+	}
+	if c.SyncTimeout < 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.SyncTimeout must not be negative", c)
+	}
+	if c.MaxDeletes < 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.MaxDeletes must not be negative", c)
+	}
+	if c.MaxDeletePercentage < 0 || c.MaxDeletePercentage > 100 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.MaxDeletePercentage must be between 0 and 100, got %d", c, c.MaxDeletePercentage)
+	}
+	if c.SyncRetries < 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.SyncRetries must not be negative", c)
+	}
+	if c.ConsistencyRetries < 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.ConsistencyRetries must not be negative", c)
+	}
+	if c.PriorRequestRetries == 0 {
+		c.PriorRequestRetries = DefaultPriorRequestRetries
+	}
+	if c.PriorRequestRetries < 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.PriorRequestRetries must not be negative", c)
+	}
+	if c.PauseTag == "" {
+		c.PauseTag = DefaultPauseTag
+	}
+	if c.ENIClusterTag == "" {
+		c.ENIClusterTag = DefaultENIClusterTag
+	}
+	if c.ENIOrderTag == "" {
+		c.ENIOrderTag = DefaultENIOrderTag
+	}
+	minEtcdENIs := DefaultMinEtcdENIs
+	if c.MinEtcdENIs != nil {
+		minEtcdENIs = *c.MinEtcdENIs
+	}
+	if c.MaxEtcdENIs == 0 {
+		c.MaxEtcdENIs = DefaultMaxEtcdENIs
+	}
+	if minEtcdENIs < 0 || c.MaxEtcdENIs < minEtcdENIs {
+		return nil, microerror.Maskf(invalidConfigError, "%T.MinEtcdENIs must not be negative and not exceed %T.MaxEtcdENIs, got %d and %d", c, c, minEtcdENIs, c.MaxEtcdENIs)
+	}
+	if c.ELBLookup == "" {
+		c.ELBLookup = ELBLookupName
+	}
+	if c.ELBLookup != ELBLookupName && c.ELBLookup != ELBLookupTags {
+		return nil, microerror.Maskf(invalidConfigError, "%T.ELBLookup must be %#q or %#q", c, ELBLookupName, ELBLookupTags)
+	}
+	if c.ELBRoleTag == "" {
+		c.ELBRoleTag = DefaultELBRoleTag
+	}
+	if len(c.DeleteTriggerStatuses) == 0 {
+		c.DeleteTriggerStatuses = []string{cloudformation.StackStatusDeleteComplete}
+	}
+	for _, s := range c.DeleteTriggerStatuses {
+		if !stringInSlice(s, plan.StackStatusDeleting) {
+			return nil, microerror.Maskf(invalidConfigError, "%T.DeleteTriggerStatuses must only contain %v, got %#q", c, plan.StackStatusDeleting, s)
+		}
+	}
+	if c.MinStackAge < 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.MinStackAge must not be negative", c)
+	}
+	if len(c.ChangeReason) > maxChangeReasonLength {
+		return nil, microerror.Maskf(invalidConfigError, "%T.ChangeReason must not exceed %d characters", c, maxChangeReasonLength)
+	}
+	if c.StackPolicy != "" && !json.Valid([]byte(c.StackPolicy)) {
+		return nil, microerror.Maskf(invalidConfigError, "%T.StackPolicy must be valid JSON", c)
+	}
+	for _, a := range c.NotificationARNs {
+		if !arn.IsARN(a) {
+			return nil, microerror.Maskf(invalidConfigError, "%T.NotificationARNs must only contain ARNs, got %#q", c, a)
+		}
+	}
+	for _, k := range c.PreserveTargetTags {
+		if k == "" || k == managedByTag || k == versionTag || k == templateVersionTag || k == changeReasonTag {
+			return nil, microerror.Maskf(invalidConfigError, "%T.PreserveTargetTags must not contain empty or route53-manager tags, got %#q", c, k)
+		}
+	}
+	if c.ParentClient != nil && c.ParentHostedZoneID == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.ParentHostedZoneID must not be empty when %T.ParentClient is set", c, c)
+	}
+	if c.ParentClient == nil && c.ParentHostedZoneID != "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.ParentClient must not be empty when %T.ParentHostedZoneID is set", c, c)
+	}
+	c.TargetHostedZoneName = strings.TrimSuffix(c.TargetHostedZoneName, ".")
+	c.EtcdHostedZoneName = strings.TrimSuffix(c.EtcdHostedZoneName, ".")
+	if c.TargetHostedZoneName == "" && !c.TargetOnly {
 		return nil, microerror.Maskf(invalidConfigError, "%T.TargetHostedZoneName must not be empty", c)
 	}
+	if c.TargetHostedZoneID == "" && c.TargetHostedZoneName != "" {
+		c.TargetHostedZoneID, err = resolveHostedZoneID(context.Background(), c.TargetClient, c.TargetHostedZoneName)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		c.Logger.Log("level", "debug", "message", fmt.Sprintf("resolved target hosted zone %#q to ID %#q", c.TargetHostedZoneName, c.TargetHostedZoneID))
+	}
+	switch c.TargetHostedZoneFailover {
+	case "", FailoverPrimary, FailoverSecondary:
+	default:
+		return nil, microerror.Maskf(invalidConfigError, "%T.TargetHostedZoneFailover must be empty, %#q or %#q, got %#q", c, FailoverPrimary, FailoverSecondary, c.TargetHostedZoneFailover)
+	}
+	if c.TargetHostedZoneFailover == FailoverPrimary && c.TargetHostedZoneHealthCheckID == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.TargetHostedZoneHealthCheckID must not be empty when %T.TargetHostedZoneFailover is %#q", c, c, FailoverPrimary)
+	}
+	if c.TargetHostedZoneFailover == "" && c.TargetHostedZoneHealthCheckID != "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.TargetHostedZoneHealthCheckID must be empty when %T.TargetHostedZoneFailover is empty", c, c)
+	}
+	if len(c.SetIdentifierPrefix+c.Installation) > maxSetIdentifierLength {
+		return nil, microerror.Maskf(invalidConfigError, "%T.SetIdentifierPrefix and %T.Installation must not exceed %d characters together", c, c, maxSetIdentifierLength)
+	}
+	if c.EtcdHostedZoneID == "" && c.EtcdHostedZoneName == "" {
+		c.EtcdHostedZoneID = c.TargetHostedZoneID
+		c.EtcdHostedZoneName = c.TargetHostedZoneName
+	}
+	if c.EtcdHostedZoneID == "" && c.EtcdHostedZoneName != "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.EtcdHostedZoneID must not be empty when %T.EtcdHostedZoneName is set", c, c)
+	}
+	if c.EtcdHostedZoneName == "" && c.EtcdHostedZoneID != "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.EtcdHostedZoneName must not be empty when %T.EtcdHostedZoneID is set", c, c)
+	}
+	regionHostedZones := map[string]HostedZone{}
+	for region, z := range c.RegionHostedZones {
+		z.Name = strings.TrimSuffix(z.Name, ".")
+		if region == "" || z.ID == "" || z.Name == "" {
+			return nil, microerror.Maskf(invalidConfigError, "%T.RegionHostedZones must only contain regions with hosted zone ID and name, got %#q: %#v", c, region, z)
+		}
+		regionHostedZones[region] = z
+	}
+	if c.RegionTag == "" {
+		c.RegionTag = DefaultRegionTag
+	}
+	if c.EmitPTR && c.ReverseHostedZoneID == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.ReverseHostedZoneID must not be empty when %T.EmitPTR is set", c, c)
+	}
+	if c.EmitPTR && !isReverseZoneName(c.ReverseHostedZoneName) {
+		return nil, microerror.Maskf(invalidConfigError, "%T.ReverseHostedZoneName must be an IPv4 reverse zone like %#q, got %#q", c, "10.in-addr.arpa", c.ReverseHostedZoneName)
+	}
 
 	m := &Manager{
 		logger:       c.Logger,
 		installation: c.Installation,
-		sourceClient: c.SourceClient,
 		targetClient: c.TargetClient,
 
+		targetOnly: c.TargetOnly,
+
+		sourceClients:       append([]client.SourceInterface{c.SourceClient}, c.AdditionalSourceClients...),
+		sourceAccounts:      map[string]int{},
+		clusterSourceStacks: map[string]cloudformation.Stack{},
+
+		cluster:          c.Cluster,
+		recreateCluster:  c.RecreateCluster,
+		sourceStackNames: c.SourceStackNames,
+		aliasWildcard:    c.AliasWildcard,
+		bareDomainRecord: c.BareDomainRecord,
+		apiRecordType:    c.APIRecordType,
+		nonLegacyIngress: c.NonLegacyIngress,
+		dryRun:           c.DryRun,
+		dryRunOutput:     c.DryRunOutput,
+		applyMode:        c.ApplyMode,
+		caaValue:         c.CAAValue,
+		metadataRecord:   c.MetadataRecord,
+		components:       c.Components,
+		templateFormat:   c.TemplateFormat,
+		templateVersion:  c.TemplateVersion,
+		ignoreVersionTag: c.IgnoreVersionTag,
+		version:          c.Version,
+		changeReason:     c.ChangeReason,
+		quiet:            c.Quiet,
+		pauseTag:         c.PauseTag,
+		tagOnlyUpdates:   c.TagOnlyUpdates,
+		adoptExisting:    c.AdoptExisting,
+		minStackAge:      c.MinStackAge,
+
+		skipUnchangedUpdates: c.SkipUnchangedUpdates,
+
+		maxTemplateBodySize:  c.MaxTemplateBodySize,
+		templateBucket:       c.TemplateBucket,
+		templateBucketRegion: c.TemplateBucketRegion,
+
+		bufferClusterLogs: c.BufferClusterLogs,
+
+		clusterMetricsOperationLabel: c.ClusterMetricsOperationLabel,
+
+		summaryHistoryFile: c.SummaryHistoryFile,
+		planOutputFile:     c.PlanOutputFile,
+
+		leftoverCheckpointFile: c.LeftoverCheckpointFile,
+		leftoverChangeInterval: c.LeftoverChangeInterval,
+		explainCleanup:         c.ExplainCleanup,
+
+		strictClusterNames:      c.StrictClusterNames,
+		ignoreUnparseableStacks: c.IgnoreUnparseableStacks,
+
+		auditLog: c.AuditLog,
+
+		useChangeSets:         c.UseChangeSets,
+		autoExecuteChangeSets: c.AutoExecuteChangeSets,
+
+		deletionOrder:         c.DeletionOrder,
+		deletionStopOnFailure: c.DeletionStopOnFailure,
+		deleteOnlyOnCleanSync: c.DeleteOnlyOnCleanSync,
+		disableOrphanDeletion: c.DisableOrphanDeletion,
+		confirmOrphans:        c.ConfirmOrphans,
+		deleteTriggerStatuses: c.DeleteTriggerStatuses,
+
+		maxDeletes:          c.MaxDeletes,
+		maxDeletePercentage: c.MaxDeletePercentage,
+		forceMassDelete:     c.ForceMassDelete,
+
+		lockHostedZone: c.LockHostedZone,
+		lockOwner:      c.LockOwner,
+		lockLease:      c.LockLease,
+		locks:          map[string]hostedZoneLock{},
+
+		waitForSync:        c.WaitForSync,
+		waitForSyncTimeout: c.WaitForSyncTimeout,
+		syncTimeout:        c.SyncTimeout,
+
+		priorRequestRetries: c.PriorRequestRetries,
+
+		syncRetries:        c.SyncRetries,
+		consistencyRetries: c.ConsistencyRetries,
+
+		recordLimitMargin: c.RecordLimitMargin,
+
+		eniClusterTag: c.ENIClusterTag,
+		eniOrderTag:   c.ENIOrderTag,
+		minEtcdENIs:   minEtcdENIs,
+		maxEtcdENIs:   c.MaxEtcdENIs,
+
+		elbLookup:  c.ELBLookup,
+		elbRoleTag: c.ELBRoleTag,
+
+		ctx: context.Background(),
+
+		elbHostedZoneIDs: map[string]string{},
+
+		elbDNSFromOutputs: c.ELBDNSFromOutputs,
+		elbDNSOutputKeys:  c.ELBDNSOutputKeys,
+		requireHealthyELB: c.RequireHealthyELB,
+
+		stackPolicy:      c.StackPolicy,
+		notificationARNs: c.NotificationARNs,
+
+		preserveTargetTags: c.PreserveTargetTags,
+
+		now:   time.Now,
+		sleep: time.Sleep,
+
+		parentClient:         c.ParentClient,
+		parentHostedZoneID:   c.ParentHostedZoneID,
+		parentHostedZoneName: strings.TrimSuffix(c.ParentHostedZoneName, "."),
+
 		targetHostedZoneID:   c.TargetHostedZoneID,
 		targetHostedZoneName: c.TargetHostedZoneName,
+		etcdHostedZoneID:     c.EtcdHostedZoneID,
+		etcdHostedZoneName:   c.EtcdHostedZoneName,
+		failover: failoverRouting{
+			Role:          c.TargetHostedZoneFailover,
+			SetIdentifier: c.SetIdentifierPrefix + c.Installation,
+			HealthCheckID: c.TargetHostedZoneHealthCheckID,
+		},
+		setIdentifier: c.SetIdentifierPrefix + c.Installation,
+
+		regionHostedZones: regionHostedZones,
+		regionTag:         c.RegionTag,
+
+		emitPTR:               c.EmitPTR,
+		reverseHostedZoneID:   c.ReverseHostedZoneID,
+		reverseHostedZoneName: c.ReverseHostedZoneName,
+	}
+
+	m.recordSource = c.RecordSource
+	if m.recordSource == nil {
+		m.recordSource = &stackRecordSource{manager: m}
+	}
+
+	m.clusterDiscoverer = c.ClusterDiscoverer
+	if m.clusterDiscoverer == nil && c.Discovery == DiscoveryTags {
+		m.clusterDiscoverer = &tagClusterDiscoverer{manager: m}
+		m.discoveryGracePeriod = c.DiscoveryGracePeriod
+	}
+	if m.clusterDiscoverer == nil {
+		m.clusterDiscoverer = &stackClusterDiscoverer{manager: m}
 	}
 
 	return m, nil
 }
 
+// Sync creates, updates and deletes the target stacks of the clusters. The
+// whole run is retried up to m.syncRetries times while it fails with a
+// retryable error. The interval between attempts doubles up to
+// syncRetryMaxInterval. No attempt is made after the deadline of the run.
 func (m *Manager) Sync() error {
+	if m.targetOnly {
+		return microerror.Maskf(invalidConfigError, "Sync must not be called on a Manager with %T.TargetOnly set", Config{})
+	}
+
+	m.ctx = context.Background()
+	m.deadline = time.Time{}
+	if m.syncTimeout > 0 {
+		m.deadline = m.now().Add(m.syncTimeout)
+		var cancel context.CancelFunc
+		m.ctx, cancel = context.WithTimeout(m.ctx, m.syncTimeout)
+		defer cancel()
+	}
+
+	interval := syncRetryInitialInterval
+
+	for attempt := 0; ; attempt++ {
+		err := m.sync()
+		if IsRetryable(err) && attempt < m.syncRetries {
+			m.logger.Log("level", "warning", "message", fmt.Sprintf("sync run failed with retryable error, retrying in %s", interval), "stack", microerror.JSON(err))
+			deadlineErr := m.sleepBeforeDeadline(interval)
+			if deadlineErr != nil {
+				return microerror.Mask(deadlineErr)
+			}
+
+			interval *= 2
+			if interval > syncRetryMaxInterval {
+				interval = syncRetryMaxInterval
+			}
+			continue
+		} else if err != nil {
+			return microerror.Mask(err)
+		}
+
+		return nil
+	}
+}
+
+func (m *Manager) sync() error {
+	m.summary = syncSummary{}
+	m.taggedELBs = nil
+	m.etcdENIs = nil
+	defer m.flushClusterLogs()
+	defer m.observeClusterReconcile()
+
+	// The locks are acquired before the stacks are listed, so the plan is
+	// computed from a state no other instance changes. Dry runs do not
+	// change anything and are not locked.
+	if !m.dryRun {
+		locked, err := m.lockOrBackOff()
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		if !locked {
+			return nil
+		}
+		defer m.releaseLock()
+	}
+
 	sourceStacks, err := m.sourceStacks()
 	if err != nil {
 		return microerror.Mask(err)
@@ -169,47 +1276,157 @@ func (m *Manager) Sync() error {
 		return microerror.Mask(err)
 	}
 
-	err = m.createMissingTargetStacks(sourceStacks, targetStacks)
+	err = m.checkClusterNames(sourceStacks, targetStacks)
 	if err != nil {
 		return microerror.Mask(err)
 	}
 
-	err = m.updateCurrentTargetStacks(sourceStacks, targetStacks)
+	err = m.checkDeadline()
 	if err != nil {
 		return microerror.Mask(err)
 	}
 
-	err = m.deleteOrphanTargetStacks(sourceStacks, targetStacks)
+	if m.recreateCluster != "" {
+		err = m.recreateTargetStack(sourceStacks, targetStacks)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		m.logger.Log("level", "info", "message", m.summary.String())
+
+		return nil
+	}
+
+	sourceStacks, targetStacks = m.filterPausedClusters(sourceStacks, targetStacks)
+
+	p := m.computePlan(sourceStacks, targetStacks)
+	p.Deletes = m.confirmGoneClusters(p.Deletes)
+	p, err = m.filterCreatesNearRecordLimit(p)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = m.writePlanOutput(p)
 	if err != nil {
 		return microerror.Mask(err)
 	}
 
+	if m.dryRun {
+		err = m.reportPlan(p)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		m.logger.Log("level", "info", "message", "dry run: "+m.summary.String())
+
+		return nil
+	}
+
+	if m.applyMode == ApplyModeRoute53Atomic || m.applyMode == ApplyModeRoute53Direct {
+		err = m.applyRecordsAtomically(append(p.Creates, p.Updates...))
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	} else {
+		err = m.createMissingTargetStacks(p.Creates)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		err = m.updateCurrentTargetStacks(p.Updates)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	err = m.checkMassDeletion(p.Deletes, len(targetStacks)+len(m.recordClusterIDs))
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = m.deleteOrphanTargetStacks(p.Deletes)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = m.verifyApplied()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	m.logger.Log("level", "info", "message", m.summary.String())
+
+	if m.summaryHistoryFile != "" {
+		err = m.compareSummaryHistory()
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
 	return nil
 }
 
+// logSkipped logs a per stack debug message about a stack which is left
+// untouched. These messages are suppressed in quiet mode.
+func (m *Manager) logSkipped(message string) {
+	if m.quiet {
+		return
+	}
+
+	m.logger.Log("level", "debug", "message", message)
+}
+
+// sourceStacks returns the source stacks of all source accounts. It fails
+// when any source account cannot be listed, so target stacks are never
+// deleted based on the clusters of only some source accounts.
 func (m *Manager) sourceStacks() ([]cloudformation.Stack, error) {
-	result, err := getStacks(m.sourceClient, sourceStackNameREs, m.installation)
-	if err != nil {
-		return nil, microerror.Mask(err)
+	m.sourceAccounts = map[string]int{}
+	m.clusterSourceStacks = map[string]cloudformation.Stack{}
+
+	var result []cloudformation.Stack
+	for i, cl := range m.sourceClients {
+		stacks, err := m.accountSourceStacks(cl)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		result = append(result, m.claimSourceStacks(i, stacks)...)
 	}
-	m.logger.Log("level", "debug", "message", fmt.Sprintf("found source stacks: %v", getStacksName(result)))
+	m.logger.Log("level", "debug", "message", fmt.Sprintf("found source stacks: %v", getStacksNameWithKind(result)))
 	return result, nil
 }
 
 func (m *Manager) targetStacks() ([]cloudformation.Stack, error) {
-	result, err := getStacks(m.targetClient, targetStackNameREs, m.installation)
+	m.recordClusterIDs = nil
+
+	var result []cloudformation.Stack
+	var err error
+	if m.applyMode == ApplyModeRoute53Direct {
+		// There are no target stacks, the clusters are found by their
+		// metadata records only.
+	} else if m.scoped() {
+		result, err = m.getStacksByName(m.targetClient, m.scopedTargetStackNames())
+	} else {
+		result, err = m.getStacks(m.targetClient, targetStackNameREs)
+	}
 	if err != nil {
 		return nil, microerror.Mask(err)
 	}
-	m.logger.Log("level", "debug", "message", fmt.Sprintf("found target stacks: %v", getStacksName(result)))
+	if m.applyMode == ApplyModeRoute53Atomic || m.applyMode == ApplyModeRoute53Direct {
+		err = m.findRecordClusters(result)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		m.logger.Log("level", "debug", "message", fmt.Sprintf("found clusters without target stack by their metadata records: %v", m.recordClusterIDs))
+	}
+	m.logger.Log("level", "debug", "message", fmt.Sprintf("found target stacks: %v", getStacksNameWithKind(result)))
 	return result, nil
 }
 
-func getStacks(cl client.StackDescribeLister, res []*regexp.Regexp, installation string) ([]cloudformation.Stack, error) {
+func (m *Manager) getStacks(cl client.StackDescribeLister, res []*regexp.Regexp) ([]cloudformation.Stack, error) {
 	input := &cloudformation.ListStacksInput{
-		StackStatusFilter: stackStatusValid,
+		StackStatusFilter: plan.StackStatusValid,
 	}
-	output, err := cl.ListStacks(input)
+	output, err := cl.ListStacksWithContext(m.ctx, input)
 	if err != nil {
 		return nil, microerror.Mask(err)
 	}
@@ -223,15 +1440,13 @@ func getStacks(cl client.StackDescribeLister, res []*regexp.Regexp, installation
 		}
 
 		// filter stack by installation tag.
-		describeInput := &cloudformation.DescribeStacksInput{
-			StackName: aws.String(*item.StackId),
-		}
-		stacks, err := cl.DescribeStacks(describeInput)
+		stacks, err := describeStacks(m.ctx, cl, *item.StackId)
 		if err != nil {
 			return nil, microerror.Mask(err)
 		}
-		key := validStackInstallationTag(stacks, installation)
+		key := validStackInstallationTag(stacks, m.installation)
 		if key == -1 {
+			m.skip(*item.StackName, SkipReasonMissingInstallationTag, fmt.Sprintf("skipped stack %#q without installation tag %#q", *item.StackName, m.installation), nil)
 			continue
 		}
 
@@ -241,25 +1456,29 @@ func getStacks(cl client.StackDescribeLister, res []*regexp.Regexp, installation
 	return result, nil
 }
 
-// stackHasStatus checks if stack.StackStatus matches any of statues status.
-func stackHasStatus(stack cloudformation.Stack, statuses []string) bool {
-	if stack.StackStatus != nil {
-		for _, status := range statuses {
-			if *stack.StackStatus == status {
-				return true
-			}
-		}
+// describeStacks returns the stacks matching stackName across all pages of
+// the DescribeStacks output.
+func describeStacks(ctx context.Context, cl client.StackDescribeLister, stackName string) (*cloudformation.DescribeStacksOutput, error) {
+	result := &cloudformation.DescribeStacksOutput{}
+
+	input := &cloudformation.DescribeStacksInput{
+		StackName: aws.String(stackName),
 	}
+	for {
+		output, err := cl.DescribeStacksWithContext(ctx, input)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
 
-	return false
-}
+		result.Stacks = append(result.Stacks, output.Stacks...)
 
-func getStacksName(stacks []cloudformation.Stack) (names []string) {
-	for _, stack := range stacks {
-		names = append(names, *stack.StackName)
+		if output.NextToken == nil || *output.NextToken == "" {
+			break
+		}
+		input.NextToken = output.NextToken
 	}
 
-	return names
+	return result, nil
 }
 
 func validStackName(stack cloudformation.StackSummary, res []*regexp.Regexp) bool {
@@ -284,305 +1503,592 @@ func validStackInstallationTag(stacks *cloudformation.DescribeStacksOutput, inst
 	return -1
 }
 
-// createMissingTargetStacks ensures each source stack has a corresponding target stack created.
-// only source stack with StackStatus matching stackStatusValidSource are processed.
-// only target stack with StackStatus not matching stackStatusValidDelete are processed.
-func (m *Manager) createMissingTargetStacks(sourceStacks, targetStacks []cloudformation.Stack) error {
+// computePlan computes the plan for the given source and target stacks and
+// records the stacks it skips.
+func (m *Manager) computePlan(sourceStacks, targetStacks []cloudformation.Stack) plan.Plan {
+	c := plan.Config{
+		Now:                   m.now(),
+		MinStackAge:           m.minStackAge,
+		DeleteTriggerStatuses: m.deleteTriggerStatuses,
+		RecordClusterIDs:      m.recordClusterIDs,
+	}
+	p := plan.Compute(sourceStacks, targetStacks, c)
+
+	for _, s := range p.Skips {
+		m.skip(s.StackName, s.Reason, s.Message, s.Err)
+	}
+
+	return p
+}
+
+// createMissingTargetStacks creates the planned target stacks.
+func (m *Manager) createMissingTargetStacks(creates []plan.ClusterRef) error {
 	m.logger.Log("level", "debug", "message", "create missing target stacks")
-	for _, source := range sourceStacks {
-		found := false
+	for _, ref := range creates {
+		m.startClusterLogs()
+		m.startClusterReconcile(auditOperationCreate)
 
-		if !stackHasStatus(source, stackStatusValidSource) {
-			m.logger.Log("level", "debug", "message", fmt.Sprintf("skipped source stack %#q with status %#q", *source.StackName, *source.StackStatus))
-			continue
+		err := m.checkDeadline()
+		if err != nil {
+			return microerror.Mask(err)
 		}
-
-		sourceClusterName, err := extractClusterName(*source.StackName)
+		err = m.renewLock()
 		if err != nil {
-			m.logger.Log("level", "error", "message", fmt.Sprintf("failed to get source stack name %#q", *source.StackName), "stack", microerror.JSON(err))
-			continue
+			return microerror.Mask(err)
 		}
 
-		for _, target := range targetStacks {
-			if stackHasStatus(target, stackStatusValidDelete) {
-				m.logger.Log("level", "debug", "message", fmt.Sprintf("skipped target stack %#q with status %#q", *target.StackName, *target.StackStatus))
-				continue
-			}
+		source := *ref.SourceStack
 
-			targetClusterName, err := extractClusterName(*target.StackName)
-			if err != nil {
-				m.logger.Log("level", "error", "message", fmt.Sprintf("failed to get target stack name %#q", *target.StackName), "stack", microerror.JSON(err))
-				continue
-			}
+		records, err := m.getRecords(m.newCluster(ref))
+		if err != nil {
+			m.skipRecordsFailed(*source.StackName, ref.ID, err)
+			continue
+		}
 
-			if sourceClusterName == targetClusterName {
-				found = true
-				break
-			}
+		input, err := m.getCreateStackInput(ref.TargetStackName, records, source)
+		if err != nil {
+			m.logger.Log("level", "error", "message", fmt.Sprintf("failed to create target stack input %#q", ref.TargetStackName), "stack", microerror.JSON(err))
+			m.summary.failed++
+			continue
 		}
-		if !found {
-			isLegacyStack, err := sourceStackIsLegacy(*source.StackName)
-			if err != nil {
-				return microerror.Mask(err)
-			}
 
-			targetStackName := targetStackName(sourceClusterName)
-			data, err := m.getSourceStackData(sourceClusterName, isLegacyStack)
-			if err != nil {
-				m.logger.Log("level", "error", "message", fmt.Sprintf("failed to get source stack data %#q", sourceClusterName), "stack", microerror.JSON(err))
-				continue
-			}
+		input.TemplateBody, input.TemplateURL, err = m.getTemplateLocation(ref.TargetStackName, aws.StringValue(input.TemplateBody))
+		if err == nil {
+			_, err = m.targetClient.CreateStackWithContext(m.ctx, input)
+		}
+		m.audit(ref.ID, auditOperationCreate, ref.TargetStackName, auditOutcome(err), err)
+		if err != nil {
+			m.logger.Log("level", "error", "message", fmt.Sprintf("failed to create target stack %#q", ref.TargetStackName), "stack", microerror.JSON(err))
+			m.summary.failed++
+			continue
+		}
 
-			input, err := m.getCreateStackInput(targetStackName, data, source)
-			if err != nil {
-				m.logger.Log("level", "error", "message", fmt.Sprintf("failed to create target stack input %#q", targetStackName), "stack", microerror.JSON(err))
-				continue
-			}
+		m.logger.Log("level", "debug", "message", fmt.Sprintf("created target stack %#q", ref.TargetStackName))
+		m.summary.addCreated(ref.ID)
 
-			_, err = m.targetClient.CreateStack(input)
-			if err != nil {
-				m.logger.Log("level", "error", "message", fmt.Sprintf("failed to create target stack %#q", targetStackName), "stack", microerror.JSON(err))
-				continue
-			}
-
-			m.logger.Log("level", "debug", "message", fmt.Sprintf("created target stack %#q", targetStackName))
-		}
+		m.ensureClusterDelegation(ref)
 	}
+	m.flushClusterLogs()
+	m.observeClusterReconcile()
 	m.logger.Log("level", "debug", "message", "created missing target stacks")
 	return nil
 }
 
-// updateCurrentTargetStacks ensures each source stack has its corresponding target stack updated.
-// only source stack with StackStatus matching stackStatusValidSource are processed.
-// only target stack with StackStatus matching stackStatusValidTarget are processed.
-func (m *Manager) updateCurrentTargetStacks(sourceStacks, targetStacks []cloudformation.Stack) error {
+// updateCurrentTargetStacks updates the planned target stacks.
+func (m *Manager) updateCurrentTargetStacks(updates []plan.ClusterRef) error {
 	m.logger.Log("level", "debug", "message", "update current target stacks")
-	for _, source := range sourceStacks {
-		found := false
+	if m.skipUnchangedUpdates {
+		m.prefetchEtcdENIs(updates)
+	}
+	for _, ref := range updates {
+		m.startClusterLogs()
+		m.startClusterReconcile(auditOperationUpdate)
 
-		if !stackHasStatus(source, stackStatusValidSource) {
-			m.logger.Log("level", "debug", "message", fmt.Sprintf("skipped source stack %#q with status %#q", *source.StackName, *source.StackStatus))
-			continue
+		err := m.checkDeadline()
+		if err != nil {
+			return microerror.Mask(err)
 		}
-
-		sourceClusterName, err := extractClusterName(*source.StackName)
+		err = m.renewLock()
 		if err != nil {
-			m.logger.Log("level", "error", "message", fmt.Sprintf("failed to get source stack name %#q", *source.StackName), "stack", microerror.JSON(err))
-			continue
+			return microerror.Mask(err)
 		}
 
-		for _, target := range targetStacks {
-			if !stackHasStatus(target, stackStatusValidTarget) {
-				m.logger.Log("level", "debug", "message", fmt.Sprintf("skipped target stack %#q with status %#q", *target.StackName, *target.StackStatus))
-				continue
-			}
+		source := *ref.SourceStack
 
-			targetClusterName, err := extractClusterName(*target.StackName)
+		if ref.TargetStack != nil {
+			err = m.checkWeightToggle(source, *ref.TargetStack)
 			if err != nil {
-				m.logger.Log("level", "error", "message", fmt.Sprintf("failed to get target stack name %#q", *target.StackName), "stack", microerror.JSON(err))
+				m.skip(ref.TargetStackName, SkipReasonWeightToggled, fmt.Sprintf("not updating target stack %#q", ref.TargetStackName), err)
+				m.summary.failed++
 				continue
 			}
+		}
 
-			if sourceClusterName == targetClusterName {
-				found = true
-				break
-			}
+		records, err := m.getRecords(m.newCluster(ref))
+		if err != nil {
+			m.skipRecordsFailed(*source.StackName, ref.ID, err)
+			continue
+		}
+
+		input, err := m.getUpdateStackInput(ref.TargetStackName, records, source)
+		if err != nil {
+			m.logger.Log("level", "error", "message", fmt.Sprintf("failed to create target stack input %#q", ref.TargetStackName), "stack", microerror.JSON(err))
+			m.summary.failed++
+			continue
 		}
-		if found {
-			isLegacyStack, err := sourceStackIsLegacy(*source.StackName)
-			if err != nil {
-				return microerror.Mask(err)
-			}
 
-			targetStackName := targetStackName(sourceClusterName)
-			data, err := m.getSourceStackData(sourceClusterName, isLegacyStack)
+		if ref.TargetStack != nil {
+			m.applyOwnership(input, *ref.TargetStack)
+			m.applyPreservedTags(input, *ref.TargetStack)
+			m.applyCurrentChangeReason(input, *ref.TargetStack)
+		}
+
+		if m.skipUnchangedUpdates && ref.TargetStack != nil {
+			unchanged, err := m.isUnchangedUpdate(input, *ref.TargetStack, records)
 			if err != nil {
-				m.logger.Log("level", "error", "message", fmt.Sprintf("failed to get source stack data %#q", sourceClusterName), "stack", microerror.JSON(err))
+				m.logger.Log("level", "error", "message", fmt.Sprintf("failed to compare template of target stack %#q", ref.TargetStackName), "stack", microerror.JSON(err))
+				m.summary.failed++
+				continue
+			}
+			if unchanged {
+				m.logSkipped(fmt.Sprintf("skipped target stack %#q (etcd ENI IPs and template unchanged)", ref.TargetStackName))
+				m.summary.unchanged++
+				m.ensureClusterDelegation(ref)
 				continue
 			}
+		}
 
-			input, err := m.getUpdateStackInput(targetStackName, data, source)
+		if m.tagOnlyUpdates && ref.TargetStack != nil {
+			tagOnly, err := m.isTagOnlyUpdate(input, *ref.TargetStack)
 			if err != nil {
-				m.logger.Log("level", "error", "message", fmt.Sprintf("failed to create target stack input %#q", targetStackName), "stack", microerror.JSON(err))
+				m.logger.Log("level", "error", "message", fmt.Sprintf("failed to get template of target stack %#q", ref.TargetStackName), "stack", microerror.JSON(err))
+				m.summary.failed++
 				continue
 			}
+			if tagOnly {
+				m.logger.Log("level", "debug", "message", fmt.Sprintf("updating tags of target stack %#q only", ref.TargetStackName))
+				input.TemplateBody = nil
+				input.UsePreviousTemplate = aws.Bool(true)
+			}
+		}
 
-			_, err = m.targetClient.UpdateStack(input)
-			if IsNoUpdateNeededError(err) {
-				m.logger.Log("level", "debug", "message", fmt.Sprintf("skipped target stack %#q (already up to date)", targetStackName))
-			} else if err != nil {
-				m.logger.Log("level", "error", "message", fmt.Sprintf("failed to update target stack %#q", targetStackName), "stack", microerror.JSON(err))
-			} else {
-				m.logger.Log("level", "debug", "message", fmt.Sprintf("updated target stack %#q", targetStackName))
+		var pending bool
+		if m.useChangeSets {
+			pending, err = m.updateTargetStackWithChangeSet(input)
+		} else {
+			err = m.locateUpdateTemplate(input)
+			if err == nil {
+				_, err = m.targetClient.UpdateStackWithContext(m.ctx, input)
 			}
 		}
+		if IsNoUpdateNeededError(err) {
+			m.logSkipped(fmt.Sprintf("skipped target stack %#q (already up to date)", ref.TargetStackName))
+			m.summary.unchanged++
+			m.ensureClusterDelegation(ref)
+		} else if err != nil {
+			m.audit(ref.ID, auditOperationUpdate, ref.TargetStackName, auditOutcomeFailure, err)
+			m.logger.Log("level", "error", "message", fmt.Sprintf("failed to update target stack %#q", ref.TargetStackName), "stack", microerror.JSON(err))
+			m.summary.failed++
+		} else if pending {
+			m.audit(ref.ID, auditOperationUpdate, ref.TargetStackName, auditOutcomePending, nil)
+			m.skip(ref.TargetStackName, SkipReasonChangeSetPending, fmt.Sprintf("left update of target stack %#q for manual change set execution", ref.TargetStackName), nil)
+		} else {
+			m.audit(ref.ID, auditOperationUpdate, ref.TargetStackName, auditOutcomeSuccess, nil)
+			m.logger.Log("level", "debug", "message", fmt.Sprintf("updated target stack %#q", ref.TargetStackName))
+			m.summary.addUpdated(ref.ID)
+			m.ensureClusterDelegation(ref)
+		}
 	}
+	m.flushClusterLogs()
+	m.observeClusterReconcile()
 	m.logger.Log("level", "debug", "message", "updated current target stacks")
 	return nil
 }
 
-// deleteOrphanTargetStacks ensures each target stack with no corresponding source stack is deleted.
-// only source stack with StackStatus not matching stackStatusValidDelete are processed.
-// only target stack with StackStatus not matching stackStatusValidDelete are processed.
-func (m *Manager) deleteOrphanTargetStacks(sourceStacks, targetStacks []cloudformation.Stack) error {
+// deleteOrphanTargetStacks deletes the planned orphan target stacks.
+func (m *Manager) deleteOrphanTargetStacks(deletes []plan.ClusterRef) error {
 	m.logger.Log("level", "debug", "message", "delete orphan target stacks")
-	for _, target := range targetStacks {
-		found := false
 
-		if stackHasStatus(target, stackStatusValidDelete) {
-			m.logger.Log("level", "debug", "message", fmt.Sprintf("skipped target stack %#q with status %#q", *target.StackName, *target.StackStatus))
-			continue
-		}
+	// Failures of the delete phase itself are counted too, so the failures of
+	// the create and update phases are captured before it starts.
+	createUpdateFailed := m.deleteOnlyOnCleanSync && m.summary.failed > 0
+	if createUpdateFailed && len(deletes) > 0 {
+		m.logger.Log("level", "warning", "message", fmt.Sprintf("keeping %d orphan target stacks, %d clusters failed to be created or updated", len(deletes), m.summary.failed))
+	}
 
-		targetClusterName, err := extractClusterName(*target.StackName)
+	for _, ref := range deletes {
+		m.startClusterLogs()
+		m.startClusterReconcile(auditOperationDelete)
+
+		err := m.checkDeadline()
 		if err != nil {
-			m.logger.Log("level", "error", "message", fmt.Sprintf("failed to get target stack name %#q", *target.StackName), "stack", microerror.JSON(err))
-			continue
+			return microerror.Mask(err)
+		}
+		err = m.renewLock()
+		if err != nil {
+			return microerror.Mask(err)
 		}
 
-		for _, source := range sourceStacks {
-			if stackHasStatus(source, stackStatusValidDelete) {
-				m.logger.Log("level", "debug", "message", fmt.Sprintf("skipped source stack %#q with status %#q", *source.StackName, *source.StackStatus))
-				continue
-			}
+		if m.disableOrphanDeletion {
+			m.skip(ref.TargetStackName, SkipReasonOrphanDeletionDisabled, fmt.Sprintf("would delete orphan target stack %#q, orphan deletion is disabled", ref.TargetStackName), nil)
+			continue
+		}
+		if createUpdateFailed {
+			m.skip(ref.TargetStackName, SkipReasonCreateUpdateFailed, fmt.Sprintf("would delete orphan target stack %#q, creating or updating target stacks failed", ref.TargetStackName), nil)
+			continue
+		}
 
-			sourceClusterName, err := extractClusterName(*source.StackName)
+		if m.confirmOrphans {
+			exists, err := m.orphanSourceExists(ref.ID)
 			if err != nil {
-				m.logger.Log("level", "error", "message", fmt.Sprintf("failed to get source stack name %#q", *source.StackName), "stack", microerror.JSON(err))
+				m.logger.Log("level", "error", "message", fmt.Sprintf("failed to confirm source stack of orphan target stack %#q is gone", ref.TargetStackName), "stack", microerror.JSON(err))
+				m.summary.failed++
 				continue
 			}
-
-			if sourceClusterName == targetClusterName {
-				found = true
-				break
+			if exists {
+				m.skip(ref.TargetStackName, SkipReasonSourceReappeared, fmt.Sprintf("kept orphan target stack %#q, source stack of cluster %#q reappeared", ref.TargetStackName, ref.ID), nil)
+				continue
 			}
 		}
-		if !found {
-			err := m.deleteTargetStack(*target.StackName)
-			if err != nil {
-				m.logger.Log("level", "error", "message", fmt.Sprintf("failed to delete target stack %#q", *target.StackName), "stack", microerror.JSON(err))
-			} else {
-				m.logger.Log("level", "debug", "message", fmt.Sprintf("deleted target stack %#q", *target.StackName))
-			}
-
-			err = m.deleteTargetLeftovers(targetClusterName)
-			if err != nil {
-				m.logger.Log("level", "error", "message", "failed to delete target record sets leftovers")
-			} else {
-				m.logger.Log("level", "debug", "message", "deleted target record sets leftovers")
-			}
 
-		}
+		m.deleteOrphanTargetStack(ref, refTags(ref))
 	}
+	m.flushClusterLogs()
+	m.observeClusterReconcile()
 	m.logger.Log("level", "debug", "message", "deleted orphan target stacks")
 	return nil
 }
 
+// deleteOrphanTargetStack deletes the target stack and the leftover record
+// sets of the cluster with the given stack tags in the configured
+// m.deletionOrder. With m.deletionStopOnFailure the second step is skipped
+// when the first failed. For clusters without target stack, see
+// plan.Config.RecordClusterIDs, the records of the cluster are deleted
+// instead.
+func (m *Manager) deleteOrphanTargetStack(ref plan.ClusterRef, tags map[string]string) {
+	targetStackName, targetClusterName := ref.TargetStackName, ref.ID
+	if ref.TargetStack == nil {
+		m.deleteDirectClusterRecords(targetClusterName)
+		return
+	}
+
+	deleteStack := func() bool {
+		err := m.deleteTargetStack(targetStackName)
+		m.audit(targetClusterName, auditOperationDelete, targetStackName, auditOutcome(err), err)
+		if err != nil {
+			m.logger.Log("level", "error", "message", fmt.Sprintf("failed to delete target stack %#q", targetStackName), "stack", microerror.JSON(err))
+			m.summary.failed++
+			return false
+		}
+
+		m.logger.Log("level", "debug", "message", fmt.Sprintf("deleted target stack %#q", targetStackName))
+		m.summary.addDeleted(targetClusterName)
+		return true
+	}
+	deleteLeftovers := func() bool {
+		err := m.deleteTargetLeftovers(targetClusterName, tags)
+		if err != nil {
+			m.logger.Log("level", "error", "message", "failed to delete target record sets leftovers", "stack", microerror.JSON(err))
+			return false
+		}
+
+		m.logger.Log("level", "debug", "message", "deleted target record sets leftovers")
+		return true
+	}
+
+	first, second := deleteStack, deleteLeftovers
+	if m.deletionOrder == DeletionOrderRecordsFirst {
+		first, second = deleteLeftovers, deleteStack
+	}
+
+	if !first() && m.deletionStopOnFailure {
+		m.logger.Log("level", "debug", "message", fmt.Sprintf("skipped remaining deletion of target stack %#q after failure", targetStackName))
+		return
+	}
+	second()
+}
+
+// deleteTargetStack deletes the given target stack. Stacks which are already
+// being deleted or do not exist anymore are considered deleted.
 func (m *Manager) deleteTargetStack(targetStackName string) error {
 	input := &cloudformation.DeleteStackInput{
 		StackName: aws.String(targetStackName),
 	}
-	_, err := m.targetClient.DeleteStack(input)
-	if err != nil {
+	_, err := m.targetClient.DeleteStackWithContext(m.ctx, input)
+	if IsStackNotFound(err) {
+		m.logger.Log("level", "debug", "message", fmt.Sprintf("target stack %#q already deleted", targetStackName))
+		return nil
+	} else if err != nil {
+		deleting, describeErr := m.targetStackDeleting(targetStackName)
+		if describeErr != nil {
+			m.logger.Log("level", "error", "message", fmt.Sprintf("failed to describe target stack %#q", targetStackName), "stack", microerror.JSON(describeErr))
+		} else if deleting {
+			m.logger.Log("level", "debug", "message", fmt.Sprintf("target stack %#q already being deleted", targetStackName))
+			return nil
+		}
+
 		return microerror.Mask(err)
 	}
 	return nil
 }
 
-func (m *Manager) deleteTargetLeftovers(targetClusterName string) error {
-	input := &route53.ListResourceRecordSetsInput{
-		HostedZoneId: &m.targetHostedZoneID,
+// targetStackDeleting checks whether the given target stack is being deleted
+// or is already gone.
+func (m *Manager) targetStackDeleting(targetStackName string) (bool, error) {
+	stacks, err := describeStacks(m.ctx, m.targetClient, targetStackName)
+	if IsStackNotFound(err) {
+		return true, nil
+	} else if err != nil {
+		return false, microerror.Mask(err)
 	}
-	o, err := m.targetClient.ListResourceRecordSets(input)
 
-	if err != nil {
-		return microerror.Mask(err)
+	for _, stack := range stacks.Stacks {
+		if !plan.HasStatus(*stack, plan.StackStatusDeleting) {
+			return false, nil
+		}
 	}
 
-	resourceRecordSets := o.ResourceRecordSets
+	return true, nil
+}
+
+// deleteTargetLeftovers deletes the record sets of the cluster with the given
+// stack tags which are not managed by its target stack. When etcd records
+// live in their own hosted zone, each zone is only checked against the record
+// sets managed in it.
+func (m *Manager) deleteTargetLeftovers(targetClusterName string, tags map[string]string) error {
+	main, etcd := m.clusterHostedZones(tags)
 
-	route53Changes := []*route53.Change{}
-	for _, rr := range resourceRecordSets {
-		rrPattern := fmt.Sprintf("^*.%s.%s.$", targetClusterName, m.targetHostedZoneName)
-		match, err := regexp.Match(rrPattern, []byte(*rr.Name))
+	if etcd.ID == main.ID {
+		managedRecordSets := getManagedRecordSets(targetClusterName, main.Name, m.components, m.caaValue != "", m.bareDomainRecord, m.metadataRecord)
+
+		err := m.deleteHostedZoneLeftovers(main.ID, main.Name, targetClusterName, managedRecordSets)
 		if err != nil {
 			return microerror.Mask(err)
 		}
 
-		managedRecordSets := getManagedRecordSets(targetClusterName, m.targetHostedZoneName)
-		if match && !stringInSlice(*rr.Name, managedRecordSets) {
-			route53Change := &route53.Change{
-				Action: aws.String("DELETE"),
-				ResourceRecordSet: &route53.ResourceRecordSet{
-					AliasTarget:     rr.AliasTarget,
-					Name:            rr.Name,
-					ResourceRecords: rr.ResourceRecords,
-					TTL:             rr.TTL,
-					Type:            rr.Type,
-					Weight:          rr.Weight,
-					SetIdentifier:   rr.SetIdentifier,
-				},
-			}
+		return nil
+	}
 
-			route53Changes = append(route53Changes, route53Change)
+	{
+		managedRecordSets := getManagedMainRecordSets(targetClusterName, main.Name, m.components, m.caaValue != "", m.bareDomainRecord, m.metadataRecord)
 
-			m.logger.Log("level", "debug", "message", fmt.Sprintf("found non-managed record set %#q in hosted zone %#q", *rr.Name, m.targetHostedZoneID))
+		err := m.deleteHostedZoneLeftovers(main.ID, main.Name, targetClusterName, managedRecordSets)
+		if err != nil {
+			return microerror.Mask(err)
 		}
 	}
 
-	if len(route53Changes) > 0 {
-		m.logger.Log("level", "debug", "message", fmt.Sprintf("deleting non-managed record sets in hosted zone %#q", m.targetHostedZoneID))
+	{
+		managedRecordSets := getManagedEtcdRecordSets(targetClusterName, etcd.Name, m.components)
 
-		changeRecordSetInput := &route53.ChangeResourceRecordSetsInput{
-			ChangeBatch: &route53.ChangeBatch{
-				Changes: route53Changes,
-			},
-			HostedZoneId: &m.targetHostedZoneID,
+		err := m.deleteHostedZoneLeftovers(etcd.ID, etcd.Name, targetClusterName, managedRecordSets)
+		if err != nil {
+			return microerror.Mask(err)
 		}
+	}
+
+	return nil
+}
 
-		_, err = m.targetClient.ChangeResourceRecordSets(changeRecordSetInput)
+func (m *Manager) deleteHostedZoneLeftovers(hostedZoneID, hostedZoneName, targetClusterName string, managedRecordSets []string) error {
+	rrRE, err := leftoverRecordSetRE(targetClusterName, hostedZoneName)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	input := &route53.ListResourceRecordSetsInput{
+		HostedZoneId: aws.String(hostedZoneID),
+	}
+
+	checkpoint, err := m.readLeftoverCheckpoint(hostedZoneID, targetClusterName)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	if checkpoint != nil {
+		m.logger.Log("level", "debug", "message", fmt.Sprintf("resuming leftover cleanup of cluster %#q in hosted zone %#q at record set %#q", targetClusterName, hostedZoneID, checkpoint.StartRecordName))
+		input.StartRecordName = aws.String(checkpoint.StartRecordName)
+		input.StartRecordType = aws.String(checkpoint.StartRecordType)
+		if checkpoint.StartRecordIdentifier != "" {
+			input.StartRecordIdentifier = aws.String(checkpoint.StartRecordIdentifier)
+		}
+	}
+
+	// The record sets are deleted page by page, so the cleanup of large
+	// hosted zones can resume from the checkpoint of the last deleted page.
+	deleted := false
+	for {
+		output, err := m.targetClient.ListResourceRecordSetsWithContext(m.ctx, input)
 		if err != nil {
 			return microerror.Mask(err)
 		}
 
-		m.logger.Log("level", "debug", "message", fmt.Sprintf("deleted non-managed record sets in hosted zone %#q", m.targetHostedZoneID))
+		route53Changes := []*route53.Change{}
+		for _, rr := range output.ResourceRecordSets {
+			name := normalizeRecordName(*rr.Name)
+			matched := rrRE.MatchString(name)
+			managed := stringInSlice(name, managedRecordSets)
+			if m.explainCleanup {
+				m.explainLeftover(hostedZoneID, targetClusterName, rr, matched, managed)
+			}
+			if matched && !managed {
+				route53Change := &route53.Change{
+					Action: aws.String("DELETE"),
+					ResourceRecordSet: &route53.ResourceRecordSet{
+						AliasTarget:     rr.AliasTarget,
+						Name:            rr.Name,
+						ResourceRecords: rr.ResourceRecords,
+						TTL:             rr.TTL,
+						Type:            rr.Type,
+						Weight:          rr.Weight,
+						SetIdentifier:   rr.SetIdentifier,
+					},
+				}
+
+				route53Changes = append(route53Changes, route53Change)
+
+				m.logger.Log("level", "debug", "message", fmt.Sprintf("found non-managed record set %#q in hosted zone %#q", *rr.Name, hostedZoneID))
+			}
+		}
+
+		if len(route53Changes) > 0 {
+			if deleted && m.leftoverChangeInterval > 0 {
+				m.sleep(m.leftoverChangeInterval)
+			}
+
+			m.logger.Log("level", "debug", "message", fmt.Sprintf("deleting non-managed record sets in hosted zone %#q", hostedZoneID))
+
+			changeRecordSetInput := &route53.ChangeResourceRecordSetsInput{
+				ChangeBatch: &route53.ChangeBatch{
+					Changes: route53Changes,
+					Comment: m.changeComment(fmt.Sprintf("route53-manager leftover cleanup of cluster %s", targetClusterName)),
+				},
+				HostedZoneId: aws.String(hostedZoneID),
+			}
+
+			output, err := m.changeResourceRecordSets(m.targetClient, changeRecordSetInput)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+
+			err = m.waitForChange(output.ChangeInfo)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+			deleted = true
+
+			m.logger.Log("level", "debug", "message", fmt.Sprintf("deleted non-managed record sets in hosted zone %#q", hostedZoneID))
+		}
+
+		if !aws.BoolValue(output.IsTruncated) {
+			break
+		}
+		input.StartRecordName = output.NextRecordName
+		input.StartRecordType = output.NextRecordType
+		input.StartRecordIdentifier = output.NextRecordIdentifier
+
+		err = m.writeLeftoverCheckpoint(leftoverCheckpoint{
+			HostedZoneID:          hostedZoneID,
+			Cluster:               targetClusterName,
+			StartRecordName:       aws.StringValue(output.NextRecordName),
+			StartRecordType:       aws.StringValue(output.NextRecordType),
+			StartRecordIdentifier: aws.StringValue(output.NextRecordIdentifier),
+		})
+		if err != nil {
+			return microerror.Mask(err)
+		}
 	}
 
+	err = m.removeLeftoverCheckpoint(hostedZoneID, targetClusterName)
 	if err != nil {
 		return microerror.Mask(err)
 	}
+
 	return nil
 }
 
-func sourceStackIsLegacy(sourceStackName string) (bool, error) {
-	return regexp.Match(legacySourceStackNamePattern, []byte(sourceStackName))
+func (m *Manager) getRecords(cluster Cluster) ([]DesiredRecord, error) {
+	records, err := m.recordSource.Records(m.ctx, cluster)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	err = validateRecords(records)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	records, err = m.flattenApexCNAMEs(cluster, normalizeCNAMERecords(records))
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return records, nil
 }
 
-func targetStackName(clusterName string) string {
-	targetStackNameFmt := strings.Replace(targetStackNamePattern, ".*", "%s", 1)
+// getManagedRecordSets returns the names of all record sets of the cluster
+// managed by its target stack when all of them live in the same hosted zone.
+func getManagedRecordSets(clusterID, baseDomain string, components []Component, caa, bare, metadata bool) []string {
+	recordSets := getManagedMainRecordSets(clusterID, baseDomain, components, caa, bare, metadata)
+	recordSets = append(recordSets, getManagedEtcdRecordSets(clusterID, baseDomain, components)...)
 
-	return fmt.Sprintf(targetStackNameFmt, clusterName)
+	return recordSets
 }
 
-func extractClusterName(sourceStackName string) (string, error) {
-	parts := strings.Split(sourceStackName, "-")
-	if len(parts) >= 2 {
-		return parts[1], nil
+// getManagedMainRecordSets returns the names of the record sets of the cluster
+// managed in the target hosted zone, i.e. all but the etcd ones. The cluster
+// domain itself is only managed when it gets a CAA or a bare domain record,
+// the metadata record only when enabled.
+func getManagedMainRecordSets(clusterID, baseDomain string, components []Component, caa, bare, metadata bool) []string {
+	recordSets := []string{
+		normalizeRecordName(fmt.Sprintf("*.%s.%s", clusterID, baseDomain)),
+	}
+	if caa || bare {
+		recordSets = append(recordSets, normalizeRecordName(fmt.Sprintf("%s.%s", clusterID, baseDomain)))
+	}
+	if metadata {
+		recordSets = append(recordSets, normalizeRecordName(fmt.Sprintf("%s%s.%s", metadataRecordPrefix, clusterID, baseDomain)))
+	}
+	for _, c := range components {
+		if c.Name == etcdComponentName {
+			continue
+		}
+		recordSets = append(recordSets, normalizeRecordName(fmt.Sprintf("%s.%s.%s", c.Name, clusterID, baseDomain)))
 	}
 
-	return "", microerror.Maskf(invalidClusterNameError, "cluster name %#q", sourceStackName)
+	return recordSets
 }
 
-func getManagedRecordSets(clusterID, baseDomain string) []string {
-	return []string{
-		fmt.Sprintf("\\052.%s.%s.", clusterID, baseDomain), // \\052 - `*` wildcard record
-		fmt.Sprintf("api.%s.%s.", clusterID, baseDomain),
-		fmt.Sprintf("etcd.%s.%s.", clusterID, baseDomain),
-		fmt.Sprintf("etcd1.%s.%s.", clusterID, baseDomain),
-		fmt.Sprintf("etcd2.%s.%s.", clusterID, baseDomain),
-		fmt.Sprintf("etcd3.%s.%s.", clusterID, baseDomain),
-		fmt.Sprintf("ingress.%s.%s.", clusterID, baseDomain),
+// getManagedEtcdRecordSets returns the names of the record sets of the cluster
+// managed in the etcd hosted zone.
+func getManagedEtcdRecordSets(clusterID, baseDomain string, components []Component) []string {
+	var recordSets []string
+	for _, c := range components {
+		if c.Name == etcdComponentName {
+			recordSets = append(recordSets, normalizeRecordName(fmt.Sprintf("%s.%s.%s", c.Name, clusterID, baseDomain)))
+		}
 	}
+	recordSets = append(recordSets,
+		normalizeRecordName(fmt.Sprintf("etcd1.%s.%s", clusterID, baseDomain)),
+		normalizeRecordName(fmt.Sprintf("etcd2.%s.%s", clusterID, baseDomain)),
+		normalizeRecordName(fmt.Sprintf("etcd3.%s.%s", clusterID, baseDomain)),
+	)
+
+	return recordSets
+}
+
+func validateComponents(components []Component) error {
+	names := map[string]bool{}
+	for _, c := range components {
+		if !componentNameRE.MatchString(c.Name) {
+			return microerror.Maskf(invalidConfigError, "component name %#q must match %#q", c.Name, componentNameRE.String())
+		}
+		if c.ELBSuffix == "" {
+			return microerror.Maskf(invalidConfigError, "component %#q ELB suffix must not be empty", c.Name)
+		}
+		if names[c.Name] {
+			return microerror.Maskf(invalidConfigError, "component %#q must not be declared twice", c.Name)
+		}
+		names[c.Name] = true
+	}
+
+	return nil
+}
+
+func validateELBDNSOutputKeys(outputKeys map[string]string, components []Component) error {
+	names := map[string]bool{}
+	for _, c := range components {
+		names[c.Name] = true
+	}
+	for name, outputKey := range outputKeys {
+		if !names[name] {
+			return microerror.Maskf(invalidConfigError, "ELB DNS output key component %#q must be declared", name)
+		}
+		if outputKey == "" {
+			return microerror.Maskf(invalidConfigError, "component %#q ELB DNS output key must not be empty", name)
+		}
+	}
+
+	return nil
 }
 
 func stringInSlice(str string, list []string) bool {
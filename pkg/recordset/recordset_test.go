@@ -1,13 +1,18 @@
 package recordset
 
 import (
+	"errors"
 	"io/ioutil"
 	"reflect"
 	"sort"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/route53"
 	"github.com/giantswarm/micrologger"
 )
 
@@ -131,7 +136,7 @@ func TestCreateMissingStacks_Cases(t *testing.T) {
 				t.Fatalf("NewManager: %v", err)
 			}
 
-			err = m.createMissingTargetStacks(tc.sourceStacks, tc.targetStacks)
+			err = m.createMissingTargetStacks(m.computePlan(tc.sourceStacks, tc.targetStacks).Creates)
 			if err != nil {
 				t.Fatalf("m.Sync: %v", err)
 			}
@@ -283,7 +288,7 @@ func TestCreateMissingStacks_Statuses(t *testing.T) {
 				t.Fatalf("NewManager: %v", err)
 			}
 
-			err = m.createMissingTargetStacks(sourceStacks, nil)
+			err = m.createMissingTargetStacks(m.computePlan(sourceStacks, nil).Creates)
 			if err != nil {
 				t.Fatalf("m.Sync: %v", err)
 			}
@@ -431,7 +436,7 @@ func TestUpdateCurrentTargetStacks_Cases(t *testing.T) {
 				t.Fatalf("NewManager: %v", err)
 			}
 
-			err = m.updateCurrentTargetStacks(tc.sourceStacks, tc.targetStacks)
+			err = m.updateCurrentTargetStacks(m.computePlan(tc.sourceStacks, tc.targetStacks).Updates)
 			if err != nil {
 				t.Fatalf("m.Sync: %v", err)
 			}
@@ -594,7 +599,7 @@ func TestUpdateCurrentTargetStacks_SourceStatuses(t *testing.T) {
 				t.Fatalf("NewManager: %v", err)
 			}
 
-			err = m.updateCurrentTargetStacks(sourceStacks, targetStacks)
+			err = m.updateCurrentTargetStacks(m.computePlan(sourceStacks, targetStacks).Updates)
 			if err != nil {
 				t.Fatalf("m.Sync: %v", err)
 			}
@@ -756,7 +761,7 @@ func TestUpdateCurrentTargetStacks_TargetStatuses(t *testing.T) {
 				t.Fatalf("NewManager: %v", err)
 			}
 
-			err = m.updateCurrentTargetStacks(sourceStacks, targetStacks)
+			err = m.updateCurrentTargetStacks(m.computePlan(sourceStacks, targetStacks).Updates)
 			if err != nil {
 				t.Fatalf("m.Sync: %v", err)
 			}
@@ -875,7 +880,7 @@ func TestDeleteOrphanTargetStacks_Cases(t *testing.T) {
 	for _, tc := range tcs {
 		targetClient.deletedStacks = []string{}
 		t.Run(tc.name, func(t *testing.T) {
-			err := m.deleteOrphanTargetStacks(tc.sourceStacks, tc.targetStacks)
+			err := m.deleteOrphanTargetStacks(m.computePlan(tc.sourceStacks, tc.targetStacks).Deletes)
 			if err != nil {
 				t.Fatalf("could not create manager %#v", err)
 			}
@@ -1037,7 +1042,7 @@ func TestDeleteOrphanTargetStacks_Statuses(t *testing.T) {
 				t.Fatalf("NewManager: %v", err)
 			}
 
-			err = m.deleteOrphanTargetStacks(sourceStacks, targetStacks)
+			err = m.deleteOrphanTargetStacks(m.computePlan(sourceStacks, targetStacks).Deletes)
 			if err != nil {
 				t.Fatalf("m.Sync: %v", err)
 			}
@@ -1051,54 +1056,956 @@ func TestDeleteOrphanTargetStacks_Statuses(t *testing.T) {
 	}
 }
 
-func TestStackHasStatus(t *testing.T) {
+func TestGetStacks_DescribeStacksPagination(t *testing.T) {
+	installation := "installation"
+
+	sourceClient := newSourceWithStacks([]cloudformation.Stack{
+		cloudformation.Stack{
+			StackName:   aws.String("cluster-foo-tccp"),
+			StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+		},
+	})
+	sourceClient.describeStacksPages = [][]*cloudformation.Stack{
+		{
+			&cloudformation.Stack{
+				StackName:   aws.String("cluster-foo-tccp"),
+				StackStatus: aws.String(cloudformation.StackStatusDeleteComplete),
+			},
+		},
+		{
+			&cloudformation.Stack{
+				StackName:   aws.String("cluster-foo-tccp"),
+				StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+				Tags: []*cloudformation.Tag{
+					&cloudformation.Tag{
+						Key:   aws.String(installationTag),
+						Value: aws.String(installation),
+					},
+				},
+			},
+		},
+	}
+
+	c := newTestConfig(t)
+	c.Installation = installation
+	c.SourceClient = sourceClient
+	m, err := NewManager(c)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	stacks, err := m.getStacks(sourceClient, sourceStackNameREs)
+	if err != nil {
+		t.Fatalf("getStacks: %v", err)
+	}
+
+	if len(stacks) != 1 {
+		t.Fatalf("expected 1 stack, got %d", len(stacks))
+	}
+	if *stacks[0].StackStatus != cloudformation.StackStatusCreateComplete {
+		t.Errorf("expected stack from second page with status %#q, got %#q", cloudformation.StackStatusCreateComplete, *stacks[0].StackStatus)
+	}
+}
+
+func TestDeleteTargetStack_Idempotent(t *testing.T) {
 	tcs := []struct {
-		name     string
-		input    cloudformation.Stack
-		statuses []string
-		expected bool
+		name             string
+		targetStacks     []cloudformation.Stack
+		deleteStackError error
+		expectError      bool
 	}{
 		{
-			name:     "case 0: zero value inputs",
-			input:    cloudformation.Stack{},
-			statuses: nil,
-			expected: false,
+			name: "case 0: delete existing stack",
+			targetStacks: []cloudformation.Stack{
+				cloudformation.Stack{
+					StackName:   aws.String("cluster-foo-guest-recordsets"),
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+				},
+			},
+			deleteStackError: nil,
+			expectError:      false,
 		},
 		{
-			name: "case 1: empty statuses",
-			input: cloudformation.Stack{
-				StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+			name:             "case 1: stack already deleted",
+			targetStacks:     nil,
+			deleteStackError: awserr.New("ValidationError", "Stack with id cluster-foo-guest-recordsets does not exist", nil),
+			expectError:      false,
+		},
+		{
+			name: "case 2: stack already being deleted",
+			targetStacks: []cloudformation.Stack{
+				cloudformation.Stack{
+					StackName:   aws.String("cluster-foo-guest-recordsets"),
+					StackStatus: aws.String(cloudformation.StackStatusDeleteInProgress),
+				},
 			},
-			statuses: []string{},
-			expected: false,
+			deleteStackError: awserr.New("Throttling", "Rate exceeded", nil),
+			expectError:      false,
 		},
 		{
-			name: "case 2: non matching statuses",
-			input: cloudformation.Stack{
-				StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+			name:             "case 3: stack gone when deletion failed",
+			targetStacks:     nil,
+			deleteStackError: awserr.New("Throttling", "Rate exceeded", nil),
+			expectError:      false,
+		},
+		{
+			name: "case 4: deletion failed for existing stack",
+			targetStacks: []cloudformation.Stack{
+				cloudformation.Stack{
+					StackName:   aws.String("cluster-foo-guest-recordsets"),
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+				},
+			},
+			deleteStackError: awserr.New("Throttling", "Rate exceeded", nil),
+			expectError:      true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			targetClient := newTargetWithStacks(tc.targetStacks)
+			targetClient.deleteStackError = tc.deleteStackError
+			targetClient.stackNotFoundErrors = true
+
+			c := newTestConfig(t)
+			c.TargetClient = targetClient
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			err = m.deleteTargetStack("cluster-foo-guest-recordsets")
+			if tc.expectError && err == nil {
+				t.Errorf("expected error, got nil")
+			} else if !tc.expectError && err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestCreateMissingStacks_TargetDeleteInProgress(t *testing.T) {
+	installation := "installation"
+	tags := []*cloudformation.Tag{
+		&cloudformation.Tag{
+			Key:   aws.String(installationTag),
+			Value: aws.String(installation),
+		},
+	}
+
+	sourceStacks := []cloudformation.Stack{
+		cloudformation.Stack{
+			StackName:   aws.String("cluster-foo-tccp"),
+			StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+			Tags:        tags,
+		},
+	}
+	targetStacks := []cloudformation.Stack{
+		cloudformation.Stack{
+			StackName:   aws.String("cluster-foo-guest-recordsets"),
+			StackStatus: aws.String(cloudformation.StackStatusDeleteInProgress),
+			Tags:        tags,
+		},
+	}
+
+	targetClient := newTargetWithStacks(targetStacks)
+
+	c := newTestConfig(t)
+	c.SourceClient = newSourceWithStacks(sourceStacks)
+	c.TargetClient = targetClient
+	m, err := NewManager(c)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	// The target stack is being deleted, so creation is deferred.
+	err = m.Sync()
+	if err != nil {
+		t.Fatalf("m.Sync: %v", err)
+	}
+	if len(targetClient.createdStacks) > 0 {
+		t.Errorf("no creation expected while target stack is being deleted, got %v", targetClient.createdStacks)
+	}
+	if len(targetClient.updatedStacks) > 0 {
+		t.Errorf("no update expected while target stack is being deleted, got %v", targetClient.updatedStacks)
+	}
+
+	// The deletion completed, so the target stack is recreated.
+	targetClient.targetStacks[0].StackStatus = aws.String(cloudformation.StackStatusDeleteComplete)
+
+	err = m.Sync()
+	if err != nil {
+		t.Fatalf("m.Sync: %v", err)
+	}
+	expected := []string{"cluster-foo-guest-recordsets"}
+	if !reflect.DeepEqual(targetClient.createdStacks, expected) {
+		t.Errorf("expected created stacks %v, got %v", expected, targetClient.createdStacks)
+	}
+}
+
+func TestSync_MinStackAge(t *testing.T) {
+	installation := "installation"
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tcs := []struct {
+		name            string
+		creationTime    *time.Time
+		lastUpdatedTime *time.Time
+		minStackAge     time.Duration
+		expectSync      bool
+	}{
+		{
+			name:         "case 0: sync aged source stack",
+			creationTime: aws.Time(now.Add(-time.Hour)),
+			minStackAge:  10 * time.Minute,
+			expectSync:   true,
+		},
+		{
+			name:         "case 1: defer freshly created source stack",
+			creationTime: aws.Time(now.Add(-time.Minute)),
+			minStackAge:  10 * time.Minute,
+			expectSync:   false,
+		},
+		{
+			name:            "case 2: defer freshly updated source stack",
+			creationTime:    aws.Time(now.Add(-time.Hour)),
+			lastUpdatedTime: aws.Time(now.Add(-time.Minute)),
+			minStackAge:     10 * time.Minute,
+			expectSync:      false,
+		},
+		{
+			name:         "case 3: sync fresh source stack when check is disabled",
+			creationTime: aws.Time(now.Add(-time.Minute)),
+			minStackAge:  0,
+			expectSync:   true,
+		},
+		{
+			name:        "case 4: sync source stack without timestamps",
+			minStackAge: 10 * time.Minute,
+			expectSync:  true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			tags := []*cloudformation.Tag{
+				&cloudformation.Tag{
+					Key:   aws.String(installationTag),
+					Value: aws.String(installation),
+				},
+			}
+			sourceStacks := []cloudformation.Stack{
+				cloudformation.Stack{
+					StackName:       aws.String("cluster-foo-tccp"),
+					StackStatus:     aws.String(cloudformation.StackStatusCreateComplete),
+					CreationTime:    tc.creationTime,
+					LastUpdatedTime: tc.lastUpdatedTime,
+					Tags:            tags,
+				},
+				cloudformation.Stack{
+					StackName:       aws.String("cluster-bar-tccp"),
+					StackStatus:     aws.String(cloudformation.StackStatusCreateComplete),
+					CreationTime:    tc.creationTime,
+					LastUpdatedTime: tc.lastUpdatedTime,
+					Tags:            tags,
+				},
+			}
+			targetStacks := []cloudformation.Stack{
+				cloudformation.Stack{
+					StackName:   aws.String("cluster-bar-guest-recordsets"),
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+					Tags:        tags,
+				},
+			}
+
+			targetClient := newTargetWithStacks(targetStacks)
+
+			c := newTestConfig(t)
+			c.SourceClient = newSourceWithStacks(sourceStacks)
+			c.TargetClient = targetClient
+			c.MinStackAge = tc.minStackAge
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+			m.now = func() time.Time { return now }
+
+			err = m.Sync()
+			if err != nil {
+				t.Fatalf("m.Sync: %v", err)
+			}
+
+			synced := len(targetClient.createdStacks) == 1 && len(targetClient.updatedStacks) == 1
+			deferred := len(targetClient.createdStacks) == 0 && len(targetClient.updatedStacks) == 0
+			if tc.expectSync && !synced {
+				t.Errorf("expected create and update, got created %v updated %v", targetClient.createdStacks, targetClient.updatedStacks)
+			} else if !tc.expectSync && !deferred {
+				t.Errorf("expected no create and update, got created %v updated %v", targetClient.createdStacks, targetClient.updatedStacks)
+			}
+		})
+	}
+}
+
+func TestDeleteTargetLeftovers_EtcdHostedZone(t *testing.T) {
+	newRecordSet := func(name string) *route53.ResourceRecordSet {
+		return &route53.ResourceRecordSet{
+			Name: aws.String(name),
+			Type: aws.String(route53.RRTypeCname),
+		}
+	}
+
+	tcs := []struct {
+		name            string
+		etcdZoneID      string
+		etcdZoneName    string
+		recordSets      map[string][]*route53.ResourceRecordSet
+		expectedDeleted map[string][]string
+	}{
+		{
+			name: "case 0: single hosted zone",
+			recordSets: map[string][]*route53.ResourceRecordSet{
+				"zoneID": {
+					newRecordSet("api.foo.zoneName."),
+					newRecordSet("etcd.foo.zoneName."),
+					newRecordSet("etcd1.foo.zoneName."),
+					newRecordSet("vault.foo.zoneName."),
+					newRecordSet("api.bar.zoneName."),
+				},
+			},
+			expectedDeleted: map[string][]string{
+				"zoneID": {"vault.foo.zoneName."},
+			},
+		},
+		{
+			name:         "case 1: separate etcd hosted zone",
+			etcdZoneID:   "etcdZoneID",
+			etcdZoneName: "etcdZoneName",
+			recordSets: map[string][]*route53.ResourceRecordSet{
+				"zoneID": {
+					newRecordSet("api.foo.zoneName."),
+					newRecordSet("etcd.foo.zoneName."),
+					newRecordSet("vault.foo.zoneName."),
+				},
+				"etcdZoneID": {
+					newRecordSet("etcd.foo.etcdZoneName."),
+					newRecordSet("etcd1.foo.etcdZoneName."),
+					newRecordSet("api.foo.etcdZoneName."),
+					newRecordSet("etcd1.bar.etcdZoneName."),
+				},
+			},
+			expectedDeleted: map[string][]string{
+				"zoneID":     {"etcd.foo.zoneName.", "vault.foo.zoneName."},
+				"etcdZoneID": {"api.foo.etcdZoneName."},
+			},
+		},
+		{
+			name:         "case 2: separate etcd hosted zone with the same name",
+			etcdZoneID:   "etcdZoneID",
+			etcdZoneName: "zoneName",
+			recordSets: map[string][]*route53.ResourceRecordSet{
+				"zoneID": {
+					newRecordSet("api.foo.zoneName."),
+					newRecordSet("etcd1.foo.zoneName."),
+				},
+				"etcdZoneID": {
+					newRecordSet("api.foo.zoneName."),
+					newRecordSet("etcd1.foo.zoneName."),
+				},
 			},
-			statuses: []string{
-				cloudformation.StackStatusDeleteComplete,
+			expectedDeleted: map[string][]string{
+				"zoneID":     {"etcd1.foo.zoneName."},
+				"etcdZoneID": {"api.foo.zoneName."},
 			},
-			expected: false,
 		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			targetClient := newTargetWithStacks(nil)
+			targetClient.recordSets = tc.recordSets
+
+			c := newTestConfig(t)
+			c.TargetClient = targetClient
+			c.EtcdHostedZoneID = tc.etcdZoneID
+			c.EtcdHostedZoneName = tc.etcdZoneName
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			err = m.deleteTargetLeftovers("foo", nil)
+			if err != nil {
+				t.Fatalf("deleteTargetLeftovers: %v", err)
+			}
+
+			deleted := map[string][]string{}
+			for zoneID, changes := range targetClient.changes {
+				for _, change := range changes {
+					if *change.Action != "DELETE" {
+						t.Errorf("expected DELETE change, got %#q", *change.Action)
+					}
+					deleted[zoneID] = append(deleted[zoneID], *change.ResourceRecordSet.Name)
+				}
+			}
+			if !reflect.DeepEqual(deleted, tc.expectedDeleted) {
+				t.Errorf("expected deleted record sets %v, got %v", tc.expectedDeleted, deleted)
+			}
+		})
+	}
+}
+
+func TestDeleteOrphanTargetStacks_DeletionOrder(t *testing.T) {
+	tcs := []struct {
+		name                        string
+		deletionOrder               string
+		deletionStopOnFailure       bool
+		deleteStackError            error
+		listResourceRecordSetsError error
+		expectedCalls               []string
+	}{
+		{
+			name:          "case 0: delete records after stack",
+			deletionOrder: DeletionOrderRecordsAfterStack,
+			expectedCalls: []string{"DeleteStack", "ListResourceRecordSets"},
+		},
+		{
+			name:          "case 1: delete records first",
+			deletionOrder: DeletionOrderRecordsFirst,
+			expectedCalls: []string{"ListResourceRecordSets", "DeleteStack"},
+		},
+		{
+			name:             "case 2: delete records after failed stack deletion",
+			deletionOrder:    DeletionOrderRecordsAfterStack,
+			deleteStackError: awserr.New("Throttling", "Rate exceeded", nil),
+			expectedCalls:    []string{"DeleteStack", "DescribeStacks", "ListResourceRecordSets"},
+		},
+		{
+			name:                  "case 3: keep records after failed stack deletion",
+			deletionOrder:         DeletionOrderRecordsAfterStack,
+			deletionStopOnFailure: true,
+			deleteStackError:      awserr.New("Throttling", "Rate exceeded", nil),
+			expectedCalls:         []string{"DeleteStack", "DescribeStacks"},
+		},
+		{
+			name:                        "case 4: delete stack after failed records deletion",
+			deletionOrder:               DeletionOrderRecordsFirst,
+			listResourceRecordSetsError: awserr.New("Throttling", "Rate exceeded", nil),
+			expectedCalls:               []string{"ListResourceRecordSets", "DeleteStack"},
+		},
+		{
+			name:                        "case 5: keep stack after failed records deletion",
+			deletionOrder:               DeletionOrderRecordsFirst,
+			deletionStopOnFailure:       true,
+			listResourceRecordSetsError: awserr.New("Throttling", "Rate exceeded", nil),
+			expectedCalls:               []string{"ListResourceRecordSets"},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			targetStacks := []cloudformation.Stack{
+				cloudformation.Stack{
+					StackName:   aws.String("cluster-foo-guest-recordsets"),
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+				},
+			}
+			targetClient := newTargetWithStacks(targetStacks)
+			targetClient.deleteStackError = tc.deleteStackError
+			targetClient.listResourceRecordSetsError = tc.listResourceRecordSetsError
+			targetClient.calls = nil
+
+			c := newTestConfig(t)
+			c.TargetClient = targetClient
+			c.DeletionOrder = tc.deletionOrder
+			c.DeletionStopOnFailure = tc.deletionStopOnFailure
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			err = m.deleteOrphanTargetStacks(m.computePlan(nil, targetStacks).Deletes)
+			if err != nil {
+				t.Fatalf("deleteOrphanTargetStacks: %v", err)
+			}
+
+			if !reflect.DeepEqual(targetClient.calls, tc.expectedCalls) {
+				t.Errorf("expected calls %v, got %v", tc.expectedCalls, targetClient.calls)
+			}
+		})
+	}
+}
+
+func TestDeleteOrphanTargetStacks_Disabled(t *testing.T) {
+	targetStacks := []cloudformation.Stack{
+		cloudformation.Stack{
+			StackName:   aws.String("cluster-foo-guest-recordsets"),
+			StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+		},
+	}
+	targetClient := newTargetWithStacks(targetStacks)
+
+	c := newTestConfig(t)
+	c.TargetClient = targetClient
+	c.DisableOrphanDeletion = true
+	m, err := NewManager(c)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	err = m.deleteOrphanTargetStacks(m.computePlan(nil, targetStacks).Deletes)
+	if err != nil {
+		t.Fatalf("deleteOrphanTargetStacks: %v", err)
+	}
+
+	if len(targetClient.deletedStacks) != 0 || len(targetClient.calls) != 0 {
+		t.Errorf("expected no deletions, got deleted stacks %v and calls %v", targetClient.deletedStacks, targetClient.calls)
+	}
+	if !m.summary.skipped[SkipReasonOrphanDeletionDisabled]["cluster-foo-guest-recordsets"] {
+		t.Errorf("expected orphan target stack `cluster-foo-guest-recordsets` to be reported, got %v", m.summary.skipped)
+	}
+}
+
+func TestSync_DeleteOnlyOnCleanSync(t *testing.T) {
+	tags := []*cloudformation.Tag{
+		{
+			Key:   aws.String(installationTag),
+			Value: aws.String("installation"),
+		},
+	}
+
+	tcs := []struct {
+		name                  string
+		deleteOnlyOnCleanSync bool
+		createStackError      error
+		noLoadBalancers       bool
+		expectedDeleted       []string
+		expectedSkipped       bool
+	}{
+		{
+			name:                  "case 0: clean create phase",
+			deleteOnlyOnCleanSync: true,
+			expectedDeleted:       []string{"cluster-bar-guest-recordsets"},
+		},
+		{
+			name:                  "case 1: create error suppresses the delete phase",
+			deleteOnlyOnCleanSync: true,
+			createStackError:      errors.New("throttling"),
+			expectedSkipped:       true,
+		},
+		{
+			name:                  "case 2: record lookup error suppresses the delete phase",
+			deleteOnlyOnCleanSync: true,
+			noLoadBalancers:       true,
+			expectedSkipped:       true,
+		},
+		{
+			name:             "case 3: create error without option",
+			createStackError: errors.New("throttling"),
+			expectedDeleted:  []string{"cluster-bar-guest-recordsets"},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			sourceClient := newSourceWithStacks([]cloudformation.Stack{
+				{
+					StackName:   aws.String("cluster-foo-tccp"),
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+					Tags:        tags,
+				},
+			})
+			targetClient := newTargetWithStacks([]cloudformation.Stack{
+				{
+					StackName:   aws.String("cluster-bar-guest-recordsets"),
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+					Tags:        tags,
+				},
+			})
+			sourceClient.noLoadBalancers = tc.noLoadBalancers
+			targetClient.createStackError = tc.createStackError
+
+			c := newTestConfig(t)
+			c.SourceClient = sourceClient
+			c.TargetClient = targetClient
+			c.DeleteOnlyOnCleanSync = tc.deleteOnlyOnCleanSync
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			err = m.Sync()
+			if err != nil {
+				t.Fatalf("Sync: %v", err)
+			}
+
+			if !reflect.DeepEqual(tc.expectedDeleted, targetClient.deletedStacks) {
+				t.Errorf("expected deleted stacks %v, got %v", tc.expectedDeleted, targetClient.deletedStacks)
+			}
+			skipped := m.summary.skipped[SkipReasonCreateUpdateFailed]["cluster-bar-guest-recordsets"]
+			if skipped != tc.expectedSkipped {
+				t.Errorf("expected orphan target stack `cluster-bar-guest-recordsets` skipped %t, got %t", tc.expectedSkipped, skipped)
+			}
+		})
+	}
+}
+
+func TestDeleteTargetLeftovers_CAA(t *testing.T) {
+	targetClient := newTargetWithStacks(nil)
+	targetClient.recordSets = map[string][]*route53.ResourceRecordSet{
+		"zoneID": {
+			{Name: aws.String("foo.zoneName."), Type: aws.String(route53.RRTypeCaa)},
+			{Name: aws.String("api.foo.zoneName."), Type: aws.String(route53.RRTypeCname)},
+			{Name: aws.String("caa.foo.zoneName."), Type: aws.String(route53.RRTypeCaa)},
+		},
+	}
+
+	c := newTestConfig(t)
+	c.TargetClient = targetClient
+	c.CAAValue = `0 issue "letsencrypt.org"`
+	m, err := NewManager(c)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	managed := getManagedRecordSets("foo", "zoneName", m.components, true, false, false)
+	if !stringInSlice("foo.zonename.", managed) {
+		t.Errorf("expected CAA record set `foo.zonename.` to be managed, got %v", managed)
+	}
+
+	err = m.deleteTargetLeftovers("foo", nil)
+	if err != nil {
+		t.Fatalf("deleteTargetLeftovers: %v", err)
+	}
+
+	var deleted []string
+	for _, change := range targetClient.changes["zoneID"] {
+		deleted = append(deleted, *change.ResourceRecordSet.Name)
+	}
+	expected := []string{"caa.foo.zoneName."}
+	if !reflect.DeepEqual(deleted, expected) {
+		t.Errorf("expected deleted record sets %v, got %v", expected, deleted)
+	}
+}
+
+func TestDeleteTargetLeftovers_BareDomain(t *testing.T) {
+	targetClient := newTargetWithStacks(nil)
+	targetClient.recordSets = map[string][]*route53.ResourceRecordSet{
+		"zoneID": {
+			{Name: aws.String("foo.zonename."), Type: aws.String(route53.RRTypeCname)},
+			{Name: aws.String("\\052.foo.zonename."), Type: aws.String(route53.RRTypeCname)},
+			{Name: aws.String("old.foo.zonename."), Type: aws.String(route53.RRTypeCname)},
+		},
+	}
+
+	c := newTestConfig(t)
+	c.TargetClient = targetClient
+	c.BareDomainRecord = true
+	m, err := NewManager(c)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	managed := getManagedRecordSets("foo", "zoneName", m.components, false, true, false)
+	if !stringInSlice("foo.zonename.", managed) {
+		t.Errorf("expected bare domain record set `foo.zonename.` to be managed, got %v", managed)
+	}
+
+	err = m.deleteTargetLeftovers("foo", nil)
+	if err != nil {
+		t.Fatalf("deleteTargetLeftovers: %v", err)
+	}
+
+	var deleted []string
+	for _, change := range targetClient.changes["zoneID"] {
+		deleted = append(deleted, *change.ResourceRecordSet.Name)
+	}
+	expected := []string{"old.foo.zonename."}
+	if !reflect.DeepEqual(deleted, expected) {
+		t.Errorf("expected deleted record sets %v, got %v", expected, deleted)
+	}
+}
+
+func TestDeleteTargetLeftovers_MixedCase(t *testing.T) {
+	targetClient := newTargetWithStacks(nil)
+	targetClient.recordSets = map[string][]*route53.ResourceRecordSet{
+		"zoneID": {
+			{Name: aws.String("\\052.foo.zonename."), Type: aws.String(route53.RRTypeCname)},
+			{Name: aws.String("api.foo.zonename."), Type: aws.String(route53.RRTypeCname)},
+			{Name: aws.String("etcd1.foo.zonename."), Type: aws.String(route53.RRTypeA)},
+			{Name: aws.String("old.foo.zonename."), Type: aws.String(route53.RRTypeCname)},
+			{Name: aws.String("api.bar.zonename."), Type: aws.String(route53.RRTypeCname)},
+		},
+	}
+
+	c := newTestConfig(t)
+	c.TargetClient = targetClient
+	c.TargetHostedZoneName = "ZoneName"
+	m, err := NewManager(c)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	err = m.deleteTargetLeftovers("Foo", nil)
+	if err != nil {
+		t.Fatalf("deleteTargetLeftovers: %v", err)
+	}
+
+	var deleted []string
+	for _, change := range targetClient.changes["zoneID"] {
+		deleted = append(deleted, *change.ResourceRecordSet.Name)
+	}
+	expected := []string{"old.foo.zonename."}
+	if !reflect.DeepEqual(deleted, expected) {
+		t.Errorf("expected deleted record sets %v, got %v", expected, deleted)
+	}
+}
+
+func TestDeleteTargetLeftovers_TrailingDot(t *testing.T) {
+	tcs := []struct {
+		name     string
+		zoneName string
+	}{
+		{
+			name:     "case 0: zone name without trailing dot",
+			zoneName: "zoneName",
+		},
+		{
+			name:     "case 1: zone name with trailing dot",
+			zoneName: "zoneName.",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			targetClient := newTargetWithStacks(nil)
+			targetClient.recordSets = map[string][]*route53.ResourceRecordSet{
+				"zoneID": {
+					{Name: aws.String("\\052.foo.zonename."), Type: aws.String(route53.RRTypeCname)},
+					{Name: aws.String("api.foo.zonename."), Type: aws.String(route53.RRTypeCname)},
+					{Name: aws.String("etcd1.foo.zonename."), Type: aws.String(route53.RRTypeA)},
+					{Name: aws.String("old.foo.zonename."), Type: aws.String(route53.RRTypeCname)},
+					{Name: aws.String("api.xfoo.zonename."), Type: aws.String(route53.RRTypeCname)},
+					{Name: aws.String("foo.zonename."), Type: aws.String(route53.RRTypeNs)},
+				},
+			}
+
+			c := newTestConfig(t)
+			c.TargetClient = targetClient
+			c.TargetHostedZoneName = tc.zoneName
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			err = m.deleteTargetLeftovers("foo", nil)
+			if err != nil {
+				t.Fatalf("deleteTargetLeftovers: %v", err)
+			}
+
+			var deleted []string
+			for _, change := range targetClient.changes["zoneID"] {
+				deleted = append(deleted, *change.ResourceRecordSet.Name)
+			}
+			expected := []string{"old.foo.zonename."}
+			if !reflect.DeepEqual(deleted, expected) {
+				t.Errorf("expected deleted record sets %v, got %v", expected, deleted)
+			}
+		})
+	}
+}
+
+// writableSourceMock is a source client which also implements the write
+// calls of the target client, e.g. when given target credentials by mistake.
+type writableSourceMock struct {
+	*sourceClientMock
+
+	writes []string
+}
+
+func (s *writableSourceMock) ChangeResourceRecordSetsWithContext(aws.Context, *route53.ChangeResourceRecordSetsInput, ...request.Option) (*route53.ChangeResourceRecordSetsOutput, error) {
+	s.writes = append(s.writes, "ChangeResourceRecordSets")
+	return &route53.ChangeResourceRecordSetsOutput{}, nil
+}
+
+func (s *writableSourceMock) CreateStackWithContext(aws.Context, *cloudformation.CreateStackInput, ...request.Option) (*cloudformation.CreateStackOutput, error) {
+	s.writes = append(s.writes, "CreateStack")
+	return &cloudformation.CreateStackOutput{}, nil
+}
+
+func (s *writableSourceMock) DeleteStackWithContext(aws.Context, *cloudformation.DeleteStackInput, ...request.Option) (*cloudformation.DeleteStackOutput, error) {
+	s.writes = append(s.writes, "DeleteStack")
+	return &cloudformation.DeleteStackOutput{}, nil
+}
+
+func (s *writableSourceMock) UpdateStackWithContext(aws.Context, *cloudformation.UpdateStackInput, ...request.Option) (*cloudformation.UpdateStackOutput, error) {
+	s.writes = append(s.writes, "UpdateStack")
+	return &cloudformation.UpdateStackOutput{}, nil
+}
+
+func TestSync_SourceReadOnly(t *testing.T) {
+	tags := []*cloudformation.Tag{
 		{
-			name: "case 3: one matching status",
-			input: cloudformation.Stack{
+			Key:   aws.String(installationTag),
+			Value: aws.String("installation"),
+		},
+	}
+	sourceClient := &writableSourceMock{
+		sourceClientMock: newSourceWithStacks([]cloudformation.Stack{
+			{
+				StackName:   aws.String("cluster-foo-tccp"),
 				StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+				Tags:        tags,
+			},
+		}),
+	}
+	targetClient := newTargetWithStacks([]cloudformation.Stack{
+		{
+			StackName:   aws.String("cluster-bar-guest-recordsets"),
+			StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+			Tags:        tags,
+		},
+	})
+
+	c := newTestConfig(t)
+	c.SourceClient = sourceClient
+	c.TargetClient = targetClient
+	m, err := NewManager(c)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	err = m.Sync()
+	if err != nil {
+		t.Fatalf("m.Sync: %v", err)
+	}
+
+	if len(targetClient.createdStacks) != 1 || len(targetClient.deletedStacks) != 1 {
+		t.Fatalf("expected one created and one deleted target stack, got %v and %v", targetClient.createdStacks, targetClient.deletedStacks)
+	}
+	if len(sourceClient.writes) != 0 {
+		t.Errorf("expected no write calls on the source client, got %v", sourceClient.writes)
+	}
+}
+
+// TestSync_EndToEnd runs a whole sync of a mix of clusters to create, update
+// and delete against the mocks, so the interplay of the phases is covered.
+func TestSync_EndToEnd(t *testing.T) {
+	tags := []*cloudformation.Tag{
+		{
+			Key:   aws.String(installationTag),
+			Value: aws.String("installation"),
+		},
+	}
+	otherTags := []*cloudformation.Tag{
+		{
+			Key:   aws.String(installationTag),
+			Value: aws.String("other"),
+		},
+	}
+	stack := func(name, status string, tags []*cloudformation.Tag) cloudformation.Stack {
+		return cloudformation.Stack{
+			StackName:   aws.String(name),
+			StackStatus: aws.String(status),
+			Tags:        tags,
+		}
+	}
+
+	tcs := []struct {
+		name            string
+		dryRun          bool
+		expectedCreated []string
+		expectedUpdated []string
+		expectedDeleted []string
+	}{
+		{
+			name: "case 0: creates, updates and deletions",
+			expectedCreated: []string{
+				"cluster-bar-guest-recordsets",
+				"cluster-foo-guest-recordsets",
+			},
+			expectedUpdated: []string{
+				"cluster-baz-guest-recordsets",
+				"cluster-qux-guest-recordsets",
 			},
-			statuses: []string{
-				cloudformation.StackStatusCreateComplete,
+			expectedDeleted: []string{
+				"cluster-old-guest-recordsets",
 			},
-			expected: true,
+		},
+		{
+			name:   "case 1: dry run",
+			dryRun: true,
 		},
 	}
 
 	for _, tc := range tcs {
 		t.Run(tc.name, func(t *testing.T) {
-			output := stackHasStatus(tc.input, tc.statuses)
-			if tc.expected != output {
-				t.Errorf("expected %v, got %v", tc.expected, output)
+			sourceClient := newSourceWithStacks([]cloudformation.Stack{
+				// foo and bar, a legacy cluster, have no target stack yet.
+				stack("cluster-foo-tccp", cloudformation.StackStatusCreateComplete, tags),
+				stack("cluster-bar-guest-main", cloudformation.StackStatusCreateComplete, tags),
+				// baz and qux have target stacks to update.
+				stack("cluster-baz-tccp", cloudformation.StackStatusUpdateComplete, tags),
+				stack("cluster-qux-tccp", cloudformation.StackStatusCreateComplete, tags),
+				// wip is still being created.
+				stack("cluster-wip-tccp", cloudformation.StackStatusCreateInProgress, tags),
+				// ext belongs to another installation.
+				stack("cluster-ext-tccp", cloudformation.StackStatusCreateComplete, otherTags),
+			})
+			targetClient := newTargetWithStacks([]cloudformation.Stack{
+				stack("cluster-baz-guest-recordsets", cloudformation.StackStatusCreateComplete, tags),
+				stack("cluster-qux-guest-recordsets", cloudformation.StackStatusUpdateComplete, tags),
+				// old is an orphan of a deleted cluster.
+				stack("cluster-old-guest-recordsets", cloudformation.StackStatusCreateComplete, tags),
+				// other belongs to another installation.
+				stack("cluster-other-guest-recordsets", cloudformation.StackStatusCreateComplete, otherTags),
+			})
+
+			c := newTestConfig(t)
+			c.SourceClient = sourceClient
+			c.TargetClient = targetClient
+			c.DryRun = tc.dryRun
+			c.DryRunOutput = ioutil.Discard
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			err = m.Sync()
+			if err != nil {
+				t.Fatalf("m.Sync: %v", err)
+			}
+
+			created := append([]string(nil), targetClient.createdStacks...)
+			updated := append([]string(nil), targetClient.updatedStacks...)
+			sort.Strings(created)
+			sort.Strings(updated)
+			if !reflect.DeepEqual(tc.expectedCreated, created) {
+				t.Errorf("expected created stacks %v, got %v", tc.expectedCreated, created)
+			}
+			if !reflect.DeepEqual(tc.expectedUpdated, updated) {
+				t.Errorf("expected updated stacks %v, got %v", tc.expectedUpdated, updated)
+			}
+			if !reflect.DeepEqual(tc.expectedDeleted, targetClient.deletedStacks) {
+				t.Errorf("expected deleted stacks %v, got %v", tc.expectedDeleted, targetClient.deletedStacks)
+			}
+
+			// Target stacks are created before they are updated, and orphans
+			// are only deleted once all clusters are synced.
+			var phases []string
+			for _, call := range targetClient.calls {
+				if call != "CreateStack" && call != "UpdateStack" && call != "DeleteStack" {
+					continue
+				}
+				if len(phases) == 0 || phases[len(phases)-1] != call {
+					phases = append(phases, call)
+				}
+			}
+			var expectedPhases []string
+			if !tc.dryRun {
+				expectedPhases = []string{"CreateStack", "UpdateStack", "DeleteStack"}
+			}
+			if !reflect.DeepEqual(expectedPhases, phases) {
+				t.Errorf("expected phases %v, got calls %v", expectedPhases, targetClient.calls)
 			}
 		})
 	}
@@ -0,0 +1,111 @@
+package recordset
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/route53-manager/pkg/recordset/plan"
+)
+
+// recreateTargetStack deletes the target stack of m.recreateCluster together
+// with its leftover record sets, waits for the deletion to complete and
+// creates the target stack again from the source stack of the cluster. The
+// plan is bypassed, so the target stack is recreated whatever its status.
+// The records are rendered and the create input is validated before anything
+// is deleted, so a cluster whose records cannot be computed keeps its target
+// stack. Paused clusters are skipped.
+func (m *Manager) recreateTargetStack(sourceStacks, targetStacks []cloudformation.Stack) error {
+	var source *cloudformation.Stack
+	for i, stack := range sourceStacks {
+		clusterID, err := plan.ClusterID(*stack.StackName)
+		if err == nil && clusterID == m.recreateCluster {
+			source = &sourceStacks[i]
+			break
+		}
+	}
+	if source == nil {
+		return microerror.Maskf(stackNotFoundError, "source stack of cluster %#q", m.recreateCluster)
+	}
+
+	ref := plan.ClusterRef{
+		ID:              m.recreateCluster,
+		IsLegacy:        getStackKind(*source.StackName) == StackKindLegacy,
+		SourceStack:     source,
+		TargetStackName: plan.TargetStackName(m.recreateCluster),
+	}
+
+	var target *cloudformation.Stack
+	for i, stack := range targetStacks {
+		if *stack.StackName == ref.TargetStackName {
+			target = &targetStacks[i]
+			break
+		}
+	}
+
+	for _, stack := range []*cloudformation.Stack{source, target} {
+		if stack != nil && m.isPaused(*stack) {
+			m.skip(ref.TargetStackName, SkipReasonPaused, fmt.Sprintf("did not recreate target stack %#q of cluster %#q paused by tag %#q of stack %#q", ref.TargetStackName, ref.ID, m.pauseTag, *stack.StackName), nil)
+			return nil
+		}
+	}
+
+	records, err := m.getRecords(m.newCluster(ref))
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	input, err := m.getCreateStackInput(ref.TargetStackName, records, *source)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if target != nil {
+		m.logger.Log("level", "info", "message", fmt.Sprintf("recreating target stack %#q", ref.TargetStackName))
+
+		err := m.deleteTargetStack(ref.TargetStackName)
+		m.audit(ref.ID, auditOperationDelete, ref.TargetStackName, auditOutcome(err), err)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		err = m.waitForStackDeletion(ref.TargetStackName)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		m.logger.Log("level", "debug", "message", fmt.Sprintf("deleted target stack %#q", ref.TargetStackName))
+		m.summary.addDeleted(ref.ID)
+	}
+
+	// Leftover record sets are cleaned up like the ones of orphan clusters.
+	err = m.deleteTargetLeftovers(ref.ID, refTags(ref))
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = m.checkDeadline()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	err = m.renewLock()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	input.TemplateBody, input.TemplateURL, err = m.getTemplateLocation(ref.TargetStackName, aws.StringValue(input.TemplateBody))
+	if err == nil {
+		_, err = m.targetClient.CreateStackWithContext(m.ctx, input)
+	}
+	m.audit(ref.ID, auditOperationCreate, ref.TargetStackName, auditOutcome(err), err)
+	if err != nil {
+		m.summary.failed++
+		return microerror.Mask(err)
+	}
+
+	m.logger.Log("level", "debug", "message", fmt.Sprintf("created target stack %#q", ref.TargetStackName))
+	m.summary.addCreated(ref.ID)
+	m.ensureClusterDelegation(ref)
+
+	return nil
+}
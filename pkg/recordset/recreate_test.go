@@ -0,0 +1,248 @@
+package recordset
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+)
+
+func TestSync_RecreateCluster(t *testing.T) {
+	tags := []*cloudformation.Tag{
+		&cloudformation.Tag{
+			Key:   aws.String(installationTag),
+			Value: aws.String("installation"),
+		},
+	}
+	sourceStacks := []cloudformation.Stack{
+		cloudformation.Stack{
+			StackName:   aws.String("cluster-foo-tccp"),
+			StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+			Tags:        tags,
+		},
+	}
+	targetStacks := []cloudformation.Stack{
+		cloudformation.Stack{
+			StackName:   aws.String("cluster-foo-guest-recordsets"),
+			StackStatus: aws.String(cloudformation.StackStatusUpdateRollbackFailed),
+			Tags:        tags,
+		},
+	}
+
+	targetClient := newTargetWithStacks(targetStacks)
+	targetClient.deletionPolls = map[string]int{
+		"cluster-foo-guest-recordsets": 2,
+	}
+
+	sourceClient := newSourceWithStacks(sourceStacks)
+	sourceClient.stackNotFoundErrors = true
+
+	c := newTestConfig(t)
+	c.SourceClient = sourceClient
+	c.TargetClient = targetClient
+	c.RecreateCluster = "foo"
+	m, err := NewManager(c)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	var sleeps []time.Duration
+	m.sleep = func(d time.Duration) {
+		sleeps = append(sleeps, d)
+	}
+
+	err = m.Sync()
+	if err != nil {
+		t.Fatalf("m.Sync: %v", err)
+	}
+
+	expected := []string{"cluster-foo-guest-recordsets"}
+	if !reflect.DeepEqual(targetClient.deletedStacks, expected) {
+		t.Errorf("expected deleted stacks %v, got %v", expected, targetClient.deletedStacks)
+	}
+	if !reflect.DeepEqual(targetClient.createdStacks, expected) {
+		t.Errorf("expected created stacks %v, got %v", expected, targetClient.createdStacks)
+	}
+	if len(targetClient.updatedStacks) != 0 {
+		t.Errorf("expected no updated stacks, got %v", targetClient.updatedStacks)
+	}
+
+	// The deletion is polled until the stack is gone, before the leftovers
+	// are deleted and the stack is created again.
+	var calls []string
+	for i, call := range targetClient.calls {
+		if call == "DeleteStack" {
+			calls = targetClient.calls[i:]
+			break
+		}
+	}
+	expectedCalls := []string{"DeleteStack", "DescribeStacks", "DescribeStacks", "DescribeStacks", "ListResourceRecordSets", "CreateStack"}
+	if !reflect.DeepEqual(calls, expectedCalls) {
+		t.Errorf("expected calls %v, got %v", expectedCalls, calls)
+	}
+	if len(sleeps) != 2 {
+		t.Errorf("expected 2 polls of the deletion, got %v", sleeps)
+	}
+}
+
+func TestSync_RecreateCluster_Failures(t *testing.T) {
+	tags := []*cloudformation.Tag{
+		&cloudformation.Tag{
+			Key:   aws.String(installationTag),
+			Value: aws.String("installation"),
+		},
+	}
+	pausedTags := append([]*cloudformation.Tag{
+		&cloudformation.Tag{
+			Key:   aws.String(DefaultPauseTag),
+			Value: aws.String("true"),
+		},
+	}, tags...)
+
+	tcs := []struct {
+		name            string
+		targetTags      []*cloudformation.Tag
+		recordsError    error
+		createError     error
+		expectedDeleted bool
+		expectedPaused  bool
+		expectedError   bool
+	}{
+		{
+			name:            "case 0: records cannot be computed",
+			targetTags:      tags,
+			recordsError:    errors.New("throttled"),
+			expectedDeleted: false,
+			expectedError:   true,
+		},
+		{
+			name:            "case 1: paused cluster",
+			targetTags:      pausedTags,
+			expectedDeleted: false,
+			expectedPaused:  true,
+		},
+		{
+			name:            "case 2: create fails",
+			targetTags:      tags,
+			createError:     errors.New("access denied"),
+			expectedDeleted: true,
+			expectedError:   true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			sourceClient := newSourceWithStacks([]cloudformation.Stack{
+				{
+					StackName:   aws.String("cluster-foo-tccp"),
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+					Tags:        tags,
+				},
+			})
+			sourceClient.stackNotFoundErrors = true
+			sourceClient.loadBalancersError = tc.recordsError
+			targetClient := newTargetWithStacks([]cloudformation.Stack{
+				{
+					StackName:   aws.String("cluster-foo-guest-recordsets"),
+					StackStatus: aws.String(cloudformation.StackStatusUpdateRollbackFailed),
+					Tags:        tc.targetTags,
+				},
+			})
+			targetClient.createStackError = tc.createError
+			targetClient.deletionPolls = map[string]int{
+				"cluster-foo-guest-recordsets": 1,
+			}
+
+			c := newTestConfig(t)
+			c.SourceClient = sourceClient
+			c.TargetClient = targetClient
+			c.RecreateCluster = "foo"
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+			m.sleep = func(time.Duration) {}
+
+			err = m.Sync()
+			if (err != nil) != tc.expectedError {
+				t.Fatalf("expected error %t, got %v", tc.expectedError, err)
+			}
+
+			if (len(targetClient.deletedStacks) > 0) != tc.expectedDeleted {
+				t.Errorf("expected target stack deleted %t, got deleted stacks %v", tc.expectedDeleted, targetClient.deletedStacks)
+			}
+			if len(targetClient.createdStacks) != 0 {
+				t.Errorf("expected no created stacks, got %v", targetClient.createdStacks)
+			}
+			if m.summary.skipped[SkipReasonPaused]["cluster-foo-guest-recordsets"] != tc.expectedPaused {
+				t.Errorf("expected paused %t, got %v", tc.expectedPaused, m.summary.skipped)
+			}
+		})
+	}
+}
+
+func TestNewManager_RecreateCluster(t *testing.T) {
+	tcs := []struct {
+		name         string
+		mutate       func(c *Config)
+		errorMatcher func(error) bool
+	}{
+		{
+			name: "case 0: recreate cluster",
+			mutate: func(c *Config) {
+				c.RecreateCluster = "foo"
+			},
+		},
+		{
+			name: "case 1: recreate cluster of the scoped cluster",
+			mutate: func(c *Config) {
+				c.Cluster = "foo"
+				c.RecreateCluster = "foo"
+			},
+		},
+		{
+			name: "case 2: recreate cluster other than the scoped cluster",
+			mutate: func(c *Config) {
+				c.Cluster = "bar"
+				c.RecreateCluster = "foo"
+			},
+			errorMatcher: IsInvalidConfig,
+		},
+		{
+			name: "case 3: recreate cluster in dry run",
+			mutate: func(c *Config) {
+				c.RecreateCluster = "foo"
+				c.DryRun = true
+			},
+			errorMatcher: IsInvalidConfig,
+		},
+		{
+			name: "case 4: recreate cluster in route53 atomic apply mode",
+			mutate: func(c *Config) {
+				c.RecreateCluster = "foo"
+				c.ApplyMode = ApplyModeRoute53Atomic
+			},
+			errorMatcher: IsInvalidConfig,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newTestConfig(t)
+			tc.mutate(c)
+
+			_, err := NewManager(c)
+			if tc.errorMatcher != nil {
+				if !tc.errorMatcher(err) {
+					t.Fatalf("expected matching error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+		})
+	}
+}
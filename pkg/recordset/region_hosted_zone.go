@@ -0,0 +1,87 @@
+package recordset
+
+import (
+	"sort"
+
+	"github.com/giantswarm/route53-manager/pkg/recordset/plan"
+)
+
+const (
+	// DefaultRegionTag is the tag key of the source stacks carrying the
+	// region of the cluster, e.g. `eu-west-1`.
+	DefaultRegionTag = "giantswarm.io/region"
+)
+
+// HostedZone is a hosted zone of the target account.
+type HostedZone struct {
+	ID   string
+	Name string
+}
+
+// clusterHostedZones returns the hosted zones the records of the cluster with
+// the given stack tags are created in. The main hosted zone is the one of the
+// region in the region tag, falling back to the target hosted zone. The etcd
+// records follow it unless the etcd hosted zone is configured separately.
+func (m *Manager) clusterHostedZones(tags map[string]string) (HostedZone, HostedZone) {
+	main := HostedZone{
+		ID:   m.targetHostedZoneID,
+		Name: m.targetHostedZoneName,
+	}
+	etcd := HostedZone{
+		ID:   m.etcdHostedZoneID,
+		Name: m.etcdHostedZoneName,
+	}
+
+	z, ok := m.regionHostedZones[tags[m.regionTag]]
+	if !ok {
+		return main, etcd
+	}
+	if m.etcdHostedZoneID == m.targetHostedZoneID {
+		etcd = z
+	}
+
+	return z, etcd
+}
+
+// refTags returns the tags of the source stack of the planned operation, or
+// of its target stack when there is no source stack, e.g. for deletes. Target
+// stacks carry the tags of their source stack.
+func refTags(ref plan.ClusterRef) map[string]string {
+	if ref.SourceStack != nil {
+		return stackTags(*ref.SourceStack)
+	}
+	if ref.TargetStack != nil {
+		return stackTags(*ref.TargetStack)
+	}
+
+	return map[string]string{}
+}
+
+// mainHostedZoneName returns the name of the target hosted zone or hosted zone
+// of a region with the given ID.
+func (m *Manager) mainHostedZoneName(hostedZoneID string) (string, bool) {
+	if hostedZoneID == m.targetHostedZoneID {
+		return m.targetHostedZoneName, true
+	}
+	for _, z := range m.regionHostedZones {
+		if z.ID == hostedZoneID {
+			return z.Name, true
+		}
+	}
+
+	return "", false
+}
+
+// regionHostedZoneIDs returns the sorted IDs of the hosted zones of the
+// regions.
+func (m *Manager) regionHostedZoneIDs() []string {
+	var ids []string
+	for _, z := range m.regionHostedZones {
+		if !stringInSlice(z.ID, ids) {
+			ids = append(ids, z.ID)
+		}
+	}
+	sort.Strings(ids)
+
+	return ids
+}
@@ -0,0 +1,200 @@
+package recordset
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+func TestSync_RegionHostedZones(t *testing.T) {
+	stack := func(name, region string) cloudformation.Stack {
+		s := cloudformation.Stack{
+			StackName:   aws.String(name),
+			StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+			Tags: []*cloudformation.Tag{
+				{
+					Key:   aws.String(installationTag),
+					Value: aws.String("installation"),
+				},
+			},
+		}
+		if region != "" {
+			s.Tags = append(s.Tags, &cloudformation.Tag{
+				Key:   aws.String(DefaultRegionTag),
+				Value: aws.String(region),
+			})
+		}
+		return s
+	}
+
+	sourceClient := newSourceWithStacks([]cloudformation.Stack{
+		stack("cluster-foo-tccp", "eu-west-1"),
+		stack("cluster-bar-tccp", "us-east-1"),
+		stack("cluster-baz-tccp", "ap-south-1"),
+		stack("cluster-qux-tccp", ""),
+	})
+	targetClient := newTargetWithStacks(nil)
+
+	c := newTestConfig(t)
+	c.SourceClient = sourceClient
+	c.TargetClient = targetClient
+	c.RegionHostedZones = map[string]HostedZone{
+		"eu-west-1": {ID: "euZoneID", Name: "eu.zoneName"},
+		"us-east-1": {ID: "usZoneID", Name: "us.zoneName."},
+	}
+	m, err := NewManager(c)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	err = m.Sync()
+	if err != nil {
+		t.Fatalf("m.Sync: %v", err)
+	}
+
+	expected := map[string]HostedZone{
+		"cluster-foo-guest-recordsets": {ID: "euZoneID", Name: "foo.eu.zoneName"},
+		"cluster-bar-guest-recordsets": {ID: "usZoneID", Name: "bar.us.zoneName"},
+		"cluster-baz-guest-recordsets": {ID: "zoneID", Name: "baz.zoneName"},
+		"cluster-qux-guest-recordsets": {ID: "zoneID", Name: "qux.zoneName"},
+	}
+
+	if len(targetClient.createStackInputs) != len(expected) {
+		t.Fatalf("expected %d created stacks, got %v", len(expected), targetClient.createdStacks)
+	}
+	for _, input := range targetClient.createStackInputs {
+		e, ok := expected[*input.StackName]
+		if !ok {
+			t.Errorf("unexpected created stack %#q", *input.StackName)
+			continue
+		}
+
+		template, err := parseStackTemplate(*input.TemplateBody)
+		if err != nil {
+			t.Fatalf("parseStackTemplate: %v", err)
+		}
+		for name, r := range template.Resources {
+			if r.Properties.HostedZoneID != e.ID {
+				t.Errorf("expected resource %#q of stack %#q in hosted zone %#q, got %#q", name, *input.StackName, e.ID, r.Properties.HostedZoneID)
+			}
+			if !strings.HasSuffix(strings.TrimSuffix(r.Properties.Name, "."), e.Name) {
+				t.Errorf("expected resource %#q of stack %#q in domain %#q, got %#q", name, *input.StackName, e.Name, r.Properties.Name)
+			}
+		}
+	}
+}
+
+func TestDeleteTargetLeftovers_RegionHostedZone(t *testing.T) {
+	tcs := []struct {
+		name                 string
+		tags                 map[string]string
+		expectedChangedZones []string
+	}{
+		{
+			name: "case 0: region with hosted zone",
+			tags: map[string]string{
+				DefaultRegionTag: "eu-west-1",
+			},
+			expectedChangedZones: []string{"euZoneID"},
+		},
+		{
+			name: "case 1: region without hosted zone",
+			tags: map[string]string{
+				DefaultRegionTag: "us-east-1",
+			},
+			expectedChangedZones: []string{"zoneID"},
+		},
+		{
+			name:                 "case 2: missing region tag",
+			expectedChangedZones: []string{"zoneID"},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			leftover := func(name string) *route53.ResourceRecordSet {
+				return &route53.ResourceRecordSet{
+					Name: aws.String(name),
+					Type: aws.String(route53.RRTypeCname),
+				}
+			}
+
+			targetClient := newTargetWithStacks(nil)
+			targetClient.recordSets = map[string][]*route53.ResourceRecordSet{
+				"zoneID": {
+					leftover("leftover.foo.zoneName."),
+				},
+				"euZoneID": {
+					leftover("leftover.foo.eu.zoneName."),
+				},
+			}
+
+			c := newTestConfig(t)
+			c.TargetClient = targetClient
+			c.RegionHostedZones = map[string]HostedZone{
+				"eu-west-1": {ID: "euZoneID", Name: "eu.zoneName"},
+			}
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			err = m.deleteTargetLeftovers("foo", tc.tags)
+			if err != nil {
+				t.Fatalf("deleteTargetLeftovers: %v", err)
+			}
+
+			var changedZones []string
+			for _, id := range []string{"zoneID", "euZoneID"} {
+				if len(targetClient.changes[id]) > 0 {
+					changedZones = append(changedZones, id)
+				}
+			}
+			if !reflect.DeepEqual(changedZones, tc.expectedChangedZones) {
+				t.Errorf("expected leftovers deleted in hosted zones %v, got %v", tc.expectedChangedZones, changedZones)
+			}
+		})
+	}
+}
+
+func TestNewManager_InvalidRegionHostedZones(t *testing.T) {
+	tcs := []struct {
+		name              string
+		regionHostedZones map[string]HostedZone
+	}{
+		{
+			name: "case 0: missing hosted zone ID",
+			regionHostedZones: map[string]HostedZone{
+				"eu-west-1": {Name: "eu.zoneName"},
+			},
+		},
+		{
+			name: "case 1: missing hosted zone name",
+			regionHostedZones: map[string]HostedZone{
+				"eu-west-1": {ID: "euZoneID"},
+			},
+		},
+		{
+			name: "case 2: missing region",
+			regionHostedZones: map[string]HostedZone{
+				"": {ID: "euZoneID", Name: "eu.zoneName"},
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newTestConfig(t)
+			c.RegionHostedZones = tc.regionHostedZones
+
+			_, err := NewManager(c)
+			if !IsInvalidConfig(err) {
+				t.Errorf("expected invalid config error, got %v", err)
+			}
+		})
+	}
+}
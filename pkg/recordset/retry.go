@@ -0,0 +1,27 @@
+package recordset
+
+import (
+	"time"
+)
+
+const (
+	syncRetryInitialInterval = 5 * time.Second
+	syncRetryMaxInterval     = 1 * time.Minute
+)
+
+var (
+	// retryableErrorCodes are the codes of AWS errors returned for throttled
+	// requests and transient server failures.
+	retryableErrorCodes = []string{
+		"InternalError",
+		"InternalFailure",
+		"PriorRequestNotComplete",
+		"RequestLimitExceeded",
+		"RequestThrottled",
+		"RequestThrottledException",
+		"ServiceUnavailable",
+		"Throttling",
+		"ThrottlingException",
+		"TooManyRequestsException",
+	}
+)
@@ -0,0 +1,158 @@
+package recordset
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/giantswarm/microerror"
+)
+
+func TestSync_SyncRetries(t *testing.T) {
+	throttled := awserr.New("Throttling", "Rate exceeded", nil)
+	serverError := awserr.NewRequestFailure(awserr.New("InternalError", "", nil), 500, "")
+	accessDenied := awserr.New("AccessDenied", "User is not authorized to perform cloudformation:ListStacks", nil)
+
+	tcs := []struct {
+		name              string
+		syncRetries       int
+		syncTimeout       time.Duration
+		listStacksErrors  []error
+		expectedError     bool
+		expectedTimeout   bool
+		expectedCreated   []string
+		expectedIntervals []time.Duration
+	}{
+		{
+			name:             "case 0: throttled then succeeding",
+			syncRetries:      3,
+			listStacksErrors: []error{throttled, serverError},
+			expectedCreated: []string{
+				"cluster-foo-guest-recordsets",
+			},
+			expectedIntervals: []time.Duration{
+				5 * time.Second,
+				10 * time.Second,
+			},
+		},
+		{
+			name:             "case 1: retries exhausted",
+			syncRetries:      1,
+			listStacksErrors: []error{throttled, throttled},
+			expectedError:    true,
+			expectedIntervals: []time.Duration{
+				5 * time.Second,
+			},
+		},
+		{
+			name:             "case 2: not retryable",
+			syncRetries:      3,
+			listStacksErrors: []error{accessDenied},
+			expectedError:    true,
+		},
+		{
+			name:             "case 3: disabled",
+			listStacksErrors: []error{throttled},
+			expectedError:    true,
+		},
+		{
+			name:             "case 4: backoff exceeding sync timeout",
+			syncRetries:      3,
+			syncTimeout:      3 * time.Second,
+			listStacksErrors: []error{throttled},
+			expectedError:    true,
+			expectedTimeout:  true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			sourceClient := newSourceWithStacks([]cloudformation.Stack{
+				{
+					StackName:   aws.String("cluster-foo-tccp"),
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+					Tags: []*cloudformation.Tag{
+						{
+							Key:   aws.String(installationTag),
+							Value: aws.String("installation"),
+						},
+					},
+				},
+			})
+			sourceClient.listStacksErrors = tc.listStacksErrors
+			targetClient := newTargetWithStacks(nil)
+
+			c := newTestConfig(t)
+			c.SourceClient = sourceClient
+			c.TargetClient = targetClient
+			c.SyncRetries = tc.syncRetries
+			c.SyncTimeout = tc.syncTimeout
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+			var intervals []time.Duration
+			m.sleep = func(d time.Duration) { intervals = append(intervals, d) }
+
+			err = m.Sync()
+			if tc.expectedError != (err != nil) {
+				t.Fatalf("expected error %t, got %v", tc.expectedError, err)
+			}
+			if tc.expectedTimeout && !IsSyncTimeout(err) {
+				t.Fatalf("expected sync timeout error, got %v", err)
+			}
+
+			if !reflect.DeepEqual(tc.expectedCreated, targetClient.createdStacks) {
+				t.Errorf("expected created stacks %v, got %v", tc.expectedCreated, targetClient.createdStacks)
+			}
+			if !reflect.DeepEqual(tc.expectedIntervals, intervals) {
+				t.Errorf("expected retry intervals %v, got %v", tc.expectedIntervals, intervals)
+			}
+		})
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tcs := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{
+			name:     "case 0: throttling",
+			err:      microerror.Mask(awserr.New("Throttling", "Rate exceeded", nil)),
+			expected: true,
+		},
+		{
+			name:     "case 1: server error",
+			err:      awserr.NewRequestFailure(awserr.New("Unknown", "", nil), 503, ""),
+			expected: true,
+		},
+		{
+			name:     "case 2: expired credentials",
+			err:      awserr.NewRequestFailure(awserr.New("ExpiredToken", "", nil), 403, ""),
+			expected: false,
+		},
+		{
+			name:     "case 3: invalid config",
+			err:      microerror.Mask(invalidConfigError),
+			expected: false,
+		},
+		{
+			name:     "case 4: no error",
+			err:      nil,
+			expected: false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			if IsRetryable(tc.err) != tc.expected {
+				t.Errorf("expected retryable %t, got %t", tc.expected, !tc.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package recordset
+
+import (
+	"fmt"
+
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/route53-manager/pkg/metrics"
+	"github.com/giantswarm/route53-manager/pkg/recordset/plan"
+)
+
+// SkipReason is the reason a stack is not processed.
+type SkipReason = plan.SkipReason
+
+// Skip reasons of the plan computation, see package plan.
+const (
+	SkipReasonSourceStatus     = plan.SkipReasonSourceStatus
+	SkipReasonTargetStatus     = plan.SkipReasonTargetStatus
+	SkipReasonSourceTooYoung   = plan.SkipReasonSourceTooYoung
+	SkipReasonTargetDeleting   = plan.SkipReasonTargetDeleting
+	SkipReasonInvalidStackName = plan.SkipReasonInvalidStackName
+	SkipReasonMissingStatus    = plan.SkipReasonMissingStatus
+)
+
+const (
+	// SkipReasonLegacySuperseded is used for legacy source stacks of clusters
+	// which also have a tccp source stack.
+	SkipReasonLegacySuperseded SkipReason = "legacy_superseded"
+	// SkipReasonMissingInstallationTag is used for stacks without the
+	// installation tag of this installation.
+	SkipReasonMissingInstallationTag SkipReason = "missing_installation_tag"
+	// SkipReasonELBNotFound is used for clusters missing a component ELB.
+	SkipReasonELBNotFound SkipReason = "elb_not_found"
+	// SkipReasonELBUnhealthy is used for clusters with a component ELB
+	// without any healthy instance or target when healthy ELBs are required.
+	SkipReasonELBUnhealthy SkipReason = "elb_unhealthy"
+	// SkipReasonEtcdENIsOutOfBounds is used for clusters whose number of
+	// etcd ENIs is not within the configured bounds.
+	SkipReasonEtcdENIsOutOfBounds SkipReason = "etcd_enis_out_of_bounds"
+	// SkipReasonRecordsFailed is used for clusters whose records cannot be
+	// computed for any other reason.
+	SkipReasonRecordsFailed SkipReason = "records_failed"
+	// SkipReasonOrphanDeletionDisabled is used for orphan target stacks which
+	// would be deleted if orphan deletion was enabled.
+	SkipReasonOrphanDeletionDisabled SkipReason = "orphan_deletion_disabled"
+	// SkipReasonCreateUpdateFailed is used for orphan target stacks which
+	// are kept because creating or updating target stacks failed in the same
+	// sync run when deletions require a clean create and update phase.
+	SkipReasonCreateUpdateFailed SkipReason = "create_update_failed"
+)
+
+var (
+	skippedTotal = metrics.NewCounterVec("skipped_total", "Number of stacks skipped, by reason.", "reason")
+)
+
+func init() {
+	metrics.DefaultRegistry.MustRegister(skippedTotal)
+}
+
+// skip records that the stack is not processed for the given reason. Each
+// stack and reason is counted once per run, even if several phases skip it.
+// Skips caused by an error are logged as errors and skips of stacks without
+// status as warnings, all others as debug messages which are suppressed in
+// quiet mode. Stacks with invalid names never match in any run, so they are
+// only logged once per run, and not at all with m.ignoreUnparseableStacks.
+func (m *Manager) skip(stackName string, reason SkipReason, message string, err error) {
+	if m.summary.skipped == nil {
+		m.summary.skipped = map[SkipReason]map[string]bool{}
+	}
+	if m.summary.skipped[reason] == nil {
+		m.summary.skipped[reason] = map[string]bool{}
+	}
+	seen := m.summary.skipped[reason][stackName]
+	if !seen {
+		m.summary.skipped[reason][stackName] = true
+		skippedTotal.Inc(string(reason))
+	}
+
+	if reason == SkipReasonInvalidStackName && (seen || m.ignoreUnparseableStacks) {
+		return
+	}
+
+	if err != nil {
+		m.logger.Log("level", "error", "message", message, "reason", string(reason), "stack", microerror.JSON(err))
+		return
+	}
+	if reason == SkipReasonMissingStatus {
+		m.logger.Log("level", "warning", "message", message, "reason", string(reason))
+		return
+	}
+
+	if m.quiet {
+		return
+	}
+	m.logger.Log("level", "debug", "message", message, "reason", string(reason))
+}
+
+// skipRecordsFailed records that the records of the cluster with the given
+// source stack could not be computed. The stack is skipped with the reason of
+// the error and counted as failed, so e.g. a throttled lookup is reflected in
+// the result of the sync run like a failed stack operation.
+func (m *Manager) skipRecordsFailed(sourceStackName, clusterID string, err error) {
+	m.skip(sourceStackName, recordsSkipReason(err), fmt.Sprintf("failed to get records of cluster %#q", clusterID), err)
+	m.summary.failed++
+}
+
+// recordsSkipReason returns the skip reason for an error returned when
+// computing the records of a cluster.
+func recordsSkipReason(err error) SkipReason {
+	if IsELBNotFound(err) {
+		return SkipReasonELBNotFound
+	}
+	if IsUnhealthyELB(err) {
+		return SkipReasonELBUnhealthy
+	}
+	if IsEtcdENIsOutOfBounds(err) {
+		return SkipReasonEtcdENIsOutOfBounds
+	}
+
+	return SkipReasonRecordsFailed
+}
+
+// skippedCount returns the number of stacks skipped for the given reason in
+// the current run.
+func (s syncSummary) skippedCount(reason SkipReason) int {
+	return len(s.skipped[reason])
+}
+
+func (s syncSummary) skippedTotal() int {
+	var n int
+	for _, stacks := range s.skipped {
+		n += len(stacks)
+	}
+
+	return n
+}
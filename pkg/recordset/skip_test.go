@@ -0,0 +1,298 @@
+package recordset
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/giantswarm/micrologger"
+)
+
+func TestSync_SkipReasons(t *testing.T) {
+	tags := []*cloudformation.Tag{
+		&cloudformation.Tag{
+			Key:   aws.String(installationTag),
+			Value: aws.String("installation"),
+		},
+	}
+	otherTags := []*cloudformation.Tag{
+		&cloudformation.Tag{
+			Key:   aws.String(installationTag),
+			Value: aws.String("other"),
+		},
+	}
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tcs := []struct {
+		name            string
+		sourceStacks    []cloudformation.Stack
+		targetStacks    []cloudformation.Stack
+		minStackAge     time.Duration
+		noLoadBalancers bool
+		expectedReason  SkipReason
+		expectedFailed  int
+	}{
+		{
+			name: "case 0: source stack status",
+			sourceStacks: []cloudformation.Stack{
+				cloudformation.Stack{
+					StackName:   aws.String("cluster-foo-tccp"),
+					StackStatus: aws.String(cloudformation.StackStatusUpdateInProgress),
+					Tags:        tags,
+				},
+			},
+			expectedReason: SkipReasonSourceStatus,
+		},
+		{
+			name: "case 1: target stack status",
+			sourceStacks: []cloudformation.Stack{
+				cloudformation.Stack{
+					StackName:   aws.String("cluster-foo-tccp"),
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+					Tags:        tags,
+				},
+			},
+			targetStacks: []cloudformation.Stack{
+				cloudformation.Stack{
+					StackName:   aws.String("cluster-foo-guest-recordsets"),
+					StackStatus: aws.String(cloudformation.StackStatusUpdateInProgress),
+					Tags:        tags,
+				},
+			},
+			expectedReason: SkipReasonTargetStatus,
+		},
+		{
+			name: "case 2: source stack too young",
+			sourceStacks: []cloudformation.Stack{
+				cloudformation.Stack{
+					StackName:    aws.String("cluster-foo-tccp"),
+					StackStatus:  aws.String(cloudformation.StackStatusCreateComplete),
+					CreationTime: aws.Time(now.Add(-time.Minute)),
+					Tags:         tags,
+				},
+			},
+			minStackAge:    time.Hour,
+			expectedReason: SkipReasonSourceTooYoung,
+		},
+		{
+			name: "case 3: target stack deleting",
+			sourceStacks: []cloudformation.Stack{
+				cloudformation.Stack{
+					StackName:   aws.String("cluster-foo-tccp"),
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+					Tags:        tags,
+				},
+			},
+			targetStacks: []cloudformation.Stack{
+				cloudformation.Stack{
+					StackName:   aws.String("cluster-foo-guest-recordsets"),
+					StackStatus: aws.String(cloudformation.StackStatusDeleteInProgress),
+					Tags:        tags,
+				},
+			},
+			expectedReason: SkipReasonTargetDeleting,
+		},
+		{
+			name: "case 4: missing installation tag",
+			sourceStacks: []cloudformation.Stack{
+				cloudformation.Stack{
+					StackName:   aws.String("cluster-foo-tccp"),
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+					Tags:        otherTags,
+				},
+			},
+			expectedReason: SkipReasonMissingInstallationTag,
+		},
+		{
+			name: "case 5: ELB not found",
+			sourceStacks: []cloudformation.Stack{
+				cloudformation.Stack{
+					StackName:   aws.String("cluster-foo-tccp"),
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+					Tags:        tags,
+				},
+			},
+			noLoadBalancers: true,
+			expectedReason:  SkipReasonELBNotFound,
+			expectedFailed:  1,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			sourceClient := newSourceWithStacks(tc.sourceStacks)
+			sourceClient.noLoadBalancers = tc.noLoadBalancers
+
+			c := newTestConfig(t)
+			c.SourceClient = sourceClient
+			c.TargetClient = newTargetWithStacks(tc.targetStacks)
+			c.MinStackAge = tc.minStackAge
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+			m.now = func() time.Time { return now }
+
+			before := skippedTotal.Value(string(tc.expectedReason))
+
+			err = m.Sync()
+			if err != nil {
+				t.Fatalf("m.Sync: %v", err)
+			}
+
+			if m.summary.skippedCount(tc.expectedReason) != 1 {
+				t.Errorf("expected 1 stack skipped with reason %#q, got %v", tc.expectedReason, m.summary.skipped)
+			}
+			if got := skippedTotal.Value(string(tc.expectedReason)) - before; got != 1 {
+				t.Errorf("expected skipped_total{reason=%#q} to increase by 1, got %v", tc.expectedReason, got)
+			}
+			if m.summary.failed != tc.expectedFailed {
+				t.Errorf("expected %d failed stacks, got %d", tc.expectedFailed, m.summary.failed)
+			}
+		})
+	}
+}
+
+func TestSkip_InvalidStackName(t *testing.T) {
+	m := newTestManager(t, nil)
+
+	sourceStacks := []cloudformation.Stack{
+		cloudformation.Stack{
+			StackName:   aws.String("invalid"),
+			StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+		},
+	}
+
+	err := m.createMissingTargetStacks(m.computePlan(sourceStacks, nil).Creates)
+	if err != nil {
+		t.Fatalf("createMissingTargetStacks: %v", err)
+	}
+	err = m.updateCurrentTargetStacks(m.computePlan(sourceStacks, nil).Updates)
+	if err != nil {
+		t.Fatalf("updateCurrentTargetStacks: %v", err)
+	}
+
+	if m.summary.skippedCount(SkipReasonInvalidStackName) != 1 {
+		t.Errorf("expected stack to be counted once, got %v", m.summary.skipped)
+	}
+}
+
+func TestSync_MissingStatus(t *testing.T) {
+	var out bytes.Buffer
+	logger, err := micrologger.New(micrologger.Config{IOWriter: &out})
+	if err != nil {
+		t.Fatalf("micrologger.New: %v", err)
+	}
+
+	sourceStacks := []cloudformation.Stack{
+		cloudformation.Stack{
+			StackName: aws.String("cluster-foo-tccp"),
+			Tags: []*cloudformation.Tag{
+				&cloudformation.Tag{
+					Key:   aws.String(installationTag),
+					Value: aws.String("installation"),
+				},
+			},
+		},
+	}
+
+	c := newTestConfig(t)
+	c.Logger = logger
+	c.Quiet = true
+	c.SourceClient = newSourceWithStacks(sourceStacks)
+	c.TargetClient = newTargetWithStacks(nil)
+	m, err := NewManager(c)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	err = m.Sync()
+	if err != nil {
+		t.Fatalf("m.Sync: %v", err)
+	}
+
+	if m.summary.skippedCount(SkipReasonMissingStatus) != 1 {
+		t.Errorf("expected 1 stack skipped with reason %#q, got %v", SkipReasonMissingStatus, m.summary.skipped)
+	}
+	if m.summary.skippedCount(SkipReasonSourceStatus) != 0 {
+		t.Errorf("expected no stack skipped with reason %#q, got %v", SkipReasonSourceStatus, m.summary.skipped)
+	}
+	if !strings.Contains(out.String(), `"level":"warning"`) {
+		t.Errorf("expected warning to be logged, got %s", out.String())
+	}
+}
+
+func TestSkip_InvalidStackNameLogs(t *testing.T) {
+	tcs := []struct {
+		name                    string
+		ignoreUnparseableStacks bool
+		expectedLines           int
+	}{
+		{
+			name:          "case 0: logged once per run",
+			expectedLines: 2,
+		},
+		{
+			name:                    "case 1: ignored",
+			ignoreUnparseableStacks: true,
+			expectedLines:           0,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			logger, err := micrologger.New(micrologger.Config{IOWriter: &out})
+			if err != nil {
+				t.Fatalf("micrologger.New: %v", err)
+			}
+
+			c := newTestConfig(t)
+			c.Logger = logger
+			c.IgnoreUnparseableStacks = tc.ignoreUnparseableStacks
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			sourceStacks := []cloudformation.Stack{
+				cloudformation.Stack{
+					StackName:   aws.String("invalid"),
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+				},
+			}
+
+			before := skippedTotal.Value(string(SkipReasonInvalidStackName))
+
+			// Two runs with the stack skipped by the plan of every phase.
+			for run := 0; run < 2; run++ {
+				m.summary = syncSummary{}
+
+				err = m.createMissingTargetStacks(m.computePlan(sourceStacks, nil).Creates)
+				if err != nil {
+					t.Fatalf("createMissingTargetStacks: %v", err)
+				}
+				err = m.updateCurrentTargetStacks(m.computePlan(sourceStacks, nil).Updates)
+				if err != nil {
+					t.Fatalf("updateCurrentTargetStacks: %v", err)
+				}
+			}
+
+			var lines int
+			for _, line := range strings.Split(out.String(), "\n") {
+				if strings.Contains(line, string(SkipReasonInvalidStackName)) {
+					lines++
+				}
+			}
+			if lines != tc.expectedLines {
+				t.Errorf("expected %d log lines of the invalid stack, got %d: %s", tc.expectedLines, lines, out.String())
+			}
+			if got := skippedTotal.Value(string(SkipReasonInvalidStackName)) - before; got != 2 {
+				t.Errorf("expected skipped_total{reason=%#q} to increase by 2, got %v", SkipReasonInvalidStackName, got)
+			}
+		})
+	}
+}
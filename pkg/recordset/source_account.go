@@ -0,0 +1,72 @@
+package recordset
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/route53-manager/pkg/client"
+	"github.com/giantswarm/route53-manager/pkg/recordset/plan"
+)
+
+const (
+	// SkipReasonDuplicateCluster is used for source stacks of clusters which
+	// are already synced from another source stack, either of the same or of
+	// another source account.
+	SkipReasonDuplicateCluster SkipReason = "duplicate_cluster"
+)
+
+// accountSourceStacks returns the source stacks of the given source account.
+// Clusters discovered without source stack are represented by the stand-in
+// returned by discoveredSourceStack.
+func (m *Manager) accountSourceStacks(cl client.SourceInterface) ([]cloudformation.Stack, error) {
+	clusters, err := m.clusterDiscoverer.Clusters(m.ctx, cl)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var result []cloudformation.Stack
+	for _, c := range clusters {
+		if c.SourceStack != nil {
+			result = append(result, *c.SourceStack)
+		} else {
+			result = append(result, m.discoveredSourceStack(c))
+		}
+	}
+
+	return m.dedupeSourceStacks(result), nil
+}
+
+// claimSourceStacks records the given source account as origin of the
+// clusters of the given source stacks. Source stacks of clusters already
+// claimed by a previous source account are dropped, so every cluster is
+// synced from exactly one source account.
+func (m *Manager) claimSourceStacks(account int, stacks []cloudformation.Stack) []cloudformation.Stack {
+	var result []cloudformation.Stack
+	for _, stack := range stacks {
+		clusterID, err := plan.ClusterID(*stack.StackName)
+		if err != nil {
+			// The plan computation skips stacks with invalid names.
+			result = append(result, stack)
+			continue
+		}
+
+		claimed, ok := m.sourceAccounts[clusterID]
+		if ok && claimed != account {
+			m.skip(*stack.StackName, SkipReasonDuplicateCluster, fmt.Sprintf("ignored source stack %#q of source account %d, cluster %#q is synced from source account %d", *stack.StackName, account, clusterID, claimed), nil)
+			continue
+		}
+		m.sourceAccounts[clusterID] = account
+
+		result = append(result, stack)
+	}
+
+	return result
+}
+
+// sourceClientOf returns the client of the source account the cluster lives
+// in.
+func (m *Manager) sourceClientOf(cluster Cluster) client.SourceInterface {
+	return m.sourceClients[cluster.SourceAccount]
+}
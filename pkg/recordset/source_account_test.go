@@ -0,0 +1,142 @@
+package recordset
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+
+	"github.com/giantswarm/route53-manager/pkg/client"
+)
+
+func TestSync_AdditionalSourceClients(t *testing.T) {
+	tags := []*cloudformation.Tag{
+		&cloudformation.Tag{
+			Key:   aws.String(installationTag),
+			Value: aws.String("installation"),
+		},
+	}
+	newStacks := func(names ...string) []cloudformation.Stack {
+		var stacks []cloudformation.Stack
+		for _, name := range names {
+			stacks = append(stacks, cloudformation.Stack{
+				StackName:   aws.String(name),
+				StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+				Tags:        tags,
+			})
+		}
+		return stacks
+	}
+
+	tcs := []struct {
+		name               string
+		sourceStacks       []cloudformation.Stack
+		otherSourceStacks  []cloudformation.Stack
+		otherSourceFails   bool
+		targetStacks       []cloudformation.Stack
+		expectedCreated    []string
+		expectedUpdated    []string
+		expectedDeleted    []string
+		expectedDuplicates int
+		expectedError      bool
+	}{
+		{
+			name:              "case 0: create target stacks of clusters of both source accounts",
+			sourceStacks:      newStacks("cluster-foo-tccp"),
+			otherSourceStacks: newStacks("cluster-bar-tccp"),
+			expectedCreated:   []string{"cluster-foo-guest-recordsets", "cluster-bar-guest-recordsets"},
+		},
+		{
+			name:              "case 1: delete only target stacks of clusters absent from all source accounts",
+			sourceStacks:      newStacks("cluster-foo-tccp"),
+			otherSourceStacks: newStacks("cluster-bar-tccp"),
+			targetStacks:      newStacks("cluster-foo-guest-recordsets", "cluster-bar-guest-recordsets", "cluster-baz-guest-recordsets"),
+			expectedUpdated:   []string{"cluster-foo-guest-recordsets", "cluster-bar-guest-recordsets"},
+			expectedDeleted:   []string{"cluster-baz-guest-recordsets"},
+		},
+		{
+			name:               "case 2: cluster in both source accounts is synced from the first one",
+			sourceStacks:       newStacks("cluster-foo-tccp"),
+			otherSourceStacks:  newStacks("cluster-foo-tccp", "cluster-bar-tccp"),
+			expectedCreated:    []string{"cluster-foo-guest-recordsets", "cluster-bar-guest-recordsets"},
+			expectedDuplicates: 1,
+		},
+		{
+			name:             "case 3: failed source account listing",
+			sourceStacks:     newStacks("cluster-foo-tccp"),
+			otherSourceFails: true,
+			targetStacks:     newStacks("cluster-foo-guest-recordsets", "cluster-bar-guest-recordsets"),
+			expectedError:    true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			// The other source account only has network load balancers, so
+			// the records show which account the ELBs were looked up in.
+			otherSourceClient := newSourceWithStacks(tc.otherSourceStacks)
+			otherSourceClient.noLoadBalancers = true
+			otherSourceClient.loadBalancersV2 = []*elbv2.LoadBalancer{
+				{
+					CanonicalHostedZoneId: aws.String("nlbZoneID"),
+					DNSName:               aws.String("nlb.dns.test"),
+					Type:                  aws.String(elbv2.LoadBalancerTypeEnumNetwork),
+				},
+			}
+			if tc.otherSourceFails {
+				otherSourceClient.listStacksErrors = []error{mockClientError}
+			}
+			targetClient := newTargetWithStacks(tc.targetStacks)
+
+			c := newTestConfig(t)
+			c.SourceClient = newSourceWithStacks(tc.sourceStacks)
+			c.AdditionalSourceClients = []client.SourceInterface{otherSourceClient}
+			c.TargetClient = targetClient
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			err = m.Sync()
+			if tc.expectedError != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tc.expectedError, err)
+			}
+
+			if !reflect.DeepEqual(tc.expectedCreated, targetClient.createdStacks) {
+				t.Errorf("expected created stacks %v, got %v", tc.expectedCreated, targetClient.createdStacks)
+			}
+			if !reflect.DeepEqual(tc.expectedUpdated, targetClient.updatedStacks) {
+				t.Errorf("expected updated stacks %v, got %v", tc.expectedUpdated, targetClient.updatedStacks)
+			}
+			if !reflect.DeepEqual(tc.expectedDeleted, targetClient.deletedStacks) {
+				t.Errorf("expected deleted stacks %v, got %v", tc.expectedDeleted, targetClient.deletedStacks)
+			}
+			if m.summary.skippedCount(SkipReasonDuplicateCluster) != tc.expectedDuplicates {
+				t.Errorf("expected %d duplicate clusters, got %d", tc.expectedDuplicates, m.summary.skippedCount(SkipReasonDuplicateCluster))
+			}
+
+			for _, input := range targetClient.createStackInputs {
+				expected := "elb.dns.test"
+				if *input.StackName == "cluster-bar-guest-recordsets" {
+					expected = "nlb.dns.test"
+				}
+				if !strings.Contains(*input.TemplateBody, expected) {
+					t.Errorf("expected template of %#q to contain %#q, got %s", *input.StackName, expected, *input.TemplateBody)
+				}
+			}
+		})
+	}
+}
+
+func TestNewManager_InvalidAdditionalSourceClients(t *testing.T) {
+	c := newTestConfig(t)
+	c.AdditionalSourceClients = []client.SourceInterface{nil}
+
+	_, err := NewManager(c)
+	if !IsInvalidConfig(err) {
+		t.Fatalf("expected invalid config error, got %v", err)
+	}
+}
@@ -0,0 +1,141 @@
+package recordset
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+
+	"github.com/giantswarm/route53-manager/pkg/recordset/plan"
+)
+
+// StackKind is the kind of stack name pattern a stack matched.
+type StackKind string
+
+const (
+	// StackKindLegacy matches source stacks of legacy clusters, see
+	// legacySourceStackNamePattern.
+	StackKindLegacy StackKind = "legacy"
+	// StackKindTCCP matches source stacks of node pool clusters, see
+	// sourceStackNamePattern.
+	StackKindTCCP StackKind = "tccp"
+	// StackKindTarget matches target stacks, see targetStackNamePattern.
+	StackKindTarget StackKind = "target"
+	// StackKindUnknown is returned for stacks matching none of the patterns.
+	StackKindUnknown StackKind = "unknown"
+)
+
+var (
+	stackKindREs = []struct {
+		kind StackKind
+		re   *regexp.Regexp
+	}{
+		{kind: StackKindLegacy, re: regexp.MustCompile(legacySourceStackNamePattern)},
+		{kind: StackKindTCCP, re: regexp.MustCompile(sourceStackNamePattern)},
+		{kind: StackKindTarget, re: regexp.MustCompile(targetStackNamePattern)},
+	}
+)
+
+// getStackKind returns the kind of the first stack name pattern stackName
+// matches.
+func getStackKind(stackName string) StackKind {
+	for _, k := range stackKindREs {
+		if k.re.MatchString(stackName) {
+			return k.kind
+		}
+	}
+
+	return StackKindUnknown
+}
+
+// getStacksNameWithKind returns the names of the stacks together with the
+// kind of pattern they matched, e.g. `cluster-foo-tccp (tccp)`.
+func getStacksNameWithKind(stacks []cloudformation.Stack) (names []string) {
+	for _, stack := range stacks {
+		names = append(names, fmt.Sprintf("%s (%s)", *stack.StackName, getStackKind(*stack.StackName)))
+	}
+
+	return names
+}
+
+// dedupeSourceStacks keeps a single source stack per cluster. Legacy source
+// stacks of clusters which also have a tccp source stack are dropped, e.g.
+// during the migration to Giant Swarm Release version 10.0.0. Any other source
+// stacks resolving to the same cluster ID are dropped in favour of the one
+// preferred by preferSourceStack, so records are not applied twice for the
+// same cluster.
+func (m *Manager) dedupeSourceStacks(stacks []cloudformation.Stack) []cloudformation.Stack {
+	winners := map[string]int{}
+	for i, stack := range stacks {
+		clusterID, err := plan.ClusterID(*stack.StackName)
+		if err != nil {
+			continue
+		}
+		j, ok := winners[clusterID]
+		if !ok || preferSourceStack(stack, stacks[j]) {
+			winners[clusterID] = i
+		}
+	}
+
+	var result []cloudformation.Stack
+	for i, stack := range stacks {
+		clusterID, err := plan.ClusterID(*stack.StackName)
+		if err != nil || winners[clusterID] == i {
+			// The plan computation skips stacks with invalid names.
+			result = append(result, stack)
+			continue
+		}
+
+		winner := *stacks[winners[clusterID]].StackName
+		if getStackKind(*stack.StackName) == StackKindLegacy && getStackKind(winner) == StackKindTCCP {
+			m.skip(*stack.StackName, SkipReasonLegacySuperseded, fmt.Sprintf("ignored legacy source stack %#q in favour of tccp source stack %#q", *stack.StackName, winner), nil)
+			continue
+		}
+
+		m.logger.Log("level", "warning", "message", fmt.Sprintf("found multiple source stacks for cluster %#q", clusterID), "stacks", fmt.Sprintf("%s, %s", winner, *stack.StackName))
+		m.skip(*stack.StackName, SkipReasonDuplicateCluster, fmt.Sprintf("ignored source stack %#q in favour of source stack %#q of the same cluster %#q", *stack.StackName, winner, clusterID), nil)
+	}
+
+	return result
+}
+
+// preferSourceStack checks if the source stack a takes precedence over the
+// source stack b of the same cluster. Stacks in a status records are read in
+// are preferred over stacks in any other status, and deleted or deleting
+// stacks lose against all others, so a stale stack does not shadow a healthy
+// one. Then tccp stacks are preferred over legacy stacks, then the most
+// recently updated stack wins. Ties are broken by stack name, so the choice
+// does not depend on the order stacks are listed in.
+func preferSourceStack(a, b cloudformation.Stack) bool {
+	aRank := plan.SourceStatusRank(a)
+	bRank := plan.SourceStatusRank(b)
+	if aRank != bRank {
+		return aRank > bRank
+	}
+
+	aTCCP := getStackKind(*a.StackName) == StackKindTCCP
+	bTCCP := getStackKind(*b.StackName) == StackKindTCCP
+	if aTCCP != bTCCP {
+		return aTCCP
+	}
+
+	aTime := stackUpdateTime(a)
+	bTime := stackUpdateTime(b)
+	if !aTime.Equal(bTime) {
+		return aTime.After(bTime)
+	}
+
+	return *a.StackName < *b.StackName
+}
+
+// stackUpdateTime returns the time the stack was last updated, falling back
+// to its creation time.
+func stackUpdateTime(stack cloudformation.Stack) time.Time {
+	if stack.LastUpdatedTime != nil {
+		return *stack.LastUpdatedTime
+	}
+
+	return aws.TimeValue(stack.CreationTime)
+}
@@ -0,0 +1,209 @@
+package recordset
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+)
+
+func TestGetStackKind(t *testing.T) {
+	tcs := []struct {
+		name      string
+		stackName string
+		expected  StackKind
+	}{
+		{
+			name:      "case 0: legacy source stack",
+			stackName: "cluster-foo-guest-main",
+			expected:  StackKindLegacy,
+		},
+		{
+			name:      "case 1: tccp source stack",
+			stackName: "cluster-foo-tccp",
+			expected:  StackKindTCCP,
+		},
+		{
+			name:      "case 2: target stack",
+			stackName: "cluster-foo-guest-recordsets",
+			expected:  StackKindTarget,
+		},
+		{
+			name:      "case 3: tccpn stack is unknown",
+			stackName: "cluster-foo-tccpn",
+			expected:  StackKindUnknown,
+		},
+		{
+			name:      "case 4: unrelated stack is unknown",
+			stackName: "some-other-stack",
+			expected:  StackKindUnknown,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			kind := getStackKind(tc.stackName)
+			if kind != tc.expected {
+				t.Errorf("expected %#q, got %#q", tc.expected, kind)
+			}
+		})
+	}
+}
+
+func TestGetStacksNameWithKind(t *testing.T) {
+	stacks := []cloudformation.Stack{
+		{StackName: aws.String("cluster-foo-guest-main")},
+		{StackName: aws.String("cluster-bar-tccp")},
+	}
+
+	expected := []string{"cluster-foo-guest-main (legacy)", "cluster-bar-tccp (tccp)"}
+	names := getStacksNameWithKind(stacks)
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected %v, got %v", expected, names)
+	}
+}
+
+func TestSync_LegacyAndTCCPSourceStacks(t *testing.T) {
+	tags := []*cloudformation.Tag{
+		&cloudformation.Tag{
+			Key:   aws.String(installationTag),
+			Value: aws.String("installation"),
+		},
+	}
+	sourceStacks := []cloudformation.Stack{
+		cloudformation.Stack{
+			StackName:   aws.String("cluster-foo-guest-main"),
+			StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+			Tags:        tags,
+		},
+		cloudformation.Stack{
+			StackName:   aws.String("cluster-foo-tccp"),
+			StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+			Tags:        tags,
+		},
+		cloudformation.Stack{
+			StackName:   aws.String("cluster-bar-guest-main"),
+			StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+			Tags:        tags,
+		},
+	}
+	targetClient := newTargetWithStacks(nil)
+
+	c := newTestConfig(t)
+	c.SourceClient = newSourceWithStacks(sourceStacks)
+	c.TargetClient = targetClient
+	c.TemplateFormat = TemplateFormatJSON
+	m, err := NewManager(c)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	err = m.Sync()
+	if err != nil {
+		t.Fatalf("m.Sync: %v", err)
+	}
+
+	expected := []string{"cluster-foo-guest-recordsets", "cluster-bar-guest-recordsets"}
+	if !reflect.DeepEqual(targetClient.createdStacks, expected) {
+		t.Fatalf("expected created stacks %v, got %v", expected, targetClient.createdStacks)
+	}
+	if m.summary.skippedCount(SkipReasonLegacySuperseded) != 1 || !m.summary.skipped[SkipReasonLegacySuperseded]["cluster-foo-guest-main"] {
+		t.Errorf("expected legacy source stack `cluster-foo-guest-main` to be ignored, got %v", m.summary.skipped)
+	}
+
+	var template stackTemplate
+	err = json.Unmarshal([]byte(*targetClient.createStackInputs[0].TemplateBody), &template)
+	if err != nil {
+		t.Fatalf("json.Unmarshal: %v", err)
+	}
+	if _, ok := template.Resources["ingressDNSRecord"]; ok {
+		t.Errorf("expected cluster `foo` records to be computed from the tccp source stack, got %v", template.Resources)
+	}
+}
+
+func TestSync_DuplicateClusterSourceStacks(t *testing.T) {
+	tags := []*cloudformation.Tag{
+		&cloudformation.Tag{
+			Key:   aws.String(installationTag),
+			Value: aws.String("installation"),
+		},
+	}
+	now := time.Now()
+	sourceStacks := []cloudformation.Stack{
+		cloudformation.Stack{
+			StackName:       aws.String("cluster-foo-guest-main"),
+			StackStatus:     aws.String(cloudformation.StackStatusUpdateComplete),
+			CreationTime:    aws.Time(now.Add(-2 * time.Hour)),
+			LastUpdatedTime: aws.Time(now.Add(-time.Hour)),
+			Tags:            tags,
+		},
+		cloudformation.Stack{
+			StackName:    aws.String("cluster-foo-old-guest-main"),
+			StackStatus:  aws.String(cloudformation.StackStatusCreateComplete),
+			CreationTime: aws.Time(now.Add(-3 * time.Hour)),
+			Tags:         tags,
+		},
+	}
+
+	deleting := sourceStacks[0]
+	deleting.StackStatus = aws.String(cloudformation.StackStatusDeleteInProgress)
+	rollingBack := sourceStacks[0]
+	rollingBack.StackStatus = aws.String(cloudformation.StackStatusUpdateRollbackInProgress)
+
+	tcs := []struct {
+		name            string
+		sourceStacks    []cloudformation.Stack
+		expectedIgnored string
+	}{
+		{
+			name:            "case 0: newest source stack listed first",
+			sourceStacks:    sourceStacks,
+			expectedIgnored: "cluster-foo-old-guest-main",
+		},
+		{
+			name:            "case 1: newest source stack listed last",
+			sourceStacks:    []cloudformation.Stack{sourceStacks[1], sourceStacks[0]},
+			expectedIgnored: "cluster-foo-old-guest-main",
+		},
+		{
+			name:            "case 2: newest source stack is being deleted",
+			sourceStacks:    []cloudformation.Stack{deleting, sourceStacks[1]},
+			expectedIgnored: "cluster-foo-guest-main",
+		},
+		{
+			name:            "case 3: newest source stack is rolling back",
+			sourceStacks:    []cloudformation.Stack{rollingBack, sourceStacks[1]},
+			expectedIgnored: "cluster-foo-guest-main",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			targetClient := newTargetWithStacks(nil)
+
+			c := newTestConfig(t)
+			c.SourceClient = newSourceWithStacks(tc.sourceStacks)
+			c.TargetClient = targetClient
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			err = m.Sync()
+			if err != nil {
+				t.Fatalf("m.Sync: %v", err)
+			}
+
+			expected := []string{"cluster-foo-guest-recordsets"}
+			if !reflect.DeepEqual(targetClient.createdStacks, expected) {
+				t.Fatalf("expected created stacks %v, got %v", expected, targetClient.createdStacks)
+			}
+			if m.summary.skippedCount(SkipReasonDuplicateCluster) != 1 || !m.summary.skipped[SkipReasonDuplicateCluster][tc.expectedIgnored] {
+				t.Errorf("expected source stack %#q to be ignored, got %v", tc.expectedIgnored, m.summary.skipped)
+			}
+		})
+	}
+}
@@ -1,194 +1,676 @@
 package recordset
 
 import (
-	"bytes"
+	"encoding/json"
 	"fmt"
+	"reflect"
 	"sort"
-	"text/template"
+	"strconv"
+	"strings"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/cloudformation"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/aws/aws-sdk-go/service/route53"
 	"github.com/giantswarm/microerror"
+	"gopkg.in/yaml.v3"
 
+	"github.com/giantswarm/route53-manager/pkg/client"
 	"github.com/giantswarm/route53-manager/pkg/key"
 )
 
 const (
-	targetStackTemplate = `AWSTemplateFormatVersion: 2010-09-09
-Description: Recordset Guest CloudFormation stack.
-Resources:
-  {{ if .IsLegacyCluster -}}
-  ingressDNSRecord:
-    Type: AWS::Route53::RecordSet
-    Properties:
-      HostedZoneId: {{ .HostedZoneID }}
-      Name: 'ingress.{{ .ClusterName }}.{{ .HostedZoneName }}'
-      Type: CNAME
-      TTL: '30'
-      ResourceRecords:
-      - {{ .IngressELBDNS }}
-  {{ end -}}
-
-  ingressWildcardDNSRecord:
-    Type: AWS::Route53::RecordSet
-    Properties:
-      HostedZoneId: {{ .HostedZoneID }}
-      Name: '*.{{ .ClusterName }}.{{ .HostedZoneName }}'
-      Type: CNAME
-      TTL: '30'
-      ResourceRecords:
-      - 'ingress.{{ .ClusterName }}.{{ .HostedZoneName }}'
-
-  apiDNSRecord:
-    Type: AWS::Route53::RecordSet
-    Properties:
-      HostedZoneId: {{ .HostedZoneID }}
-      Name: 'api.{{ .ClusterName }}.{{ .HostedZoneName }}'
-      Type: CNAME
-      TTL: '30'
-      ResourceRecords:
-      - {{ .APIELBDNS }}
-
-  etcdDNSRecord:
-    Type: AWS::Route53::RecordSet
-    Properties:
-      HostedZoneId: {{ .HostedZoneID }}
-      Name: 'etcd.{{ .ClusterName }}.{{ .HostedZoneName }}'
-      Type: CNAME
-      TTL: '30'
-      ResourceRecords:
-      - {{ .EtcdELBDNS }}
-
-  {{ $hz := .HostedZoneID }}
-  {{- range .EtcdEniList }}
-  {{ .Name }}:
-    Type: AWS::Route53::RecordSet
-    Properties:
-      HostedZoneId: {{ $hz }}
-      Name: '{{ .DNSName }}'
-      Type: A
-      TTL: '30'
-      ResourceRecords:
-      - {{ .IPAddress }}
-  {{- end }} 
-`
+	TemplateFormatJSON = "json"
+	TemplateFormatYAML = "yaml"
 )
 
-func (m *Manager) getCreateStackInput(targetStackName string, data *sourceStackData, sourceStack cloudformation.Stack) (*cloudformation.CreateStackInput, error) {
-	templateBody, err := m.getStackTemplateBody(data)
+const (
+	// DefaultENIClusterTag is the tag key carrying the cluster ID the network
+	// interfaces of the etcd records are filtered by.
+	DefaultENIClusterTag = key.TagCluster
+	// DefaultENIOrderTag is the tag key the network interfaces of the etcd
+	// records are ordered by, assigning etcd1 to the first one.
+	DefaultENIOrderTag = "Name"
+)
+
+const (
+	templateDescription   = "Recordset Guest CloudFormation stack."
+	templateFormatVersion = "2010-09-09"
+
+	recordSetResourceType = "AWS::Route53::RecordSet"
+	recordSetTTL          = "30"
+)
+
+// stackTemplate is the CloudFormation template of a target stack. It is
+// marshaled to either YAML or JSON.
+type stackTemplate struct {
+	AWSTemplateFormatVersion string                   `json:"AWSTemplateFormatVersion" yaml:"AWSTemplateFormatVersion"`
+	Description              string                   `json:"Description" yaml:"Description"`
+	Metadata                 *stackTemplateMetadata   `json:"Metadata,omitempty" yaml:"Metadata,omitempty"`
+	Resources                map[string]stackResource `json:"Resources" yaml:"Resources"`
+}
+
+// stackTemplateMetadata is the Metadata section of a target stack template.
+type stackTemplateMetadata struct {
+	TemplateVersion string `json:"TemplateVersion" yaml:"TemplateVersion"`
+}
+
+type stackResource struct {
+	Type       string              `json:"Type" yaml:"Type"`
+	Properties recordSetProperties `json:"Properties" yaml:"Properties"`
+}
+
+type recordSetProperties struct {
+	HostedZoneID    string                 `json:"HostedZoneId" yaml:"HostedZoneId"`
+	Name            string                 `json:"Name" yaml:"Name"`
+	Type            string                 `json:"Type" yaml:"Type"`
+	TTL             string                 `json:"TTL,omitempty" yaml:"TTL,omitempty"`
+	ResourceRecords []string               `json:"ResourceRecords,omitempty" yaml:"ResourceRecords,omitempty"`
+	AliasTarget     *aliasTargetProperties `json:"AliasTarget,omitempty" yaml:"AliasTarget,omitempty"`
+	Weight          *int64                 `json:"Weight,omitempty" yaml:"Weight,omitempty"`
+	Failover        string                 `json:"Failover,omitempty" yaml:"Failover,omitempty"`
+	SetIdentifier   string                 `json:"SetIdentifier,omitempty" yaml:"SetIdentifier,omitempty"`
+	HealthCheckID   string                 `json:"HealthCheckId,omitempty" yaml:"HealthCheckId,omitempty"`
+}
+
+// aliasTargetProperties is the alias target of an alias record set. Alias
+// record sets have neither a TTL nor resource records.
+type aliasTargetProperties struct {
+	DNSName      string `json:"DNSName" yaml:"DNSName"`
+	HostedZoneID string `json:"HostedZoneId" yaml:"HostedZoneId"`
+}
+
+func (m *Manager) getCreateStackInput(targetStackName string, records []DesiredRecord, sourceStack cloudformation.Stack) (*cloudformation.CreateStackInput, error) {
+	templateBody, err := m.getStackTemplateBody(records)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	err = m.checkTemplateSize(targetStackName, templateBody)
 	if err != nil {
 		return nil, microerror.Mask(err)
 	}
 
 	input := &cloudformation.CreateStackInput{
 		StackName:        aws.String(targetStackName),
-		Tags:             sourceStack.Tags,
+		Tags:             withManagedByTag(m.getStackTags(sourceStack)),
 		TemplateBody:     aws.String(templateBody),
 		TimeoutInMinutes: aws.Int64(2),
 	}
+	if m.stackPolicy != "" {
+		input.StackPolicyBody = aws.String(m.stackPolicy)
+	}
+	if len(m.notificationARNs) > 0 {
+		input.NotificationARNs = aws.StringSlice(m.notificationARNs)
+	}
 
 	return input, nil
 }
 
-func (m *Manager) getUpdateStackInput(targetStackName string, data *sourceStackData, sourceStack cloudformation.Stack) (*cloudformation.UpdateStackInput, error) {
-	templateBody, err := m.getStackTemplateBody(data)
+func (m *Manager) getUpdateStackInput(targetStackName string, records []DesiredRecord, sourceStack cloudformation.Stack) (*cloudformation.UpdateStackInput, error) {
+	templateBody, err := m.getStackTemplateBody(records)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	err = m.checkTemplateSize(targetStackName, templateBody)
 	if err != nil {
 		return nil, microerror.Mask(err)
 	}
 
 	input := &cloudformation.UpdateStackInput{
 		StackName:    aws.String(targetStackName),
-		Tags:         sourceStack.Tags,
+		Tags:         m.getStackTags(sourceStack),
 		TemplateBody: aws.String(templateBody),
 	}
+	if m.stackPolicy != "" {
+		input.StackPolicyBody = aws.String(m.stackPolicy)
+	}
+	if len(m.notificationARNs) > 0 {
+		input.NotificationARNs = aws.StringSlice(m.notificationARNs)
+	}
 
 	return input, nil
 }
 
-func (m *Manager) getStackTemplateBody(data *sourceStackData) (string, error) {
-	tmpl, err := template.New("recordsets").Parse(targetStackTemplate)
+// isTagOnlyUpdate returns true when the rendered template of the update equals
+// the current template of the target stack, but the tags differ.
+func (m *Manager) isTagOnlyUpdate(input *cloudformation.UpdateStackInput, targetStack cloudformation.Stack) (bool, error) {
+	if equalStackTags(withoutStackTag(input.Tags, changeReasonTag), withoutStackTag(targetStack.Tags, changeReasonTag)) || input.TemplateBody == nil {
+		return false, nil
+	}
+
+	o, err := m.targetClient.GetTemplateWithContext(m.ctx, &cloudformation.GetTemplateInput{
+		StackName: input.StackName,
+	})
 	if err != nil {
-		return "", microerror.Mask(err)
+		return false, microerror.Mask(err)
+	}
+
+	equal, err := equalTemplateBodies(aws.StringValue(o.TemplateBody), aws.StringValue(input.TemplateBody))
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	return equal, nil
+}
+
+// equalTemplateBodies returns true when both template bodies have the same
+// sections and resources, with CNAME values compared by normalizeCNAMEValue.
+func equalTemplateBodies(a, b string) (bool, error) {
+	if a == b {
+		return true, nil
+	}
+
+	ta, err := parseStackTemplate(a)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+	tb, err := parseStackTemplate(b)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+	normalizeTemplateCNAMEs(ta)
+	normalizeTemplateCNAMEs(tb)
+	if !reflect.DeepEqual(ta, tb) {
+		return false, nil
+	}
+
+	sa, err := templateSections(a)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+	sb, err := templateSections(b)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	return reflect.DeepEqual(sa, sb), nil
+}
+
+// equalStackTags returns true when both lists hold the same tags, regardless
+// of their order.
+func equalStackTags(a, b []*cloudformation.Tag) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	tags := map[string]string{}
+	for _, t := range a {
+		tags[aws.StringValue(t.Key)] = aws.StringValue(t.Value)
 	}
+	for _, t := range b {
+		v, ok := tags[aws.StringValue(t.Key)]
+		if !ok || v != aws.StringValue(t.Value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// getStackTags returns the tags of the source stack extended by the version,
+// template version and change reason tags, so operators can tell which
+// route53-manager version last touched a target stack, which template it was
+// rendered with and why.
+func (m *Manager) getStackTags(sourceStack cloudformation.Stack) []*cloudformation.Tag {
+	var tags []*cloudformation.Tag
+	for _, t := range sourceStack.Tags {
+		if t.Key != nil && (*t.Key == versionTag || *t.Key == templateVersionTag || *t.Key == changeReasonTag) {
+			continue
+		}
+		tags = append(tags, t)
+	}
+
+	if m.templateVersion != "" {
+		t := &cloudformation.Tag{
+			Key:   aws.String(templateVersionTag),
+			Value: aws.String(m.templateVersion),
+		}
+		tags = append(tags, t)
+	}
+
+	if m.version != "" {
+		t := &cloudformation.Tag{
+			Key:   aws.String(versionTag),
+			Value: aws.String(m.version),
+		}
+		tags = append(tags, t)
+	}
+
+	if t := m.changeReasonStackTag(); t != nil {
+		tags = append(tags, t)
+	}
+
+	return tags
+}
+
+func (m *Manager) getStackTemplateBody(records []DesiredRecord) (string, error) {
+	t := m.newTargetStackTemplate(records)
 
-	var templateBody bytes.Buffer
-	err = tmpl.Execute(&templateBody, data)
+	var templateBody []byte
+	var err error
+	switch m.templateFormat {
+	case TemplateFormatJSON:
+		templateBody, err = json.MarshalIndent(t, "", "  ")
+	default:
+		templateBody, err = yaml.Marshal(t)
+	}
 	if err != nil {
 		return "", microerror.Mask(err)
 	}
 
-	return templateBody.String(), nil
+	return string(templateBody), nil
 }
 
-func (m *Manager) getSourceStackData(clusterName string, isLegacyCluster bool) (*sourceStackData, error) {
+// parseStackTemplate parses a target stack template in either template
+// format, e.g. the current template of a target stack rendered in another
+// format than m.templateFormat.
+func parseStackTemplate(body string) (stackTemplate, error) {
+	var t stackTemplate
 	var err error
-	var ingressELBDNS string
+	if strings.HasPrefix(strings.TrimSpace(body), "{") {
+		err = json.Unmarshal([]byte(body), &t)
+	} else {
+		err = yaml.Unmarshal([]byte(body), &t)
+	}
+	if err != nil {
+		return stackTemplate{}, microerror.Mask(err)
+	}
 
-	if isLegacyCluster {
-		ingressELBName := clusterName + "-ingress"
-		ingressELBDNS, err = m.getELBDNS(ingressELBName)
-		if err != nil {
-			return nil, microerror.Mask(err)
+	return t, nil
+}
+
+// newTargetStackTemplate returns the template of a target stack with the
+// given records.
+func (m *Manager) newTargetStackTemplate(records []DesiredRecord) stackTemplate {
+	t := newStackTemplate(m.targetHostedZoneID, records)
+	applyFailover(t, m.targetHostedZoneID, m.failover)
+	if m.templateVersion != "" {
+		t.Metadata = &stackTemplateMetadata{
+			TemplateVersion: m.templateVersion,
 		}
 	}
 
-	apiELBName := clusterName + "-api"
-	apiELBDNS, err := m.getELBDNS(apiELBName)
+	return t
+}
+
+func newStackTemplate(hostedZoneID string, records []DesiredRecord) stackTemplate {
+	resources := map[string]stackResource{}
+	for _, r := range records {
+		recordHostedZoneID := hostedZoneID
+		if r.HostedZoneID != "" {
+			recordHostedZoneID = r.HostedZoneID
+		}
+		var resource stackResource
+		if r.AliasTarget != nil {
+			resource = newAliasRecordSetResource(recordHostedZoneID, r.Name, r.Type, *r.AliasTarget)
+		} else {
+			resource = newRecordSetResource(recordHostedZoneID, r.Name, r.Type, r.Values...)
+		}
+		if r.TTL > 0 && r.AliasTarget == nil {
+			resource.Properties.TTL = strconv.FormatInt(r.TTL, 10)
+		}
+		if r.Weight != nil {
+			resource.Properties.Weight = aws.Int64(*r.Weight)
+			resource.Properties.SetIdentifier = r.SetIdentifier
+		}
+		resources[r.ResourceName] = resource
+	}
+
+	t := stackTemplate{
+		AWSTemplateFormatVersion: templateFormatVersion,
+		Description:              templateDescription,
+		Resources:                resources,
+	}
+
+	return t
+}
+
+func newRecordSetResource(hostedZoneID, name, recordType string, records ...string) stackResource {
+	r := stackResource{
+		Type: recordSetResourceType,
+		Properties: recordSetProperties{
+			HostedZoneID:    hostedZoneID,
+			Name:            name,
+			Type:            recordType,
+			TTL:             recordSetTTL,
+			ResourceRecords: records,
+		},
+	}
+
+	return r
+}
+
+func newAliasRecordSetResource(hostedZoneID, name, recordType string, target AliasTarget) stackResource {
+	r := stackResource{
+		Type: recordSetResourceType,
+		Properties: recordSetProperties{
+			HostedZoneID: hostedZoneID,
+			Name:         name,
+			Type:         recordType,
+			AliasTarget: &aliasTargetProperties{
+				DNSName:      target.DNSName,
+				HostedZoneID: target.HostedZoneID,
+			},
+		},
+	}
+
+	return r
+}
+
+func (m *Manager) getSourceStackData(cluster Cluster) (*sourceStackData, error) {
+	clusterName := cluster.ID
+	isLegacyCluster := cluster.IsLegacy
+	cl := m.sourceClientOf(cluster)
+
+	apiRecordType, err := m.clusterAPIRecordType(cluster)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	weights, err := m.clusterWeights(cluster)
 	if err != nil {
 		return nil, microerror.Mask(err)
 	}
 
-	etcdELBName := clusterName + "-etcd"
-	etcdELBDNS, err := m.getELBDNS(etcdELBName)
+	ttl, err := m.clusterTTL(cluster)
 	if err != nil {
 		return nil, microerror.Mask(err)
 	}
 
-	eniList, err := m.getEniList(clusterName, key.BaseDomain(clusterName, m.targetHostedZoneName))
+	var apiAliasTarget *AliasTarget
+	var componentRecords []ComponentRecord
+	var unhealthyComponents []string
+	for _, c := range m.components {
+		if c.LegacyOnly && !isLegacyCluster && !(m.nonLegacyIngress && c.Name == ingressComponentName) {
+			continue
+		}
+
+		r := ComponentRecord{
+			ResourceName: key.ComponentResourceName(c.Name),
+			Name:         c.Name,
+		}
+		if c.Name == apiComponentName && apiRecordType == route53.RRTypeA {
+			apiAliasTarget, err = m.getComponentAliasTarget(cl, cluster, c)
+			if IsUnhealthyELB(err) {
+				m.logUnhealthyComponent(cluster, c, err)
+				unhealthyComponents = append(unhealthyComponents, c.Name)
+				continue
+			} else if err != nil {
+				return nil, microerror.Mask(err)
+			}
+			// The alias record has its own logical ID, so every logical ID
+			// stays bound to one record type when the type is switched.
+			r.ResourceName = key.ComponentAliasResourceName(c.Name)
+			r.ELBDNS = apiAliasTarget.DNSName
+		} else {
+			r.ELBDNS, err = m.getComponentELBDNS(cl, cluster, c)
+			if IsUnhealthyELB(err) {
+				m.logUnhealthyComponent(cluster, c, err)
+				unhealthyComponents = append(unhealthyComponents, c.Name)
+				continue
+			} else if err != nil {
+				return nil, microerror.Mask(err)
+			}
+		}
+		componentRecords = append(componentRecords, r)
+	}
+
+	hostedZone, etcdHostedZone := m.clusterHostedZones(m.clusterTags(cluster))
+
+	eniList, err := m.getEniList(cl, clusterName, key.BaseDomain(clusterName, etcdHostedZone.Name))
 	if err != nil {
 		return nil, microerror.Mask(err)
 	}
+	// Legacy clusters have no tccpn stack, so no etcd ENIs are found.
+	if !isLegacyCluster {
+		err = m.checkEtcdENIBounds(clusterName, eniList)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var ingressAliasTarget *AliasTarget
+	if m.aliasWildcard {
+		ingressAliasTarget, err = m.getIngressAliasTarget(cl, clusterName)
+		if IsUnhealthyELB(err) {
+			if !stringInSlice(ingressComponentName, unhealthyComponents) {
+				m.logUnhealthyComponent(cluster, Component{Name: ingressComponentName}, err)
+				unhealthyComponents = append(unhealthyComponents, ingressComponentName)
+			}
+		} else if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
 
 	output := &sourceStackData{
-		HostedZoneID:    m.targetHostedZoneID,
-		HostedZoneName:  m.targetHostedZoneName,
-		ClusterName:     clusterName,
-		IngressELBDNS:   ingressELBDNS,
-		IsLegacyCluster: isLegacyCluster,
-		APIELBDNS:       apiELBDNS,
-		EtcdELBDNS:      etcdELBDNS,
-		EtcdEniList:     eniList,
+		HostedZoneID:       hostedZone.ID,
+		HostedZoneName:     hostedZone.Name,
+		EtcdHostedZoneID:   etcdHostedZone.ID,
+		EtcdHostedZoneName: etcdHostedZone.Name,
+		ClusterName:        clusterName,
+		IsLegacyCluster:    isLegacyCluster,
+		ComponentRecords:   componentRecords,
+		EtcdEniList:        eniList,
+		IngressAliasTarget: ingressAliasTarget,
+		APIAliasTarget:     apiAliasTarget,
+		OmitIngressRecords: stringInSlice(ingressComponentName, unhealthyComponents),
+		BareDomainRecord:   m.bareDomainRecord,
+		CAAValue:           m.caaValue,
+		TTL:                ttl,
+	}
+	if len(weights) > 0 {
+		output.Weights = weights
+		output.SetIdentifier = m.setIdentifier
+	}
+	if m.metadataRecord {
+		output.MetadataValue = m.clusterMetadata(cluster)
+	}
+	if m.emitPTR {
+		output.ReverseHostedZoneID = m.reverseHostedZoneID
+		output.ReverseHostedZoneName = m.reverseHostedZoneName
 	}
 	return output, nil
 }
 
-func (m *Manager) getELBDNS(elbName string) (string, error) {
+// logUnhealthyComponent logs that the record of the component is left out of
+// the records of the cluster, because its ELB has no healthy backend and
+// Config.RequireHealthyELB is set.
+func (m *Manager) logUnhealthyComponent(cluster Cluster, c Component, err error) {
+	m.logger.Log("level", "warning", "message", fmt.Sprintf("left out %s record of cluster %#q", c.Name, cluster.ID), "reason", string(SkipReasonELBUnhealthy), "stack", microerror.JSON(err))
+}
+
+// getComponentELBDNS returns the DNS name of the ELB of the component. When
+// enabled, it is read from the source stack outputs first, falling back to
+// the ELB lookup.
+func (m *Manager) getComponentELBDNS(cl client.SourceInterface, cluster Cluster, c Component) (string, error) {
+	if m.elbDNSFromOutputs {
+		outputKey, ok := m.elbDNSOutputKeys[c.Name]
+		outputs := m.clusterOutputs(cluster)
+		if ok && outputs[outputKey] != "" {
+			return outputs[outputKey], nil
+		}
+	}
+
+	lb, err := m.getComponentELB(cl, cluster.ID, c)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return lb.DNSName, nil
+}
+
+// getComponentAliasTarget returns the ELB of the component as alias target.
+// Like getComponentELBDNS, the DNS name is read from the source stack outputs
+// first when enabled. The canonical hosted zone ID of the ELB is looked up
+// in the source account then.
+func (m *Manager) getComponentAliasTarget(cl client.SourceInterface, cluster Cluster, c Component) (*AliasTarget, error) {
+	if !m.elbDNSFromOutputs {
+		t, err := m.getAliasTarget(cl, cluster.ID, c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		return t, nil
+	}
+
+	dnsName, err := m.getComponentELBDNS(cl, cluster, c)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	hostedZoneID, err := m.elbHostedZoneID(cl, dnsName)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	if hostedZoneID == "" {
+		return nil, microerror.Maskf(elbNotFoundError, "canonical hosted zone ID of %s ELB %#q of cluster %#q is unknown", c.Name, dnsName, cluster.ID)
+	}
+
+	t := &AliasTarget{
+		HostedZoneID: hostedZoneID,
+		DNSName:      dnsName,
+	}
+
+	return t, nil
+}
+
+// getIngressAliasTarget returns the ingress ELB of the cluster as alias
+// target. The ELB is looked up for non legacy clusters too, even though they
+// get no ingress record.
+func (m *Manager) getIngressAliasTarget(cl client.SourceInterface, clusterName string) (*AliasTarget, error) {
+	ingress := Component{
+		Name:      ingressComponentName,
+		ELBSuffix: "-ingress",
+	}
+	for _, c := range m.components {
+		if c.Name == ingressComponentName {
+			ingress = c
+		}
+	}
+
+	t, err := m.getAliasTarget(cl, clusterName, ingress)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return t, nil
+}
+
+// getAliasTarget returns the ELB of the component of the cluster as alias
+// target.
+func (m *Manager) getAliasTarget(cl client.SourceInterface, clusterName string, c Component) (*AliasTarget, error) {
+	lb, err := m.getComponentELB(cl, clusterName, c)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	t := &AliasTarget{
+		HostedZoneID: lb.CanonicalHostedZoneID,
+		DNSName:      lb.DNSName,
+	}
+
+	return t, nil
+}
+
+// loadBalancer is a classic, application or network load balancer.
+type loadBalancer struct {
+	CanonicalHostedZoneID string
+	DNSName               string
+	// Name is set for classic ELBs and ARN for application and network load
+	// balancers.
+	Name string
+	ARN  string
+}
+
+// getELB looks the load balancer up by name, first as classic ELB and then
+// through the elbv2 API. elbNotFoundError is only returned when neither API
+// knows the load balancer. Any other error is a failed lookup which may
+// succeed on a later run.
+func (m *Manager) getELB(cl client.SourceInterface, elbName string) (*loadBalancer, error) {
+	lb, err := m.getClassicELB(cl, elbName)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	if lb == nil {
+		lb, err = m.getELBV2(cl, elbName)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+	if lb != nil {
+		m.elbHostedZoneIDs[lb.DNSName] = lb.CanonicalHostedZoneID
+		return lb, nil
+	}
+
+	return nil, microerror.Maskf(elbNotFoundError, "load balancer %#q", elbName)
+}
+
+// getClassicELB returns nil when there is no classic ELB with the given name.
+// The API reports a missing name with the LoadBalancerNotFound error rather
+// than an empty result, both are handled the same.
+func (m *Manager) getClassicELB(cl client.SourceInterface, elbName string) (*loadBalancer, error) {
 	input := &elb.DescribeLoadBalancersInput{
 		LoadBalancerNames: []*string{
 			aws.String(elbName),
 		},
 	}
-	output, err := m.sourceClient.DescribeLoadBalancers(input)
-	if err != nil {
-		return "", microerror.Mask(err)
+	output, err := cl.DescribeLoadBalancersWithContext(m.ctx, input)
+	if isAWSErrorCode(err, elb.ErrCodeAccessPointNotFoundException) {
+		return nil, nil
+	} else if err != nil {
+		return nil, microerror.Mask(err)
 	}
 
 	if len(output.LoadBalancerDescriptions) == 0 {
-		return "", microerror.Mask(tooFewResultsError)
+		return nil, nil
+	}
+
+	lb := &loadBalancer{
+		CanonicalHostedZoneID: aws.StringValue(output.LoadBalancerDescriptions[0].CanonicalHostedZoneNameID),
+		DNSName:               aws.StringValue(output.LoadBalancerDescriptions[0].DNSName),
+		Name:                  elbName,
 	}
 
-	return *output.LoadBalancerDescriptions[0].DNSName, nil
+	return lb, nil
 }
 
-func (m *Manager) getEniList(clusterID string, baseDomain string) ([]EtcdEni, error) {
+// getELBV2 returns nil when there is no application or network load balancer
+// with the given name, reported either with the LoadBalancerNotFound error or
+// an empty result.
+func (m *Manager) getELBV2(cl client.SourceInterface, elbName string) (*loadBalancer, error) {
+	input := &elbv2.DescribeLoadBalancersInput{
+		Names: []*string{
+			aws.String(elbName),
+		},
+	}
+	output, err := cl.DescribeLoadBalancersV2WithContext(m.ctx, input)
+	if isAWSErrorCode(err, elbv2.ErrCodeLoadBalancerNotFoundException) {
+		return nil, nil
+	} else if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	if len(output.LoadBalancers) == 0 {
+		return nil, nil
+	}
+
+	lb := &loadBalancer{
+		CanonicalHostedZoneID: aws.StringValue(output.LoadBalancers[0].CanonicalHostedZoneId),
+		DNSName:               aws.StringValue(output.LoadBalancers[0].DNSName),
+		ARN:                   aws.StringValue(output.LoadBalancers[0].LoadBalancerArn),
+	}
+
+	return lb, nil
+}
+
+func (m *Manager) getEniList(cl client.SourceInterface, clusterID string, baseDomain string) ([]EtcdEni, error) {
 	var eniList []EtcdEni
 
 	input := &ec2.DescribeNetworkInterfacesInput{
 		Filters: []*ec2.Filter{
 			{
-				Name: aws.String(fmt.Sprintf("tag:%s", key.TagCluster)),
+				Name: aws.String(fmt.Sprintf("tag:%s", m.eniClusterTag)),
 				Values: []*string{
 					aws.String(clusterID),
 				},
@@ -201,14 +683,24 @@ func (m *Manager) getEniList(clusterID string, baseDomain string) ([]EtcdEni, er
 			},
 		},
 	}
-
-	output, err := m.sourceClient.DescribeNetworkInterfaces(input)
-	if err != nil {
-		return nil, microerror.Mask(err)
+	// Clusters discovered without source stack have no tccpn stack either,
+	// so their etcd ENIs are told apart by the order tag instead.
+	if isDiscoveredStack(m.clusterSourceStacks[clusterID]) {
+		input.Filters[1] = &ec2.Filter{
+			Name:   aws.String("tag-key"),
+			Values: aws.StringSlice([]string{m.eniOrderTag}),
+		}
 	}
 
-	nicList := output.NetworkInterfaces
-	sortNetworkInterfacesByName(nicList)
+	nicList, ok := m.etcdENIs[clusterID]
+	if !ok {
+		output, err := cl.DescribeNetworkInterfacesWithContext(m.ctx, input)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		nicList = output.NetworkInterfaces
+	}
+	sortNetworkInterfacesByTag(nicList, m.eniOrderTag)
 
 	for i, nic := range nicList {
 		e := EtcdEni{
@@ -232,20 +724,28 @@ func (m *Manager) getEniList(clusterID string, baseDomain string) ([]EtcdEni, er
 	return eniList, nil
 }
 
-func sortNetworkInterfacesByName(nicList []*ec2.NetworkInterface) {
-	sort.Slice(nicList, func(i, j int) bool {
-		nameI := getNICNameFromTag(nicList[i].TagSet)
-		nameJ := getNICNameFromTag(nicList[j].TagSet)
-		return nameI < nameJ
-	})
-}
+// sortNetworkInterfacesByTag orders the network interfaces by the value of
+// the tag with the given key. Network interfaces without the tag come first,
+// in their original order. Integer values, e.g. of an etcd index tag, follow
+// and are compared numerically so `10` follows `9`. Other values come last
+// and are compared as strings.
+func sortNetworkInterfacesByTag(nicList []*ec2.NetworkInterface, key string) {
+	sort.SliceStable(nicList, func(i, j int) bool {
+		valueI := ec2TagValue(nicList[i].TagSet, key)
+		valueJ := ec2TagValue(nicList[j].TagSet, key)
+		if valueI == "" || valueJ == "" {
+			return valueI == "" && valueJ != ""
+		}
 
-func getNICNameFromTag(tags []*ec2.Tag) string {
-	for _, tag := range tags {
-		if *tag.Key == "Name" {
-			return *tag.Value
+		numI, errI := strconv.Atoi(valueI)
+		numJ, errJ := strconv.Atoi(valueJ)
+		if (errI == nil) != (errJ == nil) {
+			return errI == nil
 		}
-	}
-	// failed to find name tag
-	return ""
+		if errI == nil && numI != numJ {
+			return numI < numJ
+		}
+
+		return valueI < valueJ
+	})
 }
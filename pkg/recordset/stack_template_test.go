@@ -0,0 +1,1652 @@
+package recordset
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/giantswarm/micrologger"
+	"gopkg.in/yaml.v3"
+)
+
+func TestGetSourceStackData_Components(t *testing.T) {
+	tcs := []struct {
+		name              string
+		components        []Component
+		isLegacyCluster   bool
+		expectedResources []string
+	}{
+		{
+			name:              "case 0: default components for legacy cluster",
+			components:        nil,
+			isLegacyCluster:   true,
+			expectedResources: []string{"apiDNSRecord", "etcdDNSRecord", "ingressDNSRecord"},
+		},
+		{
+			name:              "case 1: default components for non legacy cluster",
+			components:        nil,
+			isLegacyCluster:   false,
+			expectedResources: []string{"apiDNSRecord", "etcdDNSRecord"},
+		},
+		{
+			name: "case 2: extra component for non legacy cluster",
+			components: append(append([]Component{}, DefaultComponents...), Component{
+				Name:      "konnectivity",
+				ELBSuffix: "-konnectivity",
+			}),
+			isLegacyCluster:   false,
+			expectedResources: []string{"apiDNSRecord", "etcdDNSRecord", "konnectivityDNSRecord"},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newTestManager(t, tc.components)
+
+			data, err := m.getSourceStackData(Cluster{ID: "foo", IsLegacy: tc.isLegacyCluster})
+			if err != nil {
+				t.Fatalf("getSourceStackData: %v", err)
+			}
+
+			var resources []string
+			for _, r := range data.ComponentRecords {
+				resources = append(resources, r.ResourceName)
+			}
+			if strings.Join(resources, ",") != strings.Join(tc.expectedResources, ",") {
+				t.Errorf("expected resources %v, got %v", tc.expectedResources, resources)
+			}
+		})
+	}
+}
+
+func TestGetELB(t *testing.T) {
+	tcs := []struct {
+		name                 string
+		noLoadBalancers      bool
+		emptyLoadBalancers   bool
+		loadBalancersV2      []*elbv2.LoadBalancer
+		emptyLoadBalancersV2 bool
+		loadBalancersError   error
+		expected             *loadBalancer
+		expectedError        bool
+		expectedNotFound     bool
+	}{
+		{
+			name: "case 0: classic ELB only",
+			expected: &loadBalancer{
+				CanonicalHostedZoneID: "elbZoneID",
+				DNSName:               "elb.dns.test",
+				Name:                  "foo-api",
+			},
+		},
+		{
+			name:            "case 1: elbv2 load balancer only",
+			noLoadBalancers: true,
+			loadBalancersV2: []*elbv2.LoadBalancer{
+				{
+					CanonicalHostedZoneId: aws.String("nlbZoneID"),
+					DNSName:               aws.String("nlb.dns.test"),
+					Type:                  aws.String(elbv2.LoadBalancerTypeEnumNetwork),
+				},
+			},
+			expected: &loadBalancer{
+				CanonicalHostedZoneID: "nlbZoneID",
+				DNSName:               "nlb.dns.test",
+			},
+		},
+		{
+			name:             "case 2: neither found",
+			noLoadBalancers:  true,
+			expectedError:    true,
+			expectedNotFound: true,
+		},
+		{
+			name:               "case 3: failed classic ELB lookup",
+			loadBalancersError: awserr.New("Throttling", "Rate exceeded", nil),
+			expectedError:      true,
+		},
+		{
+			name:               "case 4: classic ELB API without results",
+			emptyLoadBalancers: true,
+			loadBalancersV2: []*elbv2.LoadBalancer{
+				{
+					CanonicalHostedZoneId: aws.String("nlbZoneID"),
+					DNSName:               aws.String("nlb.dns.test"),
+				},
+			},
+			expected: &loadBalancer{
+				CanonicalHostedZoneID: "nlbZoneID",
+				DNSName:               "nlb.dns.test",
+			},
+		},
+		{
+			name:                 "case 5: neither API with results",
+			emptyLoadBalancers:   true,
+			emptyLoadBalancersV2: true,
+			expectedError:        true,
+			expectedNotFound:     true,
+		},
+		{
+			name:                 "case 6: classic ELB not found and elbv2 API without results",
+			noLoadBalancers:      true,
+			emptyLoadBalancersV2: true,
+			expectedError:        true,
+			expectedNotFound:     true,
+		},
+		{
+			name:               "case 7: classic ELB API without results and elbv2 load balancer not found",
+			emptyLoadBalancers: true,
+			expectedError:      true,
+			expectedNotFound:   true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			sourceClient := newSourceWithStacks(nil)
+			sourceClient.noLoadBalancers = tc.noLoadBalancers
+			sourceClient.emptyLoadBalancers = tc.emptyLoadBalancers
+			sourceClient.loadBalancersV2 = tc.loadBalancersV2
+			sourceClient.emptyLoadBalancersV2 = tc.emptyLoadBalancersV2
+			sourceClient.loadBalancersError = tc.loadBalancersError
+
+			c := newTestConfig(t)
+			c.SourceClient = sourceClient
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			lb, err := m.getELB(c.SourceClient, "foo-api")
+			if tc.expectedError != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tc.expectedError, err)
+			}
+			if tc.expectedNotFound != IsELBNotFound(err) {
+				t.Errorf("expected not found %v, got %v", tc.expectedNotFound, err)
+			}
+
+			if !reflect.DeepEqual(tc.expected, lb) {
+				t.Errorf("expected %#v, got %#v", tc.expected, lb)
+			}
+		})
+	}
+}
+
+func TestGetEniList_ClusterTag(t *testing.T) {
+	tcs := []struct {
+		name          string
+		eniClusterTag string
+		expected      string
+	}{
+		{
+			name:     "case 0: default tag",
+			expected: "tag:giantswarm.io/cluster",
+		},
+		{
+			name:          "case 1: configured tag",
+			eniClusterTag: "example.com/cluster-id",
+			expected:      "tag:example.com/cluster-id",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			sourceClient := newSourceWithStacks(nil)
+
+			c := newTestConfig(t)
+			c.SourceClient = sourceClient
+			c.ENIClusterTag = tc.eniClusterTag
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			_, err = m.getEniList(sourceClient, "foo", "foo.zoneName")
+			if err != nil {
+				t.Fatalf("getEniList: %v", err)
+			}
+
+			if len(sourceClient.networkInterfacesInputs) != 1 {
+				t.Fatalf("expected 1 DescribeNetworkInterfaces call, got %d", len(sourceClient.networkInterfacesInputs))
+			}
+			filter := sourceClient.networkInterfacesInputs[0].Filters[0]
+			if aws.StringValue(filter.Name) != tc.expected {
+				t.Errorf("expected filter %#q, got %#q", tc.expected, aws.StringValue(filter.Name))
+			}
+			if len(filter.Values) != 1 || aws.StringValue(filter.Values[0]) != "foo" {
+				t.Errorf("expected filter values [foo], got %v", aws.StringValueSlice(filter.Values))
+			}
+		})
+	}
+}
+
+func TestGetEniList_OrderTag(t *testing.T) {
+	nic := func(ip string, tags map[string]string) *ec2.NetworkInterface {
+		n := &ec2.NetworkInterface{
+			PrivateIpAddress: aws.String(ip),
+		}
+		for k, v := range tags {
+			n.TagSet = append(n.TagSet, &ec2.Tag{Key: aws.String(k), Value: aws.String(v)})
+		}
+		return n
+	}
+
+	tcs := []struct {
+		name        string
+		eniOrderTag string
+		expected    []string
+	}{
+		{
+			name:     "case 0: ordered by Name by default",
+			expected: []string{"10.1.0.3", "10.1.0.1", "10.1.0.2"},
+		},
+		{
+			name:        "case 1: ordered by custom tag",
+			eniOrderTag: "giantswarm.io/etcd-index",
+			expected:    []string{"10.1.0.1", "10.1.0.2", "10.1.0.3"},
+		},
+		{
+			name:        "case 2: interfaces without the tag keep their order",
+			eniOrderTag: "example.com/missing",
+			expected:    []string{"10.1.0.1", "10.1.0.2", "10.1.0.3"},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			sourceClient := newSourceWithStacks(nil)
+			sourceClient.networkInterfaces = []*ec2.NetworkInterface{
+				nic("10.1.0.1", map[string]string{"Name": "master-c", "giantswarm.io/etcd-index": "1"}),
+				nic("10.1.0.2", map[string]string{"Name": "master-d", "giantswarm.io/etcd-index": "2"}),
+				nic("10.1.0.3", map[string]string{"Name": "master-a", "giantswarm.io/etcd-index": "10"}),
+			}
+
+			c := newTestConfig(t)
+			c.SourceClient = sourceClient
+			c.ENIOrderTag = tc.eniOrderTag
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			eniList, err := m.getEniList(sourceClient, "foo", "foo.zoneName")
+			if err != nil {
+				t.Fatalf("getEniList: %v", err)
+			}
+
+			// The last entry is the etcd0 record of the first interface.
+			var ips []string
+			for _, e := range eniList[:len(eniList)-1] {
+				ips = append(ips, e.IPAddress)
+			}
+			if !reflect.DeepEqual(tc.expected, ips) {
+				t.Errorf("expected etcd1 to etcd3 IPs %v, got %v", tc.expected, ips)
+			}
+		})
+	}
+}
+
+func TestSortNetworkInterfacesByTag(t *testing.T) {
+	tcs := []struct {
+		name          string
+		values        []string
+		expectedOrder []string
+	}{
+		{
+			name:          "case 0: numeric values",
+			values:        []string{"10", "9", "1"},
+			expectedOrder: []string{"1", "9", "10"},
+		},
+		{
+			name:          "case 1: untagged network interfaces first, in their original order",
+			values:        []string{"2", "", "1", ""},
+			expectedOrder: []string{"", "", "1", "2"},
+		},
+		{
+			name:          "case 2: numeric values before other values",
+			values:        []string{"b", "10", "a", "9", "", "10a"},
+			expectedOrder: []string{"", "9", "10", "10a", "a", "b"},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			var nicList []*ec2.NetworkInterface
+			for _, v := range tc.values {
+				nic := &ec2.NetworkInterface{}
+				if v != "" {
+					nic.TagSet = []*ec2.Tag{{Key: aws.String("giantswarm.io/etcd-index"), Value: aws.String(v)}}
+				}
+				nicList = append(nicList, nic)
+			}
+
+			sortNetworkInterfacesByTag(nicList, "giantswarm.io/etcd-index")
+
+			var order []string
+			for _, nic := range nicList {
+				order = append(order, ec2TagValue(nic.TagSet, "giantswarm.io/etcd-index"))
+			}
+			if !reflect.DeepEqual(tc.expectedOrder, order) {
+				t.Errorf("expected order %v, got %v", tc.expectedOrder, order)
+			}
+		})
+	}
+}
+
+func TestNewStackTemplate_ExtraComponent(t *testing.T) {
+	components := append(append([]Component{}, DefaultComponents...), Component{
+		Name:      "konnectivity",
+		ELBSuffix: "-konnectivity",
+	})
+	m := newTestManager(t, components)
+
+	records, err := m.getRecords(Cluster{ID: "foo"})
+	if err != nil {
+		t.Fatalf("getRecords: %v", err)
+	}
+
+	template := newStackTemplate(m.targetHostedZoneID, records)
+
+	expected := map[string]string{
+		"apiDNSRecord":          "api.foo.zoneName",
+		"etcdDNSRecord":         "etcd.foo.zoneName",
+		"konnectivityDNSRecord": "konnectivity.foo.zoneName",
+	}
+	for resourceName, recordName := range expected {
+		r, ok := template.Resources[resourceName]
+		if !ok {
+			t.Errorf("expected resource %#q, got %v", resourceName, template.Resources)
+			continue
+		}
+		if r.Properties.Name != recordName {
+			t.Errorf("expected resource %#q record name %#q, got %#q", resourceName, recordName, r.Properties.Name)
+		}
+		if r.Properties.Type != "CNAME" {
+			t.Errorf("expected resource %#q record type CNAME, got %#q", resourceName, r.Properties.Type)
+		}
+	}
+	if _, ok := template.Resources["ingressDNSRecord"]; ok {
+		t.Errorf("expected no ingress record for non legacy cluster")
+	}
+}
+
+func TestGetStackTemplateBody_Formats(t *testing.T) {
+	renderTemplate := func(format string) string {
+		c := newTestConfig(t)
+		c.TemplateFormat = format
+		m, err := NewManager(c)
+		if err != nil {
+			t.Fatalf("NewManager: %v", err)
+		}
+
+		records, err := m.getRecords(Cluster{ID: "foo", IsLegacy: true})
+		if err != nil {
+			t.Fatalf("getRecords: %v", err)
+		}
+
+		body, err := m.getStackTemplateBody(records)
+		if err != nil {
+			t.Fatalf("getStackTemplateBody: %v", err)
+		}
+
+		return body
+	}
+
+	yamlBody := renderTemplate(TemplateFormatYAML)
+	jsonBody := renderTemplate(TemplateFormatJSON)
+
+	if !json.Valid([]byte(jsonBody)) {
+		t.Fatalf("expected valid JSON template, got\n%s", jsonBody)
+	}
+	if json.Valid([]byte(yamlBody)) {
+		t.Fatalf("expected YAML template, got JSON\n%s", yamlBody)
+	}
+
+	var fromYAML stackTemplate
+	err := yaml.Unmarshal([]byte(yamlBody), &fromYAML)
+	if err != nil {
+		t.Fatalf("yaml.Unmarshal: %v", err)
+	}
+	var fromJSON stackTemplate
+	err = json.Unmarshal([]byte(jsonBody), &fromJSON)
+	if err != nil {
+		t.Fatalf("json.Unmarshal: %v", err)
+	}
+
+	if len(fromJSON.Resources) == 0 {
+		t.Fatalf("expected resources, got none")
+	}
+	if !reflect.DeepEqual(fromYAML, fromJSON) {
+		t.Errorf("expected equivalent templates, got YAML\n%s\nand JSON\n%s", yamlBody, jsonBody)
+	}
+}
+
+func TestNewManager_InvalidTemplateFormat(t *testing.T) {
+	c := newTestConfig(t)
+	c.TemplateFormat = "toml"
+
+	_, err := NewManager(c)
+	if !IsInvalidConfig(err) {
+		t.Errorf("expected invalid config error, got %v", err)
+	}
+}
+
+func TestGetStackInput_VersionTag(t *testing.T) {
+	c := newTestConfig(t)
+	c.Version = "abc123"
+	m, err := NewManager(c)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	records, err := m.getRecords(Cluster{ID: "foo"})
+	if err != nil {
+		t.Fatalf("getRecords: %v", err)
+	}
+
+	sourceStack := cloudformation.Stack{
+		StackName: aws.String("cluster-foo-tccp"),
+		Tags: []*cloudformation.Tag{
+			{
+				Key:   aws.String(installationTag),
+				Value: aws.String("installation"),
+			},
+			{
+				Key:   aws.String(versionTag),
+				Value: aws.String("old"),
+			},
+		},
+	}
+
+	createInput, err := m.getCreateStackInput("cluster-foo-guest-recordsets", records, sourceStack)
+	if err != nil {
+		t.Fatalf("getCreateStackInput: %v", err)
+	}
+	updateInput, err := m.getUpdateStackInput("cluster-foo-guest-recordsets", records, sourceStack)
+	if err != nil {
+		t.Fatalf("getUpdateStackInput: %v", err)
+	}
+
+	expectedUpdate := map[string]string{
+		installationTag: "installation",
+		versionTag:      "abc123",
+	}
+	// Created target stacks are owned by route53-manager.
+	expectedCreate := map[string]string{
+		installationTag: "installation",
+		versionTag:      "abc123",
+		managedByTag:    managedByValue,
+	}
+	for name, tags := range map[string][]*cloudformation.Tag{"create": createInput.Tags, "update": updateInput.Tags} {
+		expected := expectedUpdate
+		if name == "create" {
+			expected = expectedCreate
+		}
+
+		got := map[string]string{}
+		for _, tag := range tags {
+			got[*tag.Key] = *tag.Value
+		}
+		if len(tags) != len(expected) || !reflect.DeepEqual(got, expected) {
+			t.Errorf("expected %s input tags %v, got %v", name, expected, got)
+		}
+	}
+}
+
+func TestGetStackTemplateBody_TemplateVersion(t *testing.T) {
+	tcs := []struct {
+		name            string
+		templateFormat  string
+		templateVersion string
+		expectedTag     bool
+	}{
+		{
+			name:           "case 0: no template version",
+			templateFormat: TemplateFormatYAML,
+		},
+		{
+			name:            "case 1: YAML template",
+			templateFormat:  TemplateFormatYAML,
+			templateVersion: "2",
+			expectedTag:     true,
+		},
+		{
+			name:            "case 2: JSON template",
+			templateFormat:  TemplateFormatJSON,
+			templateVersion: "2",
+			expectedTag:     true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newTestConfig(t)
+			c.TemplateFormat = tc.templateFormat
+			c.TemplateVersion = tc.templateVersion
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			records, err := m.getRecords(Cluster{ID: "foo"})
+			if err != nil {
+				t.Fatalf("getRecords: %v", err)
+			}
+			sourceStack := cloudformation.Stack{
+				StackName: aws.String("cluster-foo-tccp"),
+				Tags: []*cloudformation.Tag{
+					{
+						Key:   aws.String(templateVersionTag),
+						Value: aws.String("1"),
+					},
+				},
+			}
+			input, err := m.getUpdateStackInput("cluster-foo-guest-recordsets", records, sourceStack)
+			if err != nil {
+				t.Fatalf("getUpdateStackInput: %v", err)
+			}
+
+			body, err := parseStackTemplate(aws.StringValue(input.TemplateBody))
+			if err != nil {
+				t.Fatalf("parseStackTemplate: %v", err)
+			}
+			var templateVersion string
+			if body.Metadata != nil {
+				templateVersion = body.Metadata.TemplateVersion
+			}
+			if templateVersion != tc.templateVersion {
+				t.Errorf("expected template version %#q, got %#q in\n%s", tc.templateVersion, templateVersion, aws.StringValue(input.TemplateBody))
+			}
+
+			var tags []string
+			for _, tag := range input.Tags {
+				if *tag.Key == templateVersionTag {
+					tags = append(tags, *tag.Value)
+				}
+			}
+			var expectedTags []string
+			if tc.expectedTag {
+				expectedTags = []string{tc.templateVersion}
+			}
+			if !reflect.DeepEqual(tags, expectedTags) {
+				t.Errorf("expected template version tags %v, got %v", expectedTags, tags)
+			}
+		})
+	}
+}
+
+func TestGetStackInput_StackOptions(t *testing.T) {
+	policy := `{"Statement":[{"Effect":"Deny","Action":"Update:Replace","Principal":"*","Resource":"*"}]}`
+
+	tcs := []struct {
+		name                     string
+		stackPolicy              string
+		notificationARNs         []string
+		expectedStackPolicy      *string
+		expectedNotificationARNs []*string
+	}{
+		{
+			name:                     "case 0: no stack options",
+			expectedStackPolicy:      nil,
+			expectedNotificationARNs: nil,
+		},
+		{
+			name:                "case 1: stack policy",
+			stackPolicy:         policy,
+			expectedStackPolicy: aws.String(policy),
+		},
+		{
+			name: "case 2: notification ARNs",
+			notificationARNs: []string{
+				"arn:aws:sns:eu-central-1:123456789012:foo",
+				"arn:aws:sns:eu-central-1:123456789012:bar",
+			},
+			expectedNotificationARNs: aws.StringSlice([]string{
+				"arn:aws:sns:eu-central-1:123456789012:foo",
+				"arn:aws:sns:eu-central-1:123456789012:bar",
+			}),
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newTestConfig(t)
+			c.StackPolicy = tc.stackPolicy
+			c.NotificationARNs = tc.notificationARNs
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			records, err := m.getRecords(Cluster{ID: "foo"})
+			if err != nil {
+				t.Fatalf("getRecords: %v", err)
+			}
+
+			sourceStack := cloudformation.Stack{
+				StackName: aws.String("cluster-foo-tccp"),
+			}
+
+			createInput, err := m.getCreateStackInput("cluster-foo-guest-recordsets", records, sourceStack)
+			if err != nil {
+				t.Fatalf("getCreateStackInput: %v", err)
+			}
+			updateInput, err := m.getUpdateStackInput("cluster-foo-guest-recordsets", records, sourceStack)
+			if err != nil {
+				t.Fatalf("getUpdateStackInput: %v", err)
+			}
+
+			if !reflect.DeepEqual(tc.expectedStackPolicy, createInput.StackPolicyBody) {
+				t.Errorf("expected create input stack policy %v, got %v", aws.StringValue(tc.expectedStackPolicy), aws.StringValue(createInput.StackPolicyBody))
+			}
+			if !reflect.DeepEqual(tc.expectedStackPolicy, updateInput.StackPolicyBody) {
+				t.Errorf("expected update input stack policy %v, got %v", aws.StringValue(tc.expectedStackPolicy), aws.StringValue(updateInput.StackPolicyBody))
+			}
+			if !reflect.DeepEqual(tc.expectedNotificationARNs, createInput.NotificationARNs) {
+				t.Errorf("expected create input notification ARNs %v, got %v", aws.StringValueSlice(tc.expectedNotificationARNs), aws.StringValueSlice(createInput.NotificationARNs))
+			}
+			if !reflect.DeepEqual(tc.expectedNotificationARNs, updateInput.NotificationARNs) {
+				t.Errorf("expected update input notification ARNs %v, got %v", aws.StringValueSlice(tc.expectedNotificationARNs), aws.StringValueSlice(updateInput.NotificationARNs))
+			}
+		})
+	}
+}
+
+func TestNewManager_InvalidStackOptions(t *testing.T) {
+	tcs := []struct {
+		name             string
+		stackPolicy      string
+		notificationARNs []string
+	}{
+		{
+			name:        "case 0: invalid stack policy",
+			stackPolicy: `{"Statement":`,
+		},
+		{
+			name:             "case 1: invalid notification ARN",
+			notificationARNs: []string{"arn:aws:sns:eu-central-1:123456789012:foo", "foo"},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newTestConfig(t)
+			c.StackPolicy = tc.stackPolicy
+			c.NotificationARNs = tc.notificationARNs
+
+			_, err := NewManager(c)
+			if !IsInvalidConfig(err) {
+				t.Errorf("expected invalid config error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestSync_TagOnlyUpdates(t *testing.T) {
+	newTags := func(organization string) []*cloudformation.Tag {
+		return []*cloudformation.Tag{
+			&cloudformation.Tag{
+				Key:   aws.String(installationTag),
+				Value: aws.String("installation"),
+			},
+			&cloudformation.Tag{
+				Key:   aws.String("giantswarm.io/organization"),
+				Value: aws.String(organization),
+			},
+		}
+	}
+
+	tcs := []struct {
+		name               string
+		tagOnlyUpdates     bool
+		targetOrganization string
+		templateChanged    bool
+		templateFQDN       bool
+		expectedTagOnly    bool
+		expectedTemplate   bool
+	}{
+		{
+			name:               "case 0: only tags differ",
+			tagOnlyUpdates:     true,
+			targetOrganization: "old",
+			expectedTagOnly:    true,
+			expectedTemplate:   true,
+		},
+		{
+			name:               "case 1: tags and template differ",
+			tagOnlyUpdates:     true,
+			targetOrganization: "old",
+			templateChanged:    true,
+			expectedTagOnly:    false,
+			expectedTemplate:   true,
+		},
+		{
+			name:               "case 2: tags equal",
+			tagOnlyUpdates:     true,
+			targetOrganization: "acme",
+			expectedTagOnly:    false,
+		},
+		{
+			name:               "case 3: disabled",
+			tagOnlyUpdates:     false,
+			targetOrganization: "old",
+			expectedTagOnly:    false,
+		},
+		{
+			name:               "case 4: only tags differ with fully qualified CNAME values",
+			tagOnlyUpdates:     true,
+			targetOrganization: "old",
+			templateFQDN:       true,
+			expectedTagOnly:    true,
+			expectedTemplate:   true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			sourceClient := newSourceWithStacks([]cloudformation.Stack{
+				{
+					StackName:   aws.String("cluster-foo-tccp"),
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+					Tags:        newTags("acme"),
+				},
+			})
+			targetClient := newTargetWithStacks([]cloudformation.Stack{
+				{
+					StackName:   aws.String("cluster-foo-guest-recordsets"),
+					StackStatus: aws.String(cloudformation.StackStatusUpdateComplete),
+					Tags:        newTags(tc.targetOrganization),
+				},
+			})
+
+			c := newTestConfig(t)
+			c.SourceClient = sourceClient
+			c.TargetClient = targetClient
+			c.TagOnlyUpdates = tc.tagOnlyUpdates
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			records, err := m.getRecords(Cluster{ID: "foo"})
+			if err != nil {
+				t.Fatalf("getRecords: %v", err)
+			}
+			if tc.templateChanged {
+				records = records[:1]
+			}
+			templateBody, err := m.getStackTemplateBody(records)
+			if err != nil {
+				t.Fatalf("getStackTemplateBody: %v", err)
+			}
+			if tc.templateFQDN {
+				templateBody = strings.Replace(templateBody, "elb.dns.test", "ELB.dns.test.", -1)
+			}
+			targetClient.templates = map[string]string{
+				"cluster-foo-guest-recordsets": templateBody,
+			}
+
+			err = m.Sync()
+			if err != nil {
+				t.Fatalf("m.Sync: %v", err)
+			}
+
+			if len(targetClient.updateStackInputs) != 1 {
+				t.Fatalf("expected 1 update, got %d", len(targetClient.updateStackInputs))
+			}
+			input := targetClient.updateStackInputs[0]
+			tagOnly := aws.BoolValue(input.UsePreviousTemplate)
+			if tagOnly != tc.expectedTagOnly {
+				t.Errorf("expected UsePreviousTemplate %t, got %t", tc.expectedTagOnly, tagOnly)
+			}
+			if tagOnly && input.TemplateBody != nil {
+				t.Errorf("expected no template body with UsePreviousTemplate, got %q", *input.TemplateBody)
+			}
+			if !tagOnly && input.TemplateBody == nil {
+				t.Errorf("expected template body")
+			}
+			if !reflect.DeepEqual(input.Tags, newTags("acme")) {
+				t.Errorf("expected tags %v, got %v", newTags("acme"), input.Tags)
+			}
+			if stringInSlice("GetTemplate", targetClient.calls) != tc.expectedTemplate {
+				t.Errorf("expected template to be fetched %t, got calls %v", tc.expectedTemplate, targetClient.calls)
+			}
+		})
+	}
+}
+
+func TestNewStackTemplate_EtcdHostedZone(t *testing.T) {
+	c := newTestConfig(t)
+	c.EtcdHostedZoneID = "etcdZoneID"
+	c.EtcdHostedZoneName = "etcdZoneName"
+	m, err := NewManager(c)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	records, err := m.getRecords(Cluster{ID: "foo", IsLegacy: true})
+	if err != nil {
+		t.Fatalf("getRecords: %v", err)
+	}
+
+	template := newStackTemplate(m.targetHostedZoneID, records)
+
+	expected := map[string]struct {
+		hostedZoneID string
+		name         string
+	}{
+		"ingressWildcardDNSRecord": {hostedZoneID: "zoneID", name: "*.foo.zoneName"},
+		"apiDNSRecord":             {hostedZoneID: "zoneID", name: "api.foo.zoneName"},
+		"ingressDNSRecord":         {hostedZoneID: "zoneID", name: "ingress.foo.zoneName"},
+		"etcdDNSRecord":            {hostedZoneID: "etcdZoneID", name: "etcd.foo.etcdZoneName"},
+		"EtcdEniDNSRecordSet1":     {hostedZoneID: "etcdZoneID", name: "etcd1.foo.etcdZoneName"},
+		"EtcdEniDNSRecordSet0":     {hostedZoneID: "etcdZoneID", name: "etcd0.foo.etcdZoneName"},
+	}
+	if len(template.Resources) != len(expected) {
+		t.Errorf("expected %d resources, got %v", len(expected), template.Resources)
+	}
+	for resourceName, e := range expected {
+		r, ok := template.Resources[resourceName]
+		if !ok {
+			t.Errorf("expected resource %#q, got %v", resourceName, template.Resources)
+			continue
+		}
+		if r.Properties.HostedZoneID != e.hostedZoneID {
+			t.Errorf("expected resource %#q hosted zone %#q, got %#q", resourceName, e.hostedZoneID, r.Properties.HostedZoneID)
+		}
+		if r.Properties.Name != e.name {
+			t.Errorf("expected resource %#q record name %#q, got %#q", resourceName, e.name, r.Properties.Name)
+		}
+	}
+}
+
+func TestGetStackTemplateBody_AliasWildcard(t *testing.T) {
+	c := newTestConfig(t)
+	c.AliasWildcard = true
+	c.TemplateFormat = TemplateFormatJSON
+	m, err := NewManager(c)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	records, err := m.getRecords(Cluster{ID: "foo"})
+	if err != nil {
+		t.Fatalf("getRecords: %v", err)
+	}
+
+	body, err := m.getStackTemplateBody(records)
+	if err != nil {
+		t.Fatalf("getStackTemplateBody: %v", err)
+	}
+
+	var template struct {
+		Resources map[string]struct {
+			Properties map[string]interface{}
+		}
+	}
+	err = json.Unmarshal([]byte(body), &template)
+	if err != nil {
+		t.Fatalf("json.Unmarshal: %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"HostedZoneId": "zoneID",
+		"Name":         "*.foo.zoneName",
+		"Type":         "A",
+		"AliasTarget": map[string]interface{}{
+			"DNSName":      "elb.dns.test",
+			"HostedZoneId": "elbZoneID",
+		},
+	}
+	properties := template.Resources["ingressWildcardDNSRecord"].Properties
+	if !reflect.DeepEqual(properties, expected) {
+		t.Errorf("expected wildcard record properties %v, got %v", expected, properties)
+	}
+	if _, ok := template.Resources["ingressDNSRecord"]; ok {
+		t.Errorf("expected no ingress record for non legacy cluster")
+	}
+}
+
+func TestGetStackTemplateBody_NonLegacyIngress(t *testing.T) {
+	tcs := []struct {
+		name             string
+		nonLegacyIngress bool
+		elbDNSOutputs    bool
+		cluster          Cluster
+		outputs          []*cloudformation.Output
+		expectedIngress  string
+	}{
+		{
+			name:            "case 0: no ingress record for non legacy cluster by default",
+			cluster:         Cluster{ID: "foo"},
+			expectedIngress: "",
+		},
+		{
+			name:             "case 1: ingress record for non legacy cluster from ELB name",
+			nonLegacyIngress: true,
+			cluster:          Cluster{ID: "foo"},
+			expectedIngress:  "elb.dns.test",
+		},
+		{
+			name:             "case 2: ingress record for non legacy cluster from source stack output",
+			nonLegacyIngress: true,
+			elbDNSOutputs:    true,
+			cluster:          Cluster{ID: "foo"},
+			outputs: []*cloudformation.Output{
+				{OutputKey: aws.String("IngressELBDNSName"), OutputValue: aws.String("ingress.elb.output.test")},
+			},
+			expectedIngress: "ingress.elb.output.test",
+		},
+		{
+			name:            "case 3: ingress record for legacy cluster",
+			cluster:         Cluster{ID: "foo", IsLegacy: true},
+			expectedIngress: "elb.dns.test",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newTestConfig(t)
+			c.NonLegacyIngress = tc.nonLegacyIngress
+			c.ELBDNSFromOutputs = tc.elbDNSOutputs
+			c.TemplateFormat = TemplateFormatJSON
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			m.clusterSourceStacks["foo"] = cloudformation.Stack{Outputs: tc.outputs}
+
+			records, err := m.getRecords(tc.cluster)
+			if err != nil {
+				t.Fatalf("getRecords: %v", err)
+			}
+
+			body, err := m.getStackTemplateBody(records)
+			if err != nil {
+				t.Fatalf("getStackTemplateBody: %v", err)
+			}
+
+			var template stackTemplate
+			err = json.Unmarshal([]byte(body), &template)
+			if err != nil {
+				t.Fatalf("json.Unmarshal: %v", err)
+			}
+
+			r, ok := template.Resources["ingressDNSRecord"]
+			if tc.expectedIngress == "" {
+				if ok {
+					t.Fatalf("expected no ingress record, got %v", r)
+				}
+				return
+			}
+			if !ok {
+				t.Fatalf("expected ingress record, got %v", template.Resources)
+			}
+			if r.Properties.Name != "ingress.foo.zoneName" {
+				t.Errorf("expected ingress record name %#q, got %#q", "ingress.foo.zoneName", r.Properties.Name)
+			}
+			if !reflect.DeepEqual(r.Properties.ResourceRecords, []string{tc.expectedIngress}) {
+				t.Errorf("expected ingress record values %v, got %v", []string{tc.expectedIngress}, r.Properties.ResourceRecords)
+			}
+		})
+	}
+}
+
+func TestGetStackTemplateBody_CAA(t *testing.T) {
+	tcs := []struct {
+		name     string
+		caaValue string
+		expected map[string]interface{}
+	}{
+		{
+			name:     "case 0: no CAA record",
+			caaValue: "",
+			expected: nil,
+		},
+		{
+			name:     "case 1: CAA record",
+			caaValue: `0 issue "letsencrypt.org"`,
+			expected: map[string]interface{}{
+				"HostedZoneId":    "zoneID",
+				"Name":            "foo.zoneName",
+				"Type":            "CAA",
+				"TTL":             recordSetTTL,
+				"ResourceRecords": []interface{}{`0 issue "letsencrypt.org"`},
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newTestConfig(t)
+			c.CAAValue = tc.caaValue
+			c.TemplateFormat = TemplateFormatJSON
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			records, err := m.getRecords(Cluster{ID: "foo"})
+			if err != nil {
+				t.Fatalf("getRecords: %v", err)
+			}
+
+			body, err := m.getStackTemplateBody(records)
+			if err != nil {
+				t.Fatalf("getStackTemplateBody: %v", err)
+			}
+
+			var template struct {
+				Resources map[string]struct {
+					Properties map[string]interface{}
+				}
+			}
+			err = json.Unmarshal([]byte(body), &template)
+			if err != nil {
+				t.Fatalf("json.Unmarshal: %v", err)
+			}
+
+			resource, ok := template.Resources["caaDNSRecord"]
+			if tc.expected == nil {
+				if ok {
+					t.Errorf("expected no CAA record, got %v", resource.Properties)
+				}
+				return
+			}
+			if !reflect.DeepEqual(resource.Properties, tc.expected) {
+				t.Errorf("expected CAA record properties %v, got %v", tc.expected, resource.Properties)
+			}
+		})
+	}
+}
+
+func TestGetStackTemplateBody_BareDomain(t *testing.T) {
+	tcs := []struct {
+		name             string
+		bareDomainRecord bool
+		aliasWildcard    bool
+		expected         map[string]interface{}
+	}{
+		{
+			name:     "case 0: no bare domain record",
+			expected: nil,
+		},
+		{
+			name:             "case 1: bare domain CNAME record",
+			bareDomainRecord: true,
+			expected: map[string]interface{}{
+				"HostedZoneId":    "zoneID",
+				"Name":            "foo.zoneName",
+				"Type":            "CNAME",
+				"TTL":             recordSetTTL,
+				"ResourceRecords": []interface{}{"ingress.foo.zoneName"},
+			},
+		},
+		{
+			name:             "case 2: bare domain alias record",
+			bareDomainRecord: true,
+			aliasWildcard:    true,
+			expected: map[string]interface{}{
+				"HostedZoneId": "zoneID",
+				"Name":         "foo.zoneName",
+				"Type":         "A",
+				"AliasTarget": map[string]interface{}{
+					"DNSName":      "elb.dns.test",
+					"HostedZoneId": "elbZoneID",
+				},
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newTestConfig(t)
+			c.BareDomainRecord = tc.bareDomainRecord
+			c.AliasWildcard = tc.aliasWildcard
+			c.TemplateFormat = TemplateFormatJSON
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			records, err := m.getRecords(Cluster{ID: "foo"})
+			if err != nil {
+				t.Fatalf("getRecords: %v", err)
+			}
+
+			body, err := m.getStackTemplateBody(records)
+			if err != nil {
+				t.Fatalf("getStackTemplateBody: %v", err)
+			}
+
+			var template struct {
+				Resources map[string]struct {
+					Properties map[string]interface{}
+				}
+			}
+			err = json.Unmarshal([]byte(body), &template)
+			if err != nil {
+				t.Fatalf("json.Unmarshal: %v", err)
+			}
+
+			resource, ok := template.Resources["ingressBareDNSRecord"]
+			if tc.expected == nil {
+				if ok {
+					t.Errorf("expected no bare domain record, got %v", resource.Properties)
+				}
+				return
+			}
+			if !reflect.DeepEqual(resource.Properties, tc.expected) {
+				t.Errorf("expected bare domain record properties %v, got %v", tc.expected, resource.Properties)
+			}
+		})
+	}
+}
+
+func TestGetStackTemplateBody_TrailingDot(t *testing.T) {
+	tcs := []struct {
+		name     string
+		zoneName string
+	}{
+		{
+			name:     "case 0: zone name without trailing dot",
+			zoneName: "zoneName",
+		},
+		{
+			name:     "case 1: zone name with trailing dot",
+			zoneName: "zoneName.",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newTestConfig(t)
+			c.TargetHostedZoneName = tc.zoneName
+			c.TemplateFormat = TemplateFormatJSON
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			records, err := m.getRecords(Cluster{ID: "foo"})
+			if err != nil {
+				t.Fatalf("getRecords: %v", err)
+			}
+
+			body, err := m.getStackTemplateBody(records)
+			if err != nil {
+				t.Fatalf("getStackTemplateBody: %v", err)
+			}
+
+			var template struct {
+				Resources map[string]struct {
+					Properties map[string]interface{}
+				}
+			}
+			err = json.Unmarshal([]byte(body), &template)
+			if err != nil {
+				t.Fatalf("json.Unmarshal: %v", err)
+			}
+
+			expected := map[string]string{
+				"ingressWildcardDNSRecord": "*.foo.zoneName",
+				"apiDNSRecord":             "api.foo.zoneName",
+				"EtcdEniDNSRecordSet1":     "etcd1.foo.zoneName",
+			}
+			for resourceName, name := range expected {
+				got := template.Resources[resourceName].Properties["Name"]
+				if got != name {
+					t.Errorf("expected %#q with name %#q, got %#q", resourceName, name, got)
+				}
+			}
+		})
+	}
+}
+
+func TestGetStackTemplateBody_APIRecordType(t *testing.T) {
+	tcs := []struct {
+		name              string
+		apiRecordType     string
+		tags              map[string]string
+		elbDNSFromOutputs bool
+		outputs           map[string]string
+		expectedResource  string
+		expected          map[string]interface{}
+		errorMatcher      func(error) bool
+	}{
+		{
+			name:             "case 0: default CNAME",
+			expectedResource: "apiDNSRecord",
+			expected: map[string]interface{}{
+				"HostedZoneId":    "zoneID",
+				"Name":            "api.foo.zoneName",
+				"Type":            "CNAME",
+				"TTL":             recordSetTTL,
+				"ResourceRecords": []interface{}{"elb.dns.test"},
+			},
+		},
+		{
+			name:             "case 1: A from cluster tag",
+			expectedResource: "apiAliasDNSRecord",
+			tags: map[string]string{
+				APIRecordTypeTag: "A",
+			},
+			expected: map[string]interface{}{
+				"HostedZoneId": "zoneID",
+				"Name":         "api.foo.zoneName",
+				"Type":         "A",
+				"AliasTarget": map[string]interface{}{
+					"DNSName":      "elb.dns.test",
+					"HostedZoneId": "elbZoneID",
+				},
+			},
+		},
+		{
+			name:             "case 2: CNAME from cluster tag overriding A default",
+			apiRecordType:    "A",
+			expectedResource: "apiDNSRecord",
+			tags: map[string]string{
+				APIRecordTypeTag: "CNAME",
+			},
+			expected: map[string]interface{}{
+				"HostedZoneId":    "zoneID",
+				"Name":            "api.foo.zoneName",
+				"Type":            "CNAME",
+				"TTL":             recordSetTTL,
+				"ResourceRecords": []interface{}{"elb.dns.test"},
+			},
+		},
+		{
+			name:             "case 3: A default",
+			apiRecordType:    "A",
+			expectedResource: "apiAliasDNSRecord",
+			expected: map[string]interface{}{
+				"HostedZoneId": "zoneID",
+				"Name":         "api.foo.zoneName",
+				"Type":         "A",
+				"AliasTarget": map[string]interface{}{
+					"DNSName":      "elb.dns.test",
+					"HostedZoneId": "elbZoneID",
+				},
+			},
+		},
+		{
+			name: "case 4: invalid cluster tag",
+			tags: map[string]string{
+				APIRecordTypeTag: "AAAA",
+			},
+			errorMatcher: IsInvalidConfig,
+		},
+		{
+			name:              "case 5: A from source stack output",
+			apiRecordType:     "A",
+			elbDNSFromOutputs: true,
+			outputs: map[string]string{
+				"APIELBDNSName": "api.output.test",
+			},
+			expectedResource: "apiAliasDNSRecord",
+			expected: map[string]interface{}{
+				"HostedZoneId": "zoneID",
+				"Name":         "api.foo.zoneName",
+				"Type":         "A",
+				"AliasTarget": map[string]interface{}{
+					"DNSName":      "api.output.test",
+					"HostedZoneId": "outputZoneID",
+				},
+			},
+		},
+		{
+			name:              "case 6: A from source stack output of unknown ELB",
+			apiRecordType:     "A",
+			elbDNSFromOutputs: true,
+			outputs: map[string]string{
+				"APIELBDNSName": "unknown.output.test",
+			},
+			errorMatcher: IsELBNotFound,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			sourceClient := newSourceWithStacks(nil)
+			sourceClient.loadBalancers = []*elb.LoadBalancerDescription{
+				{
+					CanonicalHostedZoneNameID: aws.String("elbZoneID"),
+					DNSName:                   aws.String("elb.dns.test"),
+				},
+				{
+					CanonicalHostedZoneNameID: aws.String("outputZoneID"),
+					DNSName:                   aws.String("api.output.test"),
+				},
+			}
+
+			c := newTestConfig(t)
+			c.SourceClient = sourceClient
+			c.APIRecordType = tc.apiRecordType
+			c.ELBDNSFromOutputs = tc.elbDNSFromOutputs
+			c.TemplateFormat = TemplateFormatJSON
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			var outputs []*cloudformation.Output
+			for k, v := range tc.outputs {
+				outputs = append(outputs, &cloudformation.Output{
+					OutputKey:   aws.String(k),
+					OutputValue: aws.String(v),
+				})
+			}
+			m.clusterSourceStacks["foo"] = cloudformation.Stack{Tags: newStackTags(tc.tags), Outputs: outputs}
+
+			records, err := m.getRecords(Cluster{ID: "foo"})
+			if tc.errorMatcher != nil {
+				if !tc.errorMatcher(err) {
+					t.Fatalf("expected error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("getRecords: %v", err)
+			}
+
+			body, err := m.getStackTemplateBody(records)
+			if err != nil {
+				t.Fatalf("getStackTemplateBody: %v", err)
+			}
+
+			var template struct {
+				Resources map[string]struct {
+					Properties map[string]interface{}
+				}
+			}
+			err = json.Unmarshal([]byte(body), &template)
+			if err != nil {
+				t.Fatalf("json.Unmarshal: %v", err)
+			}
+
+			for _, name := range []string{"apiDNSRecord", "apiAliasDNSRecord"} {
+				_, ok := template.Resources[name]
+				if ok != (name == tc.expectedResource) {
+					t.Errorf("expected api record resource %#q, got resource %#q %t", tc.expectedResource, name, ok)
+				}
+			}
+			resource := template.Resources[tc.expectedResource]
+			if !reflect.DeepEqual(resource.Properties, tc.expected) {
+				t.Errorf("expected api record properties %v, got %v", tc.expected, resource.Properties)
+			}
+		})
+	}
+}
+
+func TestNewManager_InvalidAPIRecordType(t *testing.T) {
+	c := newTestConfig(t)
+	c.APIRecordType = "AAAA"
+
+	_, err := NewManager(c)
+	if !IsInvalidConfig(err) {
+		t.Errorf("expected invalid config error, got %v", err)
+	}
+}
+
+func TestNewManager_InvalidCAAValue(t *testing.T) {
+	c := newTestConfig(t)
+	c.CAAValue = "letsencrypt.org"
+
+	_, err := NewManager(c)
+	if !IsInvalidConfig(err) {
+		t.Errorf("expected invalid config error, got %v", err)
+	}
+}
+
+func TestNewManager_InvalidBareDomainRecord(t *testing.T) {
+	c := newTestConfig(t)
+	c.BareDomainRecord = true
+	c.CAAValue = `0 issue "letsencrypt.org"`
+
+	_, err := NewManager(c)
+	if !IsInvalidConfig(err) {
+		t.Errorf("expected invalid config error, got %v", err)
+	}
+}
+
+func TestGetStackTemplateBody_PTR(t *testing.T) {
+	tcs := []struct {
+		name                  string
+		emitPTR               bool
+		reverseHostedZoneName string
+		expected              map[string]map[string]interface{}
+	}{
+		{
+			name:     "case 0: no PTR records",
+			emitPTR:  false,
+			expected: map[string]map[string]interface{}{},
+		},
+		{
+			name:                  "case 1: PTR record of the etcd ENI",
+			emitPTR:               true,
+			reverseHostedZoneName: "10.in-addr.arpa",
+			expected: map[string]map[string]interface{}{
+				"EtcdEniDNSRecordSet1PTR": {
+					"HostedZoneId":    "reverseZoneID",
+					"Name":            "1.0.1.10.in-addr.arpa",
+					"Type":            "PTR",
+					"TTL":             recordSetTTL,
+					"ResourceRecords": []interface{}{"etcd1.foo.zoneName"},
+				},
+			},
+		},
+		{
+			name:                  "case 2: PTR record in fully qualified reverse zone",
+			emitPTR:               true,
+			reverseHostedZoneName: "1.10.in-addr.arpa.",
+			expected: map[string]map[string]interface{}{
+				"EtcdEniDNSRecordSet1PTR": {
+					"HostedZoneId":    "reverseZoneID",
+					"Name":            "1.0.1.10.in-addr.arpa",
+					"Type":            "PTR",
+					"TTL":             recordSetTTL,
+					"ResourceRecords": []interface{}{"etcd1.foo.zoneName"},
+				},
+			},
+		},
+		{
+			name:                  "case 3: no PTR record for IP outside of the reverse zone",
+			emitPTR:               true,
+			reverseHostedZoneName: "172.in-addr.arpa",
+			expected:              map[string]map[string]interface{}{},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newTestConfig(t)
+			c.EmitPTR = tc.emitPTR
+			if tc.emitPTR {
+				c.ReverseHostedZoneID = "reverseZoneID"
+				c.ReverseHostedZoneName = tc.reverseHostedZoneName
+			}
+			c.TemplateFormat = TemplateFormatJSON
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			records, err := m.getRecords(Cluster{ID: "foo"})
+			if err != nil {
+				t.Fatalf("getRecords: %v", err)
+			}
+
+			body, err := m.getStackTemplateBody(records)
+			if err != nil {
+				t.Fatalf("getStackTemplateBody: %v", err)
+			}
+
+			var template struct {
+				Resources map[string]struct {
+					Properties map[string]interface{}
+				}
+			}
+			err = json.Unmarshal([]byte(body), &template)
+			if err != nil {
+				t.Fatalf("json.Unmarshal: %v", err)
+			}
+
+			ptrRecords := map[string]map[string]interface{}{}
+			for name, resource := range template.Resources {
+				if resource.Properties["Type"] == "PTR" {
+					ptrRecords[name] = resource.Properties
+				}
+			}
+			if !reflect.DeepEqual(ptrRecords, tc.expected) {
+				t.Errorf("expected PTR records %v, got %v", tc.expected, ptrRecords)
+			}
+		})
+	}
+}
+
+func TestNewManager_InvalidReverseHostedZone(t *testing.T) {
+	tcs := []struct {
+		name                  string
+		reverseHostedZoneID   string
+		reverseHostedZoneName string
+	}{
+		{
+			name:                  "case 0: missing reverse hosted zone ID",
+			reverseHostedZoneName: "10.in-addr.arpa",
+		},
+		{
+			name:                  "case 1: forward zone name",
+			reverseHostedZoneID:   "reverseZoneID",
+			reverseHostedZoneName: "zoneName",
+		},
+		{
+			name:                  "case 2: IPv4 address as zone name",
+			reverseHostedZoneID:   "reverseZoneID",
+			reverseHostedZoneName: "1.0.1.10.in-addr.arpa",
+		},
+		{
+			name:                  "case 3: invalid octet",
+			reverseHostedZoneID:   "reverseZoneID",
+			reverseHostedZoneName: "256.in-addr.arpa",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newTestConfig(t)
+			c.EmitPTR = true
+			c.ReverseHostedZoneID = tc.reverseHostedZoneID
+			c.ReverseHostedZoneName = tc.reverseHostedZoneName
+
+			_, err := NewManager(c)
+			if !IsInvalidConfig(err) {
+				t.Errorf("expected invalid config error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestNewManager_InvalidEtcdHostedZone(t *testing.T) {
+	c := newTestConfig(t)
+	c.EtcdHostedZoneID = "etcdZoneID"
+
+	_, err := NewManager(c)
+	if !IsInvalidConfig(err) {
+		t.Errorf("expected invalid config error, got %v", err)
+	}
+}
+
+func TestGetManagedRecordSets_ExtraComponent(t *testing.T) {
+	components := append(append([]Component{}, DefaultComponents...), Component{
+		Name:      "oidc",
+		ELBSuffix: "-oidc",
+	})
+
+	managed := getManagedRecordSets("foo", "zoneName", components, false, false, false)
+
+	expected := []string{
+		"\\052.foo.zonename.",
+		"api.foo.zonename.",
+		"etcd.foo.zonename.",
+		"etcd1.foo.zonename.",
+		"ingress.foo.zonename.",
+		"oidc.foo.zonename.",
+	}
+	for _, e := range expected {
+		if !stringInSlice(e, managed) {
+			t.Errorf("expected %#q to be managed, got %v", e, managed)
+		}
+	}
+}
+
+func TestNewManager_InvalidComponents(t *testing.T) {
+	tcs := []struct {
+		name       string
+		components []Component
+	}{
+		{
+			name:       "case 0: name with dash",
+			components: []Component{{Name: "my-comp", ELBSuffix: "-my-comp"}},
+		},
+		{
+			name:       "case 1: empty ELB suffix",
+			components: []Component{{Name: "oidc"}},
+		},
+		{
+			name:       "case 2: duplicate name",
+			components: []Component{{Name: "api", ELBSuffix: "-api"}, {Name: "api", ELBSuffix: "-api2"}},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newTestConfig(t)
+			c.Components = tc.components
+
+			_, err := NewManager(c)
+			if !IsInvalidConfig(err) {
+				t.Errorf("expected invalid config error, got %v", err)
+			}
+		})
+	}
+}
+
+func newTestConfig(t *testing.T) *Config {
+	logger, err := micrologger.New(micrologger.Config{IOWriter: ioutil.Discard})
+	if err != nil {
+		t.Fatalf("micrologger.New: %v", err)
+	}
+
+	c := &Config{
+		Logger:               logger,
+		Installation:         "installation",
+		SourceClient:         newSourceWithStacks(nil),
+		TargetClient:         newTargetWithStacks(nil),
+		TargetHostedZoneID:   "zoneID",
+		TargetHostedZoneName: "zoneName",
+	}
+
+	return c
+}
+
+func newTestManager(t *testing.T, components []Component) *Manager {
+	c := newTestConfig(t)
+	c.Components = components
+
+	m, err := NewManager(c)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	return m
+}
@@ -0,0 +1,39 @@
+package recordset
+
+import "fmt"
+
+// syncSummary counts the target stack operations of a single Sync run.
+type syncSummary struct {
+	created   int
+	updated   int
+	unchanged int
+	deleted   int
+	failed    int
+	// skipped holds the names of the skipped stacks by skip reason.
+	skipped map[SkipReason]map[string]bool
+
+	// createdClusters, updatedClusters and deletedClusters are the IDs of
+	// the clusters counted in created, updated and deleted.
+	createdClusters []string
+	updatedClusters []string
+	deletedClusters []string
+}
+
+func (s syncSummary) String() string {
+	return fmt.Sprintf("synced target stacks: %d created, %d updated, %d unchanged, %d deleted, %d failed, %d skipped", s.created, s.updated, s.unchanged, s.deleted, s.failed, s.skippedTotal())
+}
+
+func (s *syncSummary) addCreated(clusterID string) {
+	s.created++
+	s.createdClusters = append(s.createdClusters, clusterID)
+}
+
+func (s *syncSummary) addUpdated(clusterID string) {
+	s.updated++
+	s.updatedClusters = append(s.updatedClusters, clusterID)
+}
+
+func (s *syncSummary) addDeleted(clusterID string) {
+	s.deleted++
+	s.deletedClusters = append(s.deletedClusters, clusterID)
+}
@@ -0,0 +1,144 @@
+package recordset
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+)
+
+// summaryHistory is the part of a run summary persisted in the summary
+// history file, so the next run can compare against it.
+type summaryHistory struct {
+	Created []string `json:"created"`
+	Updated []string `json:"updated"`
+	Deleted []string `json:"deleted"`
+}
+
+func (s syncSummary) history() summaryHistory {
+	return summaryHistory{
+		Created: sortedUnique(s.createdClusters),
+		Updated: sortedUnique(s.updatedClusters),
+		Deleted: sortedUnique(s.deletedClusters),
+	}
+}
+
+// diffSummaryHistory describes the clusters which newly appeared in or
+// dropped out of the created, updated and deleted clusters of cur compared
+// to prev, e.g. `updated: +[foo] -[bar]`. It returns an empty string when
+// both are the same.
+func diffSummaryHistory(prev, cur summaryHistory) string {
+	buckets := []struct {
+		name string
+		prev []string
+		cur  []string
+	}{
+		{name: "created", prev: prev.Created, cur: cur.Created},
+		{name: "updated", prev: prev.Updated, cur: cur.Updated},
+		{name: "deleted", prev: prev.Deleted, cur: cur.Deleted},
+	}
+
+	var parts []string
+	for _, b := range buckets {
+		appeared := stringsMissingFrom(b.cur, b.prev)
+		droppedOut := stringsMissingFrom(b.prev, b.cur)
+		if len(appeared) == 0 && len(droppedOut) == 0 {
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s: +%v -%v", b.name, appeared, droppedOut))
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// compareSummaryHistory logs the difference between the summary of the
+// current run and the one of the previous run read from m.summaryHistoryFile,
+// and replaces the file with the summary of the current run. Unreadable
+// history files are logged and replaced.
+func (m *Manager) compareSummaryHistory() error {
+	cur := m.summary.history()
+
+	b, err := ioutil.ReadFile(m.summaryHistoryFile)
+	if os.IsNotExist(err) {
+		m.logger.Log("level", "debug", "message", fmt.Sprintf("summary history file %#q does not exist yet, nothing to compare", m.summaryHistoryFile))
+	} else if err != nil {
+		return microerror.Mask(err)
+	} else {
+		var prev summaryHistory
+		err = json.Unmarshal(b, &prev)
+		if err != nil {
+			m.logger.Log("level", "error", "message", fmt.Sprintf("failed to parse summary history file %#q", m.summaryHistoryFile), "stack", microerror.JSON(err))
+		} else if diff := diffSummaryHistory(prev, cur); diff != "" {
+			m.logger.Log("level", "info", "message", "changes since previous run: "+diff)
+		} else {
+			m.logger.Log("level", "debug", "message", "no changes since previous run")
+		}
+	}
+
+	b, err = json.Marshal(cur)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = writeFileAtomic(m.summaryHistoryFile, b)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// writeFileAtomic replaces the file with the given content atomically, so an
+// interrupted run never leaves a truncated file behind.
+func writeFileAtomic(path string, b []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(b)
+	if err != nil {
+		tmp.Close()
+		return microerror.Mask(err)
+	}
+	err = tmp.Close()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	err = os.Rename(tmp.Name(), path)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// stringsMissingFrom returns the elements of a which are not in b.
+func stringsMissingFrom(a, b []string) []string {
+	var result []string
+	for _, s := range a {
+		if !stringInSlice(s, b) {
+			result = append(result, s)
+		}
+	}
+
+	return result
+}
+
+func sortedUnique(s []string) []string {
+	result := []string{}
+	for _, v := range s {
+		if !stringInSlice(v, result) {
+			result = append(result, v)
+		}
+	}
+	sort.Strings(result)
+
+	return result
+}
@@ -0,0 +1,122 @@
+package recordset
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/giantswarm/micrologger"
+)
+
+func TestDiffSummaryHistory(t *testing.T) {
+	tcs := []struct {
+		name         string
+		prev         summaryHistory
+		cur          summaryHistory
+		expectedDiff string
+	}{
+		{
+			name: "case 0: same summaries",
+			prev: summaryHistory{Updated: []string{"foo"}},
+			cur:  summaryHistory{Updated: []string{"foo"}},
+		},
+		{
+			name:         "case 1: cluster appeared in updated",
+			prev:         summaryHistory{Updated: []string{"foo"}},
+			cur:          summaryHistory{Updated: []string{"bar", "foo"}},
+			expectedDiff: "updated: +[bar] -[]",
+		},
+		{
+			name:         "case 2: cluster moved from created to deleted",
+			prev:         summaryHistory{Created: []string{"foo"}},
+			cur:          summaryHistory{Deleted: []string{"foo"}},
+			expectedDiff: "created: +[] -[foo], deleted: +[foo] -[]",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			diff := diffSummaryHistory(tc.prev, tc.cur)
+			if diff != tc.expectedDiff {
+				t.Errorf("expected diff %q, got %q", tc.expectedDiff, diff)
+			}
+		})
+	}
+}
+
+func TestSync_SummaryHistory(t *testing.T) {
+	tags := []*cloudformation.Tag{
+		&cloudformation.Tag{
+			Key:   aws.String(installationTag),
+			Value: aws.String("installation"),
+		},
+	}
+	newStack := func(name string) cloudformation.Stack {
+		return cloudformation.Stack{
+			StackName:   aws.String(name),
+			StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+			Tags:        tags,
+		}
+	}
+
+	dir, err := ioutil.TempDir("", "summary-history")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	historyFile := filepath.Join(dir, "history.json")
+
+	sync := func(sourceStacks, targetStacks []cloudformation.Stack) string {
+		var out bytes.Buffer
+		logger, err := micrologger.New(micrologger.Config{IOWriter: &out})
+		if err != nil {
+			t.Fatalf("micrologger.New: %v", err)
+		}
+
+		c := newTestConfig(t)
+		c.Logger = logger
+		c.SourceClient = newSourceWithStacks(sourceStacks)
+		c.TargetClient = newTargetWithStacks(targetStacks)
+		c.SummaryHistoryFile = historyFile
+		m, err := NewManager(c)
+		if err != nil {
+			t.Fatalf("NewManager: %v", err)
+		}
+
+		err = m.Sync()
+		if err != nil {
+			t.Fatalf("m.Sync: %v", err)
+		}
+
+		return out.String()
+	}
+
+	// The first run creates the target stack of foo and has nothing to
+	// compare against.
+	out := sync([]cloudformation.Stack{newStack("cluster-foo-tccp")}, nil)
+	if strings.Contains(out, "changes since previous run") {
+		t.Errorf("expected no changes logged in first run, got %s", out)
+	}
+
+	// The second run deletes the target stack of foo, as its source stack is
+	// gone.
+	out = sync(nil, []cloudformation.Stack{newStack("cluster-foo-guest-recordsets")})
+	expected := "changes since previous run: created: +[] -[foo], deleted: +[foo] -[]"
+	if !strings.Contains(out, expected) {
+		t.Errorf("expected %q logged in second run, got %s", expected, out)
+	}
+
+	b, err := ioutil.ReadFile(historyFile)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	expected = `{"created":[],"updated":[],"deleted":["foo"]}`
+	if string(b) != expected {
+		t.Errorf("expected history %s, got %s", expected, b)
+	}
+}
@@ -0,0 +1,91 @@
+package recordset
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/giantswarm/micrologger"
+)
+
+func TestSync_Quiet(t *testing.T) {
+	tcs := []struct {
+		name        string
+		quiet       bool
+		expectNoisy bool
+	}{
+		{
+			name:        "case 0: log skipped stacks",
+			quiet:       false,
+			expectNoisy: true,
+		},
+		{
+			name:        "case 1: suppress skipped stacks in quiet mode",
+			quiet:       true,
+			expectNoisy: false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			tags := []*cloudformation.Tag{
+				&cloudformation.Tag{
+					Key:   aws.String(installationTag),
+					Value: aws.String("installation"),
+				},
+			}
+			sourceStacks := []cloudformation.Stack{
+				cloudformation.Stack{
+					StackName:   aws.String("cluster-foo-tccp"),
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+					Tags:        tags,
+				},
+				cloudformation.Stack{
+					StackName:   aws.String("cluster-bar-tccp"),
+					StackStatus: aws.String(cloudformation.StackStatusUpdateInProgress),
+					Tags:        tags,
+				},
+			}
+
+			var out bytes.Buffer
+			logger, err := micrologger.New(micrologger.Config{IOWriter: &out})
+			if err != nil {
+				t.Fatalf("micrologger.New: %v", err)
+			}
+
+			c := newTestConfig(t)
+			c.Logger = logger
+			c.SourceClient = newSourceWithStacks(sourceStacks)
+			c.Quiet = tc.quiet
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			err = m.Sync()
+			if err != nil {
+				t.Fatalf("m.Sync: %v", err)
+			}
+
+			noisy := strings.Contains(out.String(), "skipped source stack `cluster-bar-tccp`")
+			if tc.expectNoisy && !noisy {
+				t.Errorf("expected skipped stack messages, got\n%s", out.String())
+			} else if !tc.expectNoisy && noisy {
+				t.Errorf("expected no skipped stack messages, got\n%s", out.String())
+			}
+
+			expected := []string{
+				"create missing target stacks",
+				"created target stack `cluster-foo-guest-recordsets`",
+				"synced target stacks: 1 created, 0 updated, 0 unchanged, 0 deleted, 0 failed",
+			}
+			for _, e := range expected {
+				if !strings.Contains(out.String(), e) {
+					t.Errorf("expected %#q to be logged, got\n%s", e, out.String())
+				}
+			}
+		})
+	}
+}
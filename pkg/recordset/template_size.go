@@ -0,0 +1,92 @@
+package recordset
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/route53-manager/pkg/client"
+)
+
+const (
+	// DefaultMaxTemplateBodySize is the maximum size in bytes of templates
+	// passed inline to CloudFormation.
+	DefaultMaxTemplateBodySize = 51200
+
+	// maxTemplateURLSize is the maximum size in bytes of templates passed to
+	// CloudFormation through an S3 URL.
+	maxTemplateURLSize = 1024 * 1024
+)
+
+// checkTemplateSize returns templateTooLargeError when the rendered template
+// of the target stack exceeds m.maxTemplateBodySize and no template bucket
+// is configured, or when it exceeds the limit of CloudFormation for
+// templates in S3 too.
+func (m *Manager) checkTemplateSize(targetStackName, templateBody string) error {
+	if len(templateBody) <= m.maxTemplateBodySize {
+		return nil
+	}
+	if m.templateBucket == "" {
+		return microerror.Maskf(templateTooLargeError, "template of target stack %#q has %d bytes, exceeding the inline limit of %d bytes, and no template bucket is configured", targetStackName, len(templateBody), m.maxTemplateBodySize)
+	}
+	if len(templateBody) > maxTemplateURLSize {
+		return microerror.Maskf(templateTooLargeError, "template of target stack %#q has %d bytes, exceeding the limit of %d bytes", targetStackName, len(templateBody), maxTemplateURLSize)
+	}
+
+	return nil
+}
+
+// getTemplateLocation returns how the rendered template of the target stack
+// is passed to CloudFormation. It is called right before the template is
+// applied, so the stack inputs keep the template body for the comparisons
+// deciding whether to apply it at all. Templates up to m.maxTemplateBodySize
+// are returned as template body. Larger templates are uploaded to
+// m.templateBucket and their S3 URL is returned instead. The key only depends
+// on the installation and the target stack, e.g.
+// `installation/cluster-foo-guest-recordsets.yaml`, so every upload replaces
+// the previous template of the stack instead of accumulating objects.
+func (m *Manager) getTemplateLocation(targetStackName, templateBody string) (body *string, url *string, err error) {
+	err = m.checkTemplateSize(targetStackName, templateBody)
+	if err != nil {
+		return nil, nil, microerror.Mask(err)
+	}
+	if len(templateBody) <= m.maxTemplateBodySize {
+		return aws.String(templateBody), nil, nil
+	}
+
+	key := fmt.Sprintf("%s/%s.%s", m.installation, targetStackName, m.templateFormat)
+	input := &s3.PutObjectInput{
+		Body:   strings.NewReader(templateBody),
+		Bucket: aws.String(m.templateBucket),
+		Key:    aws.String(key),
+	}
+	_, err = m.targetClient.PutObjectWithContext(m.ctx, input)
+	if err != nil {
+		return nil, nil, microerror.Mask(err)
+	}
+
+	m.logger.Log("level", "debug", "message", fmt.Sprintf("uploaded template of target stack %#q with %d bytes to bucket %#q", targetStackName, len(templateBody), m.templateBucket))
+
+	return nil, aws.String(fmt.Sprintf("https://%s.s3.%s.%s/%s", m.templateBucket, m.templateBucketRegion, client.DNSSuffix(m.templateBucketRegion), key)), nil
+}
+
+// locateUpdateTemplate replaces the template body of the update by its
+// location, see getTemplateLocation. Updates reusing the previous template
+// are kept.
+func (m *Manager) locateUpdateTemplate(input *cloudformation.UpdateStackInput) error {
+	if input.TemplateBody == nil {
+		return nil
+	}
+
+	var err error
+	input.TemplateBody, input.TemplateURL, err = m.getTemplateLocation(*input.StackName, *input.TemplateBody)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
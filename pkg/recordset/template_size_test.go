@@ -0,0 +1,293 @@
+package recordset
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+func TestGetCreateStackInput_TemplateSize(t *testing.T) {
+	// Every record adds roughly 150 bytes to the template, so 500 records
+	// exceed the inline template size limit of CloudFormation.
+	var largeRecords []DesiredRecord
+	for i := 0; i < 500; i++ {
+		largeRecords = append(largeRecords, DesiredRecord{
+			ResourceName: fmt.Sprintf("etcd%dDNSRecord", i),
+			Name:         fmt.Sprintf("etcd%d.foo.zoneName", i),
+			Type:         route53.RRTypeA,
+			Values:       []string{fmt.Sprintf("10.0.%d.%d", i/256, i%256)},
+		})
+	}
+
+	tcs := []struct {
+		name                 string
+		records              []DesiredRecord
+		maxTemplateBodySize  int
+		templateBucket       string
+		templateBucketRegion string
+		expectedUpload       bool
+		expectedURLPrefix    string
+		errorMatcher         func(error) bool
+	}{
+		{
+			name:    "case 0: small template is passed inline",
+			records: largeRecords[:1],
+		},
+		{
+			name:         "case 1: large template without template bucket",
+			records:      largeRecords,
+			errorMatcher: IsTemplateTooLarge,
+		},
+		{
+			name:              "case 2: large template is uploaded to template bucket",
+			records:           largeRecords,
+			templateBucket:    "bucket",
+			expectedUpload:    true,
+			expectedURLPrefix: "https://bucket.s3.eu-central-1.amazonaws.com/",
+		},
+		{
+			name:                "case 3: small template exceeding configured size is uploaded",
+			records:             largeRecords[:1],
+			maxTemplateBodySize: 100,
+			templateBucket:      "bucket",
+			expectedUpload:      true,
+			expectedURLPrefix:   "https://bucket.s3.eu-central-1.amazonaws.com/",
+		},
+		{
+			name:                 "case 4: template bucket in the China partition",
+			records:              largeRecords[:1],
+			maxTemplateBodySize:  100,
+			templateBucket:       "bucket",
+			templateBucketRegion: "cn-north-1",
+			expectedUpload:       true,
+			expectedURLPrefix:    "https://bucket.s3.cn-north-1.amazonaws.com.cn/",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			targetClient := newTargetWithStacks(nil)
+
+			c := newTestConfig(t)
+			c.TargetClient = targetClient
+			c.MaxTemplateBodySize = tc.maxTemplateBodySize
+			c.TemplateBucket = tc.templateBucket
+			c.TemplateBucketRegion = "eu-central-1"
+			if tc.templateBucketRegion != "" {
+				c.TemplateBucketRegion = tc.templateBucketRegion
+			}
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			body, err := m.getStackTemplateBody(tc.records)
+			if err != nil {
+				t.Fatalf("getStackTemplateBody: %v", err)
+			}
+
+			input, err := m.getCreateStackInput("cluster-foo-guest-recordsets", tc.records, cloudformation.Stack{})
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("expected no error, got %v", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("expected error, got none")
+			case !tc.errorMatcher(err):
+				t.Fatalf("unexpected error %v", err)
+			}
+			if err != nil {
+				return
+			}
+
+			if aws.StringValue(input.TemplateBody) != body || input.TemplateURL != nil {
+				t.Fatalf("expected stack input with template body only")
+			}
+			if len(targetClient.objects) != 0 {
+				t.Fatalf("expected no uploads before applying, got %d", len(targetClient.objects))
+			}
+
+			input.TemplateBody, input.TemplateURL, err = m.getTemplateLocation("cluster-foo-guest-recordsets", body)
+			if err != nil {
+				t.Fatalf("getTemplateLocation: %v", err)
+			}
+
+			if !tc.expectedUpload {
+				if aws.StringValue(input.TemplateBody) != body || input.TemplateURL != nil {
+					t.Errorf("expected template body only, got template URL %#q", aws.StringValue(input.TemplateURL))
+				}
+				if len(targetClient.objects) != 0 {
+					t.Errorf("expected no uploads, got %d", len(targetClient.objects))
+				}
+				return
+			}
+
+			if input.TemplateBody != nil {
+				t.Errorf("expected no template body, got %d bytes", len(*input.TemplateBody))
+			}
+			url := aws.StringValue(input.TemplateURL)
+			expectedURL := tc.expectedURLPrefix + "installation/cluster-foo-guest-recordsets.yaml"
+			if url != expectedURL {
+				t.Fatalf("expected template URL %#q, got %#q", expectedURL, url)
+			}
+			uploaded, ok := targetClient.objects["bucket/installation/cluster-foo-guest-recordsets.yaml"]
+			if !ok || uploaded != body {
+				t.Errorf("expected rendered template uploaded, got %v", targetClient.objects)
+			}
+		})
+	}
+}
+
+func TestNewManager_TemplateSize(t *testing.T) {
+	tcs := []struct {
+		name                 string
+		maxTemplateBodySize  int
+		templateBucket       string
+		templateBucketRegion string
+	}{
+		{
+			name:                "case 0: negative maximum template body size",
+			maxTemplateBodySize: -1,
+		},
+		{
+			name:                "case 1: maximum template body size exceeding the CloudFormation limit",
+			maxTemplateBodySize: DefaultMaxTemplateBodySize + 1,
+		},
+		{
+			name:           "case 2: template bucket without region",
+			templateBucket: "bucket",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newTestConfig(t)
+			c.MaxTemplateBodySize = tc.maxTemplateBodySize
+			c.TemplateBucket = tc.templateBucket
+			c.TemplateBucketRegion = tc.templateBucketRegion
+
+			_, err := NewManager(c)
+			if !IsInvalidConfig(err) {
+				t.Errorf("expected invalid config error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestSync_TemplateUpload(t *testing.T) {
+	newTags := func(organization string) []*cloudformation.Tag {
+		return []*cloudformation.Tag{
+			&cloudformation.Tag{
+				Key:   aws.String(installationTag),
+				Value: aws.String("installation"),
+			},
+			&cloudformation.Tag{
+				Key:   aws.String("giantswarm.io/organization"),
+				Value: aws.String(organization),
+			},
+		}
+	}
+
+	tcs := []struct {
+		name               string
+		targetOrganization string
+		templateChanged    bool
+		expectedUpdate     bool
+		expectedUpload     bool
+	}{
+		{
+			name:               "case 0: unchanged template is not uploaded",
+			targetOrganization: "acme",
+		},
+		{
+			name:               "case 1: tag only update does not upload the template",
+			targetOrganization: "old",
+			expectedUpdate:     true,
+		},
+		{
+			name:               "case 2: changed template is uploaded",
+			targetOrganization: "acme",
+			templateChanged:    true,
+			expectedUpdate:     true,
+			expectedUpload:     true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			sourceClient := newSourceWithStacks([]cloudformation.Stack{
+				{
+					StackName:   aws.String("cluster-foo-tccp"),
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+					Tags:        newTags("acme"),
+				},
+			})
+			targetClient := newTargetWithStacks([]cloudformation.Stack{
+				{
+					StackName:   aws.String("cluster-foo-guest-recordsets"),
+					StackStatus: aws.String(cloudformation.StackStatusUpdateComplete),
+					Tags:        newTags(tc.targetOrganization),
+				},
+			})
+
+			c := newTestConfig(t)
+			c.SourceClient = sourceClient
+			c.TargetClient = targetClient
+			c.SkipUnchangedUpdates = true
+			c.TagOnlyUpdates = true
+			c.MaxTemplateBodySize = 100
+			c.TemplateBucket = "bucket"
+			c.TemplateBucketRegion = "eu-central-1"
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			records, err := m.getRecords(Cluster{ID: "foo"})
+			if err != nil {
+				t.Fatalf("getRecords: %v", err)
+			}
+			templateBody, err := m.getStackTemplateBody(records)
+			if err != nil {
+				t.Fatalf("getStackTemplateBody: %v", err)
+			}
+			current := templateBody
+			if tc.templateChanged {
+				current, err = m.getStackTemplateBody(records[:1])
+				if err != nil {
+					t.Fatalf("getStackTemplateBody: %v", err)
+				}
+			}
+			targetClient.templates = map[string]string{
+				"cluster-foo-guest-recordsets": current,
+			}
+
+			err = m.Sync()
+			if err != nil {
+				t.Fatalf("m.Sync: %v", err)
+			}
+
+			if (len(targetClient.updateStackInputs) == 1) != tc.expectedUpdate {
+				t.Fatalf("expected update %t, got %d updates", tc.expectedUpdate, len(targetClient.updateStackInputs))
+			}
+			if !tc.expectedUpload {
+				if len(targetClient.objects) != 0 {
+					t.Errorf("expected no uploads, got %v", targetClient.objects)
+				}
+				return
+			}
+
+			uploaded := targetClient.objects["bucket/installation/cluster-foo-guest-recordsets.yaml"]
+			if len(targetClient.objects) != 1 || uploaded != templateBody {
+				t.Errorf("expected rendered template uploaded once, got %v", targetClient.objects)
+			}
+			input := targetClient.updateStackInputs[0]
+			if input.TemplateBody != nil || aws.StringValue(input.TemplateURL) != "https://bucket.s3.eu-central-1.amazonaws.com/installation/cluster-foo-guest-recordsets.yaml" {
+				t.Errorf("expected template URL only, got %v", input)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package recordset
+
+import (
+	"time"
+
+	"github.com/giantswarm/microerror"
+)
+
+// checkDeadline returns syncTimeoutError once the deadline of the current
+// sync run, see Config.SyncTimeout, has passed. It is called between
+// operations, so a run is aborted before its next AWS call. The deadline is
+// also compared against m.now, so it does not depend on the timer of the
+// context firing.
+func (m *Manager) checkDeadline() error {
+	if m.ctx.Err() != nil {
+		return microerror.Maskf(syncTimeoutError, "sync run exceeded %s", m.syncTimeout)
+	}
+	if !m.deadline.IsZero() && !m.now().Before(m.deadline) {
+		return microerror.Maskf(syncTimeoutError, "sync run exceeded %s", m.syncTimeout)
+	}
+
+	return nil
+}
+
+// sleepBeforeDeadline sleeps for d unless the current sync run would pass
+// its deadline in the meantime, in which case syncTimeoutError is returned
+// right away instead of sleeping past the deadline.
+func (m *Manager) sleepBeforeDeadline(d time.Duration) error {
+	if !m.deadline.IsZero() && m.now().Add(d).After(m.deadline) {
+		return microerror.Maskf(syncTimeoutError, "sync run would exceed %s while waiting %s", m.syncTimeout, d)
+	}
+
+	m.sleep(d)
+
+	return m.checkDeadline()
+}
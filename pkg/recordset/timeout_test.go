@@ -0,0 +1,105 @@
+package recordset
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+)
+
+func TestSync_SyncTimeout(t *testing.T) {
+	tags := []*cloudformation.Tag{
+		&cloudformation.Tag{
+			Key:   aws.String(installationTag),
+			Value: aws.String("installation"),
+		},
+	}
+	newStack := func(name string) cloudformation.Stack {
+		return cloudformation.Stack{
+			StackName:   aws.String(name),
+			StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+			Tags:        tags,
+		}
+	}
+
+	tcs := []struct {
+		name string
+		// advance is the duration the clock advances during each CreateStack.
+		advance          time.Duration
+		blockCreateStack bool
+		syncTimeout      time.Duration
+		expectedTimeout  bool
+		expectedCreated  []string
+	}{
+		{
+			name:            "case 0: deadline passes between operations",
+			advance:         time.Hour,
+			syncTimeout:     time.Minute,
+			expectedTimeout: true,
+			expectedCreated: []string{"cluster-foo-guest-recordsets"},
+		},
+		{
+			name:             "case 1: call in flight is cancelled at the deadline",
+			blockCreateStack: true,
+			syncTimeout:      time.Millisecond,
+			expectedTimeout:  true,
+		},
+		{
+			name:            "case 2: deadline not exceeded",
+			advance:         time.Second,
+			syncTimeout:     time.Minute,
+			expectedCreated: []string{"cluster-foo-guest-recordsets", "cluster-bar-guest-recordsets"},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+			sourceClient := newSourceWithStacks([]cloudformation.Stack{
+				newStack("cluster-foo-tccp"),
+				newStack("cluster-bar-tccp"),
+			})
+			targetClient := newTargetWithStacks(nil)
+			targetClient.onCreateStack = func() {
+				now = now.Add(tc.advance)
+			}
+			targetClient.blockCreateStack = tc.blockCreateStack
+
+			c := newTestConfig(t)
+			c.SourceClient = sourceClient
+			c.TargetClient = targetClient
+			c.SyncTimeout = tc.syncTimeout
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+			m.now = func() time.Time { return now }
+
+			err = m.Sync()
+			if tc.expectedTimeout {
+				if !IsSyncTimeout(err) {
+					t.Fatalf("expected sync timeout error, got %v", err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(tc.expectedCreated, targetClient.createdStacks) {
+				t.Errorf("expected created stacks %v, got %v", tc.expectedCreated, targetClient.createdStacks)
+			}
+		})
+	}
+}
+
+func TestNewManager_InvalidSyncTimeout(t *testing.T) {
+	c := newTestConfig(t)
+	c.SyncTimeout = -time.Second
+
+	_, err := NewManager(c)
+	if !IsInvalidConfig(err) {
+		t.Errorf("expected invalid config error, got %v", err)
+	}
+}
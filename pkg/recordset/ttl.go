@@ -0,0 +1,33 @@
+package recordset
+
+import (
+	"strconv"
+
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	// RecordTTLTag is the source stack tag overriding the TTL in seconds of
+	// the non-alias records of the cluster, e.g. `300`. The records have a
+	// TTL of 30 seconds without it.
+	RecordTTLTag = "giantswarm.io/record-ttl"
+
+	// maxRecordTTL is the maximum TTL in seconds accepted by Route53.
+	maxRecordTTL = 2147483647
+)
+
+// clusterTTL returns the TTL of the records of the cluster read from its
+// record TTL tag. Zero is returned for clusters without the tag.
+func (m *Manager) clusterTTL(cluster Cluster) (int64, error) {
+	v, ok := m.clusterTags(cluster)[RecordTTLTag]
+	if !ok {
+		return 0, nil
+	}
+
+	ttl, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || ttl <= 0 || ttl > maxRecordTTL {
+		return 0, microerror.Maskf(invalidConfigError, "tag %#q of cluster %#q must be an integer between 1 and %d, got %#q", RecordTTLTag, cluster.ID, maxRecordTTL, v)
+	}
+
+	return ttl, nil
+}
@@ -0,0 +1,132 @@
+package recordset
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+func TestGetStackTemplateBody_TTL(t *testing.T) {
+	tcs := []struct {
+		name          string
+		tags          map[string]string
+		aliasWildcard bool
+		expectedTTL   string
+		errorMatcher  func(error) bool
+	}{
+		{
+			name:        "case 0: untagged cluster",
+			expectedTTL: "30",
+		},
+		{
+			name: "case 1: tagged cluster",
+			tags: map[string]string{
+				RecordTTLTag: "300",
+			},
+			expectedTTL: "300",
+		},
+		{
+			name: "case 2: tagged cluster with alias records",
+			tags: map[string]string{
+				RecordTTLTag: "300",
+			},
+			aliasWildcard: true,
+			expectedTTL:   "300",
+		},
+		{
+			name: "case 3: zero TTL",
+			tags: map[string]string{
+				RecordTTLTag: "0",
+			},
+			errorMatcher: IsInvalidConfig,
+		},
+		{
+			name: "case 4: TTL is no integer",
+			tags: map[string]string{
+				RecordTTLTag: "5m",
+			},
+			errorMatcher: IsInvalidConfig,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newTestConfig(t)
+			c.TemplateFormat = TemplateFormatJSON
+			c.AliasWildcard = tc.aliasWildcard
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			m.clusterSourceStacks["foo"] = cloudformation.Stack{Tags: newStackTags(tc.tags)}
+
+			records, err := m.getRecords(Cluster{ID: "foo"})
+			if tc.errorMatcher != nil {
+				if !tc.errorMatcher(err) {
+					t.Fatalf("expected matching error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("getRecords: %v", err)
+			}
+
+			body, err := m.getStackTemplateBody(records)
+			if err != nil {
+				t.Fatalf("getStackTemplateBody: %v", err)
+			}
+
+			var template stackTemplate
+			err = json.Unmarshal([]byte(body), &template)
+			if err != nil {
+				t.Fatalf("json.Unmarshal: %v", err)
+			}
+
+			for name, r := range template.Resources {
+				p := r.Properties
+				if p.AliasTarget != nil {
+					if p.TTL != "" {
+						t.Errorf("expected alias resource %#q without TTL, got %#q", name, p.TTL)
+					}
+					continue
+				}
+				if p.TTL != tc.expectedTTL {
+					t.Errorf("expected resource %#q TTL %#q, got %#q", name, tc.expectedTTL, p.TTL)
+				}
+			}
+		})
+	}
+}
+
+func TestGetAtomicChanges_TTL(t *testing.T) {
+	c := newTestConfig(t)
+	m, err := NewManager(c)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	m.clusterSourceStacks["foo"] = cloudformation.Stack{Tags: newStackTags(map[string]string{RecordTTLTag: "300"})}
+
+	records, err := m.getRecords(Cluster{ID: "foo"})
+	if err != nil {
+		t.Fatalf("getRecords: %v", err)
+	}
+
+	changes, err := m.getAtomicChanges(m.targetHostedZoneID, records, nil)
+	if err != nil {
+		t.Fatalf("getAtomicChanges: %v", err)
+	}
+
+	for _, ch := range changes {
+		rr := ch.ResourceRecordSet
+		if *ch.Action != route53.ChangeActionUpsert {
+			continue
+		}
+		if rr.TTL == nil || *rr.TTL != 300 {
+			t.Errorf("expected record set %#q TTL 300, got %v", *rr.Name, rr.TTL)
+		}
+	}
+}
@@ -0,0 +1,290 @@
+package recordset
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"gopkg.in/yaml.v3"
+
+	"github.com/giantswarm/route53-manager/pkg/client"
+)
+
+const (
+	// MismatchReasonMissing is used for record sets of a target stack
+	// template which do not exist in Route53.
+	MismatchReasonMissing = "missing"
+	// MismatchReasonValue is used for record sets whose values in Route53
+	// differ from the target stack template.
+	MismatchReasonValue = "value"
+)
+
+type VerifierConfig struct {
+	Logger       micrologger.Logger
+	Installation string
+	TargetClient client.TargetInterface
+
+	// Cluster restricts the verification to the target stack of the cluster
+	// with the given ID, e.g. `foo`. All target stacks are verified when
+	// empty.
+	Cluster string
+}
+
+// Verifier compares the record sets of the target stack templates with the
+// live record sets in Route53. It never changes any resource.
+type Verifier struct {
+	logger       micrologger.Logger
+	targetClient client.TargetInterface
+
+	// manager lists the target stacks the same way Sync does.
+	manager *Manager
+}
+
+// RecordMismatch is a record set of a target stack template which does not
+// match Route53.
+type RecordMismatch struct {
+	StackName    string
+	HostedZoneID string
+	Name         string
+	Type         string
+	// Reason is either MismatchReasonMissing or MismatchReasonValue.
+	Reason string
+	// Expected are the values of the template, Actual the ones in Route53.
+	// Alias records have the DNS name of their alias target as only value.
+	Expected []string
+	Actual   []string
+}
+
+func NewVerifier(c VerifierConfig) (*Verifier, error) {
+	if c.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", c)
+	}
+	if c.Installation == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Installation must not be empty", c)
+	}
+	if c.TargetClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.TargetClient must not be empty", c)
+	}
+
+	manager, err := NewManager(&Config{
+		Logger:       c.Logger,
+		Installation: c.Installation,
+		TargetClient: c.TargetClient,
+		TargetOnly:   true,
+
+		Cluster: c.Cluster,
+		Quiet:   true,
+	})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	v := &Verifier{
+		logger:       c.Logger,
+		targetClient: c.TargetClient,
+
+		manager: manager,
+	}
+
+	return v, nil
+}
+
+// ManagedRecord is a record set of a target stack template.
+type ManagedRecord struct {
+	StackName    string
+	ResourceName string
+	HostedZoneID string
+	Name         string
+	Type         string
+	// SetIdentifier is the set identifier of record sets with a routing
+	// policy, e.g. weighted records.
+	SetIdentifier string
+	// Values are the values of the template. Alias records have the DNS name
+	// of their alias target as only value.
+	Values []string
+}
+
+// ManagedRecords returns the record sets of all target stack templates,
+// ordered by resource name within each stack.
+func (v *Verifier) ManagedRecords() ([]ManagedRecord, error) {
+	targetStacks, err := v.manager.targetStacks()
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var records []ManagedRecord
+	for _, stack := range targetStacks {
+		t, err := v.getTemplate(*stack.StackName)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		var resourceNames []string
+		for name := range t.Resources {
+			resourceNames = append(resourceNames, name)
+		}
+		sort.Strings(resourceNames)
+
+		for _, resourceName := range resourceNames {
+			r := t.Resources[resourceName]
+			if r.Type != recordSetResourceType {
+				continue
+			}
+
+			records = append(records, ManagedRecord{
+				StackName:     *stack.StackName,
+				ResourceName:  resourceName,
+				HostedZoneID:  r.Properties.HostedZoneID,
+				Name:          r.Properties.Name,
+				Type:          r.Properties.Type,
+				SetIdentifier: r.Properties.SetIdentifier,
+				Values:        recordSetPropertiesValues(r.Properties),
+			})
+		}
+
+		v.logger.Log("level", "debug", "message", fmt.Sprintf("read record sets of target stack %#q", *stack.StackName))
+	}
+
+	return records, nil
+}
+
+// Verify returns the record sets of all target stack templates which are
+// missing in Route53 or have different values there.
+func (v *Verifier) Verify() ([]RecordMismatch, error) {
+	records, err := v.ManagedRecords()
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	// recordSets caches the live record sets by hosted zone ID, as the
+	// stacks of all clusters share the same few hosted zones.
+	recordSets := map[string]map[string]*route53.ResourceRecordSet{}
+
+	var mismatches []RecordMismatch
+	for _, r := range records {
+		if recordSets[r.HostedZoneID] == nil {
+			recordSets[r.HostedZoneID], err = v.listRecordSets(r.HostedZoneID)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+		}
+
+		mismatch := RecordMismatch{
+			StackName:    r.StackName,
+			HostedZoneID: r.HostedZoneID,
+			Name:         r.Name,
+			Type:         r.Type,
+			Expected:     r.Values,
+		}
+
+		rr, ok := recordSets[r.HostedZoneID][recordSetKey(r.Name, r.Type, r.SetIdentifier)]
+		if !ok {
+			mismatch.Reason = MismatchReasonMissing
+			mismatches = append(mismatches, mismatch)
+			continue
+		}
+
+		actual := recordSetValues(rr)
+		if !equalRecordValues(r.Values, actual) {
+			mismatch.Reason = MismatchReasonValue
+			mismatch.Actual = actual
+			mismatches = append(mismatches, mismatch)
+		}
+	}
+
+	return mismatches, nil
+}
+
+// getTemplate returns the current template of the target stack. Both
+// template formats are parsed, since JSON is valid YAML.
+func (v *Verifier) getTemplate(stackName string) (*stackTemplate, error) {
+	o, err := v.targetClient.GetTemplateWithContext(v.manager.ctx, &cloudformation.GetTemplateInput{
+		StackName: aws.String(stackName),
+	})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var t stackTemplate
+	err = yaml.Unmarshal([]byte(aws.StringValue(o.TemplateBody)), &t)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return &t, nil
+}
+
+// listRecordSets returns the record sets of the hosted zone by recordSetKey.
+func (v *Verifier) listRecordSets(hostedZoneID string) (map[string]*route53.ResourceRecordSet, error) {
+	list, err := listRecordSets(v.manager.ctx, v.targetClient, hostedZoneID)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	recordSets := map[string]*route53.ResourceRecordSet{}
+	for _, rr := range list {
+		recordSets[recordSetKey(aws.StringValue(rr.Name), aws.StringValue(rr.Type), aws.StringValue(rr.SetIdentifier))] = rr
+	}
+
+	return recordSets, nil
+}
+
+// recordSetKey identifies a record set within a hosted zone by its
+// normalized name. Record sets with a routing policy share their name and
+// type and are told apart by their set identifier.
+func recordSetKey(name, recordType, setIdentifier string) string {
+	return normalizeRecordName(name) + " " + recordType + " " + setIdentifier
+}
+
+func recordSetPropertiesValues(p recordSetProperties) []string {
+	if p.AliasTarget != nil {
+		return []string{p.AliasTarget.DNSName}
+	}
+
+	return p.ResourceRecords
+}
+
+func recordSetValues(rr *route53.ResourceRecordSet) []string {
+	if rr.AliasTarget != nil {
+		return []string{aws.StringValue(rr.AliasTarget.DNSName)}
+	}
+
+	var values []string
+	for _, r := range rr.ResourceRecords {
+		values = append(values, aws.StringValue(r.Value))
+	}
+
+	return values
+}
+
+// equalRecordValues compares the values regardless of their order, case and
+// trailing dots, as Route53 lists e.g. alias DNS names fully qualified.
+func equalRecordValues(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	normalize := func(values []string) []string {
+		var result []string
+		for _, v := range values {
+			result = append(result, normalizeCNAMEValue(v))
+		}
+		sort.Strings(result)
+
+		return result
+	}
+
+	na := normalize(a)
+	nb := normalize(b)
+	for i := range na {
+		if na[i] != nb[i] {
+			return false
+		}
+	}
+
+	return true
+}
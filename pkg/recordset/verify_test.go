@@ -0,0 +1,202 @@
+package recordset
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+func TestVerify(t *testing.T) {
+	records := []DesiredRecord{
+		{
+			ResourceName: "apiDNSRecord",
+			Name:         "api.foo.zoneName",
+			Type:         route53.RRTypeCname,
+			Values:       []string{"api.elb.dns.test"},
+		},
+		{
+			ResourceName: "ingressWildcardDNSRecord",
+			Name:         "*.foo.zoneName",
+			Type:         route53.RRTypeA,
+			AliasTarget: &AliasTarget{
+				HostedZoneID: "elbZoneID",
+				DNSName:      "ingress.elb.dns.test",
+			},
+		},
+		{
+			ResourceName: "EtcdEniDNSRecordSet1",
+			Name:         "etcd1.foo.etcdZoneName",
+			Type:         route53.RRTypeA,
+			Values:       []string{"10.1.0.1"},
+			HostedZoneID: "etcdZoneID",
+		},
+	}
+
+	newRecordSet := func(name, recordType string, values ...string) *route53.ResourceRecordSet {
+		rr := &route53.ResourceRecordSet{
+			Name: aws.String(name),
+			Type: aws.String(recordType),
+		}
+		for _, v := range values {
+			rr.ResourceRecords = append(rr.ResourceRecords, &route53.ResourceRecord{Value: aws.String(v)})
+		}
+
+		return rr
+	}
+	newAliasRecordSet := func(name, dnsName string) *route53.ResourceRecordSet {
+		return &route53.ResourceRecordSet{
+			Name: aws.String(name),
+			Type: aws.String(route53.RRTypeA),
+			AliasTarget: &route53.AliasTarget{
+				DNSName:      aws.String(dnsName),
+				HostedZoneId: aws.String("elbZoneID"),
+			},
+		}
+	}
+
+	tcs := []struct {
+		name               string
+		recordSets         map[string][]*route53.ResourceRecordSet
+		expectedMismatches []RecordMismatch
+	}{
+		{
+			name: "case 0: all records match",
+			recordSets: map[string][]*route53.ResourceRecordSet{
+				"zoneID": {
+					newRecordSet("api.foo.zoneName.", route53.RRTypeCname, "api.elb.dns.test"),
+					newAliasRecordSet("\\052.foo.zoneName.", "ingress.elb.dns.test."),
+				},
+				"etcdZoneID": {
+					newRecordSet("etcd1.foo.etcdZoneName.", route53.RRTypeA, "10.1.0.1"),
+				},
+			},
+		},
+		{
+			name: "case 1: mismatching and missing records",
+			recordSets: map[string][]*route53.ResourceRecordSet{
+				"zoneID": {
+					newRecordSet("api.foo.zoneName.", route53.RRTypeCname, "other.elb.dns.test"),
+					newAliasRecordSet("\\052.foo.zoneName.", "other.elb.dns.test."),
+				},
+			},
+			expectedMismatches: []RecordMismatch{
+				{
+					StackName:    "cluster-foo-guest-recordsets",
+					HostedZoneID: "etcdZoneID",
+					Name:         "etcd1.foo.etcdZoneName",
+					Type:         route53.RRTypeA,
+					Reason:       MismatchReasonMissing,
+					Expected:     []string{"10.1.0.1"},
+				},
+				{
+					StackName:    "cluster-foo-guest-recordsets",
+					HostedZoneID: "zoneID",
+					Name:         "api.foo.zoneName",
+					Type:         route53.RRTypeCname,
+					Reason:       MismatchReasonValue,
+					Expected:     []string{"api.elb.dns.test"},
+					Actual:       []string{"other.elb.dns.test"},
+				},
+				{
+					StackName:    "cluster-foo-guest-recordsets",
+					HostedZoneID: "zoneID",
+					Name:         "*.foo.zoneName",
+					Type:         route53.RRTypeA,
+					Reason:       MismatchReasonValue,
+					Expected:     []string{"ingress.elb.dns.test"},
+					Actual:       []string{"other.elb.dns.test."},
+				},
+			},
+		},
+		{
+			name: "case 2: record with different type",
+			recordSets: map[string][]*route53.ResourceRecordSet{
+				"zoneID": {
+					newRecordSet("api.foo.zoneName.", route53.RRTypeCname, "api.elb.dns.test"),
+					newRecordSet("\\052.foo.zoneName.", route53.RRTypeCname, "ingress.foo.zoneName"),
+				},
+				"etcdZoneID": {
+					newRecordSet("etcd1.foo.etcdZoneName.", route53.RRTypeA, "10.1.0.1"),
+				},
+			},
+			expectedMismatches: []RecordMismatch{
+				{
+					StackName:    "cluster-foo-guest-recordsets",
+					HostedZoneID: "zoneID",
+					Name:         "*.foo.zoneName",
+					Type:         route53.RRTypeA,
+					Reason:       MismatchReasonMissing,
+					Expected:     []string{"ingress.elb.dns.test"},
+				},
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			templateBody, err := newTestManager(t, nil).getStackTemplateBody(records)
+			if err != nil {
+				t.Fatalf("getStackTemplateBody: %v", err)
+			}
+
+			targetClient := newTargetWithStacks([]cloudformation.Stack{
+				{
+					StackName:   aws.String("cluster-foo-guest-recordsets"),
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+					Tags: []*cloudformation.Tag{
+						{
+							Key:   aws.String(installationTag),
+							Value: aws.String("installation"),
+						},
+					},
+				},
+			})
+			targetClient.templates = map[string]string{
+				"cluster-foo-guest-recordsets": templateBody,
+			}
+			targetClient.recordSets = tc.recordSets
+
+			c := newTestConfig(t)
+			v, err := NewVerifier(VerifierConfig{
+				Logger:       c.Logger,
+				Installation: "installation",
+				TargetClient: targetClient,
+			})
+			if err != nil {
+				t.Fatalf("NewVerifier: %v", err)
+			}
+
+			mismatches, err := v.Verify()
+			if err != nil {
+				t.Fatalf("v.Verify: %v", err)
+			}
+
+			if !reflect.DeepEqual(tc.expectedMismatches, mismatches) {
+				t.Errorf("expected mismatches %+v, got %+v", tc.expectedMismatches, mismatches)
+			}
+			if len(targetClient.changes) != 0 || len(targetClient.updatedStacks) != 0 || len(targetClient.deletedStacks) != 0 {
+				t.Errorf("expected no changes")
+			}
+		})
+	}
+}
+
+func TestSync_TargetOnly(t *testing.T) {
+	c := newTestConfig(t)
+	c.SourceClient = nil
+	c.TargetHostedZoneID = ""
+	c.TargetHostedZoneName = ""
+	c.TargetOnly = true
+	m, err := NewManager(c)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	err = m.Sync()
+	if !IsInvalidConfig(err) {
+		t.Errorf("expected invalid config error, got %v", err)
+	}
+}
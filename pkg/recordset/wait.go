@@ -0,0 +1,125 @@
+package recordset
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	// DefaultWaitForSyncTimeout is the time to wait for record set changes to
+	// be in sync when no timeout is configured.
+	DefaultWaitForSyncTimeout = 2 * time.Minute
+
+	waitForSyncInitialInterval = 1 * time.Second
+	waitForSyncMaxInterval     = 30 * time.Second
+
+	// stackDeletionTimeout is the time to wait for a target stack deletion to
+	// complete.
+	stackDeletionTimeout = 30 * time.Minute
+)
+
+// waitForChange polls the status of the given Route53 change until it is
+// INSYNC or m.waitForSyncTimeout elapsed. The interval between polls doubles
+// up to waitForSyncMaxInterval. It is a no-op when waiting is disabled.
+func (m *Manager) waitForChange(changeInfo *route53.ChangeInfo) error {
+	if !m.waitForSync || changeInfo == nil || changeInfo.Id == nil {
+		return nil
+	}
+
+	deadline := m.now().Add(m.waitForSyncTimeout)
+	interval := waitForSyncInitialInterval
+	status := aws.StringValue(changeInfo.Status)
+
+	for status != route53.ChangeStatusInsync {
+		err := m.checkDeadline()
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		remaining := deadline.Sub(m.now())
+		if remaining <= 0 {
+			return microerror.Maskf(waitTimeoutError, "change %#q not in sync after %s", *changeInfo.Id, m.waitForSyncTimeout)
+		}
+		if interval > remaining {
+			interval = remaining
+		}
+		m.sleep(interval)
+
+		interval *= 2
+		if interval > waitForSyncMaxInterval {
+			interval = waitForSyncMaxInterval
+		}
+
+		input := &route53.GetChangeInput{
+			Id: changeInfo.Id,
+		}
+		output, err := m.targetClient.GetChangeWithContext(m.ctx, input)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		if output.ChangeInfo != nil {
+			status = aws.StringValue(output.ChangeInfo.Status)
+		}
+
+		m.logger.Log("level", "debug", "message", fmt.Sprintf("change %#q has status %#q", *changeInfo.Id, status))
+	}
+
+	return nil
+}
+
+// waitForStackDeletion polls the status of the given target stack until it
+// does not exist anymore or stackDeletionTimeout elapsed. The interval
+// between polls doubles up to waitForSyncMaxInterval.
+func (m *Manager) waitForStackDeletion(stackName string) error {
+	deadline := m.now().Add(stackDeletionTimeout)
+	interval := waitForSyncInitialInterval
+
+	for {
+		input := &cloudformation.DescribeStacksInput{
+			StackName: aws.String(stackName),
+		}
+		output, err := m.targetClient.DescribeStacksWithContext(m.ctx, input)
+		if IsStackNotFound(err) {
+			return nil
+		} else if err != nil {
+			return microerror.Mask(err)
+		}
+
+		var status string
+		if len(output.Stacks) > 0 {
+			status = aws.StringValue(output.Stacks[0].StackStatus)
+		}
+		switch status {
+		case cloudformation.StackStatusDeleteComplete:
+			return nil
+		case cloudformation.StackStatusDeleteFailed:
+			return microerror.Maskf(stackDeletionFailedError, "target stack %#q has status %#q", stackName, status)
+		}
+
+		m.logger.Log("level", "debug", "message", fmt.Sprintf("target stack %#q has status %#q", stackName, status))
+
+		err = m.checkDeadline()
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		remaining := deadline.Sub(m.now())
+		if remaining <= 0 {
+			return microerror.Maskf(waitTimeoutError, "target stack %#q not deleted after %s", stackName, stackDeletionTimeout)
+		}
+		if interval > remaining {
+			interval = remaining
+		}
+		m.sleep(interval)
+
+		interval *= 2
+		if interval > waitForSyncMaxInterval {
+			interval = waitForSyncMaxInterval
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package recordset
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+func TestDeleteTargetLeftovers_WaitForSync(t *testing.T) {
+	tcs := []struct {
+		name               string
+		waitForSync        bool
+		changeStatuses     []string
+		expectedPolls      int
+		expectedSleeps     []time.Duration
+		expectTimeoutError bool
+	}{
+		{
+			name:           "case 0: do not wait when disabled",
+			waitForSync:    false,
+			changeStatuses: []string{route53.ChangeStatusPending},
+			expectedPolls:  0,
+			expectedSleeps: nil,
+		},
+		{
+			name:           "case 1: wait until change is in sync",
+			waitForSync:    true,
+			changeStatuses: []string{route53.ChangeStatusPending, route53.ChangeStatusPending, route53.ChangeStatusInsync},
+			expectedPolls:  3,
+			expectedSleeps: []time.Duration{1 * time.Second, 2 * time.Second, 4 * time.Second},
+		},
+		{
+			name:               "case 2: time out when change stays pending",
+			waitForSync:        true,
+			changeStatuses:     []string{route53.ChangeStatusPending, route53.ChangeStatusPending, route53.ChangeStatusPending, route53.ChangeStatusPending, route53.ChangeStatusPending},
+			expectedPolls:      4,
+			expectedSleeps:     []time.Duration{1 * time.Second, 2 * time.Second, 4 * time.Second, 3 * time.Second},
+			expectTimeoutError: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			targetClient := newTargetWithStacks(nil)
+			targetClient.changeStatuses = tc.changeStatuses
+			targetClient.recordSets = map[string][]*route53.ResourceRecordSet{
+				"zoneID": {
+					&route53.ResourceRecordSet{
+						Name: aws.String("vault.foo.zoneName."),
+						Type: aws.String(route53.RRTypeCname),
+					},
+				},
+			}
+
+			c := newTestConfig(t)
+			c.TargetClient = targetClient
+			c.WaitForSync = tc.waitForSync
+			c.WaitForSyncTimeout = 10 * time.Second
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+			var sleeps []time.Duration
+			m.now = func() time.Time { return now }
+			m.sleep = func(d time.Duration) {
+				sleeps = append(sleeps, d)
+				now = now.Add(d)
+			}
+
+			err = m.deleteTargetLeftovers("foo", nil)
+			if tc.expectTimeoutError && !IsWaitTimeout(err) {
+				t.Errorf("expected wait timeout error, got %v", err)
+			} else if !tc.expectTimeoutError && err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+
+			if targetClient.getChangeCalls != tc.expectedPolls {
+				t.Errorf("expected %d polls, got %d", tc.expectedPolls, targetClient.getChangeCalls)
+			}
+			if len(sleeps) != len(tc.expectedSleeps) {
+				t.Fatalf("expected sleeps %v, got %v", tc.expectedSleeps, sleeps)
+			}
+			for i := range sleeps {
+				if sleeps[i] != tc.expectedSleeps[i] {
+					t.Errorf("expected sleeps %v, got %v", tc.expectedSleeps, sleeps)
+					break
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package recordset
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	// WeightTagFormat is the format of the source stack tags setting the
+	// weight of the record of a component, e.g. `giantswarm.io/api-weight`
+	// for the api record. Records with a weight are created with weighted
+	// routing, so traffic is shifted between installations sharing the
+	// record name by editing the tag.
+	WeightTagFormat = "giantswarm.io/%s-weight"
+
+	// SkipReasonWeightToggled is used for target stacks which are not
+	// updated because a weight tag was added to or removed from their source
+	// stack.
+	SkipReasonWeightToggled SkipReason = "weight_toggled"
+
+	// maxWeight is the maximum weight of a weighted record set accepted by
+	// Route53.
+	maxWeight = 255
+)
+
+// weightTag returns the source stack tag setting the weight of the record of
+// the component with the given name.
+func weightTag(componentName string) string {
+	return fmt.Sprintf(WeightTagFormat, componentName)
+}
+
+// clusterWeights returns the weights of the component records of the cluster
+// by component name, read from the weight tags of its source stack.
+// Components without weight tag are missing.
+func (m *Manager) clusterWeights(cluster Cluster) (map[string]int64, error) {
+	tags := m.clusterTags(cluster)
+	weights := map[string]int64{}
+	for _, c := range m.components {
+		tag := weightTag(c.Name)
+		v, ok := tags[tag]
+		if !ok {
+			continue
+		}
+
+		w, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || w < 0 || w > maxWeight {
+			return nil, microerror.Maskf(invalidConfigError, "tag %#q of cluster %#q must be an integer between 0 and %d, got %#q", tag, cluster.ID, maxWeight, v)
+		}
+		weights[c.Name] = w
+	}
+
+	return weights, nil
+}
+
+// checkWeightToggle returns a weightToggledError when the weight tags of the
+// source stack switch a component record between simple and weighted routing.
+// The source stack tags are copied to the target stack, so its tags tell the
+// routing of the current records. Route53 does not allow a simple and a
+// weighted record set of the same name, neither in place nor side by side, so
+// the switch needs the target stack to be deleted and recreated.
+func (m *Manager) checkWeightToggle(sourceStack, targetStack cloudformation.Stack) error {
+	sourceTags := stackTags(sourceStack)
+	targetTags := stackTags(targetStack)
+	for _, c := range m.components {
+		tag := weightTag(c.Name)
+		_, weighted := sourceTags[tag]
+		_, currentWeighted := targetTags[tag]
+		if weighted != currentWeighted {
+			return microerror.Maskf(weightToggledError, "tag %#q must not be added to or removed from source stack %#q, the %s record of target stack %#q cannot switch between simple and weighted routing, delete the target stack to recreate it", tag, *sourceStack.StackName, c.Name, *targetStack.StackName)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,280 @@
+package recordset
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+func TestGetStackTemplateBody_Weight(t *testing.T) {
+	tcs := []struct {
+		name            string
+		tags            map[string]string
+		failover        string
+		expectedWeights map[string]int64
+		errorMatcher    func(error) bool
+	}{
+		{
+			name: "case 0: unweighted cluster",
+		},
+		{
+			name: "case 1: weighted api record",
+			tags: map[string]string{
+				"giantswarm.io/api-weight": "10",
+			},
+			expectedWeights: map[string]int64{
+				"apiDNSRecord": 10,
+			},
+		},
+		{
+			name: "case 2: weighted api and etcd records",
+			tags: map[string]string{
+				"giantswarm.io/api-weight":  "0",
+				"giantswarm.io/etcd-weight": "255",
+			},
+			expectedWeights: map[string]int64{
+				"apiDNSRecord":  0,
+				"etcdDNSRecord": 255,
+			},
+		},
+		{
+			name: "case 3: weighted records are excluded from failover routing",
+			tags: map[string]string{
+				"giantswarm.io/api-weight": "10",
+			},
+			failover: FailoverSecondary,
+			expectedWeights: map[string]int64{
+				"apiDNSRecord": 10,
+			},
+		},
+		{
+			name: "case 4: invalid weight",
+			tags: map[string]string{
+				"giantswarm.io/api-weight": "256",
+			},
+			errorMatcher: IsInvalidConfig,
+		},
+		{
+			name: "case 5: weight is no integer",
+			tags: map[string]string{
+				"giantswarm.io/api-weight": "half",
+			},
+			errorMatcher: IsInvalidConfig,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newTestConfig(t)
+			c.TemplateFormat = TemplateFormatJSON
+			c.TargetHostedZoneFailover = tc.failover
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			m.clusterSourceStacks["foo"] = cloudformation.Stack{Tags: newStackTags(tc.tags)}
+
+			records, err := m.getRecords(Cluster{ID: "foo"})
+			if tc.errorMatcher != nil {
+				if !tc.errorMatcher(err) {
+					t.Fatalf("expected matching error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("getRecords: %v", err)
+			}
+
+			body, err := m.getStackTemplateBody(records)
+			if err != nil {
+				t.Fatalf("getStackTemplateBody: %v", err)
+			}
+
+			var template stackTemplate
+			err = json.Unmarshal([]byte(body), &template)
+			if err != nil {
+				t.Fatalf("json.Unmarshal: %v", err)
+			}
+
+			weights := map[string]int64{}
+			for name, r := range template.Resources {
+				p := r.Properties
+				if p.Weight == nil {
+					if tc.failover != "" && p.Failover != tc.failover {
+						t.Errorf("expected resource %#q failover %#q, got %#q", name, tc.failover, p.Failover)
+					}
+					continue
+				}
+
+				weights[name] = *p.Weight
+				if p.SetIdentifier != "installation" {
+					t.Errorf("expected resource %#q set identifier %#q, got %#q", name, "installation", p.SetIdentifier)
+				}
+				if p.Failover != "" {
+					t.Errorf("expected weighted resource %#q without failover, got %#q", name, p.Failover)
+				}
+			}
+			if len(tc.expectedWeights) == 0 {
+				if len(weights) != 0 {
+					t.Errorf("expected no weighted resources, got %v", weights)
+				}
+				if strings.Contains(body, "Weight") {
+					t.Errorf("expected template without weight attributes, got %s", body)
+				}
+				return
+			}
+			if !reflect.DeepEqual(tc.expectedWeights, weights) {
+				t.Errorf("expected weights %v, got %v", tc.expectedWeights, weights)
+			}
+		})
+	}
+}
+
+func TestGetAtomicChanges_Weight(t *testing.T) {
+	targetClient := newTargetWithStacks(nil)
+	targetClient.recordSets = map[string][]*route53.ResourceRecordSet{
+		"zoneID": {
+			{
+				Name:            aws.String("api.foo.zonename."),
+				Type:            aws.String(route53.RRTypeCname),
+				ResourceRecords: []*route53.ResourceRecord{{Value: aws.String("old.elb.dns.test")}},
+			},
+			{
+				Name:            aws.String("api.foo.zonename."),
+				Type:            aws.String(route53.RRTypeCname),
+				SetIdentifier:   aws.String("other"),
+				Weight:          aws.Int64(90),
+				ResourceRecords: []*route53.ResourceRecord{{Value: aws.String("other.elb.dns.test")}},
+			},
+		},
+	}
+
+	c := newTestConfig(t)
+	c.TargetClient = targetClient
+	m, err := NewManager(c)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	m.clusterSourceStacks["foo"] = cloudformation.Stack{Tags: newStackTags(map[string]string{"giantswarm.io/api-weight": "10"})}
+
+	records, err := m.getRecords(Cluster{ID: "foo"})
+	if err != nil {
+		t.Fatalf("getRecords: %v", err)
+	}
+
+	changes, err := m.getAtomicChanges(m.targetHostedZoneID, records, []string{"api.foo.zonename."})
+	if err != nil {
+		t.Fatalf("getAtomicChanges: %v", err)
+	}
+
+	var deleted []string
+	var weighted []string
+	for _, ch := range changes {
+		rr := ch.ResourceRecordSet
+		if *ch.Action == route53.ChangeActionDelete {
+			deleted = append(deleted, *rr.Name+" "+aws.StringValue(rr.SetIdentifier))
+			continue
+		}
+		if rr.Weight != nil {
+			weighted = append(weighted, *rr.Name+" "+aws.StringValue(rr.SetIdentifier))
+			if *rr.Weight != 10 {
+				t.Errorf("expected record set %#q weight 10, got %d", *rr.Name, *rr.Weight)
+			}
+		}
+	}
+
+	expectedDeleted := []string{"api.foo.zonename. "}
+	if !reflect.DeepEqual(expectedDeleted, deleted) {
+		t.Errorf("expected deleted record sets %v, got %v", expectedDeleted, deleted)
+	}
+	expectedWeighted := []string{"api.foo.zoneName. installation"}
+	if !reflect.DeepEqual(expectedWeighted, weighted) {
+		t.Errorf("expected weighted record sets %v, got %v", expectedWeighted, weighted)
+	}
+}
+
+func TestSync_WeightToggle(t *testing.T) {
+	tcs := []struct {
+		name           string
+		sourceTags     map[string]string
+		targetTags     map[string]string
+		expectedUpdate bool
+	}{
+		{
+			name:           "case 0: unweighted cluster",
+			expectedUpdate: true,
+		},
+		{
+			name:           "case 1: weight changed",
+			sourceTags:     map[string]string{"giantswarm.io/api-weight": "10"},
+			targetTags:     map[string]string{"giantswarm.io/api-weight": "20"},
+			expectedUpdate: true,
+		},
+		{
+			name:           "case 2: weight tag added",
+			sourceTags:     map[string]string{"giantswarm.io/api-weight": "10"},
+			expectedUpdate: false,
+		},
+		{
+			name:           "case 3: weight tag removed",
+			targetTags:     map[string]string{"giantswarm.io/etcd-weight": "10"},
+			expectedUpdate: false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			sourceTags := map[string]string{installationTag: "installation"}
+			for k, v := range tc.sourceTags {
+				sourceTags[k] = v
+			}
+			targetTags := map[string]string{installationTag: "installation"}
+			for k, v := range tc.targetTags {
+				targetTags[k] = v
+			}
+
+			targetClient := newTargetWithStacks([]cloudformation.Stack{
+				{
+					StackName:   aws.String("cluster-foo-guest-recordsets"),
+					StackStatus: aws.String(cloudformation.StackStatusUpdateComplete),
+					Tags:        newStackTags(targetTags),
+				},
+			})
+
+			c := newTestConfig(t)
+			c.SourceClient = newSourceWithStacks([]cloudformation.Stack{
+				{
+					StackName:   aws.String("cluster-foo-tccp"),
+					StackStatus: aws.String(cloudformation.StackStatusUpdateComplete),
+					Tags:        newStackTags(sourceTags),
+				},
+			})
+			c.TargetClient = targetClient
+			m, err := NewManager(c)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			err = m.Sync()
+			if err != nil {
+				t.Fatalf("m.Sync: %v", err)
+			}
+
+			updated := len(targetClient.updateStackInputs) > 0
+			if updated != tc.expectedUpdate {
+				t.Errorf("expected update %t, got %d updates", tc.expectedUpdate, len(targetClient.updateStackInputs))
+			}
+			skipped := m.summary.skippedCount(SkipReasonWeightToggled) > 0
+			if skipped == tc.expectedUpdate {
+				t.Errorf("expected weight toggle skip %t, got %s", !tc.expectedUpdate, m.summary)
+			}
+		})
+	}
+}